@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/accounts"
+	"github.com/armadakv/console/backend/api"
+	"github.com/armadakv/console/backend/apikeys"
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/httpclient"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// oidcTimeout bounds the requests to the OIDC provider and the token issuer
+const oidcTimeout = 10 * time.Second
+
+// useAuthentication requires authentication for every route of the router. Browsers log in
+// with the OIDC provider if one is configured, scripts present bearer tokens of the token
+// issuer or use basic authentication. Authentication is disabled when neither a username
+// nor an issuer is configured. The public paths, and the paths below those ending in a slash,
+// are served without authentication, they must authorize requests themselves, e.g. by a signature.
+// Browsers logged in with OIDC or basic authentication are identified by their sessions, if any,
+// and changes made with a session must carry its CSRF token, changes made with basic credentials
+// must come from the console. API keys are accepted if apiKeys is set.
+// It returns the OIDC login flow to serve with the authentication endpoints, nil if there is none.
+func useAuthentication(logger *zap.Logger, r chi.Router, cfg config.AuthConfig, outbound *httpclient.Factory, directory *accounts.Directory, guard *auth.Guard, sessions *auth.SessionStore, apiKeys *apikeys.Manager, public ...string) *auth.OIDC {
+	var basic func(http.Handler) http.Handler
+	if cfg.Username != "" {
+		var opts []auth.BasicOption
+		if guard != nil {
+			opts = append(opts, auth.WithGuard(guard))
+		}
+		if directory != nil {
+			opts = append(opts, auth.WithLocalUsers(directory))
+		}
+		b, err := auth.NewBasicAuth(cfg.Realm, cfg.Username, cfg.PasswordHash, opts...)
+		if err != nil {
+			logger.Fatal("Failed to set up authentication", zap.Error(err))
+		}
+		basic = b.Middleware
+	}
+
+	// Bearer tokens are checked before basic authentication, requests without one fall through to it
+	credentials := basic
+	if cfg.JWTIssuer != "" {
+		keys := auth.NewJWKS(cfg.JWTJWKSURL, outbound.Client(oidcTimeout), cfg.JWKSRefresh)
+		bearer := auth.NewJWTAuth(cfg.JWTIssuer, cfg.JWTAudience, cfg.JWTUserClaim, cfg.GroupsClaim, keys)
+		logger.Info("Accepting bearer tokens",
+			zap.String("issuer", cfg.JWTIssuer),
+			zap.String("jwks", cfg.JWTJWKSURL),
+			zap.Bool("basicAuth", basic != nil))
+		credentials = bearer.Middleware(basic)
+	}
+	// API keys are recognized by their header or prefix, other requests fall through to the token issuer
+	if apiKeys != nil {
+		credentials = auth.NewAPIKeyAuth(apiKeys).Middleware(credentials)
+	}
+
+	var oidc *auth.OIDC
+	switch {
+	case cfg.OIDCIssuer != "":
+		ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+		defer cancel()
+		var err error
+		oidc, err = auth.NewOIDC(ctx, auth.OIDCConfig{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+			SessionTTL:   cfg.SessionTTL,
+			Sessions:     sessions,
+			GroupsClaim:  cfg.GroupsClaim,
+		}, outbound.Client(oidcTimeout))
+		if err != nil {
+			logger.Fatal("Failed to set up OIDC login", zap.Error(err), zap.String("issuer", cfg.OIDCIssuer))
+		}
+		r.Use(exceptPaths(oidc.Middleware(credentials), public))
+	case credentials != nil && sessions != nil:
+		r.Use(exceptPaths(sessions.Middleware(credentials), public))
+	case credentials != nil:
+		r.Use(exceptPaths(credentials, public))
+	default:
+		logger.Warn("Authentication is disabled, anyone who can reach the console can use it")
+	}
+	// Browsers attach the session cookie and remembered basic credentials to requests triggered by
+	// other sites, changes made with them must carry the CSRF token of the session or come from the console
+	if sessions != nil {
+		r.Use(auth.CSRF)
+	}
+	return oidc
+}
+
+// newSessionStore creates the store of the sessions of logged-in browsers, nil if users can't
+// log in with OIDC or basic authentication
+func newSessionStore(cfg *config.Config, store metadata.Store) *auth.SessionStore {
+	if cfg.Auth.OIDCIssuer == "" && cfg.Auth.Username == "" {
+		return nil
+	}
+	// Browsers only send secure cookies over HTTPS
+	secure := cfg.Server.TLSCertFile != "" || strings.HasPrefix(cfg.Auth.OIDCRedirectURL, "https://")
+	sameSite := http.SameSiteLaxMode
+	if cfg.Auth.SessionSameSite == "strict" {
+		sameSite = http.SameSiteStrictMode
+	}
+	return auth.NewSessionStore(store, cfg.Auth.SessionTTL,
+		auth.WithIdleTimeout(cfg.Auth.SessionIdleTimeout),
+		auth.WithMaxSessions(cfg.Auth.MaxSessions),
+		auth.WithSameSite(sameSite),
+		auth.WithSecureCookies(secure))
+}
+
+// newAPIKeyManager creates the manager of the API keys, nil if they are disabled. The keys are
+// kept in the configured table of the cluster, which is created if missing, or the metadata store.
+func newAPIKeyManager(logger *zap.Logger, cfg config.AuthConfig, client *armada.Client, store metadata.Store) *apikeys.Manager {
+	if !cfg.APIKeys {
+		return nil
+	}
+	if cfg.APIKeysTable == "" {
+		return apikeys.NewManager(store)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+	defer cancel()
+	// The cluster may not be reachable yet, keys are checked against the table once it is
+	if _, err := client.CreateTable(ctx, cfg.APIKeysTable); err != nil && !errors.Is(err, armada.ErrTableExists) {
+		logger.Warn("Failed to create API key table", zap.Error(err), zap.String("table", cfg.APIKeysTable))
+	}
+	return apikeys.NewManager(metadata.NewTableStore(client, cfg.APIKeysTable))
+}
+
+// auditLockout records the lockout of an account or a client address after failed logins
+func auditLockout(logger *zap.Logger, auditLog audit.Log, l auth.Lockout) {
+	resource := "users/" + l.Subject
+	if l.Kind == auth.LockoutAddress {
+		resource = "addresses/" + l.Subject
+	}
+	logger.Warn("Locked out logins after failed attempts", zap.String("kind", l.Kind),
+		zap.String("subject", l.Subject), zap.Int("failures", l.Failures), zap.Time("until", l.Until))
+	entry := audit.Entry{
+		User:     auth.Anonymous,
+		Action:   "auth.lockout",
+		Resource: resource,
+		Outcome:  audit.OutcomeDenied,
+		Error:    fmt.Sprintf("%d failed logins", l.Failures),
+		Details:  map[string]string{"kind": l.Kind, "until": l.Until.UTC().Format(time.RFC3339)},
+	}
+	if _, err := auditLog.Record(context.Background(), entry); err != nil {
+		logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", entry.Action))
+	}
+}
+
+// exceptPaths applies the middleware to all requests except those of one of the paths. Paths
+// ending in a slash are prefixes and cover everything below them, others only match exactly, so
+// e.g. /healthz doesn't expose /healthzfoo.
+func exceptPaths(middleware func(http.Handler) http.Handler, paths []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		protected := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, path := range paths {
+				if r.URL.Path == path || strings.HasSuffix(path, "/") && strings.HasPrefix(r.URL.Path, path) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			protected.ServeHTTP(w, r)
+		})
+	}
+}
+
+// reservedNames returns the names local users can't be created with: the configured user and
+// the local users granted privileges by name. Otherwise an operator could create a user with
+// the name of such a user, e.g. a guardrail admin, and gain their privileges.
+func reservedNames(cfg config.AuthConfig) []string {
+	reserved := []string{cfg.Username}
+	for _, list := range []string{cfg.Operators, cfg.GuardrailAdmins, cfg.ClusterImportAdmins} {
+		// Invalid lists are refused when the roles and guardrails are set up
+		principals, _ := auth.ParsePrincipals(list)
+		for _, p := range principals {
+			if p.Method == auth.MethodBasic {
+				reserved = append(reserved, p.Name)
+			}
+		}
+	}
+	return reserved
+}
+
+// roleMapping returns the assignment of roles configured for the users and, if local users
+// or API keys are managed in the console, the roles assigned to them
+func roleMapping(cfg config.AuthConfig, directory *accounts.Directory, apiKeys *apikeys.Manager) (api.RoleMapping, error) {
+	operators, err := auth.ParsePrincipals(cfg.Operators)
+	if err != nil {
+		return api.RoleMapping{}, err
+	}
+	roles := api.RoleMapping{
+		Default:        auth.Role(cfg.DefaultRole),
+		Operators:      operators,
+		OperatorGroups: api.SplitList(cfg.OperatorGroups),
+	}
+	if directory != nil {
+		roles.Local = directory
+	}
+	if apiKeys != nil {
+		roles.APIKeys = apiKeys
+	}
+	return roles, nil
+}
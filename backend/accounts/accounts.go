@@ -0,0 +1,435 @@
+// Package accounts manages the local users of the console and the roles assigned to them.
+// Users log in with HTTP basic authentication; their password is stored as a bcrypt hash
+// in the metadata store next to the roles, so operators can manage users through the API
+// instead of editing the configuration. Every role grants one of the access levels of
+// auth.Role; the viewer and operator roles always exist.
+package accounts
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Metadata namespaces the users and roles are stored in
+const (
+	UsersNamespace = "users"
+	RolesNamespace = "roles"
+)
+
+// MinPasswordLength is the minimum length of passwords
+const MinPasswordLength = 12
+
+const (
+	// verifiedTTL is how long verified credentials are remembered, bcrypt is too slow to
+	// check every polled request
+	verifiedTTL = 5 * time.Minute
+	// maxVerified bounds the number of remembered credentials
+	maxVerified = 128
+)
+
+var (
+	// ErrNotFound is returned when a user or role does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrExists is returned when creating a user or role that already exists
+	ErrExists = errors.New("already exists")
+	// ErrInvalid is returned for users and roles that can't be stored
+	ErrInvalid = errors.New("invalid")
+	// ErrInUse is returned when deleting a role that is assigned to users
+	ErrInUse = errors.New("in use")
+)
+
+// validName matches the names of users and roles
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]{0,63}$`)
+
+// User is a local user of the console
+type User struct {
+	Name string `json:"name"`
+	// Roles are the names of the roles assigned to the user. The user has the highest access
+	// level granted by any of them.
+	Roles []string `json:"roles"`
+	// Disabled users can't log in.
+	Disabled  bool      `json:"disabled"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// account is a user as stored, including the hash of their password
+type account struct {
+	User
+	PasswordHash string `json:"passwordHash"`
+}
+
+// Role is a named set of permissions that can be assigned to users
+type Role struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Grants is the access level of the users with the role.
+	Grants auth.Role `json:"grants"`
+	// BuiltIn roles can't be changed or deleted.
+	BuiltIn bool `json:"builtIn"`
+}
+
+// builtInRoles exist without being stored
+var builtInRoles = map[string]Role{
+	string(auth.RoleViewer): {
+		Name: string(auth.RoleViewer), Description: "May read the cluster state and data", Grants: auth.RoleViewer, BuiltIn: true,
+	},
+	string(auth.RoleOperator): {
+		Name: string(auth.RoleOperator), Description: "May also write keys and manage tables and users", Grants: auth.RoleOperator, BuiltIn: true,
+	},
+}
+
+// Directory stores the local users and roles in the metadata store. It is safe for concurrent use.
+type Directory struct {
+	store metadata.Store
+	// reserved are names of users configured elsewhere, e.g. the user of AUTH_USERNAME
+	reserved []string
+	now      func() time.Time
+
+	// mu serializes changes, so checks and writes of users and roles don't interleave
+	mu sync.Mutex
+
+	// verifiedMu protects verified
+	verifiedMu sync.Mutex
+	// verified maps digests of recently verified credentials to when they expire
+	verified map[[sha256.Size]byte]time.Time
+}
+
+// NewDirectory creates a Directory backed by the store. Users can't be created with one of
+// the reserved names.
+func NewDirectory(store metadata.Store, reserved ...string) *Directory {
+	return &Directory{
+		store:    store,
+		reserved: reserved,
+		now:      time.Now,
+		verified: make(map[[sha256.Size]byte]time.Time),
+	}
+}
+
+// Users returns all users ordered by name
+func (d *Directory) Users() ([]User, error) {
+	accounts, err := metadata.List[account](d.store, UsersNamespace)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]User, 0, len(accounts))
+	for _, a := range accounts {
+		users = append(users, a.User)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users, nil
+}
+
+// User returns a user by name, or ErrNotFound
+func (d *Directory) User(name string) (User, error) {
+	a, err := d.account(name)
+	return a.User, err
+}
+
+// CreateUser validates and stores a new user with the password
+func (d *Directory) CreateUser(user User, password string) (User, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !validName.MatchString(user.Name) {
+		return User{}, fmt.Errorf("%w user name %q", ErrInvalid, user.Name)
+	}
+	if slices.Contains(d.reserved, user.Name) {
+		return User{}, fmt.Errorf("user %s %w in the configuration", user.Name, ErrExists)
+	}
+	if _, err := d.account(user.Name); err == nil {
+		return User{}, fmt.Errorf("user %s %w", user.Name, ErrExists)
+	} else if !errors.Is(err, ErrNotFound) {
+		return User{}, err
+	}
+	if err := d.checkRoles(user.Roles); err != nil {
+		return User{}, err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	now := d.now().UTC()
+	user.Roles = normalizeRoles(user.Roles)
+	user.CreatedAt, user.UpdatedAt = now, now
+	if err := metadata.Put(d.store, UsersNamespace, user.Name, account{User: user, PasswordHash: hash}); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// UpdateUser replaces the roles of a user and enables or disables them
+func (d *Directory) UpdateUser(name string, roles []string, disabled bool) (User, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	a, err := d.account(name)
+	if err != nil {
+		return User{}, err
+	}
+	if err := d.checkRoles(roles); err != nil {
+		return User{}, err
+	}
+	a.Roles, a.Disabled, a.UpdatedAt = normalizeRoles(roles), disabled, d.now().UTC()
+	if err := d.put(a); err != nil {
+		return User{}, err
+	}
+	return a.User, nil
+}
+
+// SetPassword replaces the password of a user
+func (d *Directory) SetPassword(name, password string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	a, err := d.account(name)
+	if err != nil {
+		return err
+	}
+	if a.PasswordHash, err = hashPassword(password); err != nil {
+		return err
+	}
+	a.UpdatedAt = d.now().UTC()
+	return d.put(a)
+}
+
+// DeleteUser removes a user, or returns ErrNotFound
+func (d *Directory) DeleteUser(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, err := d.account(name); err != nil {
+		return err
+	}
+	if err := d.store.Delete(UsersNamespace, name); err != nil {
+		return err
+	}
+	d.forgetVerified()
+	return nil
+}
+
+// Roles returns the built-in and the stored roles ordered by name
+func (d *Directory) Roles() ([]Role, error) {
+	stored, err := metadata.List[Role](d.store, RolesNamespace)
+	if err != nil {
+		return nil, err
+	}
+	roles := slices.Collect(maps.Values(builtInRoles))
+	for _, role := range stored {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// Role returns a role by name, or ErrNotFound
+func (d *Directory) Role(name string) (Role, error) {
+	if role, ok := builtInRoles[name]; ok {
+		return role, nil
+	}
+	role, err := metadata.Get[Role](d.store, RolesNamespace, name)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Role{}, fmt.Errorf("role %s %w", name, ErrNotFound)
+	}
+	return role, err
+}
+
+// CreateRole validates and stores a new role
+func (d *Directory) CreateRole(role Role) (Role, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !validName.MatchString(role.Name) {
+		return Role{}, fmt.Errorf("%w role name %q", ErrInvalid, role.Name)
+	}
+	if _, err := d.Role(role.Name); err == nil {
+		return Role{}, fmt.Errorf("role %s %w", role.Name, ErrExists)
+	} else if !errors.Is(err, ErrNotFound) {
+		return Role{}, err
+	}
+	return d.putRole(role)
+}
+
+// UpdateRole replaces the description and the access level of a stored role
+func (d *Directory) UpdateRole(role Role) (Role, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	existing, err := d.Role(role.Name)
+	if err != nil {
+		return Role{}, err
+	}
+	if existing.BuiltIn {
+		return Role{}, fmt.Errorf("%w role: built-in role %s can't be changed", ErrInvalid, role.Name)
+	}
+	return d.putRole(role)
+}
+
+// DeleteRole removes a stored role. It returns ErrInUse if the role is assigned to a user.
+func (d *Directory) DeleteRole(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	role, err := d.Role(name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return fmt.Errorf("%w role: built-in role %s can't be deleted", ErrInvalid, name)
+	}
+	users, err := d.Users()
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if slices.Contains(user.Roles, name) {
+			return fmt.Errorf("role %s is assigned to user %s: %w", name, user.Name, ErrInUse)
+		}
+	}
+	if err := d.store.Delete(RolesNamespace, name); err != nil {
+		return err
+	}
+	d.forgetVerified()
+	return nil
+}
+
+// Authenticate reports whether the password is the one of an enabled user. Valid credentials
+// are remembered for a while, until the users change.
+func (d *Directory) Authenticate(name, password string) bool {
+	digest := sha256.Sum256([]byte(name + ":" + password))
+	now := d.now()
+	d.verifiedMu.Lock()
+	expires, ok := d.verified[digest]
+	d.verifiedMu.Unlock()
+	if ok && now.Before(expires) {
+		return true
+	}
+
+	a, err := d.account(name)
+	if err != nil || a.Disabled || bcrypt.CompareHashAndPassword([]byte(a.PasswordHash), []byte(password)) != nil {
+		return false
+	}
+
+	d.verifiedMu.Lock()
+	defer d.verifiedMu.Unlock()
+	if len(d.verified) >= maxVerified {
+		for digest, e := range d.verified {
+			if !now.Before(e) || len(d.verified) >= maxVerified {
+				delete(d.verified, digest)
+			}
+		}
+	}
+	d.verified[digest] = now.Add(verifiedTTL)
+	return true
+}
+
+// RoleOf returns the highest access level granted by the roles of an enabled user. It returns
+// false for unknown and disabled users and for users without roles.
+func (d *Directory) RoleOf(name string) (auth.Role, bool) {
+	a, err := d.account(name)
+	if err != nil || a.Disabled {
+		return "", false
+	}
+	var granted auth.Role
+	for _, name := range a.Roles {
+		role, err := d.Role(name)
+		if err != nil {
+			continue
+		}
+		if role.Grants == auth.RoleOperator || granted == "" {
+			granted = role.Grants
+		}
+	}
+	return granted, granted != ""
+}
+
+// account returns the stored user, or ErrNotFound
+func (d *Directory) account(name string) (account, error) {
+	a, err := metadata.Get[account](d.store, UsersNamespace, name)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return account{}, fmt.Errorf("user %s %w", name, ErrNotFound)
+	}
+	return a, err
+}
+
+// put stores a changed user and forgets verified credentials, so a new password or disabling
+// the user takes effect immediately
+func (d *Directory) put(a account) error {
+	if err := metadata.Put(d.store, UsersNamespace, a.Name, a); err != nil {
+		return err
+	}
+	d.forgetVerified()
+	return nil
+}
+
+// putRole validates and stores a role
+func (d *Directory) putRole(role Role) (Role, error) {
+	if role.Grants != auth.RoleViewer && role.Grants != auth.RoleOperator {
+		return Role{}, fmt.Errorf("%w role: must grant %s or %s, got %q", ErrInvalid, auth.RoleViewer, auth.RoleOperator, role.Grants)
+	}
+	role.BuiltIn = false
+	if err := metadata.Put(d.store, RolesNamespace, role.Name, role); err != nil {
+		return Role{}, err
+	}
+	d.forgetVerified()
+	return role, nil
+}
+
+// checkRoles checks that the roles exist
+func (d *Directory) checkRoles(roles []string) error {
+	for _, name := range roles {
+		if _, err := d.Role(name); errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w roles: role %s does not exist", ErrInvalid, name)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forgetVerified forgets all verified credentials
+func (d *Directory) forgetVerified() {
+	d.verifiedMu.Lock()
+	defer d.verifiedMu.Unlock()
+	clear(d.verified)
+}
+
+// hashPassword checks the length of a password and hashes it with bcrypt
+func hashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("%w password: must be at least %d characters long", ErrInvalid, MinPasswordLength)
+	}
+	// bcrypt ignores everything beyond 72 bytes, longer passwords would be truncated silently
+	if len(password) > 72 {
+		return "", fmt.Errorf("%w password: must be at most 72 bytes long", ErrInvalid)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// normalizeRoles sorts the role names and removes duplicates, an empty list is stored as such
+func normalizeRoles(roles []string) []string {
+	roles = slices.Clone(roles)
+	slices.Sort(roles)
+	roles = slices.Compact(roles)
+	if roles == nil {
+		roles = []string{}
+	}
+	return roles
+}
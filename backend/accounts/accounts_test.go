@@ -0,0 +1,104 @@
+package accounts
+
+import (
+	"testing"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const password = "correct horse battery"
+
+func TestDirectoryUsers(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	d := NewDirectory(store, "admin")
+
+	alice, err := d.CreateUser(User{Name: "alice", Roles: []string{"viewer", "viewer"}}, password)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"viewer"}, alice.Roles)
+	assert.False(t, alice.CreatedAt.IsZero())
+
+	_, err = d.CreateUser(User{Name: "alice"}, password)
+	assert.ErrorIs(t, err, ErrExists)
+	_, err = d.CreateUser(User{Name: "admin"}, password)
+	assert.ErrorIs(t, err, ErrExists, "the configured user can't be shadowed")
+	_, err = d.CreateUser(User{Name: "bob"}, "short")
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = d.CreateUser(User{Name: "bob", Roles: []string{"dba"}}, password)
+	assert.ErrorIs(t, err, ErrInvalid, "roles must exist")
+	_, err = d.CreateUser(User{Name: "../bob"}, password)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	raw, err := store.Get(UsersNamespace, "alice")
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), password, "only the hash is stored")
+
+	assert.True(t, d.Authenticate("alice", password))
+	assert.False(t, d.Authenticate("alice", "wrong password"))
+	assert.False(t, d.Authenticate("bob", password))
+
+	role, ok := d.RoleOf("alice")
+	assert.True(t, ok)
+	assert.Equal(t, auth.RoleViewer, role)
+
+	require.NoError(t, d.SetPassword("alice", "another long password"))
+	assert.False(t, d.Authenticate("alice", password), "the old password is forgotten")
+	assert.True(t, d.Authenticate("alice", "another long password"))
+
+	_, err = d.UpdateUser("alice", []string{"operator"}, true)
+	require.NoError(t, err)
+	assert.False(t, d.Authenticate("alice", "another long password"), "disabled users can't log in")
+	_, ok = d.RoleOf("alice")
+	assert.False(t, ok)
+
+	users, err := d.Users()
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.True(t, users[0].Disabled)
+
+	require.NoError(t, d.DeleteUser("alice"))
+	assert.ErrorIs(t, d.DeleteUser("alice"), ErrNotFound)
+}
+
+func TestDirectoryRoles(t *testing.T) {
+	d := NewDirectory(metadata.NewMemoryStore())
+
+	_, err := d.CreateRole(Role{Name: "dba", Description: "Database administrators", Grants: auth.RoleOperator})
+	require.NoError(t, err)
+	_, err = d.CreateRole(Role{Name: "operator", Grants: auth.RoleOperator})
+	assert.ErrorIs(t, err, ErrExists)
+	_, err = d.CreateRole(Role{Name: "auditor", Grants: "admin"})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = d.UpdateRole(Role{Name: "viewer", Grants: auth.RoleOperator})
+	assert.ErrorIs(t, err, ErrInvalid, "built-in roles can't be changed")
+
+	roles, err := d.Roles()
+	require.NoError(t, err)
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	assert.Equal(t, []string{"dba", "operator", "viewer"}, names)
+
+	_, err = d.CreateUser(User{Name: "carol", Roles: []string{"viewer", "dba"}}, password)
+	require.NoError(t, err)
+	role, ok := d.RoleOf("carol")
+	assert.True(t, ok)
+	assert.Equal(t, auth.RoleOperator, role, "the highest access level wins")
+
+	assert.ErrorIs(t, d.DeleteRole("dba"), ErrInUse)
+	assert.ErrorIs(t, d.DeleteRole("viewer"), ErrInvalid)
+
+	_, err = d.UpdateRole(Role{Name: "dba", Grants: auth.RoleViewer})
+	require.NoError(t, err)
+	role, _ = d.RoleOf("carol")
+	assert.Equal(t, auth.RoleViewer, role)
+
+	_, err = d.UpdateUser("carol", nil, false)
+	require.NoError(t, err)
+	require.NoError(t, d.DeleteRole("dba"))
+	_, err = d.Role("dba")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
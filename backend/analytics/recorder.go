@@ -0,0 +1,274 @@
+// Package analytics counts how the console is used, e.g. page views per feature and API
+// queries per route, so maintainers learn which features matter. It is opt-in and the
+// counts are kept in the console's metadata store only; nothing is sent to third parties
+// and no user names or request parameters are recorded.
+package analytics
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Namespace is the metadata namespace the daily counts are stored in
+const Namespace = "analytics"
+
+const (
+	// dateLayout formats the days counts are grouped by, which are also their keys in the store
+	dateLayout = "2006-01-02"
+	// maxNamesPerDay bounds the number of distinct features or routes counted per day
+	maxNamesPerDay = 500
+	// flushInterval is how often counts are written to the store
+	flushInterval = time.Minute
+)
+
+// featurePattern matches the names of features page views are recorded for, e.g. "data" or "settings"
+var featurePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// ValidFeature reports whether page views can be recorded for the feature name
+func ValidFeature(feature string) bool {
+	return featurePattern.MatchString(feature)
+}
+
+// Day holds the counts of one day
+type Day struct {
+	// PageViews counts the page views by feature
+	PageViews map[string]uint64 `json:"pageViews"`
+	// Queries counts the API requests by route, e.g. "GET /api/tables"
+	Queries map[string]uint64 `json:"queries"`
+}
+
+// add merges the counts of other into the day, dropping names beyond the per-day limit
+func (d *Day) add(other Day) {
+	d.PageViews = addCounts(d.PageViews, other.PageViews)
+	d.Queries = addCounts(d.Queries, other.Queries)
+}
+
+// addCounts adds the counts of src to dst, which is created if nil
+func addCounts(dst, src map[string]uint64) map[string]uint64 {
+	if dst == nil {
+		dst = make(map[string]uint64)
+	}
+	for name, n := range src {
+		if _, ok := dst[name]; ok || len(dst) < maxNamesPerDay {
+			dst[name] += n
+		}
+	}
+	return dst
+}
+
+// Recorder counts usage in memory and periodically adds the counts to the store
+type Recorder struct {
+	store     metadata.Store
+	retention time.Duration
+	logger    *zap.Logger
+	now       func() time.Time
+
+	// mu protects pending and serializes flushes
+	mu sync.Mutex
+	// pending are the counts not yet written to the store, by date
+	pending map[string]*Day
+}
+
+// NewRecorder creates a Recorder keeping daily counts in the store for the given retention
+func NewRecorder(store metadata.Store, retention time.Duration, logger *zap.Logger) *Recorder {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Recorder{
+		store:     store,
+		retention: retention,
+		logger:    logger.Named("analytics"),
+		now:       time.Now,
+		pending:   make(map[string]*Day),
+	}
+}
+
+// PageView counts a page view of a feature. Invalid feature names are ignored.
+func (r *Recorder) PageView(feature string) {
+	if !ValidFeature(feature) {
+		return
+	}
+	r.count(func(d *Day) map[string]uint64 { return d.PageViews }, feature)
+}
+
+// Query counts an API request of a route
+func (r *Recorder) Query(route string) {
+	r.count(func(d *Day) map[string]uint64 { return d.Queries }, route)
+}
+
+// count increments the counter of name in the counts selected from today's pending counts
+func (r *Recorder) count(counts func(*Day) map[string]uint64, name string) {
+	date := r.now().UTC().Format(dateLayout)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.pending[date]
+	if !ok {
+		d = &Day{PageViews: make(map[string]uint64), Queries: make(map[string]uint64)}
+		r.pending[date] = d
+	}
+	c := counts(d)
+	if _, ok := c[name]; ok || len(c) < maxNamesPerDay {
+		c[name]++
+	}
+}
+
+// Middleware counts the API requests passing through it by method and route pattern,
+// e.g. "GET /api/tables/{name}", so path parameters such as table names are not recorded.
+// It must be installed on the root router, which resolves the route within it.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req)
+		rctx := chi.RouteContext(req.Context())
+		if rctx == nil {
+			return
+		}
+		pattern := rctx.RoutePattern()
+		if strings.HasPrefix(pattern, "/api/") && !strings.HasPrefix(pattern, "/api/analytics") {
+			r.Query(req.Method + " " + pattern)
+		}
+	})
+}
+
+// Start periodically writes the counts to the store until the context is done
+func (r *Recorder) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Flush(); err != nil {
+					r.logger.Warn("Failed to store usage analytics", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Flush adds the pending counts to the store and deletes days beyond the retention
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for date, pending := range r.pending {
+		stored, err := metadata.Get[Day](r.store, Namespace, date)
+		if err != nil && !errors.Is(err, metadata.ErrNotFound) {
+			return err
+		}
+		stored.add(*pending)
+		if err := metadata.Put(r.store, Namespace, date, stored); err != nil {
+			return err
+		}
+		delete(r.pending, date)
+	}
+
+	cutoff := r.now().UTC().Add(-r.retention).Format(dateLayout)
+	days, err := r.store.List(Namespace)
+	if err != nil {
+		return err
+	}
+	for date := range days {
+		if date < cutoff {
+			if err := r.store.Delete(Namespace, date); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Count is the number of times something was recorded
+type Count struct {
+	Name  string `json:"name"`
+	Count uint64 `json:"count"`
+}
+
+// DayTotals are the total counts of a day
+type DayTotals struct {
+	Date      string `json:"date"`
+	PageViews uint64 `json:"pageViews"`
+	Queries   uint64 `json:"queries"`
+}
+
+// Report summarizes the usage of the console over a number of days
+type Report struct {
+	// From and To are the first and last day of the report
+	From string `json:"from"`
+	To   string `json:"to"`
+	// PageViews are the page views by feature, most viewed first
+	PageViews []Count `json:"pageViews"`
+	// Queries are the API requests by route, most requested first
+	Queries []Count `json:"queries"`
+	// Days are the totals of the days with any usage, oldest first
+	Days []DayTotals `json:"days"`
+}
+
+// Report summarizes the usage of the last number of days, including today
+func (r *Recorder) Report(days int) (Report, error) {
+	if err := r.Flush(); err != nil {
+		return Report{}, err
+	}
+	stored, err := metadata.List[Day](r.store, Namespace)
+	if err != nil {
+		return Report{}, err
+	}
+
+	today := r.now().UTC()
+	report := Report{
+		From:      today.AddDate(0, 0, 1-days).Format(dateLayout),
+		To:        today.Format(dateLayout),
+		PageViews: []Count{},
+		Queries:   []Count{},
+		Days:      []DayTotals{},
+	}
+	pageViews := make(map[string]uint64)
+	queries := make(map[string]uint64)
+	for _, date := range slices.Sorted(maps.Keys(stored)) {
+		if date < report.From || date > report.To {
+			continue
+		}
+		day := stored[date]
+		totals := DayTotals{Date: date}
+		for name, n := range day.PageViews {
+			pageViews[name] += n
+			totals.PageViews += n
+		}
+		for name, n := range day.Queries {
+			queries[name] += n
+			totals.Queries += n
+		}
+		report.Days = append(report.Days, totals)
+	}
+	report.PageViews = ranked(pageViews)
+	report.Queries = ranked(queries)
+	return report, nil
+}
+
+// ranked orders counts by count, highest first, and then by name
+func ranked(counts map[string]uint64) []Count {
+	ranked := make([]Count, 0, len(counts))
+	for name, n := range counts {
+		ranked = append(ranked, Count{Name: name, Count: n})
+	}
+	slices.SortFunc(ranked, func(a, b Count) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return ranked
+}
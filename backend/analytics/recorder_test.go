@@ -0,0 +1,81 @@
+package analytics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderReport(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	r := NewRecorder(store, 30*24*time.Hour, nil)
+	r.now = func() time.Time { return now }
+
+	r.PageView("data")
+	r.PageView("Data/../secret") // not a feature name
+	r.Query("GET /api/tables")
+	require.NoError(t, r.Flush())
+
+	now = now.Add(24 * time.Hour)
+	r.PageView("data")
+	r.PageView("settings")
+	r.PageView("data")
+
+	report, err := r.Report(7)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-03-05", report.From)
+	assert.Equal(t, "2025-03-11", report.To)
+	assert.Equal(t, []Count{{Name: "data", Count: 3}, {Name: "settings", Count: 1}}, report.PageViews)
+	assert.Equal(t, []Count{{Name: "GET /api/tables", Count: 1}}, report.Queries)
+	assert.Equal(t, []DayTotals{
+		{Date: "2025-03-10", PageViews: 1, Queries: 1},
+		{Date: "2025-03-11", PageViews: 3},
+	}, report.Days)
+
+	// Only today is in a report of one day
+	report, err = r.Report(1)
+	require.NoError(t, err)
+	assert.Equal(t, []Count{{Name: "data", Count: 2}, {Name: "settings", Count: 1}}, report.PageViews)
+
+	// Days beyond the retention are deleted
+	now = now.Add(31 * 24 * time.Hour)
+	require.NoError(t, r.Flush())
+	days, err := store.List(Namespace)
+	require.NoError(t, err)
+	assert.Empty(t, days)
+}
+
+func TestRecorderBoundsNamesPerDay(t *testing.T) {
+	r := NewRecorder(metadata.NewMemoryStore(), time.Hour, nil)
+	for i := range maxNamesPerDay + 10 {
+		r.Query("GET /api/" + string(rune('a'+i%26)) + string(rune('a'+i/26)))
+	}
+	report, err := r.Report(1)
+	require.NoError(t, err)
+	assert.Len(t, report.Queries, maxNamesPerDay)
+}
+
+func TestMiddlewareCountsRoutes(t *testing.T) {
+	rec := NewRecorder(metadata.NewMemoryStore(), time.Hour, nil)
+	router := chi.NewRouter()
+	router.Use(rec.Middleware)
+	router.Get("/api/tables/{name}", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/api/analytics/pageviews", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/*", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, target := range []string{"/api/tables/users", "/api/tables/orders", "/data/users"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/analytics/pageviews", nil))
+
+	report, err := rec.Report(1)
+	require.NoError(t, err)
+	assert.Equal(t, []Count{{Name: "GET /api/tables/{name}", Count: 2}}, report.Queries)
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/armadakv/console/backend/accounts"
+	"github.com/armadakv/console/backend/apikeys"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/reload"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// ConfigResponse represents the response for the effective configuration API endpoint
+type ConfigResponse struct {
+	File     string           `json:"file,omitempty"`
+	Settings []config.Setting `json:"settings"`
+	Warnings []string         `json:"warnings"`
+	// Pending are the keys of the settings changed by a reload that aren't in effect, because
+	// they require a restart or failed to apply
+	Pending []string `json:"pending"`
+}
+
+// AdminOption configures optional behaviour of the AdminHandler
+type AdminOption func(*AdminHandler)
+
+// WithReloader allows operators to reload the configuration. The configuration shown
+// is the one in effect as of the last reload.
+func WithReloader(reloader *reload.Reloader) AdminOption {
+	return func(h *AdminHandler) {
+		h.reloader = reloader
+	}
+}
+
+// AdminHandler serves administrative API endpoints
+type AdminHandler struct {
+	cfg       *config.Config
+	reloader  *reload.Reloader
+	directory *accounts.Directory
+	apiKeys   *apikeys.Manager
+	sessions  *auth.SessionStore
+	auditLog  audit.Log
+	logger    *zap.Logger
+}
+
+// NewAdminHandler creates a new admin API handler
+func NewAdminHandler(cfg *config.Config, logger *zap.Logger, opts ...AdminOption) *AdminHandler {
+	h := &AdminHandler{
+		cfg:    cfg,
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers the admin routes under /api/admin. They are only served to operators,
+// as the configuration, users and keys are not for viewers to read either.
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	adminRouter := chi.NewRouter()
+	adminRouter.Use(requireOperator)
+	adminRouter.Get("/config", h.handleConfig)
+	if h.reloader != nil {
+		adminRouter.Post("/reload", h.handleReload)
+	}
+	if h.directory != nil {
+		h.registerUserRoutes(adminRouter)
+	}
+	if h.apiKeys != nil {
+		h.registerAPIKeyRoutes(adminRouter)
+	}
+	r.Mount("/api/admin", adminRouter)
+}
+
+// handleConfig returns the effective configuration with the source of every value.
+// Secret values are redacted.
+// @Summary Get configuration
+// @Description Get the effective configuration with the source of every value, secrets are redacted. Settings changed by a reload that aren't in effect yet are listed as pending.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} ConfigResponse
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/config [get]
+func (h *AdminHandler) handleConfig(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	cfg := h.cfg
+	pending := []string{}
+	if h.reloader != nil {
+		cfg = h.reloader.Current()
+		pending = append(pending, cfg.Changed(h.reloader.Loaded())...)
+	}
+	warnings := cfg.Warnings()
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	render.JSON(ConfigResponse{
+		File:     cfg.File(),
+		Settings: cfg.Settings(),
+		Warnings: warnings,
+		Pending:  pending,
+	})
+}
+
+// handleReload reloads the configuration like SIGHUP does
+// @Summary Reload configuration
+// @Description Reload the configuration file and apply the changed seed list, scrape interval, TLS certificate and log level without a restart. Other changes are listed as requiring a restart.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} reload.Result
+// @Failure 403 {string} string "The operator role is required"
+// @Failure 422 {string} string "Invalid configuration"
+// @Router /api/admin/reload [post]
+func (h *AdminHandler) handleReload(w http.ResponseWriter, r *http.Request) {
+	result, err := h.reloader.Reload(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	chix.NewRender(w).JSON(result)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/armadakv/console/backend/accounts"
+	"github.com/armadakv/console/backend/apikeys"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/reload"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandleConfig(t *testing.T) {
+	cfg, err := config.Load("", func(name string) (string, bool) {
+		if name == "ARMADA_URL" {
+			return "http://armada:5001", true
+		}
+		return "", false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	NewAdminHandler(cfg, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/config", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response ConfigResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+
+	if response.Warnings == nil {
+		t.Error("warnings should be an empty list, not null")
+	}
+
+	for _, setting := range response.Settings {
+		if setting.Key != "armada.url" {
+			continue
+		}
+		if setting.Value != "http://armada:5001" || setting.Source != config.SourceEnv {
+			t.Errorf("unexpected armada.url setting: %+v", setting)
+		}
+		return
+	}
+	t.Error("armada.url setting not found in response")
+}
+
+func TestHandleReload(t *testing.T) {
+	env := map[string]string{"LOG_LEVEL": "debug"}
+	load := func() (*config.Config, error) {
+		cfg, err := config.Load("", func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		})
+		if err != nil {
+			return nil, err
+		}
+		return cfg, cfg.Validate()
+	}
+	cfg, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader := reload.NewReloader(cfg, load, zap.NewNop())
+	reloader.Handle("log", func(context.Context, *config.Config) error { return nil }, "log.level")
+
+	r := chi.NewRouter()
+	NewAdminHandler(cfg, zap.NewNop(), WithReloader(reloader)).RegisterRoutes(r)
+
+	req := httptest.NewRequest("POST", "/api/admin/reload", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req.WithContext(auth.WithRole(req.Context(), auth.RoleViewer)))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected viewers to be forbidden, got status %d", rr.Code)
+	}
+
+	env["LOG_LEVEL"] = "info"
+	env["METRICS_RETENTION"] = "48h"
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/admin/reload", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var result reload.Result
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if !slices.Equal(result.Applied, []string{"log.level"}) {
+		t.Errorf("Expected the log level to be applied, got %v", result.Applied)
+	}
+
+	// The configuration shown has the applied settings, the others are pending until a restart
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/config", nil))
+	var response ConfigResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	for _, setting := range response.Settings {
+		if setting.Key == "log.level" && setting.Value != "info" {
+			t.Errorf("Expected the reloaded log level, got %v", setting.Value)
+		}
+		if setting.Key == "metrics.retention" && setting.Value == "48h0m0s" {
+			t.Errorf("Expected the retention requiring a restart not to be shown in effect")
+		}
+	}
+	if !slices.Equal(response.Pending, []string{"metrics.retention"}) {
+		t.Errorf("Expected the retention to be pending, got %v", response.Pending)
+	}
+
+	env["LOG_LEVEL"] = "verbose"
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/admin/reload", nil))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected an invalid configuration to be rejected, got status %d", rr.Code)
+	}
+}
+
+func TestAdminRequiresOperator(t *testing.T) {
+	directory := accounts.NewDirectory(metadata.NewMemoryStore(), "admin")
+	auditLog := audit.NewMemoryLog(10)
+	r := chi.NewRouter()
+	NewAdminHandler(&config.Config{}, zap.NewNop(),
+		WithUserDirectory(directory, auditLog),
+		WithAPIKeys(apikeys.NewManager(metadata.NewMemoryStore()), auditLog)).RegisterRoutes(r)
+
+	for _, path := range []string{"/api/admin/config", "/api/admin/users", "/api/admin/users/admin", "/api/admin/roles", "/api/admin/roles/viewer", "/api/admin/apikeys"} {
+		req := httptest.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req.WithContext(auth.WithRole(req.Context(), auth.RoleViewer)))
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("GET %s: expected viewers to be forbidden, got status %d", path, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, req.WithContext(auth.WithRole(req.Context(), auth.RoleOperator)))
+		if rr.Code == http.StatusForbidden {
+			t.Errorf("GET %s: expected operators to be served, got status %d", path, rr.Code)
+		}
+	}
+}
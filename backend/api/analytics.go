@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/armadakv/console/backend/analytics"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// PageViewPath is where the frontend records page views. Viewers may call it, it doesn't change the cluster.
+const PageViewPath = "/api/analytics/pageviews"
+
+const (
+	// defaultAnalyticsDays is the number of days reported unless requested otherwise
+	defaultAnalyticsDays = 30
+	// maxAnalyticsDays is the largest number of days that can be reported
+	maxAnalyticsDays = 366
+)
+
+// AnalyticsStatus tells the frontend whether usage is counted
+type AnalyticsStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// PageViewRequest records a page view of a feature
+type PageViewRequest struct {
+	// Feature is the name of the viewed feature, e.g. "data" or "settings"
+	Feature string `json:"feature"`
+}
+
+// AnalyticsHandler records usage and reports it to operators
+type AnalyticsHandler struct {
+	// recorder counts usage, nil if analytics are disabled
+	recorder *analytics.Recorder
+	logger   *zap.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics API handler. Analytics are disabled if the recorder is nil.
+func NewAnalyticsHandler(recorder *analytics.Recorder, logger *zap.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the analytics routes
+func (h *AnalyticsHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/analytics", h.handleStatus)
+	r.Post(PageViewPath, h.handlePageView)
+	r.Get("/api/admin/analytics", h.handleReport)
+}
+
+// handleStatus reports whether usage analytics are enabled
+// @Summary Get analytics status
+// @Description Report whether usage analytics are enabled, so the frontend only records page views if they are
+// @Tags analytics
+// @Produce json
+// @Success 200 {object} AnalyticsStatus
+// @Router /api/analytics [get]
+func (h *AnalyticsHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	chix.NewRender(w).JSON(AnalyticsStatus{Enabled: h.recorder != nil})
+}
+
+// handlePageView records a page view
+// @Summary Record a page view
+// @Description Count a page view of a console feature
+// @Tags analytics
+// @Accept json
+// @Param request body PageViewRequest true "Viewed feature"
+// @Success 200 {object} map[string]any
+// @Failure 400 {string} string "Invalid feature"
+// @Failure 404 {string} string "Analytics are disabled"
+// @Router /api/analytics/pageviews [post]
+func (h *AnalyticsHandler) handlePageView(w http.ResponseWriter, r *http.Request) {
+	if h.recorder == nil {
+		http.Error(w, "Usage analytics are disabled", http.StatusNotFound)
+		return
+	}
+	var req PageViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !analytics.ValidFeature(req.Feature) {
+		http.Error(w, "feature must be a lowercase name like \"data\"", http.StatusBadRequest)
+		return
+	}
+	h.recorder.PageView(req.Feature)
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// handleReport reports the usage of the console to operators
+// @Summary Get usage report
+// @Description Get the page views per feature and API requests per route of the last days
+// @Tags analytics
+// @Produce json
+// @Param days query int false "Number of days reported, including today (default 30)"
+// @Success 200 {object} analytics.Report
+// @Failure 400 {string} string "Invalid days"
+// @Failure 403 {string} string "The operator role is required"
+// @Failure 404 {string} string "Analytics are disabled"
+// @Router /api/admin/analytics [get]
+func (h *AnalyticsHandler) handleReport(w http.ResponseWriter, r *http.Request) {
+	if h.recorder == nil {
+		http.Error(w, "Usage analytics are disabled", http.StatusNotFound)
+		return
+	}
+	if role, ok := auth.RoleFromContext(r.Context()); ok && role != auth.RoleOperator {
+		http.Error(w, "Forbidden: the operator role is required", http.StatusForbidden)
+		return
+	}
+	days := defaultAnalyticsDays
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		var err error
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 1 || days > maxAnalyticsDays {
+			http.Error(w, "days must be between 1 and "+strconv.Itoa(maxAnalyticsDays), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.recorder.Report(days)
+	if err != nil {
+		h.logger.Error("Failed to report usage analytics", zap.Error(err))
+		http.Error(w, "Failed to report usage analytics", http.StatusInternalServerError)
+		return
+	}
+	chix.NewRender(w).JSON(report)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/analytics"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestAnalyticsHandler(t *testing.T) {
+	recorder := analytics.NewRecorder(metadata.NewMemoryStore(), 24*time.Hour, zap.NewNop())
+	r := chi.NewRouter()
+	NewAnalyticsHandler(recorder, zap.NewNop()).RegisterRoutes(r)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(httptest.NewRequest("GET", "/api/analytics", nil)); strings.TrimSpace(rr.Body.String()) != `{"enabled":true}` {
+		t.Errorf("unexpected analytics status: %s", rr.Body.String())
+	}
+	if rr := serve(httptest.NewRequest("POST", PageViewPath, strings.NewReader(`{"feature":"data"}`))); rr.Code != http.StatusOK {
+		t.Errorf("Expected page view to be recorded, got status %d", rr.Code)
+	}
+	if rr := serve(httptest.NewRequest("POST", PageViewPath, strings.NewReader(`{"feature":"/data/users"}`))); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid feature to be rejected, got status %d", rr.Code)
+	}
+	if rr := serve(httptest.NewRequest("GET", "/api/admin/analytics?days=0", nil)); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid days to be rejected, got status %d", rr.Code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/admin/analytics", nil)
+	if rr := serve(req.WithContext(auth.WithRole(req.Context(), auth.RoleViewer))); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected viewers to be denied the report, got status %d", rr.Code)
+	}
+
+	rr := serve(req.WithContext(auth.WithRole(req.Context(), auth.RoleOperator)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var report analytics.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(report.PageViews) != 1 || report.PageViews[0] != (analytics.Count{Name: "data", Count: 1}) {
+		t.Errorf("unexpected page views: %+v", report.PageViews)
+	}
+}
+
+func TestAnalyticsHandlerDisabled(t *testing.T) {
+	r := chi.NewRouter()
+	NewAnalyticsHandler(nil, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/analytics", nil))
+	if strings.TrimSpace(rr.Body.String()) != `{"enabled":false}` {
+		t.Errorf("unexpected analytics status: %s", rr.Body.String())
+	}
+	for _, req := range []*http.Request{
+		httptest.NewRequest("POST", PageViewPath, strings.NewReader(`{"feature":"data"}`)),
+		httptest.NewRequest("GET", "/api/admin/analytics", nil),
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected %s %s to return 404 while disabled, got %d", req.Method, req.URL.Path, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/armadakv/console/backend/apikeys"
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name string    `json:"name"`
+	Role auth.Role `json:"role"`
+	// ExpiresIn is a duration like 720h after which the key stops working, it works until revoked if empty.
+	ExpiresIn string `json:"expiresIn,omitempty"`
+}
+
+// CreateAPIKeyResponse represents the response for a created API key. The key is only returned here.
+type CreateAPIKeyResponse struct {
+	apikeys.Key
+	Token string `json:"token"`
+}
+
+// WithAPIKeys lets operators create, list and revoke API keys under /api/admin/apikeys. Every
+// change is recorded in the audit log.
+func WithAPIKeys(manager *apikeys.Manager, auditLog audit.Log) AdminOption {
+	return func(h *AdminHandler) {
+		h.apiKeys = manager
+		h.auditLog = auditLog
+	}
+}
+
+// registerAPIKeyRoutes registers the API key management routes on the admin router
+func (h *AdminHandler) registerAPIKeyRoutes(r chi.Router) {
+	r.Get("/apikeys", h.handleListAPIKeys)
+	r.Post("/apikeys", h.handleCreateAPIKey)
+	r.Delete("/apikeys/{id}", h.handleRevokeAPIKey)
+}
+
+// handleListAPIKeys returns the API keys
+// @Summary List API keys
+// @Description List the API keys with their role, expiry and last use. The keys themselves are never returned.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} apikeys.Key
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/apikeys [get]
+func (h *AdminHandler) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeys.Keys()
+	if err != nil {
+		h.apiKeyError(w, err, "Failed to list API keys")
+		return
+	}
+	chix.NewRender(w).JSON(keys)
+}
+
+// handleCreateAPIKey creates an API key
+// @Summary Create API key
+// @Description Create a key for scripts and CI jobs, sent in the X-API-Key header or as a bearer token. The key is only returned in this response, a digest of it is stored.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateAPIKeyRequest true "API key"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Header 201 {string} Location "Path of the new API key"
+// @Failure 400 {string} string "Invalid API key"
+// @Failure 409 {string} string "API key already exists"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/apikeys [post]
+func (h *AdminHandler) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.ExpiresIn); err != nil || ttl <= 0 {
+			http.Error(w, "Invalid expiresIn: use a positive duration like 720h", http.StatusBadRequest)
+			return
+		}
+	}
+	key, token, err := h.apiKeys.Create(req.Name, req.Role, ttl, auth.UserName(r.Context()))
+	details := map[string]string{"role": string(req.Role)}
+	if req.ExpiresIn != "" {
+		details["expiresIn"] = req.ExpiresIn
+	}
+	h.recordAudit(r, "apikey.create", "apikeys/"+req.Name, err, details)
+	if err != nil {
+		h.apiKeyError(w, err, "Failed to create API key")
+		return
+	}
+	h.logger.Info("Created API key", zap.String("name", key.Name), zap.String("id", key.ID),
+		zap.String("role", string(key.Role)), zap.String("user", key.CreatedBy))
+
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/admin/apikeys/"+key.ID)))
+	render.Status(http.StatusCreated)
+	render.JSON(CreateAPIKeyResponse{Key: key, Token: token})
+}
+
+// handleRevokeAPIKey deletes an API key
+// @Summary Revoke API key
+// @Description Delete an API key, requests using it are refused
+// @Tags admin
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "API key not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/apikeys/{id} [delete]
+func (h *AdminHandler) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	key, err := h.apiKeys.Revoke(id)
+	resource := "apikeys/" + id
+	if err == nil {
+		resource = "apikeys/" + key.Name
+	}
+	h.recordAudit(r, "apikey.revoke", resource, err, map[string]string{"id": id})
+	if err != nil {
+		h.apiKeyError(w, err, "Failed to revoke API key")
+		return
+	}
+	h.logger.Info("Revoked API key", zap.String("name", key.Name), zap.String("id", id),
+		zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// apiKeyError answers with the status matching an error of the API key manager. Unexpected
+// errors are logged and answered with the message.
+func (h *AdminHandler) apiKeyError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, apikeys.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apikeys.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apikeys.ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/apikeys"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestAPIKeyManagement(t *testing.T) {
+	manager := apikeys.NewManager(metadata.NewMemoryStore())
+	auditLog := audit.NewMemoryLog(100)
+	r := chi.NewRouter()
+	NewAdminHandler(&config.Config{}, zap.NewNop(), WithAPIKeys(manager, auditLog)).RegisterRoutes(r)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithUser(req.Context(), auth.User{Name: "admin", Method: auth.MethodBasic}))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("POST", "/api/admin/apikeys", `{"name": "ci", "role": "operator", "expiresIn": "720h"}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("creating a key returned %d: %s", rr.Code, rr.Body.String())
+	}
+	var created CreateAPIKeyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ExpiresAt == nil || created.CreatedBy != "admin" {
+		t.Errorf("unexpected key: %+v", created.Key)
+	}
+	if got, want := rr.Header().Get("Location"), "/api/admin/apikeys/"+created.ID; got != want {
+		t.Errorf("Location = %q, want %q", got, want)
+	}
+	if user, ok := manager.AuthenticateKey(created.Token); !ok || user.Name != "ci" {
+		t.Errorf("the returned key must authenticate, got %+v", user)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{name: "CreateTwice", method: "POST", path: "/api/admin/apikeys", body: `{"name": "ci", "role": "viewer"}`, want: http.StatusConflict},
+		{name: "CreateInvalidRole", method: "POST", path: "/api/admin/apikeys", body: `{"name": "deploy", "role": "root"}`, want: http.StatusBadRequest},
+		{name: "CreateInvalidExpiry", method: "POST", path: "/api/admin/apikeys", body: `{"name": "deploy", "role": "viewer", "expiresIn": "soon"}`, want: http.StatusBadRequest},
+		{name: "RevokeUnknown", method: "DELETE", path: "/api/admin/apikeys/0123456789abcdef", want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := serve(tt.method, tt.path, tt.body); rr.Code != tt.want {
+				t.Errorf("%s %s returned %d, want %d: %s", tt.method, tt.path, rr.Code, tt.want, rr.Body.String())
+			}
+		})
+	}
+
+	rr = serve("GET", "/api/admin/apikeys", "")
+	if strings.Contains(rr.Body.String(), created.Token) || strings.Contains(rr.Body.String(), "digest") {
+		t.Error("neither keys nor their digests must be listed")
+	}
+	var keys []apikeys.Key
+	if err := json.Unmarshal(rr.Body.Bytes(), &keys); err != nil || len(keys) != 1 {
+		t.Fatalf("unexpected keys %s: %v", rr.Body.String(), err)
+	}
+
+	if rr := serve("DELETE", "/api/admin/apikeys/"+created.ID, ""); rr.Code != http.StatusOK {
+		t.Fatalf("revoking the key returned %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, ok := manager.AuthenticateKey(created.Token); ok {
+		t.Error("revoked keys must not authenticate")
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{Action: "apikey.revoke"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Resource != "apikeys/ci" || entries[1].Outcome != audit.OutcomeFailure {
+		t.Errorf("revocations should be audited, got %+v", entries)
+	}
+}
@@ -0,0 +1,350 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrApprovalNotFound is returned for unknown or expired approvals
+	ErrApprovalNotFound = errors.New("approval not found or expired")
+	// ErrSelfApproval is returned when users approve their own requests
+	ErrSelfApproval = errors.New("approvals must be granted by another admin")
+	// ErrNotApproved is returned when a pending approval is used
+	ErrNotApproved = errors.New("the approval has not been granted yet")
+	// ErrApprovalMismatch is returned when an approval is used for another request than it was requested for
+	ErrApprovalMismatch = errors.New("the approval was requested for another change")
+)
+
+// Approval is a change held back by a guardrail until a guardrail admin other than the requesting
+// user approves it. It approves the method, path and query of the request, e.g. whether a single
+// key or a prefix is deleted, but not its body.
+type Approval struct {
+	ID string `json:"id"`
+	// User is the principal requesting the change as method:name, e.g. oidc:jane@example.com
+	User    string `json:"user"`
+	Cluster string `json:"cluster"`
+	Action  string `json:"action"`
+	Method  string `json:"method"`
+	// Target is the path and query of the request, its parameters sorted
+	Target      string    `json:"target"`
+	RequestedAt time.Time `json:"requestedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// ApprovedBy is the principal of the admin who granted the approval
+	ApprovedBy string     `json:"approvedBy,omitempty"`
+	ApprovedAt *time.Time `json:"approvedAt,omitempty"`
+}
+
+// Approvals keeps the requested approvals in memory until they are used or expire, so pending
+// approvals are lost on restart
+type Approvals struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	approvals map[string]*Approval
+}
+
+// NewApprovals creates a store of approvals that expire ttl after they were requested
+func NewApprovals(ttl time.Duration) *Approvals {
+	return &Approvals{
+		ttl:       ttl,
+		now:       time.Now,
+		approvals: make(map[string]*Approval),
+	}
+}
+
+// Request holds back a change until it is approved
+func (a *Approvals) Request(user, cluster, action, method, target string) (Approval, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Approval{}, fmt.Errorf("failed to generate approval ID: %w", err)
+	}
+	now := a.now().UTC()
+	approval := &Approval{
+		ID:          hex.EncodeToString(id),
+		User:        user,
+		Cluster:     cluster,
+		Action:      action,
+		Method:      method,
+		Target:      target,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(a.ttl),
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prune()
+	a.approvals[approval.ID] = approval
+	return *approval, nil
+}
+
+// List returns the approvals that neither expired nor were used, oldest first
+func (a *Approvals) List() []Approval {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prune()
+	approvals := make([]Approval, 0, len(a.approvals))
+	for _, approval := range a.approvals {
+		approvals = append(approvals, *approval)
+	}
+	slices.SortFunc(approvals, func(x, y Approval) int { return x.RequestedAt.Compare(y.RequestedAt) })
+	return approvals
+}
+
+// Get returns an approval, or ErrApprovalNotFound
+func (a *Approvals) Get(id string) (Approval, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prune()
+	approval, ok := a.approvals[id]
+	if !ok {
+		return Approval{}, ErrApprovalNotFound
+	}
+	return *approval, nil
+}
+
+// Approve grants an approval. Users may not approve their own requests, the principals of the
+// requesting user and the admin are compared.
+func (a *Approvals) Approve(id, admin string) (Approval, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prune()
+	approval, ok := a.approvals[id]
+	if !ok {
+		return Approval{}, ErrApprovalNotFound
+	}
+	if approval.User == admin {
+		return Approval{}, ErrSelfApproval
+	}
+	now := a.now().UTC()
+	approval.ApprovedBy = admin
+	approval.ApprovedAt = &now
+	return *approval, nil
+}
+
+// Reject removes an approval, whether it was granted or not
+func (a *Approvals) Reject(id string) (Approval, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prune()
+	approval, ok := a.approvals[id]
+	if !ok {
+		return Approval{}, ErrApprovalNotFound
+	}
+	delete(a.approvals, id)
+	return *approval, nil
+}
+
+// Use consumes a granted approval for the change it was requested for by the same user. Each
+// approval can be used once.
+func (a *Approvals) Use(id, user, cluster, action, method, target string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prune()
+	approval, ok := a.approvals[id]
+	switch {
+	case !ok:
+		return ErrApprovalNotFound
+	case approval.User != user || approval.Cluster != cluster || approval.Action != action ||
+		approval.Method != method || approval.Target != target:
+		return ErrApprovalMismatch
+	case approval.ApprovedAt == nil:
+		return ErrNotApproved
+	}
+	delete(a.approvals, id)
+	return nil
+}
+
+// principalOf returns the principal of the user of a request as method:name. Approvals are
+// requested, granted and used by principals, so an API key or a local user named like an admin
+// is another user than the admin.
+func principalOf(ctx context.Context) string {
+	user, ok := auth.UserFromContext(ctx)
+	if !ok || user.Name == "" {
+		return auth.Anonymous
+	}
+	return user.Principal().String()
+}
+
+// approvalTarget returns the path and query a request is approved for. The parameters are sorted,
+// so an approval doesn't depend on their order.
+func approvalTarget(r *http.Request) string {
+	if r.URL.RawQuery == "" {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// prune removes the expired approvals, the caller must hold the lock
+func (a *Approvals) prune() {
+	now := a.now()
+	for id, approval := range a.approvals {
+		if !now.Before(approval.ExpiresAt) {
+			delete(a.approvals, id)
+		}
+	}
+}
+
+// ApprovalHandler serves the approvals of changes held back by guardrails
+type ApprovalHandler struct {
+	guardrails Guardrails
+	auditLog   audit.Log
+	logger     *zap.Logger
+}
+
+// NewApprovalHandler creates a new approval API handler
+func NewApprovalHandler(guardrails Guardrails, auditLog audit.Log, logger *zap.Logger) *ApprovalHandler {
+	return &ApprovalHandler{
+		guardrails: guardrails,
+		auditLog:   auditLog,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers the approval routes under /api/approvals
+func (h *ApprovalHandler) RegisterRoutes(r chi.Router) {
+	approvalRouter := chi.NewRouter()
+	approvalRouter.Get("/", h.handleList)
+	approvalRouter.Post("/{id}/approve", h.handleApprove)
+	approvalRouter.Delete("/{id}", h.handleReject)
+	r.Mount("/api/approvals", approvalRouter)
+}
+
+// handleList lists the pending and granted approvals
+// @Summary List approvals
+// @Description List the changes held back by guardrails that neither expired nor were used, oldest first. Granted approvals carry approvedBy and approvedAt.
+// @Tags approvals
+// @Produce json
+// @Success 200 {array} Approval
+// @Router /api/approvals [get]
+func (h *ApprovalHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	chix.NewRender(w).JSON(h.guardrails.Approvals.List())
+}
+
+// handleApprove grants an approval
+// @Summary Grant approval
+// @Description Approve a change held back by a guardrail. Only guardrail admins may approve, and not their own requests. The requesting user then repeats the change with the approval ID in the X-Approval-ID header.
+// @Tags approvals
+// @Produce json
+// @Param id path string true "Approval ID"
+// @Success 200 {object} Approval
+// @Failure 403 {string} string "Not a guardrail admin or own request"
+// @Failure 404 {string} string "Approval not found or expired"
+// @Router /api/approvals/{id}/approve [post]
+func (h *ApprovalHandler) handleApprove(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	user, _ := auth.UserFromContext(r.Context())
+	if !h.guardrails.IsAdmin(user) {
+		h.recordAudit(r, "approval.grant", id, errors.New("not a guardrail admin"), nil)
+		http.Error(w, "Forbidden: only guardrail admins may approve changes", http.StatusForbidden)
+		return
+	}
+
+	approval, err := h.guardrails.Approvals.Approve(id, principalOf(r.Context()))
+	h.recordAudit(r, "approval.grant", id, err, approvalDetails(approval))
+	if err != nil {
+		h.approvalError(w, err)
+		return
+	}
+	h.logger.Info("Granted approval",
+		zap.String("approval", id),
+		zap.String("user", approval.User),
+		zap.String("approvedBy", approval.ApprovedBy),
+		zap.String("cluster", approval.Cluster),
+		zap.String("action", approval.Action))
+	chix.NewRender(w).JSON(approval)
+}
+
+// handleReject removes an approval
+// @Summary Reject approval
+// @Description Remove a pending or granted approval, so the change can't be made with it. Guardrail admins may reject any approval, other users only their own.
+// @Tags approvals
+// @Param id path string true "Approval ID"
+// @Success 204
+// @Failure 403 {string} string "Not a guardrail admin nor the requesting user"
+// @Failure 404 {string} string "Approval not found or expired"
+// @Router /api/approvals/{id} [delete]
+func (h *ApprovalHandler) handleReject(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	user, _ := auth.UserFromContext(r.Context())
+	approval, err := h.guardrails.Approvals.Get(id)
+	if err != nil {
+		h.approvalError(w, err)
+		return
+	}
+	if approval.User != principalOf(r.Context()) && !h.guardrails.IsAdmin(user) {
+		http.Error(w, "Forbidden: only guardrail admins may reject approvals of other users", http.StatusForbidden)
+		return
+	}
+
+	approval, err = h.guardrails.Approvals.Reject(id)
+	h.recordAudit(r, "approval.reject", id, err, approvalDetails(approval))
+	if err != nil {
+		h.approvalError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// approvalDetails returns the audit details of an approval
+func approvalDetails(approval Approval) map[string]string {
+	if approval.ID == "" {
+		return nil
+	}
+	return map[string]string{
+		"user":    approval.User,
+		"cluster": approval.Cluster,
+		"action":  approval.Action,
+		"target":  strings.TrimPrefix(approval.Target, "/api/"),
+	}
+}
+
+// recordAudit appends an entry for a granted or rejected approval to the audit log.
+// Failures to write the audit log are logged but don't fail the request.
+func (h *ApprovalHandler) recordAudit(r *http.Request, action, id string, opErr error, details map[string]string) {
+	if h.auditLog == nil {
+		return
+	}
+	entry := audit.Entry{
+		User:     auth.UserName(r.Context()),
+		Action:   action,
+		Resource: "approvals/" + id,
+		Outcome:  audit.OutcomeSuccess,
+		Details:  details,
+	}
+	if opErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = opErr.Error()
+	}
+	if _, err := h.auditLog.Record(r.Context(), entry); err != nil {
+		h.logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", entry.Action))
+	}
+}
+
+// approvalError answers with the status matching an error of the approvals
+func (h *ApprovalHandler) approvalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrApprovalNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrSelfApproval):
+		http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+	default:
+		h.logger.Error("Failed to handle approval", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
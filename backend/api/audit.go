@@ -0,0 +1,256 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// maxAuditPageSize bounds the number of audit entries returned at once
+const maxAuditPageSize = 1000
+
+// maxAuditExportBytes bounds the size of exports uploaded for verification. Exports are verified
+// as they are read, only the entry being verified is held in memory.
+const maxAuditExportBytes = 256 << 20
+
+// AuditVerifyPath verifies audit log exports. Uploading an export changes nothing, so viewers
+// may use it even in read-only mode.
+const AuditVerifyPath = "/api/audit/verify"
+
+// recordAudit appends an entry for an operation to the audit log.
+// A nil error records a success. The snapshot, if any, is stored with the entry.
+// Failures to write the audit log are logged but don't fail the operation.
+func (h *Handler) recordAudit(r *http.Request, action, resource string, opErr error, details map[string]string, snapshot any) {
+	entry := audit.Entry{
+		User:     auth.UserName(r.Context()),
+		Action:   action,
+		Resource: resource,
+		Outcome:  audit.OutcomeSuccess,
+		Details:  details,
+	}
+	if opErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = opErr.Error()
+	}
+	if snapshot != nil {
+		raw, err := json.Marshal(snapshot)
+		if err != nil {
+			h.logger.Warn("Failed to encode state snapshot", zap.Error(err), zap.String("action", action))
+		} else {
+			entry.Snapshot = raw
+		}
+	}
+
+	if _, err := h.auditLog.Record(r.Context(), entry); err != nil {
+		h.logger.Error("Failed to write audit entry",
+			zap.Error(err),
+			zap.String("action", action),
+			zap.String("resource", resource))
+	}
+}
+
+// AuditHandler serves the audit log
+type AuditHandler struct {
+	log    audit.Log
+	logger *zap.Logger
+	// signingKey is the key the hash chain of the log is signed with, if any
+	signingKey []byte
+}
+
+// AuditOption configures optional behaviour of the AuditHandler
+type AuditOption func(*AuditHandler)
+
+// WithAuditSigningKey verifies the hash chain with the key the log is signed with
+func WithAuditSigningKey(key []byte) AuditOption {
+	return func(h *AuditHandler) {
+		h.signingKey = key
+	}
+}
+
+// NewAuditHandler creates a new audit log API handler
+func NewAuditHandler(log audit.Log, logger *zap.Logger, opts ...AuditOption) *AuditHandler {
+	h := &AuditHandler{
+		log:    log,
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers the audit routes under /api/audit
+func (h *AuditHandler) RegisterRoutes(r chi.Router) {
+	auditRouter := chi.NewRouter()
+	auditRouter.Get("/", h.handleList)
+	auditRouter.Get("/export", h.handleExport)
+	auditRouter.Get("/verify", h.handleVerify)
+	auditRouter.Post("/verify", h.handleVerifyExport)
+	auditRouter.Get("/{id}", h.handleGet)
+	r.Mount("/api/audit", auditRouter)
+}
+
+// handleList returns audit entries newest first, optionally filtered by action and resource.
+// Pollers pass the ID of the newest entry they have seen as since to only get newer entries.
+// Snapshots are omitted from the listing; fetch a single entry to see its snapshot.
+// @Summary List audit entries
+// @Tags audit
+// @Produce json
+// @Param action query string false "Action, e.g. table.delete"
+// @Param resource query string false "Resource, e.g. tables/users"
+// @Param since query int false "ID of the newest entry already seen"
+// @Param limit query int false "Maximum number of entries (default 100)"
+// @Success 200 {array} audit.Entry
+// @Failure 400 {string} string "Invalid query"
+// @Router /api/audit [get]
+func (h *AuditHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	q := audit.Query{
+		Action:   r.URL.Query().Get("action"),
+		Resource: r.URL.Query().Get("resource"),
+		Limit:    100,
+	}
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		since, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			http.Error(w, "since must be an audit entry ID", http.StatusBadRequest)
+			return
+		}
+		q.After = since
+	}
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxAuditPageSize {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxAuditPageSize), http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	entries, err := h.log.List(r.Context(), q)
+	if err != nil {
+		h.logger.Error("Failed to list audit entries", zap.Error(err))
+		http.Error(w, "Failed to list audit entries", http.StatusInternalServerError)
+		return
+	}
+	for i := range entries {
+		entries[i].Snapshot = nil
+	}
+
+	render.JSON(entries)
+}
+
+// handleGet returns a single audit entry including its snapshot
+// @Summary Get audit entry
+// @Description Get an audit entry including the state captured before the operation
+// @Tags audit
+// @Produce json
+// @Param id path int true "Audit entry ID"
+// @Success 200 {object} audit.Entry
+// @Failure 404 {string} string "Audit entry not found"
+// @Router /api/audit/{id} [get]
+func (h *AuditHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid audit entry ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.log.Get(r.Context(), id)
+	if errors.Is(err, audit.ErrNotFound) {
+		http.Error(w, "Audit entry not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get audit entry", zap.Error(err))
+		http.Error(w, "Failed to get audit entry", http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(entry)
+}
+
+// entries returns all entries of the log, oldest first
+func (h *AuditHandler) entries(r *http.Request) ([]audit.Entry, error) {
+	entries, err := h.log.List(r.Context(), audit.Query{})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// handleExport returns the whole audit log as JSON lines, oldest first
+// @Summary Export audit log
+// @Description Export all audit entries including their snapshots as JSON lines, oldest first. Every entry carries the hash of its predecessor, so the export can be verified later with POST /api/audit/verify.
+// @Tags audit
+// @Produce application/x-ndjson
+// @Success 200 {string} string "Audit entries as JSON lines"
+// @Router /api/audit/export [get]
+func (h *AuditHandler) handleExport(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.entries(r)
+	if err != nil {
+		h.logger.Error("Failed to export audit log", zap.Error(err))
+		http.Error(w, "Failed to export audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.jsonl"`, time.Now().UTC().Format("20060102-150405")))
+	if err := audit.WriteEntries(w, entries); err != nil {
+		h.logger.Warn("Failed to write audit export", zap.Error(err))
+	}
+}
+
+// handleVerify verifies the hash chain of the audit log
+// @Summary Verify audit log
+// @Description Verify that no audit entry was changed, inserted or removed since it was recorded by checking the hash chain of the log
+// @Tags audit
+// @Produce json
+// @Success 200 {object} audit.Verification
+// @Router /api/audit/verify [get]
+func (h *AuditHandler) handleVerify(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	entries, err := h.entries(r)
+	if err != nil {
+		h.logger.Error("Failed to verify audit log", zap.Error(err))
+		http.Error(w, "Failed to verify audit log", http.StatusInternalServerError)
+		return
+	}
+	render.JSON(audit.Verify(entries, h.signingKey))
+}
+
+// handleVerifyExport verifies the hash chain of an uploaded export
+// @Summary Verify audit log export
+// @Description Verify the hash chain of an export of the audit log, e.g. one archived for compliance
+// @Tags audit
+// @Accept application/x-ndjson
+// @Produce json
+// @Param export body string true "Audit entries as JSON lines, as returned by GET /api/audit/export"
+// @Success 200 {object} audit.Verification
+// @Failure 400 {string} string "Invalid export"
+// @Router /api/audit/verify [post]
+func (h *AuditHandler) handleVerifyExport(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	v, err := audit.VerifyReader(http.MaxBytesReader(w, r.Body, maxAuditExportBytes), h.signingKey)
+	if err != nil {
+		http.Error(w, "Invalid export: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	render.JSON(v)
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"go.uber.org/zap"
+)
+
+func TestDeleteTableRecordsSnapshot(t *testing.T) {
+	handler := createTestHandler()
+	handler.snapshotSample = 10
+
+	req := httptest.NewRequest("DELETE", "/api/tables/table1", nil)
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{Name: "alice"}))
+	rr := serveWithParams(handler.handleDeleteTable, req, map[string]string{"name": "table1"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	entries, err := handler.auditLog.List(context.Background(), audit.Query{Action: "table.delete"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.User != "alice" || entry.Resource != "tables/table1" || entry.Outcome != audit.OutcomeSuccess {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+
+	var snapshot StateSnapshot
+	if err := json.Unmarshal(entry.Snapshot, &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.Table != "table1" || len(snapshot.Members) == 0 || snapshot.Status == nil {
+		t.Errorf("Expected snapshot with members and status, got %+v", snapshot)
+	}
+	if len(snapshot.Sample) != 2 {
+		t.Errorf("Expected 2 sampled keys, got %d", len(snapshot.Sample))
+	}
+	if len(snapshot.Errors) != 0 {
+		t.Errorf("Expected no snapshot errors, got %v", snapshot.Errors)
+	}
+}
+
+func TestDeletePrefixRecordsSnapshot(t *testing.T) {
+	handler := createTestHandler()
+
+	rr := serveWithParams(handler.handleDeleteKey, httptest.NewRequest("DELETE", "/api/kv/table1?prefix=user/", nil), map[string]string{"table": "table1"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	entries, err := handler.auditLog.List(context.Background(), audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].Action != "kv.deletePrefix" || entries[0].Details["prefix"] != "user/" || entries[0].Details["deleted"] != "2" {
+		t.Errorf("Unexpected audit entry: %+v", entries[0])
+	}
+
+	// Sampling is disabled by default
+	var snapshot StateSnapshot
+	if err := json.Unmarshal(entries[0].Snapshot, &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.Sample != nil {
+		t.Errorf("Expected no sample, got %v", snapshot.Sample)
+	}
+}
+
+func TestAuditHandler(t *testing.T) {
+	log := audit.NewMemoryLog(10)
+	for _, action := range []string{"table.delete", "kv.deletePrefix"} {
+		if _, err := log.Record(context.Background(), audit.Entry{Action: action, Snapshot: json.RawMessage(`{"table":"t"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewAuditHandler(log, zap.NewNop())
+
+	rr := httptest.NewRecorder()
+	handler.handleList(rr, httptest.NewRequest("GET", "/api/audit?action=table.delete", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var entries []audit.Entry
+	if err := json.NewDecoder(rr.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != "table.delete" || entries[0].Snapshot != nil {
+		t.Errorf("Expected one entry without snapshot, got %+v", entries)
+	}
+
+	rr = serveWithParams(handler.handleGet, httptest.NewRequest("GET", "/api/audit/1", nil), map[string]string{"id": "1"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `"snapshot":{"table":"t"}`) {
+		t.Errorf("Expected snapshot in response, got %s", rr.Body.String())
+	}
+
+	rr = serveWithParams(handler.handleGet, httptest.NewRequest("GET", "/api/audit/42", nil), map[string]string{"id": "42"})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleList(rr, httptest.NewRequest("GET", "/api/audit?limit=0", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestAuditExportAndVerify(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	log := audit.NewMemoryLog(10, audit.WithSigningKey(key))
+	for _, action := range []string{"table.create", "table.delete"} {
+		if _, err := log.Record(context.Background(), audit.Entry{Action: action, Snapshot: json.RawMessage(`{"table":"t"}`)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewAuditHandler(log, zap.NewNop(), WithAuditSigningKey(key))
+
+	rr := httptest.NewRecorder()
+	handler.handleExport(rr, httptest.NewRequest("GET", "/api/audit/export", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	export := rr.Body.String()
+	lines := strings.Split(strings.TrimSpace(export), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"action":"table.create"`) || !strings.Contains(lines[1], `"snapshot"`) {
+		t.Fatalf("Expected both entries oldest first with snapshots, got %s", export)
+	}
+
+	verify := func(body string) audit.Verification {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		if body == "" {
+			handler.handleVerify(rr, httptest.NewRequest("GET", AuditVerifyPath, nil))
+		} else {
+			handler.handleVerifyExport(rr, httptest.NewRequest("POST", AuditVerifyPath, strings.NewReader(body)))
+		}
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+		var v audit.Verification
+		if err := json.NewDecoder(rr.Body).Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	if v := verify(""); !v.Valid || v.Entries != 2 {
+		t.Errorf("Expected the log to verify, got %+v", v)
+	}
+	if v := verify(export); !v.Valid || v.LastID != 2 {
+		t.Errorf("Expected the export to verify, got %+v", v)
+	}
+	if v := verify(strings.Replace(export, "table.create", "table.update", 1)); v.Valid || v.BrokenAt != 1 {
+		t.Errorf("Expected the modified export to fail at entry 1, got %+v", v)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleVerifyExport(rr, httptest.NewRequest("POST", AuditVerifyPath, strings.NewReader("not json")))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/armadakv/console/backend/config"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// brandingLogoPath is where the configured logo is served
+const brandingLogoPath = "/api/branding/logo"
+
+// FooterLink is a link shown in the footer of the console
+type FooterLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// BrandingResponse describes how the console presents itself
+type BrandingResponse struct {
+	Title string `json:"title"`
+	// LogoURL is where the logo is served, empty if no logo is configured
+	LogoURL string `json:"logoUrl,omitempty"`
+	// Colors maps palette names such as primary to the colors replacing the built-in ones
+	Colors      map[string]string `json:"colors"`
+	FooterLinks []FooterLink      `json:"footerLinks"`
+}
+
+// BrandingHandler serves the branding of the console
+type BrandingHandler struct {
+	cfg    config.BrandingConfig
+	logger *zap.Logger
+}
+
+// NewBrandingHandler creates a new branding API handler. The configuration must have been validated.
+func NewBrandingHandler(cfg config.BrandingConfig, logger *zap.Logger) *BrandingHandler {
+	return &BrandingHandler{
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the branding routes
+func (h *BrandingHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/branding", h.handleBranding)
+	r.Get(brandingLogoPath, h.handleLogo)
+}
+
+// handleBranding returns the title, logo, colors and footer links of the console
+// @Summary Get branding
+// @Description Get the title, logo, color palette and footer links the console is branded with
+// @Tags branding
+// @Produce json
+// @Success 200 {object} BrandingResponse
+// @Router /api/branding [get]
+func (h *BrandingHandler) handleBranding(w http.ResponseWriter, r *http.Request) {
+	response := BrandingResponse{
+		Title:       h.cfg.Title,
+		Colors:      make(map[string]string),
+		FooterLinks: []FooterLink{},
+	}
+	if h.cfg.LogoFile != "" {
+		response.LogoURL = brandingLogoPath
+	}
+	for _, color := range h.cfg.Colors {
+		if name, value, ok := strings.Cut(color, "="); ok {
+			response.Colors[strings.TrimSpace(name)] = strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	for _, link := range h.cfg.FooterLinks {
+		if label, target, ok := strings.Cut(link, "="); ok {
+			response.FooterLinks = append(response.FooterLinks, FooterLink{
+				Label: strings.TrimSpace(label),
+				URL:   strings.TrimSpace(target),
+			})
+		}
+	}
+	chix.NewRender(w).JSON(response)
+}
+
+// handleLogo serves the configured logo
+// @Summary Get logo
+// @Description Get the logo the console is branded with
+// @Tags branding
+// @Produce image/png,image/svg+xml
+// @Success 200 {file} binary
+// @Failure 404 {string} string "No logo configured"
+// @Router /api/branding/logo [get]
+func (h *BrandingHandler) handleLogo(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.LogoFile == "" {
+		http.NotFound(w, r)
+		return
+	}
+	// An SVG logo must not run scripts when opened directly
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	http.ServeFile(w, r, h.cfg.LogoFile)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/config"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandleBranding(t *testing.T) {
+	logo := filepath.Join(t.TempDir(), "logo.svg")
+	if err := os.WriteFile(logo, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := chi.NewRouter()
+	NewBrandingHandler(config.BrandingConfig{
+		Title:       "Acme KV",
+		LogoFile:    logo,
+		Colors:      []string{"primary=#0055AA"},
+		FooterLinks: []string{"Runbook=https://wiki.example.com/armada?page=1"},
+	}, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/branding", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var response BrandingResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	want := BrandingResponse{
+		Title:       "Acme KV",
+		LogoURL:     brandingLogoPath,
+		Colors:      map[string]string{"primary": "#0055aa"},
+		FooterLinks: []FooterLink{{Label: "Runbook", URL: "https://wiki.example.com/armada?page=1"}},
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("unexpected branding: got %+v want %+v", response, want)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", brandingLogoPath, nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Errorf("Expected the logo to be served, got status %d and type %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandleBrandingDefaults(t *testing.T) {
+	r := chi.NewRouter()
+	NewBrandingHandler(config.BrandingConfig{Title: "Armada Console"}, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/branding", nil))
+	if body := strings.TrimSpace(rr.Body.String()); body != `{"title":"Armada Console","colors":{},"footerLinks":[]}` {
+		t.Errorf("unexpected default branding: %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", brandingLogoPath, nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without a logo, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// maxClusterImportBytes limits the size of an uploaded cluster list
+	maxClusterImportBytes = 1 << 20
+	// maxClusterImports limits the number of clusters imported at once
+	maxClusterImports = 500
+)
+
+// Statuses of imported clusters
+const (
+	ClusterImportCreated = "created"
+	// ClusterImportValid is the status of clusters that would be created by a dry run
+	ClusterImportValid   = "valid"
+	ClusterImportExists  = "exists"
+	ClusterImportInvalid = "invalid"
+)
+
+// ClusterStore keeps the imported clusters. The cluster.Store implements this interface.
+type ClusterStore interface {
+	// Get returns the imported cluster with the given name, or cluster.ErrClusterNotFound
+	Get(name string) (cluster.Definition, error)
+	// Create stores a new cluster, or returns cluster.ErrClusterExists or cluster.ErrInvalid
+	Create(d cluster.Definition) error
+}
+
+// SecretResolver resolves references to secrets. The secrets.Resolver implements this interface.
+type SecretResolver interface {
+	// IsReference reports whether the value is a reference to a secret
+	IsReference(value string) bool
+	// Resolve returns the value of the referenced secret
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// ClusterImportPolicy limits who may import clusters and which secrets their tokens may refer to
+type ClusterImportPolicy struct {
+	// Admins are the only users who may import clusters, other operators may not
+	Admins []auth.Principal
+	// Tokens are the prefixes of the references tokens may be, e.g. vault://secret/data/armada/.
+	// Without them imported clusters can't have tokens.
+	Tokens []string
+}
+
+// AllowsToken reports whether the token is a reference starting with one of the prefixes.
+// References to files and environment variables are always refused, they would hand the
+// credentials of the console to the servers of an imported cluster.
+func (p ClusterImportPolicy) AllowsToken(ref string) bool {
+	scheme, _, ok := strings.Cut(ref, "://")
+	if !ok || scheme == "file" || scheme == "env" || strings.Contains(ref, "..") || strings.ContainsAny(ref, "%?") {
+		return false
+	}
+	return slices.ContainsFunc(p.Tokens, func(prefix string) bool {
+		return strings.HasPrefix(ref, prefix)
+	})
+}
+
+// ImportCluster is a cluster of an import
+type ImportCluster struct {
+	Name  string      `json:"name"`
+	Seeds []string    `json:"seeds"`
+	TLS   cluster.TLS `json:"tls,omitzero"`
+	// Token is a reference to the bearer token of the cluster, e.g. vault://armada/prod#token
+	Token    string           `json:"token,omitempty"`
+	Defaults cluster.Defaults `json:"defaults,omitzero"`
+	Tags     cluster.Tags     `json:"tags,omitempty"`
+}
+
+// ClusterImportResult is the outcome of importing a cluster
+type ClusterImportResult struct {
+	Name string `json:"name"`
+	// Status is created, valid for dry runs, exists or invalid
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ClusterImportResponse lists the outcome of every cluster of an import in the order uploaded
+type ClusterImportResponse struct {
+	DryRun  bool                  `json:"dryRun"`
+	Results []ClusterImportResult `json:"results"`
+	// Created is the number of clusters imported, they are connected after the next restart
+	Created int `json:"created"`
+}
+
+// WithClusterImport enables importing clusters into the store by the admins of the policy.
+// Tokens must be references to secrets of the resolver allowed by the policy, they are resolved
+// to check them but never stored.
+func WithClusterImport(store ClusterStore, resolver SecretResolver, policy ClusterImportPolicy, auditLog audit.Log) ClusterOption {
+	return func(h *ClusterHandler) {
+		h.store = store
+		h.secrets = resolver
+		h.importPolicy = policy
+		h.auditLog = auditLog
+	}
+}
+
+// handleImport imports a list of clusters
+// @Summary Import clusters
+// @Description Register clusters from a YAML or JSON list, either a list of clusters or an object with a clusters list. Only cluster import admins may import clusters. Every cluster is validated on its own: valid clusters are stored and connected after the next restart, the others are reported. With dryRun=true nothing is stored. Tokens must be references to secrets under the allowed prefixes, e.g. vault://armada/prod#token, and can't be combined with tls.insecureSkipVerify.
+// @Tags clusters
+// @Accept json
+// @Accept application/yaml
+// @Produce json
+// @Param request body []ImportCluster true "Clusters, at most 500"
+// @Param dryRun query bool false "Only validate the clusters"
+// @Success 200 {object} ClusterImportResponse
+// @Failure 400 {string} string "Invalid cluster list"
+// @Failure 403 {string} string "Not a cluster import admin"
+// @Failure 404 {string} string "Importing clusters is not enabled"
+// @Router /api/clusters/import [post]
+func (h *ClusterHandler) handleImport(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		http.Error(w, "Importing clusters is not enabled", http.StatusNotFound)
+		return
+	}
+	if user, _ := auth.UserFromContext(r.Context()); !user.IsAny(h.importPolicy.Admins) {
+		http.Error(w, "Only cluster import admins may import clusters", http.StatusForbidden)
+		return
+	}
+	dryRun := false
+	if raw := r.URL.Query().Get("dryRun"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			http.Error(w, "Invalid dryRun: use true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	entries, err := decodeClusterImport(http.MaxBytesReader(w, r.Body, maxClusterImportBytes))
+	if err != nil {
+		http.Error(w, "Invalid cluster list: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := ClusterImportResponse{DryRun: dryRun, Results: make([]ClusterImportResult, len(entries))}
+	seen := make(map[string]bool, len(entries))
+	for i, raw := range entries {
+		result := h.importCluster(r, raw, seen, dryRun)
+		if result.Status == ClusterImportCreated {
+			response.Created++
+		}
+		response.Results[i] = result
+	}
+	if response.Created > 0 {
+		h.logger.Info("Imported clusters, they are connected after the next restart",
+			zap.Int("created", response.Created),
+			zap.Int("uploaded", len(entries)),
+			zap.String("user", auth.UserName(r.Context())))
+	}
+
+	chix.NewRender(w).JSON(response)
+}
+
+// decodeClusterImport returns the entries of an uploaded cluster list. YAML is a superset of
+// JSON, so both are decoded as YAML and every entry is kept as JSON to be decoded on its own.
+func decodeClusterImport(r io.Reader) ([]json.RawMessage, error) {
+	var document any
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no clusters")
+		}
+		return nil, err
+	}
+	if object, ok := document.(map[string]any); ok {
+		document = object["clusters"]
+	}
+	list, ok := document.([]any)
+	switch {
+	case !ok:
+		return nil, errors.New("expected a list of clusters or an object with a clusters list")
+	case len(list) == 0:
+		return nil, errors.New("no clusters")
+	case len(list) > maxClusterImports:
+		return nil, fmt.Errorf("at most %d clusters can be imported at once, got %d", maxClusterImports, len(list))
+	}
+	entries := make([]json.RawMessage, len(list))
+	for i, entry := range list {
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %d: %w", i+1, err)
+		}
+		entries[i] = raw
+	}
+	return entries, nil
+}
+
+// importCluster validates and, unless it is a dry run, stores a cluster of an import
+func (h *ClusterHandler) importCluster(r *http.Request, raw json.RawMessage, seen map[string]bool, dryRun bool) ClusterImportResult {
+	var entry ImportCluster
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entry); err != nil {
+		// The name helps finding the entry, if it can be told
+		var named struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(raw, &named)
+		return ClusterImportResult{Name: named.Name, Status: ClusterImportInvalid, Error: err.Error()}
+	}
+	result := ClusterImportResult{Name: entry.Name}
+
+	if seen[entry.Name] {
+		result.Status, result.Error = ClusterImportExists, "listed more than once"
+		return result
+	}
+	seen[entry.Name] = true
+	if h.clusterExists(entry.Name) {
+		result.Status, result.Error = ClusterImportExists, cluster.ErrClusterExists.Error()
+		return result
+	}
+
+	d := cluster.Definition{
+		Name:       entry.Name,
+		Seeds:      entry.Seeds,
+		TLS:        entry.TLS,
+		Token:      entry.Token,
+		Defaults:   entry.Defaults,
+		Tags:       entry.Tags,
+		ImportedBy: auth.UserName(r.Context()),
+		ImportedAt: time.Now().UTC(),
+	}
+	if err := h.checkImport(r.Context(), d); err != nil {
+		result.Status, result.Error = ClusterImportInvalid, err.Error()
+		return result
+	}
+	if dryRun {
+		result.Status = ClusterImportValid
+		return result
+	}
+
+	err := h.store.Create(d)
+	h.recordAudit(r, "cluster.import", "clusters/"+d.Name, err, map[string]string{"seeds": fmt.Sprint(d.Seeds)})
+	switch {
+	case errors.Is(err, cluster.ErrClusterExists):
+		result.Status, result.Error = ClusterImportExists, err.Error()
+	case err != nil:
+		if !errors.Is(err, cluster.ErrInvalid) {
+			h.logger.Error("Failed to store imported cluster", zap.Error(err), zap.String("cluster", d.Name))
+		}
+		result.Status, result.Error = ClusterImportInvalid, err.Error()
+	default:
+		result.Status = ClusterImportCreated
+	}
+	return result
+}
+
+// clusterExists reports whether a cluster of the name is registered or was imported before,
+// imported clusters are only registered after the next restart
+func (h *ClusterHandler) clusterExists(name string) bool {
+	if _, err := h.registry.Get(name); err == nil {
+		return true
+	}
+	_, err := h.store.Get(name)
+	return err == nil
+}
+
+// checkImport checks a cluster beyond its definition: the CA file must hold certificates on this
+// host and the token must be an allowed reference to a secret that resolves. The token is only
+// sent to servers whose certificates are verified.
+func (h *ClusterHandler) checkImport(ctx context.Context, d cluster.Definition) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if d.TLS.CAFile != "" && !readableCAFile(d.TLS.CAFile) {
+		// Why the file can't be used isn't reported, it would tell which files exist on this host
+		return fmt.Errorf("%w %q: tls.caFile must be a readable file of PEM certificates", cluster.ErrInvalid, d.Name)
+	}
+	if d.Token != "" {
+		if len(h.importPolicy.Tokens) == 0 {
+			return fmt.Errorf("%w %q: no token references are allowed for imported clusters", cluster.ErrInvalid, d.Name)
+		}
+		if h.secrets == nil || !h.secrets.IsReference(d.Token) || !h.importPolicy.AllowsToken(d.Token) {
+			return fmt.Errorf("%w %q: the token must be a reference to a secret starting with one of %s", cluster.ErrInvalid,
+				d.Name, strings.Join(h.importPolicy.Tokens, ", "))
+		}
+		if d.TLS.InsecureSkipVerify {
+			return fmt.Errorf("%w %q: a token can't be sent to servers with tls.insecureSkipVerify", cluster.ErrInvalid, d.Name)
+		}
+		if _, err := h.secrets.Resolve(ctx, d.Token); err != nil {
+			return fmt.Errorf("%w %q: the token can't be resolved: %v", cluster.ErrInvalid, d.Name, err)
+		}
+	}
+	return nil
+}
+
+// readableCAFile reports whether the file holds PEM certificates
+func readableCAFile(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && x509.NewCertPool().AppendCertsFromPEM(data)
+}
+
+// recordAudit appends an entry for an imported cluster to the audit log.
+// Failures to write the audit log are logged but don't fail the import.
+func (h *ClusterHandler) recordAudit(r *http.Request, action, resource string, opErr error, details map[string]string) {
+	if h.auditLog == nil {
+		return
+	}
+	entry := audit.Entry{
+		User:     auth.UserName(r.Context()),
+		Action:   action,
+		Resource: resource,
+		Outcome:  audit.OutcomeSuccess,
+		Details:  details,
+	}
+	if opErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = opErr.Error()
+	}
+	if _, err := h.auditLog.Record(r.Context(), entry); err != nil {
+		h.logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", action))
+	}
+}
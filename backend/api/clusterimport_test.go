@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/secrets"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// importAdmin is the user who may import clusters in the tests
+var importAdmin = auth.User{Name: "alice", Method: auth.MethodOIDC}
+
+// newClusterImportTestRouter creates a router importing clusters next to the "prod" cluster.
+// The secrets of the test and file schemes resolve unless their key is missing, tokens may only
+// refer to test://armada/.
+func newClusterImportTestRouter(t *testing.T) (chi.Router, *cluster.Store, *audit.MemoryLog) {
+	t.Helper()
+	registry := cluster.NewRegistry()
+	if err := registry.Register(cluster.Cluster{Name: "prod", Seeds: []string{"http://armada:5001"}}); err != nil {
+		t.Fatal(err)
+	}
+	store := cluster.NewStore(metadata.NewMemoryStore())
+	resolve := secrets.ProviderFunc(func(_ context.Context, _, key string) (string, error) {
+		if key == "missing" {
+			return "", errors.New("no such secret")
+		}
+		return "token", nil
+	})
+	resolver := secrets.NewResolver(0, secrets.WithProvider("test", resolve), secrets.WithProvider("file", resolve))
+	auditLog := audit.NewMemoryLog(10)
+	policy := ClusterImportPolicy{Admins: []auth.Principal{importAdmin.Principal()}, Tokens: []string{"test://armada/"}}
+
+	r := chi.NewRouter()
+	NewClusterHandler(registry, zap.NewNop(), WithClusterImport(store, resolver, policy, auditLog)).RegisterRoutes(r)
+	return r, store, auditLog
+}
+
+func importClusters(t *testing.T, r chi.Router, target, body string) ClusterImportResponse {
+	t.Helper()
+	req := httptest.NewRequest("POST", target, strings.NewReader(body))
+	req = req.WithContext(auth.WithUser(req.Context(), importAdmin))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var response ClusterImportResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	return response
+}
+
+// statuses returns the status of every result
+func statuses(response ClusterImportResponse) []string {
+	var s []string
+	for _, result := range response.Results {
+		s = append(s, result.Name+"="+result.Status)
+	}
+	return s
+}
+
+func TestHandleImportClusters(t *testing.T) {
+	r, store, auditLog := newClusterImportTestRouter(t)
+
+	body := `
+clusters:
+  - name: staging
+    seeds: [http://staging:5001]
+    defaults:
+      table: users
+      keyPrefixes: [user/]
+  - name: prod-eu
+    seeds: [https://prod-eu-1:5001, https://prod-eu-2:5001]
+    token: test://armada/prod-eu#token
+  - name: prod
+    seeds: [http://armada:5001]
+  - name: staging
+    seeds: [http://staging:5001]
+  - name: broken
+    seeds: [ftp://broken]
+  - name: unresolved
+    seeds: [https://unresolved:5001]
+    token: test://armada/unresolved#missing
+  - name: plain
+    seeds: [https://plain:5001]
+    token: secret-token
+  - name: typo
+    seed: [http://typo:5001]
+  - name: local-file
+    seeds: [https://evil:5001]
+    token: file:///etc/armada/token
+  - name: other-path
+    seeds: [https://other:5001]
+    token: test://other/prod#token
+  - name: parent
+    seeds: [https://parent:5001]
+    token: test://armada/../other#token
+  - name: unverified
+    seeds: [https://unverified:5001]
+    tls:
+      insecureSkipVerify: true
+    token: test://armada/unverified#token
+  - name: missing-ca
+    seeds: [https://missing-ca:5001]
+    tls:
+      caFile: /nonexistent/ca.pem
+`
+	want := []string{"staging=valid", "prod-eu=valid", "prod=exists", "staging=exists", "broken=invalid",
+		"unresolved=invalid", "plain=invalid", "typo=invalid", "local-file=invalid", "other-path=invalid",
+		"parent=invalid", "unverified=invalid", "missing-ca=invalid"}
+
+	// A dry run validates without storing
+	response := importClusters(t, r, "/api/clusters/import?dryRun=true", body)
+	if got := statuses(response); !response.DryRun || response.Created != 0 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected dry run: %+v", response)
+	}
+	if definitions, _ := store.List(); len(definitions) != 0 {
+		t.Fatalf("dry run stored clusters: %+v", definitions)
+	}
+	if !strings.Contains(response.Results[6].Error, "must be a reference to a secret") {
+		t.Errorf("unexpected error of plain token: %q", response.Results[6].Error)
+	}
+	if !strings.Contains(response.Results[11].Error, "insecureSkipVerify") {
+		t.Errorf("unexpected error of unverified token: %q", response.Results[11].Error)
+	}
+	if strings.Contains(response.Results[12].Error, "no such file") {
+		t.Errorf("the error of the CA file tells whether it exists: %q", response.Results[12].Error)
+	}
+
+	want[0], want[1] = "staging=created", "prod-eu=created"
+	response = importClusters(t, r, "/api/clusters/import", body)
+	if got := statuses(response); response.DryRun || response.Created != 2 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected import: %+v", response)
+	}
+	staging, err := store.Get("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if staging.Defaults.Table != "users" || !reflect.DeepEqual(staging.Defaults.KeyPrefixes, []string{"user/"}) {
+		t.Errorf("unexpected defaults of staging: %+v", staging.Defaults)
+	}
+	prodEU, err := store.Get("prod-eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the reference to the token is stored
+	if prodEU.Token != "test://armada/prod-eu#token" || len(prodEU.Seeds) != 2 {
+		t.Errorf("unexpected prod-eu: %+v", prodEU)
+	}
+	entries, err := auditLog.List(context.Background(), audit.Query{Action: "cluster.import"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 audit entries, got %d", len(entries))
+	}
+
+	// Clusters imported before are reported until they are registered by a restart
+	response = importClusters(t, r, "/api/clusters/import", `[{"name": "staging", "seeds": ["http://staging:5001"]}]`)
+	if got := statuses(response); !reflect.DeepEqual(got, []string{"staging=exists"}) {
+		t.Errorf("unexpected import of JSON list: %+v", response)
+	}
+}
+
+func TestHandleImportClustersInvalid(t *testing.T) {
+	r, _, _ := newClusterImportTestRouter(t)
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{name: "Empty", target: "/api/clusters/import"},
+		{name: "EmptyList", target: "/api/clusters/import", body: "[]"},
+		{name: "NotAList", target: "/api/clusters/import", body: `{"name": "staging"}`},
+		{name: "InvalidYAML", target: "/api/clusters/import", body: "clusters: ["},
+		{name: "InvalidDryRun", target: "/api/clusters/import?dryRun=maybe", body: `[{"name": "staging"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+			req = req.WithContext(auth.WithUser(req.Context(), importAdmin))
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+			}
+		})
+	}
+
+	// Only import admins may import clusters, users of other methods named like them included
+	for _, user := range []auth.User{{Name: "alice", Method: auth.MethodBasic}, {Name: "bob", Method: auth.MethodOIDC}, {}} {
+		req := httptest.NewRequest("POST", "/api/clusters/import", strings.NewReader(`[{"name": "staging"}]`))
+		if user.Name != "" {
+			req = req.WithContext(auth.WithUser(req.Context(), user))
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden {
+			t.Errorf("handler returned wrong status code for %+v: got %v want %v", user, rr.Code, http.StatusForbidden)
+		}
+	}
+
+	// Without a store importing is disabled
+	r = chi.NewRouter()
+	NewClusterHandler(cluster.NewRegistry(), zap.NewNop()).RegisterRoutes(r)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/clusters/import", strings.NewReader("[]")))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// ClusterRegistry is the interface of the cluster registry used by the cluster API.
+type ClusterRegistry interface {
+	// List returns all registered clusters sorted by name.
+	List() []cluster.Cluster
+
+	// Get returns the cluster with the given name, or cluster.ErrClusterNotFound.
+	Get(name string) (cluster.Cluster, error)
+
+	// SetDefaults replaces the defaults of a cluster.
+	// It returns cluster.ErrClusterNotFound if the cluster is not registered.
+	SetDefaults(name string, defaults cluster.Defaults) error
+
+	// SetTags replaces the tags of a cluster.
+	// It returns cluster.ErrClusterNotFound if the cluster is not registered.
+	SetTags(name string, tags cluster.Tags) error
+}
+
+// ClusterParam is the query parameter selecting the cluster an API request is served by
+const ClusterParam = "cluster"
+
+// TagsParam is the query parameter filtering clusters by their tags, as comma separated key=value
+// pairs that must all match, e.g. tags=env=prod,region=eu
+const TagsParam = "tags"
+
+// tagFilter returns the tags clusters must have to be listed, nil if the request doesn't filter them
+func tagFilter(r *http.Request) (cluster.Tags, error) {
+	var values []string
+	for _, param := range r.URL.Query()[TagsParam] {
+		for _, v := range strings.Split(param, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return cluster.ParseTags(values)
+}
+
+// ClustersResponse represents the response for the clusters API endpoint
+type ClustersResponse struct {
+	Clusters []cluster.Cluster `json:"clusters"`
+	// Default is the cluster serving requests without the cluster parameter
+	Default string `json:"default,omitempty"`
+}
+
+// ClusterHandler serves the registered clusters and their defaults
+type ClusterHandler struct {
+	registry ClusterRegistry
+	logger   *zap.Logger
+	// defaultCluster is the name of the cluster serving requests without the cluster parameter
+	defaultCluster string
+	// store keeps the imported clusters, importing is disabled if it is nil
+	store        ClusterStore
+	secrets      SecretResolver
+	importPolicy ClusterImportPolicy
+	auditLog     audit.Log
+}
+
+// ClusterOption configures optional behaviour of the ClusterHandler
+type ClusterOption func(*ClusterHandler)
+
+// WithDefaultCluster reports the cluster serving requests without the cluster parameter
+func WithDefaultCluster(name string) ClusterOption {
+	return func(h *ClusterHandler) {
+		h.defaultCluster = name
+	}
+}
+
+// NewClusterHandler creates a new cluster API handler
+func NewClusterHandler(registry ClusterRegistry, logger *zap.Logger, opts ...ClusterOption) *ClusterHandler {
+	h := &ClusterHandler{
+		registry: registry,
+		logger:   logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// SelectCluster serves API requests naming another than the default cluster in the cluster
+// parameter with the routes of that cluster, e.g. /api/tables?cluster=staging lists the tables
+// of staging. Requests without the parameter, and requests to routes the console serves for all
+// clusters alike, e.g. metrics or the audit log, are passed to next. Requests naming an unknown
+// cluster are answered with 404 Not Found.
+func SelectCluster(defaultCluster string, clusters map[string]chi.Router) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := r.URL.Query().Get(ClusterParam)
+			if name == "" || name == defaultCluster || !strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			routes, ok := clusters[name]
+			if !ok {
+				http.Error(w, "Cluster not found", http.StatusNotFound)
+				return
+			}
+			if !routes.Match(chi.NewRouteContext(), r.Method, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// The cluster's router routes the request from scratch
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()))
+			routes.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RegisterRoutes registers the cluster routes under /api/clusters
+func (h *ClusterHandler) RegisterRoutes(r chi.Router) {
+	clustersRouter := chi.NewRouter()
+	clustersRouter.Get("/", h.handleClusters)
+	clustersRouter.Post("/import", h.handleImport)
+	clustersRouter.Get("/{name}", h.handleGetCluster)
+	clustersRouter.Put("/{name}/defaults", h.handlePutDefaults)
+	clustersRouter.Put("/{name}/tags", h.handlePutTags)
+	r.Mount("/api/clusters", clustersRouter)
+}
+
+// handleClusters lists all clusters with their defaults
+// @Summary List clusters
+// @Description List the registered clusters; API requests select one with the cluster parameter, e.g. /api/tables?cluster=staging
+// @Tags clusters
+// @Produce json
+// @Param tags query string false "Only list clusters with all of these tags, e.g. env=prod,region=eu"
+// @Success 200 {object} ClustersResponse
+// @Failure 400 {string} string "Invalid tags"
+// @Router /api/clusters [get]
+func (h *ClusterHandler) handleClusters(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	filter, err := tagFilter(r)
+	if err != nil {
+		http.Error(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	clusters := slices.DeleteFunc(h.registry.List(), func(c cluster.Cluster) bool {
+		return !c.Tags.Matches(filter)
+	})
+	render.JSON(ClustersResponse{Clusters: clusters, Default: h.defaultCluster})
+}
+
+// handleGetCluster returns a single cluster with its defaults
+// @Summary Get cluster
+// @Tags clusters
+// @Produce json
+// @Param name path string true "Cluster name"
+// @Success 200 {object} cluster.Cluster
+// @Failure 404 {string} string "Cluster not found"
+// @Router /api/clusters/{name} [get]
+func (h *ClusterHandler) handleGetCluster(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	name := chi.URLParam(r, "name")
+
+	c, err := h.registry.Get(name)
+	if errors.Is(err, cluster.ErrClusterNotFound) {
+		http.Error(w, "Cluster not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get cluster", zap.Error(err), zap.String("cluster", name))
+		http.Error(w, "Failed to get cluster", http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(c)
+}
+
+// handlePutDefaults replaces the defaults of a cluster
+// @Summary Set cluster defaults
+// @Description Replace the table and key prefixes the UI opens first for the cluster
+// @Tags clusters
+// @Accept json
+// @Produce json
+// @Param name path string true "Cluster name"
+// @Param request body cluster.Defaults true "Defaults"
+// @Success 200 {object} cluster.Defaults
+// @Failure 400 {string} string "Invalid defaults"
+// @Failure 404 {string} string "Cluster not found"
+// @Router /api/clusters/{name}/defaults [put]
+func (h *ClusterHandler) handlePutDefaults(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	name := chi.URLParam(r, "name")
+
+	var defaults cluster.Defaults
+	if err := json.NewDecoder(r.Body).Decode(&defaults); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.registry.SetDefaults(name, defaults)
+	switch {
+	case errors.Is(err, cluster.ErrClusterNotFound):
+		http.Error(w, "Cluster not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "Invalid defaults: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("Updated cluster defaults", zap.String("cluster", name),
+		zap.String("table", defaults.Table), zap.Strings("keyPrefixes", defaults.KeyPrefixes))
+	render.JSON(defaults)
+}
+
+// handlePutTags replaces the tags of a cluster
+// @Summary Set cluster tags
+// @Description Replace the tags of the cluster, e.g. {"env": "prod", "region": "eu"}. Tags filter the fleet and are added as labels to the metrics scraped from then on. Tags set here last until the next restart, configured tags are set again then.
+// @Tags clusters
+// @Accept json
+// @Produce json
+// @Param name path string true "Cluster name"
+// @Param request body cluster.Tags true "Tags"
+// @Success 200 {object} cluster.Tags
+// @Failure 400 {string} string "Invalid tags"
+// @Failure 404 {string} string "Cluster not found"
+// @Router /api/clusters/{name}/tags [put]
+func (h *ClusterHandler) handlePutTags(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	name := chi.URLParam(r, "name")
+
+	var tags cluster.Tags
+	if err := json.NewDecoder(r.Body).Decode(&tags); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.registry.SetTags(name, tags)
+	switch {
+	case errors.Is(err, cluster.ErrClusterNotFound):
+		http.Error(w, "Cluster not found", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("Updated cluster tags", zap.String("cluster", name), zap.Any("tags", tags))
+	render.JSON(tags)
+}
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// newClusterTestRouter creates a router serving a registry with a single "prod" cluster
+func newClusterTestRouter(t *testing.T) (chi.Router, *cluster.Registry) {
+	t.Helper()
+	registry := cluster.NewRegistry()
+	err := registry.Register(cluster.Cluster{
+		Name:     "prod",
+		Seeds:    []string{"http://armada:5001"},
+		Defaults: cluster.Defaults{Table: "users", KeyPrefixes: []string{"user/"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	NewClusterHandler(registry, zap.NewNop(), WithDefaultCluster("prod")).RegisterRoutes(r)
+	return r, registry
+}
+
+func TestHandleClusters(t *testing.T) {
+	r, _ := newClusterTestRouter(t)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/clusters", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var response ClustersResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(response.Clusters) != 1 {
+		t.Fatalf("Expected 1 cluster, got %d", len(response.Clusters))
+	}
+	if response.Default != "prod" {
+		t.Errorf("Expected default cluster prod, got %q", response.Default)
+	}
+	defaults := response.Clusters[0].Defaults
+	if defaults.Table != "users" || len(defaults.KeyPrefixes) != 1 || defaults.KeyPrefixes[0] != "user/" {
+		t.Errorf("unexpected cluster defaults: %+v", defaults)
+	}
+}
+
+func TestHandleGetCluster(t *testing.T) {
+	r, _ := newClusterTestRouter(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{name: "Existing", path: "/api/clusters/prod", wantStatus: http.StatusOK},
+		{name: "Missing", path: "/api/clusters/staging", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.wantStatus {
+				t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandlePutClusterDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Valid", path: "/api/clusters/prod/defaults", body: `{"table":"orders","keyPrefixes":["eu/"]}`, wantStatus: http.StatusOK},
+		{name: "Clear", path: "/api/clusters/prod/defaults", body: `{}`, wantStatus: http.StatusOK},
+		{name: "MissingCluster", path: "/api/clusters/staging/defaults", body: `{}`, wantStatus: http.StatusNotFound},
+		{name: "InvalidBody", path: "/api/clusters/prod/defaults", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "EmptyPrefix", path: "/api/clusters/prod/defaults", body: `{"keyPrefixes":[""]}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, registry := newClusterTestRouter(t)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("PUT", tt.path, strings.NewReader(tt.body)))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.name != "Valid" {
+				return
+			}
+
+			c, err := registry.Get("prod")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Defaults.Table != "orders" || len(c.Defaults.KeyPrefixes) != 1 || c.Defaults.KeyPrefixes[0] != "eu/" {
+				t.Errorf("defaults were not stored: %+v", c.Defaults)
+			}
+		})
+	}
+}
+
+func TestHandlePutClusterTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Valid", path: "/api/clusters/prod/tags", body: `{"env":"prod","region":"eu"}`, wantStatus: http.StatusOK},
+		{name: "Clear", path: "/api/clusters/prod/tags", body: `{}`, wantStatus: http.StatusOK},
+		{name: "MissingCluster", path: "/api/clusters/staging/tags", body: `{}`, wantStatus: http.StatusNotFound},
+		{name: "InvalidBody", path: "/api/clusters/prod/tags", body: `["env"]`, wantStatus: http.StatusBadRequest},
+		{name: "ReservedKey", path: "/api/clusters/prod/tags", body: `{"cluster":"prod"}`, wantStatus: http.StatusBadRequest},
+		{name: "EmptyValue", path: "/api/clusters/prod/tags", body: `{"env":""}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, registry := newClusterTestRouter(t)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("PUT", tt.path, strings.NewReader(tt.body)))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.name != "Valid" {
+				return
+			}
+
+			c, err := registry.Get("prod")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Tags["env"] != "prod" || c.Tags["region"] != "eu" {
+				t.Errorf("tags were not stored: %+v", c.Tags)
+			}
+		})
+	}
+}
+
+func TestHandleClustersFilteredByTags(t *testing.T) {
+	r, registry := newClusterTestRouter(t)
+	if err := registry.SetTags("prod", cluster.Tags{"env": "prod", "region": "eu"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(cluster.Cluster{Name: "staging", Tags: cluster.Tags{"env": "staging", "region": "eu"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{query: "", wantStatus: http.StatusOK, want: []string{"prod", "staging"}},
+		{query: "?tags=region=eu", wantStatus: http.StatusOK, want: []string{"prod", "staging"}},
+		{query: "?tags=env=prod,region=eu", wantStatus: http.StatusOK, want: []string{"prod"}},
+		{query: "?tags=env=prod&tags=region=us", wantStatus: http.StatusOK, want: []string{}},
+		{query: "?tags=env", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/clusters"+tt.query, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var response ClustersResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response body: %v", err)
+			}
+			names := []string{}
+			for _, c := range response.Clusters {
+				names = append(names, c.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got clusters %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectCluster(t *testing.T) {
+	// served answers with the name of the router serving the request
+	served := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}
+	}
+	staging := chi.NewRouter()
+	staging.Get("/api/tables/{table}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("staging " + chi.URLParam(r, "table")))
+	})
+
+	r := chi.NewRouter()
+	r.Use(SelectCluster("prod", map[string]chi.Router{"staging": staging}))
+	r.Get("/api/tables/{table}", served("prod"))
+	r.Get("/api/audit", served("console"))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   string
+	}{
+		{"Default", "/api/tables/users", http.StatusOK, "prod"},
+		{"DefaultByName", "/api/tables/users?cluster=prod", http.StatusOK, "prod"},
+		{"Selected", "/api/tables/users?cluster=staging", http.StatusOK, "staging users"},
+		{"ConsoleRoute", "/api/audit?cluster=staging", http.StatusOK, "console"},
+		{"Unknown", "/api/tables/users?cluster=qa", http.StatusNotFound, "Cluster not found\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			if rr.Code != tt.status || rr.Body.String() != tt.want {
+				t.Errorf("Expected %d %q, got %d %q", tt.status, tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
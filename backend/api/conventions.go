@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/conventions"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultConventionsScan is the number of keys examined for a usage summary unless requested otherwise
+	defaultConventionsScan = 1000
+	// maxConventionsScan is the most keys a usage summary examines
+	maxConventionsScan = 10000
+)
+
+// ConventionsHandler serves the well-known key conventions and decodes the keys following them
+type ConventionsHandler struct {
+	registry *conventions.Registry
+	client   ArmadaClient
+	logger   *zap.Logger
+}
+
+// NewConventionsHandler creates a new key conventions API handler reading keys with client
+func NewConventionsHandler(registry *conventions.Registry, client ArmadaClient, logger *zap.Logger) *ConventionsHandler {
+	return &ConventionsHandler{
+		registry: registry,
+		client:   client,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the key convention routes under /api/conventions
+func (h *ConventionsHandler) RegisterRoutes(r chi.Router) {
+	conventionRouter := chi.NewRouter()
+	conventionRouter.Get("/", h.handleList)
+	conventionRouter.Get("/match", h.handleMatch)
+	conventionRouter.Get("/usage", h.handleUsage)
+	conventionRouter.Get("/{name}", h.handleGet)
+	conventionRouter.Put("/{name}", h.handlePut)
+	conventionRouter.Delete("/{name}", h.handleDelete)
+	r.Mount("/api/conventions", conventionRouter)
+}
+
+// handleList returns the conventions
+// @Summary List key conventions
+// @Description List the custom key conventions followed by the built-in ones, in the order keys are matched against them
+// @Tags conventions
+// @Produce json
+// @Success 200 {array} conventions.Convention
+// @Router /api/conventions [get]
+func (h *ConventionsHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.registry.List()
+	if err != nil {
+		h.conventionError(w, err, "Failed to list conventions")
+		return
+	}
+	chix.NewRender(w).JSON(list)
+}
+
+// handleGet returns a single convention
+// @Summary Get key convention
+// @Tags conventions
+// @Produce json
+// @Param name path string true "Convention name"
+// @Success 200 {object} conventions.Convention
+// @Failure 404 {string} string "Convention not found"
+// @Router /api/conventions/{name} [get]
+func (h *ConventionsHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	c, err := h.registry.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		h.conventionError(w, err, "Failed to get convention")
+		return
+	}
+	chix.NewRender(w).JSON(c)
+}
+
+// handlePut creates or replaces a custom convention
+// @Summary Put key convention
+// @Description Create or replace a custom key convention. Its pattern lists the segments of the keys separated by /: literal segments, {field} for any segment, {field:hex} for a hexadecimal number and a final {field...} for the rest of the key. Placeholders in the description are replaced by the decoded fields.
+// @Tags conventions
+// @Accept json
+// @Produce json
+// @Param name path string true "Convention name"
+// @Param request body conventions.Convention true "Convention, its name is taken from the path"
+// @Success 200 {object} conventions.Convention
+// @Failure 400 {string} string "Invalid convention"
+// @Failure 409 {string} string "Convention is built in"
+// @Router /api/conventions/{name} [put]
+func (h *ConventionsHandler) handlePut(w http.ResponseWriter, r *http.Request) {
+	var c conventions.Convention
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	c.Name = chi.URLParam(r, "name")
+	c, err := h.registry.Put(c)
+	if err != nil {
+		h.conventionError(w, err, "Failed to store convention")
+		return
+	}
+	h.logger.Info("Stored key convention", zap.String("name", c.Name), zap.String("pattern", c.Pattern),
+		zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(c)
+}
+
+// handleDelete removes a custom convention
+// @Summary Delete key convention
+// @Tags conventions
+// @Produce json
+// @Param name path string true "Convention name"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Convention not found"
+// @Failure 409 {string} string "Convention is built in"
+// @Router /api/conventions/{name} [delete]
+func (h *ConventionsHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := h.registry.Delete(name); err != nil {
+		h.conventionError(w, err, "Failed to delete convention")
+		return
+	}
+	h.logger.Info("Deleted key convention", zap.String("name", name), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// handleMatch decodes a single key
+// @Summary Match key against conventions
+// @Description Decode a key with the first convention it follows, e.g. the lock and lease of an etcd lock key
+// @Tags conventions
+// @Produce json
+// @Param table query string false "Table of the key, for conventions restricted to a table"
+// @Param key query string true "Key"
+// @Success 200 {object} conventions.Match
+// @Failure 404 {string} string "Key follows no convention"
+// @Router /api/conventions/match [get]
+func (h *ConventionsHandler) handleMatch(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "key is required", http.StatusBadRequest)
+		return
+	}
+	matcher, err := h.registry.Matcher()
+	if err != nil {
+		h.conventionError(w, err, "Failed to match key")
+		return
+	}
+	match := matcher.Match(r.URL.Query().Get("table"), key)
+	if match == nil {
+		http.Error(w, "Key follows no convention", http.StatusNotFound)
+		return
+	}
+	chix.NewRender(w).JSON(match)
+}
+
+// handleUsage summarizes the conventions the keys of a table follow
+// @Summary Get key convention usage
+// @Description Scan the first keys of a table and count the keys following each convention, with decoded samples, to tell which infrastructure components stored them
+// @Tags conventions
+// @Produce json
+// @Param table query string true "Table"
+// @Param prefix query string false "Only scan keys with this prefix"
+// @Param limit query int false "Number of keys to scan, 1000 by default and at most 10000"
+// @Success 200 {object} conventions.Summary
+// @Failure 400 {string} string "Invalid parameters"
+// @Failure 404 {string} string "Table not found"
+// @Router /api/conventions/usage [get]
+func (h *ConventionsHandler) handleUsage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	table := query.Get("table")
+	if table == "" {
+		http.Error(w, "table is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultConventionsScan
+	if raw := query.Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxConventionsScan {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxConventionsScan), http.StatusBadRequest)
+			return
+		}
+	}
+	matcher, err := h.registry.Matcher()
+	if err != nil {
+		h.conventionError(w, err, "Failed to summarize conventions")
+		return
+	}
+
+	// One more key than scanned tells whether the table has more
+	pairs, err := h.client.GetKeyValuePairs(r.Context(), table, query.Get("prefix"), "", "", limit+1)
+	if errors.Is(err, armada.ErrTableNotFound) {
+		http.Error(w, "Table not found: "+table, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.conventionError(w, err, "Failed to read keys")
+		return
+	}
+	truncated := len(pairs) > limit
+	if truncated {
+		pairs = pairs[:limit]
+	}
+	keys := make([]string, len(pairs))
+	for i, pair := range pairs {
+		keys[i] = pair.Key
+	}
+	summary := matcher.Summarize(table, keys)
+	summary.Truncated = truncated
+
+	chix.NewRender(w).JSON(summary)
+}
+
+// conventionError answers with the status matching an error of the registry. Unexpected
+// errors are logged and answered with the message.
+func (h *ConventionsHandler) conventionError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, conventions.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, conventions.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, conventions.ErrBuiltin):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/conventions"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestConventions(t *testing.T) {
+	client := &tablesClient{mockArmadaClient: &mockArmadaClient{}, tables: map[string]map[string]string{"coordination": {
+		"elections/leader/1f":   "node1",
+		"leases/1f":             "",
+		"locks/migrations/1f":   "node1",
+		"locks/scheduler/2a":    "node2",
+		"settings/retention":    "7d",
+		"workers/node1/running": "true",
+	}}}
+	r := chi.NewRouter()
+	NewConventionsHandler(conventions.NewRegistry(metadata.NewMemoryStore()), client, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/conventions/match?table=coordination&key=locks/migrations/1f", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var match conventions.Match
+	if err := json.Unmarshal(rr.Body.Bytes(), &match); err != nil {
+		t.Fatal(err)
+	}
+	if match.Convention != "etcd-lock" || match.Fields["lease"] != "31" || match.Description != "Lock migrations held by the owner of lease 31" {
+		t.Errorf("Expected the key to be decoded as an etcd lock, got %+v", match)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/conventions/match?key=workers/node1/running", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a key following no convention, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	// Custom conventions decode the keys of other components
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/conventions/workers",
+		strings.NewReader(`{"kind":"other","pattern":"workers/{node}/{state}","component":"job runner","description":"Worker on {node} is {state}"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/conventions/lease", strings.NewReader(`{"kind":"lease","pattern":"l/{id}"}`)))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d when replacing a built-in convention, got %d", http.StatusConflict, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/conventions/broken", strings.NewReader(`{"kind":"other","pattern":"a/{b"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid pattern, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/conventions/usage?table=coordination&limit=5", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var summary conventions.Summary
+	if err := json.Unmarshal(rr.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Scanned != 5 || summary.Matched != 4 || !summary.Truncated {
+		t.Errorf("Expected 4 of 5 scanned keys to match with more keys left, got %+v", summary)
+	}
+	if len(summary.Conventions) != 3 || summary.Conventions[0].Convention != "etcd-lock" || summary.Conventions[0].Keys != 2 {
+		t.Errorf("Expected the etcd locks to be the most common convention, got %+v", summary.Conventions)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/conventions/usage?table=coordination&limit=0", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid limit, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/conventions/workers", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/conventions/workers", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for a deleted convention, got %d", http.StatusNotFound, rr.Code)
+	}
+}
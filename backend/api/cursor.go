@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// Key range scans that exceed their budget answer 200 OK with the keys received so far.
+// The response then sets TruncatedHeader to "true" and CursorHeader to an opaque cursor;
+// passing it as the "cursor" query parameter, with the same filter, continues the scan
+// after the last returned key.
+const (
+	// TruncatedHeader is set to "true" when a scan returned only part of the range
+	TruncatedHeader = "X-Truncated"
+	// CursorHeader carries the cursor continuing a truncated scan
+	CursorHeader = "X-Continuation-Cursor"
+)
+
+// encodeCursor returns the cursor of a scan continuing at key
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeCursor returns the key a scan continues at, it must lie within the scanned range
+func decodeCursor(cursor, prefix, start, end string) (string, bool) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(key) == 0 {
+		return "", false
+	}
+	switch {
+	case prefix != "":
+		return string(key), strings.HasPrefix(string(key), prefix)
+	case start != "":
+		return string(key), string(key) >= start && string(key) < end
+	default:
+		return string(key), true
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHandleGetKeyValueTruncated(t *testing.T) {
+	handler := createTestHandler()
+	client := &mockArmadaClient{
+		kvPairs: []armada.KeyValuePair{{Key: "user/1", Value: "a"}, {Key: "user/2", Value: "b"}},
+		kvErr:   fmt.Errorf("%w after 2 pairs: %w", armada.ErrRangeTruncated, context.DeadlineExceeded),
+	}
+	handler.client = client
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/test?prefix=user/", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get(TruncatedHeader); got != "true" {
+		t.Errorf("%s = %q, want true", TruncatedHeader, got)
+	}
+	var pairs []armada.KeyValuePair
+	if err := json.Unmarshal(rr.Body.Bytes(), &pairs); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(pairs) != 2 {
+		t.Errorf("got %d pairs, want the 2 received before the timeout", len(pairs))
+	}
+
+	// The cursor continues after the last key, within the prefix
+	cursor := rr.Header().Get(CursorHeader)
+	if cursor != encodeCursor("user/2\x00") {
+		t.Errorf("%s = %q, want the cursor of the key after user/2", CursorHeader, cursor)
+	}
+	client.kvErr = nil
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/test?prefix=user/&cursor="+cursor, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if want := [3]string{"", "user/2\x00", "user0"}; client.kvScan != want {
+		t.Errorf("scanned %q, want %q", client.kvScan, want)
+	}
+	if got := rr.Header().Get(TruncatedHeader); got != "" {
+		t.Errorf("complete scan set %s = %q", TruncatedHeader, got)
+	}
+}
+
+func TestHandleGetKeyValueCursor(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		want     int
+		wantScan [3]string
+	}{
+		{name: "AllKeys", query: "cursor=" + encodeCursor("k"), want: http.StatusOK, wantScan: [3]string{"", "k", "\x00"}},
+		{name: "Range", query: "start=a&end=m&cursor=" + encodeCursor("c"), want: http.StatusOK, wantScan: [3]string{"", "c", "m"}},
+		{name: "OutsideRange", query: "start=a&end=m&cursor=" + encodeCursor("x"), want: http.StatusBadRequest},
+		{name: "OutsidePrefix", query: "prefix=user/&cursor=" + encodeCursor("order/1"), want: http.StatusBadRequest},
+		{name: "NotBase64", query: "cursor=!!!", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := createTestHandler()
+			client := &mockArmadaClient{}
+			handler.client = client
+			r := chi.NewRouter()
+			handler.RegisterRoutes(r)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/test?"+tt.query, nil))
+			if rr.Code != tt.want {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && client.kvScan != tt.wantScan {
+				t.Errorf("scanned %q, want %q", client.kvScan, tt.wantScan)
+			}
+		})
+	}
+}
+
+// slowArmadaClient doesn't answer range scans before their deadline
+type slowArmadaClient struct {
+	mockArmadaClient
+}
+
+func (s *slowArmadaClient) GetKeyValuePairs(ctx context.Context, table, prefix, start, end string, limit int) ([]armada.KeyValuePair, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestHandleGetKeyValueTimeout(t *testing.T) {
+	handler := NewHandler(nil, createTestHandler().logger, WithRangeTimeout(10*time.Millisecond))
+	handler.client = &slowArmadaClient{}
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/test", nil))
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusGatewayTimeout)
+	}
+}
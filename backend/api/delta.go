@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRemovedServers bounds the number of removed servers remembered for delta responses
+const maxRemovedServers = 1024
+
+// trackedServer is the last observed state of a server
+type trackedServer struct {
+	fingerprint uint64
+	// changed is the revision in which the server last changed
+	changed uint64
+}
+
+// statusTracker assigns revisions to observed cluster states so that pollers can
+// ask for the servers that changed since a revision they have already seen.
+type statusTracker struct {
+	mu sync.Mutex
+	// epoch distinguishes revisions of different console processes
+	epoch    string
+	revision uint64
+	// floor is the oldest revision deltas can be computed from
+	floor   uint64
+	servers map[string]trackedServer
+	// removed maps servers that left the cluster to the revision they were removed in
+	removed map[string]uint64
+}
+
+// newStatusTracker creates a tracker with no observed state
+func newStatusTracker() *statusTracker {
+	return &statusTracker{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		servers: make(map[string]trackedServer),
+		removed: make(map[string]uint64),
+	}
+}
+
+// apply records the status and returns the response for a client that last saw
+// the revision since. If since is empty, unknown or too old, the full status is returned.
+// The given status is shared and not modified.
+func (t *statusTracker) apply(status StatusResponse, since string) StatusResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.observe(status)
+	status.Revision = t.token()
+
+	base, ok := t.parseToken(since)
+	if !ok {
+		return status
+	}
+
+	changed := make([]ServerStatus, 0)
+	for _, server := range status.Servers {
+		if t.servers[server.ID].changed > base {
+			changed = append(changed, server)
+		}
+	}
+	status.Servers = changed
+	status.Delta = true
+	for id, rev := range t.removed {
+		if rev > base {
+			status.Removed = append(status.Removed, id)
+		}
+	}
+	return status
+}
+
+// observe bumps the revision if any server changed, appeared or disappeared
+func (t *statusTracker) observe(status StatusResponse) {
+	next := t.revision + 1
+	changed := false
+
+	seen := make(map[string]bool, len(status.Servers))
+	for _, server := range status.Servers {
+		seen[server.ID] = true
+		fp := fingerprint(server)
+		if tracked, ok := t.servers[server.ID]; ok && tracked.fingerprint == fp {
+			continue
+		}
+		t.servers[server.ID] = trackedServer{fingerprint: fp, changed: next}
+		delete(t.removed, server.ID)
+		changed = true
+	}
+	for id := range t.servers {
+		if !seen[id] {
+			delete(t.servers, id)
+			t.removed[id] = next
+			changed = true
+		}
+	}
+
+	if changed {
+		t.revision = next
+	}
+	t.pruneRemoved()
+}
+
+// pruneRemoved forgets the oldest removals once too many are remembered.
+// Clients with older revisions get a full response afterwards.
+func (t *statusTracker) pruneRemoved() {
+	for len(t.removed) > maxRemovedServers {
+		var oldestID string
+		var oldest uint64
+		for id, rev := range t.removed {
+			if oldestID == "" || rev < oldest {
+				oldestID, oldest = id, rev
+			}
+		}
+		delete(t.removed, oldestID)
+		t.floor = max(t.floor, oldest)
+	}
+}
+
+// token returns the opaque token of the current revision
+func (t *statusTracker) token() string {
+	return t.epoch + "-" + strconv.FormatUint(t.revision, 10)
+}
+
+// parseToken returns the revision of a token issued by this tracker.
+// Tokens may be quoted like entity tags.
+func (t *statusTracker) parseToken(token string) (uint64, bool) {
+	token = strings.Trim(strings.TrimPrefix(token, "W/"), `"`)
+	epoch, rev, ok := strings.Cut(token, "-")
+	if !ok || epoch != t.epoch {
+		return 0, false
+	}
+	revision, err := strconv.ParseUint(rev, 10, 64)
+	if err != nil || revision > t.revision || revision < t.floor {
+		return 0, false
+	}
+	return revision, true
+}
+
+// fingerprint hashes the JSON representation of v
+func fingerprint(v any) uint64 {
+	h := fnv.New64a()
+	// Encoding plain response structs doesn't fail
+	_ = json.NewEncoder(h).Encode(v)
+	return h.Sum64()
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+func TestStatusTrackerDelta(t *testing.T) {
+	tracker := newStatusTracker()
+	status := StatusResponse{Servers: []ServerStatus{
+		{ID: "node1", Status: "ok"},
+		{ID: "node2", Status: "ok"},
+		{ID: "node3", Status: "ok"},
+	}}
+
+	full := tracker.apply(status, "")
+	if full.Delta || len(full.Servers) != 3 || full.Revision == "" {
+		t.Fatalf("Expected full response with revision, got %+v", full)
+	}
+
+	// Nothing changed
+	unchanged := tracker.apply(status, full.Revision)
+	if !unchanged.Delta || len(unchanged.Servers) != 0 || unchanged.Revision != full.Revision {
+		t.Errorf("Expected empty delta with the same revision, got %+v", unchanged)
+	}
+
+	// node2 changes and node3 leaves the cluster
+	status = StatusResponse{Servers: []ServerStatus{
+		{ID: "node1", Status: "ok"},
+		{ID: "node2", Status: "error", Error: "unreachable"},
+	}}
+	delta := tracker.apply(status, full.Revision)
+	if !delta.Delta || len(delta.Servers) != 1 || delta.Servers[0].ID != "node2" {
+		t.Errorf("Expected delta with node2, got %+v", delta.Servers)
+	}
+	if !slices.Equal(delta.Removed, []string{"node3"}) {
+		t.Errorf("Expected node3 to be removed, got %v", delta.Removed)
+	}
+	if delta.Revision == full.Revision {
+		t.Error("Expected the revision to change")
+	}
+
+	// Asking with the latest revision returns nothing
+	latest := tracker.apply(status, delta.Revision)
+	if len(latest.Servers) != 0 || len(latest.Removed) != 0 {
+		t.Errorf("Expected empty delta, got %+v", latest)
+	}
+
+	// Unknown tokens get the full state
+	for _, token := range []string{"garbage", "other-1", tracker.epoch + "-99"} {
+		if resp := tracker.apply(status, token); resp.Delta || len(resp.Servers) != 2 {
+			t.Errorf("Expected full response for token %q, got %+v", token, resp)
+		}
+	}
+
+	// Tokens may be passed quoted like the ETag header
+	if resp := tracker.apply(status, `"`+delta.Revision+`"`); !resp.Delta {
+		t.Error("Expected quoted token to be accepted")
+	}
+}
+
+func TestHandleStatusSince(t *testing.T) {
+	handler := createTestHandler()
+
+	rr := httptest.NewRecorder()
+	handler.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+	var full StatusResponse
+	if err := json.NewDecoder(rr.Body).Decode(&full); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("ETag") != `"`+full.Revision+`"` {
+		t.Errorf("Expected ETag to carry the revision, got %q", rr.Header().Get("ETag"))
+	}
+
+	handler.client.(*mockArmadaClient).statusResponse = &armada.Status{Status: "ok", Message: "Armada server is busy"}
+	rr = httptest.NewRecorder()
+	handler.handleStatus(rr, httptest.NewRequest("GET", "/api/status?since="+full.Revision, nil))
+	var delta StatusResponse
+	if err := json.NewDecoder(rr.Body).Decode(&delta); err != nil {
+		t.Fatal(err)
+	}
+	if !delta.Delta || len(delta.Servers) != len(full.Servers) {
+		t.Errorf("Expected all servers to have changed, got %+v", delta)
+	}
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/armadakv/console/backend/maintenance"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/runbooks"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// Severities of diagnostic findings
+const (
+	SeverityWarning = "warning"
+)
+
+// clockSkewRemediation are the first steps for servers whose clock is off
+const clockSkewRemediation = "1. Check that NTP, e.g. chronyd or systemd-timesyncd, runs and is synchronized on the node\n" +
+	"2. Check that the node reaches its time servers\n" +
+	"3. Compare the clock of the console's host too, the skew is measured against it"
+
+// ClockSkewSource provides the clock skew measured for each server
+type ClockSkewSource interface {
+	ClockSkews() []metrics.ClockSkew
+}
+
+// AlertEvaluator evaluates alert rules against the stored metrics.
+// The metrics.QueryEngine implements this interface.
+type AlertEvaluator interface {
+	Alerts(ctx context.Context, rules []metrics.AlertRule, ts time.Time) ([]metrics.Alert, error)
+}
+
+// Silencer decides whether a finding is expected, e.g. during a maintenance window.
+// The maintenance.Scheduler implements this interface.
+type Silencer interface {
+	Silenced(check, subject string, t time.Time) (maintenance.Window, bool, error)
+}
+
+// RunbookSource provides the stored runbooks of checks, which replace those the checks ship with.
+// The runbooks.Store implements this interface.
+type RunbookSource interface {
+	List() ([]runbooks.Runbook, error)
+}
+
+// DiagnosticFinding is a problem detected by a diagnostic check
+type DiagnosticFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	// Subject is the server or resource the finding is about
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+	// SilencedBy is the ID of the maintenance window silencing the finding, if any
+	SilencedBy string `json:"silencedBy,omitempty"`
+	// RunbookURL links to the runbook of the check, if any
+	RunbookURL string `json:"runbookUrl,omitempty"`
+	// Remediation are first steps in Markdown, if any
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// DiagnosticsReport summarizes the health checks the console runs against the cluster
+type DiagnosticsReport struct {
+	GeneratedAt time.Time           `json:"generatedAt"`
+	ClockSkew   []metrics.ClockSkew `json:"clockSkew"`
+	// Alerts are the firing alerts of the configured alert rules
+	Alerts   []metrics.Alert     `json:"alerts"`
+	Findings []DiagnosticFinding `json:"findings"`
+	// Silenced are the findings expected during an active maintenance window
+	Silenced []DiagnosticFinding `json:"silenced"`
+}
+
+// DiagnosticsHandler serves the diagnostics report
+type DiagnosticsHandler struct {
+	skews   ClockSkewSource
+	maxSkew time.Duration
+	logger  *zap.Logger
+	// alerts evaluates rules, it may be nil
+	alerts AlertEvaluator
+	rules  []metrics.AlertRule
+	// silencer silences findings, it may be nil
+	silencer Silencer
+	// runbooks replace the runbooks of checks, it may be nil
+	runbooks RunbookSource
+}
+
+// DiagnosticsOption configures optional checks of the DiagnosticsHandler
+type DiagnosticsOption func(*DiagnosticsHandler)
+
+// WithAlertRules reports every firing alert of the rules as a finding
+func WithAlertRules(evaluator AlertEvaluator, rules []metrics.AlertRule) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.alerts = evaluator
+		h.rules = rules
+	}
+}
+
+// WithSilencer moves the findings silenced by the silencer out of the report's findings
+func WithSilencer(silencer Silencer) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.silencer = silencer
+	}
+}
+
+// WithRunbooks reports the stored runbook of a check with its findings and alerts instead of the
+// one the check ships with
+func WithRunbooks(source RunbookSource) DiagnosticsOption {
+	return func(h *DiagnosticsHandler) {
+		h.runbooks = source
+	}
+}
+
+// NewDiagnosticsHandler creates a new diagnostics API handler.
+// Servers whose clock is off by more than maxSkew are reported as findings.
+func NewDiagnosticsHandler(skews ClockSkewSource, maxSkew time.Duration, logger *zap.Logger, opts ...DiagnosticsOption) *DiagnosticsHandler {
+	h := &DiagnosticsHandler{
+		skews:   skews,
+		maxSkew: maxSkew,
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers the diagnostics routes
+func (h *DiagnosticsHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/diagnostics", h.handleReport)
+}
+
+// handleReport returns the diagnostics report
+// @Summary Get diagnostics report
+// @Description Report clock skew, firing alert rules and other findings; findings covered by a maintenance window are listed as silenced. Findings and alerts carry the runbook URL and Markdown remediation of their check, stored runbooks replace those the checks ship with.
+// @Tags diagnostics
+// @Produce json
+// @Success 200 {object} DiagnosticsReport
+// @Router /api/diagnostics [get]
+func (h *DiagnosticsHandler) handleReport(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	render.JSON(h.report(r.Context()))
+}
+
+// report runs all checks
+func (h *DiagnosticsHandler) report(ctx context.Context) DiagnosticsReport {
+	report := DiagnosticsReport{
+		GeneratedAt: time.Now().UTC(),
+		ClockSkew:   h.skews.ClockSkews(),
+		Alerts:      make([]metrics.Alert, 0),
+		Findings:    make([]DiagnosticFinding, 0),
+		Silenced:    make([]DiagnosticFinding, 0),
+	}
+	report.Findings = append(report.Findings, h.checkClockSkew(report.ClockSkew)...)
+	if h.alerts != nil {
+		alerts, err := h.alerts.Alerts(ctx, h.rules, report.GeneratedAt)
+		if err != nil {
+			// A broken rule must not hide the other checks
+			h.logger.Error("Failed to evaluate alert rules", zap.Error(err))
+		}
+		report.Alerts = append(report.Alerts, alerts...)
+		for _, alert := range alerts {
+			report.Findings = append(report.Findings, DiagnosticFinding{
+				Check:       alert.Rule,
+				Severity:    alert.Severity,
+				Subject:     alert.Subject,
+				Message:     alert.Summary,
+				RunbookURL:  alert.RunbookURL,
+				Remediation: alert.Remediation,
+			})
+		}
+	}
+	h.attachRunbooks(&report)
+	h.silence(&report)
+	return report
+}
+
+// attachRunbooks replaces the runbooks of the findings and alerts whose check has a stored runbook
+func (h *DiagnosticsHandler) attachRunbooks(report *DiagnosticsReport) {
+	if h.runbooks == nil {
+		return
+	}
+	list, err := h.runbooks.List()
+	if err != nil {
+		// The findings are still worth reporting with the runbooks they ship with
+		h.logger.Warn("Failed to list runbooks", zap.Error(err))
+		return
+	}
+	stored := make(map[string]runbooks.Runbook, len(list))
+	for _, rb := range list {
+		stored[rb.Check] = rb
+	}
+	for i, finding := range report.Findings {
+		if rb, ok := stored[finding.Check]; ok {
+			report.Findings[i].RunbookURL, report.Findings[i].Remediation = rb.URL, rb.Remediation
+		}
+	}
+	for i, alert := range report.Alerts {
+		if rb, ok := stored[alert.Rule]; ok {
+			report.Alerts[i].RunbookURL, report.Alerts[i].Remediation = rb.URL, rb.Remediation
+		}
+	}
+}
+
+// silence moves the findings silenced by an active maintenance window to the silenced list
+func (h *DiagnosticsHandler) silence(report *DiagnosticsReport) {
+	if h.silencer == nil {
+		return
+	}
+	findings := report.Findings[:0]
+	for _, finding := range report.Findings {
+		window, silenced, err := h.silencer.Silenced(finding.Check, finding.Subject, report.GeneratedAt)
+		if err != nil {
+			// Rather report too much than hide a problem
+			h.logger.Warn("Failed to check maintenance windows", zap.Error(err))
+		}
+		if silenced {
+			finding.SilencedBy = window.ID
+			report.Silenced = append(report.Silenced, finding)
+			continue
+		}
+		findings = append(findings, finding)
+	}
+	report.Findings = findings
+}
+
+// checkClockSkew reports servers whose clock is off, which breaks TTLs and misaligns metrics
+func (h *DiagnosticsHandler) checkClockSkew(skews []metrics.ClockSkew) []DiagnosticFinding {
+	var findings []DiagnosticFinding
+	for _, skew := range skews {
+		if !skew.Exceeds(h.maxSkew) {
+			continue
+		}
+		subject := skew.NodeName
+		if subject == "" {
+			subject = skew.Cluster
+		}
+		direction := "ahead of"
+		if skew.Skew < 0 {
+			direction = "behind"
+		}
+		findings = append(findings, DiagnosticFinding{
+			Check:    "clock-skew",
+			Severity: SeverityWarning,
+			Subject:  subject,
+			Message: fmt.Sprintf("Clock is %s (±%s) %s the console, more than the allowed %s",
+				skew.Skew.Abs().Round(time.Millisecond), skew.Uncertainty.Round(time.Millisecond), direction, h.maxSkew),
+			Remediation: clockSkewRemediation,
+		})
+	}
+	return findings
+}
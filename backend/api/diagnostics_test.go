@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/runbooks"
+	"go.uber.org/zap"
+)
+
+type staticSkews []metrics.ClockSkew
+
+func (s staticSkews) ClockSkews() []metrics.ClockSkew { return s }
+
+func TestDiagnosticsClockSkew(t *testing.T) {
+	skews := staticSkews{
+		{Cluster: "node1:8443", NodeName: "node1", Skew: 100 * time.Millisecond, Uncertainty: 600 * time.Millisecond},
+		{Cluster: "node2:8443", NodeName: "node2", Skew: -5 * time.Second, Uncertainty: 600 * time.Millisecond},
+		// Within the measurement uncertainty of the threshold
+		{Cluster: "node3:8443", Skew: 2500 * time.Millisecond, Uncertainty: 600 * time.Millisecond},
+	}
+	handler := NewDiagnosticsHandler(skews, 2*time.Second, zap.NewNop())
+
+	rr := httptest.NewRecorder()
+	handler.handleReport(rr, httptest.NewRequest("GET", "/api/diagnostics", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var report DiagnosticsReport
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.ClockSkew) != 3 {
+		t.Errorf("Expected 3 clock skew measurements, got %d", len(report.ClockSkew))
+	}
+	if len(report.Findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %+v", report.Findings)
+	}
+	finding := report.Findings[0]
+	if finding.Check != "clock-skew" || finding.Subject != "node2" || !strings.Contains(finding.Message, "5s (±600ms) behind") {
+		t.Errorf("Unexpected finding: %+v", finding)
+	}
+}
+
+type staticAlerts []metrics.Alert
+
+func (s staticAlerts) Alerts(_ context.Context, _ []metrics.AlertRule, _ time.Time) ([]metrics.Alert, error) {
+	return s, nil
+}
+
+func TestDiagnosticsAlerts(t *testing.T) {
+	alerts := staticAlerts{
+		{Rule: "leader-flapping", Severity: SeverityWarning, Subject: "users", Summary: "Leadership of table users changed 5 times"},
+	}
+	handler := NewDiagnosticsHandler(staticSkews{}, 2*time.Second, zap.NewNop(), WithAlertRules(alerts, metrics.DefaultAlertRules))
+
+	rr := httptest.NewRecorder()
+	handler.handleReport(rr, httptest.NewRequest("GET", "/api/diagnostics", nil))
+	var report DiagnosticsReport
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Alerts) != 1 {
+		t.Errorf("Expected 1 alert, got %d", len(report.Alerts))
+	}
+	if len(report.Findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %+v", report.Findings)
+	}
+	if finding := report.Findings[0]; finding.Check != "leader-flapping" || finding.Subject != "users" {
+		t.Errorf("Unexpected finding: %+v", finding)
+	}
+}
+
+func TestDiagnosticsRunbooks(t *testing.T) {
+	skews := staticSkews{{Cluster: "node1:8443", NodeName: "node1", Skew: 5 * time.Second}}
+	alerts := staticAlerts{
+		{Rule: "leader-flapping", Subject: "users", RunbookURL: "https://wiki.example.com/shipped", Remediation: "Shipped steps"},
+		{Rule: "disk-full", Subject: "node1", RunbookURL: "https://wiki.example.com/disk-full"},
+	}
+	store := runbooks.NewStore(metadata.NewMemoryStore())
+	if _, err := store.Put(runbooks.Runbook{Check: "leader-flapping", URL: "https://wiki.example.com/ours"}); err != nil {
+		t.Fatal(err)
+	}
+	handler := NewDiagnosticsHandler(skews, 2*time.Second, zap.NewNop(), WithAlertRules(alerts, nil), WithRunbooks(store))
+
+	report := handler.report(context.Background())
+	if len(report.Findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %+v", report.Findings)
+	}
+	byCheck := make(map[string]DiagnosticFinding)
+	for _, finding := range report.Findings {
+		byCheck[finding.Check] = finding
+	}
+	// A stored runbook replaces the shipped one entirely
+	if f := byCheck["leader-flapping"]; f.RunbookURL != "https://wiki.example.com/ours" || f.Remediation != "" {
+		t.Errorf("Expected the stored runbook, got %+v", f)
+	}
+	if f := byCheck["disk-full"]; f.RunbookURL != "https://wiki.example.com/disk-full" {
+		t.Errorf("Expected the runbook of the rule, got %+v", f)
+	}
+	if f := byCheck["clock-skew"]; !strings.Contains(f.Remediation, "NTP") {
+		t.Errorf("Expected the remediation of the clock skew check, got %+v", f)
+	}
+	if a := report.Alerts[0]; a.RunbookURL != "https://wiki.example.com/ours" {
+		t.Errorf("Expected the stored runbook on the alert, got %+v", a)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/basepath"
+	// Registers the OpenAPI specification generated from the annotations of the handlers
+	_ "github.com/armadakv/console/backend/docs"
+	"github.com/go-chi/chi/v5"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
+)
+
+// DocsPath is where the interactive API documentation is served.
+// The OpenAPI specification it is rendered from is served at DocsPath/doc.json.
+const DocsPath = "/api/docs"
+
+// DocsHandler serves the OpenAPI specification of the REST API and Swagger UI to browse it
+type DocsHandler struct {
+	ui     http.HandlerFunc
+	logger *zap.Logger
+}
+
+// NewDocsHandler creates a new API documentation handler
+func NewDocsHandler(logger *zap.Logger) *DocsHandler {
+	return &DocsHandler{
+		ui:     httpSwagger.Handler(httpSwagger.URL("doc.json")),
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the documentation routes
+func (h *DocsHandler) RegisterRoutes(r chi.Router) {
+	r.Get(DocsPath, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, basepath.Path(r.Context(), apiversion.Path(r.Context(), DocsPath+"/index.html")), http.StatusMovedPermanently)
+	})
+	r.Get(DocsPath+"/*", h.handleDocs)
+}
+
+// handleDocs serves Swagger UI, its assets and the specification
+func (h *DocsHandler) handleDocs(w http.ResponseWriter, r *http.Request) {
+	// Swagger UI resolves its assets against the request URI and remembers the prefix of the
+	// first request, so versioned requests are served under the unversioned path like all others
+	r.RequestURI = r.URL.RequestURI()
+	h.ui(w, r)
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestDocsHandler(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(apiversion.Middleware)
+	NewDocsHandler(zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", DocsPath+"/doc.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("Failed to parse specification: %v", err)
+	}
+	for path, method := range map[string]string{
+		"/api/status":                   "get",
+		"/api/tables":                   "post",
+		"/api/kv/{table}":               "put",
+		"/api/metrics/targets":          "get",
+		"/api/maintenance/{id}":         "delete",
+		"/api/tables/{name}/protection": "delete",
+	} {
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("Expected the specification to document %s %s", strings.ToUpper(method), path)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/v1/docs/index.html", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `url: "doc.json"`) {
+		t.Errorf("Expected Swagger UI to be served, got status %d", rr.Code)
+	}
+
+	for path, want := range map[string]string{
+		DocsPath:       "/api/docs/index.html",
+		"/api/v1/docs": "/api/v1/docs/index.html",
+	} {
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != want {
+			t.Errorf("Expected %s to redirect to %s, got status %d and location %q", path, want, rr.Code, rr.Header().Get("Location"))
+		}
+	}
+}
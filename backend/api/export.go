@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"iter"
+	"net/http"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/query"
+	"github.com/armadakv/console/backend/render"
+	"github.com/armadakv/console/backend/transform"
+)
+
+// renderPairsTable exports key-value pairs as a table with a row per pair. Projected fields get
+// a column each, JSON strings among them are written without their quotes.
+func renderPairsTable(w http.ResponseWriter, format render.Format, table string, decoder *transform.Pipeline, paths []query.Path, pairs []armada.KeyValuePair) error {
+	columns := []render.Column{{Name: "key"}}
+	if paths == nil {
+		columns = append(columns, render.Column{Name: "value"})
+	}
+	for _, path := range paths {
+		columns = append(columns, render.Column{Name: path.String()})
+	}
+
+	rows := func(yield func([]string) bool) {
+		for _, pair := range pairs {
+			var cells []string
+			switch {
+			case paths != nil:
+				projected := projectPair(decoder, paths, pair)
+				cells = append(cells, pair.Key)
+				for _, path := range paths {
+					cells = append(cells, fieldText(projected.Fields[path.String()]))
+				}
+			case decoder != nil:
+				cells = []string{pair.Key, decodePair(decoder, pair).Value}
+			default:
+				cells = []string{pair.Key, pair.Value}
+			}
+			if !yield(cells) {
+				return
+			}
+		}
+	}
+
+	filename := table + "-" + time.Now().UTC().Format("20060102-150405")
+	return render.Table(w, format, filename, columns, iter.Seq[[]string](rows))
+}
+
+// fieldText returns a projected field as the text of a cell: strings without their quotes, other
+// values as JSON and missing fields empty
+func fieldText(field json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(field, &s); err == nil {
+		return s
+	}
+	return string(field)
+}
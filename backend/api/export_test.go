@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+func TestGetKeyValueExport(t *testing.T) {
+	handler := createTestHandler()
+	handler.client.(*mockArmadaClient).kvPairs = []armada.KeyValuePair{
+		{Key: "order/1", Value: `{"user":{"name":"alice"},"total":12.5}`},
+		{Key: "=order/2", Value: `{"user":{"name":"bob, \"jr\""}}`},
+	}
+	params := map[string]string{"table": "table1"}
+
+	rr := serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?format=csv", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if disposition := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment; filename=table1-") {
+		t.Errorf("Expected an attachment named after the table, got %q", disposition)
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"key", "value"},
+		{"order/1", `{"user":{"name":"alice"},"total":12.5}`},
+		{"'=order/2", `{"user":{"name":"bob, \"jr\""}}`},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %q, got %q", want, records)
+	}
+
+	// Projected fields get a column each
+	query := url.Values{"project": {"$.user.name", "$.total"}, "format": {"csv"}}
+	rr = serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?"+query.Encode(), nil), params)
+	records, err = csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = [][]string{
+		{"key", "$.user.name", "$.total"},
+		{"order/1", "alice", "12.5"},
+		{"'=order/2", `bob, "jr"`, ""},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %q, got %q", want, records)
+	}
+
+	rr = serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?format=xml", nil), params)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an unknown format, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
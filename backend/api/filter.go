@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/query"
+	"github.com/armadakv/console/backend/transform"
+)
+
+const (
+	// filterBatch is the number of pairs fetched at a time while scanning for matches
+	filterBatch = 500
+	// maxFilterScan bounds the pairs a filtered scan reads before it returns a cursor, so
+	// filters matching few values don't scan whole tables in one request
+	maxFilterScan = 10000
+)
+
+// Value filters select the pairs whose JSON value matches a predicate, see query.ParseFilter.
+// Values that aren't JSON documents never match.
+
+// valueFilterOf returns the filter of the filter query parameter, or nil if there is none
+func valueFilterOf(r *http.Request) (query.Filter, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("filter"))
+	if raw == "" {
+		return nil, nil
+	}
+	return query.ParseFilter(raw)
+}
+
+// matchPair reports whether the JSON value of a pair matches the filter, the value is decoded
+// first if decoder is not nil
+func matchPair(decoder *transform.Pipeline, filter query.Filter, pair armada.KeyValuePair) bool {
+	value := pair.Value
+	if decoder != nil {
+		decoded := decodePair(decoder, pair)
+		if decoded.DecodeError != "" {
+			return false
+		}
+		value = decoded.Value
+	}
+	doc, err := query.DecodeDocument(value)
+	if err != nil {
+		return false
+	}
+	return filter.Match(doc)
+}
+
+// continueScan returns the bounds of a scan of the filter continuing at key
+func continueScan(prefix, end, key string) (scanPrefix, scanStart, scanEnd string) {
+	switch {
+	case prefix != "":
+		return "", key, armada.PrefixEnd(prefix)
+	case end == "":
+		// The end of the table
+		return "", key, "\x00"
+	default:
+		return "", key, end
+	}
+}
+
+// scanMatching scans the pairs of the filter given by prefix, start and end in batches from the
+// scan bounds on, and returns up to limit pairs whose values match. If the scan stops early
+// because it read maxFilterScan pairs or timed out, next is the key it continues at.
+func (h *Handler) scanMatching(ctx context.Context, table, prefix, end, scanPrefix, scanStart, scanEnd string,
+	limit int, decoder *transform.Pipeline, filter query.Filter) (matches []armada.KeyValuePair, next string, err error) {
+	matches = make([]armada.KeyValuePair, 0)
+	scanned := 0
+	for {
+		pairs, err := h.client.GetKeyValuePairs(ctx, table, scanPrefix, scanStart, scanEnd, filterBatch)
+		truncated := errors.Is(err, armada.ErrRangeTruncated)
+		if err != nil && !truncated {
+			// Batches read before the deadline are kept, the scan continues where the failed one started
+			if scanned > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return matches, scanStart, nil
+			}
+			return nil, "", err
+		}
+		for _, pair := range pairs {
+			if matchPair(decoder, filter, pair) {
+				matches = append(matches, pair)
+				if len(matches) == limit {
+					return matches, "", nil
+				}
+			}
+		}
+		scanned += len(pairs)
+		if !truncated && len(pairs) < filterBatch {
+			return matches, "", nil
+		}
+		next = pairs[len(pairs)-1].Key + "\x00"
+		if truncated || scanned >= maxFilterScan {
+			return matches, next, nil
+		}
+		scanPrefix, scanStart, scanEnd = continueScan(prefix, end, next)
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/go-chi/chi/v5"
+)
+
+// rangeClient serves key scans from its pairs honouring the start and the limit, so filtered
+// scans can be followed across batches
+type rangeClient struct {
+	*mockArmadaClient
+	pairs []armada.KeyValuePair
+	scans int
+}
+
+func (c *rangeClient) GetKeyValuePairs(_ context.Context, _, _, start, _ string, limit int) ([]armada.KeyValuePair, error) {
+	c.scans++
+	var pairs []armada.KeyValuePair
+	for _, pair := range c.pairs {
+		if pair.Key >= start && len(pairs) < limit {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
+}
+
+func TestGetKeyValueFiltered(t *testing.T) {
+	handler := createTestHandler()
+	client := &rangeClient{mockArmadaClient: &mockArmadaClient{}}
+	for i := range maxFilterScan + 5 {
+		status := "done"
+		if i%1000 == 999 {
+			status = "active"
+		}
+		client.pairs = append(client.pairs, armada.KeyValuePair{
+			Key:   fmt.Sprintf("job/%05d", i),
+			Value: fmt.Sprintf(`{"status":%q,"retries":%d}`, status, i%7),
+		})
+	}
+	client.pairs = append(client.pairs, armada.KeyValuePair{Key: "job/99999", Value: "not json"})
+	handler.client = client
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	get := func(query url.Values) ([]armada.KeyValuePair, *httptest.ResponseRecorder) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/jobs?"+query.Encode(), nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned %d: %s", rr.Code, rr.Body.String())
+		}
+		var pairs []armada.KeyValuePair
+		if err := json.Unmarshal(rr.Body.Bytes(), &pairs); err != nil {
+			t.Fatal(err)
+		}
+		return pairs, rr
+	}
+
+	// The scan stops after reading its maximum and returns a cursor continuing after the last key read
+	pairs, rr := get(url.Values{"prefix": {"job/"}, "filter": {`status == "active"`}})
+	if len(pairs) != maxFilterScan/1000 {
+		t.Errorf("got %d pairs, want %d", len(pairs), maxFilterScan/1000)
+	}
+	if client.scans != maxFilterScan/filterBatch {
+		t.Errorf("scanned %d batches, want %d", client.scans, maxFilterScan/filterBatch)
+	}
+	if got := rr.Header().Get(TruncatedHeader); got != "true" {
+		t.Errorf("%s = %q, want true", TruncatedHeader, got)
+	}
+	cursor := rr.Header().Get(CursorHeader)
+	if want := encodeCursor(fmt.Sprintf("job/%05d\x00", maxFilterScan-1)); cursor != want {
+		t.Errorf("%s = %q, want %q", CursorHeader, cursor, want)
+	}
+
+	pairs, rr = get(url.Values{"prefix": {"job/"}, "filter": {`status == "active"`}, "cursor": {cursor}})
+	if len(pairs) != 0 || rr.Header().Get(TruncatedHeader) != "" {
+		t.Errorf("the rest of the scan returned %d pairs, truncated %q", len(pairs), rr.Header().Get(TruncatedHeader))
+	}
+
+	// Filters matching many values stop at the page size
+	pairs, _ = get(url.Values{"filter": {`retries > 5`}})
+	if len(pairs) != 100 || pairs[0].Key != "job/00006" {
+		t.Errorf("got %d pairs starting with %+v", len(pairs), pairs[0])
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/jobs?filter="+url.QueryEscape("status =="), nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("an invalid filter returned %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/armadakv/console/backend/coalesce"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+	"golang.org/x/mod/semver"
+)
+
+// Health of a cluster in the fleet view
+const (
+	// FleetHealthy is the health of a cluster whose servers all answer without errors
+	FleetHealthy = "healthy"
+	// FleetDegraded is the health of a cluster with unreachable servers, server errors,
+	// failing scrapes or firing alerts
+	FleetDegraded = "degraded"
+	// FleetUnreachable is the health of a cluster none of whose servers answered recently
+	FleetUnreachable = "unreachable"
+	// FleetUnknown is the health of a cluster that was not sampled yet
+	FleetUnknown = "unknown"
+)
+
+// ClusterStatsSource provides the cached state of a cluster.
+// The stats.Sampler implements this interface.
+type ClusterStatsSource interface {
+	// Latest returns the cluster state seen by the latest sample, if any.
+	Latest() (stats.Observation, bool)
+	// Tables returns the latest statistics of all sampled tables.
+	Tables() map[string]stats.TableStats
+}
+
+// ScrapeTargetSource provides the scrape state of the metrics targets of a cluster.
+// The metrics.MetricsManager implements this interface.
+type ScrapeTargetSource interface {
+	Targets() []metrics.Target
+}
+
+// FleetSource are the caches the fleet view summarizes a cluster from. Every source may be nil,
+// the fields it provides are left empty then.
+type FleetSource struct {
+	Stats   ClusterStatsSource
+	Targets ScrapeTargetSource
+	Alerts  AlertEvaluator
+	// Addresses returns the addresses of the servers of the cluster when the targets and alerts
+	// are shared by several clusters. Only the targets and alerts of these servers are counted.
+	Addresses func() []string
+	// Derived counts the alerts without a cluster label for the cluster, i.e. alerts on the
+	// metrics the console derives from it, e.g. leader changes
+	Derived bool
+}
+
+// owns reports whether the server with the address belongs to the cluster of the source
+func (s FleetSource) owns(addrs []string, addr string) bool {
+	return s.Addresses == nil || slices.Contains(addrs, addr)
+}
+
+// FleetScrape summarizes the metrics scrapes of a cluster
+type FleetScrape struct {
+	Up      int `json:"up"`
+	Down    int `json:"down"`
+	Unknown int `json:"unknown"`
+	// LastScrape is the most recent scrape of any target of the cluster
+	LastScrape time.Time `json:"lastScrape,omitzero"`
+}
+
+// FleetCluster is the summary of a registered cluster in the fleet view
+type FleetCluster struct {
+	Name string       `json:"name"`
+	Tags cluster.Tags `json:"tags,omitempty"`
+	// Health is one of healthy, degraded, unreachable or unknown
+	Health string `json:"health"`
+	// Nodes is the number of cluster members, NodesUp the number of those that answered
+	Nodes   int `json:"nodes"`
+	NodesUp int `json:"nodesUp"`
+	// MinVersion and MaxVersion are the oldest and newest Armada version the servers run,
+	// they differ during rolling upgrades
+	MinVersion string `json:"minVersion,omitempty"`
+	MaxVersion string `json:"maxVersion,omitempty"`
+	// DBSize is the total size of the databases of all tables in bytes
+	DBSize       int64       `json:"dbSize"`
+	Tables       int         `json:"tables"`
+	FiringAlerts int         `json:"firingAlerts"`
+	Scrape       FleetScrape `json:"scrape"`
+	// SampledAt is when the state of the cluster was last sampled
+	SampledAt time.Time `json:"sampledAt,omitzero"`
+}
+
+// FleetResponse represents the response for the fleet API endpoint
+type FleetResponse struct {
+	GeneratedAt time.Time      `json:"generatedAt"`
+	Clusters    []FleetCluster `json:"clusters"`
+}
+
+// FleetHandler serves a summary of all registered clusters. It only reads caches, so it stays
+// cheap for landing pages across dozens of clusters.
+type FleetHandler struct {
+	registry ClusterRegistry
+	// staleAfter is the age after which the sampled state of a cluster is considered unreachable
+	staleAfter time.Duration
+	logger     *zap.Logger
+	sources    map[string]FleetSource
+	rules      []metrics.AlertRule
+	reads      coalesce.Group
+}
+
+// FleetOption configures optional behaviour of the FleetHandler
+type FleetOption func(*FleetHandler)
+
+// WithFleetSource summarizes the named cluster from the source
+func WithFleetSource(name string, source FleetSource) FleetOption {
+	return func(h *FleetHandler) {
+		h.sources[name] = source
+	}
+}
+
+// WithFleetAlertRules counts the firing alerts of the rules for every cluster
+func WithFleetAlertRules(rules []metrics.AlertRule) FleetOption {
+	return func(h *FleetHandler) {
+		h.rules = rules
+	}
+}
+
+// NewFleetHandler creates a new fleet API handler.
+// Clusters whose latest sample is older than staleAfter are reported as unreachable.
+func NewFleetHandler(registry ClusterRegistry, staleAfter time.Duration, logger *zap.Logger, opts ...FleetOption) *FleetHandler {
+	h := &FleetHandler{
+		registry:   registry,
+		staleAfter: staleAfter,
+		logger:     logger,
+		sources:    make(map[string]FleetSource),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers the fleet routes
+func (h *FleetHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/fleet", h.handleFleet)
+}
+
+// handleFleet returns one row per registered cluster
+// @Summary Get fleet summary
+// @Description Summarize every registered cluster with its health, version range, node count, database size, firing alerts and scrape status, assembled from cached samples
+// @Tags clusters
+// @Produce json
+// @Param tags query string false "Only list clusters with all of these tags, e.g. env=prod,region=eu"
+// @Success 200 {object} FleetResponse
+// @Failure 400 {string} string "Invalid tags"
+// @Router /api/fleet [get]
+func (h *FleetHandler) handleFleet(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	filter, err := tagFilter(r)
+	if err != nil {
+		http.Error(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Landing pages of many operators poll this endpoint, so concurrent requests share one evaluation
+	fleet, _, err := coalesce.Do(r.Context(), &h.reads, "fleet", func(ctx context.Context) (FleetResponse, error) {
+		return h.fleet(ctx), nil
+	})
+	if err != nil {
+		// Only the client going away fails the summary
+		return
+	}
+	render.JSON(fleet.filter(filter))
+}
+
+// filter returns the fleet of the clusters with all tags of the filter. The fleet is shared by
+// concurrent requests, so it is copied rather than filtered in place.
+func (f FleetResponse) filter(tags cluster.Tags) FleetResponse {
+	if len(tags) == 0 {
+		return f
+	}
+	filtered := FleetResponse{GeneratedAt: f.GeneratedAt, Clusters: make([]FleetCluster, 0, len(f.Clusters))}
+	for _, c := range f.Clusters {
+		if c.Tags.Matches(tags) {
+			filtered.Clusters = append(filtered.Clusters, c)
+		}
+	}
+	return filtered
+}
+
+// fleet summarizes all registered clusters
+func (h *FleetHandler) fleet(ctx context.Context) FleetResponse {
+	now := time.Now().UTC()
+	clusters := h.registry.List()
+	fleet := FleetResponse{
+		GeneratedAt: now,
+		Clusters:    make([]FleetCluster, 0, len(clusters)),
+	}
+	for _, c := range clusters {
+		row := h.summarize(ctx, c.Name, now)
+		row.Tags = c.Tags
+		fleet.Clusters = append(fleet.Clusters, row)
+	}
+	return fleet
+}
+
+// summarize assembles the row of a cluster from its sources
+func (h *FleetHandler) summarize(ctx context.Context, name string, now time.Time) FleetCluster {
+	row := FleetCluster{Name: name, Health: FleetUnknown}
+	source := h.sources[name]
+
+	serverErrors := false
+	if source.Stats != nil {
+		if o, ok := source.Stats.Latest(); ok {
+			row.SampledAt = o.Time
+			row.Nodes = len(o.Servers)
+			row.NodesUp = len(o.Statuses)
+			for _, status := range o.Statuses {
+				serverErrors = serverErrors || len(status.Errors) > 0
+				if status.Version == "" {
+					continue
+				}
+				if row.MinVersion == "" || compareVersions(status.Version, row.MinVersion) < 0 {
+					row.MinVersion = status.Version
+				}
+				if row.MaxVersion == "" || compareVersions(status.Version, row.MaxVersion) > 0 {
+					row.MaxVersion = status.Version
+				}
+			}
+			for _, ts := range source.Stats.Tables() {
+				row.DBSize += ts.DBSize
+				row.Tables++
+			}
+		}
+	}
+
+	var addrs []string
+	if source.Addresses != nil {
+		addrs = source.Addresses()
+	}
+	if source.Targets != nil {
+		for _, target := range source.Targets.Targets() {
+			if !source.owns(addrs, target.Cluster) {
+				continue
+			}
+			switch target.Health {
+			case metrics.TargetUp:
+				row.Scrape.Up++
+			case metrics.TargetDown:
+				row.Scrape.Down++
+			default:
+				row.Scrape.Unknown++
+			}
+			if target.LastScrape.After(row.Scrape.LastScrape) {
+				row.Scrape.LastScrape = target.LastScrape
+			}
+		}
+	}
+
+	if source.Alerts != nil && len(h.rules) > 0 {
+		alerts, err := source.Alerts.Alerts(ctx, h.rules, now)
+		if err != nil {
+			// A broken rule must not hide the rest of the fleet
+			h.logger.Error("Failed to evaluate alert rules", zap.Error(err), zap.String("cluster", name))
+		}
+		for _, alert := range alerts {
+			addr, ok := alert.Labels["cluster"]
+			if (ok && source.owns(addrs, addr)) || (!ok && (source.Addresses == nil || source.Derived)) {
+				row.FiringAlerts++
+			}
+		}
+	}
+
+	switch {
+	case row.SampledAt.IsZero():
+		row.Health = FleetUnknown
+	case now.Sub(row.SampledAt) > h.staleAfter:
+		// The sampler keeps the previous state while no server answers
+		row.Health = FleetUnreachable
+	case row.NodesUp < row.Nodes || serverErrors || row.Scrape.Down > 0 || row.FiringAlerts > 0:
+		row.Health = FleetDegraded
+	default:
+		row.Health = FleetHealthy
+	}
+	return row
+}
+
+// compareVersions compares Armada versions semantically, e.g. v0.10.0 is newer than v0.9.1.
+// Versions that are not semantic versions are compared as strings.
+func compareVersions(a, b string) int {
+	va, vb := "v"+strings.TrimPrefix(a, "v"), "v"+strings.TrimPrefix(b, "v")
+	if semver.IsValid(va) && semver.IsValid(vb) {
+		return semver.Compare(va, vb)
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"go.uber.org/zap"
+)
+
+// staticClusterStats serves a fixed observation and table statistics
+type staticClusterStats struct {
+	observation stats.Observation
+	tables      map[string]stats.TableStats
+}
+
+func (s staticClusterStats) Latest() (stats.Observation, bool) {
+	return s.observation, !s.observation.Time.IsZero()
+}
+
+func (s staticClusterStats) Tables() map[string]stats.TableStats { return s.tables }
+
+type staticTargets []metrics.Target
+
+func (s staticTargets) Targets() []metrics.Target { return s }
+
+func TestFleet(t *testing.T) {
+	registry := cluster.NewRegistry()
+	for _, name := range []string{"eu", "stale", "unsampled", "us"} {
+		if err := registry.Register(cluster.Cluster{Name: name, Seeds: []string{name + ":5001"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	servers := []armada.Server{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	eu := FleetSource{
+		Stats: staticClusterStats{
+			observation: stats.Observation{Time: now, Servers: servers, Statuses: map[string]*armada.Status{
+				"1": {Status: "ok", Version: "v0.10.0"},
+				"2": {Status: "ok", Version: "v0.9.1"},
+				"3": {Status: "ok", Version: "v0.10.0"},
+			}},
+			tables: map[string]stats.TableStats{"users": {DBSize: 100}, "orders": {DBSize: 50}},
+		},
+		Targets: staticTargets{
+			{Cluster: "a:5001", Health: metrics.TargetUp, LastScrape: now},
+			{Cluster: "b:5001", Health: metrics.TargetUp, LastScrape: now.Add(-time.Second)},
+		},
+		Alerts: staticAlerts{},
+	}
+	us := FleetSource{
+		Stats: staticClusterStats{
+			observation: stats.Observation{Time: now, Servers: servers, Statuses: map[string]*armada.Status{
+				"1": {Status: "ok", Version: "v0.10.0"},
+				"2": {Status: "ok", Version: "v0.10.0"},
+			}},
+		},
+		Targets: staticTargets{{Cluster: "c:5001", Health: metrics.TargetDown}},
+		Alerts:  staticAlerts{{Rule: "leader-flapping", Subject: "users"}},
+	}
+	stale := FleetSource{
+		Stats: staticClusterStats{
+			observation: stats.Observation{Time: now.Add(-time.Hour), Servers: servers, Statuses: map[string]*armada.Status{
+				"1": {Status: "ok"},
+			}},
+		},
+	}
+	handler := NewFleetHandler(registry, time.Minute, zap.NewNop(),
+		WithFleetSource("eu", eu),
+		WithFleetSource("us", us),
+		WithFleetSource("stale", stale),
+		WithFleetAlertRules(metrics.DefaultAlertRules))
+
+	rr := httptest.NewRecorder()
+	handler.handleFleet(rr, httptest.NewRequest("GET", "/api/fleet", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var fleet FleetResponse
+	if err := json.NewDecoder(rr.Body).Decode(&fleet); err != nil {
+		t.Fatal(err)
+	}
+	if len(fleet.Clusters) != 4 {
+		t.Fatalf("Expected a row per registered cluster, got %+v", fleet.Clusters)
+	}
+	rows := make(map[string]FleetCluster)
+	for _, row := range fleet.Clusters {
+		rows[row.Name] = row
+	}
+
+	row := rows["eu"]
+	if row.Health != FleetHealthy || row.Nodes != 3 || row.NodesUp != 3 {
+		t.Errorf("Unexpected eu row: %+v", row)
+	}
+	if row.MinVersion != "v0.9.1" || row.MaxVersion != "v0.10.0" {
+		t.Errorf("Expected versions v0.9.1 to v0.10.0, got %s to %s", row.MinVersion, row.MaxVersion)
+	}
+	if row.DBSize != 150 || row.Tables != 2 {
+		t.Errorf("Expected 2 tables of 150 bytes, got %d tables of %d bytes", row.Tables, row.DBSize)
+	}
+	if row.Scrape.Up != 2 || !row.Scrape.LastScrape.Equal(now) {
+		t.Errorf("Unexpected eu scrape summary: %+v", row.Scrape)
+	}
+
+	row = rows["us"]
+	if row.Health != FleetDegraded || row.NodesUp != 2 || row.FiringAlerts != 1 || row.Scrape.Down != 1 {
+		t.Errorf("Unexpected us row: %+v", row)
+	}
+	if row := rows["stale"]; row.Health != FleetUnreachable {
+		t.Errorf("Expected stale cluster to be unreachable, got %+v", row)
+	}
+	if row := rows["unsampled"]; row.Health != FleetUnknown || !row.SampledAt.IsZero() {
+		t.Errorf("Expected unsampled cluster to be unknown, got %+v", row)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v0.10.0", "v0.9.1", 1},
+		{"0.9.1", "v0.10.0", -1},
+		{"v1.0.0", "1.0.0", 0},
+		{"dev", "dev", 0},
+		{"dev", "v1.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFleetSharedTargets(t *testing.T) {
+	registry := cluster.NewRegistry()
+	for _, name := range []string{"prod", "staging"} {
+		if err := registry.Register(cluster.Cluster{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Both clusters are scraped into the same storage
+	targets := staticTargets{
+		{Cluster: "prod:5001", Health: metrics.TargetUp},
+		{Cluster: "staging:5001", Health: metrics.TargetDown},
+	}
+	alerts := staticAlerts{
+		{Rule: "leader-flapping", Labels: map[string]string{"table": "users"}},
+		{Rule: "slow-disk", Labels: map[string]string{"cluster": "staging:5001"}},
+	}
+	handler := NewFleetHandler(registry, time.Minute, zap.NewNop(),
+		WithFleetSource("prod", FleetSource{
+			Targets:   targets,
+			Alerts:    alerts,
+			Addresses: func() []string { return []string{"prod:5001"} },
+			Derived:   true,
+		}),
+		WithFleetSource("staging", FleetSource{
+			Targets:   targets,
+			Alerts:    alerts,
+			Addresses: func() []string { return []string{"staging:5001"} },
+		}),
+		WithFleetAlertRules(metrics.DefaultAlertRules))
+
+	fleet := handler.fleet(t.Context())
+	prod, staging := fleet.Clusters[0], fleet.Clusters[1]
+	if prod.Scrape.Up != 1 || prod.Scrape.Down != 0 || prod.FiringAlerts != 1 {
+		t.Errorf("Unexpected prod row: %+v", prod)
+	}
+	if staging.Scrape.Up != 0 || staging.Scrape.Down != 1 || staging.FiringAlerts != 1 {
+		t.Errorf("Unexpected staging row: %+v", staging)
+	}
+}
+
+func TestFleetFilteredByTags(t *testing.T) {
+	registry := cluster.NewRegistry()
+	for name, tags := range map[string]cluster.Tags{
+		"eu-prod":    {"env": "prod", "region": "eu"},
+		"us-prod":    {"env": "prod", "region": "us"},
+		"eu-staging": {"env": "staging", "region": "eu"},
+	} {
+		if err := registry.Register(cluster.Cluster{Name: name, Tags: tags}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handler := NewFleetHandler(registry, time.Minute, zap.NewNop())
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{query: "", wantStatus: http.StatusOK, want: []string{"eu-prod", "eu-staging", "us-prod"}},
+		{query: "?tags=env=prod", wantStatus: http.StatusOK, want: []string{"eu-prod", "us-prod"}},
+		{query: "?tags=env=prod,region=eu", wantStatus: http.StatusOK, want: []string{"eu-prod"}},
+		{query: "?tags=tier=gold", wantStatus: http.StatusOK, want: []string{}},
+		{query: "?tags=region", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.handleFleet(rr, httptest.NewRequest("GET", "/api/fleet"+tt.query, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Expected status code %d, got %d", tt.wantStatus, rr.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var fleet FleetResponse
+			if err := json.NewDecoder(rr.Body).Decode(&fleet); err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, row := range fleet.Clusters {
+				names = append(names, row.Name)
+				if row.Tags["env"] == "" {
+					t.Errorf("Expected the tags of %s, got %+v", row.Name, row.Tags)
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Expected clusters %v, got %v", tt.want, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/growth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// maxGrowthDays is the longest period a growth report covers
+const maxGrowthDays = 90
+
+// WithGrowth makes the table growth endpoint report from the key counts recorded by a growth.Recorder.
+// The time to reach sizeCap bytes is projected unless it is 0.
+func WithGrowth(reader growth.SampleReader, sizeCap int64) HandlerOption {
+	return func(h *Handler) {
+		h.growth = reader
+		h.sizeCap = sizeCap
+	}
+}
+
+// handleTableGrowth reports how the keyspace of a table grew
+// @Summary Get table growth
+// @Description Report the daily key counts and sizes of a table with their changes, the key prefixes that gained the most keys and when the table reaches the size cap at its current growth. The history is kept for the metrics retention.
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name"
+// @Param days query int false "Number of days, including today (default 7)"
+// @Param top query int false "Maximum number of prefixes (default 10)"
+// @Param cap query int false "Size cap in bytes, overrides GROWTH_SIZE_CAP"
+// @Success 200 {object} growth.Report
+// @Failure 400 {string} string "Invalid query"
+// @Failure 404 {string} string "Growth is not recorded for this cluster"
+// @Router /api/tables/{name}/growth [get]
+func (h *Handler) handleTableGrowth(w http.ResponseWriter, r *http.Request) {
+	if h.growth == nil {
+		http.Error(w, "Growth is not recorded for this cluster", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	q := growth.Query{Table: chi.URLParam(r, "name"), Days: 7, TopPrefixes: 10, SizeCap: h.sizeCap}
+	if raw := query.Get("days"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 1 || days > maxGrowthDays {
+			http.Error(w, "days must be between 1 and "+strconv.Itoa(maxGrowthDays), http.StatusBadRequest)
+			return
+		}
+		q.Days = days
+	}
+	if raw := query.Get("top"); raw != "" {
+		top, err := strconv.Atoi(raw)
+		if err != nil || top < 0 || top > maxHotKeysLimit {
+			http.Error(w, "top must be between 0 and "+strconv.Itoa(maxHotKeysLimit), http.StatusBadRequest)
+			return
+		}
+		q.TopPrefixes = top
+	}
+	if raw := query.Get("cap"); raw != "" {
+		sizeCap, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || sizeCap < 0 {
+			http.Error(w, "cap must be a size in bytes", http.StatusBadRequest)
+			return
+		}
+		q.SizeCap = sizeCap
+	}
+
+	report, err := growth.Analyze(r.Context(), h.growth, q, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to report table growth", zap.Error(err), zap.String("table", q.Table))
+		http.Error(w, "Failed to report table growth", http.StatusInternalServerError)
+		return
+	}
+	chix.NewRender(w).JSON(report)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/growth"
+	"github.com/armadakv/console/backend/metrics"
+)
+
+// growthSamples returns a day of growth of the table "users" for every metric
+type growthSamples struct{}
+
+func (growthSamples) ReadSamples(_ context.Context, name string, match map[string]string, _, end time.Time) ([]metrics.ConsoleSample, error) {
+	if match["table"] != "users" {
+		return nil, nil
+	}
+	values := map[string][2]float64{
+		metrics.TableKeysMetric:  {100, 150},
+		metrics.TableBytesMetric: {1000, 2000},
+		metrics.PrefixKeysMetric: {10, 60},
+	}[name]
+	labels := map[string]string{"table": "users", "prefix": "user/"}
+	return []metrics.ConsoleSample{
+		{Name: name, Labels: labels, Time: end.Add(-24 * time.Hour), Value: values[0]},
+		{Name: name, Labels: labels, Time: end, Value: values[1]},
+	}, nil
+}
+
+func TestTableGrowth(t *testing.T) {
+	handler := createTestHandler()
+	params := map[string]string{"name": "users"}
+
+	rr := serveWithParams(handler.handleTableGrowth, httptest.NewRequest("GET", "/api/tables/users/growth", nil), params)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d without recorded growth, got %d", http.StatusNotFound, rr.Code)
+	}
+
+	WithGrowth(growthSamples{}, 5000)(handler)
+	rr = serveWithParams(handler.handleTableGrowth, httptest.NewRequest("GET", "/api/tables/users/growth", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var report growth.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Keys != 150 || report.Bytes != 2000 || report.BytesPerDay != 1000 {
+		t.Errorf("Expected 150 keys, 2000 bytes growing by 1000 a day, got %+v", report)
+	}
+	if len(report.TopPrefixes) != 1 || report.TopPrefixes[0].KeysDelta != 50 {
+		t.Errorf("Expected user/ to gain 50 keys, got %+v", report.TopPrefixes)
+	}
+	if report.CapReachedAt == nil || time.Until(*report.CapReachedAt) < 71*time.Hour || time.Until(*report.CapReachedAt) > 73*time.Hour {
+		t.Errorf("Expected the cap of 5000 bytes to be reached in 3 days, got %v", report.CapReachedAt)
+	}
+
+	rr = serveWithParams(handler.handleTableGrowth, httptest.NewRequest("GET", "/api/tables/users/growth?cap=0", nil), params)
+	var uncapped growth.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &uncapped); err != nil {
+		t.Fatal(err)
+	}
+	if uncapped.CapReachedAt != nil {
+		t.Errorf("Expected no projection without a cap, got %v", uncapped.CapReachedAt)
+	}
+
+	for _, query := range []string{"days=0", "days=91", "top=-1", "cap=big"} {
+		rr = serveWithParams(handler.handleTableGrowth, httptest.NewRequest("GET", "/api/tables/users/growth?"+query, nil), params)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected %s to return %d, got %d", query, http.StatusBadRequest, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// ApprovalHeader carries the ID of the approval of a change a guardrail held back
+const ApprovalHeader = "X-Approval-ID"
+
+// Rules of guardrail policies, as written in their configuration
+const (
+	// GuardrailReadOnly refuses changes of users who are not guardrail admins
+	GuardrailReadOnly = "read-only"
+	// GuardrailApprove requires an action to be approved by another guardrail admin, e.g. approve=table.delete
+	GuardrailApprove = "approve="
+)
+
+// guardedRoutes are the paths of the API changing a cluster and the resources they change.
+// Requests to them are changes of the cluster serving them, see Guardrails.ClusterRoutes, or of
+// the cluster named in the path below /api/clusters.
+var guardedRoutes = []struct{ prefix, resource string }{
+	{"/api/tables", "table"},
+	{"/api/kv", "kv"},
+	{"/api/indexes", "index"},
+	{"/api/snapshots", "snapshot"},
+	{"/api/maintenance", "maintenance"},
+	{"/api/locks", "lock"},
+	{"/api/servers", "server"},
+	{"/api/shadow", "shadow"},
+	{"/api/clusters", "cluster"},
+}
+
+// GuardrailPolicy restricts the changes of the clusters with all of its tags
+type GuardrailPolicy struct {
+	Tags cluster.Tags
+	// ReadOnly refuses changes of users who are not guardrail admins
+	ReadOnly bool
+	// Approve are the actions a guardrail admin other than the requesting user must approve, e.g. table.delete
+	Approve []string
+}
+
+// ParseGuardrails parses policies written as tag:rule, e.g. env=prod:read-only or
+// env=prod:approve=table.delete. Rules of the same tag are merged into one policy.
+func ParseGuardrails(entries []string) ([]GuardrailPolicy, error) {
+	var policies []GuardrailPolicy
+	for _, entry := range entries {
+		tag, rule, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("guardrails must be written as key=value:rule, got %q", entry)
+		}
+		tags, err := cluster.ParseTags([]string{tag})
+		if err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(policies, func(p GuardrailPolicy) bool { return maps.Equal(p.Tags, tags) })
+		if i < 0 {
+			policies = append(policies, GuardrailPolicy{Tags: tags})
+			i = len(policies) - 1
+		}
+		rule = strings.TrimSpace(rule)
+		if action, ok := strings.CutPrefix(rule, GuardrailApprove); ok {
+			policies[i].Approve = append(policies[i].Approve, strings.TrimSpace(action))
+			continue
+		}
+		if rule != GuardrailReadOnly {
+			return nil, fmt.Errorf("guardrail rules must be %s or %s<action>, got %q", GuardrailReadOnly, GuardrailApprove, rule)
+		}
+		policies[i].ReadOnly = true
+	}
+	return policies, nil
+}
+
+// Guardrails enforce policies on the changes of clusters selected by their tags, e.g. to keep
+// production clusters read-only for everyone but a few admins
+type Guardrails struct {
+	Policies []GuardrailPolicy
+	// Admins are the users who may change read-only clusters and approve actions. They are named
+	// with the method they authenticate with, so local users or API keys named like them aren't admins.
+	Admins []auth.Principal
+	// AdminGroups are the groups whose members are admins
+	AdminGroups []string
+	// DefaultCluster is the cluster changed by requests that are not served by another cluster
+	DefaultCluster string
+	// ClusterRoutes are the routes of the other clusters as passed to SelectCluster. Requests
+	// naming a cluster in the cluster parameter only change it if its routes serve them, other
+	// routes, e.g. releasing locks, always change the default cluster.
+	ClusterRoutes map[string]chi.Router
+	// Tags returns the tags of a cluster, nil if it is unknown
+	Tags func(name string) cluster.Tags
+	// Approvals keeps the actions held back until they are approved
+	Approvals *Approvals
+}
+
+// IsAdmin reports whether a user is a guardrail admin by principal or group
+func (g Guardrails) IsAdmin(user auth.User) bool {
+	if user.IsAny(g.Admins) {
+		return true
+	}
+	for _, group := range user.Groups {
+		if slices.Contains(g.AdminGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// rules returns whether a cluster with the tags is read-only and the actions that must be approved,
+// combining every policy matching them
+func (g Guardrails) rules(tags cluster.Tags) (bool, []string) {
+	var readOnly bool
+	var approve []string
+	for _, p := range g.Policies {
+		if len(tags) == 0 || !tags.Matches(p.Tags) {
+			continue
+		}
+		readOnly = readOnly || p.ReadOnly
+		approve = append(approve, p.Approve...)
+	}
+	return readOnly, approve
+}
+
+// clusterChange returns the cluster a request changes and the action, e.g. table.delete, or false
+// if it doesn't change a cluster
+func (g Guardrails) clusterChange(r *http.Request) (string, string, bool) {
+	if slices.Contains(readMethods, r.Method) {
+		return "", "", false
+	}
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	for _, route := range guardedRoutes {
+		rest, ok := strings.CutPrefix(path, route.prefix)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+		// Deleting below a resource, e.g. its protection, updates it
+		verb := "update"
+		switch {
+		case r.Method == http.MethodDelete && strings.Count(rest, "/") == 1:
+			verb = "delete"
+		case r.Method == http.MethodPost && rest == "":
+			verb = "create"
+		}
+
+		if route.resource == "cluster" {
+			// Settings of a cluster name it in the path, importing clusters changes none
+			name, _, ok := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+			if !ok {
+				return "", "", false
+			}
+			return name, route.resource + "." + verb, true
+		}
+		return g.servingCluster(r), route.resource + "." + verb, true
+	}
+	return "", "", false
+}
+
+// ForCluster returns the guardrails of a router serving a single cluster, e.g. the one replaying
+// recorded requests, which changes that cluster whatever the cluster parameter says
+func (g Guardrails) ForCluster(name string) Guardrails {
+	g.DefaultCluster, g.ClusterRoutes = name, nil
+	return g
+}
+
+// servingCluster returns the cluster serving a request like SelectCluster routes it: the cluster
+// of the cluster parameter if its routes match the request, the default cluster otherwise
+func (g Guardrails) servingCluster(r *http.Request) string {
+	name := r.URL.Query().Get(ClusterParam)
+	routes, ok := g.ClusterRoutes[name]
+	if !ok || !routes.Match(chi.NewRouteContext(), r.Method, r.URL.Path) {
+		return g.DefaultCluster
+	}
+	return name
+}
+
+// EnforceGuardrails returns a middleware enforcing the guardrails on changes of clusters. Changes of read-only
+// clusters by users who are not admins are rejected with 403 Forbidden. Actions that must be
+// approved are held back with 428 Precondition Required and a pending approval; once another admin
+// approved it, the user repeats the request with the approval ID in the X-Approval-ID header.
+// Refused and held back requests are recorded in the audit log, if it is not nil. It must run after
+// authentication, as it reads the user from the request context.
+func EnforceGuardrails(g Guardrails, auditLog audit.Log, logger *zap.Logger) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	record := func(r *http.Request, entry audit.Entry) {
+		if auditLog == nil {
+			return
+		}
+		if _, err := auditLog.Record(r.Context(), entry); err != nil {
+			logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", entry.Action))
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, action, ok := g.clusterChange(r)
+			if !ok || g.Tags == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			readOnly, approve := g.rules(g.Tags(name))
+			user, _ := auth.UserFromContext(r.Context())
+			details := map[string]string{"cluster": name, "action": action, "method": r.Method}
+
+			if readOnly && !g.IsAdmin(user) {
+				logger.Info("Denied change of read-only cluster",
+					zap.String("user", auth.UserName(r.Context())),
+					zap.String("cluster", name),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path))
+				record(r, audit.Entry{
+					User:     auth.UserName(r.Context()),
+					Action:   "request",
+					Resource: strings.TrimPrefix(r.URL.Path, "/api/"),
+					Outcome:  audit.OutcomeDenied,
+					Error:    "the cluster is read-only by a guardrail",
+					Details:  details,
+				})
+				http.Error(w, fmt.Sprintf("Forbidden: cluster %q is read-only, only guardrail admins may change it", name), http.StatusForbidden)
+				return
+			}
+			if !slices.Contains(approve, action) || g.Approvals == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id := r.Header.Get(ApprovalHeader)
+			if id == "" {
+				approval, err := g.Approvals.Request(principalOf(r.Context()), name, action, r.Method, approvalTarget(r))
+				if err != nil {
+					logger.Error("Failed to request approval", zap.Error(err))
+					http.Error(w, "Failed to request approval", http.StatusInternalServerError)
+					return
+				}
+				details["approval"] = approval.ID
+				record(r, audit.Entry{
+					User:     auth.UserName(r.Context()),
+					Action:   "approval.request",
+					Resource: strings.TrimPrefix(r.URL.Path, "/api/"),
+					Outcome:  audit.OutcomeSuccess,
+					Details:  details,
+				})
+				render := chix.NewRender(w)
+				render.Status(http.StatusPreconditionRequired)
+				render.JSON(approval)
+				return
+			}
+			if err := g.Approvals.Use(id, principalOf(r.Context()), name, action, r.Method, approvalTarget(r)); err != nil {
+				http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+				return
+			}
+			logger.Info("Using approval",
+				zap.String("user", auth.UserName(r.Context())),
+				zap.String("approval", id),
+				zap.String("cluster", name),
+				zap.String("action", action))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
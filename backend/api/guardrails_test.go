@@ -0,0 +1,345 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestParseGuardrails(t *testing.T) {
+	policies, err := ParseGuardrails([]string{"env=prod:read-only", "env=prod:approve=table.delete", "region=eu:approve=kv.delete"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GuardrailPolicy{
+		{Tags: cluster.Tags{"env": "prod"}, ReadOnly: true, Approve: []string{"table.delete"}},
+		{Tags: cluster.Tags{"region": "eu"}, Approve: []string{"kv.delete"}},
+	}
+	if !reflect.DeepEqual(policies, want) {
+		t.Errorf("ParseGuardrails() = %+v, want %+v", policies, want)
+	}
+
+	for _, entries := range [][]string{{"read-only"}, {"env=prod:frozen"}, {"cluster=prod:read-only"}} {
+		if _, err := ParseGuardrails(entries); err == nil {
+			t.Errorf("ParseGuardrails(%q) succeeded, want an error", entries)
+		}
+	}
+}
+
+// clusterTestRoutes serves the tables and keys of a cluster like the routes of api.Handler
+func clusterTestRoutes() chi.Router {
+	routes := chi.NewRouter()
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	routes.Handle("/api/tables", noop)
+	routes.Handle("/api/tables/*", noop)
+	routes.Handle("/api/kv/*", noop)
+	return routes
+}
+
+func TestGuardrailsClusterChange(t *testing.T) {
+	g := Guardrails{DefaultCluster: "default", ClusterRoutes: map[string]chi.Router{"prod": clusterTestRoutes()}}
+	tests := []struct {
+		method  string
+		path    string
+		cluster string
+		action  string
+	}{
+		{http.MethodGet, "/api/tables", "", ""},
+		{http.MethodPost, "/api/tables", "default", "table.create"},
+		{http.MethodDelete, "/api/tables/users", "default", "table.delete"},
+		{http.MethodDelete, "/api/tables/users?cluster=prod", "prod", "table.delete"},
+		{http.MethodDelete, "/api/tables/users/protection", "default", "table.update"},
+		{http.MethodPut, "/api/tables/users/metadata", "default", "table.update"},
+		{http.MethodPut, "/api/kv/users?cluster=prod", "prod", "kv.update"},
+		{http.MethodDelete, "/api/kv/users", "default", "kv.delete"},
+		{http.MethodPost, "/api/locks/release", "default", "lock.update"},
+		// Locks are only served by the default cluster, whatever the parameter says
+		{http.MethodPost, "/api/locks/release?cluster=prod", "default", "lock.update"},
+		{http.MethodDelete, "/api/tables/users?cluster=unknown", "default", "table.delete"},
+		{http.MethodPut, "/api/clusters/prod/tags", "prod", "cluster.update"},
+		{http.MethodPost, "/api/clusters/import", "", ""},
+		{http.MethodPost, "/api/tablesets", "", ""},
+		{http.MethodPost, "/api/admin/users", "", ""},
+	}
+	for _, tt := range tests {
+		name, action, _ := g.clusterChange(httptest.NewRequest(tt.method, tt.path, nil))
+		if name != tt.cluster || action != tt.action {
+			t.Errorf("%s %s = %q %q, want %q %q", tt.method, tt.path, name, action, tt.cluster, tt.action)
+		}
+	}
+}
+
+// newGuardrailsTestHandler guards a handler changing the clusters "prod", tagged env=prod, and "dev"
+func newGuardrailsTestHandler(t *testing.T, auditLog audit.Log) (http.Handler, Guardrails) {
+	t.Helper()
+	policies, err := ParseGuardrails([]string{"env=prod:read-only", "env=prod:approve=table.delete", "env=prod:approve=kv.delete"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := Guardrails{
+		Policies:       policies,
+		Admins:         []auth.Principal{{Method: auth.MethodOIDC, Name: "alice"}},
+		AdminGroups:    []string{"sre"},
+		DefaultCluster: "prod",
+		Tags: func(name string) cluster.Tags {
+			return map[string]cluster.Tags{"prod": {"env": "prod"}, "dev": {"env": "dev"}}[name]
+		},
+		ClusterRoutes: map[string]chi.Router{"dev": clusterTestRoutes()},
+		Approvals:     NewApprovals(time.Hour),
+	}
+	handler := EnforceGuardrails(g, auditLog, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	return handler, g
+}
+
+func serveAs(handler http.Handler, user auth.User, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req = req.WithContext(auth.WithUser(req.Context(), user))
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestEnforceGuardrailsReadOnly(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	handler, _ := newGuardrailsTestHandler(t, auditLog)
+	bob := auth.User{Name: "bob", Method: auth.MethodOIDC, Groups: []string{"dev"}}
+
+	if rr := serveAs(handler, bob, http.MethodPut, "/api/kv/users", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected changes of read-only cluster to be forbidden, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, bob, http.MethodPut, "/api/clusters/prod/tags", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected untagging read-only cluster to be forbidden, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, bob, http.MethodGet, "/api/kv/users", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected reads of read-only cluster, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, bob, http.MethodPut, "/api/kv/users?cluster=dev", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected changes of other clusters, got status %d", rr.Code)
+	}
+	// Releasing locks changes the default cluster, naming another cluster doesn't route it there
+	if rr := serveAs(handler, bob, http.MethodPost, "/api/locks/release?cluster=dev", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected changes of read-only default cluster to be forbidden, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, auth.User{Name: "carol", Method: auth.MethodOIDC, Groups: []string{"sre"}}, http.MethodPut, "/api/kv/users", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected admins by group to change read-only cluster, got status %d", rr.Code)
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %d", len(entries))
+	}
+	if e := entries[2]; e.User != "bob" || e.Outcome != audit.OutcomeDenied || e.Resource != "kv/users" || e.Details["cluster"] != "prod" {
+		t.Errorf("Unexpected audit entry: %+v", e)
+	}
+}
+
+func TestEnforceGuardrailsForCluster(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	_, g := newGuardrailsTestHandler(t, auditLog)
+	// Replays of recorded requests change the cluster they are replayed on, whatever they name
+	replay := EnforceGuardrails(g.ForCluster("prod"), auditLog, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	bob := auth.User{Name: "bob", Method: auth.MethodOIDC, Groups: []string{"dev"}}
+
+	if rr := serveAs(replay, bob, http.MethodPut, "/api/kv/users?cluster=dev", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected replayed changes of read-only cluster to be forbidden, got status %d", rr.Code)
+	}
+	if rr := serveAs(replay, bob, http.MethodGet, "/api/kv/users?cluster=dev", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected replayed reads of read-only cluster, got status %d", rr.Code)
+	}
+	replay = EnforceGuardrails(g.ForCluster("dev"), auditLog, zap.NewNop())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	if rr := serveAs(replay, bob, http.MethodPut, "/api/kv/users?cluster=prod", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected replayed changes of other clusters, got status %d", rr.Code)
+	}
+}
+
+func TestEnforceGuardrailsApproval(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	handler, g := newGuardrailsTestHandler(t, auditLog)
+	alice := auth.User{Name: "alice", Method: auth.MethodOIDC}
+	carol := auth.User{Name: "carol", Method: auth.MethodOIDC, Groups: []string{"sre"}}
+
+	// Even admins must have table deletions approved
+	rr := serveAs(handler, alice, http.MethodDelete, "/api/tables/users", nil)
+	if rr.Code != http.StatusPreconditionRequired {
+		t.Fatalf("Expected deletion to be held back, got status %d", rr.Code)
+	}
+	var approval Approval
+	if err := json.NewDecoder(rr.Body).Decode(&approval); err != nil {
+		t.Fatal(err)
+	}
+	if approval.ID == "" || approval.User != "oidc:alice" || approval.Cluster != "prod" || approval.Action != "table.delete" {
+		t.Fatalf("Unexpected approval: %+v", approval)
+	}
+	header := http.Header{ApprovalHeader: []string{approval.ID}}
+
+	if rr := serveAs(handler, alice, http.MethodDelete, "/api/tables/users", header); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected pending approval to be refused, got status %d", rr.Code)
+	}
+	if _, err := g.Approvals.Approve(approval.ID, "oidc:alice"); err != ErrSelfApproval {
+		t.Errorf("Expected self approval to fail, got %v", err)
+	}
+	if _, err := g.Approvals.Approve(approval.ID, "oidc:carol"); err != nil {
+		t.Fatal(err)
+	}
+	if rr := serveAs(handler, carol, http.MethodDelete, "/api/tables/users", header); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected approvals of other users to be refused, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, alice, http.MethodDelete, "/api/tables/orders", header); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected approvals of other changes to be refused, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, alice, http.MethodDelete, "/api/tables/users", header); rr.Code != http.StatusOK {
+		t.Errorf("Expected approved deletion, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, alice, http.MethodDelete, "/api/tables/users", header); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected approvals to be used once, got status %d", rr.Code)
+	}
+	if rr := serveAs(handler, alice, http.MethodDelete, "/api/tables/users?cluster=dev", nil); rr.Code != http.StatusOK {
+		t.Errorf("Expected deletions in other clusters without approval, got status %d", rr.Code)
+	}
+}
+
+func TestEnforceGuardrailsApprovalCoversQuery(t *testing.T) {
+	handler, g := newGuardrailsTestHandler(t, audit.NewMemoryLog(10))
+	alice := auth.User{Name: "alice", Method: auth.MethodOIDC}
+
+	rr := serveAs(handler, alice, http.MethodDelete, "/api/kv/users?key=a&cluster=prod", nil)
+	if rr.Code != http.StatusPreconditionRequired {
+		t.Fatalf("Expected deletion to be held back, got status %d", rr.Code)
+	}
+	var approval Approval
+	if err := json.NewDecoder(rr.Body).Decode(&approval); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Approvals.Approve(approval.ID, "oidc:carol"); err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{ApprovalHeader: []string{approval.ID}}
+
+	// Approving the deletion of a key doesn't approve wiping the table
+	for _, target := range []string{"/api/kv/users?prefix=&cluster=prod", "/api/kv/users?cluster=prod", "/api/kv/users?key=b&cluster=prod"} {
+		if rr := serveAs(handler, alice, http.MethodDelete, target, header); rr.Code != http.StatusForbidden {
+			t.Errorf("DELETE %s: expected the approval to be refused, got status %d", target, rr.Code)
+		}
+	}
+	// The order of the parameters doesn't matter
+	if rr := serveAs(handler, alice, http.MethodDelete, "/api/kv/users?cluster=prod&key=a", header); rr.Code != http.StatusOK {
+		t.Errorf("Expected approved deletion, got status %d", rr.Code)
+	}
+}
+
+func TestApprovalsExpire(t *testing.T) {
+	approvals := NewApprovals(time.Minute)
+	now := time.Now()
+	approvals.now = func() time.Time { return now }
+	approval, err := approvals.Request("oidc:bob", "prod", "table.delete", http.MethodDelete, "/api/tables/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := approvals.List(); len(got) != 1 || got[0].ID != approval.ID {
+		t.Errorf("List() = %+v, want the requested approval", got)
+	}
+	now = now.Add(time.Minute)
+	if _, err := approvals.Approve(approval.ID, "oidc:alice"); err != ErrApprovalNotFound {
+		t.Errorf("Expected expired approval to be gone, got %v", err)
+	}
+	if got := approvals.List(); len(got) != 0 {
+		t.Errorf("List() = %+v, want none", got)
+	}
+}
+
+func TestGuardrailAdminsByPrincipal(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	handler, g := newGuardrailsTestHandler(t, auditLog)
+	r := chi.NewRouter()
+	NewApprovalHandler(g, auditLog, zap.NewNop()).RegisterRoutes(r)
+
+	// Operators may create API keys and local users with any name, they aren't the admin of that name
+	for _, method := range []string{auth.MethodAPIKey, auth.MethodBasic} {
+		namesake := auth.User{Name: "alice", Method: method}
+		if rr := serveAs(handler, namesake, http.MethodPut, "/api/kv/users", nil); rr.Code != http.StatusForbidden {
+			t.Errorf("Expected %s user named like an admin to be refused, got status %d", method, rr.Code)
+		}
+		approval, err := g.Approvals.Request("oidc:bob", "prod", "table.delete", http.MethodDelete, "/api/tables/users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rr := serveAs(r, namesake, http.MethodPost, "/api/approvals/"+approval.ID+"/approve", nil); rr.Code != http.StatusForbidden {
+			t.Errorf("Expected %s user named like an admin not to approve, got status %d", method, rr.Code)
+		}
+		if rr := serveAs(r, auth.User{Name: "bob", Method: method}, http.MethodDelete, "/api/approvals/"+approval.ID, nil); rr.Code != http.StatusForbidden {
+			t.Errorf("Expected %s user named like the requester not to withdraw the approval, got status %d", method, rr.Code)
+		}
+	}
+}
+
+func TestApprovalHandler(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	_, g := newGuardrailsTestHandler(t, auditLog)
+	r := chi.NewRouter()
+	NewApprovalHandler(g, auditLog, zap.NewNop()).RegisterRoutes(r)
+	approval, err := g.Approvals.Request("oidc:bob", "prod", "table.delete", http.MethodDelete, "/api/tables/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := serveAs(r, auth.User{Name: "dave", Method: auth.MethodOIDC}, http.MethodPost, "/api/approvals/"+approval.ID+"/approve", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected approvals of non-admins to be forbidden, got status %d", rr.Code)
+	}
+	if rr := serveAs(r, auth.User{Name: "alice", Method: auth.MethodOIDC}, http.MethodPost, "/api/approvals/unknown/approve", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown approval to be not found, got status %d", rr.Code)
+	}
+	rr := serveAs(r, auth.User{Name: "alice", Method: auth.MethodOIDC}, http.MethodPost, "/api/approvals/"+approval.ID+"/approve", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected approval, got status %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = serveAs(r, auth.User{Name: "dave", Method: auth.MethodOIDC}, http.MethodGet, "/api/approvals", nil)
+	var approvals []Approval
+	if err := json.NewDecoder(rr.Body).Decode(&approvals); err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || approvals[0].ApprovedBy != "oidc:alice" || approvals[0].ApprovedAt == nil {
+		t.Errorf("Unexpected approvals: %+v", approvals)
+	}
+
+	if rr := serveAs(r, auth.User{Name: "dave", Method: auth.MethodOIDC}, http.MethodDelete, "/api/approvals/"+approval.ID, nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Expected rejecting approvals of others to be forbidden, got status %d", rr.Code)
+	}
+	if rr := serveAs(r, auth.User{Name: "bob", Method: auth.MethodOIDC}, http.MethodDelete, "/api/approvals/"+approval.ID, nil); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected users to withdraw their approvals, got status %d", rr.Code)
+	}
+	if got := g.Approvals.List(); len(got) != 0 {
+		t.Errorf("Expected rejected approval to be removed, got %+v", got)
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, e := range entries {
+		actions = append(actions, e.Action+" "+e.Outcome)
+	}
+	want := []string{"approval.reject success", "approval.grant success", "approval.grant failure", "approval.grant failure"}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("Audited %v, want %v", actions, want)
+	}
+}
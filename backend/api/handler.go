@@ -4,13 +4,29 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
+	"github.com/armadakv/console/backend/apiversion"
 	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/armadakv/console/backend/coalesce"
+	"github.com/armadakv/console/backend/growth"
+	"github.com/armadakv/console/backend/hotkeys"
+	"github.com/armadakv/console/backend/indexes"
+	"github.com/armadakv/console/backend/maintenance"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/polling"
+	"github.com/armadakv/console/backend/render"
+	"github.com/armadakv/console/backend/transform"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-rat/chix"
 	"go.uber.org/zap"
 	"net/http"
 	"slices"
+	"strconv"
 	"sync"
+	"time"
 )
 
 // ArmadaClient is the interface for interacting with the Armada server.
@@ -36,11 +52,11 @@ type ArmadaClient interface {
 	GetTables(ctx context.Context) ([]armada.Table, error)
 
 	// CreateTable creates a new table in the Armada server.
-	// It returns the ID of the newly created table.
+	// It returns the ID of the newly created table, or armada.ErrTableExists if the name is taken.
 	CreateTable(ctx context.Context, tableName string) (string, error)
 
 	// DeleteTable deletes a table from the Armada server.
-	// It returns an error if the operation fails.
+	// It returns armada.ErrTableNotFound if the table does not exist, or an error if the operation fails.
 	DeleteTable(ctx context.Context, tableName string) error
 
 	// GetKeyValuePairs retrieves key-value pairs from the specified table.
@@ -51,8 +67,12 @@ type ArmadaClient interface {
 	// It returns a slice of KeyValuePair objects.
 	GetKeyValuePairs(ctx context.Context, table string, prefix string, start string, end string, limit int) ([]armada.KeyValuePair, error)
 
+	// EstimateKeyValuePairs counts the keys GetKeyValuePairs would return without a limit
+	// and estimates their size, without fetching them.
+	EstimateKeyValuePairs(ctx context.Context, table, prefix, start, end string) (*armada.RangeEstimate, error)
+
 	// GetKeyValue retrieves a specific key-value pair from the specified table.
-	// It returns the key-value pair if found, or an error if not found or if the operation fails.
+	// It returns the key-value pair if found, armada.ErrKeyNotFound if not found, or an error if the operation fails.
 	GetKeyValue(ctx context.Context, table string, key string) (*armada.KeyValuePair, error)
 
 	// PutKeyValue stores a key-value pair in the Armada server.
@@ -65,6 +85,17 @@ type ArmadaClient interface {
 	// It returns an error if the operation fails.
 	DeleteKey(ctx context.Context, table, key string) error
 
+	// PutKeyValueIf stores a key-value pair only if the key holds the value current, or, if current
+	// is nil, only if the key does not exist. It reports whether the pair was stored.
+	PutKeyValueIf(ctx context.Context, table, key, value string, current *string) (bool, error)
+
+	// DeleteKeyIf deletes a key only if it holds the value current. It reports whether the key was deleted.
+	DeleteKeyIf(ctx context.Context, table, key, current string) (bool, error)
+
+	// DeletePrefix deletes all keys starting with a non-empty prefix from a table.
+	// It returns the number of deleted keys, or an error if the operation fails.
+	DeletePrefix(ctx context.Context, table, prefix string) (int64, error)
+
 	// GetMetrics retrieves all Prometheus metrics from the Armada server.
 	// The format parameter can specify the desired output format.
 	// It returns metrics data and collection timestamp.
@@ -84,16 +115,32 @@ type ServerStatus struct {
 	Config  map[string]interface{}        `json:"config,omitempty"`
 	Tables  map[string]armada.TableStatus `json:"tables,omitempty"`
 	Errors  []string                      `json:"errors,omitempty"`
+	// Error is set when the status of the server could not be retrieved
+	Error string `json:"error,omitempty"`
 }
 
 // StatusResponse represents the response for the status API endpoint
 type StatusResponse struct {
 	Servers []ServerStatus `json:"servers"`
+	// Partial is true when the status of at least one server could not be retrieved
+	Partial bool `json:"partial"`
+	// SuggestedRefreshSeconds hints how long clients should wait before polling again
+	SuggestedRefreshSeconds int `json:"suggestedRefreshSeconds,omitempty"`
+	// Revision identifies this state, pass it as since to only get the servers changed afterwards
+	Revision string `json:"revision"`
+	// Delta is true when Servers only contains the servers changed since the requested revision
+	Delta bool `json:"delta,omitempty"`
+	// Removed lists the IDs of servers that left the cluster since the requested revision
+	Removed []string `json:"removed,omitempty"`
+	// Tags are the tags of the cluster
+	Tags cluster.Tags `json:"tags,omitempty"`
 }
 
 // CreateTableRequest represents the request for the create table API endpoint
 type CreateTableRequest struct {
 	Name string `json:"name"`
+	// Purpose optionally describes what the table is used for
+	Purpose string `json:"purpose,omitempty"`
 }
 
 // CreateTableResponse represents the response for the create table API endpoint
@@ -101,20 +148,141 @@ type CreateTableResponse struct {
 	ID string `json:"id"`
 }
 
+// DeletePrefixResponse represents the response for deleting keys by prefix
+type DeletePrefixResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
 // Handler is the main API handler that registers all API routes
 type Handler struct {
 	client     ArmadaClient
 	clientLock sync.RWMutex
 	armadaURL  string
 	logger     *zap.Logger
+	// deleted remembers tables and keys removed through the console to answer 410 Gone
+	deleted *tombstones
+	// tableStats provides sampled table sizes, it may be nil
+	tableStats TableStatsSource
+	// meta stores console-side annotations such as table metadata
+	meta metadata.Store
+	// auditLog records destructive operations together with state snapshots
+	auditLog audit.Log
+	// snapshotSample is the number of keys sampled into snapshots, 0 disables sampling
+	snapshotSample int
+	// reads coalesces identical concurrent reads into one upstream call
+	reads coalesce.Group
+	// refresh suggests polling intervals to clients, it may be nil
+	refresh *polling.Advisor
+	// statuses assigns revisions to observed cluster states for delta responses
+	statuses *statusTracker
+	// transforms decodes values stored in encoded form, e.g. compressed
+	transforms *transform.Pipeline
+	// hotKeys samples key-value requests for the hot key analysis, it may be nil
+	hotKeys *hotkeys.Tracker
+	// maintenance freezes writes during scheduled maintenance windows, it may be nil
+	maintenance *maintenance.Scheduler
+	// indexes answer repeated value filters of key scans, it may be nil
+	indexes *indexes.Builder
+	// growth reads the recorded key counts of tables, it may be nil
+	growth growth.SampleReader
+	// sizeCap is the table size growth reports project the time to reach for, 0 if none
+	sizeCap int64
+	// cluster is the name of the cluster the maintenance windows are matched against
+	cluster string
+	// rangeTimeout is the budget of key range scans, 0 leaves them bounded by the request only
+	rangeTimeout time.Duration
+	// timeouts bound the other requests by route group
+	timeouts RouteTimeouts
+	// tags returns the current tags of the cluster, it may be nil
+	tags func() cluster.Tags
+}
+
+// HandlerOption configures optional dependencies of the Handler
+type HandlerOption func(*Handler)
+
+// WithTableStats makes the tables endpoint include sampled statistics and support sorting by size
+func WithTableStats(source TableStatsSource) HandlerOption {
+	return func(h *Handler) {
+		h.tableStats = source
+	}
+}
+
+// WithMetadataStore sets the store for console-side annotations.
+// Without it annotations are kept in memory and lost on restart.
+func WithMetadataStore(store metadata.Store) HandlerOption {
+	return func(h *Handler) {
+		h.meta = store
+	}
+}
+
+// WithAuditLog sets the log destructive operations are recorded in.
+// Without it entries are kept in memory and lost on restart.
+func WithAuditLog(log audit.Log) HandlerOption {
+	return func(h *Handler) {
+		h.auditLog = log
+	}
+}
+
+// WithSnapshotSample makes state snapshots include up to n keys of the affected range
+func WithSnapshotSample(n int) HandlerOption {
+	return func(h *Handler) {
+		h.snapshotSample = n
+	}
+}
+
+// WithRefreshAdvisor makes polled responses include a suggested refresh interval
+func WithRefreshAdvisor(advisor *polling.Advisor) HandlerOption {
+	return func(h *Handler) {
+		h.refresh = advisor
+	}
+}
+
+// WithHotKeys samples the key-value requests served by the handler into the tracker
+func WithHotKeys(tracker *hotkeys.Tracker) HandlerOption {
+	return func(h *Handler) {
+		h.hotKeys = tracker
+	}
+}
+
+// WithRangeTimeout bounds key range scans. Scans exceeding the budget answer with the keys
+// received so far and a cursor to continue from instead of failing.
+func WithRangeTimeout(timeout time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.rangeTimeout = timeout
+	}
+}
+
+// WithRouteTimeouts bounds the requests of each route group, see RouteTimeouts
+func WithRouteTimeouts(timeouts RouteTimeouts) HandlerOption {
+	return func(h *Handler) {
+		h.timeouts = timeouts
+	}
+}
+
+// WithClusterTags reports the tags of the cluster with its status and lets status requests
+// filtered by tags fail for clusters without them. Tags can change at runtime, so they are
+// looked up for every request.
+func WithClusterTags(tags func() cluster.Tags) HandlerOption {
+	return func(h *Handler) {
+		h.tags = tags
+	}
 }
 
 // NewHandler creates a new API handler
-func NewHandler(client *armada.Client, logger *zap.Logger) *Handler {
-	return &Handler{
-		client: client,
-		logger: logger,
+func NewHandler(client *armada.Client, logger *zap.Logger, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		client:     client,
+		logger:     logger,
+		deleted:    newTombstones(),
+		meta:       metadata.NewMemoryStore(),
+		auditLog:   audit.NewMemoryLog(1000),
+		statuses:   newStatusTracker(),
+		transforms: transform.Default(),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // RegisterRoutes registers all API routes with the provided router
@@ -131,31 +299,47 @@ func NewHandler(client *armada.Client, logger *zap.Logger) *Handler {
 //
 // For more information on Chi, see: https://github.com/go-chi/chi
 func (h *Handler) RegisterRoutes(r chi.Router) {
-	// Create a subrouter with the Armada client middleware
 	apiRouter := chi.NewRouter()
 
 	// Register API routes
-	apiRouter.Get("/status", h.handleStatus)
-	apiRouter.Get("/cluster", h.handleCluster)
-	apiRouter.Get("/servers", h.handleServers)
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(RequestTimeout(h.timeouts.Status))
+		r.Get("/status", h.handleStatus)
+		r.Get("/cluster", h.handleCluster)
+		r.Get("/servers", h.handleServers)
+	})
 
 	// Tables management
 	apiRouter.Route("/tables", func(r chi.Router) {
+		r.Use(RequestTimeout(h.timeouts.Tables))
 		r.Get("/", h.handleTables)
 		r.Post("/", h.handleCreateTable)
+		r.Get("/{name}", h.handleGetTable)
+		r.Get("/{name}/growth", h.handleTableGrowth)
 		r.Delete("/{name}", h.handleDeleteTable)
+		r.Put("/{name}/metadata", h.handlePutTableMetadata)
+		r.Put("/{name}/protection", h.handleSetTableProtection(true))
+		r.Delete("/{name}/protection", h.handleSetTableProtection(false))
+		r.Put("/{name}/read-only", h.handleSetTableReadOnly(true))
+		r.Delete("/{name}/read-only", h.handleSetTableReadOnly(false))
 	})
 
 	// Group related KV routes
 	apiRouter.Route("/kv", func(r chi.Router) {
 		// URL parameter extraction for table
 		r.Route("/{table}", func(r chi.Router) {
+			// Scans have their own budget and answer with a partial result when it is exceeded
 			r.Get("/", h.handleGetKeyValue)
-			r.Put("/", h.handlePutKeyValue)
-			// URL parameter extraction for key
-			r.Delete("/", h.handleDeleteKey)
-			// Get a specific key-value pair by key
-			r.Get("/{key}", h.handleGetSpecificKeyValue)
+			r.Group(func(r chi.Router) {
+				r.Use(RequestTimeout(h.timeouts.KV))
+				// Estimates the size of a scan before the client commits to it
+				r.Head("/", h.handleEstimateKeyValue)
+				r.Put("/", h.handlePutKeyValue)
+				// Deletes a single key, or all keys with a prefix
+				r.Delete("/", h.handleDeleteKey)
+				// Get a specific key-value pair by key
+				r.Get("/{key}", h.handleGetSpecificKeyValue)
+			})
 		})
 	})
 
@@ -164,80 +348,179 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 }
 
 // handleStatus handles the status API endpoint
+// @Summary Get cluster status
+// @Description Get the status of every server of the cluster. Servers that can't be reached are reported with an error and mark the response as partial.
+// @Tags cluster
+// @Produce json
+// @Param since query string false "Revision of a previous response, only the servers changed since are returned"
+// @Param tags query string false "Tags the cluster must have, e.g. env=prod,region=eu"
+// @Success 200 {object} StatusResponse
+// @Failure 400 {string} string "Invalid tags"
+// @Failure 404 {string} string "Cluster does not have the tags"
+// @Failure 500 {string} string "Failed to get servers"
+// @Router /api/status [get]
 func (h *Handler) handleStatus(w http.ResponseWriter, r *http.Request) {
 	// Get the Armada client from the request context
 	render := chix.NewRender(w)
 
-	// Get all servers from the Armada cluster
-	servers, err := h.client.GetAllServers(r.Context())
+	// Dashboards scoped to tags, e.g. env=prod, don't show clusters outside their scope
+	filter, err := tagFilter(r)
+	if err != nil {
+		http.Error(w, "Invalid tags: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var tags cluster.Tags
+	if h.tags != nil {
+		tags = h.tags()
+	}
+	if !tags.Matches(filter) {
+		http.Error(w, "Cluster does not have the tags", http.StatusNotFound)
+		return
+	}
+
+	// Concurrent status requests share a single fan-out to the servers
+	status, _, err := coalesce.Do(r.Context(), &h.reads, "status", func(ctx context.Context) (StatusResponse, error) {
+		servers, err := h.client.GetAllServers(ctx)
+		if err != nil {
+			return StatusResponse{}, err
+		}
+		return h.collectStatus(ctx, servers), nil
+	})
 	if err != nil {
 		h.logger.Error("Failed to get servers from Armada cluster", zap.Error(err))
 		http.Error(w, "Failed to get servers", http.StatusInternalServerError)
 		return
 	}
 
-	// Create a response with statuses of all servers
-	response := StatusResponse{
-		Servers: make([]ServerStatus, 0, len(servers)),
-	}
+	status.SuggestedRefreshSeconds = h.refresh.Suggest("status", status)
+	// Frequent pollers pass the revision they have seen to only get what changed
+	status = h.statuses.apply(status, r.URL.Query().Get("since"))
+	status.Tags = tags
+	render.Header("ETag", `"`+status.Revision+`"`)
+	render.JSON(status)
+}
 
-	// Get the status of each server individually
-	for _, server := range servers {
-		// Use the first PeerURL as the server address
+// collectStatus queries the status of every server concurrently. Unreachable servers
+// are annotated with an error and mark the response as partial instead of failing it.
+func (h *Handler) collectStatus(ctx context.Context, servers []armada.Server) StatusResponse {
+	statuses, errs := fanOut(ctx, servers, func(ctx context.Context, server armada.Server) (*armada.Status, error) {
+		// Use the first client URL as the server address
 		var serverAddress string
 		if len(server.ClientURLs) > 0 {
 			serverAddress = server.ClientURLs[0]
 		}
-
-		// Get the status of this server
-		status, err := h.client.GetStatus(r.Context(), serverAddress)
+		status, err := h.client.GetStatus(ctx, serverAddress)
+		if err == nil && status.Status == "error" {
+			// The client reports connection failures as an error status
+			err = errors.New(status.Message)
+		}
 		if err != nil {
 			h.logger.Error("Failed to get status from Armada server",
 				zap.Error(err),
 				zap.String("serverID", server.ID),
 				zap.String("serverAddress", serverAddress))
+		}
+		return status, err
+	})
 
-			// Add a fallback status for this server
+	response := StatusResponse{
+		Servers: make([]ServerStatus, 0, len(servers)),
+	}
+	for i, server := range servers {
+		if errs[i] != nil {
+			message := "Failed to connect to Armada server: " + errs[i].Error()
+			if statuses[i] != nil && statuses[i].Message != "" {
+				message = statuses[i].Message
+			}
+			response.Partial = true
 			response.Servers = append(response.Servers, ServerStatus{
 				ID:      server.ID,
 				Name:    server.Name,
 				Status:  "error",
-				Message: "Failed to connect to Armada server: " + err.Error(),
-			})
-		} else {
-			// Add the status for this server
-			response.Servers = append(response.Servers, ServerStatus{
-				ID:      server.ID,
-				Name:    server.Name,
-				Status:  status.Status,
-				Message: status.Message,
-				Config:  status.Config, // Include the config data
-				Tables:  status.Tables, // Include the tables data
-				Errors:  status.Errors, // Include the errors data
+				Message: message,
+				Error:   errs[i].Error(),
 			})
+			continue
 		}
+
+		status := statuses[i]
+		response.Servers = append(response.Servers, ServerStatus{
+			ID:      server.ID,
+			Name:    server.Name,
+			Status:  status.Status,
+			Message: status.Message,
+			Config:  status.Config, // Include the config data
+			Tables:  status.Tables, // Include the tables data
+			Errors:  status.Errors, // Include the errors data
+		})
 	}
 	slices.SortFunc(response.Servers, func(e ServerStatus, e2 ServerStatus) int {
 		return cmp.Compare(e.Name, e2.Name)
 	})
-	render.JSON(response)
+	return response
 }
 
-// handleTables handles the tables API endpoint
+// handleTables handles the tables API endpoint.
+// Tables can be filtered by name, sorted by name or by their sampled size and paginated.
+// The total number of matching tables is returned in the X-Total-Count header
+// and links to adjacent pages in the Link header.
+// @Summary List tables
+// @Description List the tables with their sampled statistics and annotations
+// @Tags tables
+// @Produce json
+// @Param filter query string false "Case-insensitive substring of the table name"
+// @Param sort query string false "Sort key" Enums(name, size)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param limit query int false "Page size, all tables by default"
+// @Param offset query int false "Number of tables to skip"
+// @Success 200 {array} TableListItem
+// @Header 200 {integer} X-Total-Count "Number of matching tables"
+// @Header 200 {string} Link "Links to the adjacent pages"
+// @Failure 400 {string} string "Invalid query"
+// @Failure 500 {string} string "Failed to get tables"
+// @Router /api/tables [get]
 func (h *Handler) handleTables(w http.ResponseWriter, r *http.Request) {
-	render := chix.NewRender(w)
+	query, err := parseTablesQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get the tables from the Armada server
-	tables, err := h.client.GetTables(r.Context())
+	tables, err := h.getTables(r.Context())
 	if err != nil {
 		h.logger.Error("Failed to get tables from Armada server", zap.Error(err))
 		http.Error(w, "Failed to get tables", http.StatusInternalServerError)
 		return
 	}
 
-	render.JSON(tables)
+	page, total := query.apply(tables, h.tableStats, h.tableMetadata())
+	h.markReadOnly(page)
+	setPaginationHeaders(w, r, query, total)
+	if err := render.JSONArray(w, http.StatusOK, page); err != nil {
+		h.logger.Warn("Failed to write tables response", zap.Error(err))
+	}
+}
+
+// getTables lists the tables, sharing the upstream call between concurrent requests.
+// The returned slice is shared and must not be modified.
+func (h *Handler) getTables(ctx context.Context) ([]armada.Table, error) {
+	tables, _, err := coalesce.Do(ctx, &h.reads, "tables", h.client.GetTables)
+	return tables, err
 }
 
 // handleCreateTable handles the create table API endpoint
+// @Summary Create table
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param request body CreateTableRequest true "Table to create"
+// @Success 201 {object} CreateTableResponse
+// @Header 201 {string} Location "Path of the new table"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 409 {string} string "Table already exists"
+// @Failure 423 {string} string "Writes are frozen by a maintenance window"
+// @Router /api/tables [post]
 func (h *Handler) handleCreateTable(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 
@@ -253,9 +536,16 @@ func (h *Handler) handleCreateTable(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Table name is required", http.StatusBadRequest)
 		return
 	}
+	if !h.checkWritable(w, req.Name) {
+		return
+	}
 
 	// Create the table
 	tableID, err := h.client.CreateTable(r.Context(), req.Name)
+	if errors.Is(err, armada.ErrTableExists) {
+		http.Error(w, "Table already exists: "+req.Name, http.StatusConflict)
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to create table",
 			zap.Error(err),
@@ -263,12 +553,63 @@ func (h *Handler) handleCreateTable(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to create table: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.deleted.remove(tableResourceID(req.Name))
+	h.recordTableCreation(r, req.Name, req.Purpose)
 
-	// Return the table ID
+	// Return the table ID along with the location of the new resource
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/tables/"+req.Name)))
+	render.Status(http.StatusCreated)
 	render.JSON(CreateTableResponse{ID: tableID})
 }
 
+// handleGetTable handles the API endpoint returning a single table by name
+// @Summary Get table
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name"
+// @Success 200 {object} TableListItem
+// @Failure 404 {string} string "Table not found"
+// @Failure 410 {string} string "Table was deleted"
+// @Router /api/tables/{name} [get]
+func (h *Handler) handleGetTable(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	tableName := chi.URLParam(r, "name")
+	if tableName == "" {
+		http.Error(w, "Table name is required", http.StatusBadRequest)
+		return
+	}
+
+	tables, err := h.getTables(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get tables from Armada server", zap.Error(err))
+		http.Error(w, "Failed to get tables", http.StatusInternalServerError)
+		return
+	}
+
+	for _, table := range tables {
+		if table.Name == tableName {
+			item := []TableListItem{newTableListItem(table, h.tableStats, h.tableMetadata())}
+			h.markReadOnly(item)
+			render.JSON(item[0])
+			return
+		}
+	}
+
+	http.Error(w, "Table not found: "+tableName, h.deleted.missingStatus(tableResourceID(tableName)))
+}
+
 // handleDeleteTable handles the delete table API endpoint
+// @Summary Delete table
+// @Description Delete a table, its state is kept with the audit entry
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Table not found"
+// @Failure 409 {string} string "Table is protected"
+// @Failure 423 {string} string "Table is read-only"
+// @Router /api/tables/{name} [delete]
 func (h *Handler) handleDeleteTable(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 
@@ -279,8 +620,18 @@ func (h *Handler) handleDeleteTable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete the table
+	if !h.checkUnprotected(w, tableName) || !h.checkWritable(w, tableName) {
+		return
+	}
+
+	// Capture the state before deleting the table so it is kept with the audit entry
+	snapshot := h.captureSnapshot(r.Context(), tableName, "")
 	err := h.client.DeleteTable(r.Context(), tableName)
+	h.recordAudit(r, "table.delete", tableResourceID(tableName), err, nil, snapshot)
+	if errors.Is(err, armada.ErrTableNotFound) {
+		http.Error(w, "Table not found: "+tableName, h.deleted.missingStatus(tableResourceID(tableName)))
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to delete table",
 			zap.Error(err),
@@ -288,14 +639,35 @@ func (h *Handler) handleDeleteTable(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to delete table: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.deleted.add(tableResourceID(tableName))
+	h.forgetTable(tableName)
 
 	// Return an empty response
 	render.JSON(make(map[string]any))
 }
 
 // handleGetKeyValue handles the GET method for the key-value API endpoint
+// @Summary List key-value pairs
+// @Description List up to 100 key-value pairs of a table, selected by a prefix or a range and optionally a filter of their JSON values. Scans exceeding the range timeout return the pairs received so far.
+// @Tags kv
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param table path string true "Table name"
+// @Param prefix query string false "Key prefix"
+// @Param start query string false "First key of the range"
+// @Param end query string false "End of the range, exclusive"
+// @Param cursor query string false "Continuation cursor of a truncated scan with the same filter"
+// @Param decode query string false "Decode stored values" Enums(none, auto, decompress)
+// @Param project query []string false "JSONPath of a field to return instead of the whole JSON value, e.g. $.user.name; repeat for further fields" collectionFormat(multi)
+// @Param filter query string false "Predicate the JSON values must match, e.g. status=='active' && retries>3"
+// @Param format query string false "Response format: json (default), or csv or xlsx to download a row per pair with its key and value, or its projected fields" Enums(json, csv, xlsx)
+// @Success 200 {array} armada.KeyValuePair "Pairs, or ProjectedKeyValuePair items if fields are projected"
+// @Header 200 {string} X-Truncated "true if the scan timed out, or a filtered scan read its maximum of pairs"
+// @Header 200 {string} X-Index "Index the filtered scan was answered from"
+// @Header 200 {string} X-Continuation-Cursor "Cursor continuing a truncated scan"
+// @Failure 400 {string} string "Invalid filter or path"
+// @Failure 504 {string} string "Timed out before any pair was received"
+// @Router /api/kv/{table} [get]
 func (h *Handler) handleGetKeyValue(w http.ResponseWriter, r *http.Request) {
-	render := chix.NewRender(w, r)
 	// Get the table from the URL parameters
 	table := chi.URLParam(r, "table")
 	if table == "" {
@@ -303,32 +675,91 @@ func (h *Handler) handleGetKeyValue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get filtering parameters from query
-	prefix := r.URL.Query().Get("prefix")
-	start := r.URL.Query().Get("start")
-	end := r.URL.Query().Get("end")
+	prefix, start, end, ok := scanFilter(w, r)
+	if !ok {
+		return
+	}
 	limit := 100 // Default limit
 
-	// Validate parameters - we either need a prefix OR a start-end range (or neither for all keys)
-	if prefix != "" && (start != "" || end != "") {
-		http.Error(w, "Cannot specify both prefix and start/end range", http.StatusBadRequest)
+	decoder, err := h.decoder(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// If start is specified but end is not, return an error
-	if start != "" && end == "" {
-		http.Error(w, "Must provide both start and end for range filtering", http.StatusBadRequest)
+	paths, err := projection(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	// If end is specified but start is not, return an error
-	if end != "" && start == "" {
-		http.Error(w, "Must provide both start and end for range filtering", http.StatusBadRequest)
+	filter, err := valueFilterOf(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, tabular, err := render.TableFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	// A cursor continues a truncated scan, it replaces the start of the range
+	scanPrefix, scanStart, scanEnd := prefix, start, end
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		cursor, ok := decodeCursor(raw, prefix, start, end)
+		if !ok {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		scanPrefix, scanStart, scanEnd = continueScan(prefix, end, cursor)
+	}
+
 	// Get key-value pairs with the specified filtering
-	pairs, err := h.client.GetKeyValuePairs(r.Context(), table, prefix, start, end, limit)
+	h.hotKeys.Record(table, cmp.Or(prefix, start), hotkeys.OpScan)
+	ctx := r.Context()
+	if h.rangeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.rangeTimeout)
+		defer cancel()
+	}
+	var pairs []armada.KeyValuePair
+	indexed := false
+	if filter != nil && decoder == nil && h.indexes != nil {
+		// Indexes are built over the stored values, so scans decoding values don't use them
+		if idx, ok := h.indexes.Lookup(table, filter); ok {
+			pairs, err = h.scanIndexed(ctx, table, idx, prefix, end, scanPrefix, scanStart, scanEnd, limit, filter)
+			if err == nil || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				indexed = true
+				w.Header().Set(IndexHeader, idx.Name)
+				w.Header().Set(IndexBuiltAtHeader, idx.BuiltAt.UTC().Format(time.RFC3339))
+			} else {
+				h.logger.Warn("Failed to scan index, scanning the table", zap.Error(err),
+					zap.String("table", table), zap.String("index", idx.Name))
+			}
+		}
+	}
+	if indexed {
+		// Answered from the index
+	} else if filter != nil {
+		// Values are matched while scanning, so only matching pairs are returned
+		var next string
+		pairs, next, err = h.scanMatching(ctx, table, prefix, end, scanPrefix, scanStart, scanEnd, limit, decoder, filter)
+		if next != "" {
+			w.Header().Set(TruncatedHeader, "true")
+			w.Header().Set(CursorHeader, encodeCursor(next))
+		}
+	} else {
+		pairs, err = h.client.GetKeyValuePairs(ctx, table, scanPrefix, scanStart, scanEnd, limit)
+		if errors.Is(err, armada.ErrRangeTruncated) {
+			// Slow tables can still be paged through, the client continues after the last key
+			w.Header().Set(TruncatedHeader, "true")
+			w.Header().Set(CursorHeader, encodeCursor(pairs[len(pairs)-1].Key+"\x00"))
+			err = nil
+		}
+	}
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		http.Error(w, "Timed out before any key-value pair was received", http.StatusGatewayTimeout)
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to get key-value pairs",
 			zap.Error(err),
@@ -340,10 +771,107 @@ func (h *Handler) handleGetKeyValue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	render.JSON(pairs)
+	switch {
+	case tabular:
+		// Spreadsheets get a row per pair, the continuation headers are sent all the same
+		err = renderPairsTable(w, format, table, decoder, paths, pairs)
+	case paths != nil:
+		// Only the selected fields are transferred, so tabular views don't load whole documents
+		err = render.JSONArray(w, http.StatusOK, projectPairs(decoder, paths, pairs))
+	case decoder != nil:
+		err = render.JSONArray(w, http.StatusOK, decodePairs(decoder, pairs))
+	default:
+		err = render.JSONArray(w, http.StatusOK, pairs)
+	}
+	if err != nil {
+		h.logger.Warn("Failed to write key-value pairs response", zap.Error(err), zap.String("table", table))
+	}
+}
+
+// scanFilter returns the filter of a key scan from the query, or writes an error
+func scanFilter(w http.ResponseWriter, r *http.Request) (prefix, start, end string, ok bool) {
+	// Get filtering parameters from query
+	prefix = r.URL.Query().Get("prefix")
+	start = r.URL.Query().Get("start")
+	end = r.URL.Query().Get("end")
+
+	// Validate parameters - we either need a prefix OR a start-end range (or neither for all keys)
+	if prefix != "" && (start != "" || end != "") {
+		http.Error(w, "Cannot specify both prefix and start/end range", http.StatusBadRequest)
+		return "", "", "", false
+	}
+
+	// Start and end must be given together
+	if (start != "") != (end != "") {
+		http.Error(w, "Must provide both start and end for range filtering", http.StatusBadRequest)
+		return "", "", "", false
+	}
+	return prefix, start, end, true
+}
+
+const (
+	// EstimatedKeysHeader carries the number of keys a scan would return without a limit
+	EstimatedKeysHeader = "X-Estimated-Keys"
+	// EstimatedBytesHeader carries the approximate size of their keys and values
+	EstimatedBytesHeader = "X-Estimated-Bytes"
+)
+
+// handleEstimateKeyValue handles the HEAD method for the key-value API endpoint.
+// It answers with the estimated size of the scan the same GET request would run.
+// @Summary Estimate key scan
+// @Description Count the keys selected by the filter with a count-only range and estimate their size from a sample
+// @Tags kv
+// @Param table path string true "Table name"
+// @Param prefix query string false "Key prefix"
+// @Param start query string false "First key of the range"
+// @Param end query string false "End of the range, exclusive"
+// @Success 200 {string} string "Estimate in the X-Estimated-Keys and X-Estimated-Bytes headers"
+// @Failure 400 {string} string "Invalid filter"
+// @Router /api/kv/{table} [head]
+func (h *Handler) handleEstimateKeyValue(w http.ResponseWriter, r *http.Request) {
+	table := chi.URLParam(r, "table")
+	if table == "" {
+		http.Error(w, "Table is required", http.StatusBadRequest)
+		return
+	}
+	prefix, start, end, ok := scanFilter(w, r)
+	if !ok {
+		return
+	}
+
+	estimate, err := h.client.EstimateKeyValuePairs(r.Context(), table, prefix, start, end)
+	if err != nil {
+		h.logger.Error("Failed to estimate key-value pairs",
+			zap.Error(err),
+			zap.String("table", table),
+			zap.String("prefix", prefix),
+			zap.String("start", start),
+			zap.String("end", end))
+		http.Error(w, "Failed to estimate key-value pairs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(EstimatedKeysHeader, strconv.FormatInt(estimate.Keys, 10))
+	w.Header().Set(EstimatedBytesHeader, strconv.FormatInt(estimate.Bytes, 10))
+	w.WriteHeader(http.StatusOK)
 }
 
 // handlePutKeyValue handles the PUT method for the key-value API endpoint
+// @Summary Put key-value pair
+// @Tags kv
+// @Accept json
+// @Produce json
+// @Param table path string true "Table name"
+// @Param transform query string false "Comma-separated encodings applied before storing, e.g. base64,gzip, or preserve"
+// @Param If-Match header string false "ETag the current value must match"
+// @Param If-None-Match header string false "* to only create the key"
+// @Param request body armada.KeyValuePair true "Key-value pair"
+// @Success 200 {object} map[string]any
+// @Success 201 {object} map[string]any
+// @Header 200,201 {string} ETag "ETag of the stored value"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 412 {string} string "Precondition failed"
+// @Failure 423 {string} string "Table is read-only"
+// @Router /api/kv/{table} [put]
 func (h *Handler) handlePutKeyValue(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 	// Get the table from the URL parameters
@@ -360,7 +888,50 @@ func (h *Handler) handlePutKeyValue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.client.PutKeyValue(r.Context(), table, pair.Key, pair.Value); err != nil {
+	if pair.Key == "" {
+		http.Error(w, "Key is required", http.StatusBadRequest)
+		return
+	}
+	if !h.checkWritable(w, table) {
+		return
+	}
+
+	// Values may be stored encoded, e.g. compressed, as the application expects them
+	value, err := h.encodeValue(r, table, pair.Key, pair.Value)
+	if errors.Is(err, transform.ErrUnknownTransformer) {
+		http.Error(w, "Invalid transform: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to encode value",
+			zap.Error(err),
+			zap.String("table", table),
+			zap.String("key", pair.Key))
+		http.Error(w, "Failed to encode value", http.StatusInternalServerError)
+		return
+	}
+	pair.Value = value
+
+	// Look up the current value to evaluate preconditions and tell creates from updates
+	current, ok := h.currentValue(w, r, table, pair.Key)
+	if !ok {
+		return
+	}
+	if status := checkPreconditions(r, currentETag(current)); status != 0 {
+		http.Error(w, "Precondition failed for key: "+pair.Key, status)
+		return
+	}
+
+	h.hotKeys.Record(table, pair.Key, hotkeys.OpWrite)
+	// With preconditions the value is only stored if the key still holds the value they were
+	// checked against, so concurrent writes can't be overwritten
+	stored := true
+	if hasPreconditions(r) {
+		stored, err = h.client.PutKeyValueIf(r.Context(), table, pair.Key, pair.Value, current)
+	} else {
+		err = h.client.PutKeyValue(r.Context(), table, pair.Key, pair.Value)
+	}
+	if err != nil {
 		h.logger.Error("Failed to put key-value pair",
 			zap.Error(err),
 			zap.String("table", table),
@@ -368,11 +939,61 @@ func (h *Handler) handlePutKeyValue(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to put key-value pair", http.StatusInternalServerError)
 		return
 	}
+	if !stored {
+		http.Error(w, "Precondition failed for key: "+pair.Key+", it was changed meanwhile", http.StatusPreconditionFailed)
+		return
+	}
+	h.deleted.remove(keyResourceID(table, pair.Key))
 
+	render.Header("ETag", valueETag(pair.Value))
+	if current == nil {
+		render.Status(http.StatusCreated)
+	}
 	render.JSON(make(map[string]any))
 }
 
+// currentValue returns the value currently stored under key, or nil if the key does not exist.
+// If the lookup fails, an error response is written and ok is false.
+func (h *Handler) currentValue(w http.ResponseWriter, r *http.Request, table, key string) (value *string, ok bool) {
+	current, err := h.client.GetKeyValue(r.Context(), table, key)
+	if errors.Is(err, armada.ErrKeyNotFound) {
+		return nil, true
+	}
+	if err != nil {
+		h.logger.Error("Failed to get key-value pair",
+			zap.Error(err),
+			zap.String("table", table),
+			zap.String("key", key))
+		http.Error(w, "Failed to get key-value pair", http.StatusInternalServerError)
+		return nil, false
+	}
+	return &current.Value, true
+}
+
+// currentETag returns the entity tag of the current value, or an empty string if there is none
+func currentETag(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return valueETag(*value)
+}
+
 // handleDeleteKey handles the DELETE method for the key-value API endpoint
+// @Summary Delete keys
+// @Description Delete a single key, or all keys with a prefix
+// @Tags kv
+// @Produce json
+// @Param table path string true "Table name"
+// @Param key query string false "Key to delete"
+// @Param prefix query string false "Prefix of the keys to delete"
+// @Param If-Match header string false "ETag the current value must match"
+// @Success 200 {object} DeletePrefixResponse "Number of deleted keys, if deleted by prefix"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 404 {string} string "Key not found"
+// @Failure 409 {string} string "Table is protected"
+// @Failure 412 {string} string "Precondition failed"
+// @Failure 423 {string} string "Table is read-only"
+// @Router /api/kv/{table} [delete]
 func (h *Handler) handleDeleteKey(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 	// Get the table and key from the URL parameters
@@ -383,12 +1004,46 @@ func (h *Handler) handleDeleteKey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	key := r.URL.Query().Get("key")
+	prefix := r.URL.Query().Get("prefix")
+	if key != "" && prefix != "" {
+		http.Error(w, "Cannot specify both key and prefix", http.StatusBadRequest)
+		return
+	}
+	if !h.checkWritable(w, table) {
+		return
+	}
+	if prefix != "" {
+		h.deletePrefix(w, r, table, prefix)
+		return
+	}
 	if key == "" {
-		http.Error(w, "Key is required", http.StatusBadRequest)
+		http.Error(w, "Key or prefix is required", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.client.DeleteKey(r.Context(), table, key); err != nil {
+	current, ok := h.currentValue(w, r, table, key)
+	if !ok {
+		return
+	}
+	if current == nil {
+		http.Error(w, "Key not found: "+key, h.deleted.missingStatus(keyResourceID(table, key)))
+		return
+	}
+	if status := checkPreconditions(r, currentETag(current)); status != 0 {
+		http.Error(w, "Precondition failed for key: "+key, status)
+		return
+	}
+
+	h.hotKeys.Record(table, key, hotkeys.OpDelete)
+	// With preconditions the key is only deleted if it still holds the value they were checked against
+	deleted := true
+	var err error
+	if hasPreconditions(r) {
+		deleted, err = h.client.DeleteKeyIf(r.Context(), table, key, *current)
+	} else {
+		err = h.client.DeleteKey(r.Context(), table, key)
+	}
+	if err != nil {
 		h.logger.Error("Failed to delete key",
 			zap.Error(err),
 			zap.String("table", table),
@@ -396,11 +1051,56 @@ func (h *Handler) handleDeleteKey(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to delete key", http.StatusInternalServerError)
 		return
 	}
+	if !deleted {
+		http.Error(w, "Precondition failed for key: "+key+", it was changed meanwhile", http.StatusPreconditionFailed)
+		return
+	}
+	h.deleted.add(keyResourceID(table, key))
 
 	render.JSON(make(map[string]any))
 }
 
+// deletePrefix deletes all keys with the given prefix from a table
+func (h *Handler) deletePrefix(w http.ResponseWriter, r *http.Request, table, prefix string) {
+	render := chix.NewRender(w)
+
+	if !h.checkUnprotected(w, table) {
+		return
+	}
+
+	snapshot := h.captureSnapshot(r.Context(), table, prefix)
+	h.hotKeys.Record(table, prefix, hotkeys.OpDelete)
+	deleted, err := h.client.DeletePrefix(r.Context(), table, prefix)
+	h.recordAudit(r, "kv.deletePrefix", tableResourceID(table), err, map[string]string{
+		"prefix":  prefix,
+		"deleted": strconv.FormatInt(deleted, 10),
+	}, snapshot)
+	if err != nil {
+		h.logger.Error("Failed to delete keys by prefix",
+			zap.Error(err),
+			zap.String("table", table),
+			zap.String("prefix", prefix))
+		http.Error(w, "Failed to delete keys", http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(DeletePrefixResponse{Deleted: deleted})
+}
+
 // handleGetSpecificKeyValue handles the GET method for retrieving a specific key-value pair
+// @Summary Get key-value pair
+// @Tags kv
+// @Produce json
+// @Param table path string true "Table name"
+// @Param key path string true "Key"
+// @Param decode query string false "Decode the stored value" Enums(none, auto, decompress)
+// @Param If-None-Match header string false "ETag of a cached value"
+// @Success 200 {object} armada.KeyValuePair
+// @Success 304 "Value unchanged"
+// @Header 200 {string} ETag "ETag of the value"
+// @Failure 404 {string} string "Key not found"
+// @Failure 410 {string} string "Key was deleted"
+// @Router /api/kv/{table}/{key} [get]
 func (h *Handler) handleGetSpecificKeyValue(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 
@@ -417,25 +1117,55 @@ func (h *Handler) handleGetSpecificKeyValue(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	decoder, err := h.decoder(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Get the specific key-value pair
+	h.hotKeys.Record(table, key, hotkeys.OpRead)
 	pair, err := h.client.GetKeyValue(r.Context(), table, key)
+	if errors.Is(err, armada.ErrKeyNotFound) {
+		http.Error(w, "Failed to get key-value pair: "+err.Error(), h.deleted.missingStatus(keyResourceID(table, key)))
+		return
+	}
 	if err != nil {
 		h.logger.Error("Failed to get key-value pair",
 			zap.Error(err),
 			zap.String("table", table),
 			zap.String("key", key))
-		http.Error(w, "Failed to get key-value pair: "+err.Error(), http.StatusNotFound)
+		http.Error(w, "Failed to get key-value pair", http.StatusInternalServerError)
 		return
 	}
 
+	etag := valueETag(pair.Value)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && matchesETag(ifNoneMatch, etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	render.Header("ETag", etag)
+	if decoder != nil {
+		render.JSON(decodePair(decoder, *pair))
+		return
+	}
 	render.JSON(pair)
 }
 
 // handleCluster handles the cluster API endpoint
+// @Summary Get cluster info
+// @Description Get the members of the cluster and the node the console is connected to
+// @Tags cluster
+// @Produce json
+// @Success 200 {object} armada.ClusterInfo
+// @Failure 500 {string} string "Failed to get cluster info"
+// @Router /api/cluster [get]
 func (h *Handler) handleCluster(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 	// Get the cluster info from the Armada server
-	clusterInfo, err := h.client.GetClusterInfo(r.Context())
+	clusterInfo, _, err := coalesce.Do(r.Context(), &h.reads, "cluster", h.client.GetClusterInfo)
 	if err != nil {
 		h.logger.Error("Failed to get cluster info from Armada server", zap.Error(err))
 		http.Error(w, "Failed to get cluster info", http.StatusInternalServerError)
@@ -446,10 +1176,16 @@ func (h *Handler) handleCluster(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleServers handles the servers API endpoint
+// @Summary List servers
+// @Tags cluster
+// @Produce json
+// @Success 200 {array} armada.Server
+// @Failure 500 {string} string "Failed to get servers"
+// @Router /api/servers [get]
 func (h *Handler) handleServers(w http.ResponseWriter, r *http.Request) {
 	render := chix.NewRender(w)
 	// Get all servers from the Armada cluster
-	servers, err := h.client.GetAllServers(r.Context())
+	servers, _, err := coalesce.Do(r.Context(), &h.reads, "servers", h.client.GetAllServers)
 	if err != nil {
 		h.logger.Error("Failed to get servers from Armada cluster", zap.Error(err))
 		http.Error(w, "Failed to get servers", http.StatusInternalServerError)
@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/armadakv/console/backend/apiversion"
 	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/armadakv/console/backend/polling"
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
@@ -23,9 +30,20 @@ type mockArmadaClient struct {
 	kvPairs         []armada.KeyValuePair
 	servers         []armada.Server
 	singleKvPair    *armada.KeyValuePair
+	// statusErrors makes GetStatus fail for the given server addresses
+	statusErrors map[string]error
+	// kvErr is returned by GetKeyValuePairs together with the pairs
+	kvErr error
+	// kvScan records the prefix, start and end of the last GetKeyValuePairs call
+	kvScan [3]string
+	// changed makes conditional writes fail as if the key was changed after it was read
+	changed bool
 }
 
 func (m *mockArmadaClient) GetStatus(ctx context.Context, serverAddress string) (*armada.Status, error) {
+	if err, ok := m.statusErrors[serverAddress]; ok {
+		return nil, err
+	}
 	if m.statusResponse != nil {
 		return m.statusResponse, nil
 	}
@@ -63,8 +81,9 @@ func (m *mockArmadaClient) GetMetrics(ctx context.Context, format string) (*arma
 
 // Add the GetKeyValuePairs method with the new signature
 func (m *mockArmadaClient) GetKeyValuePairs(ctx context.Context, table, prefix, start, end string, limit int) ([]armada.KeyValuePair, error) {
-	if m.kvPairs != nil {
-		return m.kvPairs, nil
+	m.kvScan = [3]string{prefix, start, end}
+	if m.kvPairs != nil || m.kvErr != nil {
+		return m.kvPairs, m.kvErr
 	}
 	return []armada.KeyValuePair{
 		{Key: "key1", Value: "value1"},
@@ -72,6 +91,12 @@ func (m *mockArmadaClient) GetKeyValuePairs(ctx context.Context, table, prefix,
 	}, nil
 }
 
+// EstimateKeyValuePairs implements the EstimateKeyValuePairs method of the ArmadaClient interface
+func (m *mockArmadaClient) EstimateKeyValuePairs(ctx context.Context, table, prefix, start, end string) (*armada.RangeEstimate, error) {
+	m.kvScan = [3]string{prefix, start, end}
+	return &armada.RangeEstimate{Keys: 2000000, Bytes: 512000000}, nil
+}
+
 // GetKeyValue implements the GetKeyValue method of the ArmadaClient interface
 func (m *mockArmadaClient) GetKeyValue(ctx context.Context, table, key string) (*armada.KeyValuePair, error) {
 	if m.singleKvPair != nil {
@@ -92,7 +117,7 @@ func (m *mockArmadaClient) GetKeyValue(ctx context.Context, table, key string) (
 	}
 
 	// If key not found, return error
-	return nil, fmt.Errorf("key not found: %s", key)
+	return nil, fmt.Errorf("%w: %s", armada.ErrKeyNotFound, key)
 }
 
 func (m *mockArmadaClient) PutKeyValue(ctx context.Context, table, key, value string) error {
@@ -103,6 +128,18 @@ func (m *mockArmadaClient) DeleteKey(ctx context.Context, table, key string) err
 	return nil
 }
 
+func (m *mockArmadaClient) PutKeyValueIf(ctx context.Context, table, key, value string, current *string) (bool, error) {
+	return !m.changed, nil
+}
+
+func (m *mockArmadaClient) DeleteKeyIf(ctx context.Context, table, key, current string) (bool, error) {
+	return !m.changed, nil
+}
+
+func (m *mockArmadaClient) DeletePrefix(ctx context.Context, table, prefix string) (int64, error) {
+	return 2, nil
+}
+
 func (m *mockArmadaClient) GetTables(ctx context.Context) ([]armada.Table, error) {
 	return []armada.Table{
 		{Name: "table1", ID: "1"},
@@ -112,11 +149,17 @@ func (m *mockArmadaClient) GetTables(ctx context.Context) ([]armada.Table, error
 
 // Adding CreateTable method to satisfy the interface
 func (m *mockArmadaClient) CreateTable(ctx context.Context, tableName string) (string, error) {
+	if tableName == "table1" || tableName == "table2" {
+		return "", fmt.Errorf("%w: %s", armada.ErrTableExists, tableName)
+	}
 	return "table_" + tableName, nil
 }
 
 // Adding DeleteTable method to satisfy the interface
 func (m *mockArmadaClient) DeleteTable(ctx context.Context, tableName string) error {
+	if tableName == "missing_table" {
+		return fmt.Errorf("%w: %s", armada.ErrTableNotFound, tableName)
+	}
 	return nil
 }
 
@@ -203,6 +246,135 @@ func TestHandleStatus(t *testing.T) {
 		t.Errorf("handler returned unexpected message: got %v want %v",
 			response.Servers[0].Message, "Armada server is running")
 	}
+	if response.Partial {
+		t.Errorf("handler returned partial response although all servers are reachable")
+	}
+}
+
+func TestHandleStatusTags(t *testing.T) {
+	handler := createTestHandler()
+	WithClusterTags(func() cluster.Tags { return cluster.Tags{"env": "prod", "region": "eu"} })(handler)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+	}{
+		{query: "", wantStatus: http.StatusOK},
+		{query: "?tags=env=prod", wantStatus: http.StatusOK},
+		{query: "?tags=env=prod,region=eu", wantStatus: http.StatusOK},
+		{query: "?tags=env=staging", wantStatus: http.StatusNotFound},
+		{query: "?tags=env", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.handleStatus(rr, httptest.NewRequest("GET", "/api/status"+tt.query, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var response StatusResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response body: %v", err)
+			}
+			if response.Tags["env"] != "prod" || response.Tags["region"] != "eu" {
+				t.Errorf("handler returned unexpected tags: %v", response.Tags)
+			}
+		})
+	}
+}
+
+func TestHandleStatusPartial(t *testing.T) {
+	handler := createTestHandler()
+	handler.client = &mockArmadaClient{
+		servers: []armada.Server{
+			{ID: "node1", Name: "server1", ClientURLs: []string{"http://localhost:8081"}},
+			{ID: "node2", Name: "server2", ClientURLs: []string{"http://localhost:8082"}},
+			{ID: "node3", Name: "server3", ClientURLs: []string{"http://localhost:8083"}},
+		},
+		statusErrors: map[string]error{
+			"http://localhost:8082": errors.New("connection refused"),
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	rr := httptest.NewRecorder()
+	handler.handleStatus(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var response StatusResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+
+	if !response.Partial {
+		t.Errorf("Expected partial to be true when a server is unreachable")
+	}
+	if len(response.Servers) != 3 {
+		t.Fatalf("Expected 3 servers, got %d", len(response.Servers))
+	}
+	for _, server := range response.Servers {
+		switch server.Name {
+		case "server2":
+			if server.Status != "error" || server.Error != "connection refused" {
+				t.Errorf("Expected server2 to be annotated with its error, got status %q error %q", server.Status, server.Error)
+			}
+		default:
+			if server.Status != "ok" || server.Error != "" {
+				t.Errorf("Expected %s to be ok, got status %q error %q", server.Name, server.Status, server.Error)
+			}
+		}
+	}
+}
+
+// blockingServersClient holds GetAllServers until released and counts the calls
+type blockingServersClient struct {
+	mockArmadaClient
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (m *blockingServersClient) GetAllServers(ctx context.Context) ([]armada.Server, error) {
+	m.calls.Add(1)
+	<-m.release
+	return m.mockArmadaClient.GetAllServers(ctx)
+}
+
+func TestHandleStatusCoalescesConcurrentRequests(t *testing.T) {
+	handler := createTestHandler()
+	client := &blockingServersClient{release: make(chan struct{})}
+	handler.client = client
+
+	const requests = 10
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			handler.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+			codes[i] = rr.Code
+		}()
+	}
+	// Give all requests time to join the in-flight fan-out
+	time.Sleep(50 * time.Millisecond)
+	close(client.release)
+	wg.Wait()
+
+	if calls := client.calls.Load(); calls != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", calls)
+	}
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Request %d: expected status code %d, got %d", i, http.StatusOK, code)
+		}
+	}
 }
 
 func TestHandleStatusMethodNotAllowed(t *testing.T) {
@@ -359,9 +531,15 @@ func TestHandleCreateTable(t *testing.T) {
 	handlerFunc.ServeHTTP(rr, req)
 
 	// Check the status code
-	if status := rr.Code; status != http.StatusOK {
+	if status := rr.Code; status != http.StatusCreated {
 		t.Errorf("handler returned wrong status code: got %v want %v",
-			status, http.StatusOK)
+			status, http.StatusCreated)
+	}
+
+	// Check the location of the new resource
+	if location := rr.Header().Get("Location"); location != "/api/tables/new_table" {
+		t.Errorf("handler returned wrong location: got %v want %v",
+			location, "/api/tables/new_table")
 	}
 
 	// Check the content type
@@ -473,6 +651,52 @@ func TestHandleServers(t *testing.T) {
 	}
 }
 
+func TestHandleCreateTableVersioned(t *testing.T) {
+	handler := createTestHandler()
+	r := chi.NewRouter()
+	r.Use(apiversion.Middleware)
+	handler.RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/v1/tables", strings.NewReader(`{"name":"new_table"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusCreated)
+	}
+	// Clients of v1 are pointed at the resource under v1
+	if location := rr.Header().Get("Location"); location != "/api/v1/tables/new_table" {
+		t.Errorf("handler returned wrong location: got %v want %v", location, "/api/v1/tables/new_table")
+	}
+}
+
+func TestHandleEstimateKeyValue(t *testing.T) {
+	handler := createTestHandler()
+	client := &mockArmadaClient{}
+	handler.client = client
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("HEAD", "/api/kv/test?prefix=user/", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if got := rr.Header().Get(EstimatedKeysHeader); got != "2000000" {
+		t.Errorf("%s = %q, want 2000000", EstimatedKeysHeader, got)
+	}
+	if got := rr.Header().Get(EstimatedBytesHeader); got != "512000000" {
+		t.Errorf("%s = %q, want 512000000", EstimatedBytesHeader, got)
+	}
+	if client.kvScan != [3]string{"user/", "", ""} {
+		t.Errorf("estimated scan %q, want the prefix user/", client.kvScan)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("HEAD", "/api/kv/test?start=a", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("handler returned wrong status code for a range without end: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+}
+
 func TestHandleKeyValue(t *testing.T) {
 	// Create a new API handler with a mock client
 	handler := createTestHandler()
@@ -563,10 +787,16 @@ func TestHandleKeyValue(t *testing.T) {
 		// Call the handler function directly and pass our request and ResponseRecorder
 		handlerFunc.ServeHTTP(rr, req)
 
-		// Check the status code
-		if status := rr.Code; status != http.StatusOK {
+		// Check the status code - key3 does not exist yet, so it is created
+		if status := rr.Code; status != http.StatusCreated {
 			t.Errorf("handler returned wrong status code: got %v want %v",
-				status, http.StatusOK)
+				status, http.StatusCreated)
+		}
+
+		// Check the entity tag of the stored value
+		if etag := rr.Header().Get("ETag"); etag != valueETag("value3") {
+			t.Errorf("handler returned wrong ETag: got %v want %v",
+				etag, valueETag("value3"))
 		}
 	})
 
@@ -768,3 +998,191 @@ func TestHandleGetSpecificKeyValue(t *testing.T) {
 		}
 	})
 }
+
+// serveWithParams invokes a handler function with the given chi URL parameters
+func serveWithParams(handlerFunc http.HandlerFunc, req *http.Request, params map[string]string) *httptest.ResponseRecorder {
+	rctx := chi.NewRouteContext()
+	for name, value := range params {
+		rctx.URLParams.Add(name, value)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rr := httptest.NewRecorder()
+	handlerFunc.ServeHTTP(rr, req)
+	return rr
+}
+
+// TestResourceSemantics tests the status codes returned for create, update and delete operations
+func TestResourceSemantics(t *testing.T) {
+	t.Run("CreateExistingTable", func(t *testing.T) {
+		handler := createTestHandler()
+		body, _ := json.Marshal(CreateTableRequest{Name: "table1"})
+		req := httptest.NewRequest("POST", "/api/tables", bytes.NewReader(body))
+
+		rr := serveWithParams(handler.handleCreateTable, req, nil)
+		if rr.Code != http.StatusConflict {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("GetTable", func(t *testing.T) {
+		handler := createTestHandler()
+		req := httptest.NewRequest("GET", "/api/tables/table2", nil)
+
+		rr := serveWithParams(handler.handleGetTable, req, map[string]string{"name": "table2"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		var table armada.Table
+		if err := json.Unmarshal(rr.Body.Bytes(), &table); err != nil {
+			t.Fatalf("Failed to parse response body: %v", err)
+		}
+		if table.ID != "2" {
+			t.Errorf("handler returned unexpected table ID: got %v want %v", table.ID, "2")
+		}
+	})
+
+	t.Run("DeleteMissingTable", func(t *testing.T) {
+		handler := createTestHandler()
+		req := httptest.NewRequest("DELETE", "/api/tables/missing_table", nil)
+
+		rr := serveWithParams(handler.handleDeleteTable, req, map[string]string{"name": "missing_table"})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("GetDeletedTable", func(t *testing.T) {
+		handler := createTestHandler()
+		params := map[string]string{"name": "old_table"}
+
+		rr := serveWithParams(handler.handleGetTable, httptest.NewRequest("GET", "/api/tables/old_table", nil), params)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code before delete: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+
+		rr = serveWithParams(handler.handleDeleteTable, httptest.NewRequest("DELETE", "/api/tables/old_table", nil), params)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code on delete: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		rr = serveWithParams(handler.handleGetTable, httptest.NewRequest("GET", "/api/tables/old_table", nil), params)
+		if rr.Code != http.StatusGone {
+			t.Errorf("handler returned wrong status code after delete: got %v want %v", rr.Code, http.StatusGone)
+		}
+	})
+
+	t.Run("UpdateWithMatchingETag", func(t *testing.T) {
+		handler := createTestHandler()
+		body, _ := json.Marshal(armada.KeyValuePair{Key: "key1", Value: "updated"})
+		req := httptest.NewRequest("PUT", "/api/kv/test", bytes.NewReader(body))
+		req.Header.Set("If-Match", valueETag("value1"))
+
+		rr := serveWithParams(handler.handlePutKeyValue, req, map[string]string{"table": "test"})
+		if rr.Code != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("UpdateWithStaleETag", func(t *testing.T) {
+		handler := createTestHandler()
+		body, _ := json.Marshal(armada.KeyValuePair{Key: "key1", Value: "updated"})
+		req := httptest.NewRequest("PUT", "/api/kv/test", bytes.NewReader(body))
+		req.Header.Set("If-Match", valueETag("stale"))
+
+		rr := serveWithParams(handler.handlePutKeyValue, req, map[string]string{"table": "test"})
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusPreconditionFailed)
+		}
+	})
+
+	t.Run("UpdateChangedMeanwhile", func(t *testing.T) {
+		handler := createTestHandler()
+		handler.client = &mockArmadaClient{changed: true}
+		body, _ := json.Marshal(armada.KeyValuePair{Key: "key1", Value: "updated"})
+		req := httptest.NewRequest("PUT", "/api/kv/test", bytes.NewReader(body))
+		req.Header.Set("If-Match", valueETag("value1"))
+
+		rr := serveWithParams(handler.handlePutKeyValue, req, map[string]string{"table": "test"})
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusPreconditionFailed)
+		}
+	})
+
+	t.Run("DeleteChangedMeanwhile", func(t *testing.T) {
+		handler := createTestHandler()
+		handler.client = &mockArmadaClient{changed: true}
+		req := httptest.NewRequest("DELETE", "/api/kv/test?key=key1", nil)
+		req.Header.Set("If-Match", valueETag("value1"))
+
+		rr := serveWithParams(handler.handleDeleteKey, req, map[string]string{"table": "test"})
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusPreconditionFailed)
+		}
+		// The key wasn't deleted, so it isn't reported as gone
+		if handler.deleted.missingStatus(keyResourceID("test", "key1")) != http.StatusNotFound {
+			t.Error("key recorded as deleted")
+		}
+	})
+
+	t.Run("CreateOnlyExistingKey", func(t *testing.T) {
+		handler := createTestHandler()
+		body, _ := json.Marshal(armada.KeyValuePair{Key: "key1", Value: "value1"})
+		req := httptest.NewRequest("PUT", "/api/kv/test", bytes.NewReader(body))
+		req.Header.Set("If-None-Match", "*")
+
+		rr := serveWithParams(handler.handlePutKeyValue, req, map[string]string{"table": "test"})
+		if rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusPreconditionFailed)
+		}
+	})
+
+	t.Run("DeleteMissingKey", func(t *testing.T) {
+		handler := createTestHandler()
+		req := httptest.NewRequest("DELETE", "/api/kv/test?key=missing", nil)
+
+		rr := serveWithParams(handler.handleDeleteKey, req, map[string]string{"table": "test"})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("GetKeyETag", func(t *testing.T) {
+		handler := createTestHandler()
+		params := map[string]string{"table": "test", "key": "key2"}
+
+		rr := serveWithParams(handler.handleGetSpecificKeyValue, httptest.NewRequest("GET", "/api/kv/test/key2", nil), params)
+		if etag := rr.Header().Get("ETag"); etag != valueETag("value2") {
+			t.Fatalf("handler returned wrong ETag: got %v want %v", etag, valueETag("value2"))
+		}
+
+		req := httptest.NewRequest("GET", "/api/kv/test/key2", nil)
+		req.Header.Set("If-None-Match", valueETag("value2"))
+		rr = serveWithParams(handler.handleGetSpecificKeyValue, req, params)
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusNotModified)
+		}
+	})
+}
+
+func TestHandleStatusSuggestsRefresh(t *testing.T) {
+	handler := createTestHandler()
+	handler.refresh = polling.NewAdvisor(10*time.Second, time.Minute, 32)
+
+	var suggestions []int
+	for range 2 {
+		rr := httptest.NewRecorder()
+		handler.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+		var response StatusResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		suggestions = append(suggestions, response.SuggestedRefreshSeconds)
+	}
+
+	// Polling slows down while the status doesn't change
+	if suggestions[0] != 10 || suggestions[1] != 20 {
+		t.Errorf("Expected suggestions [10 20], got %v", suggestions)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+const (
+	// LivenessPath answers as long as the process serves HTTP requests
+	LivenessPath = "/healthz"
+	// ReadinessPath answers successfully only if the console can serve cluster data
+	ReadinessPath = "/readyz"
+)
+
+// readinessTimeout bounds the time spent on all readiness checks
+const readinessTimeout = 5 * time.Second
+
+// ReadinessCheck returns an error if a dependency of the console isn't usable
+type ReadinessCheck func(ctx context.Context) error
+
+// HealthResponse is the result of a liveness or readiness probe
+type HealthResponse struct {
+	// Status is "ok" or "unavailable"
+	Status string `json:"status"`
+	// Checks maps the name of every readiness check to "ok" or its error
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// HealthOption configures optional behaviour of the HealthHandler
+type HealthOption func(*HealthHandler)
+
+// WithReadinessCheck adds a check that must pass for the console to be ready
+func WithReadinessCheck(name string, check ReadinessCheck) HealthOption {
+	return func(h *HealthHandler) {
+		h.checks = append(h.checks, namedCheck{name: name, check: check})
+	}
+}
+
+type namedCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// HealthHandler serves the liveness and readiness probes, e.g. of Kubernetes
+type HealthHandler struct {
+	checks []namedCheck
+	logger *zap.Logger
+}
+
+// NewHealthHandler creates a new health API handler
+func NewHealthHandler(logger *zap.Logger, opts ...HealthOption) *HealthHandler {
+	h := &HealthHandler{logger: logger}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers the probe routes
+func (h *HealthHandler) RegisterRoutes(r chi.Router) {
+	r.Get(LivenessPath, h.handleLiveness)
+	r.Get(ReadinessPath, h.handleReadiness)
+}
+
+// handleLiveness reports that the console is running, it doesn't check any dependency
+// @Summary Liveness probe
+// @Description Report that the console process is running
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /healthz [get]
+func (h *HealthHandler) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	chix.NewRender(w).JSON(HealthResponse{Status: "ok"})
+}
+
+// handleReadiness reports whether the console can serve cluster data
+// @Summary Readiness probe
+// @Description Report whether the console has a healthy connection to the Armada cluster and its metrics storage is open
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (h *HealthHandler) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	response := HealthResponse{Status: "ok", Checks: make(map[string]string, len(h.checks))}
+	for _, c := range h.checks {
+		if err := c.check(ctx); err != nil {
+			h.logger.Warn("Readiness check failed", zap.String("check", c.name), zap.Error(err))
+			response.Status = "unavailable"
+			response.Checks[c.name] = err.Error()
+			continue
+		}
+		response.Checks[c.name] = "ok"
+	}
+
+	render := chix.NewRender(w)
+	if response.Status != "ok" {
+		render.Status(http.StatusServiceUnavailable)
+	}
+	render.JSON(response)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandleLiveness(t *testing.T) {
+	r := chi.NewRouter()
+	NewHealthHandler(zap.NewNop(), WithReadinessCheck("armada", func(context.Context) error {
+		t.Error("liveness must not run readiness checks")
+		return nil
+	})).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", LivenessPath, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+}
+
+func TestHandleReadiness(t *testing.T) {
+	var armadaErr error
+	r := chi.NewRouter()
+	NewHealthHandler(zap.NewNop(),
+		WithReadinessCheck("armada", func(context.Context) error { return armadaErr }),
+		WithReadinessCheck("tsdb", func(context.Context) error { return nil }),
+	).RegisterRoutes(r)
+
+	tests := []struct {
+		name       string
+		armadaErr  error
+		wantStatus int
+		want       HealthResponse
+	}{
+		{
+			name:       "Ready",
+			wantStatus: http.StatusOK,
+			want:       HealthResponse{Status: "ok", Checks: map[string]string{"armada": "ok", "tsdb": "ok"}},
+		},
+		{
+			name:       "NoConnection",
+			armadaErr:  errors.New("no healthy connection"),
+			wantStatus: http.StatusServiceUnavailable,
+			want:       HealthResponse{Status: "unavailable", Checks: map[string]string{"armada": "no healthy connection", "tsdb": "ok"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			armadaErr = tt.armadaErr
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest("GET", ReadinessPath, nil))
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, tt.wantStatus)
+			}
+			var response HealthResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response body: %v", err)
+			}
+			if !reflect.DeepEqual(response, tt.want) {
+				t.Errorf("got %+v, want %+v", response, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/topology"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// defaultHistoryWindow is the time range returned when no range is requested
+const defaultHistoryWindow = 24 * time.Hour
+
+// TopologyHistory provides the recorded cluster topology
+type TopologyHistory interface {
+	// At returns the snapshot in effect at t, or false if none was recorded yet.
+	At(t time.Time) (topology.Snapshot, bool, error)
+	// Range returns the snapshots in effect between from and to.
+	Range(from, to time.Time) ([]topology.Snapshot, error)
+}
+
+// TopologyHistoryHandler serves the history of cluster members and table leaders
+type TopologyHistoryHandler struct {
+	history TopologyHistory
+	logger  *zap.Logger
+}
+
+// NewTopologyHistoryHandler creates a new topology history API handler
+func NewTopologyHistoryHandler(history TopologyHistory, logger *zap.Logger) *TopologyHistoryHandler {
+	return &TopologyHistoryHandler{
+		history: history,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the topology history routes
+func (h *TopologyHistoryHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/cluster/history", h.handleHistory)
+}
+
+// handleHistory answers "who was leader at" questions.
+// With at, the single snapshot in effect at that time is returned.
+// Otherwise all snapshots in effect between from and to are returned, by default for the last day.
+// Times are RFC3339 or unix timestamps.
+// @Summary Get topology history
+// @Description Get the cluster members and table leaders in effect at a time, or all snapshots between from and to
+// @Tags cluster
+// @Produce json
+// @Param at query string false "Time of the single snapshot to return (RFC3339 or unix timestamp)"
+// @Param from query string false "Start of the range, one day before to by default (RFC3339 or unix timestamp)"
+// @Param to query string false "End of the range, now by default (RFC3339 or unix timestamp)"
+// @Success 200 {array} topology.Snapshot
+// @Failure 400 {string} string "Invalid time"
+// @Failure 404 {string} string "No topology was recorded at that time"
+// @Router /api/cluster/history [get]
+func (h *TopologyHistoryHandler) handleHistory(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	query := r.URL.Query()
+
+	if raw := query.Get("at"); raw != "" {
+		at, err := parseTimeParam(raw)
+		if err != nil {
+			http.Error(w, "at must be an RFC3339 or unix timestamp", http.StatusBadRequest)
+			return
+		}
+		snapshot, ok, err := h.history.At(at)
+		if err != nil {
+			h.logger.Error("Failed to read topology history", zap.Error(err))
+			http.Error(w, "Failed to read topology history", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "No topology was recorded at that time", http.StatusNotFound)
+			return
+		}
+		render.JSON(snapshot)
+		return
+	}
+
+	to := time.Now()
+	if raw := query.Get("to"); raw != "" {
+		t, err := parseTimeParam(raw)
+		if err != nil {
+			http.Error(w, "to must be an RFC3339 or unix timestamp", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultHistoryWindow)
+	if raw := query.Get("from"); raw != "" {
+		t, err := parseTimeParam(raw)
+		if err != nil {
+			http.Error(w, "from must be an RFC3339 or unix timestamp", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if from.After(to) {
+		http.Error(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := h.history.Range(from, to)
+	if err != nil {
+		h.logger.Error("Failed to read topology history", zap.Error(err))
+		http.Error(w, "Failed to read topology history", http.StatusInternalServerError)
+		return
+	}
+	render.JSON(snapshots)
+}
+
+// parseTimeParam parses a time given as RFC3339 or unix timestamp
+func parseTimeParam(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	unix, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/armadakv/console/backend/topology"
+	"go.uber.org/zap"
+)
+
+func createTestHistoryHandler(t *testing.T) *TopologyHistoryHandler {
+	t.Helper()
+	history := topology.NewHistory(metadata.NewMemoryStore(), 24*time.Hour, zap.NewNop())
+	servers := []armada.Server{{ID: "1", Name: "node1"}, {ID: "2", Name: "node2"}}
+	for i, leader := range []string{"1", "2"} {
+		history.Observe(stats.Observation{
+			Time:    time.Date(2025, 3, 1, 3, 0, 0, 0, time.UTC).Add(time.Duration(i) * 10 * time.Minute),
+			Servers: servers,
+			Statuses: map[string]*armada.Status{
+				"1": {Tables: map[string]armada.TableStatus{"users": {Leader: leader, RaftTerm: uint64(i + 1)}}},
+			},
+		})
+	}
+	return NewTopologyHistoryHandler(history, zap.NewNop())
+}
+
+func TestTopologyHistoryAt(t *testing.T) {
+	handler := createTestHistoryHandler(t)
+
+	rr := httptest.NewRecorder()
+	handler.handleHistory(rr, httptest.NewRequest("GET", "/api/cluster/history?at=2025-03-01T03:12:00Z", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var snapshot topology.Snapshot
+	if err := json.NewDecoder(rr.Body).Decode(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if leader := snapshot.Tables["users"].Leader; leader != "2" {
+		t.Errorf("Expected leader 2, got %q", leader)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleHistory(rr, httptest.NewRequest("GET", "/api/cluster/history?at=2025-03-01T02:00:00Z", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d before the first snapshot, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestTopologyHistoryRange(t *testing.T) {
+	handler := createTestHistoryHandler(t)
+
+	rr := httptest.NewRecorder()
+	handler.handleHistory(rr, httptest.NewRequest("GET", "/api/cluster/history?from=2025-03-01T03:00:00Z&to=2025-03-01T04:00:00Z", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var snapshots []topology.Snapshot
+	if err := json.NewDecoder(rr.Body).Decode(&snapshots); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Errorf("Expected 2 snapshots, got %d", len(snapshots))
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleHistory(rr, httptest.NewRequest("GET", "/api/cluster/history?from=yesterday", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/hotkeys"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// maxHotKeysLimit is the maximum number of prefixes returned by the hot key analysis
+const maxHotKeysLimit = 1000
+
+// HotKeysHandler serves the analysis of the hottest key prefixes
+type HotKeysHandler struct {
+	tracker *hotkeys.Tracker
+	logger  *zap.Logger
+}
+
+// NewHotKeysHandler creates a new hot key analysis API handler
+func NewHotKeysHandler(tracker *hotkeys.Tracker, logger *zap.Logger) *HotKeysHandler {
+	return &HotKeysHandler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the hot key analysis routes
+func (h *HotKeysHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/analysis/hotkeys", h.handleHotKeys)
+}
+
+// handleHotKeys reports the most requested key prefixes of the key-value requests
+// served by the console. Keys are grouped by their first depth segments split by separator.
+// @Summary Find hot keys
+// @Description Report the most requested key prefixes of the key-value requests made through the console
+// @Tags analysis
+// @Produce json
+// @Param table query string false "Only report keys of this table"
+// @Param window query string false "Time window, e.g. 15m (default)"
+// @Param separator query string false "Separator of key segments (default /)"
+// @Param depth query int false "Number of segments grouping keys (default 1)"
+// @Param limit query int false "Maximum number of prefixes (default 20)"
+// @Success 200 {object} hotkeys.Report
+// @Failure 400 {string} string "Invalid query"
+// @Router /api/analysis/hotkeys [get]
+func (h *HotKeysHandler) handleHotKeys(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	query := r.URL.Query()
+
+	q := hotkeys.Query{
+		Table:     query.Get("table"),
+		Window:    15 * time.Minute,
+		Separator: "/",
+		Depth:     1,
+		Limit:     20,
+	}
+	if raw := query.Get("window"); raw != "" {
+		window, err := time.ParseDuration(raw)
+		if err != nil || window <= 0 {
+			http.Error(w, "window must be a positive duration, e.g. 15m", http.StatusBadRequest)
+			return
+		}
+		q.Window = window
+	}
+	if query.Has("separator") {
+		q.Separator = query.Get("separator")
+	}
+	if raw := query.Get("depth"); raw != "" {
+		depth, err := strconv.Atoi(raw)
+		if err != nil || depth < 1 {
+			http.Error(w, "depth must be a positive number", http.StatusBadRequest)
+			return
+		}
+		q.Depth = depth
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxHotKeysLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxHotKeysLimit), http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	render.JSON(h.tracker.Top(q))
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/hotkeys"
+	"go.uber.org/zap"
+)
+
+func TestHandleHotKeys(t *testing.T) {
+	tracker := hotkeys.NewTracker(1, time.Hour)
+	handler := createTestHandler()
+	handler.hotKeys = tracker
+
+	// Requests served by the KV endpoints are sampled
+	for range 3 {
+		req := httptest.NewRequest("GET", "/api/kv/test-table/key/test-key", nil)
+		serveWithParams(handler.handleGetSpecificKeyValue, req, map[string]string{"table": "test-table", "key": "test-key"})
+	}
+
+	hotKeysHandler := NewHotKeysHandler(tracker, zap.NewNop())
+	rr := httptest.NewRecorder()
+	hotKeysHandler.handleHotKeys(rr, httptest.NewRequest("GET", "/api/analysis/hotkeys?table=test-table&depth=2", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var report hotkeys.Report
+	if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Prefixes) != 1 || report.Prefixes[0].Prefix != "test-key" || report.Prefixes[0].Ops[hotkeys.OpRead] != 3 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	rr = httptest.NewRecorder()
+	hotKeysHandler.handleHotKeys(rr, httptest.NewRequest("GET", "/api/analysis/hotkeys?window=-1m", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
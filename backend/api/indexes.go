@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/indexes"
+	"github.com/armadakv/console/backend/query"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+const (
+	// IndexHeader names the index a filtered scan was answered from
+	IndexHeader = "X-Index"
+	// IndexBuiltAtHeader is when that index was built, matches added since are missing
+	IndexBuiltAtHeader = "X-Index-Built-At"
+)
+
+// CreateIndexRequest represents the request body for creating an index
+type CreateIndexRequest struct {
+	Name   string `json:"name"`
+	Table  string `json:"table"`
+	Filter string `json:"filter"`
+	// Interval is how often the index is rebuilt, e.g. 1h; 15m if empty.
+	Interval string `json:"interval,omitempty"`
+}
+
+// WithIndexes answers filtered key scans from a built index of the table with an equivalent
+// filter, if there is one
+func WithIndexes(builder *indexes.Builder) HandlerOption {
+	return func(h *Handler) {
+		h.indexes = builder
+	}
+}
+
+// scanIndexed returns up to limit pairs of the scan whose keys are listed in the index table. The
+// current values are read from the table, so values changed since the build that no longer match
+// are left out.
+func (h *Handler) scanIndexed(ctx context.Context, table string, idx indexes.Index, prefix, end, scanPrefix, scanStart, scanEnd string,
+	limit int, filter query.Filter) ([]armada.KeyValuePair, error) {
+	matches := make([]armada.KeyValuePair, 0)
+	for {
+		keys, err := h.client.GetKeyValuePairs(ctx, indexes.IndexTable(idx.Name), scanPrefix, scanStart, scanEnd, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			pair, err := h.client.GetKeyValue(ctx, table, key.Key)
+			if errors.Is(err, armada.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if matchPair(nil, filter, *pair) {
+				matches = append(matches, *pair)
+				if len(matches) == limit {
+					return matches, nil
+				}
+			}
+		}
+		if len(keys) < limit {
+			return matches, nil
+		}
+		scanPrefix, scanStart, scanEnd = continueScan(prefix, end, keys[len(keys)-1].Key+"\x00")
+	}
+}
+
+// IndexHandler serves the management of index tables
+type IndexHandler struct {
+	builder *indexes.Builder
+	logger  *zap.Logger
+}
+
+// NewIndexHandler creates a new index API handler
+func NewIndexHandler(builder *indexes.Builder, logger *zap.Logger) *IndexHandler {
+	return &IndexHandler{
+		builder: builder,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the index routes under /api/indexes
+func (h *IndexHandler) RegisterRoutes(r chi.Router) {
+	indexRouter := chi.NewRouter()
+	indexRouter.Get("/", h.handleList)
+	indexRouter.Post("/", h.handleCreate)
+	indexRouter.Get("/{name}", h.handleGet)
+	indexRouter.Delete("/{name}", h.handleDelete)
+	indexRouter.Post("/{name}/rebuild", h.handleRebuild)
+	r.Mount("/api/indexes", indexRouter)
+}
+
+// handleList returns the indexes
+// @Summary List indexes
+// @Description List the index tables with the state of their last build
+// @Tags indexes
+// @Produce json
+// @Success 200 {array} indexes.Index
+// @Router /api/indexes [get]
+func (h *IndexHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.builder.Indexes()
+	if err != nil {
+		h.indexError(w, err, "Failed to list indexes")
+		return
+	}
+	chix.NewRender(w).JSON(list)
+}
+
+// handleCreate creates an index and schedules its first build
+// @Summary Create index
+// @Description Create an index table __idx_<name> listing the keys of a table whose JSON value matches a filter. It is built in the background and rebuilt at the interval; key scans with an equivalent filter are answered from it once built.
+// @Tags indexes
+// @Accept json
+// @Produce json
+// @Param request body CreateIndexRequest true "Index"
+// @Success 201 {object} indexes.Index
+// @Header 201 {string} Location "Path of the new index"
+// @Failure 400 {string} string "Invalid index"
+// @Failure 409 {string} string "Index or its table already exists"
+// @Router /api/indexes [post]
+func (h *IndexHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	var req CreateIndexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var interval time.Duration
+	if req.Interval != "" {
+		var err error
+		if interval, err = time.ParseDuration(req.Interval); err != nil {
+			http.Error(w, "Invalid interval: use a duration like 1h", http.StatusBadRequest)
+			return
+		}
+	}
+	idx, err := h.builder.Create(r.Context(), indexes.Index{
+		Name:      req.Name,
+		Table:     req.Table,
+		Filter:    req.Filter,
+		Interval:  interval,
+		CreatedBy: auth.UserName(r.Context()),
+	})
+	if err != nil {
+		h.indexError(w, err, "Failed to create index")
+		return
+	}
+	h.logger.Info("Created index", zap.String("name", idx.Name), zap.String("table", idx.Table),
+		zap.String("filter", idx.Filter), zap.String("user", idx.CreatedBy))
+
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/indexes/"+idx.Name)))
+	render.Status(http.StatusCreated)
+	render.JSON(idx)
+}
+
+// handleGet returns a single index
+// @Summary Get index
+// @Tags indexes
+// @Produce json
+// @Param name path string true "Index name"
+// @Success 200 {object} indexes.Index
+// @Failure 404 {string} string "Index not found"
+// @Router /api/indexes/{name} [get]
+func (h *IndexHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	idx, err := h.builder.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		h.indexError(w, err, "Failed to get index")
+		return
+	}
+	chix.NewRender(w).JSON(idx)
+}
+
+// handleDelete removes an index and its table
+// @Summary Delete index
+// @Tags indexes
+// @Produce json
+// @Param name path string true "Index name"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Index not found"
+// @Router /api/indexes/{name} [delete]
+func (h *IndexHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := h.builder.Delete(r.Context(), name); err != nil {
+		h.indexError(w, err, "Failed to delete index")
+		return
+	}
+	h.logger.Info("Deleted index", zap.String("name", name), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// handleRebuild schedules a build of an index
+// @Summary Rebuild index
+// @Description Rebuild an index in the background now instead of at its interval
+// @Tags indexes
+// @Produce json
+// @Param name path string true "Index name"
+// @Success 202 {object} indexes.Index
+// @Failure 404 {string} string "Index not found"
+// @Router /api/indexes/{name}/rebuild [post]
+func (h *IndexHandler) handleRebuild(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	idx, err := h.builder.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		h.indexError(w, err, "Failed to rebuild index")
+		return
+	}
+	h.builder.Rebuild(idx.Name)
+
+	render.Status(http.StatusAccepted)
+	render.JSON(idx)
+}
+
+// indexError answers with the status matching an error of the index builder. Unexpected
+// errors are logged and answered with the message.
+func (h *IndexHandler) indexError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, indexes.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, indexes.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, indexes.ErrExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
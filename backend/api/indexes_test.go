@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/indexes"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// tablesClient keeps several tables in memory, so index tables can be built and scanned
+type tablesClient struct {
+	*mockArmadaClient
+	tables map[string]map[string]string
+}
+
+func (c *tablesClient) CreateTable(_ context.Context, name string) (string, error) {
+	if _, ok := c.tables[name]; ok {
+		return "", armada.ErrTableExists
+	}
+	c.tables[name] = make(map[string]string)
+	return name, nil
+}
+
+func (c *tablesClient) DeleteTable(_ context.Context, name string) error {
+	if _, ok := c.tables[name]; !ok {
+		return armada.ErrTableNotFound
+	}
+	delete(c.tables, name)
+	return nil
+}
+
+func (c *tablesClient) GetKeyValuePairs(_ context.Context, table, _, start, _ string, limit int) ([]armada.KeyValuePair, error) {
+	keys := make([]string, 0, len(c.tables[table]))
+	for key := range c.tables[table] {
+		if key >= start {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	pairs := make([]armada.KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		if len(pairs) == limit {
+			break
+		}
+		pairs = append(pairs, armada.KeyValuePair{Key: key, Value: c.tables[table][key]})
+	}
+	return pairs, nil
+}
+
+func (c *tablesClient) GetKeyValue(_ context.Context, table, key string) (*armada.KeyValuePair, error) {
+	value, ok := c.tables[table][key]
+	if !ok {
+		return nil, armada.ErrKeyNotFound
+	}
+	return &armada.KeyValuePair{Key: key, Value: value}, nil
+}
+
+func (c *tablesClient) PutKeyValue(_ context.Context, table, key, value string) error {
+	c.tables[table][key] = value
+	return nil
+}
+
+func (c *tablesClient) DeleteKey(_ context.Context, table, key string) error {
+	delete(c.tables[table], key)
+	return nil
+}
+
+func TestGetKeyValueIndexed(t *testing.T) {
+	client := &tablesClient{mockArmadaClient: &mockArmadaClient{}, tables: map[string]map[string]string{"jobs": {}}}
+	for i := range 300 {
+		status := "done"
+		if i%10 == 0 {
+			status = "active"
+		}
+		client.tables["jobs"][fmt.Sprintf("job/%03d", i)] = fmt.Sprintf(`{"status":%q}`, status)
+	}
+	builder := indexes.NewBuilder(client, metadata.NewMemoryStore(), zap.NewNop())
+	handler := createTestHandler()
+	handler.client = client
+	WithIndexes(builder)(handler)
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+	NewIndexHandler(builder, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/indexes", strings.NewReader(`{"name":"active","table":"jobs","filter":"status=='active'","interval":"1h"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if location := rr.Header().Get("Location"); location != "/api/indexes/active" {
+		t.Errorf("Expected the location of the index, got %q", location)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/indexes", strings.NewReader(`{"name":"active","table":"jobs","filter":"status"}`)))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected an existing index to return %d, got %d", http.StatusConflict, rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/indexes", strings.NewReader(`{"name":"other","table":"jobs","filter":"status","interval":"often"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid interval to return %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+	if err := builder.Build(context.Background(), "active"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values changed since the build are read from the table and matched again
+	client.tables["jobs"]["job/000"] = `{"status":"done"}`
+	// Scans are answered from the index table, keys that were never indexed aren't in it
+	client.tables["jobs"]["job/001"] = `{"status":"active"}`
+
+	get := func(filter string) ([]armada.KeyValuePair, http.Header) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/kv/jobs?"+url.Values{"filter": {filter}}.Encode(), nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned %d: %s", rr.Code, rr.Body.String())
+		}
+		var pairs []armada.KeyValuePair
+		if err := json.Unmarshal(rr.Body.Bytes(), &pairs); err != nil {
+			t.Fatal(err)
+		}
+		return pairs, rr.Header()
+	}
+
+	pairs, header := get(`$.status == "active"`)
+	if header.Get(IndexHeader) != "active" || header.Get(IndexBuiltAtHeader) == "" {
+		t.Errorf("Expected the scan to be answered from the index, got headers %v", header)
+	}
+	if len(pairs) != 29 || pairs[0].Key != "job/010" {
+		t.Errorf("Expected the 29 indexed matches still matching from job/010, got %d pairs starting with %v", len(pairs), pairs[0])
+	}
+
+	pairs, header = get(`status == "done"`)
+	if header.Get(IndexHeader) != "" {
+		t.Errorf("Expected other filters to scan the table, got index %q", header.Get(IndexHeader))
+	}
+	if len(pairs) != 100 || pairs[0].Key != "job/000" {
+		t.Errorf("Expected 100 matches from job/000, got %d", len(pairs))
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/indexes/active", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	if _, ok := client.tables[indexes.IndexTable("active")]; ok {
+		t.Error("Expected deleting the index to delete its table")
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/indexes/active", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected a deleted index to return %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
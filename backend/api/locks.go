@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/locks"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// ReleaseLockRequest releases the key holding a lock
+type ReleaseLockRequest struct {
+	Table string `json:"table"`
+	Key   string `json:"key"`
+	// CreateRevision is the create revision of the holder as inspected, the release is refused if
+	// the key was created again since
+	CreateRevision int64 `json:"createRevision"`
+	// Confirm must repeat the key, as releasing a lock held by a live process breaks mutual exclusion
+	Confirm string `json:"confirm"`
+}
+
+// LocksHandler serves the locks and elections applications keep in the cluster
+type LocksHandler struct {
+	inspector *locks.Inspector
+	auditLog  audit.Log
+	logger    *zap.Logger
+}
+
+// NewLocksHandler creates a new lock inspector API handler
+func NewLocksHandler(inspector *locks.Inspector, auditLog audit.Log, logger *zap.Logger) *LocksHandler {
+	return &LocksHandler{
+		inspector: inspector,
+		auditLog:  auditLog,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the lock routes under /api/locks
+func (h *LocksHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/locks", h.handleInspect)
+	r.Post("/api/locks/release", h.handleRelease)
+}
+
+// handleInspect lists the locks and elections of a table
+// @Summary Inspect locks
+// @Description List the locks and elections of a table whose keys follow a lock or election convention, with their holder and waiters. The contender created first holds the lock; observedSince is when the console first saw a contender in its position.
+// @Tags locks
+// @Produce json
+// @Param table query string true "Table"
+// @Param prefix query string false "Only inspect keys with this prefix"
+// @Success 200 {object} locks.Report
+// @Failure 400 {string} string "Table is required"
+// @Router /api/locks [get]
+func (h *LocksHandler) handleInspect(w http.ResponseWriter, r *http.Request) {
+	table := r.URL.Query().Get("table")
+	if table == "" {
+		http.Error(w, "table is required", http.StatusBadRequest)
+		return
+	}
+	report, err := h.inspector.Inspect(r.Context(), table, r.URL.Query().Get("prefix"))
+	if err != nil {
+		h.lockError(w, err, "Failed to inspect locks")
+		return
+	}
+	chix.NewRender(w).JSON(report)
+}
+
+// handleRelease force releases a lock by deleting the key holding it
+// @Summary Force release lock
+// @Description Delete the key holding a lock or leading an election, handing it to the next waiter, e.g. when its holder died without its lease expiring. The key must be repeated in confirm and still hold the lock at the inspected create revision.
+// @Tags locks
+// @Accept json
+// @Produce json
+// @Param request body ReleaseLockRequest true "Lock holder"
+// @Success 200 {object} locks.Contender "Released holder"
+// @Failure 400 {string} string "Invalid request"
+// @Failure 404 {string} string "Lock key not found"
+// @Failure 409 {string} string "Key doesn't hold the lock"
+// @Router /api/locks/release [post]
+func (h *LocksHandler) handleRelease(w http.ResponseWriter, r *http.Request) {
+	var req ReleaseLockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Table == "" || req.Key == "" {
+		http.Error(w, "table and key are required", http.StatusBadRequest)
+		return
+	}
+	if req.Confirm != req.Key {
+		http.Error(w, "confirm must repeat the key to release", http.StatusBadRequest)
+		return
+	}
+
+	released, err := h.inspector.Release(r.Context(), req.Table, req.Key, req.CreateRevision)
+	h.recordAudit(r, tableResourceID(req.Table), err, map[string]string{
+		"key":            req.Key,
+		"createRevision": strconv.FormatInt(req.CreateRevision, 10),
+	})
+	if err != nil {
+		h.lockError(w, err, "Failed to release lock")
+		return
+	}
+	h.logger.Warn("Force released lock", zap.String("table", req.Table), zap.String("key", req.Key),
+		zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(released)
+}
+
+// recordAudit appends an entry for a released lock to the audit log.
+// Failures to write the audit log are logged but don't fail the release.
+func (h *LocksHandler) recordAudit(r *http.Request, resource string, opErr error, details map[string]string) {
+	entry := audit.Entry{
+		User:     auth.UserName(r.Context()),
+		Action:   "lock.release",
+		Resource: resource,
+		Outcome:  audit.OutcomeSuccess,
+		Details:  details,
+	}
+	if opErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = opErr.Error()
+	}
+	if _, err := h.auditLog.Record(r.Context(), entry); err != nil {
+		h.logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", entry.Action))
+	}
+}
+
+// lockError answers with the status matching an error of the inspector. Unexpected
+// errors are logged and answered with the message.
+func (h *LocksHandler) lockError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, locks.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, locks.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, locks.ErrNotHolder):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/conventions"
+	"github.com/armadakv/console/backend/locks"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// revisionsClient keeps the keys of a table with their create revisions
+type revisionsClient struct {
+	keys map[string]armada.KeyRevision
+}
+
+func (c *revisionsClient) GetKeyRevisions(_ context.Context, _, prefix string, _ int) ([]armada.KeyRevision, error) {
+	var revisions []armada.KeyRevision
+	for key, kv := range c.keys {
+		if strings.HasPrefix(key, prefix) {
+			revisions = append(revisions, kv)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Key < revisions[j].Key })
+	return revisions, nil
+}
+
+func (c *revisionsClient) DeleteKey(_ context.Context, _, key string) error {
+	delete(c.keys, key)
+	return nil
+}
+
+func TestLocks(t *testing.T) {
+	client := &revisionsClient{keys: map[string]armada.KeyRevision{
+		"locks/scheduler/0f": {Key: "locks/scheduler/0f", CreateRevision: 10},
+		"locks/scheduler/1a": {Key: "locks/scheduler/1a", CreateRevision: 12},
+	}}
+	auditLog := audit.NewMemoryLog(10)
+	inspector := locks.NewInspector(client, conventions.NewRegistry(metadata.NewMemoryStore()))
+	r := chi.NewRouter()
+	NewLocksHandler(inspector, auditLog, zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/locks?table=coordination", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var report locks.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Locks) != 1 || report.Locks[0].Holder.Key != "locks/scheduler/0f" || len(report.Locks[0].Waiters) != 1 {
+		t.Fatalf("Expected one lock held by locks/scheduler/0f with one waiter, got %+v", report.Locks)
+	}
+
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"unconfirmed", `{"table":"coordination","key":"locks/scheduler/0f","createRevision":10}`, http.StatusBadRequest},
+		{"waiter", `{"table":"coordination","key":"locks/scheduler/1a","createRevision":12,"confirm":"locks/scheduler/1a"}`, http.StatusConflict},
+		{"missing", `{"table":"coordination","key":"locks/other/0f","createRevision":10,"confirm":"locks/other/0f"}`, http.StatusNotFound},
+		{"holder", `{"table":"coordination","key":"locks/scheduler/0f","createRevision":10,"confirm":"locks/scheduler/0f"}`, http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/locks/release", strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected status code %d, got %d: %s", tc.name, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+	if _, ok := client.keys["locks/scheduler/0f"]; ok {
+		t.Error("Expected the holder to be deleted")
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{Action: "lock.release"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected the 3 confirmed release attempts to be audited, got %d", len(entries))
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/maintenance"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// WithMaintenance makes the handler reject writes to tables frozen by an active
+// maintenance window of the named cluster, and mark them read-only in table responses
+func WithMaintenance(scheduler *maintenance.Scheduler, cluster string) HandlerOption {
+	return func(h *Handler) {
+		h.maintenance = scheduler
+		h.cluster = cluster
+	}
+}
+
+// checkNotFrozen answers 423 Locked and returns false if writes to the table are frozen
+// by an active maintenance window. An empty table checks for a freeze of the whole cluster.
+func (h *Handler) checkNotFrozen(w http.ResponseWriter, table string) bool {
+	if h.maintenance == nil {
+		return true
+	}
+	window, frozen, err := h.maintenance.Frozen(h.cluster, table, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to check maintenance windows", zap.Error(err), zap.String("table", table))
+		http.Error(w, "Failed to check maintenance windows", http.StatusInternalServerError)
+		return false
+	}
+	if frozen {
+		http.Error(w, "Writes are frozen during maintenance window \""+window.Title+"\" until "+
+			window.End.UTC().Format(time.RFC3339), http.StatusLocked)
+		return false
+	}
+	return true
+}
+
+// markReadOnly flags the tables marked read-only or frozen by an active maintenance window
+func (h *Handler) markReadOnly(items []TableListItem) {
+	var windows []maintenance.Window
+	if h.maintenance != nil {
+		var err error
+		if windows, err = h.maintenance.Active(time.Now()); err != nil {
+			h.logger.Warn("Failed to check maintenance windows", zap.Error(err))
+		}
+	}
+	for i := range items {
+		items[i].ReadOnly = items[i].Metadata != nil && items[i].Metadata.ReadOnly
+		for _, window := range windows {
+			if window.FreezeWrites && window.Affects(h.cluster, items[i].Name) {
+				items[i].ReadOnly = true
+				items[i].MaintenanceWindow = window.ID
+				break
+			}
+		}
+	}
+}
+
+// MaintenanceHandler serves the scheduling of maintenance windows
+type MaintenanceHandler struct {
+	scheduler *maintenance.Scheduler
+	logger    *zap.Logger
+}
+
+// NewMaintenanceHandler creates a new maintenance window API handler
+func NewMaintenanceHandler(scheduler *maintenance.Scheduler, logger *zap.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		scheduler: scheduler,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the maintenance routes under /api/maintenance
+func (h *MaintenanceHandler) RegisterRoutes(r chi.Router) {
+	maintenanceRouter := chi.NewRouter()
+	maintenanceRouter.Get("/", h.handleList)
+	maintenanceRouter.Post("/", h.handleCreate)
+	maintenanceRouter.Get("/{id}", h.handleGet)
+	maintenanceRouter.Delete("/{id}", h.handleDelete)
+	r.Mount("/api/maintenance", maintenanceRouter)
+}
+
+// handleList returns the windows overlapping the range from to, e.g. to annotate a dashboard.
+// With active=true only the windows in effect now are returned.
+// @Summary List maintenance windows
+// @Tags maintenance
+// @Produce json
+// @Param from query string false "Start of the range (RFC3339 or unix timestamp)"
+// @Param to query string false "End of the range (RFC3339 or unix timestamp)"
+// @Param active query bool false "Only return the windows in effect now"
+// @Success 200 {array} maintenance.Window
+// @Failure 400 {string} string "Invalid time"
+// @Router /api/maintenance [get]
+func (h *MaintenanceHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+	query := r.URL.Query()
+
+	var windows []maintenance.Window
+	var err error
+	if query.Get("active") == "true" {
+		windows, err = h.scheduler.Active(time.Now())
+	} else {
+		var from, to time.Time
+		if raw := query.Get("from"); raw != "" {
+			if from, err = parseTimeParam(raw); err != nil {
+				http.Error(w, "from must be an RFC3339 or unix timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		if raw := query.Get("to"); raw != "" {
+			if to, err = parseTimeParam(raw); err != nil {
+				http.Error(w, "to must be an RFC3339 or unix timestamp", http.StatusBadRequest)
+				return
+			}
+		}
+		windows, err = h.scheduler.List(from, to)
+	}
+	if err != nil {
+		h.logger.Error("Failed to list maintenance windows", zap.Error(err))
+		http.Error(w, "Failed to list maintenance windows", http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(windows)
+}
+
+// handleCreate schedules a maintenance window
+// @Summary Schedule maintenance window
+// @Tags maintenance
+// @Accept json
+// @Produce json
+// @Param request body maintenance.Window true "Maintenance window"
+// @Success 201 {object} maintenance.Window
+// @Header 201 {string} Location "Path of the new window"
+// @Failure 400 {string} string "Invalid maintenance window"
+// @Router /api/maintenance [post]
+func (h *MaintenanceHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	var window maintenance.Window
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := window.Validate(); err != nil {
+		http.Error(w, "Invalid maintenance window: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	window.CreatedBy = auth.UserName(r.Context())
+	window.CreatedAt = time.Time{}
+
+	window, err := h.scheduler.Create(window)
+	if err != nil {
+		h.logger.Error("Failed to schedule maintenance window", zap.Error(err))
+		http.Error(w, "Failed to schedule maintenance window", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("Scheduled maintenance window",
+		zap.String("id", window.ID),
+		zap.String("title", window.Title),
+		zap.Time("start", window.Start),
+		zap.Time("end", window.End),
+		zap.String("user", window.CreatedBy))
+
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/maintenance/"+window.ID)))
+	render.Status(http.StatusCreated)
+	render.JSON(window)
+}
+
+// handleGet returns a single maintenance window
+// @Summary Get maintenance window
+// @Tags maintenance
+// @Produce json
+// @Param id path string true "Window ID"
+// @Success 200 {object} maintenance.Window
+// @Failure 404 {string} string "Maintenance window not found"
+// @Router /api/maintenance/{id} [get]
+func (h *MaintenanceHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	window, err := h.scheduler.Get(chi.URLParam(r, "id"))
+	if errors.Is(err, maintenance.ErrNotFound) {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get maintenance window", zap.Error(err))
+		http.Error(w, "Failed to get maintenance window", http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(window)
+}
+
+// handleDelete cancels a maintenance window or ends it early
+// @Summary Delete maintenance window
+// @Description Cancel a maintenance window or end it early
+// @Tags maintenance
+// @Produce json
+// @Param id path string true "Window ID"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Maintenance window not found"
+// @Router /api/maintenance/{id} [delete]
+func (h *MaintenanceHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	id := chi.URLParam(r, "id")
+	err := h.scheduler.Delete(id)
+	if errors.Is(err, maintenance.ErrNotFound) {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete maintenance window", zap.Error(err), zap.String("id", id))
+		http.Error(w, "Failed to delete maintenance window", http.StatusInternalServerError)
+		return
+	}
+	h.logger.Info("Deleted maintenance window", zap.String("id", id), zap.String("user", auth.UserName(r.Context())))
+
+	render.JSON(make(map[string]any))
+}
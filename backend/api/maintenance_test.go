@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/maintenance"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"go.uber.org/zap"
+)
+
+func TestMaintenanceFreezesWrites(t *testing.T) {
+	scheduler := maintenance.NewScheduler(metadata.NewMemoryStore())
+	handler := createTestHandler()
+	WithMaintenance(scheduler, "prod")(handler)
+	now := time.Now()
+	window, err := scheduler.Create(maintenance.Window{
+		Title:        "Disk replacement",
+		Start:        now.Add(-time.Minute),
+		End:          now.Add(time.Hour),
+		Tables:       []string{"table1"},
+		FreezeWrites: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := map[string]string{"name": "table1", "table": "table1"}
+
+	rr := serveWithParams(handler.handlePutKeyValue, httptest.NewRequest("PUT", "/api/kv/table1", strings.NewReader(`{"key":"k","value":"v"}`)), params)
+	if rr.Code != http.StatusLocked || !strings.Contains(rr.Body.String(), "Disk replacement") {
+		t.Errorf("Expected writing to a frozen table to return %d, got %d: %s", http.StatusLocked, rr.Code, rr.Body.String())
+	}
+	rr = serveWithParams(handler.handleDeleteKey, httptest.NewRequest("DELETE", "/api/kv/table1?prefix=user/", nil), params)
+	if rr.Code != http.StatusLocked {
+		t.Errorf("Expected deleting by prefix from a frozen table to return %d, got %d", http.StatusLocked, rr.Code)
+	}
+	rr = serveWithParams(handler.handleDeleteTable, httptest.NewRequest("DELETE", "/api/tables/table1", nil), params)
+	if rr.Code != http.StatusLocked {
+		t.Errorf("Expected deleting a frozen table to return %d, got %d", http.StatusLocked, rr.Code)
+	}
+
+	// Other tables stay writable and reads are not affected
+	rr = serveWithParams(handler.handlePutKeyValue, httptest.NewRequest("PUT", "/api/kv/table2", strings.NewReader(`{"key":"k","value":"v"}`)), map[string]string{"table": "table2"})
+	if rr.Code == http.StatusLocked {
+		t.Errorf("Expected writing to another table not to be frozen")
+	}
+	rr = serveWithParams(handler.handleGetTable, httptest.NewRequest("GET", "/api/tables/table1", nil), params)
+	var item TableListItem
+	if err := json.Unmarshal(rr.Body.Bytes(), &item); err != nil {
+		t.Fatal(err)
+	}
+	if !item.ReadOnly || item.MaintenanceWindow != window.ID {
+		t.Errorf("Expected the table to be marked read-only by %s, got %+v", window.ID, item)
+	}
+
+	// Ending the window early lifts the freeze
+	if err := scheduler.Delete(window.ID); err != nil {
+		t.Fatal(err)
+	}
+	rr = serveWithParams(handler.handlePutKeyValue, httptest.NewRequest("PUT", "/api/kv/table1", strings.NewReader(`{"key":"k","value":"v"}`)), params)
+	if rr.Code == http.StatusLocked {
+		t.Errorf("Expected writing after the window to succeed, got %d", rr.Code)
+	}
+}
+
+func TestMaintenanceLifecycle(t *testing.T) {
+	handler := NewMaintenanceHandler(maintenance.NewScheduler(metadata.NewMemoryStore()), zap.NewNop())
+
+	rr := httptest.NewRecorder()
+	handler.handleCreate(rr, httptest.NewRequest("POST", "/api/maintenance", strings.NewReader(`{"title":"Upgrade","start":"2025-03-01T02:00:00Z","end":"2025-03-01T01:00:00Z"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected a window ending before its start to return %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleCreate(rr, httptest.NewRequest("POST", "/api/maintenance", strings.NewReader(`{"title":"Upgrade","start":"2025-03-01T02:00:00Z","end":"2025-03-01T04:00:00Z"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var created maintenance.Window
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || rr.Header().Get("Location") != "/api/maintenance/"+created.ID {
+		t.Errorf("Unexpected window %+v at %q", created, rr.Header().Get("Location"))
+	}
+
+	rr = httptest.NewRecorder()
+	handler.handleList(rr, httptest.NewRequest("GET", "/api/maintenance?from=2025-03-01T03:00:00Z&to=2025-03-02T00:00:00Z", nil))
+	var windows []maintenance.Window
+	if err := json.Unmarshal(rr.Body.Bytes(), &windows); err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) != 1 || windows[0].ID != created.ID {
+		t.Errorf("Expected the created window, got %+v", windows)
+	}
+
+	params := map[string]string{"id": created.ID}
+	rr = serveWithParams(handler.handleDelete, httptest.NewRequest("DELETE", "/api/maintenance/"+created.ID, nil), params)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	rr = serveWithParams(handler.handleGet, httptest.NewRequest("GET", "/api/maintenance/"+created.ID, nil), params)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestDiagnosticsSilencedByMaintenance(t *testing.T) {
+	scheduler := maintenance.NewScheduler(metadata.NewMemoryStore())
+	window, err := scheduler.Create(maintenance.Window{
+		Title:   "Rebalancing users",
+		Start:   time.Now().Add(-time.Minute),
+		End:     time.Now().Add(time.Hour),
+		Tables:  []string{"users"},
+		Silence: []string{"leader-flapping"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := staticAlerts{
+		{Rule: "leader-flapping", Severity: SeverityWarning, Subject: "users"},
+		{Rule: "leader-flapping", Severity: SeverityWarning, Subject: "orders"},
+	}
+	handler := NewDiagnosticsHandler(staticSkews{}, 2*time.Second, zap.NewNop(),
+		WithAlertRules(alerts, metrics.DefaultAlertRules), WithSilencer(scheduler))
+
+	report := handler.report(t.Context())
+	if len(report.Findings) != 1 || report.Findings[0].Subject != "orders" {
+		t.Errorf("Expected only the finding about orders, got %+v", report.Findings)
+	}
+	if len(report.Silenced) != 1 || report.Silenced[0].SilencedBy != window.ID {
+		t.Errorf("Expected the finding about users to be silenced by %s, got %+v", window.ID, report.Silenced)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// NavigationItem is an entry of the menu of the console
+type NavigationItem struct {
+	// ID identifies the item, e.g. to keep it expanded across reloads
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	// Path is the route of the page in the console, empty for groups of items
+	Path string `json:"path,omitempty"`
+	// Icon names the icon of the item, the frontend falls back to a generic one for unknown names
+	Icon     string           `json:"icon,omitempty"`
+	Children []NavigationItem `json:"children,omitempty"`
+	// Operator items are only listed for operators
+	Operator bool `json:"-"`
+}
+
+// NavigationResponse is the menu of the console as the caller may use it
+type NavigationResponse struct {
+	Role auth.Role `json:"role,omitempty"`
+	// ReadOnly is set when the console refuses all changes, whatever the role
+	ReadOnly bool             `json:"readOnly"`
+	Items    []NavigationItem `json:"items"`
+}
+
+// DefaultNavigation returns the pages every console has, features that can be disabled are
+// added to them where they are set up
+func DefaultNavigation() []NavigationItem {
+	return []NavigationItem{
+		{ID: "dashboard", Label: "Dashboard", Path: "/", Icon: "layout-dashboard"},
+		{ID: "data", Label: "Data", Path: "/data", Icon: "database"},
+		{ID: "fleet", Label: "Fleet", Path: "/fleet", Icon: "globe"},
+		{ID: "resources", Label: "Resources", Path: "/resources", Icon: "cpu"},
+		{ID: "targets", Label: "Targets", Path: "/targets", Icon: "activity"},
+		{ID: "settings", Label: "Settings", Path: "/settings", Icon: "settings"},
+	}
+}
+
+// FindNavigationItem returns the top-level item with the ID, so features can add their pages below it
+func FindNavigationItem(items []NavigationItem, id string) (*NavigationItem, bool) {
+	i := slices.IndexFunc(items, func(item NavigationItem) bool { return item.ID == id })
+	if i < 0 {
+		return nil, false
+	}
+	return &items[i], true
+}
+
+// NavigationHandler serves the menu of the console
+type NavigationHandler struct {
+	items  []NavigationItem
+	logger *zap.Logger
+}
+
+// NewNavigationHandler creates a new navigation API handler serving the items
+func NewNavigationHandler(items []NavigationItem, logger *zap.Logger) *NavigationHandler {
+	return &NavigationHandler{
+		items:  items,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the navigation routes
+func (h *NavigationHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/navigation", h.handleNavigation)
+}
+
+// handleNavigation returns the menu filtered by the role of the caller
+// @Summary Get navigation
+// @Description Get the menu of the console with the pages of the enabled features the caller may use, so the frontend shows exactly what is accessible
+// @Tags navigation
+// @Produce json
+// @Success 200 {object} NavigationResponse
+// @Router /api/navigation [get]
+func (h *NavigationHandler) handleNavigation(w http.ResponseWriter, r *http.Request) {
+	// Without role enforcement every request may do what operators may
+	role, ok := auth.RoleFromContext(r.Context())
+	operator := !ok || role == auth.RoleOperator
+
+	chix.NewRender(w).JSON(NavigationResponse{
+		Role:     role,
+		ReadOnly: auth.ReadOnlyFromContext(r.Context()),
+		Items:    visibleItems(h.items, operator),
+	})
+}
+
+// visibleItems returns the items the caller may use. Groups left without children are dropped.
+func visibleItems(items []NavigationItem, operator bool) []NavigationItem {
+	visible := make([]NavigationItem, 0, len(items))
+	for _, item := range items {
+		if item.Operator && !operator {
+			continue
+		}
+		if item.Children != nil {
+			item.Children = visibleItems(item.Children, operator)
+			if len(item.Children) == 0 {
+				if item.Path == "" {
+					continue
+				}
+				item.Children = nil
+			}
+		}
+		visible = append(visible, item)
+	}
+	return visible
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestHandleNavigation(t *testing.T) {
+	items := []NavigationItem{
+		{ID: "dashboard", Label: "Dashboard", Path: "/"},
+		{ID: "admin", Label: "Admin", Children: []NavigationItem{
+			{ID: "users", Label: "Users", Path: "/admin/users", Operator: true},
+		}},
+		{ID: "settings", Label: "Settings", Path: "/settings", Children: []NavigationItem{
+			{ID: "keys", Label: "API keys", Path: "/settings/api-keys", Operator: true},
+		}},
+	}
+	r := chi.NewRouter()
+	NewNavigationHandler(items, zap.NewNop()).RegisterRoutes(r)
+
+	navigation := func(req *http.Request) NavigationResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var response NavigationResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response body: %v", err)
+		}
+		return response
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want NavigationResponse
+	}{
+		{
+			name: "Operator",
+			req:  withRole(httptest.NewRequest("GET", "/api/navigation", nil), auth.RoleOperator),
+			want: NavigationResponse{Role: auth.RoleOperator, Items: []NavigationItem{
+				{ID: "dashboard", Label: "Dashboard", Path: "/"},
+				{ID: "admin", Label: "Admin", Children: []NavigationItem{{ID: "users", Label: "Users", Path: "/admin/users"}}},
+				{ID: "settings", Label: "Settings", Path: "/settings", Children: []NavigationItem{{ID: "keys", Label: "API keys", Path: "/settings/api-keys"}}},
+			}},
+		},
+		{
+			name: "Viewer",
+			req:  withRole(httptest.NewRequest("GET", "/api/navigation", nil), auth.RoleViewer),
+			want: NavigationResponse{Role: auth.RoleViewer, Items: []NavigationItem{
+				{ID: "dashboard", Label: "Dashboard", Path: "/"},
+				{ID: "settings", Label: "Settings", Path: "/settings"},
+			}},
+		},
+		{
+			name: "ReadOnly",
+			req: func() *http.Request {
+				req := withRole(httptest.NewRequest("GET", "/api/navigation", nil), auth.RoleViewer)
+				return req.WithContext(auth.WithReadOnly(req.Context()))
+			}(),
+			want: NavigationResponse{Role: auth.RoleViewer, ReadOnly: true, Items: []NavigationItem{
+				{ID: "dashboard", Label: "Dashboard", Path: "/"},
+				{ID: "settings", Label: "Settings", Path: "/settings"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The operator flag isn't part of the response
+			if got := navigation(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected navigation: got %+v want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// The handler keeps its items when filtering them
+	if !items[1].Children[0].Operator || len(items[2].Children) != 1 {
+		t.Errorf("items were changed: %+v", items)
+	}
+}
+
+func TestFindNavigationItem(t *testing.T) {
+	items := DefaultNavigation()
+	settings, ok := FindNavigationItem(items, "settings")
+	if !ok {
+		t.Fatal("Expected the default navigation to have settings")
+	}
+	settings.Children = append(settings.Children, NavigationItem{ID: "users", Label: "Users"})
+	if len(items[len(items)-1].Children) != 1 {
+		t.Error("Expected changes of the found item to change the navigation")
+	}
+	if _, ok := FindNavigationItem(items, "unknown"); ok {
+		t.Error("Expected unknown items not to be found")
+	}
+}
+
+func withRole(req *http.Request, role auth.Role) *http.Request {
+	return req.WithContext(auth.WithRole(req.Context(), role))
+}
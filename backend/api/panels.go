@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/panels"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// PanelsHandler serves the saved metric queries with their units and thresholds
+type PanelsHandler struct {
+	store  *panels.Store
+	logger *zap.Logger
+}
+
+// NewPanelsHandler creates a new saved panels API handler
+func NewPanelsHandler(store *panels.Store, logger *zap.Logger) *PanelsHandler {
+	return &PanelsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the panel routes under /api/panels
+func (h *PanelsHandler) RegisterRoutes(r chi.Router) {
+	panelRouter := chi.NewRouter()
+	panelRouter.Get("/", h.handleList)
+	panelRouter.Get("/{name}", h.handleGet)
+	panelRouter.Put("/{name}", h.handlePut)
+	panelRouter.Delete("/{name}", h.handleDelete)
+	r.Mount("/api/panels", panelRouter)
+}
+
+// handleList returns the panels
+// @Summary List panels
+// @Description List the saved metric queries with the unit and thresholds of their values
+// @Tags panels
+// @Produce json
+// @Success 200 {array} panels.Panel
+// @Router /api/panels [get]
+func (h *PanelsHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.List()
+	if err != nil {
+		h.panelError(w, err, "Failed to list panels")
+		return
+	}
+	chix.NewRender(w).JSON(list)
+}
+
+// handleGet returns a single panel
+// @Summary Get panel
+// @Tags panels
+// @Produce json
+// @Param name path string true "Panel name"
+// @Success 200 {object} panels.Panel
+// @Failure 404 {string} string "Panel not found"
+// @Router /api/panels/{name} [get]
+func (h *PanelsHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	p, err := h.store.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		h.panelError(w, err, "Failed to get panel")
+		return
+	}
+	chix.NewRender(w).JSON(p)
+}
+
+// handlePut creates or replaces a panel
+// @Summary Put panel
+// @Description Create or replace a saved metric query. Its unit, e.g. seconds or bytes, and its warning and critical thresholds are returned as annotations by /api/metrics/query and /api/metrics/query_range when they are given the panel name, so every chart of the query renders the same axes and colors. Values above the thresholds are bad unless the direction is below.
+// @Tags panels
+// @Accept json
+// @Produce json
+// @Param name path string true "Panel name"
+// @Param request body panels.Panel true "Panel, its name is taken from the path"
+// @Success 200 {object} panels.Panel
+// @Failure 400 {string} string "Invalid panel"
+// @Router /api/panels/{name} [put]
+func (h *PanelsHandler) handlePut(w http.ResponseWriter, r *http.Request) {
+	var p panels.Panel
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	p.Name = chi.URLParam(r, "name")
+	p, err := h.store.Put(p)
+	if err != nil {
+		h.panelError(w, err, "Failed to store panel")
+		return
+	}
+	h.logger.Info("Stored panel", zap.String("name", p.Name), zap.String("query", p.Query),
+		zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(p)
+}
+
+// handleDelete removes a panel
+// @Summary Delete panel
+// @Tags panels
+// @Produce json
+// @Param name path string true "Panel name"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Panel not found"
+// @Router /api/panels/{name} [delete]
+func (h *PanelsHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := h.store.Delete(name); err != nil {
+		h.panelError(w, err, "Failed to delete panel")
+		return
+	}
+	h.logger.Info("Deleted panel", zap.String("name", name), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// panelError answers with the status matching an error of the panel store. Unexpected
+// errors are logged and answered with the message.
+func (h *PanelsHandler) panelError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, panels.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, panels.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/panels"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestPanels(t *testing.T) {
+	r := chi.NewRouter()
+	NewPanelsHandler(panels.NewStore(metadata.NewMemoryStore()), zap.NewNop()).RegisterRoutes(r)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"PUT", "/api/panels/put-latency", `{"query":"rate(armada_puts_total[5m])","unit":"ops/s","thresholds":{"warning":1000,"critical":5000}}`, http.StatusOK},
+		{"PUT", "/api/panels/broken", `{"query":"rate(armada_puts_total[5m]"}`, http.StatusBadRequest},
+		{"PUT", "/api/panels/swapped", `{"query":"up","thresholds":{"warning":2,"critical":1}}`, http.StatusBadRequest},
+		{"GET", "/api/panels/missing", "", http.StatusNotFound},
+		{"DELETE", "/api/panels/missing", "", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", tc.method, tc.path, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/panels", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var list []panels.Panel
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "put-latency" || list[0].Unit != "ops/s" || *list[0].Thresholds.Critical != 5000 {
+		t.Errorf("Expected the put-latency panel with its unit and thresholds, got %+v", list)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/panels/put-latency", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+}
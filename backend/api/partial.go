@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// Fan-out endpoints query every server of the cluster. When some servers cannot be reached
+// they still answer 200 OK: each affected item carries an "error" field describing the
+// failure, and the response sets "partial": true so dashboards can show what they have
+// and flag the rest instead of failing entirely.
+
+// fanOut calls fn for every item concurrently and returns the results and errors in item order
+func fanOut[T, R any](ctx context.Context, items []T, fn func(ctx context.Context, item T) (R, error)) ([]R, []error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = fn(ctx, item)
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
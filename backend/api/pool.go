@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// PoolClient is the part of the Armada client whose connections the pool endpoint reports.
+// The armada.Client implements it.
+type PoolClient interface {
+	Address() string
+	GetConnectionPool() armada.ConnectionPoolInterface
+}
+
+// PoolResponse describes the connections of the console to the servers of a cluster
+type PoolResponse struct {
+	// Address is the address the console connects to the cluster through
+	Address string `json:"address"`
+	// HealthyConnections is the number of connections that are ready or idle and passed their last health check
+	HealthyConnections int `json:"healthyConnections"`
+	// Servers are the servers the console knows, by ID
+	Servers []armada.ServerInfo `json:"servers"`
+}
+
+// PoolHandler serves the state of the connection pool of the default cluster and lets operators
+// replace or drop the connections to its servers
+type PoolHandler struct {
+	client   PoolClient
+	auditLog audit.Log
+	logger   *zap.Logger
+}
+
+// NewPoolHandler creates a new connection pool API handler
+func NewPoolHandler(client PoolClient, auditLog audit.Log, logger *zap.Logger) *PoolHandler {
+	return &PoolHandler{
+		client:   client,
+		auditLog: auditLog,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the connection pool routes
+func (h *PoolHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/debug/pool", h.handlePool)
+	r.Post("/api/servers/{id}/reconnect", h.handleReconnect)
+	r.Delete("/api/servers/{id}/connection", h.handleEvict)
+}
+
+// handlePool reports the servers of the connection pool
+// @Summary Get connection pool
+// @Description Report the Armada servers the console is connected to with their node IDs, addresses, connection states, reconnect counts and last errors, e.g. to find out why a server is marked unhealthy
+// @Tags debug
+// @Produce json
+// @Success 200 {object} PoolResponse
+// @Router /api/debug/pool [get]
+func (h *PoolHandler) handlePool(w http.ResponseWriter, r *http.Request) {
+	pool := h.client.GetConnectionPool()
+	servers := pool.GetKnownServers()
+	for _, server := range servers {
+		sort.Strings(server.Addresses)
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].ID < servers[j].ID })
+
+	chix.NewRender(w).JSON(PoolResponse{
+		Address:            h.client.Address(),
+		HealthyConnections: pool.HealthyConnections(),
+		Servers:            servers,
+	})
+}
+
+// handleReconnect replaces the connection to a server
+// @Summary Reconnect to server
+// @Description Dial a server again and replace its connection once it answers, e.g. after a certificate rotation. Failed attempts are retried up to ARMADA_RECONNECT_MAX_RETRIES times with a growing delay; the old connection is kept if the server can't be reached.
+// @Tags debug
+// @Produce json
+// @Param id path string true "Server ID, or its address if the ID isn't known"
+// @Success 200 {object} armada.ServerInfo
+// @Failure 404 {string} string "Server not found"
+// @Failure 502 {string} string "Server unreachable"
+// @Router /api/servers/{id}/reconnect [post]
+func (h *PoolHandler) handleReconnect(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	pool := h.client.GetConnectionPool()
+	err := pool.Reconnect(r.Context(), id)
+	h.recordAudit(r, "server.reconnect", id, err)
+	if err != nil {
+		h.serverError(w, err, "Failed to reconnect to server")
+		return
+	}
+	h.logger.Info("Reconnected to server", zap.String("id", id), zap.String("user", auth.UserName(r.Context())))
+
+	server, ok := findServer(pool, id)
+	if !ok {
+		http.Error(w, armada.ErrServerNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	chix.NewRender(w).JSON(server)
+}
+
+// handleEvict drops the connection to a server
+// @Summary Drop server connection
+// @Description Close the connection to a server and forget its addresses and reconnect history. The server is connected to again when it is used next or the cluster members are discovered again.
+// @Tags debug
+// @Produce json
+// @Param id path string true "Server ID, or its address if the ID isn't known"
+// @Success 200 {object} armada.ServerInfo "Dropped server"
+// @Failure 404 {string} string "Server not found"
+// @Router /api/servers/{id}/connection [delete]
+func (h *PoolHandler) handleEvict(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	pool := h.client.GetConnectionPool()
+	server, ok := findServer(pool, id)
+	if !ok {
+		http.Error(w, armada.ErrServerNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	err := pool.Evict(id)
+	h.recordAudit(r, "server.evict", id, err)
+	if err != nil {
+		h.serverError(w, err, "Failed to drop server connection")
+		return
+	}
+	h.logger.Warn("Dropped server connection", zap.String("id", id), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(server)
+}
+
+// findServer returns the server of the pool with the ID
+func findServer(pool armada.ConnectionPoolInterface, id string) (armada.ServerInfo, bool) {
+	for _, server := range pool.GetKnownServers() {
+		if server.ID == id {
+			sort.Strings(server.Addresses)
+			return server, true
+		}
+	}
+	return armada.ServerInfo{}, false
+}
+
+// recordAudit appends an entry for an operation on a server connection to the audit log.
+// Failures to write the audit log are logged but don't fail the operation.
+func (h *PoolHandler) recordAudit(r *http.Request, action, id string, opErr error) {
+	entry := audit.Entry{
+		User:     auth.UserName(r.Context()),
+		Action:   action,
+		Resource: "servers/" + id,
+		Outcome:  audit.OutcomeSuccess,
+	}
+	if opErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = opErr.Error()
+	}
+	if _, err := h.auditLog.Record(r.Context(), entry); err != nil {
+		h.logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", entry.Action))
+	}
+}
+
+// serverError answers with the status matching an error of the connection pool. Other errors
+// mean the server couldn't be reached and are answered with 502 Bad Gateway.
+func (h *PoolHandler) serverError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, armada.ErrServerNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.logger.Warn(message, zap.Error(err))
+	http.Error(w, message+": "+err.Error(), http.StatusBadGateway)
+}
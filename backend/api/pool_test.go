@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// poolClient reports a fixed set of servers
+type poolClient struct {
+	armada.ConnectionPoolInterface
+	servers []armada.ServerInfo
+	// unreachable fails reconnects to these servers
+	unreachable map[string]bool
+}
+
+func (c *poolClient) Address() string {
+	return "armada-0:5001"
+}
+
+func (c *poolClient) GetConnectionPool() armada.ConnectionPoolInterface {
+	return c
+}
+
+func (c *poolClient) GetKnownServers() []armada.ServerInfo {
+	return c.servers
+}
+
+func (c *poolClient) HealthyConnections() int {
+	return 1
+}
+
+func (c *poolClient) Reconnect(_ context.Context, id string) error {
+	for i, server := range c.servers {
+		if server.ID == id {
+			if c.unreachable[id] {
+				return errors.New("connection refused")
+			}
+			c.servers[i].Reconnects++
+			return nil
+		}
+	}
+	return armada.ErrServerNotFound
+}
+
+func (c *poolClient) Evict(id string) error {
+	for i, server := range c.servers {
+		if server.ID == id {
+			c.servers = append(c.servers[:i], c.servers[i+1:]...)
+			return nil
+		}
+	}
+	return armada.ErrServerNotFound
+}
+
+func TestPool(t *testing.T) {
+	client := &poolClient{servers: []armada.ServerInfo{
+		{ID: "node2", Addresses: []string{"armada-1:5001"}, ConnectionState: "TRANSIENT_FAILURE", ProbeError: "unavailable", Reconnects: 3, LastError: "unavailable"},
+		{ID: "node1", Addresses: []string{"armada-0:5001", "10.0.0.1:5001"}, ConnectionState: "READY"},
+	}}
+	r := chi.NewRouter()
+	NewPoolHandler(client, audit.NewMemoryLog(10), zap.NewNop()).RegisterRoutes(r)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/debug/pool", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp PoolResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Address != "armada-0:5001" || resp.HealthyConnections != 1 || len(resp.Servers) != 2 {
+		t.Fatalf("Expected the address, one healthy connection and two servers, got %+v", resp)
+	}
+	if resp.Servers[0].ID != "node1" || !reflect.DeepEqual(resp.Servers[0].Addresses, []string{"10.0.0.1:5001", "armada-0:5001"}) {
+		t.Errorf("Expected the servers and their addresses to be sorted, got %+v", resp.Servers)
+	}
+	if resp.Servers[1].Reconnects != 3 || resp.Servers[1].LastError != "unavailable" {
+		t.Errorf("Expected the reconnects and last error of node2, got %+v", resp.Servers[1])
+	}
+}
+
+func TestPoolReconnectAndEvict(t *testing.T) {
+	client := &poolClient{
+		servers: []armada.ServerInfo{
+			{ID: "node1", Addresses: []string{"armada-0:5001"}, ConnectionState: "READY"},
+			{ID: "node2", Addresses: []string{"armada-1:5001"}, ConnectionState: "TRANSIENT_FAILURE"},
+		},
+		unreachable: map[string]bool{"node2": true},
+	}
+	auditLog := audit.NewMemoryLog(10)
+	r := chi.NewRouter()
+	NewPoolHandler(client, auditLog, zap.NewNop()).RegisterRoutes(r)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{"POST", "/api/servers/node1/reconnect", http.StatusOK},
+		{"POST", "/api/servers/node2/reconnect", http.StatusBadGateway},
+		{"POST", "/api/servers/node3/reconnect", http.StatusNotFound},
+		{"DELETE", "/api/servers/node2/connection", http.StatusOK},
+		{"DELETE", "/api/servers/node2/connection", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+		if rr.Code != tc.want {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", tc.method, tc.path, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+	if client.servers[0].Reconnects != 1 || len(client.servers) != 1 {
+		t.Errorf("Expected node1 to be reconnected and node2 to be dropped, got %+v", client.servers)
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{Action: "server.reconnect"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected the 3 reconnect attempts to be audited, got %d", len(entries))
+	}
+	entries, err = auditLog.List(context.Background(), audit.Query{Action: "server.evict"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Resource != "servers/node2" {
+		t.Errorf("Expected the dropped connection of node2 to be audited, got %+v", entries)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/query"
+	"github.com/armadakv/console/backend/transform"
+)
+
+// maxProjectedFields bounds the number of fields a scan can project
+const maxProjectedFields = 32
+
+// ProjectedKeyValuePair is a key-value pair of which only selected fields of its JSON value are returned
+type ProjectedKeyValuePair struct {
+	Key string `json:"key"`
+	// Fields maps the requested paths to the values found in the value, paths the value
+	// doesn't contain are left out
+	Fields map[string]json.RawMessage `json:"fields"`
+	// Transforms lists the encodings reversed to get the value, if it was decoded
+	Transforms []string `json:"transforms,omitempty"`
+	// ProjectError explains why no fields could be extracted, e.g. because the value isn't JSON
+	ProjectError string `json:"projectError,omitempty"`
+}
+
+// projection returns the paths selected by the project query parameters, or nil if
+// whole values should be returned
+func projection(r *http.Request) ([]query.Path, error) {
+	raw := r.URL.Query()["project"]
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if len(raw) > maxProjectedFields {
+		return nil, fmt.Errorf("at most %d fields can be projected, got %d", maxProjectedFields, len(raw))
+	}
+	paths := make([]query.Path, 0, len(raw))
+	for _, s := range raw {
+		path, err := query.ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// projectPair extracts the fields selected by the paths from the JSON value of a pair, which is
+// decoded first if decoder is not nil
+func projectPair(decoder *transform.Pipeline, paths []query.Path, pair armada.KeyValuePair) ProjectedKeyValuePair {
+	projected := ProjectedKeyValuePair{Key: pair.Key, Fields: make(map[string]json.RawMessage, len(paths))}
+	value := pair.Value
+	if decoder != nil {
+		decoded := decodePair(decoder, pair)
+		if decoded.DecodeError != "" {
+			projected.ProjectError = decoded.DecodeError
+			return projected
+		}
+		value, projected.Transforms = decoded.Value, decoded.Transforms
+	}
+
+	doc, err := query.DecodeDocument(value)
+	if err != nil {
+		projected.ProjectError = err.Error()
+		return projected
+	}
+	for _, path := range paths {
+		field, ok := path.Lookup(doc)
+		if !ok {
+			continue
+		}
+		// Numbers are kept as json.Number, so re-encoding them can't fail or lose precision
+		encoded, err := json.Marshal(field)
+		if err != nil {
+			continue
+		}
+		projected.Fields[path.String()] = encoded
+	}
+	return projected
+}
+
+// projectPairs extracts the fields selected by the paths from every value of a list of pairs
+func projectPairs(decoder *transform.Pipeline, paths []query.Path, pairs []armada.KeyValuePair) []ProjectedKeyValuePair {
+	projected := make([]ProjectedKeyValuePair, 0, len(pairs))
+	for _, pair := range pairs {
+		projected = append(projected, projectPair(decoder, paths, pair))
+	}
+	return projected
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+func TestGetKeyValueProjected(t *testing.T) {
+	handler := createTestHandler()
+	compressed, err := handler.transforms.Encode([]byte(`{"user":{"name":"bob"},"total":12.50}`), []string{"gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.client.(*mockArmadaClient).kvPairs = []armada.KeyValuePair{
+		{Key: "order/1", Value: `{"user":{"name":"alice","tags":["vip"]},"total":99999999999999999999,"items":[{"sku":"a"}]}`},
+		{Key: "order/2", Value: string(compressed)},
+		{Key: "order/3", Value: "not json"},
+	}
+	params := map[string]string{"table": "table1"}
+	query := url.Values{"project": {"$.user.name", "$.total", "$.items[0].sku", "$.user.tags"}, "decode": {"auto"}}
+
+	rr := serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?"+query.Encode(), nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	var pairs []ProjectedKeyValuePair
+	if err := json.NewDecoder(rr.Body).Decode(&pairs); err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 3 {
+		t.Fatalf("Expected 3 pairs, got %d", len(pairs))
+	}
+
+	want := map[string]string{"$.user.name": `"alice"`, "$.total": `99999999999999999999`, "$.items[0].sku": `"a"`, "$.user.tags": `["vip"]`}
+	if len(pairs[0].Fields) != len(want) {
+		t.Errorf("Expected fields %v, got %s", want, pairs[0].Fields)
+	}
+	for path, value := range want {
+		if string(pairs[0].Fields[path]) != value {
+			t.Errorf("Expected %s to be %s, got %s", path, value, pairs[0].Fields[path])
+		}
+	}
+	// Compressed values are decoded first, paths the value lacks are left out
+	if string(pairs[1].Fields["$.user.name"]) != `"bob"` || string(pairs[1].Fields["$.total"]) != `12.50` ||
+		len(pairs[1].Fields) != 2 || len(pairs[1].Transforms) != 1 {
+		t.Errorf("Unexpected projection of the compressed value: %+v", pairs[1])
+	}
+	if pairs[2].ProjectError == "" || len(pairs[2].Fields) != 0 {
+		t.Errorf("Expected an error for a value that isn't JSON, got %+v", pairs[2])
+	}
+
+	rr = serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?project=user.name", nil), params)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d for an invalid path, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
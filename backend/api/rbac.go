@@ -0,0 +1,131 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"go.uber.org/zap"
+)
+
+// LocalRoles assigns roles to the users managed in the console. The accounts.Directory implements it.
+type LocalRoles interface {
+	// RoleOf returns the role of the user, or false if none is assigned.
+	RoleOf(name string) (auth.Role, bool)
+}
+
+// RoleMapping assigns roles to users by principal or group
+type RoleMapping struct {
+	// Default is the role of users that are neither listed as operators nor members of an operator group.
+	// Requests are also given this role when authentication is disabled.
+	Default auth.Role
+	// Operators are the users with the operator role. They are named with the method they
+	// authenticate with, so local users or API keys named like them aren't operators.
+	Operators []auth.Principal
+	// OperatorGroups are the groups whose members have the operator role.
+	OperatorGroups []string
+	// Local assigns the roles of users who logged in with basic authentication, if set.
+	Local LocalRoles
+	// APIKeys assigns the roles of API keys, if set. Keys are only granted the role they were
+	// created with, the viewer role if it is unknown.
+	APIKeys LocalRoles
+}
+
+// RoleOf returns the role of a user
+func (m RoleMapping) RoleOf(user auth.User) auth.Role {
+	if user.Method == auth.MethodAPIKey {
+		if m.APIKeys != nil {
+			if role, ok := m.APIKeys.RoleOf(user.Name); ok {
+				return role
+			}
+		}
+		return auth.RoleViewer
+	}
+	if user.IsAny(m.Operators) {
+		return auth.RoleOperator
+	}
+	for _, group := range user.Groups {
+		if slices.Contains(m.OperatorGroups, group) {
+			return auth.RoleOperator
+		}
+	}
+	if m.Local != nil && user.Method == auth.MethodBasic {
+		if role, ok := m.Local.RoleOf(user.Name); ok {
+			return role
+		}
+	}
+	return m.Default
+}
+
+// SplitList splits a comma-separated list of names, dropping empty entries
+func SplitList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// readMethods are the methods viewers may call
+var readMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+// Authorize returns a middleware enforcing the roles of the mapping. Viewers may only call
+// GET endpoints and the viewerPaths, which must not change the cluster; other requests are
+// rejected with 403 Forbidden and recorded in the audit log, if it is not nil. It must run
+// after authentication, as it reads the user from the request context. Every request
+// carries the role of its user in the context.
+func Authorize(roles RoleMapping, auditLog audit.Log, logger *zap.Logger, viewerPaths ...string) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, _ := auth.UserFromContext(r.Context())
+			role := roles.RoleOf(user)
+			r = r.WithContext(auth.WithRole(r.Context(), role))
+
+			// Logging out and managing sessions must always be possible, they only concern the user's own sessions
+			if role == auth.RoleOperator || slices.Contains(readMethods, r.Method) ||
+				auth.SelfServicePath(r.URL.Path) || slices.Contains(viewerPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Info("Denied request of viewer",
+				zap.String("user", auth.UserName(r.Context())),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path))
+			if auditLog != nil {
+				entry := audit.Entry{
+					User:     auth.UserName(r.Context()),
+					Action:   "request",
+					Resource: strings.TrimPrefix(r.URL.Path, "/api/"),
+					Outcome:  audit.OutcomeDenied,
+					Error:    "the operator role is required",
+					Details:  map[string]string{"method": r.Method, "role": string(role)},
+				}
+				if _, err := auditLog.Record(r.Context(), entry); err != nil {
+					logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", entry.Action))
+				}
+			}
+			http.Error(w, "Forbidden: the operator role is required", http.StatusForbidden)
+		})
+	}
+}
+
+// requireOperator rejects the requests of other roles than operators with 403 Forbidden, e.g. on
+// routes whose responses viewers must not read either. Requests without a role are let through,
+// Authorize assigns one to every request when it runs.
+func requireOperator(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if role, ok := auth.RoleFromContext(r.Context()); ok && role != auth.RoleOperator {
+			http.Error(w, "Forbidden: the operator role is required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"go.uber.org/zap"
+)
+
+// localRoles assigns roles to local users by name
+type localRoles map[string]auth.Role
+
+func (l localRoles) RoleOf(name string) (auth.Role, bool) {
+	role, ok := l[name]
+	return role, ok
+}
+
+func TestRoleOf(t *testing.T) {
+	roles := RoleMapping{
+		Default:        auth.RoleViewer,
+		Operators:      []auth.Principal{{Method: auth.MethodOIDC, Name: "alice"}},
+		OperatorGroups: []string{"sre"},
+		Local:          localRoles{"dave": auth.RoleOperator},
+		APIKeys:        localRoles{"ci": auth.RoleOperator, "alice": auth.RoleViewer},
+	}
+	tests := []struct {
+		user auth.User
+		want auth.Role
+	}{
+		{user: auth.User{Name: "alice", Method: auth.MethodOIDC}, want: auth.RoleOperator},
+		{user: auth.User{Name: "alice", Method: auth.MethodBasic}, want: auth.RoleViewer},
+		{user: auth.User{Name: "alice", Method: auth.MethodBearer}, want: auth.RoleViewer},
+		{user: auth.User{Name: "bob", Groups: []string{"dev", "sre"}}, want: auth.RoleOperator},
+		{user: auth.User{Name: "carol", Groups: []string{"dev"}}, want: auth.RoleViewer},
+		{user: auth.User{}, want: auth.RoleViewer},
+		{user: auth.User{Name: "dave", Method: auth.MethodBasic}, want: auth.RoleOperator},
+		{user: auth.User{Name: "dave", Method: auth.MethodOIDC}, want: auth.RoleViewer},
+		{user: auth.User{Name: "ci", Method: auth.MethodAPIKey}, want: auth.RoleOperator},
+		{user: auth.User{Name: "alice", Method: auth.MethodAPIKey}, want: auth.RoleViewer},
+		{user: auth.User{Name: "dave", Method: auth.MethodAPIKey}, want: auth.RoleViewer},
+	}
+	for _, tt := range tests {
+		if got := roles.RoleOf(tt.user); got != tt.want {
+			t.Errorf("RoleOf(%+v) = %q, want %q", tt.user, got, tt.want)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := SplitList(" alice, ,bob,")
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitList() = %v, want %v", got, want)
+	}
+	if got := SplitList(""); got != nil {
+		t.Errorf("SplitList(\"\") = %v, want nil", got)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	auditLog := audit.NewMemoryLog(10)
+	roles := RoleMapping{Default: auth.RoleViewer, Operators: []auth.Principal{{Method: auth.MethodOIDC, Name: "alice"}}}
+	var role auth.Role
+	handler := Authorize(roles, auditLog, zap.NewNop(), "/api/analytics/pageviews")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role, _ = auth.RoleFromContext(r.Context())
+	}))
+
+	serve := func(method, path, user string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req = req.WithContext(auth.WithUser(req.Context(), auth.User{Name: user, Method: auth.MethodOIDC}))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := serve("GET", "/api/tables", "bob"); code != http.StatusOK || role != auth.RoleViewer {
+		t.Errorf("Expected viewers to read, got status %d and role %q", code, role)
+	}
+	if code := serve("POST", auth.LogoutPath, "bob"); code != http.StatusOK {
+		t.Errorf("Expected viewers to log out, got status %d", code)
+	}
+	if code := serve("POST", "/api/analytics/pageviews", "bob"); code != http.StatusOK {
+		t.Errorf("Expected viewers to call viewer paths, got status %d", code)
+	}
+	if code := serve("DELETE", "/api/tables/users", "alice"); code != http.StatusOK || role != auth.RoleOperator {
+		t.Errorf("Expected operators to delete tables, got status %d and role %q", code, role)
+	}
+	for _, method := range []string{"PUT", "DELETE", "POST"} {
+		if code := serve(method, "/api/kv/users", "bob"); code != http.StatusForbidden {
+			t.Errorf("Expected %s of viewer to be forbidden, got status %d", method, code)
+		}
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 audit entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.User != "bob" || e.Outcome != audit.OutcomeDenied || e.Resource != "kv/users" || e.Details["method"] != "POST" {
+		t.Errorf("Unexpected audit entry: %+v", e)
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/armadakv/console/backend/auth"
+	"go.uber.org/zap"
+)
+
+// ReadOnly returns a middleware refusing every change, so the console can be shared with a
+// wide audience for observability only. Requests with other methods than GET, HEAD and OPTIONS
+// are rejected with 403 Forbidden, except for logging out, managing the user's own sessions and
+// the allowedPaths, which must not change the cluster, e.g. counting page views.
+func ReadOnly(logger *zap.Logger, allowedPaths ...string) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(auth.WithReadOnly(r.Context()))
+			if slices.Contains(readMethods, r.Method) || auth.SelfServicePath(r.URL.Path) || slices.Contains(allowedPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger.Info("Denied change in read-only mode",
+				zap.String("user", auth.UserName(r.Context())),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path))
+			http.Error(w, "Forbidden: the console is in read-only mode, changes are disabled", http.StatusForbidden)
+		})
+	}
+}
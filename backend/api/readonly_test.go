@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/auth"
+	"go.uber.org/zap"
+)
+
+func TestReadOnly(t *testing.T) {
+	var readOnly bool
+	handler := ReadOnly(zap.NewNop(), PageViewPath)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readOnly = auth.ReadOnlyFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/tables", http.StatusOK},
+		{http.MethodHead, "/api/kv/users", http.StatusOK},
+		{http.MethodPut, "/api/kv/users", http.StatusForbidden},
+		{http.MethodDelete, "/api/kv/users", http.StatusForbidden},
+		{http.MethodPost, "/api/tables", http.StatusForbidden},
+		{http.MethodDelete, "/api/tables/users", http.StatusForbidden},
+		{http.MethodPost, auth.LogoutPath, http.StatusOK},
+		{http.MethodPost, PageViewPath, http.StatusOK},
+	}
+	for _, tt := range tests {
+		readOnly = false
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rr.Code, tt.want)
+		}
+		if tt.want == http.StatusOK && !readOnly {
+			t.Errorf("%s %s: the request is not marked read-only", tt.method, tt.path)
+		}
+		if tt.want == http.StatusForbidden && !strings.Contains(rr.Body.String(), "read-only mode") {
+			t.Errorf("%s %s: unexpected message %q", tt.method, tt.path, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTombstones bounds the number of deleted resources remembered by the handler
+const maxTombstones = 4096
+
+// valueETag computes a strong entity tag for a stored value.
+// The same value always yields the same tag, so clients can use it with If-Match.
+func valueETag(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matchesETag reports whether an If-Match/If-None-Match header value matches the given tag.
+// The header may contain a comma separated list of tags or the "*" wildcard.
+func matchesETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPreconditions reports whether the request has If-Match or If-None-Match headers
+func hasPreconditions(r *http.Request) bool {
+	return r.Header.Get("If-Match") != "" || r.Header.Get("If-None-Match") != ""
+}
+
+// checkPreconditions evaluates the If-Match and If-None-Match request headers
+// against the current state of a resource. An empty etag means the resource does not exist.
+// It returns the HTTP status to respond with, or 0 if the request may proceed.
+func checkPreconditions(r *http.Request, etag string) int {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if etag == "" || !matchesETag(ifMatch, etag) {
+			return http.StatusPreconditionFailed
+		}
+	}
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if etag != "" && matchesETag(ifNoneMatch, etag) {
+			return http.StatusPreconditionFailed
+		}
+	}
+	return 0
+}
+
+// tombstones remembers resources deleted through the console so that
+// later requests for them can be answered with 410 Gone instead of 404 Not Found.
+// They are only kept in memory: after a restart, or on other consoles of the cluster,
+// deleted resources are answered with 404 Not Found.
+type tombstones struct {
+	mu      sync.Mutex
+	deleted map[string]time.Time
+}
+
+// newTombstones creates an empty tombstone set
+func newTombstones() *tombstones {
+	return &tombstones{
+		deleted: make(map[string]time.Time),
+	}
+}
+
+// add records a deletion, evicting the oldest entry when the set is full
+func (t *tombstones) add(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.deleted) >= maxTombstones {
+		var oldestID string
+		var oldest time.Time
+		for candidate, at := range t.deleted {
+			if oldestID == "" || at.Before(oldest) {
+				oldestID, oldest = candidate, at
+			}
+		}
+		delete(t.deleted, oldestID)
+	}
+	t.deleted[id] = time.Now()
+}
+
+// remove forgets a deletion, e.g. when the resource is recreated
+func (t *tombstones) remove(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.deleted, id)
+}
+
+// contains reports whether the resource was deleted through the console
+func (t *tombstones) contains(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.deleted[id]
+	return ok
+}
+
+// tableResourceID returns the stable resource ID used to track a table
+func tableResourceID(name string) string {
+	return "tables/" + name
+}
+
+// keyResourceID returns the stable resource ID used to track a key within a table
+func keyResourceID(table, key string) string {
+	return "kv/" + table + "\x00" + key
+}
+
+// missingStatus returns 410 Gone for resources deleted through the console and 404 otherwise
+func (t *tombstones) missingStatus(id string) int {
+	if t.contains(id) {
+		return http.StatusGone
+	}
+	return http.StatusNotFound
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValueETag(t *testing.T) {
+	if valueETag("a") != valueETag("a") {
+		t.Error("valueETag should be stable for the same value")
+	}
+	if valueETag("a") == valueETag("b") {
+		t.Error("valueETag should differ for different values")
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	etag := valueETag("value")
+
+	tests := []struct {
+		name        string
+		ifMatch     string
+		ifNoneMatch string
+		current     string
+		want        int
+	}{
+		{name: "NoHeaders", current: etag, want: 0},
+		{name: "IfMatchSame", ifMatch: etag, current: etag, want: 0},
+		{name: "IfMatchList", ifMatch: `"other", ` + etag, current: etag, want: 0},
+		{name: "IfMatchWeak", ifMatch: "W/" + etag, current: etag, want: 0},
+		{name: "IfMatchDifferent", ifMatch: `"other"`, current: etag, want: http.StatusPreconditionFailed},
+		{name: "IfMatchMissing", ifMatch: "*", current: "", want: http.StatusPreconditionFailed},
+		{name: "IfNoneMatchMissing", ifNoneMatch: "*", current: "", want: 0},
+		{name: "IfNoneMatchExisting", ifNoneMatch: "*", current: etag, want: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if got := checkPreconditions(req, tt.current); got != tt.want {
+				t.Errorf("checkPreconditions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTombstones(t *testing.T) {
+	ts := newTombstones()
+
+	if got := ts.missingStatus("tables/a"); got != http.StatusNotFound {
+		t.Errorf("missingStatus() = %v, want %v", got, http.StatusNotFound)
+	}
+
+	ts.add("tables/a")
+	if got := ts.missingStatus("tables/a"); got != http.StatusGone {
+		t.Errorf("missingStatus() = %v, want %v", got, http.StatusGone)
+	}
+
+	ts.remove("tables/a")
+	if ts.contains("tables/a") {
+		t.Error("tombstone should be removed")
+	}
+
+	// The set is bounded
+	for i := 0; i < maxTombstones+10; i++ {
+		ts.add(fmt.Sprintf("kv/%d", i))
+	}
+	if len(ts.deleted) != maxTombstones {
+		t.Errorf("tombstone set size = %v, want %v", len(ts.deleted), maxTombstones)
+	}
+}
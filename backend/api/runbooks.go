@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/runbooks"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// RunbooksHandler serves the runbook links and remediation notes of alert rules and diagnostic checks
+type RunbooksHandler struct {
+	store  *runbooks.Store
+	logger *zap.Logger
+}
+
+// NewRunbooksHandler creates a new runbooks API handler
+func NewRunbooksHandler(store *runbooks.Store, logger *zap.Logger) *RunbooksHandler {
+	return &RunbooksHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the runbook routes under /api/runbooks
+func (h *RunbooksHandler) RegisterRoutes(r chi.Router) {
+	runbookRouter := chi.NewRouter()
+	runbookRouter.Get("/", h.handleList)
+	runbookRouter.Get("/{check}", h.handleGet)
+	runbookRouter.Put("/{check}", h.handlePut)
+	runbookRouter.Delete("/{check}", h.handleDelete)
+	r.Mount("/api/runbooks", runbookRouter)
+}
+
+// handleList returns the stored runbooks
+// @Summary List runbooks
+// @Description List the stored runbooks of alert rules and diagnostic checks. Checks without a stored runbook report the one they ship with, if any.
+// @Tags runbooks
+// @Produce json
+// @Success 200 {array} runbooks.Runbook
+// @Router /api/runbooks [get]
+func (h *RunbooksHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.List()
+	if err != nil {
+		h.runbookError(w, err, "Failed to list runbooks")
+		return
+	}
+	chix.NewRender(w).JSON(list)
+}
+
+// handleGet returns the stored runbook of a check
+// @Summary Get runbook
+// @Tags runbooks
+// @Produce json
+// @Param check path string true "Alert rule or diagnostic check, e.g. leader-flapping"
+// @Success 200 {object} runbooks.Runbook
+// @Failure 404 {string} string "Runbook not found"
+// @Router /api/runbooks/{check} [get]
+func (h *RunbooksHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	rb, err := h.store.Get(chi.URLParam(r, "check"))
+	if err != nil {
+		h.runbookError(w, err, "Failed to get runbook")
+		return
+	}
+	chix.NewRender(w).JSON(rb)
+}
+
+// handlePut creates or replaces the runbook of a check
+// @Summary Put runbook
+// @Description Create or replace the runbook of an alert rule or diagnostic check: a link to the runbook, e.g. in the team's wiki, and first steps in Markdown. Findings and alerts of the check in /api/diagnostics carry it instead of the runbook the check ships with.
+// @Tags runbooks
+// @Accept json
+// @Produce json
+// @Param check path string true "Alert rule or diagnostic check, e.g. leader-flapping"
+// @Param request body runbooks.Runbook true "Runbook, its check is taken from the path"
+// @Success 200 {object} runbooks.Runbook
+// @Failure 400 {string} string "Invalid runbook"
+// @Router /api/runbooks/{check} [put]
+func (h *RunbooksHandler) handlePut(w http.ResponseWriter, r *http.Request) {
+	var rb runbooks.Runbook
+	if err := json.NewDecoder(r.Body).Decode(&rb); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	rb.Check = chi.URLParam(r, "check")
+	rb, err := h.store.Put(rb)
+	if err != nil {
+		h.runbookError(w, err, "Failed to store runbook")
+		return
+	}
+	h.logger.Info("Stored runbook", zap.String("check", rb.Check), zap.String("url", rb.URL),
+		zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(rb)
+}
+
+// handleDelete removes the runbook of a check
+// @Summary Delete runbook
+// @Description Remove the stored runbook of a check, its findings carry the runbook the check ships with again
+// @Tags runbooks
+// @Produce json
+// @Param check path string true "Alert rule or diagnostic check, e.g. leader-flapping"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Runbook not found"
+// @Router /api/runbooks/{check} [delete]
+func (h *RunbooksHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	check := chi.URLParam(r, "check")
+	if err := h.store.Delete(check); err != nil {
+		h.runbookError(w, err, "Failed to delete runbook")
+		return
+	}
+	h.logger.Info("Deleted runbook", zap.String("check", check), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// runbookError answers with the status matching an error of the runbook store. Unexpected
+// errors are logged and answered with the message.
+func (h *RunbooksHandler) runbookError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, runbooks.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, runbooks.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/runbooks"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestRunbooks(t *testing.T) {
+	r := chi.NewRouter()
+	NewRunbooksHandler(runbooks.NewStore(metadata.NewMemoryStore()), zap.NewNop()).RegisterRoutes(r)
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"PUT", "/api/runbooks/leader-flapping", `{"url":"https://wiki.example.com/armada/leader-flapping","remediation":"Check the disks"}`, http.StatusOK},
+		{"PUT", "/api/runbooks/clock-skew", `{}`, http.StatusBadRequest},
+		{"PUT", "/api/runbooks/clock-skew", `{"url":"javascript:alert(1)"}`, http.StatusBadRequest},
+		{"GET", "/api/runbooks/missing", "", http.StatusNotFound},
+		{"DELETE", "/api/runbooks/missing", "", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", tc.method, tc.path, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/runbooks", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var list []runbooks.Runbook
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Check != "leader-flapping" || list[0].Remediation != "Check the disks" {
+		t.Errorf("Expected the leader-flapping runbook, got %+v", list)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("DELETE", "/api/runbooks/leader-flapping", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/armadakv/console/backend/shadow"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// WithShadow mirrors the writes served by the handler to a secondary cluster once they
+// succeeded on its own cluster
+func WithShadow(mirror *shadow.Mirror) HandlerOption {
+	return func(h *Handler) {
+		if mirror != nil {
+			h.client = &shadowedClient{ArmadaClient: h.client, mirror: mirror}
+		}
+	}
+}
+
+// shadowedClient hands the successful writes of the client to the mirror
+type shadowedClient struct {
+	ArmadaClient
+	mirror *shadow.Mirror
+}
+
+func (c *shadowedClient) CreateTable(ctx context.Context, tableName string) (string, error) {
+	id, err := c.ArmadaClient.CreateTable(ctx, tableName)
+	if err == nil {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpCreateTable, Table: tableName})
+	}
+	return id, err
+}
+
+func (c *shadowedClient) DeleteTable(ctx context.Context, tableName string) error {
+	err := c.ArmadaClient.DeleteTable(ctx, tableName)
+	if err == nil {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpDeleteTable, Table: tableName})
+	}
+	return err
+}
+
+func (c *shadowedClient) PutKeyValue(ctx context.Context, table, key, value string) error {
+	err := c.ArmadaClient.PutKeyValue(ctx, table, key, value)
+	if err == nil {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpPut, Table: table, Key: key, Value: value})
+	}
+	return err
+}
+
+func (c *shadowedClient) DeleteKey(ctx context.Context, table, key string) error {
+	err := c.ArmadaClient.DeleteKey(ctx, table, key)
+	if err == nil {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpDelete, Table: table, Key: key})
+	}
+	return err
+}
+
+func (c *shadowedClient) PutKeyValueIf(ctx context.Context, table, key, value string, current *string) (bool, error) {
+	stored, err := c.ArmadaClient.PutKeyValueIf(ctx, table, key, value, current)
+	if stored {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpPut, Table: table, Key: key, Value: value})
+	}
+	return stored, err
+}
+
+func (c *shadowedClient) DeleteKeyIf(ctx context.Context, table, key, current string) (bool, error) {
+	deleted, err := c.ArmadaClient.DeleteKeyIf(ctx, table, key, current)
+	if deleted {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpDelete, Table: table, Key: key})
+	}
+	return deleted, err
+}
+
+func (c *shadowedClient) DeletePrefix(ctx context.Context, table, prefix string) (int64, error) {
+	deleted, err := c.ArmadaClient.DeletePrefix(ctx, table, prefix)
+	if err == nil {
+		c.mirror.Enqueue(shadow.Op{Type: shadow.OpDeletePrefix, Table: table, Key: prefix})
+	}
+	return deleted, err
+}
+
+// ShadowRetryResponse reports the failed writes queued again
+type ShadowRetryResponse struct {
+	Retried int `json:"retried"`
+}
+
+// ShadowClearResponse reports the discarded failed writes
+type ShadowClearResponse struct {
+	Cleared int `json:"cleared"`
+}
+
+// ShadowHandler serves the state of the writes mirrored to the secondary cluster
+type ShadowHandler struct {
+	mirror *shadow.Mirror
+	logger *zap.Logger
+}
+
+// NewShadowHandler creates a new shadowing API handler
+func NewShadowHandler(mirror *shadow.Mirror, logger *zap.Logger) *ShadowHandler {
+	return &ShadowHandler{
+		mirror: mirror,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the shadowing routes
+func (h *ShadowHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/shadow", h.handleShadowReport)
+	r.Post("/api/shadow/retry", h.handleShadowRetry)
+	r.Delete("/api/shadow/failures", h.handleShadowClear)
+}
+
+// handleShadowReport reports the writes mirrored to the secondary cluster and those that failed
+// @Summary Get shadowing report
+// @Description Report the writes mirrored to the secondary cluster and the writes that couldn't be mirrored
+// @Tags shadow
+// @Produce json
+// @Success 200 {object} shadow.Report
+// @Router /api/shadow [get]
+func (h *ShadowHandler) handleShadowReport(w http.ResponseWriter, r *http.Request) {
+	chix.NewRender(w).JSON(h.mirror.Report())
+}
+
+// handleShadowRetry queues the failed writes again
+// @Summary Retry failed mirrored writes
+// @Description Queue the writes that couldn't be mirrored again, after the writes made since
+// @Tags shadow
+// @Produce json
+// @Success 200 {object} ShadowRetryResponse
+// @Router /api/shadow/retry [post]
+func (h *ShadowHandler) handleShadowRetry(w http.ResponseWriter, r *http.Request) {
+	retried := h.mirror.Retry()
+	h.logger.Info("Retrying failed mirrored writes", zap.Int("retried", retried))
+	chix.NewRender(w).JSON(ShadowRetryResponse{Retried: retried})
+}
+
+// handleShadowClear discards the failed writes
+// @Summary Discard failed mirrored writes
+// @Description Discard the writes that couldn't be mirrored, e.g. after syncing the secondary cluster otherwise
+// @Tags shadow
+// @Produce json
+// @Success 200 {object} ShadowClearResponse
+// @Router /api/shadow/failures [delete]
+func (h *ShadowHandler) handleShadowClear(w http.ResponseWriter, r *http.Request) {
+	cleared := h.mirror.ClearFailures()
+	h.logger.Info("Discarded failed mirrored writes", zap.Int("cleared", cleared))
+	chix.NewRender(w).JSON(ShadowClearResponse{Cleared: cleared})
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/shadow"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestShadowWrites(t *testing.T) {
+	primary := &tablesClient{mockArmadaClient: &mockArmadaClient{}, tables: map[string]map[string]string{"users": {}}}
+	secondary := &tablesClient{mockArmadaClient: &mockArmadaClient{}, tables: map[string]map[string]string{"users": {}}}
+	mirror := shadow.NewMirror(secondary, "staging", 10, 10, zap.NewNop())
+	handler := createTestHandler()
+	handler.client = primary
+	WithShadow(mirror)(handler)
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+	NewShadowHandler(mirror, zap.NewNop()).RegisterRoutes(r)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("PUT", "/api/kv/users", strings.NewReader(`{"key":"user/1","value":"alice"}`)),
+		httptest.NewRequest("PUT", "/api/kv/users", strings.NewReader(`{"key":"user/2","value":"bob"}`)),
+		httptest.NewRequest("DELETE", "/api/kv/users?key=user/1", nil),
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code >= 300 {
+			t.Fatalf("%s %s returned %d: %s", req.Method, req.URL, rr.Code, rr.Body.String())
+		}
+	}
+	// Writes are mirrored in the background
+	mirror.Start(context.Background())
+	defer mirror.Stop()
+	deadline := time.Now().Add(time.Second)
+	for mirror.Report().Mirrored < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/shadow", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var report shadow.Report
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Cluster != "staging" || report.Mirrored != 3 || report.Failed != 0 {
+		t.Errorf("Expected 3 writes mirrored to staging, got %+v", report)
+	}
+	if len(secondary.tables["users"]) != 1 || secondary.tables["users"]["user/2"] != "bob" {
+		t.Errorf("Expected the secondary cluster to match the primary one, got %v", secondary.tables["users"])
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/stats"
+)
+
+// snapshotTimeout bounds how long capturing a snapshot may delay a destructive operation
+const snapshotTimeout = 10 * time.Second
+
+// StateSnapshot is the cluster state captured before a destructive operation.
+// It is stored with the audit entry of the operation so post-incident reviews
+// have a picture of the state before the change.
+type StateSnapshot struct {
+	CapturedAt time.Time         `json:"capturedAt"`
+	Members    []armada.Server   `json:"members"`
+	Status     *StatusResponse   `json:"status,omitempty"`
+	Table      string            `json:"table,omitempty"`
+	TableStats *stats.TableStats `json:"tableStats,omitempty"`
+	// Sample holds the first keys of the affected range if sampling is enabled
+	Sample []armada.KeyValuePair `json:"sample,omitempty"`
+	// Errors lists the parts of the snapshot that could not be captured
+	Errors []string `json:"errors,omitempty"`
+}
+
+// captureSnapshot records the member list, server status, table statistics and optionally
+// a sample of the keys affected by a destructive operation on a table.
+// Capturing is best effort: failures are recorded in the snapshot and never block the operation.
+func (h *Handler) captureSnapshot(ctx context.Context, table, prefix string) *StateSnapshot {
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	snapshot := &StateSnapshot{
+		CapturedAt: time.Now().UTC(),
+		Table:      table,
+	}
+
+	members, err := h.client.GetAllServers(ctx)
+	if err != nil {
+		snapshot.Errors = append(snapshot.Errors, "members: "+err.Error())
+	} else {
+		snapshot.Members = members
+		status := h.collectStatus(ctx, members)
+		// Server configuration is static and large, it is not worth keeping
+		for i := range status.Servers {
+			status.Servers[i].Config = nil
+		}
+		snapshot.Status = &status
+	}
+
+	if h.tableStats != nil {
+		if ts, ok := h.tableStats.Table(table); ok {
+			snapshot.TableStats = &ts
+		}
+	}
+
+	if h.snapshotSample > 0 {
+		// A truncated scan still keeps the pairs received before its deadline
+		sample, err := h.client.GetKeyValuePairs(ctx, table, prefix, "", "", h.snapshotSample)
+		if err != nil {
+			snapshot.Errors = append(snapshot.Errors, "sample: "+err.Error())
+		}
+		snapshot.Sample = sample
+	}
+
+	return snapshot
+}
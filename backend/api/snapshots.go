@@ -0,0 +1,221 @@
+package api
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/snapshots"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// BatchEvaluator evaluates batches of metric queries.
+// The metrics.MetricsHandler implements this interface.
+type BatchEvaluator interface {
+	// EvaluateBatch evaluates the queries, only an invalid batch is returned as an error
+	EvaluateBatch(ctx context.Context, req metrics.BatchQueryRequest) (metrics.BatchQueryResponse, error)
+}
+
+// SnapshotQuery is a query of a snapshot with the title of its panel
+type SnapshotQuery struct {
+	metrics.BatchQuery
+	Title string `json:"title,omitempty"`
+}
+
+// CreateSnapshotRequest holds the queries of a dashboard to snapshot. Queries without a time or
+// range of their own are evaluated over the range of the request, or at the time of the
+// snapshot if it has none either.
+type CreateSnapshotRequest struct {
+	Title string `json:"title,omitempty"`
+	snapshots.Range
+	Queries []SnapshotQuery `json:"queries"`
+	// Timeout is the deadline shared by all queries, e.g. 10s, at most the query timeout
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// SnapshotsHandler stores the query results of dashboards for sharing
+type SnapshotsHandler struct {
+	store     *snapshots.Store
+	evaluator BatchEvaluator
+	logger    *zap.Logger
+}
+
+// NewSnapshotsHandler creates a new snapshot API handler
+func NewSnapshotsHandler(store *snapshots.Store, evaluator BatchEvaluator, logger *zap.Logger) *SnapshotsHandler {
+	return &SnapshotsHandler{
+		store:     store,
+		evaluator: evaluator,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the snapshot routes under /api/snapshots
+func (h *SnapshotsHandler) RegisterRoutes(r chi.Router) {
+	snapshotRouter := chi.NewRouter()
+	snapshotRouter.Get("/", h.handleList)
+	snapshotRouter.Post("/", h.handleCreate)
+	snapshotRouter.Get("/{id}", h.handleGet)
+	snapshotRouter.Delete("/{id}", h.handleDelete)
+	r.Mount("/api/snapshots", snapshotRouter)
+}
+
+// handleList returns the snapshots without their results
+// @Summary List snapshots
+// @Tags snapshots
+// @Produce json
+// @Success 200 {array} snapshots.Summary
+// @Router /api/snapshots [get]
+func (h *SnapshotsHandler) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.List()
+	if err != nil {
+		h.snapshotError(w, err, "Failed to list snapshots")
+		return
+	}
+	chix.NewRender(w).JSON(list)
+}
+
+// handleCreate evaluates the queries of a dashboard and stores their results
+// @Summary Create snapshot
+// @Description Evaluate the queries of a dashboard like /api/metrics/batch_query and store their results with the queries and time range they came from. Snapshots can't be changed, so a shared link shows exactly what its author saw, even after the metrics left the retention. Queries that fail are stored with their error.
+// @Tags snapshots
+// @Accept json
+// @Produce json
+// @Param request body CreateSnapshotRequest true "Queries of the dashboard, at most 100"
+// @Success 201 {object} snapshots.Snapshot
+// @Header 201 {string} Location "Path of the new snapshot"
+// @Failure 400 {string} string "Invalid snapshot"
+// @Router /api/snapshots [post]
+func (h *SnapshotsHandler) handleCreate(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	var req CreateSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Instant queries are pinned to the time of the snapshot, so the stored queries tell when they were evaluated
+	createdAt := time.Now().UTC()
+	if req.Time == "" && req.Start == "" && req.End == "" {
+		req.Time = strconv.FormatInt(createdAt.Unix(), 10)
+	}
+	batch := metrics.BatchQueryRequest{Queries: make([]metrics.BatchQuery, len(req.Queries)), Timeout: req.Timeout}
+	for i, q := range req.Queries {
+		if q.Time == "" && q.Start == "" && q.End == "" {
+			if req.Start != "" || req.End != "" {
+				q.Start, q.End = req.Start, req.End
+				q.Step = cmp.Or(q.Step, req.Step)
+			} else {
+				q.Time = req.Time
+			}
+		}
+		req.Queries[i], batch.Queries[i] = q, q.BatchQuery
+	}
+
+	resp, err := h.evaluator.EvaluateBatch(r.Context(), batch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	snap := snapshots.Snapshot{
+		Title:     req.Title,
+		Range:     req.Range,
+		Panels:    make([]snapshots.Panel, len(req.Queries)),
+		CreatedBy: auth.UserName(r.Context()),
+		CreatedAt: createdAt,
+	}
+	for i, q := range req.Queries {
+		result := resp.Results[q.ID]
+		panel := snapshots.Panel{
+			Title:       q.Title,
+			Request:     q.BatchQuery,
+			Query:       result.Query,
+			Status:      result.Status,
+			Error:       result.Error,
+			Annotations: result.Annotations,
+		}
+		if result.Data != nil {
+			if panel.Result, err = json.Marshal(result.Data); err != nil {
+				h.snapshotError(w, err, "Failed to encode query result")
+				return
+			}
+		}
+		snap.Panels[i] = panel
+	}
+
+	snap, err = h.store.Create(snap)
+	if err != nil {
+		h.snapshotError(w, err, "Failed to store snapshot")
+		return
+	}
+	h.logger.Info("Created snapshot",
+		zap.String("id", snap.ID),
+		zap.String("title", snap.Title),
+		zap.Int("panels", len(snap.Panels)),
+		zap.String("user", snap.CreatedBy))
+
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/snapshots/"+snap.ID)))
+	render.Status(http.StatusCreated)
+	render.JSON(snap)
+}
+
+// handleGet returns a snapshot with its results
+// @Summary Get snapshot
+// @Tags snapshots
+// @Produce json
+// @Param id path string true "Snapshot ID"
+// @Success 200 {object} snapshots.Snapshot
+// @Failure 404 {string} string "Snapshot not found"
+// @Router /api/snapshots/{id} [get]
+func (h *SnapshotsHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	snap, err := h.store.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		h.snapshotError(w, err, "Failed to get snapshot")
+		return
+	}
+	chix.NewRender(w).JSON(snap)
+}
+
+// handleDelete removes a snapshot
+// @Summary Delete snapshot
+// @Tags snapshots
+// @Produce json
+// @Param id path string true "Snapshot ID"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Snapshot not found"
+// @Router /api/snapshots/{id} [delete]
+func (h *SnapshotsHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := h.store.Delete(id); err != nil {
+		h.snapshotError(w, err, "Failed to delete snapshot")
+		return
+	}
+	h.logger.Info("Deleted snapshot", zap.String("id", id), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// snapshotError answers with the status matching an error of the snapshot store. Unexpected
+// errors are logged and answered with the message.
+func (h *SnapshotsHandler) snapshotError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, snapshots.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, snapshots.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/snapshots"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// batchEvaluator answers every query of a batch with an empty vector, or fails queries named fail
+type batchEvaluator struct {
+	batch metrics.BatchQueryRequest
+}
+
+func (e *batchEvaluator) EvaluateBatch(_ context.Context, req metrics.BatchQueryRequest) (metrics.BatchQueryResponse, error) {
+	if len(req.Queries) == 0 {
+		return metrics.BatchQueryResponse{}, errors.New("Expected between 1 and 100 queries")
+	}
+	e.batch = req
+	resp := metrics.BatchQueryResponse{Status: "success", Results: make(map[string]metrics.BatchQueryResult)}
+	for _, q := range req.Queries {
+		if q.Query == "fail" {
+			resp.Results[q.ID] = metrics.BatchQueryResult{Status: "error", Query: q.Query, Error: "Query execution failed"}
+			continue
+		}
+		resp.Results[q.ID] = metrics.BatchQueryResult{Status: "success", Query: q.Query, Data: &metrics.QueryResult{Type: "vector"}}
+	}
+	return resp, nil
+}
+
+func TestSnapshots(t *testing.T) {
+	evaluator := &batchEvaluator{}
+	r := chi.NewRouter()
+	NewSnapshotsHandler(snapshots.NewStore(metadata.NewMemoryStore()), evaluator, zap.NewNop()).RegisterRoutes(r)
+
+	body := `{"title":"Incident","start":"1700000000","end":"1700003600","step":"1m","queries":[
+		{"id":"puts","title":"Puts","query":"sum(rate(armada_puts_total[5m]))"},
+		{"id":"now","query":"up","time":"1700003600"},
+		{"id":"broken","query":"fail"}]}`
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/snapshots", strings.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	var created snapshots.Snapshot
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if location := rr.Header().Get("Location"); location != "/api/snapshots/"+created.ID {
+		t.Errorf("Expected the location of the snapshot, got %q", location)
+	}
+
+	// Queries without a time range of their own are evaluated over the range of the snapshot
+	if q := evaluator.batch.Queries[0]; q.Start != "1700000000" || q.End != "1700003600" || q.Step != "1m" {
+		t.Errorf("Expected the range of the snapshot, got %+v", q)
+	}
+	if q := evaluator.batch.Queries[1]; q.Start != "" || q.Time != "1700003600" {
+		t.Errorf("Expected the instant query to keep its time, got %+v", q)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/snapshots/"+created.ID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var got snapshots.Snapshot
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Incident" || len(got.Panels) != 3 {
+		t.Fatalf("Expected the snapshot with 3 panels, got %+v", got)
+	}
+	if p := got.Panels[0]; p.Title != "Puts" || p.Status != "success" || !strings.Contains(string(p.Result), `"resultType":"vector"`) {
+		t.Errorf("Expected the result of the first panel, got %+v", p)
+	}
+	if p := got.Panels[2]; p.Status != "error" || p.Error != "Query execution failed" || p.Result != nil {
+		t.Errorf("Expected the error of the failed panel, got %+v", p)
+	}
+
+	// Instant snapshots are pinned to the time they were taken
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("POST", "/api/snapshots", strings.NewReader(`{"queries":[{"id":"up","query":"up"}]}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+	}
+	if q := evaluator.batch.Queries[0]; q.Time == "" {
+		t.Errorf("Expected the instant query to be pinned to the time of the snapshot, got %+v", q)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/snapshots", nil))
+	var list []snapshots.Summary
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("Expected 2 snapshots, got %+v", list)
+	}
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"POST", "/api/snapshots", `{"queries":[]}`, http.StatusBadRequest},
+		{"POST", "/api/snapshots", `queries`, http.StatusBadRequest},
+		{"GET", "/api/snapshots/missing", "", http.StatusNotFound},
+		{"DELETE", "/api/snapshots/missing", "", http.StatusNotFound},
+		{"DELETE", "/api/snapshots/" + created.ID, "", http.StatusOK},
+		{"GET", "/api/snapshots/" + created.ID, "", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s %s: expected status code %d, got %d: %s", tc.method, tc.path, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// tablesNamespace is the metadata namespace holding table annotations
+const tablesNamespace = "tables"
+
+// TableMetadata are console-side annotations of a table
+type TableMetadata struct {
+	// CreatedBy is the user who created the table through the console
+	CreatedBy string `json:"createdBy,omitempty"`
+	// CreatedAt is when the table was created, zero if unknown
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	// Purpose describes what the table is used for
+	Purpose string `json:"purpose,omitempty"`
+	// Backfilled is true when the annotations were added after the table was created
+	Backfilled bool `json:"backfilled,omitempty"`
+	// Protected tables can't be deleted, nor can keys be deleted from them by prefix
+	Protected bool `json:"protected,omitempty"`
+	// ReadOnly tables refuse writes through the console, e.g. during a migration,
+	// even if the cluster permits them
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// TableMetadataRequest represents the request for the table metadata API endpoint
+type TableMetadataRequest struct {
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	Purpose   string    `json:"purpose"`
+}
+
+// recordTableCreation stores the annotations of a table created through the console.
+// The table already exists at this point, so failures are only logged.
+func (h *Handler) recordTableCreation(r *http.Request, name, purpose string) {
+	meta := TableMetadata{
+		CreatedBy: auth.UserName(r.Context()),
+		CreatedAt: time.Now().UTC(),
+		Purpose:   purpose,
+	}
+	if err := metadata.Put(h.meta, tablesNamespace, name, meta); err != nil {
+		h.logger.Warn("Failed to store table metadata", zap.Error(err), zap.String("tableName", name))
+	}
+}
+
+// forgetTable removes the annotations of a deleted table
+func (h *Handler) forgetTable(name string) {
+	if err := h.meta.Delete(tablesNamespace, name); err != nil {
+		h.logger.Warn("Failed to delete table metadata", zap.Error(err), zap.String("tableName", name))
+	}
+}
+
+// tableMetadata returns the annotations of all tables. Failures are logged and
+// result in tables being listed without annotations.
+func (h *Handler) tableMetadata() map[string]TableMetadata {
+	meta, err := metadata.List[TableMetadata](h.meta, tablesNamespace)
+	if err != nil {
+		h.logger.Warn("Failed to load table metadata", zap.Error(err))
+		return nil
+	}
+	return meta
+}
+
+// handlePutTableMetadata updates the purpose of a table or backfills the annotations
+// of a table that was not created through the console. The creator and creation time
+// of tables created through the console can't be changed.
+// @Summary Update table metadata
+// @Tags tables
+// @Accept json
+// @Produce json
+// @Param name path string true "Table name"
+// @Param request body TableMetadataRequest true "Purpose, and creator and creation time of tables not created through the console"
+// @Success 200 {object} TableMetadata
+// @Failure 400 {string} string "Invalid request"
+// @Failure 404 {string} string "Table not found"
+// @Router /api/tables/{name}/metadata [put]
+func (h *Handler) handlePutTableMetadata(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	tableName := chi.URLParam(r, "name")
+	if tableName == "" {
+		http.Error(w, "Table name is required", http.StatusBadRequest)
+		return
+	}
+
+	var req TableMetadataRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tables, err := h.client.GetTables(r.Context())
+	if err != nil {
+		h.logger.Error("Failed to get tables from Armada server", zap.Error(err))
+		http.Error(w, "Failed to get tables", http.StatusInternalServerError)
+		return
+	}
+	if !containsTable(tables, tableName) {
+		http.Error(w, "Table not found: "+tableName, h.deleted.missingStatus(tableResourceID(tableName)))
+		return
+	}
+
+	meta, err := metadata.Get[TableMetadata](h.meta, tablesNamespace, tableName)
+	switch {
+	case errors.Is(err, metadata.ErrNotFound):
+		meta = TableMetadata{
+			CreatedBy:  req.CreatedBy,
+			CreatedAt:  req.CreatedAt.UTC(),
+			Backfilled: true,
+		}
+	case err != nil:
+		h.logger.Error("Failed to load table metadata", zap.Error(err), zap.String("tableName", tableName))
+		http.Error(w, "Failed to load table metadata", http.StatusInternalServerError)
+		return
+	case meta.Backfilled:
+		// Backfilled annotations are best effort and may be corrected
+		if req.CreatedBy != "" {
+			meta.CreatedBy = req.CreatedBy
+		}
+		if !req.CreatedAt.IsZero() {
+			meta.CreatedAt = req.CreatedAt.UTC()
+		}
+	}
+	meta.Purpose = req.Purpose
+
+	if err := metadata.Put(h.meta, tablesNamespace, tableName, meta); err != nil {
+		h.logger.Error("Failed to store table metadata", zap.Error(err), zap.String("tableName", tableName))
+		http.Error(w, "Failed to store table metadata", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("Updated table metadata",
+		zap.String("tableName", tableName),
+		zap.String("user", auth.UserName(r.Context())))
+	render.JSON(meta)
+}
+
+// checkUnprotected answers 409 Conflict and returns false if the table is protected from deletion.
+// If the protection flag can't be read the operation is refused as well.
+func (h *Handler) checkUnprotected(w http.ResponseWriter, tableName string) bool {
+	meta, err := metadata.Get[TableMetadata](h.meta, tablesNamespace, tableName)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		h.logger.Error("Failed to load table metadata", zap.Error(err), zap.String("tableName", tableName))
+		http.Error(w, "Failed to check table protection", http.StatusInternalServerError)
+		return false
+	}
+	if meta.Protected {
+		http.Error(w, "Table is protected: "+tableName+"; remove the protection before deleting", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// checkWritable answers 423 Locked and returns false if the table is read-only or its
+// writes are frozen by a maintenance window. If the flag can't be read the write is refused as well.
+func (h *Handler) checkWritable(w http.ResponseWriter, tableName string) bool {
+	meta, err := metadata.Get[TableMetadata](h.meta, tablesNamespace, tableName)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return h.checkNotFrozen(w, tableName)
+	}
+	if err != nil {
+		h.logger.Error("Failed to load table metadata", zap.Error(err), zap.String("tableName", tableName))
+		http.Error(w, "Failed to check table write access", http.StatusInternalServerError)
+		return false
+	}
+	if meta.ReadOnly {
+		http.Error(w, "Table is read-only: "+tableName+"; make it writable before changing it", http.StatusLocked)
+		return false
+	}
+	return h.checkNotFrozen(w, tableName)
+}
+
+// handleSetTableProtection returns a handler that sets or removes the deletion protection of a table
+// @Summary Protect table from deletion
+// @Description PUT protects the table from being deleted, also by prefix, DELETE removes the protection
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name"
+// @Success 200 {object} TableMetadata
+// @Failure 404 {string} string "Table not found"
+// @Router /api/tables/{name}/protection [put]
+// @Router /api/tables/{name}/protection [delete]
+func (h *Handler) handleSetTableProtection(protected bool) http.HandlerFunc {
+	return h.handleSetTableFlag(func(meta *TableMetadata) { meta.Protected = protected },
+		"Changed table deletion protection", zap.Bool("protected", protected))
+}
+
+// handleSetTableReadOnly returns a handler that makes a table read-only or writable again
+// @Summary Make table read-only
+// @Description PUT makes the console refuse writes to the table with 423 Locked, DELETE makes it writable again
+// @Tags tables
+// @Produce json
+// @Param name path string true "Table name"
+// @Success 200 {object} TableMetadata
+// @Failure 404 {string} string "Table not found"
+// @Router /api/tables/{name}/read-only [put]
+// @Router /api/tables/{name}/read-only [delete]
+func (h *Handler) handleSetTableReadOnly(readOnly bool) http.HandlerFunc {
+	return h.handleSetTableFlag(func(meta *TableMetadata) { meta.ReadOnly = readOnly },
+		"Changed table write access", zap.Bool("readOnly", readOnly))
+}
+
+// handleSetTableFlag returns a handler that applies set to the annotations of a table
+// and logs the change with msg and field
+func (h *Handler) handleSetTableFlag(set func(meta *TableMetadata), msg string, field zap.Field) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render := chix.NewRender(w)
+
+		tableName := chi.URLParam(r, "name")
+		if tableName == "" {
+			http.Error(w, "Table name is required", http.StatusBadRequest)
+			return
+		}
+
+		tables, err := h.client.GetTables(r.Context())
+		if err != nil {
+			h.logger.Error("Failed to get tables from Armada server", zap.Error(err))
+			http.Error(w, "Failed to get tables", http.StatusInternalServerError)
+			return
+		}
+		if !containsTable(tables, tableName) {
+			http.Error(w, "Table not found: "+tableName, h.deleted.missingStatus(tableResourceID(tableName)))
+			return
+		}
+
+		meta, err := metadata.Get[TableMetadata](h.meta, tablesNamespace, tableName)
+		if errors.Is(err, metadata.ErrNotFound) {
+			meta = TableMetadata{Backfilled: true}
+		} else if err != nil {
+			h.logger.Error("Failed to load table metadata", zap.Error(err), zap.String("tableName", tableName))
+			http.Error(w, "Failed to load table metadata", http.StatusInternalServerError)
+			return
+		}
+		set(&meta)
+
+		if err := metadata.Put(h.meta, tablesNamespace, tableName, meta); err != nil {
+			h.logger.Error("Failed to store table metadata", zap.Error(err), zap.String("tableName", tableName))
+			http.Error(w, "Failed to store table metadata", http.StatusInternalServerError)
+			return
+		}
+
+		h.logger.Info(msg,
+			zap.String("tableName", tableName),
+			field,
+			zap.String("user", auth.UserName(r.Context())))
+		render.JSON(meta)
+	}
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+)
+
+func TestTableMetadataLifecycle(t *testing.T) {
+	handler := createTestHandler()
+
+	// Create a table through the console as alice
+	req := httptest.NewRequest("POST", "/api/tables", strings.NewReader(`{"name":"table3","purpose":"session cache"}`))
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{Name: "alice"}))
+	rr := httptest.NewRecorder()
+	handler.handleCreateTable(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	meta, err := metadata.Get[TableMetadata](handler.meta, tablesNamespace, "table3")
+	if err != nil {
+		t.Fatalf("Expected metadata to be recorded: %v", err)
+	}
+	if meta.CreatedBy != "alice" || meta.Purpose != "session cache" || meta.CreatedAt.IsZero() || meta.Backfilled {
+		t.Errorf("Unexpected metadata: %+v", meta)
+	}
+
+	// Deleting the table forgets its metadata
+	rr = serveWithParams(handler.handleDeleteTable, httptest.NewRequest("DELETE", "/api/tables/table3", nil), map[string]string{"name": "table3"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if _, err := handler.meta.Get(tablesNamespace, "table3"); err == nil {
+		t.Errorf("Expected metadata to be removed with the table")
+	}
+}
+
+func TestCreateTableWithoutUser(t *testing.T) {
+	handler := createTestHandler()
+
+	rr := httptest.NewRecorder()
+	handler.handleCreateTable(rr, httptest.NewRequest("POST", "/api/tables", strings.NewReader(`{"name":"table3"}`)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, rr.Code)
+	}
+
+	meta, err := metadata.Get[TableMetadata](handler.meta, tablesNamespace, "table3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.CreatedBy != auth.Anonymous {
+		t.Errorf("Expected creator %q, got %q", auth.Anonymous, meta.CreatedBy)
+	}
+}
+
+func TestPutTableMetadata(t *testing.T) {
+	t.Run("Backfill", func(t *testing.T) {
+		handler := createTestHandler()
+
+		body := `{"createdBy":"bob","createdAt":"2024-01-02T03:04:05Z","purpose":"orders"}`
+		rr := serveWithParams(handler.handlePutTableMetadata, httptest.NewRequest("PUT", "/api/tables/table1/metadata", strings.NewReader(body)), map[string]string{"name": "table1"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var meta TableMetadata
+		if err := json.Unmarshal(rr.Body.Bytes(), &meta); err != nil {
+			t.Fatal(err)
+		}
+		if !meta.Backfilled || meta.CreatedBy != "bob" || meta.CreatedAt.Year() != 2024 || meta.Purpose != "orders" {
+			t.Errorf("Unexpected metadata: %+v", meta)
+		}
+
+		// The annotations are returned with the table listing
+		rr = httptest.NewRecorder()
+		handler.handleTables(rr, httptest.NewRequest("GET", "/api/tables", nil))
+		var items []TableListItem
+		if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		if items[0].Name != "table1" || items[0].Metadata == nil || items[0].Metadata.Purpose != "orders" {
+			t.Errorf("Expected table1 to be listed with its metadata, got %+v", items[0])
+		}
+		if items[1].Metadata != nil {
+			t.Errorf("Expected table2 to be listed without metadata, got %+v", items[1].Metadata)
+		}
+	})
+
+	t.Run("CreatorIsImmutable", func(t *testing.T) {
+		handler := createTestHandler()
+		if err := metadata.Put(handler.meta, tablesNamespace, "table1", TableMetadata{CreatedBy: "alice", Purpose: "old"}); err != nil {
+			t.Fatal(err)
+		}
+
+		rr := serveWithParams(handler.handlePutTableMetadata, httptest.NewRequest("PUT", "/api/tables/table1/metadata", strings.NewReader(`{"createdBy":"mallory","purpose":"new"}`)), map[string]string{"name": "table1"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		meta, err := metadata.Get[TableMetadata](handler.meta, tablesNamespace, "table1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.CreatedBy != "alice" || meta.Purpose != "new" {
+			t.Errorf("Unexpected metadata: %+v", meta)
+		}
+	})
+
+	t.Run("MissingTable", func(t *testing.T) {
+		handler := createTestHandler()
+		rr := serveWithParams(handler.handlePutTableMetadata, httptest.NewRequest("PUT", "/api/tables/missing/metadata", strings.NewReader(`{}`)), map[string]string{"name": "missing"})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status code %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+}
+
+func TestTableProtection(t *testing.T) {
+	handler := createTestHandler()
+	params := map[string]string{"name": "table1", "table": "table1"}
+
+	rr := serveWithParams(handler.handleSetTableProtection(true), httptest.NewRequest("PUT", "/api/tables/table1/protection", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	// Neither the table nor keys by prefix can be deleted while it is protected
+	rr = serveWithParams(handler.handleDeleteTable, httptest.NewRequest("DELETE", "/api/tables/table1", nil), params)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected deleting a protected table to return %d, got %d", http.StatusConflict, rr.Code)
+	}
+	rr = serveWithParams(handler.handleDeleteKey, httptest.NewRequest("DELETE", "/api/kv/table1?prefix=user/", nil), params)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Expected deleting by prefix from a protected table to return %d, got %d", http.StatusConflict, rr.Code)
+	}
+
+	// Updating the purpose keeps the protection
+	rr = serveWithParams(handler.handlePutTableMetadata, httptest.NewRequest("PUT", "/api/tables/table1/metadata", strings.NewReader(`{"purpose":"orders"}`)), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	meta, err := metadata.Get[TableMetadata](handler.meta, tablesNamespace, "table1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !meta.Protected || meta.Purpose != "orders" {
+		t.Errorf("Unexpected metadata: %+v", meta)
+	}
+
+	// Once the protection is removed, deletion works again
+	rr = serveWithParams(handler.handleSetTableProtection(false), httptest.NewRequest("DELETE", "/api/tables/table1/protection", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	rr = serveWithParams(handler.handleDeleteKey, httptest.NewRequest("DELETE", "/api/kv/table1?prefix=user/", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected deleting by prefix to return %d, got %d", http.StatusOK, rr.Code)
+	}
+	var resp DeletePrefixResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || resp.Deleted != 2 {
+		t.Errorf("Expected 2 deleted keys, got %s", rr.Body.String())
+	}
+	rr = serveWithParams(handler.handleDeleteTable, httptest.NewRequest("DELETE", "/api/tables/table1", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected deleting the table to return %d, got %d", http.StatusOK, rr.Code)
+	}
+}
+
+func TestTableReadOnly(t *testing.T) {
+	handler := createTestHandler()
+	params := map[string]string{"name": "table1", "table": "table1"}
+
+	rr := serveWithParams(handler.handleSetTableReadOnly(true), httptest.NewRequest("PUT", "/api/tables/table1/read-only", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	// Keys can be read but not changed
+	rr = serveWithParams(handler.handleGetSpecificKeyValue, httptest.NewRequest("GET", "/api/kv/table1/key/key1", nil), map[string]string{"table": "table1", "key": "key1"})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected reading from a read-only table to return %d, got %d", http.StatusOK, rr.Code)
+	}
+	rr = serveWithParams(handler.handlePutKeyValue, httptest.NewRequest("PUT", "/api/kv/table1", strings.NewReader(`{"key":"key1","value":"v"}`)), params)
+	if rr.Code != http.StatusLocked || !strings.Contains(rr.Body.String(), "read-only") {
+		t.Errorf("Expected writing to a read-only table to return %d, got %d: %s", http.StatusLocked, rr.Code, rr.Body.String())
+	}
+	rr = serveWithParams(handler.handleDeleteKey, httptest.NewRequest("DELETE", "/api/kv/table1?key=key1", nil), params)
+	if rr.Code != http.StatusLocked {
+		t.Errorf("Expected deleting from a read-only table to return %d, got %d", http.StatusLocked, rr.Code)
+	}
+	rr = serveWithParams(handler.handleDeleteTable, httptest.NewRequest("DELETE", "/api/tables/table1", nil), params)
+	if rr.Code != http.StatusLocked {
+		t.Errorf("Expected deleting a read-only table to return %d, got %d", http.StatusLocked, rr.Code)
+	}
+
+	// Other tables stay writable
+	rr = serveWithParams(handler.handlePutKeyValue, httptest.NewRequest("PUT", "/api/kv/table2", strings.NewReader(`{"key":"key1","value":"v"}`)), map[string]string{"table": "table2"})
+	if rr.Code == http.StatusLocked {
+		t.Errorf("Expected writing to another table to succeed, got %d", rr.Code)
+	}
+
+	rr = serveWithParams(handler.handleSetTableReadOnly(false), httptest.NewRequest("DELETE", "/api/tables/table1/read-only", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	rr = serveWithParams(handler.handlePutKeyValue, httptest.NewRequest("PUT", "/api/kv/table1", strings.NewReader(`{"key":"key1","value":"v"}`)), params)
+	if rr.Code == http.StatusLocked {
+		t.Errorf("Expected writing to a writable table to succeed, got %d", rr.Code)
+	}
+}
+
+func TestDeleteKeyParameters(t *testing.T) {
+	handler := createTestHandler()
+	params := map[string]string{"table": "table1"}
+
+	tests := map[string]int{
+		"/api/kv/table1":                    http.StatusBadRequest,
+		"/api/kv/table1?key=a&prefix=user/": http.StatusBadRequest,
+	}
+	for target, want := range tests {
+		rr := serveWithParams(handler.handleDeleteKey, httptest.NewRequest("DELETE", target, nil), params)
+		if rr.Code != want {
+			t.Errorf("DELETE %s: expected status code %d, got %d", target, want, rr.Code)
+		}
+	}
+}
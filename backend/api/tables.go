@@ -0,0 +1,183 @@
+package api
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/stats"
+)
+
+// maxTablesPageSize bounds the page size of the tables endpoint
+const maxTablesPageSize = 1000
+
+// TableStatsSource provides cached table statistics used to sort tables by size.
+// The stats.Sampler implements this interface.
+type TableStatsSource interface {
+	// Table returns the latest statistics of a table, if it has been sampled.
+	Table(name string) (stats.TableStats, bool)
+}
+
+// TableListItem is a table in the response of the tables API endpoint
+type TableListItem struct {
+	armada.Table
+	// Stats are the latest sampled statistics, if available
+	Stats *stats.TableStats `json:"stats,omitempty"`
+	// Metadata are the console-side annotations, if any
+	Metadata *TableMetadata `json:"metadata,omitempty"`
+	// ReadOnly is set while the console refuses writes, because the table is marked
+	// read-only or its writes are frozen by a maintenance window
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// MaintenanceWindow is the ID of the window freezing writes, if any
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty"`
+}
+
+// newTableListItem combines a table with its sampled statistics and annotations
+func newTableListItem(table armada.Table, source TableStatsSource, meta map[string]TableMetadata) TableListItem {
+	item := TableListItem{Table: table}
+	if source != nil {
+		if ts, ok := source.Table(table.Name); ok {
+			item.Stats = &ts
+		}
+	}
+	if m, ok := meta[table.Name]; ok {
+		item.Metadata = &m
+	}
+	return item
+}
+
+// tablesQuery holds the filtering, sorting and pagination parameters of the tables endpoint
+type tablesQuery struct {
+	// filter is a case-insensitive substring the table name must contain
+	filter string
+	// sort is the sort key, "name" or "size"
+	sort string
+	// desc reverses the sort order
+	desc bool
+	// limit is the page size, 0 means all tables
+	limit int
+	// offset is the number of tables to skip
+	offset int
+}
+
+// parseTablesQuery reads the tables query parameters
+func parseTablesQuery(q url.Values) (tablesQuery, error) {
+	query := tablesQuery{
+		filter: strings.ToLower(q.Get("filter")),
+		sort:   cmp.Or(q.Get("sort"), "name"),
+	}
+
+	if query.sort != "name" && query.sort != "size" {
+		return query, fmt.Errorf("sort must be name or size, got %q", query.sort)
+	}
+
+	switch order := q.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		query.desc = true
+	default:
+		return query, fmt.Errorf("order must be asc or desc, got %q", order)
+	}
+
+	var err error
+	if raw := q.Get("limit"); raw != "" {
+		query.limit, err = strconv.Atoi(raw)
+		if err != nil || query.limit < 1 || query.limit > maxTablesPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxTablesPageSize)
+		}
+	}
+	if raw := q.Get("offset"); raw != "" {
+		query.offset, err = strconv.Atoi(raw)
+		if err != nil || query.offset < 0 {
+			return query, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+
+	return query, nil
+}
+
+// apply filters, sorts and paginates tables. It returns the page and the number of matching tables.
+// Tables without sampled statistics sort after all others when sorting by size.
+func (q tablesQuery) apply(tables []armada.Table, source TableStatsSource, meta map[string]TableMetadata) ([]TableListItem, int) {
+	items := make([]TableListItem, 0, len(tables))
+	for _, table := range tables {
+		if q.filter != "" && !strings.Contains(strings.ToLower(table.Name), q.filter) {
+			continue
+		}
+		items = append(items, newTableListItem(table, source, meta))
+	}
+
+	slices.SortStableFunc(items, func(a, b TableListItem) int {
+		if q.sort == "size" {
+			switch {
+			case a.Stats == nil && b.Stats == nil:
+			case a.Stats == nil:
+				return 1
+			case b.Stats == nil:
+				return -1
+			default:
+				if c := q.direction(cmp.Compare(a.Stats.DBSize, b.Stats.DBSize)); c != 0 {
+					return c
+				}
+			}
+		}
+		return q.direction(cmp.Compare(a.Name, b.Name))
+	})
+
+	total := len(items)
+	start := min(q.offset, total)
+	end := total
+	if q.limit > 0 {
+		end = min(start+q.limit, total)
+	}
+	return items[start:end], total
+}
+
+// containsTable reports whether a table with the given name exists
+func containsTable(tables []armada.Table, name string) bool {
+	return slices.ContainsFunc(tables, func(t armada.Table) bool {
+		return t.Name == name
+	})
+}
+
+// direction applies the sort order to a comparison result
+func (q tablesQuery) direction(c int) int {
+	if q.desc {
+		return -c
+	}
+	return c
+}
+
+// setPaginationHeaders sets X-Total-Count and a Link header with the next and previous pages
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, q tablesQuery, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if q.limit == 0 {
+		return
+	}
+
+	page := func(offset int, rel string) string {
+		u := *r.URL
+		values := u.Query()
+		values.Set("offset", strconv.Itoa(offset))
+		values.Set("limit", strconv.Itoa(q.limit))
+		u.RawQuery = values.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", basepath.Path(r.Context(), u.RequestURI()), rel)
+	}
+
+	var links []string
+	if q.offset+q.limit < total {
+		links = append(links, page(q.offset+q.limit, "next"))
+	}
+	if q.offset > 0 {
+		links = append(links, page(max(q.offset-q.limit, 0), "prev"))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/stats"
+)
+
+// staticTableStats serves fixed table sizes
+type staticTableStats map[string]int64
+
+func (s staticTableStats) Table(name string) (stats.TableStats, bool) {
+	size, ok := s[name]
+	return stats.TableStats{DBSize: size}, ok
+}
+
+func tableNames(items []TableListItem) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestTablesQuery(t *testing.T) {
+	tables := []armada.Table{
+		{Name: "orders"}, {Name: "users"}, {Name: "Audit"}, {Name: "user_sessions"}, {Name: "events"},
+	}
+	sizes := staticTableStats{"orders": 300, "users": 100, "user_sessions": 200, "Audit": 100}
+
+	tests := []struct {
+		name      string
+		query     string
+		want      []string
+		wantTotal int
+	}{
+		{name: "DefaultSortsByName", query: "", want: []string{"Audit", "events", "orders", "user_sessions", "users"}, wantTotal: 5},
+		{name: "Descending", query: "order=desc", want: []string{"users", "user_sessions", "orders", "events", "Audit"}, wantTotal: 5},
+		{name: "Filter", query: "filter=USER", want: []string{"user_sessions", "users"}, wantTotal: 2},
+		{name: "SizeUnsampledLast", query: "sort=size", want: []string{"Audit", "users", "user_sessions", "orders", "events"}, wantTotal: 5},
+		{name: "SizeDescending", query: "sort=size&order=desc", want: []string{"orders", "user_sessions", "users", "Audit", "events"}, wantTotal: 5},
+		{name: "FirstPage", query: "limit=2", want: []string{"Audit", "events"}, wantTotal: 5},
+		{name: "LastPage", query: "limit=2&offset=4", want: []string{"users"}, wantTotal: 5},
+		{name: "PastEnd", query: "limit=2&offset=10", want: []string{}, wantTotal: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := parseTablesQuery(values)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			page, total := q.apply(tables, sizes, nil)
+			if total != tt.wantTotal {
+				t.Errorf("Expected total %d, got %d", tt.wantTotal, total)
+			}
+			got := tableNames(page)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTablesQueryErrors(t *testing.T) {
+	for _, query := range []string{"sort=id", "order=up", "limit=0", "limit=5000", "limit=x", "offset=-1"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := parseTablesQuery(values); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
+
+func TestHandleTablesPagination(t *testing.T) {
+	handler := createTestHandler()
+	handler.tableStats = staticTableStats{"table2": 42}
+
+	rr := httptest.NewRecorder()
+	handler.handleTables(rr, httptest.NewRequest("GET", "/api/tables?limit=1&sort=size", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	if total := rr.Header().Get("X-Total-Count"); total != "2" {
+		t.Errorf("Expected X-Total-Count 2, got %q", total)
+	}
+	if link := rr.Header().Get("Link"); link != `</api/tables?limit=1&offset=1&sort=size>; rel="next"` {
+		t.Errorf("Unexpected Link header: %q", link)
+	}
+
+	var items []TableListItem
+	if err := json.Unmarshal(rr.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Failed to parse response body: %v", err)
+	}
+	if len(items) != 1 || items[0].Name != "table2" || items[0].Stats == nil || items[0].Stats.DBSize != 42 {
+		t.Errorf("Unexpected tables page: %+v", items)
+	}
+}
+
+func TestHandleTablesInvalidQuery(t *testing.T) {
+	handler := createTestHandler()
+
+	rr := httptest.NewRecorder()
+	handler.handleTables(rr, httptest.NewRequest("GET", "/api/tables?sort=id", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LongRunning returns a middleware that replaces the server's read and write deadlines
+// for requests whose path starts with one of the given prefixes. Streaming endpoints such as
+// range queries and exports use it to outlive the short timeouts that protect every other route.
+func LongRunning(timeout time.Duration, logger *zap.Logger, prefixes ...string) func(http.Handler) http.Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matchesPrefix(r.URL.Path, prefixes) {
+				deadline := time.Now().Add(timeout)
+				rc := http.NewResponseController(w)
+				if err := rc.SetReadDeadline(deadline); err != nil {
+					logger.Debug("Failed to extend read deadline", zap.String("path", r.URL.Path), zap.Error(err))
+				}
+				if err := rc.SetWriteDeadline(deadline); err != nil {
+					logger.Debug("Failed to extend write deadline", zap.String("path", r.URL.Path), zap.Error(err))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RouteTimeouts bound the requests of each route group, so a slow cluster fails them instead
+// of leaving clients waiting until the server's write timeout. A zero timeout leaves the
+// requests of a group bounded by the server timeouts only.
+type RouteTimeouts struct {
+	// Status bounds the status, cluster and servers endpoints, which are polled and should fail fast.
+	Status time.Duration
+	// Tables bounds listing, creating and deleting tables.
+	Tables time.Duration
+	// KV bounds reading, writing and deleting single keys. Key range scans have their own budget.
+	KV time.Duration
+}
+
+// RequestTimeout returns a middleware cancelling the context of requests after the timeout,
+// which aborts the calls to the cluster made for them. A zero timeout leaves requests unchanged.
+func RequestTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// matchesPrefix reports whether path equals one of the prefixes or is nested below it
+func matchesPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLongRunning(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	server := httptest.NewUnstartedServer(LongRunning(5*time.Second, nil, "/api/stream")(slow))
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	t.Run("StreamingRoute", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/stream/export")
+		if err != nil {
+			t.Fatalf("Expected streaming request to outlive the write timeout, got %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		if string(body) != "done" {
+			t.Errorf("Expected body %q, got %q", "done", string(body))
+		}
+	})
+
+	t.Run("RegularRoute", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/streaming")
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("Expected regular request to hit the write timeout")
+		}
+	})
+}
+
+func TestMatchesPrefix(t *testing.T) {
+	prefixes := []string{"/api/metrics/query_range", "/api/export/"}
+	tests := map[string]bool{
+		"/api/metrics/query_range":     true,
+		"/api/metrics/query_range/foo": true,
+		"/api/metrics/query":           false,
+		"/api/metrics/query_ranges":    false,
+		"/api/export":                  true,
+		"/api/export/table1":           true,
+	}
+	for path, want := range tests {
+		if got := matchesPrefix(path, prefixes); got != want {
+			t.Errorf("matchesPrefix(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	var deadline time.Time
+	var bounded bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, bounded = r.Context().Deadline()
+	})
+
+	before := time.Now()
+	RequestTimeout(3*time.Second)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/status", nil))
+	if !bounded || deadline.Before(before.Add(3*time.Second)) || deadline.After(time.Now().Add(3*time.Second)) {
+		t.Errorf("Expected the request to be bounded by 3s, got deadline %v (bounded %v)", deadline, bounded)
+	}
+
+	RequestTimeout(0)(handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/status", nil))
+	if bounded {
+		t.Error("Expected a zero timeout to leave the request unbounded")
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/transform"
+)
+
+// preserveTransforms re-applies the encodings of the currently stored value on write
+const preserveTransforms = "preserve"
+
+// DecodedKeyValuePair is a key-value pair whose value was decoded for display
+type DecodedKeyValuePair struct {
+	armada.KeyValuePair
+	// Transforms lists the encodings reversed to get the value, outermost first.
+	// Pass them as transform when writing the value back to keep the stored encoding.
+	Transforms []string `json:"transforms,omitempty"`
+	// Compressed names the compression of the stored value, even if it couldn't be decompressed
+	Compressed string `json:"compressed,omitempty"`
+	// DecodeError explains why a detected compression couldn't be reversed
+	DecodeError string `json:"decodeError,omitempty"`
+	// Encrypted names the encryption scheme of values the console can't decode
+	Encrypted string `json:"encrypted,omitempty"`
+}
+
+// decoder returns the pipeline selected by the decode query parameter, or nil if
+// values should be returned as stored. It returns an error for unsupported decode modes.
+func (h *Handler) decoder(r *http.Request) (*transform.Pipeline, error) {
+	switch mode := r.URL.Query().Get("decode"); mode {
+	case "", "none":
+		return nil, nil
+	case "auto":
+		return h.transforms, nil
+	case "decompress":
+		return h.transforms.Only(transform.Compressions...), nil
+	default:
+		return nil, fmt.Errorf("unsupported decode mode %q, use auto, decompress or none", mode)
+	}
+}
+
+// decodePair detects and reverses the encodings of a stored value
+func decodePair(decoder *transform.Pipeline, pair armada.KeyValuePair) DecodedKeyValuePair {
+	result := decoder.Decode([]byte(pair.Value))
+	decoded := DecodedKeyValuePair{
+		KeyValuePair: armada.KeyValuePair{Key: pair.Key, Value: string(result.Value)},
+		Transforms:   result.Applied,
+		Compressed:   result.Compression,
+		Encrypted:    result.Envelope,
+	}
+	if result.Err != nil {
+		decoded.DecodeError = result.Err.Error()
+	}
+	return decoded
+}
+
+// decodePairs decodes every value of a list of pairs
+func decodePairs(decoder *transform.Pipeline, pairs []armada.KeyValuePair) []DecodedKeyValuePair {
+	decoded := make([]DecodedKeyValuePair, 0, len(pairs))
+	for _, pair := range pairs {
+		decoded = append(decoded, decodePair(decoder, pair))
+	}
+	return decoded
+}
+
+// encodeValue applies the transformers selected by the transform query parameter to a value before it is stored.
+// With transform=preserve the encodings of the currently stored value are applied again,
+// e.g. to re-compress a value that was edited in decompressed form.
+func (h *Handler) encodeValue(r *http.Request, table, key, value string) (string, error) {
+	names := transform.ParseNames(r.URL.Query().Get("transform"))
+	if slices.Equal(names, []string{preserveTransforms}) {
+		current, err := h.client.GetKeyValue(r.Context(), table, key)
+		switch {
+		case errors.Is(err, armada.ErrKeyNotFound):
+			names = nil
+		case err != nil:
+			return "", fmt.Errorf("failed to get current value: %w", err)
+		default:
+			names = h.transforms.Decode([]byte(current.Value)).Applied
+		}
+	}
+	if len(names) == 0 {
+		return value, nil
+	}
+	encoded, err := h.transforms.Encode([]byte(value), names)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+func TestGetKeyValueDecoded(t *testing.T) {
+	handler := createTestHandler()
+	encoded, err := handler.transforms.Encode([]byte(`{"cart":[1,2]}`), []string{"base64", "gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := armada.KeyValuePair{Key: "cart", Value: string(encoded)}
+	handler.client.(*mockArmadaClient).singleKvPair = &stored
+	handler.client.(*mockArmadaClient).kvPairs = []armada.KeyValuePair{stored, {Key: "plain", Value: "value"}}
+	params := map[string]string{"table": "table1", "key": "cart"}
+
+	rr := serveWithParams(handler.handleGetSpecificKeyValue, httptest.NewRequest("GET", "/api/kv/table1/cart?decode=auto", nil), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var pair DecodedKeyValuePair
+	if err := json.NewDecoder(rr.Body).Decode(&pair); err != nil {
+		t.Fatal(err)
+	}
+	if pair.Value != `{"cart":[1,2]}` || strings.Join(pair.Transforms, ",") != "base64,gzip" {
+		t.Errorf("Unexpected decoded pair: %+v", pair)
+	}
+	// The entity tag still refers to the stored value
+	if rr.Header().Get("ETag") != valueETag(stored.Value) {
+		t.Errorf("Expected ETag of the stored value, got %q", rr.Header().Get("ETag"))
+	}
+
+	// Without decoding the stored value is returned as is
+	rr = serveWithParams(handler.handleGetSpecificKeyValue, httptest.NewRequest("GET", "/api/kv/table1/cart", nil), params)
+	if strings.Contains(rr.Body.String(), "transforms") || !strings.Contains(rr.Body.String(), `"value":"`+stored.Value+`"`) {
+		t.Errorf("Expected the raw value, got %s", rr.Body.String())
+	}
+
+	rr = serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?decode=auto", nil), params)
+	var pairs []DecodedKeyValuePair
+	if err := json.NewDecoder(rr.Body).Decode(&pairs); err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || pairs[0].Value != `{"cart":[1,2]}` || pairs[1].Value != "value" || pairs[1].Transforms != nil {
+		t.Errorf("Unexpected decoded pairs: %+v", pairs)
+	}
+
+	rr = serveWithParams(handler.handleGetKeyValue, httptest.NewRequest("GET", "/api/kv/table1?decode=magic", nil), params)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestPutKeyValueTransform(t *testing.T) {
+	handler := createTestHandler()
+	params := map[string]string{"table": "table1"}
+
+	rr := serveWithParams(handler.handlePutKeyValue,
+		httptest.NewRequest("PUT", "/api/kv/table1?transform=base64", strings.NewReader(`{"key":"new","value":"hello"}`)), params)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status code %d, got %d", http.StatusCreated, rr.Code)
+	}
+	// The ETag refers to the encoded value that was stored
+	if rr.Header().Get("ETag") != valueETag("aGVsbG8=") {
+		t.Errorf("Expected ETag of the encoded value, got %q", rr.Header().Get("ETag"))
+	}
+
+	rr = serveWithParams(handler.handlePutKeyValue,
+		httptest.NewRequest("PUT", "/api/kv/table1?transform=rot13", strings.NewReader(`{"key":"new","value":"hello"}`)), params)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
+func TestPutKeyValuePreservesCompression(t *testing.T) {
+	handler := createTestHandler()
+	compressed, err := handler.transforms.Encode([]byte("old"), []string{"gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.client.(*mockArmadaClient).singleKvPair = &armada.KeyValuePair{Key: "cart", Value: string(compressed)}
+	params := map[string]string{"table": "table1", "key": "cart"}
+
+	// Reading decompressed flags the stored value as compressed
+	rr := serveWithParams(handler.handleGetSpecificKeyValue, httptest.NewRequest("GET", "/api/kv/table1/cart?decode=decompress", nil), params)
+	var pair DecodedKeyValuePair
+	if err := json.NewDecoder(rr.Body).Decode(&pair); err != nil {
+		t.Fatal(err)
+	}
+	if pair.Value != "old" || pair.Compressed != "gzip" {
+		t.Errorf("Unexpected decoded pair: %+v", pair)
+	}
+
+	// Saving with transform=preserve compresses the new value again
+	rr = serveWithParams(handler.handlePutKeyValue,
+		httptest.NewRequest("PUT", "/api/kv/table1?transform=preserve", strings.NewReader(`{"key":"cart","value":"new"}`)), params)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	recompressed, err := handler.transforms.Encode([]byte("new"), []string{"gzip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("ETag") != valueETag(string(recompressed)) {
+		t.Errorf("Expected the value to be stored compressed")
+	}
+}
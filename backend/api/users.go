@@ -0,0 +1,415 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/armadakv/console/backend/accounts"
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// CreateUserRequest represents the request body for creating a local user
+type CreateUserRequest struct {
+	Name     string   `json:"name"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+// UpdateUserRequest represents the request body for changing the roles of a user or disabling them
+type UpdateUserRequest struct {
+	Roles    []string `json:"roles"`
+	Disabled bool     `json:"disabled"`
+}
+
+// PasswordRequest represents the request body for resetting the password of a user
+type PasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// WithUserDirectory lets operators manage the local users and their roles under
+// /api/admin/users and /api/admin/roles. Every change is recorded in the audit log.
+func WithUserDirectory(directory *accounts.Directory, auditLog audit.Log) AdminOption {
+	return func(h *AdminHandler) {
+		h.directory = directory
+		h.auditLog = auditLog
+	}
+}
+
+// WithUserSessions ends the sessions of local users when their password is reset, they are
+// disabled or deleted
+func WithUserSessions(sessions *auth.SessionStore) AdminOption {
+	return func(h *AdminHandler) {
+		h.sessions = sessions
+	}
+}
+
+// registerUserRoutes registers the user and role management routes on the admin router
+func (h *AdminHandler) registerUserRoutes(r chi.Router) {
+	r.Get("/users", h.handleListUsers)
+	r.Post("/users", h.handleCreateUser)
+	r.Get("/users/{name}", h.handleGetUser)
+	r.Put("/users/{name}", h.handleUpdateUser)
+	r.Put("/users/{name}/password", h.handleSetPassword)
+	r.Delete("/users/{name}", h.handleDeleteUser)
+	r.Get("/roles", h.handleListRoles)
+	r.Post("/roles", h.handleCreateRole)
+	r.Get("/roles/{name}", h.handleGetRole)
+	r.Put("/roles/{name}", h.handleUpdateRole)
+	r.Delete("/roles/{name}", h.handleDeleteRole)
+}
+
+// handleListUsers returns the local users
+// @Summary List users
+// @Description List the users managed in the console. Password hashes are never returned.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} accounts.User
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/users [get]
+func (h *AdminHandler) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.directory.Users()
+	if err != nil {
+		h.accountError(w, err, "Failed to list users")
+		return
+	}
+	chix.NewRender(w).JSON(users)
+}
+
+// handleCreateUser creates a local user
+// @Summary Create user
+// @Description Create a user who logs in with basic authentication. The password is stored as a bcrypt hash.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body CreateUserRequest true "User"
+// @Success 201 {object} accounts.User
+// @Header 201 {string} Location "Path of the new user"
+// @Failure 400 {string} string "Invalid user"
+// @Failure 409 {string} string "User already exists"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/users [post]
+func (h *AdminHandler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := h.directory.CreateUser(accounts.User{
+		Name:      req.Name,
+		Roles:     req.Roles,
+		CreatedBy: auth.UserName(r.Context()),
+	}, req.Password)
+	h.recordAudit(r, "user.create", "users/"+req.Name, err, map[string]string{"roles": strings.Join(req.Roles, ",")})
+	if err != nil {
+		h.accountError(w, err, "Failed to create user")
+		return
+	}
+	h.logger.Info("Created user", zap.String("name", user.Name), zap.Strings("roles", user.Roles),
+		zap.String("user", user.CreatedBy))
+
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/admin/users/"+user.Name)))
+	render.Status(http.StatusCreated)
+	render.JSON(user)
+}
+
+// handleGetUser returns a single local user
+// @Summary Get user
+// @Tags admin
+// @Produce json
+// @Param name path string true "User name"
+// @Success 200 {object} accounts.User
+// @Failure 404 {string} string "User not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/users/{name} [get]
+func (h *AdminHandler) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.directory.User(chi.URLParam(r, "name"))
+	if err != nil {
+		h.accountError(w, err, "Failed to get user")
+		return
+	}
+	chix.NewRender(w).JSON(user)
+}
+
+// handleUpdateUser replaces the roles of a user and enables or disables them
+// @Summary Update user
+// @Description Assign roles to a user and disable or enable their account. Disabled users can't log in and their sessions are ended.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "User name"
+// @Param request body UpdateUserRequest true "Roles and state"
+// @Success 200 {object} accounts.User
+// @Failure 400 {string} string "Invalid roles"
+// @Failure 404 {string} string "User not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/users/{name} [put]
+func (h *AdminHandler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := h.directory.UpdateUser(name, req.Roles, req.Disabled)
+	if err == nil && req.Disabled {
+		err = h.endSessions(name)
+	}
+	details := map[string]string{"roles": strings.Join(req.Roles, ","), "disabled": strconv.FormatBool(req.Disabled)}
+	h.recordAudit(r, "user.update", "users/"+name, err, details)
+	if err != nil {
+		h.accountError(w, err, "Failed to update user")
+		return
+	}
+	h.logger.Info("Updated user", zap.String("name", name), zap.Strings("roles", user.Roles),
+		zap.Bool("disabled", user.Disabled), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(user)
+}
+
+// handleSetPassword resets the password of a user
+// @Summary Reset password
+// @Description Replace the password of a user, it is stored as a bcrypt hash. The sessions of the user are ended.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "User name"
+// @Param request body PasswordRequest true "New password"
+// @Success 200 {object} map[string]any
+// @Failure 400 {string} string "Invalid password"
+// @Failure 404 {string} string "User not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/users/{name}/password [put]
+func (h *AdminHandler) handleSetPassword(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	var req PasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	err := h.directory.SetPassword(name, req.Password)
+	if err == nil {
+		err = h.endSessions(name)
+	}
+	h.recordAudit(r, "user.password", "users/"+name, err, nil)
+	if err != nil {
+		h.accountError(w, err, "Failed to reset password")
+		return
+	}
+	h.logger.Info("Reset password", zap.String("name", name), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// handleDeleteUser removes a local user
+// @Summary Delete user
+// @Description Remove a user and end their sessions
+// @Tags admin
+// @Produce json
+// @Param name path string true "User name"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "User not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/users/{name} [delete]
+func (h *AdminHandler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	err := h.directory.DeleteUser(name)
+	if err == nil {
+		err = h.endSessions(name)
+	}
+	h.recordAudit(r, "user.delete", "users/"+name, err, nil)
+	if err != nil {
+		h.accountError(w, err, "Failed to delete user")
+		return
+	}
+	h.logger.Info("Deleted user", zap.String("name", name), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// handleListRoles returns the roles users can be assigned
+// @Summary List roles
+// @Description List the built-in viewer and operator roles and the roles created in the console
+// @Tags admin
+// @Produce json
+// @Success 200 {array} accounts.Role
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/roles [get]
+func (h *AdminHandler) handleListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.directory.Roles()
+	if err != nil {
+		h.accountError(w, err, "Failed to list roles")
+		return
+	}
+	chix.NewRender(w).JSON(roles)
+}
+
+// handleCreateRole creates a role
+// @Summary Create role
+// @Description Create a role granting the viewer or operator access level
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body accounts.Role true "Role"
+// @Success 201 {object} accounts.Role
+// @Header 201 {string} Location "Path of the new role"
+// @Failure 400 {string} string "Invalid role"
+// @Failure 409 {string} string "Role already exists"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/roles [post]
+func (h *AdminHandler) handleCreateRole(w http.ResponseWriter, r *http.Request) {
+	render := chix.NewRender(w)
+
+	var req accounts.Role
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	role, err := h.directory.CreateRole(req)
+	h.recordAudit(r, "role.create", "roles/"+req.Name, err, map[string]string{"grants": string(req.Grants)})
+	if err != nil {
+		h.accountError(w, err, "Failed to create role")
+		return
+	}
+	h.logger.Info("Created role", zap.String("name", role.Name), zap.String("grants", string(role.Grants)),
+		zap.String("user", auth.UserName(r.Context())))
+
+	render.Header("Location", basepath.Path(r.Context(), apiversion.Path(r.Context(), "/api/admin/roles/"+role.Name)))
+	render.Status(http.StatusCreated)
+	render.JSON(role)
+}
+
+// handleGetRole returns a single role
+// @Summary Get role
+// @Tags admin
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} accounts.Role
+// @Failure 404 {string} string "Role not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/roles/{name} [get]
+func (h *AdminHandler) handleGetRole(w http.ResponseWriter, r *http.Request) {
+	role, err := h.directory.Role(chi.URLParam(r, "name"))
+	if err != nil {
+		h.accountError(w, err, "Failed to get role")
+		return
+	}
+	chix.NewRender(w).JSON(role)
+}
+
+// handleUpdateRole changes the description and the access level of a role
+// @Summary Update role
+// @Description Change the description and the access level of a role, built-in roles can't be changed
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param request body accounts.Role true "Role"
+// @Success 200 {object} accounts.Role
+// @Failure 400 {string} string "Invalid role"
+// @Failure 404 {string} string "Role not found"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/roles/{name} [put]
+func (h *AdminHandler) handleUpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req accounts.Role
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = chi.URLParam(r, "name")
+	role, err := h.directory.UpdateRole(req)
+	h.recordAudit(r, "role.update", "roles/"+req.Name, err, map[string]string{"grants": string(req.Grants)})
+	if err != nil {
+		h.accountError(w, err, "Failed to update role")
+		return
+	}
+	h.logger.Info("Updated role", zap.String("name", role.Name), zap.String("grants", string(role.Grants)),
+		zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(role)
+}
+
+// handleDeleteRole removes a role that is not assigned to any user
+// @Summary Delete role
+// @Tags admin
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]any
+// @Failure 400 {string} string "Built-in roles can't be deleted"
+// @Failure 404 {string} string "Role not found"
+// @Failure 409 {string} string "Role is assigned to users"
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/admin/roles/{name} [delete]
+func (h *AdminHandler) handleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	err := h.directory.DeleteRole(name)
+	h.recordAudit(r, "role.delete", "roles/"+name, err, nil)
+	if err != nil {
+		h.accountError(w, err, "Failed to delete role")
+		return
+	}
+	h.logger.Info("Deleted role", zap.String("name", name), zap.String("user", auth.UserName(r.Context())))
+
+	chix.NewRender(w).JSON(make(map[string]any))
+}
+
+// accountError answers with the status matching an error of the user directory. Unexpected
+// errors are logged and answered with the message.
+func (h *AdminHandler) accountError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, accounts.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, accounts.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, accounts.ErrExists), errors.Is(err, accounts.ErrInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
+
+// endSessions ends the sessions of a local user, so they have to log in with their current credentials again
+func (h *AdminHandler) endSessions(name string) error {
+	if h.sessions == nil {
+		return nil
+	}
+	ended, err := h.sessions.EndUser(name, auth.MethodBasic)
+	if err != nil {
+		return fmt.Errorf("failed to end sessions of user %s: %w", name, err)
+	}
+	if ended > 0 {
+		h.logger.Info("Ended sessions of user", zap.String("name", name), zap.Int("sessions", ended))
+	}
+	return nil
+}
+
+// recordAudit appends an entry for a change of the users or roles to the audit log.
+// Failures to write the audit log are logged but don't fail the change.
+func (h *AdminHandler) recordAudit(r *http.Request, action, resource string, opErr error, details map[string]string) {
+	entry := audit.Entry{
+		User:     auth.UserName(r.Context()),
+		Action:   action,
+		Resource: resource,
+		Outcome:  audit.OutcomeSuccess,
+		Details:  details,
+	}
+	if opErr != nil {
+		entry.Outcome = audit.OutcomeFailure
+		entry.Error = opErr.Error()
+	}
+	if _, err := h.auditLog.Record(r.Context(), entry); err != nil {
+		h.logger.Error("Failed to write audit entry", zap.Error(err), zap.String("action", action))
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/accounts"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+func TestUserManagement(t *testing.T) {
+	directory := accounts.NewDirectory(metadata.NewMemoryStore(), "admin")
+	auditLog := audit.NewMemoryLog(100)
+	r := chi.NewRouter()
+	NewAdminHandler(&config.Config{}, zap.NewNop(), WithUserDirectory(directory, auditLog)).RegisterRoutes(r)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(auth.WithUser(req.Context(), auth.User{Name: "admin", Method: auth.MethodBasic}))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{name: "CreateRole", method: "POST", path: "/api/admin/roles", body: `{"name": "dba", "grants": "operator"}`, want: http.StatusCreated},
+		{name: "CreateRoleInvalidGrant", method: "POST", path: "/api/admin/roles", body: `{"name": "auditor", "grants": "root"}`, want: http.StatusBadRequest},
+		{name: "UpdateBuiltInRole", method: "PUT", path: "/api/admin/roles/viewer", body: `{"grants": "operator"}`, want: http.StatusBadRequest},
+		{name: "CreateUser", method: "POST", path: "/api/admin/users", body: `{"name": "alice", "password": "correct horse battery", "roles": ["viewer"]}`, want: http.StatusCreated},
+		{name: "CreateUserTwice", method: "POST", path: "/api/admin/users", body: `{"name": "alice", "password": "correct horse battery"}`, want: http.StatusConflict},
+		{name: "CreateConfiguredUser", method: "POST", path: "/api/admin/users", body: `{"name": "admin", "password": "correct horse battery"}`, want: http.StatusConflict},
+		{name: "CreateUserShortPassword", method: "POST", path: "/api/admin/users", body: `{"name": "bob", "password": "short"}`, want: http.StatusBadRequest},
+		{name: "AssignRoles", method: "PUT", path: "/api/admin/users/alice", body: `{"roles": ["dba"]}`, want: http.StatusOK},
+		{name: "AssignMissingRole", method: "PUT", path: "/api/admin/users/alice", body: `{"roles": ["root"]}`, want: http.StatusBadRequest},
+		{name: "DeleteAssignedRole", method: "DELETE", path: "/api/admin/roles/dba", want: http.StatusConflict},
+		{name: "ResetPassword", method: "PUT", path: "/api/admin/users/alice/password", body: `{"password": "another long password"}`, want: http.StatusOK},
+		{name: "ResetPasswordUnknownUser", method: "PUT", path: "/api/admin/users/bob/password", body: `{"password": "another long password"}`, want: http.StatusNotFound},
+		{name: "Disable", method: "PUT", path: "/api/admin/users/alice", body: `{"roles": ["dba"], "disabled": true}`, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := serve(tt.method, tt.path, tt.body); rr.Code != tt.want {
+				t.Errorf("%s %s returned %d, want %d: %s", tt.method, tt.path, rr.Code, tt.want, rr.Body.String())
+			}
+		})
+	}
+
+	rr := serve("GET", "/api/admin/users/alice", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("getting the user returned %d", rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "passwordHash") {
+		t.Error("the password hash must not be returned")
+	}
+	var user accounts.User
+	if err := json.Unmarshal(rr.Body.Bytes(), &user); err != nil {
+		t.Fatal(err)
+	}
+	if !user.Disabled || len(user.Roles) != 1 || user.Roles[0] != "dba" || user.CreatedBy != "admin" {
+		t.Errorf("unexpected user: %+v", user)
+	}
+	if directory.Authenticate("alice", "another long password") {
+		t.Error("disabled users must not authenticate")
+	}
+
+	entries, err := auditLog.List(context.Background(), audit.Query{Action: "user.password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Outcome != audit.OutcomeFailure || entries[1].Resource != "users/alice" {
+		t.Errorf("password resets should be audited, got %+v", entries)
+	}
+	for _, e := range entries {
+		if strings.Contains(string(e.Snapshot)+e.Error+e.Details["password"], "another long password") {
+			t.Error("the password must not be audited")
+		}
+	}
+}
+
+func TestPasswordResetEndsSessions(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	directory := accounts.NewDirectory(store, "admin")
+	if _, err := directory.CreateUser(accounts.User{Name: "alice", Roles: []string{"viewer"}}, "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	sessions := auth.NewSessionStore(store, time.Hour)
+	alice := auth.User{Name: "alice", Method: auth.MethodBasic}
+	session, err := sessions.Create(alice, httptest.NewRequest("POST", auth.SessionPath, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sessions of an OIDC user of the same name are not those of the local user
+	if _, err := sessions.Create(auth.User{Name: "alice", Method: auth.MethodOIDC}, httptest.NewRequest("GET", auth.CallbackPath, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	r := chi.NewRouter()
+	NewAdminHandler(&config.Config{}, zap.NewNop(), WithUserDirectory(directory, audit.NewMemoryLog(10)),
+		WithUserSessions(sessions)).RegisterRoutes(r)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("PUT", "/api/admin/users/alice/password", strings.NewReader(`{"password": "another long password"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("resetting the password returned %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if _, ok := sessions.Get(session.ID); ok {
+		t.Error("the sessions of the user should end when their password is reset")
+	}
+	if others, err := sessions.Sessions(auth.User{Name: "alice", Method: auth.MethodOIDC}); err != nil || len(others) != 1 {
+		t.Errorf("sessions of other users should remain, got %v, %v", others, err)
+	}
+}
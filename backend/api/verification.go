@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.uber.org/zap"
+)
+
+// ReadVerificationHandler serves the counts of the reads compared with a second replica
+type ReadVerificationHandler struct {
+	verifier *armada.ReadVerifier
+	logger   *zap.Logger
+}
+
+// NewReadVerificationHandler creates a new read verification API handler
+func NewReadVerificationHandler(verifier *armada.ReadVerifier, logger *zap.Logger) *ReadVerificationHandler {
+	return &ReadVerificationHandler{
+		verifier: verifier,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the read verification routes
+func (h *ReadVerificationHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/analysis/read-verification", h.handleReadVerification)
+}
+
+// handleReadVerification reports how many reads were compared with a second replica and how many
+// of them differed. The differing reads are logged with their keys.
+// @Summary Get read verification counts
+// @Description Report the reads of the default cluster compared with a second replica and the mismatches found
+// @Tags analysis
+// @Produce json
+// @Success 200 {object} armada.VerificationStats
+// @Router /api/analysis/read-verification [get]
+func (h *ReadVerificationHandler) handleReadVerification(w http.ResponseWriter, r *http.Request) {
+	chix.NewRender(w).JSON(h.verifier.Stats())
+}
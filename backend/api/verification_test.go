@@ -0,0 +1,27 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"go.uber.org/zap"
+)
+
+func TestHandleReadVerification(t *testing.T) {
+	handler := NewReadVerificationHandler(armada.NewReadVerifier(1, nil), zap.NewNop())
+	rr := httptest.NewRecorder()
+	handler.handleReadVerification(rr, httptest.NewRequest("GET", "/api/analysis/read-verification", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, rr.Code)
+	}
+	var stats armada.VerificationStats
+	if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats != (armada.VerificationStats{}) {
+		t.Errorf("Expected no verified reads, got %+v", stats)
+	}
+}
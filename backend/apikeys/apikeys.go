@@ -0,0 +1,313 @@
+// Package apikeys manages the API keys scripts and CI jobs authenticate with. Every key grants
+// one of the access levels of auth.Role. Only a SHA-256 digest of a key is stored, in the
+// metadata store or a table of the cluster; the key itself is returned once when it is created.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+)
+
+// Namespace is the metadata namespace the keys are stored in
+const Namespace = "apikeys"
+
+const (
+	// idBytes is the length of the ID of a key, which is part of the key and the name it is stored under
+	idBytes = 8
+	// secretBytes is the length of the random secret of a key
+	secretBytes = 32
+	// verifiedTTL is how long verified keys are remembered, so every request doesn't read the
+	// store. Revoking a key takes effect at once on this console, on others sharing the store
+	// within this time.
+	verifiedTTL = time.Minute
+	// usedInterval is how often the last use of a key is written to the store
+	usedInterval = 5 * time.Minute
+)
+
+var (
+	// ErrNotFound is returned when a key does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrExists is returned when creating a key with the name of another key
+	ErrExists = errors.New("already exists")
+	// ErrInvalid is returned for keys that can't be created
+	ErrInvalid = errors.New("invalid")
+)
+
+// validName matches the names of keys
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]{0,63}$`)
+
+// Key describes an API key, without the key itself
+type Key struct {
+	ID string `json:"id"`
+	// Name identifies the client using the key, e.g. in the audit log.
+	Name string `json:"name"`
+	// Role is the access level granted by the key.
+	Role      auth.Role `json:"role"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ExpiresAt is when the key stops working, it works until revoked if nil.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// LastUsedAt is when the key was last used, up to a few minutes ago.
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// storedKey is a key as stored, including the digest of its secret
+type storedKey struct {
+	Key
+	Digest string `json:"digest"`
+}
+
+// verifiedKey is a key remembered after it was read from the store
+type verifiedKey struct {
+	stored storedKey
+	until  time.Time
+}
+
+// Manager stores the API keys in the metadata store. It is safe for concurrent use.
+type Manager struct {
+	store metadata.Store
+	now   func() time.Time
+
+	// mu serializes changes, so names stay unique
+	mu sync.Mutex
+
+	// verifiedMu protects verified
+	verifiedMu sync.Mutex
+	// verified maps the IDs of recently used keys to the stored keys
+	verified map[string]verifiedKey
+}
+
+// NewManager creates a Manager backed by the store
+func NewManager(store metadata.Store) *Manager {
+	return &Manager{
+		store:    store,
+		now:      time.Now,
+		verified: make(map[string]verifiedKey),
+	}
+}
+
+// Keys returns all keys ordered by name
+func (m *Manager) Keys() ([]Key, error) {
+	stored, err := metadata.List[storedKey](m.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(stored))
+	for _, k := range stored {
+		keys = append(keys, k.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// Create creates a key granting the role, which expires after ttl unless ttl is zero. It
+// returns the key, which can't be retrieved later.
+func (m *Manager) Create(name string, role auth.Role, ttl time.Duration, createdBy string) (Key, string, error) {
+	if !validName.MatchString(name) {
+		return Key{}, "", fmt.Errorf("%w key name %q: use up to 64 letters, digits and ._@-", ErrInvalid, name)
+	}
+	if role != auth.RoleViewer && role != auth.RoleOperator {
+		return Key{}, "", fmt.Errorf("%w key: role must be %s or %s, got %q", ErrInvalid, auth.RoleViewer, auth.RoleOperator, role)
+	}
+	if ttl < 0 {
+		return Key{}, "", fmt.Errorf("%w key: expiry must not be negative, got %s", ErrInvalid, ttl)
+	}
+
+	id := make([]byte, idBytes)
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	now := m.now().UTC()
+	k := storedKey{
+		Key: Key{
+			ID:        hex.EncodeToString(id),
+			Name:      name,
+			Role:      role,
+			CreatedBy: createdBy,
+			CreatedAt: now,
+		},
+		Digest: digest(encodedSecret),
+	}
+	if ttl > 0 {
+		expires := now.Add(ttl)
+		k.ExpiresAt = &expires
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys, err := m.Keys()
+	if err != nil {
+		return Key{}, "", err
+	}
+	for _, existing := range keys {
+		if existing.Name == name {
+			return Key{}, "", fmt.Errorf("key %s %w", name, ErrExists)
+		}
+	}
+	if err := metadata.Put(m.store, Namespace, k.ID, k); err != nil {
+		return Key{}, "", err
+	}
+	return k.Key, auth.APIKeyPrefix + k.ID + "_" + encodedSecret, nil
+}
+
+// Revoke deletes a key, or returns ErrNotFound
+func (m *Manager) Revoke(id string) (Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k, err := m.get(id)
+	if err != nil {
+		return Key{}, err
+	}
+	if err := m.store.Delete(Namespace, id); err != nil {
+		return Key{}, err
+	}
+	m.verifiedMu.Lock()
+	delete(m.verified, id)
+	m.verifiedMu.Unlock()
+	return k.Key, nil
+}
+
+// AuthenticateKey returns the user identified by a key that exists and hasn't expired
+func (m *Manager) AuthenticateKey(key string) (auth.User, bool) {
+	k, ok := m.verify(key)
+	if !ok {
+		return auth.User{}, false
+	}
+	return auth.User{Name: k.Name, Method: auth.MethodAPIKey}, true
+}
+
+// RoleOf returns the role granted by the key with the name. The key is usually remembered from
+// authenticating the request, otherwise it is looked up in the store.
+func (m *Manager) RoleOf(name string) (auth.Role, bool) {
+	now := m.now()
+	m.verifiedMu.Lock()
+	for _, v := range m.verified {
+		if v.stored.Name == name && now.Before(v.until) {
+			m.verifiedMu.Unlock()
+			return v.stored.Role, true
+		}
+	}
+	m.verifiedMu.Unlock()
+
+	keys, err := m.Keys()
+	if err != nil {
+		return "", false
+	}
+	for _, k := range keys {
+		if k.Name == name {
+			return k.Role, true
+		}
+	}
+	return "", false
+}
+
+// verify checks a key and records its use
+func (m *Manager) verify(key string) (Key, bool) {
+	id, secret, ok := parseKey(key)
+	if !ok {
+		return Key{}, false
+	}
+	now := m.now()
+
+	m.verifiedMu.Lock()
+	v, cached := m.verified[id]
+	m.verifiedMu.Unlock()
+	if !cached || !now.Before(v.until) {
+		var err error
+		if v, err = m.refresh(id, now, false); err != nil {
+			return Key{}, false
+		}
+	}
+	if subtle.ConstantTimeCompare([]byte(digest(secret)), []byte(v.stored.Digest)) != 1 {
+		return Key{}, false
+	}
+	if v.stored.ExpiresAt != nil && !now.Before(*v.stored.ExpiresAt) {
+		return Key{}, false
+	}
+
+	if v.stored.LastUsedAt == nil || now.Sub(*v.stored.LastUsedAt) >= usedInterval {
+		refreshed, err := m.refresh(id, now, true)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return Key{}, false
+		case err == nil:
+			v = refreshed
+		}
+	}
+	return v.stored.Key, true
+}
+
+// refresh reads a key from the store and remembers it, recording its use if used is set. It holds
+// the lock serializing changes, so a key revoked meanwhile, on this console or on another one
+// sharing the store, is neither remembered nor written back to the store.
+func (m *Manager) refresh(id string, now time.Time, used bool) (verifiedKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, err := m.get(id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			m.verifiedMu.Lock()
+			delete(m.verified, id)
+			m.verifiedMu.Unlock()
+		}
+		return verifiedKey{}, err
+	}
+	if used {
+		at := now.UTC()
+		stored.LastUsedAt = &at
+		// A failed write only shows an older last use
+		_ = metadata.Put(m.store, Namespace, id, stored)
+	}
+	v := verifiedKey{stored: stored, until: now.Add(verifiedTTL)}
+	m.verifiedMu.Lock()
+	m.verified[id] = v
+	m.verifiedMu.Unlock()
+	return v, nil
+}
+
+// get returns the stored key, or ErrNotFound
+func (m *Manager) get(id string) (storedKey, error) {
+	k, err := metadata.Get[storedKey](m.store, Namespace, id)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return storedKey{}, fmt.Errorf("key %s %w", id, ErrNotFound)
+	}
+	return k, err
+}
+
+// parseKey splits a key into its ID and secret
+func parseKey(key string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, auth.APIKeyPrefix)
+	if !ok || len(rest) < 2*idBytes+2 || rest[2*idBytes] != '_' {
+		return "", "", false
+	}
+	id, secret = rest[:2*idBytes], rest[2*idBytes+1:]
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", "", false
+	}
+	return id, secret, true
+}
+
+// digest returns the hex encoded SHA-256 digest of a secret. Keys are random, so unlike
+// passwords they don't need a slow hash.
+func digest(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
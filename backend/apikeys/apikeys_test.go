@@ -0,0 +1,99 @@
+package apikeys
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	m := NewManager(store)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	key, token, err := m.Create("ci", auth.RoleOperator, 0, "admin")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, auth.APIKeyPrefix))
+	assert.Equal(t, "admin", key.CreatedBy)
+	assert.Nil(t, key.ExpiresAt)
+
+	_, _, err = m.Create("ci", auth.RoleViewer, 0, "admin")
+	assert.ErrorIs(t, err, ErrExists)
+	_, _, err = m.Create("../ci", auth.RoleViewer, 0, "admin")
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, _, err = m.Create("deploy", auth.Role("admin"), 0, "admin")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	raw, err := store.Get(Namespace, key.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), token[len(auth.APIKeyPrefix)+2*idBytes+1:], "only the digest is stored")
+
+	user, ok := m.AuthenticateKey(token)
+	require.True(t, ok)
+	assert.Equal(t, auth.User{Name: "ci", Method: auth.MethodAPIKey}, user)
+	role, ok := m.RoleOf("ci")
+	assert.True(t, ok)
+	assert.Equal(t, auth.RoleOperator, role)
+
+	_, ok = m.AuthenticateKey(token[:len(token)-1] + "x")
+	assert.False(t, ok, "a wrong secret is refused")
+	_, ok = m.AuthenticateKey(auth.APIKeyPrefix + "nothex")
+	assert.False(t, ok)
+
+	keys, err := m.Keys()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].LastUsedAt, "the use is recorded")
+	assert.Equal(t, now, *keys[0].LastUsedAt)
+
+	revoked, err := m.Revoke(key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", revoked.Name)
+	_, ok = m.AuthenticateKey(token)
+	assert.False(t, ok, "revoked keys are refused at once")
+	_, err = m.Revoke(key.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManagerExpiry(t *testing.T) {
+	m := NewManager(metadata.NewMemoryStore())
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	key, token, err := m.Create("nightly", auth.RoleViewer, time.Hour, "admin")
+	require.NoError(t, err)
+	require.NotNil(t, key.ExpiresAt)
+	_, ok := m.AuthenticateKey(token)
+	assert.True(t, ok)
+
+	now = now.Add(time.Hour)
+	_, ok = m.AuthenticateKey(token)
+	assert.False(t, ok, "expired keys are refused, also when remembered")
+}
+
+func TestManagerRevokedElsewhere(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	m, other := NewManager(store), NewManager(store)
+	m.now = func() time.Time { return now }
+	other.now = m.now
+
+	key, token, err := m.Create("ci", auth.RoleOperator, 0, "admin")
+	require.NoError(t, err)
+	_, ok := m.AuthenticateKey(token)
+	require.True(t, ok)
+
+	_, err = other.Revoke(key.ID)
+	require.NoError(t, err)
+	now = now.Add(usedInterval)
+	_, ok = m.AuthenticateKey(token)
+	assert.False(t, ok, "recording the use rereads the key")
+	_, err = store.Get(Namespace, key.ID)
+	assert.ErrorIs(t, err, metadata.ErrNotFound, "the revoked key isn't written back")
+}
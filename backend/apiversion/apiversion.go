@@ -0,0 +1,102 @@
+// Package apiversion serves the REST API under versioned prefixes such as /api/v1.
+//
+// Routes are registered once below /api. The middleware takes the version off the path before
+// routing and keeps it in the request context, so a later version can change the responses of an
+// endpoint, e.g. to paginate them, by checking FromContext instead of duplicating its route.
+// Unversioned /api paths are an alias of Default, which stays v1, so scripts written before
+// versioning keep working when breaking changes ship in a later version.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// Prefix is the prefix of the REST API routes
+	Prefix = "/api"
+	// Default is the version of requests to unversioned API paths
+	Default = "v1"
+	// Latest is the most recent version, the one new clients should use
+	Latest = "v1"
+	// Header reports the version a response was served with
+	Header = "X-API-Version"
+)
+
+// Supported lists the versions served under /api/{version}, oldest first
+var Supported = []string{"v1"}
+
+// versionSegment matches the version segment of a path, e.g. v1
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+type contextKey struct{}
+
+// Split takes the version off an API path, e.g. /api/v1/tables becomes /api/tables and v1.
+// Paths without a version are returned unchanged with an empty version.
+func Split(path string) (string, string) {
+	rest, ok := strings.CutPrefix(path, Prefix+"/")
+	if !ok {
+		return path, ""
+	}
+	segment, tail, _ := strings.Cut(rest, "/")
+	if !versionSegment.MatchString(segment) {
+		return path, ""
+	}
+	return Prefix + "/" + tail, segment
+}
+
+// FromContext returns the API version a request was made with
+func FromContext(ctx context.Context) string {
+	if version, ok := ctx.Value(contextKey{}).(string); ok {
+		return version
+	}
+	return Default
+}
+
+// Path returns the path of an API route as the client of the request addresses it, e.g. for
+// Location headers: /api/tables/users stays unchanged for unversioned requests and becomes
+// /api/v1/tables/users for requests to v1.
+func Path(ctx context.Context, path string) string {
+	version, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		return path
+	}
+	rest, ok := strings.CutPrefix(path, Prefix+"/")
+	if !ok {
+		return path
+	}
+	return Prefix + "/" + version + "/" + rest
+}
+
+// Middleware serves the supported versions of the API. It must run before the middlewares
+// matching request paths, e.g. authentication, so they see the unversioned path.
+// Requests to an unsupported version are answered with 404 Not Found.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, version := Split(r.URL.Path)
+		if version == "" {
+			if strings.HasPrefix(r.URL.Path, Prefix+"/") {
+				w.Header().Set(Header, Default)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !slices.Contains(Supported, version) {
+			http.Error(w, "Unsupported API version "+version+", supported versions are "+strings.Join(Supported, ", "), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set(Header, version)
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, version))
+		u := *r.URL
+		u.Path = path
+		if u.RawPath != "" {
+			u.RawPath, _ = Split(u.RawPath)
+		}
+		r.URL = &u
+		next.ServeHTTP(w, r)
+	})
+}
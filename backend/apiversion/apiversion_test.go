@@ -0,0 +1,87 @@
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		version string
+	}{
+		{"/api/v1/tables", "/api/tables", "v1"},
+		{"/api/v2/kv/users", "/api/kv/users", "v2"},
+		{"/api/v1/", "/api/", "v1"},
+		{"/api/tables", "/api/tables", ""},
+		{"/api/values/v1", "/api/values/v1", ""},
+		{"/api/vip", "/api/vip", ""},
+		{"/assets/v1/app.js", "/assets/v1/app.js", ""},
+	}
+	for _, tt := range tests {
+		path, version := Split(tt.path)
+		assert.Equal(t, tt.want, path, tt.path)
+		assert.Equal(t, tt.version, version, tt.path)
+	}
+}
+
+func TestPath(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "v1")
+	assert.Equal(t, "/api/v1/tables/users", Path(ctx, "/api/tables/users"))
+	assert.Equal(t, "/api/tables/users", Path(context.Background(), "/api/tables/users"))
+}
+
+func TestMiddleware(t *testing.T) {
+	var gotPath, gotVersion string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		want    string
+		version string
+		header  string
+	}{
+		{"Versioned", "/api/v1/tables", http.StatusOK, "/api/tables", "v1", "v1"},
+		{"Unversioned", "/api/tables", http.StatusOK, "/api/tables", Default, Default},
+		{"Frontend", "/data/users", http.StatusOK, "/data/users", Default, ""},
+		{"Unsupported", "/api/v9/tables", http.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotVersion = "", ""
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.want, gotPath)
+			assert.Equal(t, tt.version, gotVersion)
+			assert.Equal(t, tt.header, rec.Header().Get(Header))
+		})
+	}
+}
+
+func TestMiddlewareEscapedPath(t *testing.T) {
+	var gotPath, gotRawPath string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotRawPath = r.URL.RawPath
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/kv/users/a%2Fb", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "/api/kv/users/a/b", gotPath)
+	assert.Equal(t, "/api/kv/users/a%2Fb", gotRawPath)
+	// The request of the caller is left unchanged
+	assert.Equal(t, "/api/v1/kv/users/a%2Fb", req.URL.RawPath)
+}
@@ -0,0 +1,199 @@
+package armada
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+// States of the circuit breaker of a server, as reported in ServerInfo
+const (
+	// CircuitClosed lets calls through
+	CircuitClosed = "closed"
+	// CircuitOpen fails calls right away until the cooldown passed
+	CircuitOpen = "open"
+	// CircuitHalfOpen lets a single trial call through, which closes the circuit if it succeeds
+	CircuitHalfOpen = "half-open"
+)
+
+// WithCircuitBreaker fails calls to a server right away for the cooldown once failures calls in a
+// row failed because it is down, so fan-outs to every server don't wait for the full timeout of
+// one that is. Calls failing with UNAVAILABLE count, as do those failing with DEADLINE_EXCEEDED
+// while the connection isn't ready; slow calls over a ready connection, e.g. range scans running
+// out of their budget, don't. After the cooldown a single trial call is let through, the circuit
+// closes again if it succeeds. Zero failures disables the breaker.
+func WithCircuitBreaker(failures int, cooldown time.Duration) ClientOption {
+	return func(p *ConnectionPool) {
+		if failures <= 0 {
+			p.breakers = nil
+			return
+		}
+		p.breakers = &circuitBreakers{
+			failures: failures,
+			cooldown: cooldown,
+			logger:   p.logger,
+			circuits: make(map[string]*circuit),
+		}
+	}
+}
+
+// circuitBreakers keeps a circuit per dial target, so every address of the pool is broken on its own
+type circuitBreakers struct {
+	failures int
+	cooldown time.Duration
+	logger   *zap.Logger
+	// mu protects circuits, it is not the connection lock so calls don't wait for connecting
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is the state of the breaker of a dial target
+type circuit struct {
+	state string
+	// failures counts the calls failed in a row while the circuit is closed
+	failures int
+	// openUntil is when an open circuit lets a trial call through
+	openUntil time.Time
+}
+
+// interceptor fails calls to targets whose circuit is open and records the outcome of the others
+func (b *circuitBreakers) interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		target := cc.Target()
+		if err := b.allow(target, time.Now()); err != nil {
+			return err
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(target, callOutcome(err, cc.GetState()), err, time.Now())
+		return err
+	}
+}
+
+// allow returns ErrCircuitOpen if a call to the target must fail right away. Once the cooldown
+// passed, the first call is let through as the trial and the circuit is half-open until it returns.
+func (b *circuitBreakers) allow(target string, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[target]
+	if c == nil {
+		return nil
+	}
+	switch {
+	case c.state == CircuitOpen && !now.Before(c.openUntil):
+		c.state = CircuitHalfOpen
+		return nil
+	case c.state == CircuitOpen:
+		return status.Errorf(codes.Unavailable, "%s: %s, retrying in %s", ErrCircuitOpen, target, c.openUntil.Sub(now).Round(time.Millisecond))
+	case c.state == CircuitHalfOpen:
+		return status.Errorf(codes.Unavailable, "%s: %s, a trial call is in flight", ErrCircuitOpen, target)
+	default:
+		return nil
+	}
+}
+
+// Outcomes of calls as counted by the circuit breaker
+type outcome int
+
+const (
+	// answered calls show the server is up, whether they succeeded or not
+	answered outcome = iota
+	// failed calls show the server may be down
+	failed
+	// inconclusive calls tell nothing about the server, e.g. canceled ones
+	inconclusive
+)
+
+// callOutcome tells whether a call failed because the server is down. Errors the server answered
+// with, e.g. NOT_FOUND, show that it is up.
+func callOutcome(err error, state connectivity.State) outcome {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return failed
+	case codes.DeadlineExceeded:
+		// A slow call over a ready connection doesn't mean the server is down
+		if state == connectivity.Ready {
+			return inconclusive
+		}
+		return failed
+	case codes.Canceled:
+		return inconclusive
+	default:
+		return answered
+	}
+}
+
+// record counts a failed call, or closes the circuit of the target after an answered one
+func (b *circuitBreakers) record(target string, o outcome, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[target]
+	switch o {
+	case failed:
+	case inconclusive:
+		// A trial call that tells nothing is given up, the next call is the trial
+		if c != nil && c.state == CircuitHalfOpen {
+			c.state = CircuitOpen
+		}
+		return
+	default:
+		if c != nil && c.state != CircuitClosed {
+			b.logger.Info("Closed circuit breaker, the server answers again", zap.String("target", target))
+		}
+		delete(b.circuits, target)
+		return
+	}
+
+	if c == nil {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[target] = c
+	}
+	c.failures++
+	if c.state == CircuitHalfOpen || c.failures >= b.failures {
+		if c.state == CircuitClosed {
+			b.logger.Warn("Opened circuit breaker, calls fail right away until the cooldown passed",
+				zap.String("target", target),
+				zap.Int("failures", c.failures),
+				zap.Duration("cooldown", b.cooldown),
+				zap.Error(err))
+		}
+		c.state = CircuitOpen
+		c.openUntil = now.Add(b.cooldown)
+	}
+}
+
+// state returns the state of the circuit of the target and when an open circuit lets a trial call through
+func (b *circuitBreakers) state(target string) (string, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[target]
+	if c == nil {
+		return CircuitClosed, time.Time{}
+	}
+	return c.state, c.openUntil
+}
+
+// circuitSeverity orders the states from the least to the most severe, to report the worst of a
+// server's addresses
+var circuitSeverity = map[string]int{CircuitClosed: 0, CircuitHalfOpen: 1, CircuitOpen: 2}
+
+// describe returns the worst state of the circuits of a server's addresses and, if one is open,
+// when it lets a trial call through
+func (b *circuitBreakers) describe(addresses []string) (string, *time.Time) {
+	worst, until := CircuitClosed, time.Time{}
+	for _, address := range addresses {
+		state, openUntil := b.state(dialTarget(address))
+		if circuitSeverity[state] > circuitSeverity[worst] {
+			worst, until = state, openUntil
+		}
+	}
+	if worst != CircuitOpen {
+		return worst, nil
+	}
+	return worst, &until
+}
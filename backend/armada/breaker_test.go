@@ -0,0 +1,131 @@
+package armada
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func newTestBreakers(failures int, cooldown time.Duration) *circuitBreakers {
+	pool := NewConnectionPool(zap.NewNop())
+	WithCircuitBreaker(failures, cooldown)(pool)
+	return pool.breakers
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newTestBreakers(3, time.Minute)
+	now := time.Now()
+	down := status.Error(codes.Unavailable, "connection refused")
+
+	// Failures in a row open the circuit, an answer in between starts counting again
+	b.record("a:5001", failed, down, now)
+	b.record("a:5001", answered, nil, now)
+	b.record("a:5001", failed, down, now)
+	b.record("a:5001", failed, down, now)
+	require.NoError(t, b.allow("a:5001", now))
+	b.record("a:5001", failed, down, now)
+	err := b.allow("a:5001", now.Add(time.Second))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Contains(t, err.Error(), ErrCircuitOpen.Error())
+	state, until := b.state("a:5001")
+	assert.Equal(t, CircuitOpen, state)
+	assert.Equal(t, now.Add(time.Minute), until)
+
+	// Other targets are broken on their own
+	require.NoError(t, b.allow("b:5001", now))
+
+	// After the cooldown a single trial call is let through
+	require.NoError(t, b.allow("a:5001", now.Add(time.Minute)))
+	state, _ = b.state("a:5001")
+	assert.Equal(t, CircuitHalfOpen, state)
+	assert.Error(t, b.allow("a:5001", now.Add(time.Minute)))
+
+	// A failed trial opens the circuit for another cooldown
+	b.record("a:5001", failed, down, now.Add(time.Minute))
+	assert.Error(t, b.allow("a:5001", now.Add(time.Minute+time.Second)))
+	_, until = b.state("a:5001")
+	assert.Equal(t, now.Add(2*time.Minute), until)
+
+	// An inconclusive trial is given up, the next call is the trial
+	require.NoError(t, b.allow("a:5001", now.Add(2*time.Minute)))
+	b.record("a:5001", inconclusive, status.Error(codes.Canceled, "canceled"), now.Add(2*time.Minute))
+	require.NoError(t, b.allow("a:5001", now.Add(2*time.Minute)))
+
+	// An answered trial closes the circuit
+	b.record("a:5001", answered, status.Error(codes.NotFound, "key not found"), now.Add(2*time.Minute))
+	require.NoError(t, b.allow("a:5001", now.Add(2*time.Minute)))
+	state, _ = b.state("a:5001")
+	assert.Equal(t, CircuitClosed, state)
+}
+
+func TestCallOutcome(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		state connectivity.State
+		want  outcome
+	}{
+		{name: "Success", state: connectivity.Ready, want: answered},
+		{name: "ServerError", err: status.Error(codes.NotFound, "not found"), state: connectivity.Ready, want: answered},
+		{name: "OtherError", err: errors.New("boom"), state: connectivity.Ready, want: answered},
+		{name: "Unavailable", err: status.Error(codes.Unavailable, "refused"), state: connectivity.Ready, want: failed},
+		{name: "DeadlineNotConnected", err: status.Error(codes.DeadlineExceeded, "deadline"), state: connectivity.TransientFailure, want: failed},
+		{name: "DeadlineReady", err: status.Error(codes.DeadlineExceeded, "deadline"), state: connectivity.Ready, want: inconclusive},
+		{name: "Canceled", err: status.Error(codes.Canceled, "canceled"), state: connectivity.Connecting, want: inconclusive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, callOutcome(tt.err, tt.state))
+		})
+	}
+}
+
+func TestCircuitBreakerInterceptor(t *testing.T) {
+	// Circuits are kept by the target the address is dialed at
+	conn, err := grpc.NewClient(dialTarget("http://armada:5001"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	b := newTestBreakers(2, time.Hour)
+	interceptor := b.interceptor()
+
+	// The server is down: after two failed calls the others fail right away
+	invoker := &scriptedInvoker{codes: []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}}
+	for range 5 {
+		err := interceptor(t.Context(), regattapb.Cluster_Status_FullMethodName, nil, nil, conn, invoker.invoke)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	}
+	assert.Equal(t, 2, invoker.calls)
+
+	state, until := b.describe([]string{"http://armada:5001"})
+	assert.Equal(t, CircuitOpen, state)
+	require.NotNil(t, until)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *until, time.Minute)
+}
+
+func TestWithCircuitBreakerDisabled(t *testing.T) {
+	pool := NewConnectionPool(zap.NewNop())
+	withoutBreaker := len(pool.dialOptions())
+	WithCircuitBreaker(5, time.Second)(pool)
+	require.NotNil(t, pool.breakers)
+	assert.Len(t, pool.dialOptions(), withoutBreaker+1)
+	WithCircuitBreaker(0, time.Second)(pool)
+	assert.Nil(t, pool.breakers)
+	assert.Len(t, pool.dialOptions(), withoutBreaker)
+}
+
+func TestDialTarget(t *testing.T) {
+	assert.Equal(t, "armada:5001", dialTarget("http://armada:5001"))
+	assert.Equal(t, "armada:5001", dialTarget("https://armada:5001"))
+	assert.Equal(t, "10.0.0.1", dialTarget("10.0.0.1"))
+	assert.Equal(t, "dns:///armada", dialTarget("armada"))
+}
@@ -5,16 +5,124 @@ package armada
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/armadakv/console/backend/events"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
+// ClientOption configures optional behaviour of the Client
+type ClientOption func(*ConnectionPool)
+
+// WithTracerProvider traces the gRPC calls of the client with the provider instead of the global one
+func WithTracerProvider(provider trace.TracerProvider) ClientOption {
+	return func(p *ConnectionPool) {
+		p.statsHandler = otelgrpc.NewClientHandler(otelgrpc.WithTracerProvider(provider))
+	}
+}
+
+// WithTLSConfig connects to https addresses with the TLS settings, e.g. from LoadTLSConfig
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(p *ConnectionPool) {
+		p.tlsConfig = config
+	}
+}
+
+// WithPerRPCCredentials attaches the credentials to every call, e.g. a StaticToken or FileToken
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) ClientOption {
+	return func(p *ConnectionPool) {
+		p.perRPCCredentials = creds
+	}
+}
+
+// WithRediscovery lists the cluster members again at the interval, connects to members that
+// joined since and closes the connections of members that left. Zero only discovers them when
+// a new connection is made.
+func WithRediscovery(interval time.Duration) ClientOption {
+	return func(p *ConnectionPool) {
+		p.rediscoveryInterval = interval
+	}
+}
+
+// WithKeepalive pings the servers after a connection has been idle for the interval and closes it
+// when a ping isn't answered within the timeout, so links silently dropped by a NAT or load balancer
+// are redialed instead of failing the next call. permitWithoutStream also pings connections without
+// active calls, which the servers must allow. A zero interval disables the pings.
+func WithKeepalive(interval, timeout time.Duration, permitWithoutStream bool) ClientOption {
+	return func(p *ConnectionPool) {
+		p.keepalive = keepalive.ClientParameters{
+			Time:                interval,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+	}
+}
+
+// WithConnectTimeout makes NewClient and InitializeConnections wait up to the timeout for their
+// connections to be ready, so an unreachable server fails them instead of the first call.
+// Zero doesn't wait, as gRPC connects lazily.
+func WithConnectTimeout(timeout time.Duration) ClientOption {
+	return func(p *ConnectionPool) {
+		p.connectTimeout = timeout
+	}
+}
+
+// WithMaxMessageSizes limits the size in bytes of the messages received and sent by calls, e.g.
+// to read large metrics payloads or values beyond the 4MB gRPC allows by default. Zero keeps
+// the gRPC default.
+func WithMaxMessageSizes(recv, send int) ClientOption {
+	return func(p *ConnectionPool) {
+		p.maxRecvMsgSize = recv
+		p.maxSendMsgSize = send
+	}
+}
+
+// WithReconnectPolicy sets how lost connections are redialed and how often an explicit reconnect
+// is attempted. The delay starts at baseDelay, grows by 1.6 after every failed attempt up to
+// maxDelay and is randomized by up to the jitter fraction in either direction. Invalid values
+// keep the defaults of 5 attempts, 500ms, 30s and 0.2.
+func WithReconnectPolicy(maxRetries int, baseDelay, maxDelay time.Duration, jitter float64) ClientOption {
+	return func(p *ConnectionPool) {
+		if maxRetries > 0 {
+			p.reconnectCfg.maxRetries = maxRetries
+		}
+		if baseDelay > 0 {
+			p.reconnectCfg.baseDelay = baseDelay
+		}
+		if maxDelay >= p.reconnectCfg.baseDelay {
+			p.reconnectCfg.maxDelay = maxDelay
+		}
+		if jitter >= 0 && jitter <= 1 {
+			p.reconnectCfg.jitter = jitter
+		}
+	}
+}
+
+// WithPublisher makes the pool publish an events.TypeMemberRemoved event when it closes the
+// connection of a member that left the cluster
+func WithPublisher(publisher events.Publisher) ClientOption {
+	return func(p *ConnectionPool) {
+		p.publisher = publisher
+	}
+}
+
 // Client is the implementation of the ArmadaClient interface.
 // It uses gRPC to communicate with the Armada server.
 type Client struct {
+	// addressMu protects address, which changes when the seeds of the console are reloaded
+	addressMu sync.RWMutex
 	// address is the address of the Armada server.
 	address string
 
@@ -23,6 +131,9 @@ type Client struct {
 
 	// connectionPool manages all server connections
 	connectionPool ConnectionPoolInterface
+
+	// verifier checks a sample of the reads against a second replica; it may be nil
+	verifier *ReadVerifier
 }
 
 // NewClient creates a new Armada client with a connection to the specified address.
@@ -33,33 +144,62 @@ type Client struct {
 // Parameters:
 //   - address: The address of the Armada server (e.g., "localhost:8081").
 //   - logger: The structured logger for logging.
+//   - opts: Optional settings of the client.
 //
 // Returns:
 //   - An ArmadaClient instance if successful.
 //   - An error if the connection could not be established.
-func NewClient(address string, logger *zap.Logger) (*Client, error) {
+func NewClient(address string, logger *zap.Logger, opts ...ClientOption) (*Client, error) {
 	logger.Info("Creating new Armada client", zap.String("address", address))
 
 	// Create a new connection pool
 	connectionPool := NewConnectionPool(logger)
+	for _, opt := range opts {
+		opt(connectionPool)
+	}
 
 	// Initialize the client
 	client := &Client{
 		address:        address,
 		logger:         logger,
 		connectionPool: connectionPool,
+		verifier:       connectionPool.readVerifier,
 	}
 
 	// Try to establish the main connection to ensure it works
-	_, err := connectionPool.GetConnection(context.Background(), address)
+	conn, err := connectionPool.GetConnection(context.Background(), address)
+	if err == nil {
+		err = connectionPool.awaitReady(context.Background(), address, conn)
+	}
 	if err != nil {
 		_ = connectionPool.Close()
 		return nil, fmt.Errorf("failed to establish initial connection: %w", err)
 	}
+	connectionPool.startHealthCheck()
+	connectionPool.startRediscovery()
 
 	return client, nil
 }
 
+// Address returns the address of the Armada server requests are sent to
+func (c *Client) Address() string {
+	c.addressMu.RLock()
+	defer c.addressMu.RUnlock()
+	return c.address
+}
+
+// SetAddress connects to the Armada server at address and sends requests to it from then on.
+// The previous address is kept if the server can't be reached.
+func (c *Client) SetAddress(ctx context.Context, address string) error {
+	if _, err := c.connectionPool.GetConnection(ctx, address); err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	c.addressMu.Lock()
+	defer c.addressMu.Unlock()
+	c.address = address
+	return nil
+}
+
 // GetConnectionPool returns the connection pool used by this client
 func (c *Client) GetConnectionPool() ConnectionPoolInterface {
 	return c.connectionPool
@@ -79,7 +219,7 @@ func (c *Client) GetConnectionPool() ConnectionPoolInterface {
 //   - An error if the request fails.
 func (c *Client) GetStatus(ctx context.Context, serverAddress string) (*Status, error) {
 	// If no server address is provided, use the client's default address
-	address := c.address
+	address := c.Address()
 	if serverAddress != "" {
 		address = serverAddress
 	}
@@ -130,6 +270,7 @@ func (c *Client) GetStatus(ctx context.Context, serverAddress string) (*Status,
 	return &Status{
 		Status:  "ok",
 		Message: resp.Version + " - " + resp.Info,
+		Version: resp.Version,
 		Config:  configMap,
 		Tables:  tables,
 		Errors:  resp.Errors,
@@ -147,10 +288,10 @@ func (c *Client) GetStatus(ctx context.Context, serverAddress string) (*Status,
 //   - A ClusterInfo object containing information about the cluster.
 //   - An error if the request fails.
 func (c *Client) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
-	c.logger.Info("Getting cluster info from Armada server", zap.String("address", c.address))
+	c.logger.Info("Getting cluster info from Armada server", zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -178,7 +319,7 @@ func (c *Client) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
 		})
 
 		// If this is the node we're connected to, record its ID and address
-		if len(member.ClientURLs) > 0 && member.ClientURLs[0] == c.address {
+		if len(member.ClientURLs) > 0 && member.ClientURLs[0] == c.Address() {
 			nodeID = member.Id
 			nodeAddress = member.ClientURLs[0]
 		}
@@ -204,10 +345,10 @@ func (c *Client) GetClusterInfo(ctx context.Context) (*ClusterInfo, error) {
 //   - A slice of Server objects containing server IDs, names, and URLs.
 //   - An error if the request fails.
 func (c *Client) GetAllServers(ctx context.Context) ([]Server, error) {
-	c.logger.Info("Getting all servers from Armada cluster", zap.String("address", c.address))
+	c.logger.Info("Getting all servers from Armada cluster", zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -243,10 +384,10 @@ func (c *Client) GetAllServers(ctx context.Context) ([]Server, error) {
 //   - A slice of Table objects.
 //   - An error if the request fails.
 func (c *Client) GetTables(ctx context.Context) ([]Table, error) {
-	c.logger.Info("Getting tables from Armada server", zap.String("address", c.address))
+	c.logger.Info("Getting tables from Armada server", zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -282,14 +423,15 @@ func (c *Client) GetTables(ctx context.Context) ([]Table, error) {
 //
 // Returns:
 //   - The ID of the newly created table.
+//   - ErrTableExists if a table with the same name already exists.
 //   - An error if the operation fails.
 func (c *Client) CreateTable(ctx context.Context, tableName string) (string, error) {
 	c.logger.Info("Creating table",
 		zap.String("tableName", tableName),
-		zap.String("address", c.address))
+		zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -305,6 +447,9 @@ func (c *Client) CreateTable(ctx context.Context, tableName string) (string, err
 		c.logger.Error("Failed to create table",
 			zap.Error(err),
 			zap.String("tableName", tableName))
+		if status.Code(err) == codes.AlreadyExists {
+			return "", fmt.Errorf("%w: %s", ErrTableExists, tableName)
+		}
 		return "", err
 	}
 
@@ -319,14 +464,15 @@ func (c *Client) CreateTable(ctx context.Context, tableName string) (string, err
 //   - tableName: The name of the table to delete.
 //
 // Returns:
+//   - ErrTableNotFound if the table does not exist.
 //   - An error if the operation fails.
 func (c *Client) DeleteTable(ctx context.Context, tableName string) error {
 	c.logger.Info("Deleting table",
 		zap.String("tableName", tableName),
-		zap.String("address", c.address))
+		zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -342,6 +488,9 @@ func (c *Client) DeleteTable(ctx context.Context, tableName string) error {
 		c.logger.Error("Failed to delete table",
 			zap.Error(err),
 			zap.String("tableName", tableName))
+		if status.Code(err) == codes.NotFound {
+			return fmt.Errorf("%w: %s", ErrTableNotFound, tableName)
+		}
 		return err
 	}
 
@@ -364,37 +513,19 @@ func (c *Client) DeleteTable(ctx context.Context, tableName string) error {
 //
 // Returns:
 //   - A slice of KeyValuePair objects.
+//   - The pairs received so far and ErrRangeTruncated if the context's deadline passed during the scan.
 //   - An error if the request fails.
 func (c *Client) GetKeyValuePairs(ctx context.Context, table, prefix, start, end string, limit int) ([]KeyValuePair, error) {
-	var rangeStart, rangeEnd string
-	filterType := "none"
-
-	// Determine filtering type and set appropriate parameters
-	if prefix != "" {
-		// Prefix filtering
-		rangeStart = prefix
-		rangeEnd = incrementLastByte(prefix)
-		filterType = "prefix"
-	} else if start != "" && end != "" {
-		// Range filtering
-		rangeStart = start
-		rangeEnd = end
-		filterType = "range"
-	} else {
-		// No filtering, get all keys
-		rangeStart = string([]byte{0x00})
-		rangeEnd = string([]byte{0x00})
-		filterType = "all"
-	}
+	rangeStart, rangeEnd, filterType := rangeBounds(prefix, start, end)
 
 	c.logger.Info("Getting key-value pairs",
 		zap.String("filter", filterType),
 		zap.String("table", table),
-		zap.String("address", c.address),
+		zap.String("address", c.Address()),
 		zap.Int("limit", limit))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -407,26 +538,161 @@ func (c *Client) GetKeyValuePairs(ctx context.Context, table, prefix, start, end
 		Limit:    int64(limit),
 	}
 
-	// Call the Range method of the KV service
-	resp, err := serverConn.KVClient.Range(ctx, req)
-	if err != nil {
-		c.logger.Error("Failed to get key-value pairs from Armada server",
-			zap.Error(err),
+	// The range is streamed, so the pairs received before a deadline aren't lost with the call
+	stream, err := serverConn.KVClient.IterateRange(ctx, req)
+	var pairs []KeyValuePair
+	for err == nil {
+		var resp *regattapb.RangeResponse
+		resp, err = stream.Recv()
+		if err != nil {
+			break
+		}
+		for _, kv := range resp.Kvs {
+			pairs = append(pairs, KeyValuePair{
+				Key:   string(kv.Key),
+				Value: string(kv.Value),
+			})
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		if c.verifier.sampled() {
+			c.verifyRange(ctx, serverConn, req, pairs)
+		}
+		return pairs, nil
+	}
+	if status.Code(err) == codes.DeadlineExceeded && len(pairs) > 0 {
+		c.logger.Warn("Range scan exceeded its deadline, returning the pairs received so far",
 			zap.String("table", table),
-			zap.String("filter", filterType))
-		return nil, err
+			zap.String("filter", filterType),
+			zap.Int("pairs", len(pairs)))
+		return pairs, fmt.Errorf("%w after %d pairs: %w", ErrRangeTruncated, len(pairs), err)
 	}
+	c.logger.Error("Failed to get key-value pairs from Armada server",
+		zap.Error(err),
+		zap.String("table", table),
+		zap.String("filter", filterType))
+	return nil, err
+}
 
-	// Convert the response to our KeyValuePair type
-	pairs := make([]KeyValuePair, 0, len(resp.Kvs))
-	for _, kv := range resp.Kvs {
-		pairs = append(pairs, KeyValuePair{
-			Key:   string(kv.Key),
-			Value: string(kv.Value),
-		})
+// GetKeyRevisions retrieves the pairs of a table with a prefix together with their revisions,
+// e.g. to order the contenders for a lock. At most limit pairs are returned.
+func (c *Client) GetKeyRevisions(ctx context.Context, table, prefix string, limit int) ([]KeyRevision, error) {
+	rangeStart, rangeEnd, _ := rangeBounds(prefix, "", "")
+
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
+	}
+
+	stream, err := serverConn.KVClient.IterateRange(ctx, &regattapb.RangeRequest{
+		Table:    []byte(table),
+		Key:      []byte(rangeStart),
+		RangeEnd: []byte(rangeEnd),
+		Limit:    int64(limit),
+	})
+	var revisions []KeyRevision
+	for err == nil {
+		var resp *regattapb.RangeResponse
+		resp, err = stream.Recv()
+		if err != nil {
+			break
+		}
+		for _, kv := range resp.Kvs {
+			revisions = append(revisions, KeyRevision{
+				Key:            string(kv.Key),
+				Value:          string(kv.Value),
+				CreateRevision: kv.CreateRevision,
+				ModRevision:    kv.ModRevision,
+			})
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		return revisions, nil
 	}
+	c.logger.Error("Failed to get key revisions from Armada server",
+		zap.Error(err),
+		zap.String("table", table),
+		zap.String("prefix", prefix))
+	return nil, err
+}
 
-	return pairs, nil
+// rangeBounds returns the range of keys selected by a prefix, a start and end, or neither
+// for the whole table, together with the type of the filter for logging
+func rangeBounds(prefix, start, end string) (rangeStart, rangeEnd, filterType string) {
+	switch {
+	case prefix != "":
+		return prefix, incrementLastByte(prefix), "prefix"
+	case start != "" && end != "":
+		return start, end, "range"
+	default:
+		// Both bounds '\0' select all keys
+		return "\x00", "\x00", "all"
+	}
+}
+
+// estimateSample is the number of pairs the average pair size of an estimate is measured on
+const estimateSample = 100
+
+// RangeEstimate approximates the amount of data a range scan would fetch
+type RangeEstimate struct {
+	// Keys is the number of keys in the range
+	Keys int64 `json:"keys"`
+	// Bytes extrapolates the size of keys and values from a sample of the first pairs
+	Bytes int64 `json:"bytes"`
+}
+
+// EstimateKeyValuePairs counts the keys selected like by GetKeyValuePairs without fetching them
+// and estimates their size, so large scans can be confirmed before they are executed.
+//
+// Parameters:
+//   - ctx: The context for the request.
+//   - table: The table to query.
+//   - prefix, start, end: The filter, as for GetKeyValuePairs.
+//
+// Returns:
+//   - The estimated number of keys and bytes.
+//   - An error if the request fails.
+func (c *Client) EstimateKeyValuePairs(ctx context.Context, table, prefix, start, end string) (*RangeEstimate, error) {
+	rangeStart, rangeEnd, _ := rangeBounds(prefix, start, end)
+
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
+	}
+
+	// Only the count is transferred for the whole range
+	count, err := serverConn.KVClient.Range(ctx, &regattapb.RangeRequest{
+		Table:     []byte(table),
+		Key:       []byte(rangeStart),
+		RangeEnd:  []byte(rangeEnd),
+		CountOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count keys: %w", err)
+	}
+	estimate := &RangeEstimate{Keys: count.Count}
+	if estimate.Keys == 0 {
+		return estimate, nil
+	}
+
+	sample, err := serverConn.KVClient.Range(ctx, &regattapb.RangeRequest{
+		Table:    []byte(table),
+		Key:      []byte(rangeStart),
+		RangeEnd: []byte(rangeEnd),
+		Limit:    estimateSample,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample keys: %w", err)
+	}
+	if len(sample.Kvs) == 0 {
+		return estimate, nil
+	}
+	var sampled int64
+	for _, kv := range sample.Kvs {
+		sampled += int64(len(kv.Key) + len(kv.Value))
+	}
+	estimate.Bytes = sampled * estimate.Keys / int64(len(sample.Kvs))
+	return estimate, nil
 }
 
 // GetKeyValue retrieves a specific key-value pair from the specified table.
@@ -439,15 +705,16 @@ func (c *Client) GetKeyValuePairs(ctx context.Context, table, prefix, start, end
 //
 // Returns:
 //   - The key-value pair if found.
-//   - An error if not found or if the operation fails.
+//   - ErrKeyNotFound if the key does not exist.
+//   - An error if the operation fails.
 func (c *Client) GetKeyValue(ctx context.Context, table, key string) (*KeyValuePair, error) {
 	c.logger.Info("Getting specific key-value pair",
 		zap.String("table", table),
 		zap.String("key", key),
-		zap.String("address", c.address))
+		zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -472,11 +739,18 @@ func (c *Client) GetKeyValue(ctx context.Context, table, key string) (*KeyValueP
 
 	// Check if we got any results
 	if len(resp.Kvs) == 0 {
-		return nil, fmt.Errorf("key not found: %s", key)
+		if c.verifier.sampled() {
+			c.verifyKey(ctx, serverConn, table, key, nil)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, key)
 	}
 
 	// Convert the response to our KeyValuePair type
 	kv := resp.Kvs[0]
+	if c.verifier.sampled() {
+		value := string(kv.Value)
+		c.verifyKey(ctx, serverConn, table, key, &value)
+	}
 	return &KeyValuePair{
 		Key:   string(kv.Key),
 		Value: string(kv.Value),
@@ -499,10 +773,10 @@ func (c *Client) PutKeyValue(ctx context.Context, table, key, value string) erro
 		zap.String("key", key),
 		zap.String("value", value),
 		zap.String("table", table),
-		zap.String("address", c.address))
+		zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -541,10 +815,10 @@ func (c *Client) DeleteKey(ctx context.Context, table, key string) error {
 	c.logger.Info("Deleting key",
 		zap.String("key", key),
 		zap.String("table", table),
-		zap.String("address", c.address))
+		zap.String("address", c.Address()))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -568,6 +842,128 @@ func (c *Client) DeleteKey(ctx context.Context, table, key string) error {
 	return nil
 }
 
+// PutKeyValueIf stores a key-value pair only if the key holds the value current, or, if current
+// is nil, only if the key does not exist. The comparison and the write are a single transaction,
+// so no other write can come between them.
+//
+// Returns:
+//   - Whether the pair was stored, false if the key holds another value.
+//   - An error if the operation fails.
+func (c *Client) PutKeyValueIf(ctx context.Context, table, key, value string, current *string) (bool, error) {
+	c.logger.Info("Putting key-value pair if unchanged",
+		zap.String("key", key),
+		zap.String("value", value),
+		zap.String("table", table),
+		zap.String("address", c.Address()))
+
+	put := &regattapb.RequestOp{Request: &regattapb.RequestOp_RequestPut{
+		RequestPut: &regattapb.RequestOp_Put{Key: []byte(key), Value: []byte(value)},
+	}}
+	if current == nil {
+		// A comparison without a value holds if the key exists, the key is only created if it fails
+		return c.txn(ctx, table, key, &regattapb.Compare{Key: []byte(key)}, nil, put)
+	}
+	return c.txn(ctx, table, key, valueEquals(key, *current), put, nil)
+}
+
+// DeleteKeyIf deletes a key only if it holds the value current. The comparison and the delete
+// are a single transaction, so no other write can come between them.
+//
+// Returns:
+//   - Whether the key was deleted, false if it holds another value or does not exist.
+//   - An error if the operation fails.
+func (c *Client) DeleteKeyIf(ctx context.Context, table, key, current string) (bool, error) {
+	c.logger.Info("Deleting key if unchanged",
+		zap.String("key", key),
+		zap.String("table", table),
+		zap.String("address", c.Address()))
+
+	remove := &regattapb.RequestOp{Request: &regattapb.RequestOp_RequestDeleteRange{
+		RequestDeleteRange: &regattapb.RequestOp_DeleteRange{Key: []byte(key)},
+	}}
+	return c.txn(ctx, table, key, valueEquals(key, current), remove, nil)
+}
+
+// valueEquals compares the value of a key in a transaction
+func valueEquals(key, value string) *regattapb.Compare {
+	return &regattapb.Compare{
+		Key:         []byte(key),
+		Result:      regattapb.Compare_EQUAL,
+		Target:      regattapb.Compare_VALUE,
+		TargetUnion: &regattapb.Compare_Value{Value: []byte(value)},
+	}
+}
+
+// txn applies success if the comparison holds and failure otherwise, either may be nil.
+// It reports whether the request meant to be applied was: success, or failure if success is nil.
+func (c *Client) txn(ctx context.Context, table, key string, compare *regattapb.Compare, success, failure *regattapb.RequestOp) (bool, error) {
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to Armada server: %w", err)
+	}
+
+	req := &regattapb.TxnRequest{Table: []byte(table), Compare: []*regattapb.Compare{compare}}
+	if success != nil {
+		req.Success = []*regattapb.RequestOp{success}
+	}
+	if failure != nil {
+		req.Failure = []*regattapb.RequestOp{failure}
+	}
+	resp, err := serverConn.KVClient.Txn(ctx, req)
+	if err != nil {
+		c.logger.Error("Failed to apply transaction on Armada server",
+			zap.Error(err),
+			zap.String("table", table),
+			zap.String("key", key))
+		return false, err
+	}
+	return resp.Succeeded == (success != nil), nil
+}
+
+// DeletePrefix deletes all keys starting with prefix from a table.
+// It calls the DeleteRange method of the KV gRPC service with the range covering the prefix.
+//
+// Parameters:
+//   - ctx: The context for the request.
+//   - table: The table to delete the keys from.
+//   - prefix: The key prefix, it must not be empty.
+//
+// Returns:
+//   - The number of deleted keys.
+//   - An error if the request fails.
+func (c *Client) DeletePrefix(ctx context.Context, table, prefix string) (int64, error) {
+	if prefix == "" {
+		return 0, errors.New("prefix must not be empty")
+	}
+
+	c.logger.Info("Deleting keys by prefix",
+		zap.String("prefix", prefix),
+		zap.String("table", table),
+		zap.String("address", c.Address()))
+
+	// Get connection from pool
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to Armada server: %w", err)
+	}
+
+	resp, err := serverConn.KVClient.DeleteRange(ctx, &regattapb.DeleteRangeRequest{
+		Table:    []byte(table),
+		Key:      []byte(prefix),
+		RangeEnd: prefixRangeEnd(prefix),
+		Count:    true,
+	})
+	if err != nil {
+		c.logger.Error("Failed to delete keys by prefix from Armada server",
+			zap.Error(err),
+			zap.String("table", table),
+			zap.String("prefix", prefix))
+		return 0, err
+	}
+
+	return resp.GetDeleted(), nil
+}
+
 // incrementLastByte increments the last byte of a string to get the range end for prefix search.
 // This is used to create a range end for the Range request to fetch all keys with a given prefix.
 //
@@ -586,6 +982,26 @@ func incrementLastByte(s string) string {
 	return string(bytes)
 }
 
+// PrefixEnd returns the end of the range of keys starting with prefix, e.g. to continue
+// a prefix scan from a key within it
+func PrefixEnd(prefix string) string {
+	return string(prefixRangeEnd(prefix))
+}
+
+// prefixRangeEnd returns the smallest key greater than every key starting with prefix.
+// Trailing 0xff bytes can't be incremented and are dropped; a prefix of only 0xff bytes
+// yields "\x00", which Armada interprets as the end of the table.
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0x00}
+}
+
 // GetMetrics retrieves all Prometheus metrics from the Armada server.
 // It calls the GetMetrics method of the Metrics gRPC service.
 //
@@ -598,11 +1014,11 @@ func incrementLastByte(s string) string {
 //   - An error if the request fails.
 func (c *Client) GetMetrics(ctx context.Context, format string) (*MetricsData, error) {
 	c.logger.Info("Getting metrics from Armada server",
-		zap.String("address", c.address),
+		zap.String("address", c.Address()),
 		zap.String("format", format))
 
 	// Get connection from pool
-	serverConn, err := c.connectionPool.GetConnection(ctx, c.address)
+	serverConn, err := c.connectionPool.GetConnection(ctx, c.Address())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to Armada server: %w", err)
 	}
@@ -628,6 +1044,6 @@ func (c *Client) GetMetrics(ctx context.Context, format string) (*MetricsData, e
 // Returns:
 //   - An error if the connection could not be closed properly.
 func (c *Client) Close() error {
-	c.logger.Info("Closing all connections", zap.String("address", c.address))
+	c.logger.Info("Closing all connections", zap.String("address", c.Address()))
 	return c.connectionPool.Close()
 }
@@ -106,6 +106,22 @@ func (s *mockServer) Range(ctx context.Context, req *regattapb.RangeRequest) (*r
 	}, nil
 }
 
+// IterateRange implements the IterateRange method of the KVServer interface by streaming
+// the pairs of Range one at a time. The "slow" table stalls after the first pair.
+func (s *mockServer) IterateRange(req *regattapb.RangeRequest, stream grpc.ServerStreamingServer[regattapb.RangeResponse]) error {
+	resp, _ := s.Range(stream.Context(), req)
+	for _, kv := range resp.Kvs {
+		if err := stream.Send(&regattapb.RangeResponse{Header: resp.Header, Kvs: []*regattapb.KeyValue{kv}}); err != nil {
+			return err
+		}
+		if string(req.Table) == "slow" {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}
+	}
+	return nil
+}
+
 // Put implements the Put method of the KVServer interface
 func (s *mockServer) Put(ctx context.Context, req *regattapb.PutRequest) (*regattapb.PutResponse, error) {
 	// Return a mock put response
@@ -120,6 +136,18 @@ func (s *mockServer) Put(ctx context.Context, req *regattapb.PutRequest) (*regat
 	}, nil
 }
 
+// Txn implements the Txn method of the KVServer interface for a single comparison with the pairs
+// of Range, a comparison of a missing key fails and one without a value checks that the key exists
+func (s *mockServer) Txn(ctx context.Context, req *regattapb.TxnRequest) (*regattapb.TxnResponse, error) {
+	values := map[string]string{"key1": "value1", "key2": "value2"}
+	compare := req.Compare[0]
+	value, ok := values[string(compare.Key)]
+	if ok && compare.TargetUnion != nil {
+		ok = value == string(compare.GetValue())
+	}
+	return &regattapb.TxnResponse{Succeeded: ok}, nil
+}
+
 // DeleteRange implements the DeleteRange method of the KVServer interface
 func (s *mockServer) DeleteRange(ctx context.Context, req *regattapb.DeleteRangeRequest) (*regattapb.DeleteRangeResponse, error) {
 	// Return a mock delete range response
@@ -180,6 +208,36 @@ func (m *mockConnectionPool) GetKnownAddresses() []string {
 	return args.Get(0).([]string)
 }
 
+func (m *mockConnectionPool) GetKnownServers() []ServerInfo {
+	args := m.Called()
+	return args.Get(0).([]ServerInfo)
+}
+
+func (m *mockConnectionPool) HealthyConnections() int {
+	args := m.Called()
+	return args.Int(0)
+}
+
+func (m *mockConnectionPool) InitializeConnections(ctx context.Context, serverAddresses []string) map[string]error {
+	args := m.Called(ctx, serverAddresses)
+	return args.Get(0).(map[string]error)
+}
+
+func (m *mockConnectionPool) Reconnect(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockConnectionPool) Evict(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockConnectionPool) EvictAddress(address string) error {
+	args := m.Called(address)
+	return args.Error(0)
+}
+
 func (m *mockConnectionPool) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -351,6 +409,30 @@ func TestGetKeyValuePairs(t *testing.T) {
 	assert.Equal(t, "value2", pairs[1].Value, "Second value should be 'value2'")
 }
 
+// TestGetKeyValuePairsTruncated tests that GetKeyValuePairs returns the pairs received before its deadline
+func TestGetKeyValuePairsTruncated(t *testing.T) {
+	client, cleanup := setupTest(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	pairs, err := client.GetKeyValuePairs(ctx, "slow", "key", "", "", 10)
+
+	assert.ErrorIs(t, err, ErrRangeTruncated)
+	assert.Equal(t, []KeyValuePair{{Key: "key1", Value: "value1"}}, pairs)
+}
+
+// TestEstimateKeyValuePairs tests the EstimateKeyValuePairs method
+func TestEstimateKeyValuePairs(t *testing.T) {
+	client, cleanup := setupTest(t)
+	defer cleanup()
+
+	estimate, err := client.EstimateKeyValuePairs(context.Background(), "test_table", "key", "", "")
+
+	assert.NoError(t, err, "EstimateKeyValuePairs should not return an error")
+	assert.Equal(t, &RangeEstimate{Keys: 2, Bytes: 20}, estimate)
+}
+
 // TestGetKeyValue tests the GetKeyValue method
 func TestGetKeyValue(t *testing.T) {
 	// Set up the test
@@ -381,6 +463,46 @@ func TestPutKeyValue(t *testing.T) {
 	assert.NoError(t, err, "PutKeyValue should not return an error")
 }
 
+// TestPutKeyValueIf tests the PutKeyValueIf method
+func TestPutKeyValueIf(t *testing.T) {
+	client, cleanup := setupTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	current := "value1"
+	stored, err := client.PutKeyValueIf(ctx, "test_table", "key1", "updated", &current)
+	assert.NoError(t, err)
+	assert.True(t, stored, "the key holds the value")
+
+	current = "stale"
+	stored, err = client.PutKeyValueIf(ctx, "test_table", "key1", "updated", &current)
+	assert.NoError(t, err)
+	assert.False(t, stored, "the key holds another value")
+
+	stored, err = client.PutKeyValueIf(ctx, "test_table", "key3", "created", nil)
+	assert.NoError(t, err)
+	assert.True(t, stored, "the key doesn't exist")
+
+	stored, err = client.PutKeyValueIf(ctx, "test_table", "key1", "created", nil)
+	assert.NoError(t, err)
+	assert.False(t, stored, "the key exists")
+}
+
+// TestDeleteKeyIf tests the DeleteKeyIf method
+func TestDeleteKeyIf(t *testing.T) {
+	client, cleanup := setupTest(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	deleted, err := client.DeleteKeyIf(ctx, "test_table", "key1", "value1")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = client.DeleteKeyIf(ctx, "test_table", "key1", "stale")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
+
 // TestDeleteKey tests the DeleteKey method
 func TestDeleteKey(t *testing.T) {
 	// Set up the test
@@ -395,6 +517,32 @@ func TestDeleteKey(t *testing.T) {
 	assert.NoError(t, err, "DeleteKey should not return an error")
 }
 
+// TestDeletePrefix tests the DeletePrefix method
+func TestDeletePrefix(t *testing.T) {
+	// Set up the test
+	client, cleanup := setupTest(t)
+	defer cleanup()
+
+	// Call the method
+	ctx := context.Background()
+	deleted, err := client.DeletePrefix(ctx, "test_table", "key")
+
+	// Check the results using testify/assert
+	assert.NoError(t, err, "DeletePrefix should not return an error")
+	assert.Equal(t, int64(1), deleted, "DeletePrefix should return the number of deleted keys")
+
+	// An empty prefix would delete the whole table
+	_, err = client.DeletePrefix(ctx, "test_table", "")
+	assert.Error(t, err, "DeletePrefix should reject an empty prefix")
+}
+
+// TestPrefixRangeEnd tests the computation of the end of a prefix range
+func TestPrefixRangeEnd(t *testing.T) {
+	assert.Equal(t, []byte("kez"), prefixRangeEnd("key"))
+	assert.Equal(t, []byte("l"), prefixRangeEnd("k\xff"))
+	assert.Equal(t, []byte{0x00}, prefixRangeEnd("\xff\xff"))
+}
+
 // TestCreateTable tests the CreateTable method
 func TestCreateTable(t *testing.T) {
 	// Set up the test
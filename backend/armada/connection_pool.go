@@ -1,10 +1,13 @@
 package armada
 
 import (
+	"cmp"
 	"context"
 	"crypto/tls"
 	"fmt"
+	"math"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -12,10 +15,15 @@ import (
 	"google.golang.org/grpc/connectivity"
 
 	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/armadakv/console/backend/events"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/stats"
 )
 
 // ConnectionPoolInterface defines the interface for a connection pool
@@ -27,6 +35,24 @@ type ConnectionPoolInterface interface {
 	// GetKnownAddresses returns a list of all known server addresses
 	GetKnownAddresses() []string
 
+	// GetKnownServers returns the servers of the pool with the state of their connections
+	GetKnownServers() []ServerInfo
+
+	// HealthyConnections returns the number of unique connections that are ready or idle
+	HealthyConnections() int
+
+	// Reconnect replaces the connection to a server, by ID, with a new one
+	Reconnect(ctx context.Context, id string) error
+
+	// Evict closes the connection to a server, by ID, and forgets its addresses
+	Evict(id string) error
+
+	// EvictAddress closes the connection to the server at an address and forgets its addresses
+	EvictAddress(address string) error
+
+	// InitializeConnections eagerly establishes connections to the given server addresses
+	InitializeConnections(ctx context.Context, serverAddresses []string) map[string]error
+
 	// Close closes all connections in the pool
 	Close() error
 }
@@ -35,12 +61,42 @@ type ConnectionPoolInterface interface {
 type reconnectConfig struct {
 	// maxRetries is the maximum number of reconnection attempts before giving up
 	maxRetries int
-	// baseDelay is the base delay for exponential backoff in milliseconds
+	// baseDelay is the delay after the first failed attempt, it grows by backoffMultiplier after every further one
 	baseDelay time.Duration
-	// maxDelay is the maximum delay between reconnection attempts in milliseconds
+	// maxDelay is the maximum delay between reconnection attempts
 	maxDelay time.Duration
+	// jitter randomizes every delay by up to this fraction in either direction, so connections
+	// lost together don't retry together; 0 disables it
+	jitter float64
 }
 
+// backoffMultiplier is the factor the delay between reconnection attempts grows by, as in gRPC
+const backoffMultiplier = 1.6
+
+// delay returns the delay before the next attempt after the given number of failed attempts
+func (c reconnectConfig) delay(failures int) time.Duration {
+	d := float64(c.baseDelay) * math.Pow(backoffMultiplier, float64(failures-1))
+	d = min(d, float64(c.maxDelay))
+	d *= 1 + c.jitter*(rand.Float64()*2-1)
+	return time.Duration(d)
+}
+
+// connectParams returns the backoff gRPC follows when a connection of the pool redials its server
+func (c reconnectConfig) connectParams() grpc.ConnectParams {
+	return grpc.ConnectParams{
+		Backoff: backoff.Config{
+			BaseDelay:  c.baseDelay,
+			Multiplier: backoffMultiplier,
+			Jitter:     c.jitter,
+			MaxDelay:   c.maxDelay,
+		},
+		MinConnectTimeout: minConnectTimeout,
+	}
+}
+
+// minConnectTimeout is how long gRPC waits for a connection attempt to complete, as by default
+const minConnectTimeout = 20 * time.Second
+
 // ConnectionPool manages a pool of gRPC connections to Armada servers
 type ConnectionPool struct {
 	// logger is the structured logger for logging
@@ -59,6 +115,54 @@ type ConnectionPool struct {
 
 	// reconnectCfg holds configuration for reconnection attempts
 	reconnectCfg reconnectConfig
+
+	// keepalive pings idle connections so dead links are detected, a zero Time disables the pings
+	keepalive keepalive.ClientParameters
+
+	// maxRecvMsgSize and maxSendMsgSize limit the messages of calls in bytes, 0 keeps the gRPC defaults
+	maxRecvMsgSize int
+	maxSendMsgSize int
+
+	// connectTimeout is how long initial connections may take to become ready, 0 doesn't wait for them
+	connectTimeout time.Duration
+
+	// unaryInterceptors and streamInterceptors intercept every call, the first is the outermost
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+
+	// breakers fail calls to servers that keep failing right away, nil if there is no circuit breaker
+	breakers *circuitBreakers
+
+	// statsHandler traces the gRPC calls made over the connections
+	statsHandler stats.Handler
+
+	// tlsConfig is used for https addresses, e.g. to present a client certificate; nil uses the defaults
+	tlsConfig *tls.Config
+
+	// perRPCCredentials authenticate every call, e.g. with a bearer token; it may be nil
+	perRPCCredentials credentials.PerRPCCredentials
+
+	// readVerifier is handed to the Client created with the pool, see WithReadVerifier
+	readVerifier *ReadVerifier
+
+	// healthInterval is how often the servers are probed, 0 disables the health checks
+	healthInterval time.Duration
+
+	// rediscoveryInterval is how often the cluster members are listed again, 0 disables it
+	rediscoveryInterval time.Duration
+
+	// publisher is told about members that left the cluster; it may be nil
+	publisher events.Publisher
+
+	// probeErrors holds the error of the last failed probe of unhealthy connections
+	probeErrors map[*ServerConnection]error
+
+	// history counts the reconnects and keeps the last error by address
+	history map[string]*connectionHistory
+
+	// done stops the health checks and the rediscovery when the pool is closed
+	done     chan struct{}
+	stopOnce sync.Once
 }
 
 // ServerConnection holds a gRPC connection and its associated clients
@@ -93,11 +197,31 @@ type NodeInfo struct {
 
 // ServerInfo holds information about a server
 type ServerInfo struct {
-	ID              string
-	Name            string
-	Addresses       []string
-	PrimaryAddress  string
-	ConnectionState string
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Addresses       []string `json:"addresses"`
+	PrimaryAddress  string   `json:"primaryAddress"`
+	ConnectionState string   `json:"connectionState"`
+	// ProbeError is the error of the last failed health check, empty if the server answered it
+	ProbeError string `json:"probeError,omitempty"`
+	// Reconnects counts the connections made to the server after the first one, over all its addresses
+	Reconnects int `json:"reconnects"`
+	// LastError is the last error connecting to or discovering through the server, empty if there was none
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorAt is when LastError happened
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	// Circuit is the state of the circuit breaker of the server: closed, open or half-open, the
+	// worst of its addresses. It is empty without a circuit breaker.
+	Circuit string `json:"circuit,omitempty"`
+	// CircuitOpenUntil is when an open circuit lets a trial call through
+	CircuitOpenUntil *time.Time `json:"circuitOpenUntil,omitempty"`
+}
+
+// connectionHistory counts the reconnects to an address and keeps its last error
+type connectionHistory struct {
+	reconnects  int
+	lastError   string
+	lastErrorAt time.Time
 }
 
 // NewConnectionPool creates a new connection pool with default reconnect configuration
@@ -106,63 +230,94 @@ func NewConnectionPool(logger *zap.Logger) *ConnectionPool {
 		logger:              logger,
 		addressToConnection: make(map[string]*ServerConnection),
 		idToConnection:      make(map[string]*ServerConnection),
+		probeErrors:         make(map[*ServerConnection]error),
+		history:             make(map[string]*connectionHistory),
+		done:                make(chan struct{}),
 		reconnectCfg: reconnectConfig{
 			maxRetries: 5,
 			baseDelay:  500 * time.Millisecond,
 			maxDelay:   30 * time.Second,
+			jitter:     0.2,
 		},
+		statsHandler: otelgrpc.NewClientHandler(),
 	}
 
 	return pool
 }
 
+// dialOptions returns the options of new connections of the pool.
+// Calls are traced as children of the request that made them when tracing is set up,
+// and carry the credentials of the pool if it has any. Lost connections are redialed with
+// the backoff of the reconnect policy and, with keepalive set, pinged to detect dead links.
+func (p *ConnectionPool) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithStatsHandler(p.statsHandler),
+		grpc.WithConnectParams(p.reconnectCfg.connectParams()),
+	}
+	if p.keepalive.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(p.keepalive))
+	}
+	var callOpts []grpc.CallOption
+	if p.maxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(p.maxRecvMsgSize))
+	}
+	if p.maxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(p.maxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+	if p.perRPCCredentials != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(p.perRPCCredentials))
+	}
+	unary := p.unaryInterceptors
+	if p.breakers != nil {
+		// Calls failed by retries count once, and failing fast isn't retried
+		unary = append([]grpc.UnaryClientInterceptor{p.breakers.interceptor()}, unary...)
+	}
+	if len(unary) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(unary...))
+	}
+	if len(p.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(p.streamInterceptors...))
+	}
+	return opts
+}
+
 // createGRPCConnection creates a new gRPC connection to the specified address.
 // It handles the protocol detection and appropriate credential setup.
 //
 // Parameters:
 //   - serverAddress: The address of the server to connect to.
 //   - logger: The logger for logging connection actions.
+//   - tlsConfig: The TLS settings of https addresses, nil for the system roots and no client certificate.
+//   - opts: Additional dial options, e.g. the stats handler tracing the calls.
 //
 // Returns:
 //   - A gRPC connection to the server.
 //   - An error if the connection could not be established.
-func createGRPCConnection(_ context.Context, serverAddress string, logger *zap.Logger) (*grpc.ClientConn, error) {
+func createGRPCConnection(_ context.Context, serverAddress string, logger *zap.Logger, tlsConfig *tls.Config, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	var creds credentials.TransportCredentials
-	var dialAddress string
 
 	// Check if address begins with http or https
 	if strings.HasPrefix(serverAddress, "https://") {
 		// Use TLS for https
-		creds = credentials.NewTLS(&tls.Config{})
-		// Remove https:// prefix
-		dialAddress = strings.TrimPrefix(serverAddress, "https://")
-	} else if strings.HasPrefix(serverAddress, "http://") {
-		// Use insecure connection for http
-		creds = insecure.NewCredentials()
-		// Remove http:// prefix
-		dialAddress = strings.TrimPrefix(serverAddress, "http://")
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		creds = credentials.NewTLS(tlsConfig)
 	} else {
-		// Default to insecure if no protocol specified
+		// Use insecure connection for http, and by default if no protocol is specified
 		creds = insecure.NewCredentials()
-		dialAddress = serverAddress
-	}
-
-	// Check if we need to apply a schema - only apply dns:/// if not an IP address and no port is specified
-	var target string
-	if strings.Contains(dialAddress, ":") || strings.Contains(dialAddress, ".") {
-		// If it contains a colon (port) or periods (likely an IP), use as is
-		target = dialAddress
-	} else {
-		// For hostnames without port, use dns resolver
-		target = "dns:///" + dialAddress
 	}
+	target := dialTarget(serverAddress)
 
 	logger.Info("Dialing Armada server",
 		zap.String("address", serverAddress),
 		zap.String("target", target))
 
 	// Using NewClient which is the correct approach for this project
-	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	conn, err := grpc.NewClient(target, append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, opts...)...)
 	if err != nil {
 		logger.Error("Failed to connect to Armada server", zap.Error(err))
 		return nil, err
@@ -170,6 +325,16 @@ func createGRPCConnection(_ context.Context, serverAddress string, logger *zap.L
 	return conn, nil
 }
 
+// dialTarget returns the gRPC target an address is dialed at, the scheme of the address selects the credentials
+func dialTarget(serverAddress string) string {
+	dialAddress := strings.TrimPrefix(strings.TrimPrefix(serverAddress, "https://"), "http://")
+	// Only apply dns:/// if not an IP address and no port is specified
+	if strings.Contains(dialAddress, ":") || strings.Contains(dialAddress, ".") {
+		return dialAddress
+	}
+	return "dns:///" + dialAddress
+}
+
 // fetchNodeInfo fetches node information for a given server connection
 func (p *ConnectionPool) fetchNodeInfo(ctx context.Context, serverConn *ServerConnection, serverAddress string) (*NodeInfo, error) {
 	p.logger.Debug("Fetching node information", zap.String("address", serverAddress))
@@ -286,9 +451,15 @@ func (p *ConnectionPool) getHealthyConnectionLocked(serverAddress string) *Serve
 // createNewConnection creates a new connection to the server
 // The caller must hold the connection lock before calling this method
 func (p *ConnectionPool) createNewConnection(ctx context.Context, serverAddress string) (*ServerConnection, error) {
+	// A connection replacing an unhealthy one is a reconnect
+	if _, exists := p.addressToConnection[serverAddress]; exists {
+		p.recordReconnect(serverAddress)
+	}
+
 	// Create a new gRPC connection
-	conn, err := createGRPCConnection(ctx, serverAddress, p.logger)
+	conn, err := createGRPCConnection(ctx, serverAddress, p.logger, p.tlsConfig, p.dialOptions()...)
 	if err != nil {
+		p.recordError(serverAddress, err)
 		return nil, fmt.Errorf("failed to create connection to %s: %w", serverAddress, err)
 	}
 
@@ -301,6 +472,7 @@ func (p *ConnectionPool) createNewConnection(ctx context.Context, serverAddress
 		p.logger.Warn("Failed to fetch node information, continuing with connection",
 			zap.String("address", serverAddress),
 			zap.Error(err))
+		p.recordError(serverAddress, err)
 	} else {
 		// Add node info to the connection
 		newServerConn.NodeID = nodeInfo.NodeID
@@ -325,6 +497,34 @@ func (p *ConnectionPool) createNewConnection(ctx context.Context, serverAddress
 	return newServerConn, nil
 }
 
+// awaitReady waits until the connection to an address is ready, for at most the connect timeout.
+// gRPC connects lazily, so without a connect timeout it returns right away and an unreachable
+// server is only noticed by the first call.
+func (p *ConnectionPool) awaitReady(ctx context.Context, address string, serverConn *ServerConnection) error {
+	if p.connectTimeout <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.connectTimeout)
+	defer cancel()
+
+	conn := serverConn.conn
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return fmt.Errorf("connection to %s was closed", address)
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			err := fmt.Errorf("%w: %s not ready within %s, last state %s", ErrConnectTimeout, address, p.connectTimeout, state)
+			p.recordError(address, err)
+			return err
+		}
+	}
+}
+
 // handleExistingNodeConnection handles the case where we already have a connection to the same node
 // Returns true if an existing connection is reused, false if we should continue with the new one
 func (p *ConnectionPool) handleExistingNodeConnection(serverAddress string, nodeID string, newConn *ServerConnection, newGRPCConn *grpc.ClientConn) bool {
@@ -361,6 +561,7 @@ func (p *ConnectionPool) handleExistingNodeConnection(serverAddress string, node
 		for addr, conn := range p.addressToConnection {
 			if conn == existingConn {
 				p.addressToConnection[addr] = newConn
+				p.recordReconnect(addr)
 				p.logger.Debug("Updated address mapping to use new connection",
 					zap.String("address", addr),
 					zap.String("serverID", nodeID))
@@ -404,13 +605,53 @@ func (p *ConnectionPool) GetConnection(ctx context.Context, serverAddress string
 	return p.createNewConnection(ctx, serverAddress)
 }
 
-// discoverClusterMembers discovers additional cluster members using a seed address
-func (p *ConnectionPool) discoverClusterMembers(ctx context.Context, seedAddress string, serverConn *ServerConnection) {
+// startRediscovery begins listing the cluster members periodically until the pool is closed
+func (p *ConnectionPool) startRediscovery() {
+	if p.rediscoveryInterval <= 0 {
+		return
+	}
+	go p.runRediscovery()
+}
+
+// runRediscovery asks the connected servers for the cluster members at every interval until the
+// pool is closed, so members that joined since are connected to without a new connection
+func (p *ConnectionPool) runRediscovery() {
+	ticker := time.NewTicker(p.rediscoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.rediscover(context.Background())
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// rediscover discovers the cluster members from the first connected server that lists them
+func (p *ConnectionPool) rediscover(ctx context.Context) {
+	targets := p.probeTargets()
+	addresses := make([]string, 0, len(targets))
+	for address := range targets {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		if err := p.discoverClusterMembers(ctx, address, targets[address]); err == nil {
+			return
+		}
+	}
+}
+
+// discoverClusterMembers discovers additional cluster members using a seed address.
+// It returns an error if the seed didn't list the members.
+func (p *ConnectionPool) discoverClusterMembers(ctx context.Context, seedAddress string, serverConn *ServerConnection) error {
 	// Create a new context with timeout for discovery
 	discCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	p.logger.Info("Attempting to discover additional cluster members",
+	p.logger.Debug("Attempting to discover additional cluster members",
 		zap.String("seedAddress", seedAddress))
 
 	// Get cluster membership information using this server as seed
@@ -419,8 +660,12 @@ func (p *ConnectionPool) discoverClusterMembers(ctx context.Context, seedAddress
 		p.logger.Warn("Failed to discover cluster members from address",
 			zap.String("address", seedAddress),
 			zap.Error(err))
-		return
+		p.connectionLock.Lock()
+		p.recordError(seedAddress, err)
+		p.connectionLock.Unlock()
+		return err
 	}
+	p.evictDepartedMembers(resp.GetMembers())
 
 	// Extract all client URLs from the member list
 	newAddresses := make([]string, 0)
@@ -474,91 +719,138 @@ func (p *ConnectionPool) discoverClusterMembers(ctx context.Context, seedAddress
 		p.logger.Debug("No new cluster members discovered",
 			zap.String("seedAddress", seedAddress))
 	}
+	return nil
 }
 
-// reconnectServer attempts to reconnect to a server with exponential backoff.
-//
-// Parameters:
-//   - ctx: The context for the operation.
-//   - serverAddress: The address of the server to reconnect to.
-//   - currentConn: The current connection that's in a bad state.
-//
-// Returns:
-//   - The new gRPC connection if successful.
-//   - An error if reconnection fails after all attempts.
-func (p *ConnectionPool) reconnectServer(ctx context.Context, serverAddress string, currentConn *grpc.ClientConn) (*grpc.ClientConn, error) {
-	p.logger.Warn("Connection to server is not healthy, attempting to reconnect",
-		zap.String("address", serverAddress),
-		zap.String("state", currentConn.GetState().String()))
+// RemovedMember is a node whose connection was closed because it left the cluster
+type RemovedMember struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Addresses are the addresses the console knew the node by
+	Addresses []string `json:"addresses"`
+}
 
-	// Close existing connection
-	if currentConn != nil {
-		if err := currentConn.Close(); err != nil {
-			p.logger.Warn("Error closing existing server connection",
-				zap.String("address", serverAddress),
-				zap.Error(err))
-			// Continue with reconnection even if close fails
+// evictDepartedMembers closes the connections of nodes that aren't members of the cluster anymore,
+// so they are no longer used or scraped. Connections to nodes of unknown ID are kept, and an empty
+// member list is ignored rather than taken for every node leaving.
+func (p *ConnectionPool) evictDepartedMembers(members []*regattapb.Member) {
+	if len(members) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(members))
+	for _, member := range members {
+		current[member.GetId()] = true
+	}
+
+	p.connectionLock.Lock()
+	departed := make(map[*ServerConnection][]string)
+	for address, conn := range p.addressToConnection {
+		if conn != nil && conn.NodeID != "" && !current[conn.NodeID] {
+			departed[conn] = append(departed[conn], address)
+			delete(p.addressToConnection, address)
+			delete(p.history, address)
+		}
+	}
+	for id, conn := range p.idToConnection {
+		if !current[id] {
+			if _, ok := departed[conn]; !ok {
+				departed[conn] = nil
+			}
+			delete(p.idToConnection, id)
 		}
 	}
+	for conn := range departed {
+		delete(p.probeErrors, conn)
+	}
+	p.connectionLock.Unlock()
 
-	// Reconnect with exponential backoff
-	var lastError error
-	currentDelay := p.reconnectCfg.baseDelay
+	for conn, addresses := range departed {
+		sort.Strings(addresses)
+		if err := conn.conn.Close(); err != nil {
+			p.logger.Debug("Failed to close connection of departed member", zap.String("nodeID", conn.NodeID), zap.Error(err))
+		}
+		p.logger.Info("Cluster member left, closed its connection",
+			zap.String("nodeID", conn.NodeID),
+			zap.String("nodeName", conn.NodeName),
+			zap.Strings("addresses", addresses))
+		if p.publisher != nil {
+			p.publisher.Publish(events.Event{
+				Type: events.TypeMemberRemoved,
+				Time: time.Now(),
+				Data: RemovedMember{ID: conn.NodeID, Name: conn.NodeName, Addresses: addresses},
+			})
+		}
+	}
+}
 
-	for attempt := 0; attempt < p.reconnectCfg.maxRetries; attempt++ {
-		p.logger.Info("Server reconnection attempt",
-			zap.String("address", serverAddress),
-			zap.Int("attempt", attempt+1),
-			zap.Int("maxAttempts", p.reconnectCfg.maxRetries),
-			zap.Duration("delay", currentDelay))
+// historyOf returns the history of an address. The caller must hold the connection lock.
+func (p *ConnectionPool) historyOf(address string) *connectionHistory {
+	h, ok := p.history[address]
+	if !ok {
+		h = &connectionHistory{}
+		p.history[address] = h
+	}
+	return h
+}
 
+// recordReconnect counts a reconnect to an address. The caller must hold the connection lock.
+func (p *ConnectionPool) recordReconnect(address string) {
+	p.historyOf(address).reconnects++
+}
+
+// recordError keeps the last error of an address. The caller must hold the connection lock.
+func (p *ConnectionPool) recordError(address string, err error) {
+	h := p.historyOf(address)
+	h.lastError = err.Error()
+	h.lastErrorAt = time.Now()
+}
+
+// reconnectServer dials a server again until it answers, waiting longer after every failed
+// attempt, and gives up after the maximum number of attempts. The new connection is to the
+// node of the old one, which is left to the caller.
+func (p *ConnectionPool) reconnectServer(ctx context.Context, serverAddress string, old *ServerConnection) (*ServerConnection, error) {
+	var lastError error
+	for attempt := 1; attempt <= p.reconnectCfg.maxRetries; attempt++ {
 		// Wait before attempting reconnection (except on first attempt)
-		if attempt > 0 {
+		if attempt > 1 {
+			delay := p.reconnectCfg.delay(attempt - 1)
+			p.logger.Debug("Waiting before the next reconnection attempt",
+				zap.String("address", serverAddress),
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay))
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
-			case <-time.After(currentDelay):
-				// Continue with reconnection attempt
+			case <-time.After(delay):
 			}
 		}
 
-		// Try to establish a new connection
-		newConn, err := createGRPCConnection(ctx, serverAddress, p.logger)
-		if err != nil {
-			lastError = err
-			p.logger.Warn("Server reconnection attempt failed",
-				zap.String("address", serverAddress),
-				zap.Int("attempt", attempt+1),
-				zap.Error(err))
-
-			// Exponential backoff with jitter
-			jitter := time.Duration(rand.Int63n(int64(currentDelay) / 2))
-			currentDelay = time.Duration(float64(currentDelay) * 1.5)
-			currentDelay += jitter
-
-			// Cap at max delay
-			if currentDelay > p.reconnectCfg.maxDelay {
-				currentDelay = p.reconnectCfg.maxDelay
+		grpcConn, err := createGRPCConnection(ctx, serverAddress, p.logger, p.tlsConfig, p.dialOptions()...)
+		if err == nil {
+			conn := createServerConnection(grpcConn)
+			conn.NodeID = old.NodeID
+			conn.NodeName = old.NodeName
+			if err = p.probe(ctx, conn); err == nil {
+				return conn, nil
 			}
-
-			continue
+			_ = grpcConn.Close()
 		}
-
-		// Reconnection successful
-		p.logger.Info("Successfully reconnected to server", zap.String("address", serverAddress))
-		return newConn, nil
+		lastError = err
+		p.logger.Warn("Server reconnection attempt failed",
+			zap.String("address", serverAddress),
+			zap.Int("attempt", attempt),
+			zap.Int("maxAttempts", p.reconnectCfg.maxRetries),
+			zap.Error(err))
 	}
-
-	p.logger.Error("Failed to reconnect to server after maximum attempts",
-		zap.String("address", serverAddress),
-		zap.Int("maxRetries", p.reconnectCfg.maxRetries),
-		zap.Error(lastError))
 	return nil, fmt.Errorf("failed to reconnect to server %s after %d attempts: %w",
 		serverAddress, p.reconnectCfg.maxRetries, lastError)
 }
 
-// Close closes all connections in the pool
+// Close stops the background loops and closes all connections in the pool
 func (p *ConnectionPool) Close() error {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
 	p.connectionLock.Lock()
 	defer p.connectionLock.Unlock()
 
@@ -593,10 +885,27 @@ func (p *ConnectionPool) Close() error {
 	// Clear both maps
 	p.addressToConnection = make(map[string]*ServerConnection)
 	p.idToConnection = make(map[string]*ServerConnection)
+	p.probeErrors = make(map[*ServerConnection]error)
+	p.history = make(map[string]*connectionHistory)
 
 	return lastErr
 }
 
+// HealthyConnections returns the number of unique connections in the pool that are ready or idle
+// and didn't fail their last health check. The console can't serve cluster data if there are none.
+func (p *ConnectionPool) HealthyConnections() int {
+	p.connectionLock.RLock()
+	defer p.connectionLock.RUnlock()
+
+	healthy := make(map[*grpc.ClientConn]bool)
+	for _, serverConn := range p.addressToConnection {
+		if serverConn != nil && isConnectionHealthy(serverConn.conn) && p.probeErrors[serverConn] == nil {
+			healthy[serverConn.conn] = true
+		}
+	}
+	return len(healthy)
+}
+
 // GetKnownAddresses returns a list of all known server addresses in the connection pool.
 // This is useful for discovering all clusters to collect metrics from.
 func (p *ConnectionPool) GetKnownAddresses() []string {
@@ -661,18 +970,124 @@ func (p *ConnectionPool) GetKnownServers() []ServerInfo {
 		for _, address := range addresses {
 			if conn := p.addressToConnection[address]; conn != nil && conn.conn != nil {
 				info.ConnectionState = conn.conn.GetState().String()
+				if err := p.probeErrors[conn]; err != nil {
+					info.ProbeError = err.Error()
+				}
 				break
 			}
 		}
 
+		// Sum up the history of all addresses, keeping the latest error
+		for _, address := range addresses {
+			h := p.history[address]
+			if h == nil {
+				continue
+			}
+			info.Reconnects += h.reconnects
+			if h.lastError != "" && (info.LastErrorAt == nil || h.lastErrorAt.After(*info.LastErrorAt)) {
+				at := h.lastErrorAt
+				info.LastError, info.LastErrorAt = h.lastError, &at
+			}
+		}
+		if p.breakers != nil {
+			info.Circuit, info.CircuitOpenUntil = p.breakers.describe(addresses)
+		}
+
 		servers = append(servers, info)
 	}
 
 	return servers
 }
 
+// serverConnection returns the connection to a server and the addresses pointing to it. Servers
+// are identified by their node ID, or by their address if the ID isn't known, as in GetKnownServers.
+// The caller must hold the connection lock.
+func (p *ConnectionPool) serverConnection(id string) (*ServerConnection, []string) {
+	var found *ServerConnection
+	var addresses []string
+	for address, conn := range p.addressToConnection {
+		if conn != nil && (conn.NodeID == id || conn.NodeID == "" && address == id) {
+			found = conn
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return found, addresses
+}
+
+// Reconnect dials a server again and, once it answers, replaces its connection by the new one,
+// e.g. to pick up rotated certificates. Failed attempts are retried with the reconnect policy,
+// the old connection is kept if the server can't be reached.
+func (p *ConnectionPool) Reconnect(ctx context.Context, id string) error {
+	p.connectionLock.RLock()
+	old, addresses := p.serverConnection(id)
+	p.connectionLock.RUnlock()
+	if old == nil {
+		return fmt.Errorf("%w: %s", ErrServerNotFound, id)
+	}
+
+	address := addresses[0]
+	conn, err := p.reconnectServer(ctx, address, old)
+	if err != nil {
+		p.connectionLock.Lock()
+		p.recordError(address, err)
+		p.connectionLock.Unlock()
+		return err
+	}
+
+	if !p.swapConnection(address, old, conn) {
+		return fmt.Errorf("%w: %s", ErrServerNotFound, id)
+	}
+	p.logger.Info("Reconnected to Armada server on request", zap.String("address", address), zap.String("nodeID", conn.NodeID))
+	return nil
+}
+
+// Evict closes the connection to a server and forgets its addresses and history. The server is
+// connected to again the next time one of its addresses is used or it is discovered as a member.
+func (p *ConnectionPool) Evict(id string) error {
+	p.connectionLock.Lock()
+	conn, addresses := p.serverConnection(id)
+	if conn == nil {
+		p.connectionLock.Unlock()
+		return fmt.Errorf("%w: %s", ErrServerNotFound, id)
+	}
+	for _, address := range addresses {
+		delete(p.addressToConnection, address)
+		delete(p.history, address)
+	}
+	if conn.NodeID != "" && p.idToConnection[conn.NodeID] == conn {
+		delete(p.idToConnection, conn.NodeID)
+	}
+	delete(p.probeErrors, conn)
+	p.connectionLock.Unlock()
+
+	if err := conn.conn.Close(); err != nil {
+		p.logger.Debug("Failed to close evicted connection", zap.String("nodeID", conn.NodeID), zap.Error(err))
+	}
+	p.logger.Info("Evicted connection to Armada server",
+		zap.String("nodeID", conn.NodeID),
+		zap.String("nodeName", conn.NodeName),
+		zap.Strings("addresses", addresses))
+	return nil
+}
+
+// EvictAddress closes the connection the address points to and forgets all addresses of its
+// server, e.g. of a seed that disappeared from discovery. If the server is still a member of the
+// cluster, it is connected again by the next discovery of the members.
+func (p *ConnectionPool) EvictAddress(address string) error {
+	p.connectionLock.RLock()
+	conn := p.addressToConnection[address]
+	p.connectionLock.RUnlock()
+	if conn == nil {
+		return fmt.Errorf("%w: %s", ErrServerNotFound, address)
+	}
+	return p.Evict(cmp.Or(conn.NodeID, address))
+}
+
 // InitializeConnections initializes connections to a list of server addresses.
-// This method eagerly establishes connections to the provided servers.
+// This method eagerly establishes connections to the provided servers. With a connect timeout
+// servers whose connection isn't ready in time are reported, their connections are kept and
+// keep being retried in the background.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -685,7 +1100,10 @@ func (p *ConnectionPool) InitializeConnections(ctx context.Context, serverAddres
 
 	errors := make(map[string]error)
 	for _, address := range serverAddresses {
-		_, err := p.GetConnection(ctx, address)
+		conn, err := p.GetConnection(ctx, address)
+		if err == nil {
+			err = p.awaitReady(ctx, address, conn)
+		}
 		if err != nil {
 			p.logger.Error("Failed to initialize connection to server",
 				zap.String("address", address),
@@ -3,15 +3,23 @@ package armada
 import (
 	"context"
 	"net"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/armadakv/console/backend/events"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 )
 
@@ -95,6 +103,7 @@ func TestNewConnectionPool(t *testing.T) {
 	assert.Equal(t, 5, pool.reconnectCfg.maxRetries)
 	assert.Equal(t, 500*time.Millisecond, pool.reconnectCfg.baseDelay)
 	assert.Equal(t, 30*time.Second, pool.reconnectCfg.maxDelay)
+	assert.InDelta(t, 0.2, pool.reconnectCfg.jitter, 0)
 }
 
 func TestCreateGRPCConnection(t *testing.T) {
@@ -125,7 +134,7 @@ func TestCreateGRPCConnection(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			conn, err := createGRPCConnection(ctx, tt.address, logger)
+			conn, err := createGRPCConnection(ctx, tt.address, logger, nil)
 			if tt.expectError {
 				// We expect an error since there's no actual server
 				// But we're testing the function logic, not actual connectivity
@@ -232,6 +241,33 @@ func TestConnectionPoolGetKnownAddresses(t *testing.T) {
 	_ = server
 }
 
+func TestConnectionPoolHealthyConnections(t *testing.T) {
+	pool, _, lis, cleanup := setupPoolTest(t)
+	defer cleanup()
+
+	assert.Zero(t, pool.HealthyConnections())
+
+	// Two addresses of the same server count once
+	conn := createTestConnection(t, lis)
+	serverConn := createServerConnection(conn)
+	// The dial doesn't block, a connection still connecting isn't healthy yet
+	conn.Connect()
+	require.Eventually(t, func() bool {
+		return conn.GetState() == connectivity.Ready
+	}, 5*time.Second, 10*time.Millisecond)
+	closed := createTestConnection(t, lis)
+	assert.NoError(t, closed.Close())
+	pool.connectionLock.Lock()
+	pool.addressToConnection["addr1"] = serverConn
+	pool.addressToConnection["addr2"] = serverConn
+	pool.addressToConnection["addr3"] = createServerConnection(closed)
+	pool.connectionLock.Unlock()
+	assert.Equal(t, 1, pool.HealthyConnections())
+
+	assert.NoError(t, conn.Close())
+	assert.Zero(t, pool.HealthyConnections())
+}
+
 func TestConnectionPoolClose(t *testing.T) {
 	pool, server, lis, cleanup := setupPoolTest(t)
 	defer cleanup()
@@ -325,6 +361,107 @@ func TestReconnectConfig(t *testing.T) {
 	assert.Equal(t, 3, config.maxRetries)
 	assert.Equal(t, 100*time.Millisecond, config.baseDelay)
 	assert.Equal(t, 10*time.Second, config.maxDelay)
+
+	// The delay grows after every failed attempt up to the maximum
+	assert.Equal(t, 100*time.Millisecond, config.delay(1))
+	assert.Equal(t, 160*time.Millisecond, config.delay(2))
+	assert.Equal(t, 10*time.Second, config.delay(20))
+
+	// and is randomized by up to the jitter in either direction
+	config.jitter = 0.5
+	for range 100 {
+		d := config.delay(1)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 150*time.Millisecond)
+	}
+
+	params := config.connectParams()
+	assert.Equal(t, 100*time.Millisecond, params.Backoff.BaseDelay)
+	assert.Equal(t, 10*time.Second, params.Backoff.MaxDelay)
+	assert.InDelta(t, 0.5, params.Backoff.Jitter, 0)
+}
+
+func TestWithReconnectPolicy(t *testing.T) {
+	pool := NewConnectionPool(zap.NewNop())
+	WithReconnectPolicy(10, time.Second, time.Minute, 0)(pool)
+	assert.Equal(t, reconnectConfig{maxRetries: 10, baseDelay: time.Second, maxDelay: time.Minute}, pool.reconnectCfg)
+
+	// Invalid values keep the previous ones
+	WithReconnectPolicy(0, 0, time.Millisecond, 2)(pool)
+	assert.Equal(t, reconnectConfig{maxRetries: 10, baseDelay: time.Second, maxDelay: time.Minute}, pool.reconnectCfg)
+}
+
+func TestWithKeepalive(t *testing.T) {
+	pool := NewConnectionPool(zap.NewNop())
+	withoutKeepalive := len(pool.dialOptions())
+
+	WithKeepalive(30*time.Second, 5*time.Second, true)(pool)
+	assert.Equal(t, keepalive.ClientParameters{Time: 30 * time.Second, Timeout: 5 * time.Second, PermitWithoutStream: true}, pool.keepalive)
+	assert.Len(t, pool.dialOptions(), withoutKeepalive+1)
+
+	// A zero interval disables the pings
+	WithKeepalive(0, 5*time.Second, false)(pool)
+	assert.Len(t, pool.dialOptions(), withoutKeepalive)
+}
+
+func TestWithMaxMessageSizes(t *testing.T) {
+	// The member list is larger than the 4MB gRPC reads by default
+	s := grpc.NewServer()
+	regattapb.RegisterClusterServer(s, &mockPoolServer{memberResponse: &regattapb.MemberListResponse{
+		Members: []*regattapb.Member{{Id: "node1", Name: strings.Repeat("n", 5<<20)}},
+	}})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	memberList := func(opts ...ClientOption) error {
+		pool := NewConnectionPool(zap.NewNop())
+		for _, opt := range opts {
+			opt(pool)
+		}
+		conn, err := createGRPCConnection(t.Context(), lis.Addr().String(), zap.NewNop(), nil, pool.dialOptions()...)
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+		_, err = regattapb.NewClusterClient(conn).MemberList(ctx, &regattapb.MemberListRequest{})
+		return err
+	}
+
+	assert.Equal(t, codes.ResourceExhausted, status.Code(memberList()))
+	assert.NoError(t, memberList(WithMaxMessageSizes(8<<20, 0)))
+}
+
+func TestWithConnectTimeout(t *testing.T) {
+	s := grpc.NewServer()
+	regattapb.RegisterClusterServer(s, &mockPoolServer{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	// Nothing listens on the address of a closed listener
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadAddress := dead.Addr().String()
+	require.NoError(t, dead.Close())
+
+	pool := NewConnectionPool(zap.NewNop())
+	WithConnectTimeout(time.Second)(pool)
+	defer pool.Close()
+
+	start := time.Now()
+	errs := pool.InitializeConnections(t.Context(), []string{lis.Addr().String(), deadAddress})
+	assert.Less(t, time.Since(start), 5*time.Second)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[deadAddress], ErrConnectTimeout)
+	// Discovery runs in the background and may replace connections meanwhile
+	pool.connectionLock.RLock()
+	live := pool.addressToConnection[lis.Addr().String()]
+	pool.connectionLock.RUnlock()
+	require.NotNil(t, live)
+	assert.Equal(t, connectivity.Ready, live.conn.GetState())
 }
 
 func TestNodeInfo(t *testing.T) {
@@ -367,3 +504,177 @@ func TestConnectionPoolInterface(t *testing.T) {
 	err := pool.Close()
 	assert.NoError(t, err)
 }
+
+// membersServer lists the members it was last given
+type membersServer struct {
+	regattapb.UnimplementedClusterServer
+	mu      sync.Mutex
+	members []*regattapb.Member
+}
+
+func (s *membersServer) MemberList(context.Context, *regattapb.MemberListRequest) (*regattapb.MemberListResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &regattapb.MemberListResponse{Members: s.members}, nil
+}
+
+func (s *membersServer) join(member *regattapb.Member) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members = append(s.members, member)
+}
+
+func (s *membersServer) leave(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members = slices.DeleteFunc(s.members, func(m *regattapb.Member) bool { return m.GetId() == id })
+}
+
+// serveMembers serves the member list of srv on a local port and returns its address
+func serveMembers(t *testing.T, srv *membersServer) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	regattapb.RegisterClusterServer(s, srv)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func TestConnectionPoolRediscover(t *testing.T) {
+	members := &membersServer{}
+	first := serveMembers(t, members)
+	second := serveMembers(t, members)
+	members.join(&regattapb.Member{Id: "node1", Name: "node1", ClientURLs: []string{first}})
+
+	pool := NewConnectionPool(zap.NewNop())
+	defer pool.Close()
+	_, err := pool.GetConnection(context.Background(), first)
+	require.NoError(t, err)
+	assert.Equal(t, []string{first}, pool.GetKnownAddresses())
+
+	// Members joining later are connected to by the next rediscovery
+	members.join(&regattapb.Member{Id: "node2", Name: "node2", ClientURLs: []string{second}})
+	pool.rediscover(context.Background())
+	assert.Eventually(t, func() bool {
+		return len(pool.GetKnownAddresses()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{first, second}, pool.GetKnownAddresses())
+}
+
+func TestConnectionPoolEvictsDepartedMembers(t *testing.T) {
+	members := &membersServer{}
+	first := serveMembers(t, members)
+	second := serveMembers(t, members)
+	members.join(&regattapb.Member{Id: "node1", Name: "node1", ClientURLs: []string{first}})
+	members.join(&regattapb.Member{Id: "node2", Name: "node2", ClientURLs: []string{second}})
+
+	hub := events.NewHub()
+	sub := hub.Subscribe(1, events.TypeMemberRemoved)
+	defer sub.Close()
+	pool := NewConnectionPool(zap.NewNop())
+	WithPublisher(hub)(pool)
+	defer pool.Close()
+	ctx := context.Background()
+	_, err := pool.GetConnection(ctx, first)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(pool.GetKnownAddresses()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	pool.connectionLock.RLock()
+	departed := pool.idToConnection["node2"]
+	pool.connectionLock.RUnlock()
+	require.NotNil(t, departed)
+
+	// The discovery through node2 may still be running and evict it first, so wait for the event
+	members.leave("node2")
+	pool.rediscover(ctx)
+	select {
+	case e := <-sub.Events():
+		assert.Equal(t, RemovedMember{ID: "node2", Name: "node2", Addresses: []string{second}}, e.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a member removed event")
+	}
+	assert.Equal(t, []string{first}, pool.GetKnownAddresses())
+	pool.connectionLock.RLock()
+	assert.NotContains(t, pool.idToConnection, "node2")
+	pool.connectionLock.RUnlock()
+	assert.Equal(t, connectivity.Shutdown, departed.conn.GetState())
+
+	// An empty member list doesn't remove every node
+	members.leave("node1")
+	pool.rediscover(ctx)
+	assert.Equal(t, []string{first}, pool.GetKnownAddresses())
+}
+
+func TestConnectionPoolReconnectAndEvict(t *testing.T) {
+	members := &membersServer{}
+	first := serveMembers(t, members)
+	second := serveMembers(t, members)
+	members.join(&regattapb.Member{Id: "node1", Name: "node1", ClientURLs: []string{first}})
+	members.join(&regattapb.Member{Id: "node2", Name: "node2", ClientURLs: []string{second}})
+
+	pool := NewConnectionPool(zap.NewNop())
+	defer pool.Close()
+	ctx := context.Background()
+	old, err := pool.GetConnection(ctx, first)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(pool.GetKnownAddresses()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A reconnect replaces the connection and closes the old one
+	require.NoError(t, pool.Reconnect(ctx, "node1"))
+	conn, err := pool.GetConnection(ctx, first)
+	require.NoError(t, err)
+	assert.NotSame(t, old, conn)
+	assert.Equal(t, "node1", conn.NodeID)
+	assert.Equal(t, connectivity.Shutdown, old.conn.GetState())
+	for _, server := range pool.GetKnownServers() {
+		if server.ID == "node1" {
+			assert.Equal(t, 1, server.Reconnects)
+		}
+	}
+
+	// An evicted server is forgotten
+	pool.connectionLock.RLock()
+	evicted := pool.idToConnection["node2"]
+	pool.connectionLock.RUnlock()
+	require.NoError(t, pool.Evict("node2"))
+	assert.Equal(t, []string{first}, pool.GetKnownAddresses())
+	assert.Equal(t, connectivity.Shutdown, evicted.conn.GetState())
+
+	assert.ErrorIs(t, pool.Evict("node2"), ErrServerNotFound)
+	assert.ErrorIs(t, pool.Reconnect(ctx, "node3"), ErrServerNotFound)
+
+	// Servers can be evicted by the address of a seed too
+	require.NoError(t, pool.EvictAddress(first))
+	assert.Empty(t, pool.GetKnownAddresses())
+	assert.Equal(t, connectivity.Shutdown, conn.conn.GetState())
+	assert.ErrorIs(t, pool.EvictAddress(first), ErrServerNotFound)
+}
+
+func TestConnectionPoolReconnectGivesUp(t *testing.T) {
+	// Nothing listens on the address once the listener is closed
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	pool := NewConnectionPool(zap.NewNop())
+	WithReconnectPolicy(2, time.Millisecond, time.Millisecond, 0)(pool)
+	defer pool.Close()
+	grpcConn, err := createGRPCConnection(context.Background(), address, zap.NewNop(), nil)
+	require.NoError(t, err)
+	old := createServerConnection(grpcConn)
+	pool.addressToConnection[address] = old
+
+	err = pool.Reconnect(context.Background(), address)
+	require.ErrorContains(t, err, "after 2 attempts")
+	conn, err := pool.GetConnection(context.Background(), address)
+	require.NoError(t, err)
+	assert.Same(t, old, conn, "the old connection is kept")
+	servers := pool.GetKnownServers()
+	require.Len(t, servers, 1)
+	assert.Contains(t, servers[0].LastError, "after 2 attempts")
+}
@@ -0,0 +1,84 @@
+package armada
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// tokenCredentials attach a bearer token to every call. Tokens are only sent over TLS connections.
+type tokenCredentials struct {
+	token func() (string, error)
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials
+func (c tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// StaticToken returns credentials attaching the token to every call
+func StaticToken(token string) credentials.PerRPCCredentials {
+	return tokenCredentials{token: func() (string, error) {
+		return token, nil
+	}}
+}
+
+// TokenFunc returns credentials attaching the token returned by fn to every call, e.g. a secret
+// resolved through a cache that picks up rotations
+func TokenFunc(fn func() (string, error)) credentials.PerRPCCredentials {
+	return tokenCredentials{token: fn}
+}
+
+// FileToken returns credentials attaching the token read from a file to every call. The file is
+// read again when it changes, so tokens rotated by e.g. Kubernetes are picked up.
+func FileToken(path string) credentials.PerRPCCredentials {
+	f := &tokenFile{path: path}
+	return tokenCredentials{token: f.token}
+}
+
+// tokenFile caches the token of a file until the file is modified
+type tokenFile struct {
+	path string
+
+	// mu protects modTime and value
+	mu      sync.Mutex
+	modTime time.Time
+	value   string
+}
+
+// token returns the trimmed content of the file
+func (f *tokenFile) token() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Armada token: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.value != "" && info.ModTime().Equal(f.modTime) {
+		return f.value, nil
+	}
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Armada token: %w", err)
+	}
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", fmt.Errorf("empty Armada token file %s", f.path)
+	}
+	f.modTime, f.value = info.ModTime(), value
+	return value, nil
+}
@@ -0,0 +1,104 @@
+package armada
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFileToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	creds := FileToken(path)
+
+	_, err := creds.GetRequestMetadata(context.Background())
+	assert.Error(t, err, "a missing file fails the call")
+
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+	md, err := creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"authorization": "Bearer first"}, md)
+
+	// Rotated tokens are read again
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	md, err = creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"authorization": "Bearer second"}, md)
+
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	_, err = creds.GetRequestMetadata(context.Background())
+	assert.Error(t, err, "empty files are refused")
+}
+
+func TestConnectionPoolToken(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCertificate(t, "ca", nil, x509.ExtKeyUsageAny)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	serverCert := issueCertificate(t, "armada", ca, x509.ExtKeyUsageServerAuth)
+
+	// The server only answers calls with the token
+	s := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.der}, PrivateKey: serverCert.key}},
+		})),
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			if auth := md.Get("authorization"); len(auth) != 1 || auth[0] != "Bearer secret" {
+				return nil, status.Error(codes.Unauthenticated, "invalid token")
+			}
+			return handler(ctx, req)
+		}))
+	regattapb.RegisterClusterServer(s, &mockPoolServer{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+	address := "https://" + lis.Addr().String()
+	tlsConfig, err := LoadTLSConfig(TLSOptions{CAPath: caFile})
+	require.NoError(t, err)
+
+	memberList := func(opts ...ClientOption) error {
+		pool := NewConnectionPool(zap.NewNop())
+		for _, opt := range append(opts, WithTLSConfig(tlsConfig)) {
+			opt(pool)
+		}
+		conn, err := createGRPCConnection(context.Background(), address, zap.NewNop(), pool.tlsConfig, pool.dialOptions()...)
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = regattapb.NewClusterClient(conn).MemberList(ctx, &regattapb.MemberListRequest{})
+		return err
+	}
+
+	assert.NoError(t, memberList(WithPerRPCCredentials(StaticToken("secret"))))
+	assert.Equal(t, codes.Unauthenticated, status.Code(memberList(WithPerRPCCredentials(StaticToken("wrong")))))
+	assert.Equal(t, codes.Unauthenticated, status.Code(memberList()))
+
+	// Tokens are not sent in plain text
+	conn, err := createGRPCConnection(context.Background(), "http://"+lis.Addr().String(), zap.NewNop(), nil,
+		grpc.WithPerRPCCredentials(StaticToken("secret")))
+	if err == nil {
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = regattapb.NewClusterClient(conn).MemberList(ctx, &regattapb.MemberListRequest{})
+	}
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+// Package armada provides a client for interacting with the Armada KV database server.
+// This file contains the sentinel errors returned by the Armada client.
+package armada
+
+import "errors"
+
+var (
+	// ErrTableNotFound is returned when an operation targets a table that does not exist.
+	ErrTableNotFound = errors.New("table not found")
+
+	// ErrTableExists is returned when creating a table whose name is already taken.
+	ErrTableExists = errors.New("table already exists")
+
+	// ErrKeyNotFound is returned when a key lookup does not match any stored key.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrRangeTruncated is returned together with the pairs received so far when a range scan
+	// is cut short by its deadline. The scan can continue after the last returned key.
+	ErrRangeTruncated = errors.New("range scan truncated")
+
+	// ErrServerNotFound is returned when an operation targets a server the connection pool doesn't know.
+	ErrServerNotFound = errors.New("server not found")
+
+	// ErrConnectTimeout is returned when a connection isn't ready within the connect timeout of the pool.
+	ErrConnectTimeout = errors.New("connect timeout")
+
+	// ErrCircuitOpen is reported in the UNAVAILABLE error of calls failed right away by the circuit
+	// breaker of a server that kept failing.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+)
@@ -0,0 +1,172 @@
+package armada
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// healthProbeTimeout bounds a single probe of a server
+const healthProbeTimeout = 5 * time.Second
+
+// WithHealthCheck probes every server of the pool at the interval. The connections of servers
+// that don't answer are marked unhealthy and replaced as soon as the server answers again, so the
+// first request after an outage doesn't pay for reconnecting. Zero disables the checks.
+func WithHealthCheck(interval time.Duration) ClientOption {
+	return func(p *ConnectionPool) {
+		p.healthInterval = interval
+	}
+}
+
+// startHealthCheck begins probing the servers in the background until the pool is closed
+func (p *ConnectionPool) startHealthCheck() {
+	if p.healthInterval <= 0 {
+		return
+	}
+	go p.runHealthCheck()
+}
+
+// runHealthCheck probes the servers at every interval
+func (p *ConnectionPool) runHealthCheck() {
+	ticker := time.NewTicker(p.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth(context.Background())
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// checkHealth probes every server of the pool once and replaces the connections that failed
+func (p *ConnectionPool) checkHealth(ctx context.Context) {
+	for address, conn := range p.probeTargets() {
+		err := p.probe(ctx, conn)
+		p.connectionLock.Lock()
+		previous, tracked := p.probeErrors[conn], p.tracks(conn)
+		if tracked {
+			if err != nil {
+				p.probeErrors[conn] = err
+				p.recordError(address, err)
+			} else {
+				delete(p.probeErrors, conn)
+			}
+		}
+		p.connectionLock.Unlock()
+		if !tracked {
+			// The connection was replaced or the pool closed meanwhile
+			continue
+		}
+
+		switch {
+		case err == nil && previous != nil:
+			p.logger.Info("Armada server is healthy again", zap.String("address", address), zap.String("nodeID", conn.NodeID))
+		case err != nil && previous == nil:
+			p.logger.Warn("Armada server failed its health check", zap.String("address", address), zap.String("nodeID", conn.NodeID), zap.Error(err))
+		}
+		if err != nil {
+			p.replaceConnection(ctx, address, conn)
+		}
+	}
+}
+
+// probeTargets returns every connection of the pool once, with the first of its addresses
+func (p *ConnectionPool) probeTargets() map[string]*ServerConnection {
+	p.connectionLock.RLock()
+	defer p.connectionLock.RUnlock()
+
+	addresses := make([]string, 0, len(p.addressToConnection))
+	for address := range p.addressToConnection {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	seen := make(map[*ServerConnection]bool)
+	targets := make(map[string]*ServerConnection)
+	for _, address := range addresses {
+		conn := p.addressToConnection[address]
+		if conn != nil && !seen[conn] {
+			seen[conn] = true
+			targets[address] = conn
+		}
+	}
+	return targets
+}
+
+// tracks reports whether the connection is still in the pool. The caller must hold the connection lock.
+func (p *ConnectionPool) tracks(conn *ServerConnection) bool {
+	for _, c := range p.addressToConnection {
+		if c == conn {
+			return true
+		}
+	}
+	return false
+}
+
+// probe asks the server for its status. A server answering with any error other than being
+// unavailable or too slow is reachable, e.g. one refusing the credentials of the console.
+func (p *ConnectionPool) probe(ctx context.Context, conn *ServerConnection) error {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	_, err := conn.ClusterClient.Status(ctx, &regattapb.StatusRequest{})
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return err
+	default:
+		return nil
+	}
+}
+
+// replaceConnection connects to the server of an unhealthy connection again. The new connection
+// takes over all addresses of the old one, which is closed, once the server answers it.
+func (p *ConnectionPool) replaceConnection(ctx context.Context, address string, old *ServerConnection) {
+	grpcConn, err := createGRPCConnection(ctx, address, p.logger, p.tlsConfig, p.dialOptions()...)
+	if err != nil {
+		return
+	}
+	conn := createServerConnection(grpcConn)
+	conn.NodeID = old.NodeID
+	conn.NodeName = old.NodeName
+	if err := p.probe(ctx, conn); err != nil {
+		_ = grpcConn.Close()
+		p.logger.Debug("Armada server is still unavailable", zap.String("address", address), zap.Error(err))
+		return
+	}
+
+	if !p.swapConnection(address, old, conn) {
+		return
+	}
+	p.logger.Info("Reconnected to Armada server", zap.String("address", address), zap.String("nodeID", conn.NodeID))
+}
+
+// swapConnection makes all addresses of the old connection use the new one and closes the old one.
+// The new connection is closed instead if the old one left the pool in the meantime.
+func (p *ConnectionPool) swapConnection(address string, old, conn *ServerConnection) bool {
+	p.connectionLock.Lock()
+	if !p.tracks(old) {
+		p.connectionLock.Unlock()
+		_ = conn.conn.Close()
+		return false
+	}
+	for addr, c := range p.addressToConnection {
+		if c == old {
+			p.addressToConnection[addr] = conn
+		}
+	}
+	if old.NodeID != "" && p.idToConnection[old.NodeID] == old {
+		p.idToConnection[old.NodeID] = conn
+	}
+	delete(p.probeErrors, old)
+	p.recordReconnect(address)
+	p.connectionLock.Unlock()
+
+	_ = old.conn.Close()
+	return true
+}
@@ -0,0 +1,66 @@
+package armada
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// serveCluster serves the cluster API of a single node on address until the test ends or the returned server is stopped
+func serveCluster(t *testing.T, address string) (*grpc.Server, string) {
+	lis, err := net.Listen("tcp", address)
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	regattapb.RegisterClusterServer(s, &mockPoolServer{memberResponse: &regattapb.MemberListResponse{
+		Members: []*regattapb.Member{{Id: "node1", Name: "node1", ClientURLs: []string{lis.Addr().String()}}},
+	}})
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return s, lis.Addr().String()
+}
+
+func TestConnectionPoolHealthCheck(t *testing.T) {
+	s, address := serveCluster(t, "127.0.0.1:0")
+	pool := NewConnectionPool(zap.NewNop())
+	defer pool.Close()
+	conn, err := pool.GetConnection(context.Background(), address)
+	require.NoError(t, err)
+
+	pool.checkHealth(context.Background())
+	assert.Equal(t, 1, pool.HealthyConnections(), "servers answering with any status are healthy")
+
+	// The outage is found by the health check rather than the next request
+	s.Stop()
+	pool.checkHealth(context.Background())
+	assert.Equal(t, 0, pool.HealthyConnections())
+	servers := pool.GetKnownServers()
+	require.Len(t, servers, 1)
+	assert.NotEmpty(t, servers[0].ProbeError)
+	assert.Equal(t, servers[0].ProbeError, servers[0].LastError)
+	require.NotNil(t, servers[0].LastErrorAt)
+
+	// The server is connected to again as soon as it is back, either by gRPC or by replacing the connection
+	serveCluster(t, address)
+	pool.checkHealth(context.Background())
+	assert.Equal(t, 1, pool.HealthyConnections())
+	assert.Empty(t, pool.GetKnownServers()[0].ProbeError)
+
+	conn, err = pool.GetConnection(context.Background(), address)
+	require.NoError(t, err)
+	pool.replaceConnection(context.Background(), address, conn)
+	replaced, err := pool.GetConnection(context.Background(), address)
+	require.NoError(t, err)
+	assert.NotSame(t, conn, replaced)
+	assert.Equal(t, "node1", replaced.NodeID)
+	assert.Equal(t, connectivity.Shutdown, conn.conn.GetState(), "the replaced connection is closed")
+	servers = pool.GetKnownServers()
+	assert.GreaterOrEqual(t, servers[0].Reconnects, 1)
+	assert.NotEmpty(t, servers[0].LastError, "the last error is kept after the server recovered")
+}
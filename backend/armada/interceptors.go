@@ -0,0 +1,90 @@
+package armada
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the metadata key the ID of the request causing a call is sent in
+const RequestIDHeader = "x-request-id"
+
+// Interceptor intercepts the calls of a client, e.g. to log them or to add metadata. Either
+// interceptor may be nil.
+type Interceptor struct {
+	Unary  grpc.UnaryClientInterceptor
+	Stream grpc.StreamClientInterceptor
+}
+
+// WithInterceptors adds interceptors to every call of the client. The first interceptor is the
+// outermost, further options add theirs after those of earlier ones.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(p *ConnectionPool) {
+		for _, i := range interceptors {
+			if i.Unary != nil {
+				p.unaryInterceptors = append(p.unaryInterceptors, i.Unary)
+			}
+			if i.Stream != nil {
+				p.streamInterceptors = append(p.streamInterceptors, i.Stream)
+			}
+		}
+	}
+}
+
+// RequestIDInterceptor sends the ID requestID returns for the context of a call in the
+// x-request-id metadata, so the logs of the servers can be matched with those of the console.
+// Calls without an ID are sent as they are.
+func RequestIDInterceptor(requestID func(context.Context) string) Interceptor {
+	withID := func(ctx context.Context) context.Context {
+		if id := requestID(ctx); id != "" {
+			return metadata.AppendToOutgoingContext(ctx, RequestIDHeader, id)
+		}
+		return ctx
+	}
+	return Interceptor{
+		Unary: func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(withID(ctx), method, req, reply, cc, opts...)
+		},
+		Stream: func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(withID(ctx), desc, cc, method, opts...)
+		},
+	}
+}
+
+// LoggingInterceptor logs every call with its duration and status at debug level, calls taking
+// longer than slow as warnings. A zero slow only logs at debug level. Streams are logged when
+// they are opened.
+func LoggingInterceptor(logger *zap.Logger, slow time.Duration) Interceptor {
+	log := func(method, target string, start time.Time, err error) {
+		elapsed := time.Since(start)
+		level := zap.DebugLevel
+		if slow > 0 && elapsed > slow {
+			level = zap.WarnLevel
+		}
+		if ce := logger.Check(level, "gRPC call"); ce != nil {
+			ce.Write(zap.String("method", method),
+				zap.String("target", target),
+				zap.Duration("duration", elapsed),
+				zap.String("code", status.Code(err).String()),
+				zap.Error(err))
+		}
+	}
+	return Interceptor{
+		Unary: func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			start := time.Now()
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			log(method, cc.Target(), start, err)
+			return err
+		},
+		Stream: func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			start := time.Now()
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			log(method, cc.Target(), start, err)
+			return stream, err
+		},
+	}
+}
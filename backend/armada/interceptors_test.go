@@ -0,0 +1,90 @@
+package armada
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+type requestIDKey struct{}
+
+func TestWithInterceptors(t *testing.T) {
+	// The server records the request IDs it receives
+	received := make(chan []string, 1)
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		received <- md.Get(RequestIDHeader)
+		return handler(ctx, req)
+	}))
+	regattapb.RegisterClusterServer(s, &mockPoolServer{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	// Interceptors run in the order they are added
+	var order []string
+	recorder := func(name string) Interceptor {
+		return Interceptor{Unary: func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			order = append(order, name)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}}
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	requestID := func(ctx context.Context) string {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return id
+	}
+
+	pool := NewConnectionPool(zap.NewNop())
+	WithInterceptors(recorder("first"), RequestIDInterceptor(requestID))(pool)
+	WithInterceptors(LoggingInterceptor(zap.New(core), time.Hour), recorder("last"))(pool)
+	conn, err := createGRPCConnection(t.Context(), lis.Addr().String(), zap.NewNop(), nil, pool.dialOptions()...)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.WithValue(t.Context(), requestIDKey{}, "req-1")
+	_, err = regattapb.NewClusterClient(conn).MemberList(ctx, &regattapb.MemberListRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"req-1"}, <-received)
+	assert.Equal(t, []string{"first", "last"}, order)
+
+	// Calls without a request ID are sent without one
+	_, err = regattapb.NewClusterClient(conn).MemberList(t.Context(), &regattapb.MemberListRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, <-received)
+
+	entries := logs.FilterMessage("gRPC call").All()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+	assert.Equal(t, "/regatta.v1.Cluster/MemberList", entries[0].ContextMap()["method"])
+	assert.Equal(t, "OK", entries[0].ContextMap()["code"])
+}
+
+func TestLoggingInterceptorSlowCalls(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	interceptor := LoggingInterceptor(zap.New(core), time.Millisecond)
+	slowInvoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}
+	conn, err := grpc.NewClient("passthrough:///armada:5001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, interceptor.Unary(t.Context(), "/regatta.v1.KV/Range", nil, nil, conn, slowInvoker))
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+}
@@ -12,6 +12,9 @@ type Status struct {
 	// Message is a human-readable message describing the status.
 	Message string `json:"message"`
 
+	// Version is the version of Armada the server runs.
+	Version string `json:"version,omitempty"`
+
 	// Config contains the server configuration values.
 	// It is a map of configuration keys to their values.
 	Config map[string]interface{} `json:"config,omitempty"`
@@ -66,6 +69,18 @@ type KeyValuePair struct {
 	Value string `json:"value"`
 }
 
+// KeyRevision is a key-value pair with the revisions the cluster created and last modified it at
+type KeyRevision struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// CreateRevision is the revision of the cluster the key was created at.
+	CreateRevision int64 `json:"createRevision"`
+
+	// ModRevision is the revision of the cluster the key was last modified at.
+	ModRevision int64 `json:"modRevision"`
+}
+
 // Table represents a table in the Armada database.
 type Table struct {
 	// Name is the name of the table.
@@ -0,0 +1,123 @@
+package armada
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryableMethods are the idempotent methods retried on transient errors
+var retryableMethods = []string{
+	regattapb.KV_Range_FullMethodName,
+	regattapb.Cluster_MemberList_FullMethodName,
+	regattapb.Cluster_Status_FullMethodName,
+	regattapb.Metrics_GetMetrics_FullMethodName,
+}
+
+// maxRetryTokens is the number of retries the budget allows in a burst
+const maxRetryTokens = 10
+
+// retryJitter randomizes the delays between attempts, so calls failed together don't retry together
+const retryJitter = 0.2
+
+// RetryPolicy sets how calls failing with a transient error are retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a call including the first, 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it grows by 1.6 after every further one up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Budget is the fraction of the calls that may be retried, e.g. 0.2 retries at most one in five
+	// calls, so a server that is down isn't called more often than when it is up
+	Budget float64
+}
+
+// RetryInterceptor retries the idempotent calls Range, MemberList, Status and GetMetrics when they
+// fail with UNAVAILABLE, or DEADLINE_EXCEEDED before the deadline of the caller passed, so a brief
+// leader election doesn't fail them. Every client should have its own interceptor, as the budget
+// is shared by its calls.
+func RetryInterceptor(policy RetryPolicy, logger *zap.Logger) Interceptor {
+	if policy.MaxAttempts <= 1 {
+		return Interceptor{}
+	}
+	budget := &retryBudget{ratio: policy.Budget, tokens: maxRetryTokens}
+	backoff := reconnectConfig{baseDelay: policy.BaseDelay, maxDelay: policy.MaxDelay, jitter: retryJitter}
+
+	return Interceptor{Unary: func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !slices.Contains(retryableMethods, method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		budget.deposit()
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= policy.MaxAttempts || !transient(ctx, err) {
+				return err
+			}
+			if !budget.withdraw() {
+				logger.Debug("Not retrying gRPC call, the retry budget is exhausted",
+					zap.String("method", method), zap.String("target", cc.Target()), zap.Error(err))
+				return err
+			}
+			delay := backoff.delay(attempt)
+			logger.Debug("Retrying gRPC call",
+				zap.String("method", method),
+				zap.String("target", cc.Target()),
+				zap.Int("attempt", attempt+1),
+				zap.Duration("delay", delay),
+				zap.Error(err))
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}}
+}
+
+// transient reports whether a call failed with an error a retry may not run into
+func transient(ctx context.Context, err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return true
+	case codes.DeadlineExceeded:
+		// A server's deadline passed, not the caller's
+		return ctx.Err() == nil
+	default:
+		return false
+	}
+}
+
+// retryBudget limits retries to a fraction of the calls. Every call deposits that fraction of a
+// token, every retry withdraws a whole one. At most maxRetryTokens are saved up.
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+// deposit saves up for retries when a call is made
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, maxRetryTokens)
+}
+
+// withdraw takes a token for a retry, it reports false if there is none
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,91 @@
+package armada
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// scriptedInvoker fails calls with the codes in turn, then succeeds
+type scriptedInvoker struct {
+	codes []codes.Code
+	calls int
+}
+
+func (s *scriptedInvoker) invoke(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+	s.calls++
+	if s.calls <= len(s.codes) {
+		return status.Error(s.codes[s.calls-1], "scripted")
+	}
+	return nil
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	conn, err := grpc.NewClient("passthrough:///armada:5001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, Budget: 1}
+
+	tests := []struct {
+		name      string
+		method    string
+		codes     []codes.Code
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{name: "LeaderElection", method: regattapb.KV_Range_FullMethodName, codes: []codes.Code{codes.Unavailable, codes.Unavailable}, wantCode: codes.OK, wantCalls: 3},
+		{name: "ServerDeadline", method: regattapb.Cluster_Status_FullMethodName, codes: []codes.Code{codes.DeadlineExceeded}, wantCode: codes.OK, wantCalls: 2},
+		{name: "MaxAttempts", method: regattapb.Metrics_GetMetrics_FullMethodName, codes: []codes.Code{codes.Unavailable, codes.Unavailable, codes.Unavailable}, wantCode: codes.Unavailable, wantCalls: 3},
+		{name: "NotTransient", method: regattapb.Cluster_MemberList_FullMethodName, codes: []codes.Code{codes.InvalidArgument}, wantCode: codes.InvalidArgument, wantCalls: 1},
+		{name: "NotIdempotent", method: regattapb.KV_Put_FullMethodName, codes: []codes.Code{codes.Unavailable}, wantCode: codes.Unavailable, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoker := &scriptedInvoker{codes: tt.codes}
+			err := RetryInterceptor(policy, zap.NewNop()).Unary(t.Context(), tt.method, nil, nil, conn, invoker.invoke)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantCalls, invoker.calls)
+		})
+	}
+
+	t.Run("CallerDeadline", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		invoker := &scriptedInvoker{codes: []codes.Code{codes.DeadlineExceeded}}
+		err := RetryInterceptor(policy, zap.NewNop()).Unary(ctx, regattapb.KV_Range_FullMethodName, nil, nil, conn, invoker.invoke)
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.Equal(t, 1, invoker.calls)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert.Nil(t, RetryInterceptor(RetryPolicy{MaxAttempts: 1}, zap.NewNop()).Unary)
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	conn, err := grpc.NewClient("passthrough:///armada:5001", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	interceptor := RetryInterceptor(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Microsecond, MaxDelay: time.Microsecond, Budget: 0.5}, zap.NewNop())
+
+	// A cluster that is down is retried in a burst, then only as the calls refill the budget
+	invoker := &scriptedInvoker{codes: make([]codes.Code, 1000)}
+	for i := range invoker.codes {
+		invoker.codes[i] = codes.Unavailable
+	}
+	for range 20 {
+		_ = interceptor.Unary(t.Context(), regattapb.KV_Range_FullMethodName, nil, nil, conn, invoker.invoke)
+	}
+	// 20 calls, a burst of 10 retries and 9 retries from the deposits of the calls after the first,
+	// whose deposit exceeds the burst
+	assert.Equal(t, 20+maxRetryTokens+9, invoker.calls)
+}
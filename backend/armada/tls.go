@@ -0,0 +1,81 @@
+package armada
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TLSOptions are the TLS settings of connections to https addresses. Empty fields keep the defaults.
+type TLSOptions struct {
+	// CertFile and KeyFile are a PEM encoded client certificate presented to servers requiring mutual TLS.
+	CertFile string
+	KeyFile  string
+	// CAPath is a file of PEM encoded certificates, or a directory of such files, trusted instead of
+	// the system roots.
+	CAPath string
+	// InsecureSkipVerify accepts any server certificate, so servers can be impersonated; prefer CAPath.
+	InsecureSkipVerify bool
+}
+
+// LoadTLSConfig reads the certificates of the options into the TLS settings of connections to https addresses
+func LoadTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if opts.CAPath != "" {
+		roots, err := loadCertPool(opts.CAPath)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = roots
+	}
+	return config, nil
+}
+
+// loadCertPool reads the PEM encoded certificates of a file, or of all files in a directory. Files in
+// a directory without certificates are skipped, like keys next to them, but one of them must have some.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			// Entries of hashed directories are links to the certificates
+			file := filepath.Join(path, entry.Name())
+			if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+				files = append(files, file)
+			}
+		}
+	}
+
+	roots := x509.NewCertPool()
+	found := false
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		if roots.AppendCertsFromPEM(pem) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no PEM encoded certificates in %s", path)
+	}
+	return roots, nil
+}
@@ -0,0 +1,142 @@
+package armada
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// testCertificate is a key pair issued by parent, or self-signed if parent is nil
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issueCertificate(t *testing.T, name string, parent *testCertificate, usage x509.ExtKeyUsage) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCertificate{cert: cert, key: key, der: der}
+}
+
+// writeFiles writes the certificate and its key PEM encoded into dir
+func (c *testCertificate) writeFiles(t *testing.T, dir, name string) (string, string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCertificate(t, "ca", nil, x509.ExtKeyUsageAny)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	certFile, keyFile := issueCertificate(t, "console", ca, x509.ExtKeyUsageClientAuth).writeFiles(t, dir, "console")
+
+	config, err := LoadTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, CAPath: caFile})
+	require.NoError(t, err)
+	assert.Len(t, config.Certificates, 1)
+	assert.NotNil(t, config.RootCAs)
+
+	config, err = LoadTLSConfig(TLSOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, config.Certificates)
+	assert.Nil(t, config.RootCAs, "the system roots are used without a CA file")
+
+	_, err = LoadTLSConfig(TLSOptions{CertFile: certFile})
+	assert.Error(t, err)
+	_, err = LoadTLSConfig(TLSOptions{CAPath: keyFile})
+	assert.Error(t, err, "files without certificates are refused")
+	_, err = LoadTLSConfig(TLSOptions{CAPath: t.TempDir()})
+	assert.Error(t, err, "directories without certificates are refused")
+}
+
+func TestCreateGRPCConnectionMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueCertificate(t, "ca", nil, x509.ExtKeyUsageAny)
+	caFile, _ := ca.writeFiles(t, dir, "ca")
+	certFile, keyFile := issueCertificate(t, "console", ca, x509.ExtKeyUsageClientAuth).writeFiles(t, dir, "console")
+	serverCert := issueCertificate(t, "armada", ca, x509.ExtKeyUsageServerAuth)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.der}, PrivateKey: serverCert.key}},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	regattapb.RegisterClusterServer(s, &mockPoolServer{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+	address := "https://" + lis.Addr().String()
+
+	memberList := func(config *tls.Config) error {
+		conn, err := createGRPCConnection(context.Background(), address, zap.NewNop(), config)
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = regattapb.NewClusterClient(conn).MemberList(ctx, &regattapb.MemberListRequest{})
+		return err
+	}
+
+	config, err := LoadTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, CAPath: caFile})
+	require.NoError(t, err)
+	assert.NoError(t, memberList(config))
+
+	// The directory also holds the keys, which are skipped
+	config, err = LoadTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, CAPath: dir})
+	require.NoError(t, err)
+	assert.NoError(t, memberList(config), "the CA is read from the directory")
+
+	config, err = LoadTLSConfig(TLSOptions{CertFile: certFile, KeyFile: keyFile, InsecureSkipVerify: true})
+	require.NoError(t, err)
+	assert.NoError(t, memberList(config), "the server certificate isn't verified")
+
+	config, err = LoadTLSConfig(TLSOptions{CAPath: caFile})
+	require.NoError(t, err)
+	assert.Error(t, memberList(config), "the server requires a client certificate")
+	assert.Error(t, memberList(nil), "the server certificate isn't trusted by the system roots")
+}
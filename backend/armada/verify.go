@@ -0,0 +1,219 @@
+package armada
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"go.uber.org/zap"
+)
+
+// verifyTimeout bounds the read from the second replica, which outlives the request it checks
+const verifyTimeout = 10 * time.Second
+
+// VerificationStats counts the reads checked against a second replica
+type VerificationStats struct {
+	// Checked is the number of reads compared with a second replica
+	Checked int64 `json:"checked"`
+	// Mismatches is the number of compared reads whose responses differed
+	Mismatches int64 `json:"mismatches"`
+	// Failed is the number of sampled reads that couldn't be compared, e.g. without a second replica
+	Failed int64 `json:"failed"`
+	// LastMismatchAt is when the last mismatch was found, nil if none was
+	LastMismatchAt *time.Time `json:"lastMismatchAt,omitempty"`
+}
+
+// ReadVerifier issues a sample of the reads of a Client to a second replica as well and compares
+// the hashes of the values, logging and counting mismatches. It is a diagnostic tool for suspected
+// data inconsistency: the second read is made after the first and without linearizability, so
+// keys written meanwhile or replicas that lag behind are reported as mismatches too.
+type ReadVerifier struct {
+	// sampleRate is the fraction of reads verified
+	sampleRate float64
+	logger     *zap.Logger
+
+	checked    atomic.Int64
+	mismatches atomic.Int64
+	failed     atomic.Int64
+	lastMu     sync.Mutex
+	last       time.Time
+
+	// pending tracks the running comparisons
+	pending sync.WaitGroup
+}
+
+// NewReadVerifier creates a ReadVerifier checking the given fraction of reads, between 0 and 1
+func NewReadVerifier(sampleRate float64, logger *zap.Logger) *ReadVerifier {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ReadVerifier{
+		sampleRate: sampleRate,
+		logger:     logger.Named("verify"),
+	}
+}
+
+// WithReadVerifier checks the reads of the client against a second replica
+func WithReadVerifier(verifier *ReadVerifier) ClientOption {
+	return func(p *ConnectionPool) {
+		p.readVerifier = verifier
+	}
+}
+
+// Stats returns the counts of the verified reads
+func (v *ReadVerifier) Stats() VerificationStats {
+	stats := VerificationStats{
+		Checked:    v.checked.Load(),
+		Mismatches: v.mismatches.Load(),
+		Failed:     v.failed.Load(),
+	}
+	v.lastMu.Lock()
+	defer v.lastMu.Unlock()
+	if !v.last.IsZero() {
+		last := v.last
+		stats.LastMismatchAt = &last
+	}
+	return stats
+}
+
+// sampled reports whether a read is verified
+func (v *ReadVerifier) sampled() bool {
+	return v != nil && v.sampleRate > 0 && rand.Float64() < v.sampleRate
+}
+
+// verify runs the comparison in the background, so the read it checks isn't slowed down
+func (v *ReadVerifier) verify(ctx context.Context, compare func(ctx context.Context) error) {
+	v.pending.Add(1)
+	go func() {
+		defer v.pending.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), verifyTimeout)
+		defer cancel()
+		if err := compare(ctx); err != nil {
+			v.failed.Add(1)
+			v.logger.Debug("Failed to verify read", zap.Error(err))
+		}
+	}()
+}
+
+// mismatch counts and logs responses that differ
+func (v *ReadVerifier) mismatch(msg string, fields ...zap.Field) {
+	v.mismatches.Add(1)
+	v.lastMu.Lock()
+	v.last = time.Now()
+	v.lastMu.Unlock()
+	v.logger.Warn(msg, fields...)
+}
+
+// wait blocks until the running comparisons are done
+func (v *ReadVerifier) wait() {
+	v.pending.Wait()
+}
+
+// errNoReplica is returned when the pool knows no other node to compare a read with
+var errNoReplica = errors.New("no second replica to compare with")
+
+// replicaConnection returns a connection to a node other than the one of primary,
+// starting at a random known address so that the replicas are checked in turn
+func (c *Client) replicaConnection(ctx context.Context, primary *ServerConnection) (*ServerConnection, string, error) {
+	addresses := c.connectionPool.GetKnownAddresses()
+	if len(addresses) == 0 {
+		return nil, "", errNoReplica
+	}
+	offset := rand.Intn(len(addresses))
+	for i := range addresses {
+		address := addresses[(offset+i)%len(addresses)]
+		conn, err := c.connectionPool.GetConnection(ctx, address)
+		if err != nil || conn == primary || conn.NodeID == "" || conn.NodeID == primary.NodeID {
+			continue
+		}
+		return conn, address, nil
+	}
+	return nil, "", errNoReplica
+}
+
+// verifyKey compares the value read for a key, nil if it wasn't found, with a second replica
+func (c *Client) verifyKey(ctx context.Context, primary *ServerConnection, table, key string, value *string) {
+	c.verifier.verify(ctx, func(ctx context.Context) error {
+		replica, address, err := c.replicaConnection(ctx, primary)
+		if err != nil {
+			return err
+		}
+		resp, err := replica.KVClient.Range(ctx, &regattapb.RangeRequest{Table: []byte(table), Key: []byte(key), Limit: 1})
+		if err != nil {
+			return err
+		}
+		c.verifier.checked.Add(1)
+		found := len(resp.Kvs) > 0
+		if found != (value != nil) || found && sha256.Sum256(resp.Kvs[0].Value) != sha256.Sum256([]byte(*value)) {
+			c.verifier.mismatch("Replicas returned different values",
+				zap.String("table", table),
+				zap.String("key", key),
+				zap.String("node", primary.NodeID),
+				zap.String("replica", replica.NodeID),
+				zap.String("address", address),
+				zap.Bool("found", value != nil),
+				zap.Bool("replicaFound", found))
+		}
+		return nil
+	})
+}
+
+// verifyRange compares the pairs read for a range with a second replica
+func (c *Client) verifyRange(ctx context.Context, primary *ServerConnection, req *regattapb.RangeRequest, pairs []KeyValuePair) {
+	c.verifier.verify(ctx, func(ctx context.Context) error {
+		replica, address, err := c.replicaConnection(ctx, primary)
+		if err != nil {
+			return err
+		}
+		stream, err := replica.KVClient.IterateRange(ctx, req)
+		if err != nil {
+			return err
+		}
+		hashes := make(map[string][sha256.Size]byte)
+		for {
+			resp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			for _, kv := range resp.Kvs {
+				hashes[string(kv.Key)] = sha256.Sum256(kv.Value)
+			}
+		}
+		c.verifier.checked.Add(1)
+
+		// Keys missing from either side count as differing
+		replicaPairs, differing, first := len(hashes), 0, ""
+		for _, pair := range pairs {
+			hash, ok := hashes[pair.Key]
+			delete(hashes, pair.Key)
+			if !ok || hash != sha256.Sum256([]byte(pair.Value)) {
+				differing++
+				if first == "" {
+					first = pair.Key
+				}
+			}
+		}
+		differing += len(hashes)
+		if differing > 0 {
+			c.verifier.mismatch("Replicas returned different ranges",
+				zap.String("table", string(req.Table)),
+				zap.String("node", primary.NodeID),
+				zap.String("replica", replica.NodeID),
+				zap.String("address", address),
+				zap.Int("pairs", len(pairs)),
+				zap.Int("replicaPairs", replicaPairs),
+				zap.Int("differing", differing),
+				zap.String("firstKey", first))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,99 @@
+package armada
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// replicaServer serves the keys of one replica
+type replicaServer struct {
+	regattapb.UnimplementedKVServer
+	values map[string]string
+}
+
+func (s *replicaServer) Range(_ context.Context, req *regattapb.RangeRequest) (*regattapb.RangeResponse, error) {
+	resp := &regattapb.RangeResponse{}
+	for _, key := range []string{"key1", "key2", "key3"} {
+		value, ok := s.values[key]
+		inRange := key == string(req.Key) || len(req.RangeEnd) > 0 && key >= string(req.Key) && key < string(req.RangeEnd)
+		if ok && inRange {
+			resp.Kvs = append(resp.Kvs, &regattapb.KeyValue{Key: []byte(key), Value: []byte(value)})
+		}
+	}
+	return resp, nil
+}
+
+func (s *replicaServer) IterateRange(req *regattapb.RangeRequest, stream grpc.ServerStreamingServer[regattapb.RangeResponse]) error {
+	resp, _ := s.Range(stream.Context(), req)
+	return stream.Send(resp)
+}
+
+// startReplica serves values over an in-memory listener and connects to it as node
+func startReplica(t *testing.T, node string, values map[string]string) *ServerConnection {
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer()
+	regattapb.RegisterKVServer(s, &replicaServer{values: values})
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///"+node,
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	serverConn := createServerConnection(conn)
+	serverConn.NodeID = node
+	return serverConn
+}
+
+func TestReadVerifier(t *testing.T) {
+	primary := startReplica(t, "node1", map[string]string{"key1": "value1", "key2": "value2"})
+	replica := startReplica(t, "node2", map[string]string{"key1": "value1", "key2": "stale", "key3": "value3"})
+	pool := &mockConnectionPool{}
+	pool.On("GetConnection", mock.Anything, "primary").Return(primary, nil)
+	pool.On("GetConnection", mock.Anything, "replica").Return(replica, nil)
+	pool.On("GetKnownAddresses").Return([]string{"primary", "replica"})
+	verifier := NewReadVerifier(1, zap.NewNop())
+	client := &Client{address: "primary", logger: zap.NewNop(), connectionPool: pool, verifier: verifier}
+	ctx := context.Background()
+
+	_, err := client.GetKeyValue(ctx, "table", "key1")
+	require.NoError(t, err)
+	verifier.wait()
+	assert.Equal(t, VerificationStats{Checked: 1}, verifier.Stats())
+
+	_, err = client.GetKeyValue(ctx, "table", "key2")
+	require.NoError(t, err)
+	_, err = client.GetKeyValue(ctx, "table", "key3")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	verifier.wait()
+	stats := verifier.Stats()
+	assert.Equal(t, int64(3), stats.Checked)
+	assert.Equal(t, int64(2), stats.Mismatches, "differing values and keys missing from one replica are mismatches")
+	assert.NotNil(t, stats.LastMismatchAt)
+
+	_, err = client.GetKeyValuePairs(ctx, "table", "key", "", "", 10)
+	require.NoError(t, err)
+	verifier.wait()
+	assert.Equal(t, int64(3), verifier.Stats().Mismatches)
+
+	// Reads can't be compared without a second node
+	single := &mockConnectionPool{}
+	single.On("GetConnection", mock.Anything, "primary").Return(primary, nil)
+	single.On("GetKnownAddresses").Return([]string{"primary"})
+	client.connectionPool = single
+	_, err = client.GetKeyValue(ctx, "table", "key1")
+	require.NoError(t, err)
+	verifier.wait()
+	assert.Equal(t, int64(1), verifier.Stats().Failed)
+}
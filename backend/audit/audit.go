@@ -0,0 +1,345 @@
+// Package audit records the operations performed through the console.
+// Entries are append-only: the FileLog writes them as JSON lines so the log
+// survives restarts and can be inspected with standard tools. Every entry carries
+// the hash of its predecessor, so tampering with the log can be detected by Verify.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Outcomes of audited operations.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeDenied  = "denied"
+)
+
+// ErrNotFound is returned when an entry does not exist.
+var ErrNotFound = errors.New("audit entry not found")
+
+// Entry is a single audited operation.
+type Entry struct {
+	// ID is assigned by the log in increasing order.
+	ID uint64 `json:"id"`
+	// Time is when the operation finished.
+	Time time.Time `json:"time"`
+	// User is the user who performed the operation.
+	User string `json:"user"`
+	// Action names the operation, e.g. "table.delete".
+	Action string `json:"action"`
+	// Resource identifies the affected resource, e.g. "tables/users".
+	Resource string `json:"resource"`
+	// Outcome is one of OutcomeSuccess, OutcomeFailure or OutcomeDenied.
+	Outcome string `json:"outcome"`
+	// Error describes why the operation failed or was denied.
+	Error string `json:"error,omitempty"`
+	// Details holds operation specific parameters.
+	Details map[string]string `json:"details,omitempty"`
+	// Snapshot is the state captured before a destructive operation, if any.
+	Snapshot json.RawMessage `json:"snapshot,omitempty"`
+	// PrevHash is the hash of the previous entry, empty for the first one.
+	PrevHash string `json:"prevHash,omitempty"`
+	// Hash covers the entry including PrevHash, it is assigned by the log.
+	Hash string `json:"hash,omitempty"`
+}
+
+// Query selects entries from the log. Zero values match everything.
+type Query struct {
+	// Action only returns entries of this action.
+	Action string
+	// Resource only returns entries of this resource.
+	Resource string
+	// After only returns entries recorded after the entry with this ID.
+	After uint64
+	// Limit is the maximum number of entries to return.
+	Limit int
+}
+
+// matches reports whether the entry is selected by the query
+func (q Query) matches(e Entry) bool {
+	return e.ID > q.After && (q.Action == "" || e.Action == q.Action) && (q.Resource == "" || e.Resource == q.Resource)
+}
+
+// Log stores audit entries.
+type Log interface {
+	// Record appends an entry, assigning its ID, its hash and, if unset, its time.
+	Record(ctx context.Context, entry Entry) (Entry, error)
+
+	// List returns the entries selected by the query, newest first.
+	List(ctx context.Context, q Query) ([]Entry, error)
+
+	// Get returns the entry with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id uint64) (Entry, error)
+}
+
+// MemoryLog keeps the most recent entries in memory.
+type MemoryLog struct {
+	mu      sync.RWMutex
+	max     int
+	lastID  uint64
+	chain   chain
+	entries []Entry
+}
+
+// NewMemoryLog creates a log keeping at most max entries
+func NewMemoryLog(max int, opts ...Option) *MemoryLog {
+	return &MemoryLog{max: max, chain: newChain(opts)}
+}
+
+// Record appends an entry, dropping the oldest entry when the log is full
+func (m *MemoryLog) Record(_ context.Context, entry Entry) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, err := m.chain.link(prepare(entry, m.lastID+1))
+	if err != nil {
+		return Entry{}, err
+	}
+	m.lastID = entry.ID
+	m.entries = append(m.entries, entry)
+	if m.max > 0 && len(m.entries) > m.max {
+		m.entries = slices.Delete(m.entries, 0, len(m.entries)-m.max)
+	}
+	return entry, nil
+}
+
+// List returns the selected entries, newest first
+func (m *MemoryLog) List(_ context.Context, q Query) ([]Entry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return selectEntries(m.entries, q), nil
+}
+
+// Get returns a single entry
+func (m *MemoryLog) Get(_ context.Context, id uint64) (Entry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, e := range m.entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// FileLog appends entries to a JSON lines file.
+type FileLog struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	lastID uint64
+	chain  chain
+}
+
+// OpenFileLog opens or creates the log file at path. Entries recorded before the log was
+// chained are kept, the chain starts with the next entry.
+func OpenFileLog(path string, opts ...Option) (*FileLog, error) {
+	l := &FileLog{path: path, chain: newChain(opts)}
+	entries, err := l.readAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		l.lastID = entries[len(entries)-1].ID
+		l.chain.last = entries[len(entries)-1].Hash
+	}
+
+	l.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return l, nil
+}
+
+// Record appends an entry to the file
+func (l *FileLog) Record(_ context.Context, entry Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The chain only advances once the entry is written
+	c := l.chain
+	entry, err := c.link(prepare(entry, l.lastID+1))
+	if err != nil {
+		return Entry{}, err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return Entry{}, fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	l.lastID = entry.ID
+	l.chain = c
+	return entry, nil
+}
+
+// List reads the file and returns the selected entries, newest first
+func (l *FileLog) List(_ context.Context, q Query) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.readAll()
+	if err != nil {
+		return nil, err
+	}
+	return selectEntries(entries, q), nil
+}
+
+// Get returns a single entry
+func (l *FileLog) Get(_ context.Context, id uint64) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries, err := l.readAll()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// Prune removes the entries recorded before the cutoff and, if maxBytes is positive, the oldest
+// entries until the file is at most maxBytes large. The newest entry is always kept, so the chain
+// continues from it. A zero cutoff keeps entries of any age. The remaining entries are written to
+// a new file that replaces the log, and still verify as the first one's predecessor is not checked.
+// Prune returns the number of removed entries and by how many bytes the file shrank.
+func (l *FileLog) Prune(cutoff time.Time, maxBytes int64) (int, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries, err := l.readAll()
+	if err != nil {
+		return 0, 0, err
+	}
+	lines := make([][]byte, len(entries))
+	for i, e := range entries {
+		if lines[i], err = json.Marshal(e); err != nil {
+			return 0, 0, fmt.Errorf("failed to encode audit entry: %w", err)
+		}
+	}
+
+	keep, kept := len(entries), int64(0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		line := int64(len(lines[i])) + 1
+		if i < len(entries)-1 && ((!cutoff.IsZero() && entries[i].Time.Before(cutoff)) || (maxBytes > 0 && kept+line > maxBytes)) {
+			break
+		}
+		keep, kept = i, kept+line
+	}
+	if keep == 0 {
+		return 0, 0, nil
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+
+	// The remaining entries are written next to the log and replace it atomically
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return 0, 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	for _, line := range lines[keep:] {
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := errors.Join(w.Flush(), tmp.Sync(), tmp.Close()); err != nil {
+		return 0, 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		return 0, 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+
+	// Later entries are appended to the new file
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to reopen audit log: %w", err)
+	}
+	l.file.Close()
+	l.file = file
+	return keep, info.Size() - kept, nil
+}
+
+// Close closes the log file
+func (l *FileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// readAll reads every entry of the file in order
+func (l *FileLog) readAll() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+	return ReadEntries(f)
+}
+
+// prepare assigns the ID and the time of a new entry
+func prepare(entry Entry, id uint64) Entry {
+	entry.ID = id
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	return entry
+}
+
+// selectEntries filters entries and returns them newest first
+func selectEntries(entries []Entry, q Query) []Entry {
+	selected := make([]Entry, 0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !q.matches(entries[i]) {
+			continue
+		}
+		selected = append(selected, entries[i])
+		if q.Limit > 0 && len(selected) == q.Limit {
+			break
+		}
+	}
+	return selected
+}
+
+// notifyingLog passes every recorded entry to a callback
+type notifyingLog struct {
+	Log
+	notify func(Entry)
+}
+
+// Notify returns a Log recording entries in log that passes every stored entry to notify,
+// e.g. to publish operations as they happen
+func Notify(log Log, notify func(Entry)) Log {
+	return notifyingLog{Log: log, notify: notify}
+}
+
+// Record appends an entry to the wrapped log and passes it on once it was stored
+func (n notifyingLog) Record(ctx context.Context, entry Entry) (Entry, error) {
+	entry, err := n.Log.Record(ctx, entry)
+	if err != nil {
+		return entry, err
+	}
+	n.notify(entry)
+	return entry, nil
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLog runs the behaviour shared by all Log implementations
+func testLog(t *testing.T, l Log) {
+	ctx := context.Background()
+
+	first, err := l.Record(ctx, Entry{User: "alice", Action: "table.create", Resource: "tables/users", Outcome: OutcomeSuccess})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), first.ID)
+	assert.False(t, first.Time.IsZero())
+
+	_, err = l.Record(ctx, Entry{
+		User: "bob", Action: "table.delete", Resource: "tables/users", Outcome: OutcomeSuccess,
+		Snapshot: json.RawMessage(`{"members":[]}`),
+	})
+	require.NoError(t, err)
+	_, err = l.Record(ctx, Entry{User: "bob", Action: "table.create", Resource: "tables/orders", Outcome: OutcomeFailure, Error: "exists"})
+	require.NoError(t, err)
+
+	all, err := l.List(ctx, Query{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, uint64(3), all[0].ID, "entries are listed newest first")
+
+	creates, err := l.List(ctx, Query{Action: "table.create"})
+	require.NoError(t, err)
+	assert.Len(t, creates, 2)
+
+	limited, err := l.List(ctx, Query{Resource: "tables/users", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, "table.delete", limited[0].Action)
+
+	newer, err := l.List(ctx, Query{After: 1})
+	require.NoError(t, err)
+	require.Len(t, newer, 2)
+	assert.Equal(t, uint64(2), newer[1].ID)
+
+	entry, err := l.Get(ctx, 2)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"members":[]}`, string(entry.Snapshot))
+
+	_, err = l.Get(ctx, 42)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryLog(t *testing.T) {
+	testLog(t, NewMemoryLog(100))
+}
+
+func TestMemoryLogEvictsOldest(t *testing.T) {
+	l := NewMemoryLog(2)
+	for range 3 {
+		_, err := l.Record(context.Background(), Entry{Action: "table.create"})
+		require.NoError(t, err)
+	}
+	entries, err := l.List(context.Background(), Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[1].ID)
+}
+
+func TestFileLog(t *testing.T) {
+	l, err := OpenFileLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	defer l.Close()
+	testLog(t, l)
+}
+
+func TestFileLogContinuesIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := OpenFileLog(path)
+	require.NoError(t, err)
+	_, err = l.Record(context.Background(), Entry{Action: "table.create"})
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	reopened, err := OpenFileLog(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	entry, err := reopened.Record(context.Background(), Entry{Action: "table.delete"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), entry.ID)
+}
+
+func TestNotify(t *testing.T) {
+	var notified []Entry
+	l := Notify(NewMemoryLog(10), func(e Entry) { notified = append(notified, e) })
+	testLog(t, l)
+	require.Len(t, notified, 3)
+	assert.Equal(t, uint64(1), notified[0].ID)
+}
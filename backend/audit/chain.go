@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Option configures optional behaviour of the logs
+type Option func(*chain)
+
+// WithSigningKey signs the hash chain with the key, i.e. entries are hashed with HMAC-SHA256
+// instead of SHA-256. Without the key, a rewritten log can't be given a valid chain again.
+func WithSigningKey(key []byte) Option {
+	return func(c *chain) {
+		c.key = key
+	}
+}
+
+// chain links every entry to its predecessor: the hash of an entry covers the entry including
+// the hash of the previous entry, so changing, inserting or removing an entry breaks the chain
+// from there on
+type chain struct {
+	key  []byte
+	last string
+}
+
+// newChain creates a chain configured by opts
+func newChain(opts []Option) chain {
+	var c chain
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// link sets the previous hash and the hash of a new entry and makes it the last one
+func (c *chain) link(entry Entry) (Entry, error) {
+	entry.PrevHash = c.last
+	h, err := hashEntry(c.key, entry)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Hash = h
+	c.last = h
+	return entry, nil
+}
+
+// hashEntry hashes the JSON encoding of the entry without its own hash
+func hashEntry(key []byte, entry Entry) (string, error) {
+	entry.Hash = ""
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verification is the result of verifying the hash chain of audit entries
+type Verification struct {
+	// Valid is true if all entries are chained and their hashes match and link to their
+	// predecessor
+	Valid bool `json:"valid"`
+	// Entries is the number of verified entries
+	Entries int `json:"entries"`
+	// Unchained is the number of leading entries without a hash, e.g. recorded before the log
+	// was chained. Their integrity can't be verified, so the log doesn't verify until retention
+	// removed them.
+	Unchained int `json:"unchained"`
+	// FirstID and LastID are the IDs of the first and the last entry. Entries before the first
+	// one may have been removed by retention, the chain is verified from there on.
+	FirstID uint64 `json:"firstId,omitempty"`
+	LastID  uint64 `json:"lastId,omitempty"`
+	// LastHash is the hash of the last entry. Keeping it elsewhere detects the removal of
+	// entries from the end of the log.
+	LastHash string `json:"lastHash,omitempty"`
+	// BrokenAt is the ID of the first entry whose hash or link doesn't match
+	BrokenAt uint64 `json:"brokenAt,omitempty"`
+	// Error describes why the chain is broken
+	Error string `json:"error,omitempty"`
+}
+
+// Verify checks the hash chain of entries, oldest first. The link of the first chained entry
+// to its predecessor isn't checked, so a log truncated by retention still verifies. Unchained
+// entries don't verify: their hashes may have been removed to hide changes.
+func Verify(entries []Entry, key []byte) Verification {
+	vr := verifier{key: key}
+	for _, e := range entries {
+		vr.add(e)
+	}
+	return vr.result()
+}
+
+// VerifyReader checks the hash chain of entries written as JSON lines like Verify, reading one
+// entry at a time so that large exports aren't held in memory
+func VerifyReader(r io.Reader, key []byte) (Verification, error) {
+	vr := verifier{key: key}
+	err := scanEntries(r, func(e Entry) {
+		vr.add(e)
+	})
+	if err != nil {
+		return Verification{}, err
+	}
+	return vr.result(), nil
+}
+
+// verifier checks the hash chain one entry at a time
+type verifier struct {
+	key     []byte
+	v       Verification
+	broken  bool
+	prev    string
+	chained bool
+}
+
+// add checks the next entry, entries after the first broken one are only counted
+func (vr *verifier) add(e Entry) {
+	v := &vr.v
+	prevID := v.LastID
+	v.Entries++
+	if v.Entries == 1 {
+		v.FirstID = e.ID
+	}
+	v.LastID = e.ID
+	if vr.broken {
+		return
+	}
+
+	if e.Hash == "" && !vr.chained {
+		v.Unchained++
+		return
+	}
+	switch {
+	case v.Entries > 1 && e.ID <= prevID:
+		v.fail(e.ID, "entry %d is out of order", e.ID)
+	case e.Hash == "":
+		v.fail(e.ID, "entry %d has no hash", e.ID)
+	case vr.chained && e.PrevHash != vr.prev:
+		v.fail(e.ID, "entry %d doesn't link to entry %d", e.ID, prevID)
+	default:
+		h, err := hashEntry(vr.key, e)
+		if err != nil || !hmac.Equal([]byte(h), []byte(e.Hash)) {
+			v.fail(e.ID, "entry %d was modified or signed with another key", e.ID)
+		}
+	}
+	vr.broken = v.Error != ""
+	vr.prev, vr.chained = e.Hash, true
+}
+
+// result returns the verification of the entries added so far
+func (vr *verifier) result() Verification {
+	v := vr.v
+	switch {
+	case vr.broken:
+	case v.Unchained > 0:
+		// Without a hash nothing shows whether the entries were changed, or whether their
+		// hashes were removed to hide a change
+		v.fail(v.FirstID, "the first %d entries aren't chained, their integrity can't be verified", v.Unchained)
+	default:
+		v.Valid = true
+		v.LastHash = vr.prev
+	}
+	return v
+}
+
+// fail marks the chain as broken at the entry with the ID
+func (v *Verification) fail(id uint64, format string, args ...any) {
+	v.Valid = false
+	v.BrokenAt = id
+	v.Error = fmt.Sprintf(format, args...)
+}
+
+// ReadEntries reads entries written as JSON lines, e.g. an export of the log
+func ReadEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	err := scanEntries(r, func(e Entry) {
+		entries = append(entries, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// scanEntries parses entries written as JSON lines and passes them to fn one at a time
+func scanEntries(r io.Reader, fn func(Entry)) error {
+	scanner := bufio.NewScanner(r)
+	// Snapshots can make lines long
+	scanner.Buffer(make([]byte, 64*1024), 64<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("failed to parse audit log line %d: %w", line, err)
+		}
+		fn(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// WriteEntries writes entries as JSON lines in the format of the FileLog
+func WriteEntries(w io.Writer, entries []Entry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
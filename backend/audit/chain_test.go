@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordAll records entries for the actions and returns them oldest first
+func recordAll(t *testing.T, l Log, actions ...string) []Entry {
+	t.Helper()
+	for _, action := range actions {
+		_, err := l.Record(context.Background(), Entry{User: "alice", Action: action, Outcome: OutcomeSuccess,
+			Details: map[string]string{"table": "users"}, Snapshot: json.RawMessage(`{"keys": 3}`)})
+		require.NoError(t, err)
+	}
+	entries, err := l.List(context.Background(), Query{})
+	require.NoError(t, err)
+	slices.Reverse(entries)
+	return entries
+}
+
+func TestVerify(t *testing.T) {
+	key := []byte("signing-key")
+	entries := recordAll(t, NewMemoryLog(100, WithSigningKey(key)), "table.create", "key.put", "table.delete")
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+
+	v := Verify(entries, key)
+	assert.True(t, v.Valid, v.Error)
+	assert.Equal(t, 3, v.Entries)
+	assert.Equal(t, entries[2].Hash, v.LastHash)
+
+	assert.False(t, Verify(entries, []byte("other-key")).Valid, "the chain is signed")
+
+	// The export survives a round trip through JSON lines
+	var buf bytes.Buffer
+	require.NoError(t, WriteEntries(&buf, entries))
+	exported, err := ReadEntries(&buf)
+	require.NoError(t, err)
+	assert.True(t, Verify(exported, key).Valid)
+	require.NoError(t, WriteEntries(&buf, entries))
+	streamed, err := VerifyReader(&buf, key)
+	require.NoError(t, err)
+	assert.Equal(t, v, streamed)
+
+	// A truncated log still verifies from its first entry
+	assert.True(t, Verify(entries[1:], key).Valid)
+
+	tampered := slices.Clone(entries)
+	tampered[1].User = "mallory"
+	v = Verify(tampered, key)
+	assert.False(t, v.Valid)
+	assert.Equal(t, uint64(2), v.BrokenAt)
+
+	removed := []Entry{entries[0], entries[2]}
+	v = Verify(removed, key)
+	assert.False(t, v.Valid)
+	assert.Equal(t, uint64(3), v.BrokenAt)
+	assert.Equal(t, 2, v.Entries)
+
+	// Removing the hashes of the first entries doesn't hide a change
+	stripped := slices.Clone(entries)
+	stripped[0].User, stripped[0].Hash = "mallory", ""
+	stripped[1].Hash, stripped[1].PrevHash = "", ""
+	v = Verify(stripped, key)
+	assert.False(t, v.Valid)
+	assert.Equal(t, 2, v.Unchained)
+	assert.Equal(t, uint64(1), v.BrokenAt)
+	assert.Empty(t, v.LastHash)
+}
+
+func TestFileLogContinuesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	// Entries written before the log was chained have no hashes
+	require.NoError(t, os.WriteFile(path, []byte(`{"id":1,"time":"2025-01-01T00:00:00Z","user":"bob","action":"table.create","resource":"tables/users","outcome":"success"}`+"\n"), 0o600))
+
+	l, err := OpenFileLog(path)
+	require.NoError(t, err)
+	recordAll(t, l, "key.put")
+	require.NoError(t, l.Close())
+
+	reopened, err := OpenFileLog(path)
+	require.NoError(t, err)
+	defer reopened.Close()
+	entries := recordAll(t, reopened, "table.delete")
+	require.Len(t, entries, 3)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash, "the chain continues after a restart")
+
+	// The entry recorded before the chain can't be verified until retention removes it
+	v := Verify(entries, nil)
+	assert.False(t, v.Valid)
+	assert.Equal(t, 1, v.Unchained)
+	assert.True(t, Verify(entries[1:], nil).Valid)
+}
+
+func TestFileLogPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := OpenFileLog(path, WithSigningKey([]byte("signing-key")))
+	require.NoError(t, err)
+	defer l.Close()
+	entries := recordAll(t, l, "table.create", "key.put", "key.put", "table.delete")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// Entries recorded before the cutoff are removed
+	removed, reclaimed, err := l.Prune(entries[1].Time, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	pruned, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size()-pruned.Size(), reclaimed)
+
+	// The oldest entries are removed until the log fits, the newest one is always kept
+	removed, _, err = l.Prune(time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	remaining := recordAll(t, l, "table.create")
+	require.Len(t, remaining, 2)
+	assert.Equal(t, entries[3].ID, remaining[0].ID)
+	v := Verify(remaining, []byte("signing-key"))
+	assert.True(t, v.Valid, "the chain continues after pruning: %s", v.Error)
+}
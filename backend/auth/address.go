@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+type addressKey struct{}
+
+// WithClientAddress returns a context carrying the IP address of the client, e.g. for requests
+// served on behalf of a client connected by other means
+func WithClientAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, addressKey{}, address)
+}
+
+// ParseTrustedProxies parses the CIDRs of trusted proxies, e.g. 10.0.0.0/8
+func ParseTrustedProxies(cidrs []string) ([]netip.Prefix, error) {
+	var trusted []netip.Prefix
+	for _, cidr := range cidrs {
+		p, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		trusted = append(trusted, p.Masked())
+	}
+	return trusted, nil
+}
+
+// ClientAddresses resolves the IP address of the client of every request. Requests from the
+// trusted proxies are attributed to the address they forwarded them for in X-Forwarded-For,
+// addresses appended by further trusted proxies are skipped. Without trusted proxies the
+// address the request was received from is the client. Requests served on behalf of a client,
+// whose context already carries its address, keep it.
+func ClientAddresses(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Value(addressKey{}).(string); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			address := remoteAddress(r.RemoteAddr)
+			if isTrusted(trusted, address) {
+				address = forwardedFor(r.Header.Values("X-Forwarded-For"), trusted, address)
+			}
+			next.ServeHTTP(w, r.WithContext(WithClientAddress(r.Context(), address)))
+		})
+	}
+}
+
+// ClientAddress returns the IP address of the client of a request, or an empty string if it
+// is unknown
+func ClientAddress(r *http.Request) string {
+	if address, ok := r.Context().Value(addressKey{}).(string); ok {
+		return address
+	}
+	return remoteAddress(r.RemoteAddr)
+}
+
+// remoteAddress returns the IP address of a host:port, or an empty string if it isn't one
+func remoteAddress(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return ""
+	}
+	return addr.Unmap().String()
+}
+
+// forwardedFor returns the last address of the X-Forwarded-For headers that isn't a trusted
+// proxy. Earlier addresses were given by the client and can't be trusted.
+func forwardedFor(headers []string, trusted []netip.Prefix, proxy string) string {
+	var hops []string
+	for _, header := range headers {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	address := proxy
+	for _, hop := range slices.Backward(hops) {
+		hop = remoteAddress(strings.TrimSpace(hop))
+		if hop == "" {
+			// A malformed entry hides the client, attributing it to the proxy would lock out
+			// everyone behind it
+			return ""
+		}
+		address = hop
+		if !isTrusted(trusted, hop) {
+			break
+		}
+	}
+	return address
+}
+
+// isTrusted reports whether the address belongs to a trusted proxy
+func isTrusted(trusted []netip.Prefix, address string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(trusted, func(p netip.Prefix) bool {
+		return p.Contains(addr)
+	})
+}
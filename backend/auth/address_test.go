@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientAddresses(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 2001:db8::/32"})
+	require.NoError(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.1"})
+	assert.Error(t, err)
+
+	resolve := func(ctx context.Context, remote string, forwarded ...string) string {
+		var address string
+		handler := ClientAddresses(trusted)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			address = ClientAddress(r)
+		}))
+		r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+		r.RemoteAddr = remote
+		for _, f := range forwarded {
+			r.Header.Add("X-Forwarded-For", f)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return address
+	}
+	ctx := context.Background()
+
+	assert.Equal(t, "192.0.2.1", resolve(ctx, "192.0.2.1:4711"))
+	assert.Equal(t, "192.0.2.1", resolve(ctx, "192.0.2.1:4711", "198.51.100.7"), "only trusted proxies may name the client")
+	assert.Equal(t, "198.51.100.7", resolve(ctx, "10.0.0.2:4711", "198.51.100.7"))
+	assert.Equal(t, "198.51.100.7", resolve(ctx, "[2001:db8::1]:4711", "203.0.113.9, 198.51.100.7", "10.1.1.1"),
+		"addresses added by the client are skipped")
+	assert.Equal(t, "10.0.0.2", resolve(ctx, "10.0.0.2:4711"))
+	assert.Empty(t, resolve(ctx, "10.0.0.2:4711", "unknown"), "a malformed entry hides the client")
+	assert.Empty(t, resolve(ctx, ""), "internal requests have no address")
+	assert.Equal(t, "192.0.2.1", resolve(WithClientAddress(ctx, "192.0.2.1"), ""),
+		"requests served on behalf of a client keep its address")
+}
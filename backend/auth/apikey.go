@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// APIKeyHeader carries an API key, it may also be sent as a bearer token
+	APIKeyHeader = "X-API-Key"
+	// APIKeyPrefix starts every API key, so keys can be told apart from other bearer tokens
+	APIKeyPrefix = "ack_"
+)
+
+// KeyAuthenticator checks API keys, e.g. the apikeys.Manager
+type KeyAuthenticator interface {
+	// AuthenticateKey returns the user an API key identifies, or false if it is unknown, revoked or expired.
+	AuthenticateKey(key string) (User, bool)
+}
+
+// APIKeyAuth authenticates scripts and CI jobs with the API keys created in the console
+type APIKeyAuth struct {
+	keys KeyAuthenticator
+}
+
+// NewAPIKeyAuth creates an APIKeyAuth checking keys with the authenticator
+func NewAPIKeyAuth(keys KeyAuthenticator) *APIKeyAuth {
+	return &APIKeyAuth{keys: keys}
+}
+
+// Middleware authenticates requests carrying an API key in the X-API-Key header or as a bearer
+// token, rejecting invalid keys with 401 Unauthorized. Requests without a key are passed to
+// fallback, e.g. JWT or basic authentication, or rejected if fallback is nil.
+func (a *APIKeyAuth) Middleware(fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		unauthenticated := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}))
+		if fallback != nil {
+			unauthenticated = fallback(next)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := apiKey(r)
+			if !ok {
+				unauthenticated.ServeHTTP(w, r)
+				return
+			}
+			user, ok := a.keys.AuthenticateKey(key)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="unknown, revoked or expired API key"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// apiKey returns the API key of a request, from the X-API-Key header or a bearer token
+func apiKey(r *http.Request) (string, bool) {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key, true
+	}
+	if token, ok := bearerToken(r); ok && strings.HasPrefix(token, APIKeyPrefix) {
+		return token, true
+	}
+	return "", false
+}
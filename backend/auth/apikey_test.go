@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// staticKeys accepts a single API key
+type staticKeys map[string]User
+
+func (k staticKeys) AuthenticateKey(key string) (User, bool) {
+	user, ok := k[key]
+	return user, ok
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	keys := staticKeys{"ack_valid": {Name: "ci", Method: MethodAPIKey}}
+	fallback := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	}
+	handler := NewAPIKeyAuth(keys).Middleware(fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := UserFromContext(r.Context())
+		assert.Equal(t, "ci", user.Name)
+		assert.Equal(t, "apikey:ci", UserName(r.Context()), "keys aren't mistaken for users of the same name")
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{name: "Header", header: APIKeyHeader, value: "ack_valid", want: http.StatusOK},
+		{name: "Bearer", header: "Authorization", value: "Bearer ack_valid", want: http.StatusOK},
+		{name: "Revoked", header: APIKeyHeader, value: "ack_revoked", want: http.StatusUnauthorized},
+		{name: "OtherBearerToken", header: "Authorization", value: "Bearer eyJhbGciOi", want: http.StatusTeapot},
+		{name: "NoKey", want: http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/tables", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.want, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// verifiedTTL is how long verified credentials are remembered. Checking a bcrypt hash
+	// deliberately takes tens of milliseconds, which is too slow for every polled request.
+	verifiedTTL = 5 * time.Minute
+	// maxVerified bounds the number of remembered credentials
+	maxVerified = 128
+)
+
+// Authenticator checks the credentials of further users, e.g. the accounts.Directory of the
+// users managed in the console
+type Authenticator interface {
+	Authenticate(username, password string) bool
+}
+
+// BasicOption configures optional behaviour of the BasicAuth
+type BasicOption func(*BasicAuth)
+
+// WithLocalUsers also accepts the credentials of the users known to the authenticator
+func WithLocalUsers(users Authenticator) BasicOption {
+	return func(b *BasicAuth) {
+		b.users = users
+	}
+}
+
+// WithGuard refuses logins of accounts and client addresses locked out by the guard after
+// failed logins
+func WithGuard(guard *Guard) BasicOption {
+	return func(b *BasicAuth) {
+		b.guard = guard
+	}
+}
+
+// BasicAuth authenticates requests with a configured username and bcrypt password hash
+// using HTTP basic authentication, and optionally the users managed in the console.
+type BasicAuth struct {
+	realm    string
+	username string
+	hash     []byte
+	users    Authenticator
+	guard    *Guard
+
+	// mu protects verified
+	mu sync.Mutex
+	// verified maps digests of recently verified credentials to when they expire
+	verified map[[sha256.Size]byte]time.Time
+}
+
+// NewBasicAuth creates a BasicAuth for the given user. The password hash must be a bcrypt hash,
+// e.g. created with the hash-password command.
+func NewBasicAuth(realm, username, passwordHash string, opts ...BasicOption) (*BasicAuth, error) {
+	if _, err := bcrypt.Cost([]byte(passwordHash)); err != nil {
+		return nil, fmt.Errorf("invalid bcrypt password hash: %w", err)
+	}
+	b := &BasicAuth{
+		realm:    realm,
+		username: username,
+		hash:     []byte(passwordHash),
+		verified: make(map[[sha256.Size]byte]time.Time),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, nil
+}
+
+// Middleware rejects requests without valid credentials with 401 Unauthorized.
+// Authenticated requests carry the user in their context.
+func (b *BasicAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok {
+			b.unauthorized(w)
+			return
+		}
+		address := ClientAddress(r)
+		if b.guard != nil {
+			if wait, locked := b.guard.Locked(username, address); locked {
+				// Locked out logins aren't checked, so guessing the password is pointless
+				seconds := int(wait.Round(time.Second) / time.Second)
+				w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+				http.Error(w, "Too many failed logins, try again later", http.StatusTooManyRequests)
+				return
+			}
+		}
+		if !b.authenticate(username, password) {
+			if b.guard != nil {
+				b.guard.Failed(username, address)
+			}
+			b.unauthorized(w)
+			return
+		}
+		if b.guard != nil {
+			b.guard.Succeeded(username)
+		}
+		next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), User{Name: username, Method: MethodBasic})))
+	})
+}
+
+// unauthorized asks the client for credentials
+func (b *BasicAuth) unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", b.realm))
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
+
+// authenticate verifies the credentials of the configured user or of a local user
+func (b *BasicAuth) authenticate(username, password string) bool {
+	if b.users == nil || username == b.username {
+		return b.check(username, password)
+	}
+	return b.users.Authenticate(username, password)
+}
+
+// check verifies the credentials of the configured user, remembering valid ones for a while
+func (b *BasicAuth) check(username, password string) bool {
+	if subtle.ConstantTimeCompare([]byte(username), []byte(b.username)) != 1 {
+		return false
+	}
+
+	digest := sha256.Sum256([]byte(username + ":" + password))
+	now := time.Now()
+	b.mu.Lock()
+	expires, ok := b.verified[digest]
+	b.mu.Unlock()
+	if ok && now.Before(expires) {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword(b.hash, []byte(password)) != nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.verified) >= maxVerified {
+		for d, e := range b.verified {
+			if !now.Before(e) || len(b.verified) >= maxVerified {
+				delete(b.verified, d)
+			}
+		}
+	}
+	b.verified[digest] = now.Add(verifiedTTL)
+	return true
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash))
+	require.NoError(t, err)
+
+	var user string
+	handler := basic.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = UserName(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     int
+	}{
+		{name: "Valid", username: "admin", password: "s3cret", want: http.StatusOK},
+		{name: "ValidRemembered", username: "admin", password: "s3cret", want: http.StatusOK},
+		{name: "WrongPassword", username: "admin", password: "guess", want: http.StatusUnauthorized},
+		{name: "WrongUser", username: "root", password: "s3cret", want: http.StatusUnauthorized},
+		{name: "Missing", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user = ""
+			req := httptest.NewRequest("GET", "/api/status", nil)
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, "admin", user)
+			} else {
+				assert.Empty(t, user)
+				assert.Contains(t, rr.Header().Get("WWW-Authenticate"), `Basic realm="Armada Console"`)
+			}
+		})
+	}
+}
+
+// localUsers authenticates users by their password
+type localUsers map[string]string
+
+func (l localUsers) Authenticate(username, password string) bool {
+	p, ok := l[username]
+	return ok && p == password
+}
+
+func TestBasicAuthWithLocalUsers(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash),
+		WithLocalUsers(localUsers{"alice": "hunter2", "admin": "other"}))
+	require.NoError(t, err)
+
+	var user User
+	handler := basic.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ = UserFromContext(r.Context())
+	}))
+	serve := func(username, password string) int {
+		req := httptest.NewRequest("GET", "/api/status", nil)
+		req.SetBasicAuth(username, password)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("alice", "hunter2"))
+	assert.Equal(t, User{Name: "alice", Method: MethodBasic}, user)
+	assert.Equal(t, http.StatusOK, serve("admin", "s3cret"))
+	assert.Equal(t, http.StatusUnauthorized, serve("alice", "s3cret"))
+	assert.Equal(t, http.StatusUnauthorized, serve("admin", "other"), "local users can't shadow the configured user")
+}
+
+func TestNewBasicAuthRejectsInvalidHash(t *testing.T) {
+	_, err := NewBasicAuth("Armada Console", "admin", "plaintext")
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+// Package auth identifies the users of the console.
+package auth
+
+import "context"
+
+// Anonymous is the name reported for requests without an authenticated user.
+const Anonymous = "anonymous"
+
+// User is an authenticated user of the console.
+type User struct {
+	// Name identifies the user, e.g. a login name or the subject of a token.
+	Name string `json:"name"`
+	// Method is how the user authenticated, e.g. MethodBasic.
+	Method string `json:"method,omitempty"`
+	// Groups are the groups of the user reported by the identity provider, if any.
+	Groups []string `json:"groups,omitempty"`
+	// DisplayName is a readable name to show, e.g. the preferred username of an OIDC login. Users
+	// may be able to change it, so it must never be used to authorize them.
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// Role is what a user may do in the console
+type Role string
+
+// Roles
+const (
+	// RoleViewer may read the cluster state and data but not change them.
+	RoleViewer Role = "viewer"
+	// RoleOperator may also write keys and create or delete tables.
+	RoleOperator Role = "operator"
+)
+
+// Authentication methods
+const (
+	MethodBasic  = "basic"
+	MethodOIDC   = "oidc"
+	MethodBearer = "bearer"
+	MethodAPIKey = "apikey"
+)
+
+type userKey struct{}
+
+type roleKey struct{}
+
+type readOnlyKey struct{}
+
+// WithUser returns a context carrying the authenticated user
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the authenticated user of a request, if any
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok
+}
+
+// UserName returns the name of the authenticated user or Anonymous. API keys are named
+// apikey:<name>, user names can't contain a colon, so a key is never mistaken for the user it
+// was named after, e.g. in the audit log or as the creator of a resource.
+func UserName(ctx context.Context) string {
+	user, ok := UserFromContext(ctx)
+	switch {
+	case !ok || user.Name == "":
+		return Anonymous
+	case user.Method == MethodAPIKey:
+		return user.Principal().String()
+	}
+	return user.Name
+}
+
+// WithRole returns a context carrying the role of the user
+func WithRole(ctx context.Context, role Role) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFromContext returns the role of the user of a request, if it was determined
+func RoleFromContext(ctx context.Context) (Role, bool) {
+	role, ok := ctx.Value(roleKey{}).(Role)
+	return role, ok
+}
+
+// WithReadOnly returns a context marking the console as read-only, whatever the role of the user
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// ReadOnlyFromContext reports whether the console is read-only for the request
+func ReadOnlyFromContext(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
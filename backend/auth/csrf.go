@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+const (
+	// CSRFHeader carries the CSRF token of the session on requests changing something
+	CSRFHeader = "X-CSRF-Token"
+	// CSRFPath returns the CSRF token of the session of the request
+	CSRFPath = "/api/auth/csrf"
+)
+
+// safeMethods don't change anything, so they are served without a CSRF token
+var safeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+// CSRFResponse carries the CSRF token of a session to the frontend
+type CSRFResponse struct {
+	// Token must be sent in the X-CSRF-Token header with every change, it is empty for
+	// requests that aren't authenticated by a session
+	Token string `json:"token"`
+}
+
+type sessionKey struct{}
+
+// withSession returns a context marking the request as authenticated by the session
+func withSession(ctx context.Context, session Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session a request was authenticated by, if any
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(Session)
+	return session, ok
+}
+
+// CSRFToken returns the CSRF token of a session. It is derived from the secret session ID,
+// so it can neither be guessed by other sites nor computed from the stored handle.
+func CSRFToken(session Session) string {
+	digest := sha256.Sum256([]byte("csrf\x00" + session.ID))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// CSRF rejects requests that change something with credentials a browser attaches on its own
+// with 403 Forbidden, unless they are proven to come from the console itself. Browsers attach the
+// session cookie and remembered basic credentials to requests other sites trigger, but those sites
+// can't read the token. Changes made with a session must carry the CSRF token of the session, other
+// changes, e.g. with basic credentials, must not come from another origin. Requests authenticated
+// by API keys or bearer tokens, which browsers never attach, don't need either.
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(safeMethods, r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if session, ok := SessionFromContext(r.Context()); ok {
+			token := r.Header.Get(CSRFHeader)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(CSRFToken(session))) != 1 {
+				http.Error(w, "Missing or invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		} else if user, ok := UserFromContext(r.Context()); ok && user.Method != MethodAPIKey && user.Method != MethodBearer {
+			if !sameOrigin(r) {
+				http.Error(w, "Cross-origin request refused", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin reports whether a request doesn't come from another site. Browsers report where a
+// request comes from in Sec-Fetch-Site or, if they are older, in Origin. Requests without either
+// aren't sent by browsers, e.g. by scripts, and are accepted.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		// none are requests the user started, e.g. by typing the URL
+		return site == "same-origin" || site == "none"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && u.Host == r.Host
+}
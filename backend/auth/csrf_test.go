@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestCSRF(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash))
+	require.NoError(t, err)
+	sessions := NewSessionStore(metadata.NewMemoryStore(), time.Hour, WithSameSite(http.SameSiteStrictMode))
+	r := chi.NewRouter()
+	r.Use(sessions.Middleware(basic.Middleware))
+	r.Use(CSRF)
+	NewHandler(nil, sessions).RegisterRoutes(r)
+	r.Put("/api/kv/orders", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(method, path string, cookie *http.Cookie, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		} else {
+			req.SetBasicAuth("admin", "s3cret")
+		}
+		if token != "" {
+			req.Header.Set(CSRFHeader, token)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Scripts authenticating every request need no token
+	rr := serve("POST", SessionPath, nil, "")
+	require.Equal(t, http.StatusCreated, rr.Code)
+	cookie := rr.Result().Cookies()[0]
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.Equal(t, http.StatusOK, serve("PUT", "/api/kv/orders", nil, "").Code)
+
+	var csrf CSRFResponse
+	rr = serve("GET", CSRFPath, nil, "")
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&csrf))
+	assert.Empty(t, csrf.Token, "requests without a session have no token")
+	rr = serve("GET", CSRFPath, cookie, "")
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&csrf))
+	require.NotEmpty(t, csrf.Token)
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	// Changes made with the session cookie must carry the token of the session
+	assert.Equal(t, http.StatusForbidden, serve("PUT", "/api/kv/orders", cookie, "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("PUT", "/api/kv/orders", cookie, "forged").Code)
+	assert.Equal(t, http.StatusOK, serve("PUT", "/api/kv/orders", cookie, csrf.Token).Code)
+	assert.Equal(t, http.StatusOK, serve("GET", SessionsPath, cookie, "").Code)
+
+	// Browsers remember basic credentials too, changes made with them must not come from other sites
+	crossSite := func(method, path string, header http.Header) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.SetBasicAuth("admin", "s3cret")
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	assert.Equal(t, http.StatusForbidden, crossSite("PUT", "/api/kv/orders", http.Header{"Sec-Fetch-Site": {"cross-site"}}))
+	assert.Equal(t, http.StatusForbidden, crossSite("PUT", "/api/kv/orders", http.Header{"Sec-Fetch-Site": {"same-site"}}))
+	assert.Equal(t, http.StatusForbidden, crossSite("PUT", "/api/kv/orders", http.Header{"Origin": {"https://evil.example.com"}}))
+	assert.Equal(t, http.StatusForbidden, crossSite("PUT", "/api/kv/orders", http.Header{"Origin": {"null"}}))
+	assert.Equal(t, http.StatusForbidden, crossSite("POST", SessionPath, http.Header{"Sec-Fetch-Site": {"cross-site"}}))
+	assert.Equal(t, http.StatusOK, crossSite("PUT", "/api/kv/orders", http.Header{"Sec-Fetch-Site": {"same-origin"}}))
+	assert.Equal(t, http.StatusOK, crossSite("PUT", "/api/kv/orders", http.Header{"Origin": {"http://example.com"}}))
+	assert.Equal(t, http.StatusOK, crossSite("GET", SessionsPath, http.Header{"Sec-Fetch-Site": {"cross-site"}}))
+
+	// The token of another session isn't accepted
+	rr = serve("POST", SessionPath, nil, "")
+	other := rr.Result().Cookies()[0]
+	assert.Equal(t, http.StatusForbidden, serve("PUT", "/api/kv/orders", other, csrf.Token).Code)
+	assert.Equal(t, http.StatusOK, serve("POST", LogoutPath, cookie, csrf.Token).Code)
+}
+
+func TestCSRFExemptsHeaderCredentials(t *testing.T) {
+	for _, method := range []string{MethodAPIKey, MethodBearer} {
+		r := chi.NewRouter()
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), User{Name: "ci", Method: method})))
+			})
+		})
+		r.Use(CSRF)
+		r.Put("/api/kv/orders", func(w http.ResponseWriter, r *http.Request) {})
+
+		// Browsers never attach these credentials, so other sites can't use them
+		req := httptest.NewRequest("PUT", "/api/kv/orders", nil)
+		req.Header.Set("Sec-Fetch-Site", "cross-site")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, method)
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTracked bounds the number of accounts and addresses whose failed logins are tracked, so
+// guessing random usernames doesn't exhaust the memory
+const maxTracked = 10000
+
+// Lockout kinds
+const (
+	LockoutAccount = "account"
+	LockoutAddress = "address"
+)
+
+// Lockout is reported when an account or a client address is locked out after failed logins
+type Lockout struct {
+	// Kind is LockoutAccount or LockoutAddress.
+	Kind string
+	// Subject is the username or the client address.
+	Subject string
+	// Failures is the number of consecutive failed logins.
+	Failures int
+	// Until is when logins are accepted again.
+	Until time.Time
+}
+
+// failures are the consecutive failed logins of an account or address
+type failures struct {
+	count int
+	last  time.Time
+	until time.Time
+}
+
+// GuardOption configures optional behaviour of the Guard
+type GuardOption func(*Guard)
+
+// WithLockoutHook calls fn whenever an account or address is locked out, e.g. to audit it.
+// It is called synchronously from the login request.
+func WithLockoutHook(fn func(Lockout)) GuardOption {
+	return func(g *Guard) {
+		g.onLockout = fn
+	}
+}
+
+// Guard protects logins against password guessing. After maxFailures consecutive failed
+// logins of an account, or maxPerAddress of a client address, further logins are refused for
+// the lockout duration, which doubles with every further failure up to maxLockout. Failures
+// are forgotten after a successful login of the account, or maxLockout after the last one.
+// A zero limit disables the lockout of accounts or addresses. Logins from an unknown address,
+// e.g. behind a proxy that isn't trusted, only count towards the account, so all clients behind
+// it aren't locked out together. The Guard is safe for concurrent use.
+type Guard struct {
+	maxFailures   int
+	maxPerAddress int
+	lockout       time.Duration
+	maxLockout    time.Duration
+	onLockout     func(Lockout)
+	now           func() time.Time
+
+	// mu protects accounts and addresses
+	mu        sync.Mutex
+	accounts  map[string]*failures
+	addresses map[string]*failures
+}
+
+// NewGuard creates a Guard with the given limits
+func NewGuard(maxFailures, maxPerAddress int, lockout, maxLockout time.Duration, opts ...GuardOption) *Guard {
+	g := &Guard{
+		maxFailures:   maxFailures,
+		maxPerAddress: maxPerAddress,
+		lockout:       lockout,
+		maxLockout:    maxLockout,
+		now:           time.Now,
+		accounts:      make(map[string]*failures),
+		addresses:     make(map[string]*failures),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Locked returns how long logins of the user from the address are refused, if they are
+func (g *Guard) Locked(username, address string) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	var wait time.Duration
+	for _, f := range []*failures{g.accounts[username], g.addresses[address]} {
+		if f != nil && now.Before(f.until) {
+			wait = max(wait, f.until.Sub(now))
+		}
+	}
+	return wait, wait > 0
+}
+
+// Failed counts a failed login of the user from the address, locking them out beyond the limits
+func (g *Guard) Failed(username, address string) {
+	var lockouts []Lockout
+	g.mu.Lock()
+	now := g.now()
+	if l, ok := g.fail(g.accounts, username, g.maxFailures, now); ok {
+		lockouts = append(lockouts, Lockout{Kind: LockoutAccount, Subject: username, Failures: l.count, Until: l.until})
+	}
+	perAddress := g.maxPerAddress
+	if address == "" {
+		// Unknown addresses aren't tracked
+		perAddress = 0
+	}
+	if l, ok := g.fail(g.addresses, address, perAddress, now); ok {
+		lockouts = append(lockouts, Lockout{Kind: LockoutAddress, Subject: address, Failures: l.count, Until: l.until})
+	}
+	g.mu.Unlock()
+
+	if g.onLockout != nil {
+		for _, l := range lockouts {
+			g.onLockout(l)
+		}
+	}
+}
+
+// Succeeded forgets the failed logins of the user. Failures of the address are kept, so a
+// valid account doesn't let the address guess the passwords of others.
+func (g *Guard) Succeeded(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.accounts, username)
+}
+
+// fail counts a failure of the subject and returns its failures if it is locked out by it
+func (g *Guard) fail(tracked map[string]*failures, subject string, limit int, now time.Time) (failures, bool) {
+	if limit <= 0 {
+		return failures{}, false
+	}
+	f, ok := tracked[subject]
+	if !ok || now.Sub(f.last) > g.maxLockout {
+		if len(tracked) >= maxTracked {
+			g.forget(tracked, now)
+		}
+		f = &failures{}
+		tracked[subject] = f
+	}
+	f.count++
+	f.last = now
+	if f.count < limit {
+		return failures{}, false
+	}
+
+	// Every failure beyond the limit doubles the lockout
+	lockout := g.lockout
+	for i := limit; i < f.count && lockout < g.maxLockout; i++ {
+		lockout *= 2
+	}
+	f.until = now.Add(min(lockout, g.maxLockout))
+	return *f, true
+}
+
+// forget removes the subjects whose failures expired. If all of them are recent, the
+// oldest ones are removed to make room.
+func (g *Guard) forget(tracked map[string]*failures, now time.Time) {
+	var oldest string
+	for subject, f := range tracked {
+		if now.Sub(f.last) > g.maxLockout {
+			delete(tracked, subject)
+		} else if oldest == "" || f.last.Before(tracked[oldest].last) {
+			oldest = subject
+		}
+	}
+	if len(tracked) >= maxTracked {
+		delete(tracked, oldest)
+	}
+}
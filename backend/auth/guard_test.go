@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGuardLocksOutAccounts(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var lockouts []Lockout
+	g := NewGuard(3, 0, time.Minute, 10*time.Minute, WithLockoutHook(func(l Lockout) { lockouts = append(lockouts, l) }))
+	g.now = func() time.Time { return now }
+
+	g.Failed("alice", "10.0.0.1")
+	g.Failed("alice", "10.0.0.2")
+	_, locked := g.Locked("alice", "10.0.0.1")
+	assert.False(t, locked)
+	g.Failed("alice", "10.0.0.3")
+	wait, locked := g.Locked("alice", "10.0.0.4")
+	assert.True(t, locked, "accounts are locked out from every address")
+	assert.Equal(t, time.Minute, wait)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, Lockout{Kind: LockoutAccount, Subject: "alice", Failures: 3, Until: now.Add(time.Minute)}, lockouts[0])
+
+	// Every further failure doubles the lockout
+	now = now.Add(time.Minute)
+	g.Failed("alice", "10.0.0.1")
+	wait, _ = g.Locked("alice", "10.0.0.1")
+	assert.Equal(t, 2*time.Minute, wait)
+	for range 5 {
+		now = now.Add(time.Hour / 6)
+		g.Failed("alice", "10.0.0.1")
+	}
+	wait, _ = g.Locked("alice", "10.0.0.1")
+	assert.Equal(t, 10*time.Minute, wait, "lockouts are capped")
+
+	g.Succeeded("alice")
+	_, locked = g.Locked("alice", "10.0.0.1")
+	assert.False(t, locked)
+}
+
+func TestGuardLocksOutAddresses(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGuard(0, 2, time.Minute, time.Hour)
+	g.now = func() time.Time { return now }
+
+	g.Failed("alice", "10.0.0.1")
+	g.Succeeded("bob")
+	g.Failed("carol", "10.0.0.1")
+	_, locked := g.Locked("dave", "10.0.0.1")
+	assert.True(t, locked, "addresses guessing the passwords of several accounts are locked out")
+	_, locked = g.Locked("alice", "10.0.0.2")
+	assert.False(t, locked, "accounts aren't locked out when the limit is 0")
+
+	// Unknown addresses, e.g. behind a proxy that isn't trusted, aren't locked out together
+	g.Failed("alice", "")
+	g.Failed("carol", "")
+	_, locked = g.Locked("dave", "")
+	assert.False(t, locked)
+
+	// Failures are forgotten after the maximum lockout
+	now = now.Add(2 * time.Hour)
+	g.Failed("alice", "10.0.0.1")
+	_, locked = g.Locked("alice", "10.0.0.1")
+	assert.False(t, locked)
+}
+
+func TestBasicAuthWithGuard(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash), WithGuard(NewGuard(2, 0, time.Minute, time.Hour)))
+	require.NoError(t, err)
+	handler := basic.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	serve := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/status", nil)
+		req.SetBasicAuth("admin", password)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("guess").Code)
+	assert.Equal(t, http.StatusOK, serve("s3cret").Code, "a successful login resets the failures")
+	assert.Equal(t, http.StatusUnauthorized, serve("guess").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("guess").Code)
+	rr := serve("s3cret")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "even the right password is refused while locked out")
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+}
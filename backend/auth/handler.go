@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+)
+
+// Me describes the user of a request to the frontend
+type Me struct {
+	User
+	// Authenticated is false when authentication is disabled
+	Authenticated bool `json:"authenticated"`
+	// Role is what the user may do, empty if roles are not enforced
+	Role Role `json:"role,omitempty"`
+	// ReadOnly is true when the console refuses all changes, whatever the role
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// LogoutResponse tells the frontend where to end the session at the identity provider
+type LogoutResponse struct {
+	// LogoutURL ends the session at the provider, empty if there is none to end
+	LogoutURL string `json:"logoutUrl,omitempty"`
+}
+
+// Paths of the session endpoints
+const (
+	SessionPath  = "/api/auth/session"
+	SessionsPath = "/api/auth/sessions"
+)
+
+// SessionInfo describes a session of the user to the frontend
+type SessionInfo struct {
+	Session
+	// Current marks the session of the request
+	Current bool `json:"current"`
+}
+
+// EndedSessions reports how many sessions were ended
+type EndedSessions struct {
+	Ended int `json:"ended"`
+}
+
+// SelfServicePath reports whether requests to the path only concern the sessions of their own
+// user, so any user may send them whatever their role
+func SelfServicePath(path string) bool {
+	return path == LogoutPath || path == SessionPath || path == SessionsPath ||
+		strings.HasPrefix(path, SessionsPath+"/")
+}
+
+// Handler serves the endpoints the frontend drives the login with
+type Handler struct {
+	// oidc is the login flow of the OpenID provider, it may be nil
+	oidc *OIDC
+	// sessions are the sessions of logged-in browsers, they may be nil
+	sessions *SessionStore
+}
+
+// NewHandler creates the authentication endpoints. The login flow is only served if oidc is not
+// nil, the session endpoints if there are sessions, which are those of the login flow if
+// sessions is nil.
+func NewHandler(oidc *OIDC, sessions *SessionStore) *Handler {
+	if sessions == nil && oidc != nil {
+		sessions = oidc.sessions
+	}
+	return &Handler{oidc: oidc, sessions: sessions}
+}
+
+// RegisterRoutes registers the authentication routes
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/auth/me", h.handleMe)
+	r.Post(LogoutPath, h.handleLogout)
+	if h.oidc != nil {
+		r.Get(LoginPath, h.oidc.handleLogin)
+		r.Get(CallbackPath, h.oidc.handleCallback)
+	}
+	if h.sessions != nil {
+		r.Get(CSRFPath, h.handleCSRF)
+		r.Post(SessionPath, h.handleStartSession)
+		r.Get(SessionsPath, h.handleListSessions)
+		r.Post(SessionsPath+"/logout-others", h.handleEndOtherSessions)
+		r.Delete(SessionsPath+"/{handle}", h.handleEndSession)
+	}
+}
+
+// handleMe returns the authenticated user
+// @Summary Get current user
+// @Description Get the logged-in user and their role
+// @Tags auth
+// @Produce json
+// @Success 200 {object} Me
+// @Router /api/auth/me [get]
+func (h *Handler) handleMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFromContext(r.Context())
+	if !ok {
+		user = User{Name: Anonymous}
+	}
+	role, _ := RoleFromContext(r.Context())
+	chix.NewRender(w).JSON(Me{User: user, Authenticated: ok, Role: role, ReadOnly: ReadOnlyFromContext(r.Context())})
+}
+
+// handleLogout ends the session of the browser. Browsers can't be logged out of basic
+// authentication, they keep sending the credentials until they are closed.
+// @Summary Log out
+// @Description End the session, the response carries the URL to log out at the OIDC provider
+// @Tags auth
+// @Produce json
+// @Success 200 {object} LogoutResponse
+// @Router /api/auth/logout [post]
+func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if h.sessions != nil {
+		if session, ok := h.sessions.FromRequest(r); ok {
+			if err := h.sessions.Delete(session.ID); err != nil {
+				http.Error(w, "Failed to end session", http.StatusInternalServerError)
+				return
+			}
+		}
+		http.SetCookie(w, h.sessions.Cookie(r, "", time.Unix(0, 0)))
+	}
+	var resp LogoutResponse
+	if h.oidc != nil {
+		resp.LogoutURL = h.oidc.logoutURL()
+	}
+	chix.NewRender(w).JSON(resp)
+}
+
+// handleCSRF returns the CSRF token of the session of the request
+// @Summary Get CSRF token
+// @Description Get the token to send in the X-CSRF-Token header with every change made with the session cookie
+// @Tags auth
+// @Produce json
+// @Success 200 {object} CSRFResponse
+// @Router /api/auth/csrf [get]
+func (h *Handler) handleCSRF(w http.ResponseWriter, r *http.Request) {
+	var resp CSRFResponse
+	if session, ok := SessionFromContext(r.Context()); ok {
+		resp.Token = CSRFToken(session)
+	}
+	// The token must not be cached, it changes with the session
+	w.Header().Set("Cache-Control", "no-store")
+	chix.NewRender(w).JSON(resp)
+}
+
+// handleStartSession starts a session for a user who authenticated with basic authentication,
+// so browsers of local users can log out and are subject to the session timeouts
+// @Summary Start session
+// @Description Start a session for the user of the basic authentication credentials, the response sets the session cookie
+// @Tags auth
+// @Produce json
+// @Success 201 {object} Session
+// @Failure 400 {string} string "Sessions are started with basic authentication"
+// @Router /api/auth/session [post]
+func (h *Handler) handleStartSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := UserFromContext(r.Context())
+	if !ok || user.Method != MethodBasic {
+		http.Error(w, "Sessions are started with basic authentication", http.StatusBadRequest)
+		return
+	}
+	session, err := h.sessions.Create(user, r)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, h.sessions.Cookie(r, session.ID, session.Expires))
+
+	render := chix.NewRender(w)
+	render.Status(http.StatusCreated)
+	render.JSON(session)
+}
+
+// handleListSessions returns the sessions of the user
+// @Summary List sessions
+// @Description List the active sessions of the logged-in user with the devices they were started from
+// @Tags auth
+// @Produce json
+// @Success 200 {array} SessionInfo
+// @Router /api/auth/sessions [get]
+func (h *Handler) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+	sessions, err := h.sessions.Sessions(user)
+	if err != nil {
+		http.Error(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+	current, _ := h.sessions.FromRequest(r)
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, SessionInfo{Session: session, Current: session.Handle == current.Handle})
+	}
+	chix.NewRender(w).JSON(infos)
+}
+
+// handleEndOtherSessions ends all sessions of the user except the one of the request
+// @Summary Log out other sessions
+// @Description End all sessions of the logged-in user except the current one
+// @Tags auth
+// @Produce json
+// @Success 200 {object} EndedSessions
+// @Router /api/auth/sessions/logout-others [post]
+func (h *Handler) handleEndOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+	current, _ := h.sessions.FromRequest(r)
+	ended, err := h.sessions.EndOthers(user, current.Handle)
+	if err != nil {
+		http.Error(w, "Failed to end sessions", http.StatusInternalServerError)
+		return
+	}
+	chix.NewRender(w).JSON(EndedSessions{Ended: ended})
+}
+
+// handleEndSession ends one of the sessions of the user
+// @Summary End session
+// @Description End a session of the logged-in user, e.g. on a lost device
+// @Tags auth
+// @Produce json
+// @Param handle path string true "Session handle"
+// @Success 200 {object} map[string]any
+// @Failure 404 {string} string "Session not found"
+// @Router /api/auth/sessions/{handle} [delete]
+func (h *Handler) handleEndSession(w http.ResponseWriter, r *http.Request) {
+	user, _ := UserFromContext(r.Context())
+	ended, err := h.sessions.End(user, chi.URLParam(r, "handle"))
+	if err != nil {
+		http.Error(w, "Failed to end session", http.StatusInternalServerError)
+		return
+	}
+	if !ended {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	chix.NewRender(w).JSON(make(map[string]any))
+}
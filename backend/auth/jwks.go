@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// minRefetch is how long a key set is used before an unknown key ID triggers another fetch.
+	// It prevents clients presenting made-up key IDs from hammering the identity provider.
+	minRefetch = time.Minute
+	// tokenLeeway tolerates clock differences between the console and the token issuer
+	tokenLeeway = 30 * time.Second
+)
+
+// ErrUnknownKey is returned when a token is signed with a key the key set does not contain
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// signingMethods are the asymmetric algorithms accepted for tokens. Symmetric algorithms are
+// rejected, as the verification keys are published.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// KeySource provides the public keys tokens are verified with
+type KeySource interface {
+	Key(ctx context.Context, kid string) (any, error)
+}
+
+// newTokenParser creates a parser for tokens of the issuer signed with an asymmetric algorithm.
+// If audience is not empty, tokens must be issued for it.
+func newTokenParser(issuer, audience string) *jwt.Parser {
+	opts := []jwt.ParserOption{
+		jwt.WithIssuer(issuer),
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(tokenLeeway),
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return jwt.NewParser(opts...)
+}
+
+// verifyToken verifies the signature and standard claims of a token and returns its claims
+func verifyToken(ctx context.Context, parser *jwt.Parser, keys KeySource, token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.Key(ctx, kid)
+	})
+	return claims, err
+}
+
+// stringsClaim returns a claim holding a list of strings, such as the groups of a user.
+// A claim holding a single string is returned as a list of one.
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		if len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// JWKS is a KeySource fetching a JSON Web Key Set from the URL published by the issuer.
+// The set is fetched again once it is older than the refresh interval, or earlier when a
+// token is signed with an unknown key, so that rotated keys are picked up.
+type JWKS struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+
+	// mu protects the fields below and serializes fetches
+	mu      sync.Mutex
+	keys    map[string]any
+	fetched time.Time
+}
+
+// NewJWKS creates a JWKS fetching the key set from url with client
+func NewJWKS(url string, client *http.Client, refresh time.Duration) *JWKS {
+	return &JWKS{
+		url:     url,
+		client:  client,
+		refresh: refresh,
+	}
+}
+
+// Key returns the public key with the ID kid. Tokens without a key ID are verified with
+// the only key of the set, if it has exactly one.
+func (s *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.fetched)
+	key, ok := s.lookup(kid)
+	if s.keys == nil || age > s.refresh || (!ok && age > minRefetch) {
+		if err := s.fetch(ctx); err != nil {
+			if s.keys == nil {
+				return nil, err
+			}
+			// Keep using the previous set while the issuer is unavailable
+		} else {
+			key, ok = s.lookup(kid)
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// lookup returns a key of the current set
+func (s *JWKS) lookup(kid string) (any, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// fetch replaces the key set with the one published at the URL
+func (s *JWKS) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch key set: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch key set: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode key set: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped, tokens signed with them fail as signed with an unknown key
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	s.keys = keys
+	s.fetched = time.Now()
+	return nil
+}
+
+// jsonWebKey is a public key of a JSON Web Key Set (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// Elliptic curve and Ed25519 keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey decodes the key into the type expected by the jwt signing methods
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTAuth authenticates requests carrying a signed JWT bearer token, e.g. from scripts
+// and other machine clients that cannot log in interactively.
+type JWTAuth struct {
+	keys        KeySource
+	userClaim   string
+	groupsClaim string
+	parser      *jwt.Parser
+}
+
+// NewJWTAuth creates a JWTAuth accepting tokens of the issuer verified with the keys.
+// If audience is not empty, tokens must be issued for it. The user is taken from userClaim, e.g. "sub",
+// and the user's groups from groupsClaim, e.g. "groups".
+func NewJWTAuth(issuer, audience, userClaim, groupsClaim string, keys KeySource) *JWTAuth {
+	return &JWTAuth{
+		keys:        keys,
+		userClaim:   userClaim,
+		groupsClaim: groupsClaim,
+		parser:      newTokenParser(issuer, audience),
+	}
+}
+
+// Authenticate verifies a token and returns the user it was issued to
+func (j *JWTAuth) Authenticate(ctx context.Context, token string) (User, error) {
+	claims, err := verifyToken(ctx, j.parser, j.keys, token)
+	if err != nil {
+		return User{}, err
+	}
+	name, _ := claims[j.userClaim].(string)
+	if name == "" {
+		return User{}, fmt.Errorf("token has no %s claim", j.userClaim)
+	}
+	return User{Name: name, Method: MethodBearer, Groups: stringsClaim(claims, j.groupsClaim)}, nil
+}
+
+// Middleware authenticates requests carrying a bearer token, rejecting invalid tokens with
+// 401 Unauthorized. Requests without a token are passed to fallback, e.g. basic authentication,
+// or rejected if fallback is nil. Authenticated requests carry the user in their context.
+func (j *JWTAuth) Middleware(fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		unauthenticated := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}))
+		if fallback != nil {
+			unauthenticated = fallback(next)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				unauthenticated.ServeHTTP(w, r)
+				return
+			}
+			user, err := j.Authenticate(r.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=\"invalid_token\", error_description=%q", err.Error()))
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUser(r.Context(), user)))
+		})
+	}
+}
+
+// bearerToken returns the token of a bearer Authorization header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testIssuer = "https://idp.example.com"
+
+// keyServer publishes the public keys of a JSON Web Key Set and counts the fetches
+type keyServer struct {
+	keys    map[string]*ecdsa.PrivateKey
+	fetches atomic.Int32
+}
+
+func (s *keyServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.fetches.Add(1)
+	set := struct {
+		Keys []jsonWebKey `json:"keys"`
+	}{}
+	for kid, key := range s.keys {
+		set.Keys = append(set.Keys, jsonWebKey{
+			Kty: "EC", Kid: kid, Use: "sig", Crv: "P-256",
+			X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(set)
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func sign(t *testing.T, kid string, key *ecdsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": testIssuer,
+		"sub": "deploy-bot",
+		"aud": "armada-console",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestJWTAuth(t *testing.T) {
+	key := newKey(t)
+	keys := &keyServer{keys: map[string]*ecdsa.PrivateKey{"k1": key}}
+	srv := httptest.NewServer(keys)
+	defer srv.Close()
+	bearer := NewJWTAuth(testIssuer, "armada-console", "sub", "groups", NewJWKS(srv.URL, srv.Client(), time.Hour))
+
+	var user string
+	handler := bearer.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = UserName(r.Context())
+	}))
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongIssuer := validClaims()
+	wrongIssuer["iss"] = "https://evil.example.com"
+	wrongAudience := validClaims()
+	wrongAudience["aud"] = "other"
+	withoutExpiry := validClaims()
+	delete(withoutExpiry, "exp")
+	hmac, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{name: "Valid", token: sign(t, "k1", key, validClaims()), want: http.StatusOK},
+		{name: "Expired", token: sign(t, "k1", key, expired), want: http.StatusUnauthorized},
+		{name: "WrongIssuer", token: sign(t, "k1", key, wrongIssuer), want: http.StatusUnauthorized},
+		{name: "WrongAudience", token: sign(t, "k1", key, wrongAudience), want: http.StatusUnauthorized},
+		{name: "WithoutExpiry", token: sign(t, "k1", key, withoutExpiry), want: http.StatusUnauthorized},
+		{name: "WrongKey", token: sign(t, "k1", newKey(t), validClaims()), want: http.StatusUnauthorized},
+		{name: "Symmetric", token: hmac, want: http.StatusUnauthorized},
+		{name: "Missing", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user = ""
+			req := httptest.NewRequest("GET", "/api/tables", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Code)
+			if tt.want == http.StatusOK {
+				assert.Equal(t, "deploy-bot", user)
+			} else {
+				assert.Empty(t, user)
+				assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+	assert.Equal(t, int32(1), keys.fetches.Load())
+}
+
+func TestJWTAuthFallsBackWithoutToken(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash))
+	require.NoError(t, err)
+	bearer := NewJWTAuth(testIssuer, "", "sub", "groups", &JWKS{})
+
+	var user string
+	handler := bearer.Middleware(basic.Middleware)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = UserName(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/api/tables", nil)
+	req.SetBasicAuth("admin", "s3cret")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "admin", user)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tables", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Basic")
+}
+
+func TestJWKSPicksUpRotatedKeys(t *testing.T) {
+	keys := &keyServer{keys: map[string]*ecdsa.PrivateKey{"k1": newKey(t)}}
+	srv := httptest.NewServer(keys)
+	defer srv.Close()
+	jwks := NewJWKS(srv.URL, srv.Client(), time.Hour)
+
+	_, err := jwks.Key(t.Context(), "k1")
+	require.NoError(t, err)
+
+	// Unknown keys do not trigger a fetch until the set is a minute old
+	keys.keys["k2"] = newKey(t)
+	_, err = jwks.Key(t.Context(), "k2")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, int32(1), keys.fetches.Load())
+
+	jwks.fetched = jwks.fetched.Add(-2 * minRefetch)
+	key, err := jwks.Key(t.Context(), "k2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, key.(*ecdsa.PublicKey).X.Cmp(keys.keys["k2"].X))
+	assert.Equal(t, int32(2), keys.fetches.Load())
+}
+
+func TestJSONWebKeyRejectsInvalidKeys(t *testing.T) {
+	_, err := jsonWebKey{Kty: "oct", Kid: "k"}.publicKey()
+	assert.Error(t, err)
+
+	offCurve := jsonWebKey{Kty: "EC", Crv: "P-256",
+		X: base64.RawURLEncoding.EncodeToString(big.NewInt(1).Bytes()),
+		Y: base64.RawURLEncoding.EncodeToString(big.NewInt(1).Bytes())}
+	_, err = offCurve.publicKey()
+	assert.Error(t, err)
+}
@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// Paths of the login flow, which are served without authentication
+const (
+	LoginPath    = "/api/auth/login"
+	CallbackPath = "/api/auth/callback"
+	LogoutPath   = "/api/auth/logout"
+)
+
+// loginTimeout is how long users have to log in at the provider
+const loginTimeout = 10 * time.Minute
+
+// LoginCookie carries the login a browser started, so the callback only completes logins of the
+// same browser and the console keeps no state for logins that are never completed
+const LoginCookie = "console_login"
+
+// OIDCConfig configures login with an OpenID Connect provider
+type OIDCConfig struct {
+	// Issuer is the URL of the provider, its configuration is discovered from it.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL of the console registered with the provider.
+	RedirectURL string
+	// Scopes are requested in addition to openid.
+	Scopes []string
+	// SessionTTL is how long users stay logged in.
+	SessionTTL time.Duration
+	// Sessions keeps the sessions of logged-in users. If nil, they are kept in memory only and
+	// expire after the SessionTTL.
+	Sessions *SessionStore
+	// GroupsClaim is the ID token claim listing the groups of the user, e.g. "groups".
+	GroupsClaim string
+}
+
+// ProviderMetadata is the configuration an OpenID Connect provider publishes
+type ProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint,omitempty"`
+}
+
+// Discover fetches the configuration of an OpenID Connect provider
+func Discover(ctx context.Context, client *http.Client, issuer string) (ProviderMetadata, error) {
+	wellKnown := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return ProviderMetadata{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ProviderMetadata{}, fmt.Errorf("failed to discover OpenID provider: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ProviderMetadata{}, fmt.Errorf("failed to discover OpenID provider: %s", resp.Status)
+	}
+
+	var md ProviderMetadata
+	if err := json.NewDecoder(resp.Body).Decode(&md); err != nil {
+		return ProviderMetadata{}, fmt.Errorf("failed to decode OpenID provider configuration: %w", err)
+	}
+	// The issuer must match exactly, otherwise tokens of another provider could be accepted
+	if md.Issuer != issuer {
+		return ProviderMetadata{}, fmt.Errorf("OpenID provider reports issuer %q, expected %q", md.Issuer, issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return ProviderMetadata{}, errors.New("OpenID provider configuration lacks required endpoints")
+	}
+	return md, nil
+}
+
+// pendingLogin is a login in progress at the provider, kept in the LoginCookie of the browser
+type pendingLogin struct {
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+	ReturnTo string    `json:"returnTo"`
+	Expires  time.Time `json:"expires"`
+}
+
+// OIDC delegates the login of browsers to an OpenID Connect provider using the
+// authorization code flow with PKCE. Logged-in browsers are identified by a session cookie.
+type OIDC struct {
+	oauth    oauth2.Config
+	provider ProviderMetadata
+	client   *http.Client
+	keys     KeySource
+	parser   *jwt.Parser
+	sessions *SessionStore
+	// groupsClaim is the ID token claim listing the groups of the user
+	groupsClaim string
+}
+
+// NewOIDC discovers the provider and creates an OIDC login flow
+func NewOIDC(ctx context.Context, cfg OIDCConfig, client *http.Client) (*OIDC, error) {
+	provider, err := Discover(ctx, client, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	sessions := cfg.Sessions
+	if sessions == nil {
+		// Browsers only send secure cookies over HTTPS, so the flag follows the callback URL
+		sessions = NewSessionStore(metadata.NewMemoryStore(), cfg.SessionTTL,
+			WithSecureCookies(strings.HasPrefix(cfg.RedirectURL, "https://")))
+	}
+	return &OIDC{
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       append([]string{"openid"}, cfg.Scopes...),
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  provider.AuthorizationEndpoint,
+				TokenURL: provider.TokenEndpoint,
+			},
+		},
+		provider:    provider,
+		client:      client,
+		keys:        NewJWKS(provider.JWKSURI, client, time.Hour),
+		parser:      newTokenParser(provider.Issuer, cfg.ClientID),
+		sessions:    sessions,
+		groupsClaim: cfg.GroupsClaim,
+	}, nil
+}
+
+// Middleware authenticates requests with the session cookie. Requests without a session
+// carrying an Authorization header are passed to fallback, e.g. basic authentication for
+// scripts, if it is not nil. Other unauthenticated API requests are rejected with 401
+// Unauthorized and browsers are redirected to the login.
+func (o *OIDC) Middleware(fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		var credentials http.Handler
+		if fallback != nil {
+			credentials = fallback(next)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case LoginPath, CallbackPath, LogoutPath:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if session, ok := o.sessions.FromRequest(r); ok {
+				next.ServeHTTP(w, r.WithContext(withSession(WithUser(r.Context(), session.User), session)))
+				return
+			}
+			if credentials != nil && r.Header.Get("Authorization") != "" {
+				credentials.ServeHTTP(w, r)
+				return
+			}
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			http.Redirect(w, r, basepath.Path(r.Context(), LoginPath)+"?returnTo="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+		})
+	}
+}
+
+// handleLogin redirects the browser to the provider to log in
+// @Summary Log in
+// @Description Redirect the browser to the OIDC provider to log in
+// @Tags auth
+// @Param returnTo query string false "Console path to return to after the login"
+// @Success 302 "Redirect to the provider"
+// @Router /api/auth/login [get]
+func (o *OIDC) handleLogin(w http.ResponseWriter, r *http.Request) {
+	state, err := randomToken()
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	login := pendingLogin{
+		State:    state,
+		Nonce:    nonce,
+		Verifier: oauth2.GenerateVerifier(),
+		ReturnTo: localPath(r.URL.Query().Get("returnTo")),
+		Expires:  time.Now().Add(loginTimeout),
+	}
+	encoded, err := json.Marshal(login)
+	if err != nil {
+		http.Error(w, "Failed to start login", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, o.loginCookie(r, base64.RawURLEncoding.EncodeToString(encoded), int(loginTimeout/time.Second)))
+
+	http.Redirect(w, r, o.oauth.AuthCodeURL(state,
+		oauth2.S256ChallengeOption(login.Verifier),
+		oauth2.SetAuthURLParam("nonce", nonce)), http.StatusFound)
+}
+
+// handleCallback completes the login when the provider redirects the browser back
+// @Summary Complete login
+// @Description Called by the browser when the OIDC provider redirects it back, starts the session
+// @Tags auth
+// @Param state query string true "State of the login"
+// @Param code query string true "Authorization code"
+// @Success 302 "Redirect to the page the login started from"
+// @Failure 400 {string} string "Invalid or expired login"
+// @Failure 401 {string} string "Login failed"
+// @Router /api/auth/callback [get]
+func (o *OIDC) handleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	// A login completes once, in the browser that started it, so other sites can't log a victim
+	// in as someone else by sending them to the callback of a login they started
+	login, ok := o.pendingLogin(r)
+	http.SetCookie(w, o.loginCookie(r, "", -1))
+	if !ok || subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(login.State)) != 1 ||
+		time.Now().After(login.Expires) {
+		http.Error(w, "Login expired or unknown, please log in again", http.StatusBadRequest)
+		return
+	}
+	if reason := query.Get("error"); reason != "" {
+		http.Error(w, "Login failed: "+reason+" "+query.Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, o.client)
+	token, err := o.oauth.Exchange(ctx, query.Get("code"), oauth2.VerifierOption(login.Verifier))
+	if err != nil {
+		http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+	user, err := o.verifyIDToken(r.Context(), token, login.Nonce)
+	if err != nil {
+		http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	session, err := o.sessions.Create(user, r)
+	if err != nil {
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, o.sessions.Cookie(r, session.ID, session.Expires))
+	// returnTo is a path of the routes, the browser addresses it under the base path
+	http.Redirect(w, r, basepath.Path(r.Context(), localPath(login.ReturnTo)), http.StatusFound)
+}
+
+// loginCookie creates the cookie carrying a login in progress for maxAge seconds, it is deleted by a
+// negative maxAge. It is only sent to the callback and, unlike a strict cookie, with the redirect of
+// the provider.
+func (o *OIDC) loginCookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     LoginCookie,
+		Value:    value,
+		Path:     basepath.Path(r.Context(), CallbackPath),
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   o.sessions.secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// pendingLogin returns the login the browser started, if any
+func (o *OIDC) pendingLogin(r *http.Request) (pendingLogin, bool) {
+	cookie, err := r.Cookie(LoginCookie)
+	if err != nil {
+		return pendingLogin{}, false
+	}
+	encoded, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return pendingLogin{}, false
+	}
+	var login pendingLogin
+	if err := json.Unmarshal(encoded, &login); err != nil || login.State == "" {
+		return pendingLogin{}, false
+	}
+	return login, true
+}
+
+// verifyIDToken verifies the ID token of a token response and returns the user it identifies
+func (o *OIDC) verifyIDToken(ctx context.Context, token *oauth2.Token, nonce string) (User, error) {
+	raw, _ := token.Extra("id_token").(string)
+	if raw == "" {
+		return User{}, errors.New("provider did not return an ID token")
+	}
+	claims, err := verifyToken(ctx, o.parser, o.keys, raw)
+	if err != nil {
+		return User{}, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claims["nonce"] != nonce {
+		return User{}, errors.New("invalid ID token: nonce mismatch")
+	}
+	// Users can change their preferred username and often their email at the provider, neither is
+	// unique at many providers. Only the subject or a verified email identifies them, the readable
+	// name is only shown.
+	name, _ := claims["sub"].(string)
+	if email, _ := claims["email"].(string); email != "" && verifiedClaim(claims["email_verified"]) {
+		name = email
+	}
+	if name == "" {
+		return User{}, errors.New("invalid ID token: no subject")
+	}
+	user := User{Name: name, Method: MethodOIDC, Groups: stringsClaim(claims, o.groupsClaim)}
+	if display, _ := claims["preferred_username"].(string); display != name {
+		user.DisplayName = display
+	}
+	return user, nil
+}
+
+// verifiedClaim reports whether a claim like email_verified is set. Some providers send it as a string.
+func verifiedClaim(claim any) bool {
+	switch v := claim.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// logoutURL returns the URL to end the session at the provider, if any
+func (o *OIDC) logoutURL() string {
+	if o.provider.EndSessionEndpoint == "" {
+		return ""
+	}
+	return o.provider.EndSessionEndpoint + "?client_id=" + url.QueryEscape(o.oauth.ClientID)
+}
+
+// localPath returns p if it is a path on this server, otherwise the root. It prevents the
+// login from redirecting to other sites.
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,336 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeProvider is an OpenID provider issuing ID tokens for the nonce of the last authorization request
+type fakeProvider struct {
+	srv   *httptest.Server
+	key   *ecdsa.PrivateKey
+	nonce string
+	// claims are added to the ID token
+	claims jwt.MapClaims
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p := &fakeProvider{key: key, claims: jwt.MapClaims{"email": "jane@example.com", "email_verified": true, "groups": []string{"sre"}}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ProviderMetadata{
+			Issuer:                p.srv.URL,
+			AuthorizationEndpoint: p.srv.URL + "/authorize",
+			TokenEndpoint:         p.srv.URL + "/token",
+			JWKSURI:               p.srv.URL + "/jwks",
+			EndSessionEndpoint:    p.srv.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string][]jsonWebKey{"keys": {{
+			Kty: "EC", Kid: "k1", Crv: "P-256",
+			X: base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			Y: base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "good-code" || r.FormValue("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   p.srv.URL,
+			"aud":   "console",
+			"sub":   "u-123",
+			"nonce": p.nonce,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range p.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = "k1"
+		idToken, err := token.SignedString(key)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+	})
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+	return p
+}
+
+// newTestRouter serves the authentication routes and a protected API route behind o
+func newTestRouter(o *OIDC, fallback func(http.Handler) http.Handler) chi.Router {
+	r := chi.NewRouter()
+	r.Use(o.Middleware(fallback))
+	NewHandler(o, nil).RegisterRoutes(r)
+	r.Get("/api/tables", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(UserName(r.Context())))
+	})
+	r.Get("/*", func(w http.ResponseWriter, r *http.Request) {})
+	return r
+}
+
+// startLogin starts a login and returns the callback URL the provider would redirect to with the
+// authorization code and the cookie of the browser that started it
+func startLogin(t *testing.T, p *fakeProvider, r chi.Router, code string) (string, *http.Cookie) {
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", LoginPath+"?returnTo=/tables", nil))
+	require.Equal(t, http.StatusFound, rr.Code)
+	authorize, err := url.Parse(rr.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, p.srv.URL+"/authorize", authorize.Scheme+"://"+authorize.Host+authorize.Path)
+	assert.Equal(t, "S256", authorize.Query().Get("code_challenge_method"))
+	p.nonce = authorize.Query().Get("nonce")
+	cookie := findCookie(rr, LoginCookie)
+	require.NotNil(t, cookie)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+	return CallbackPath + "?code=" + code + "&state=" + url.QueryEscape(authorize.Query().Get("state")), cookie
+}
+
+// callback serves the callback URL, with the cookie of the browser if it is not nil
+func callback(r http.Handler, target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// login runs the login flow with the given authorization code and returns the callback response
+func login(t *testing.T, p *fakeProvider, r chi.Router, code string) *httptest.ResponseRecorder {
+	target, cookie := startLogin(t, p, r, code)
+	return callback(r, target, cookie)
+}
+
+// findCookie returns the cookie of the name set by a response, nil if there is none
+func findCookie(rr *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestOIDCLogin(t *testing.T) {
+	p := newFakeProvider(t)
+	o, err := NewOIDC(t.Context(), OIDCConfig{
+		Issuer:      p.srv.URL,
+		ClientID:    "console",
+		RedirectURL: "https://console.example.com" + CallbackPath,
+		Scopes:      []string{"email"},
+		SessionTTL:  time.Hour,
+		GroupsClaim: "groups",
+	}, p.srv.Client())
+	require.NoError(t, err)
+	r := newTestRouter(o, nil)
+
+	// Unauthenticated browsers are sent to the login, API clients are rejected
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/tables?q=1", nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, LoginPath+"?returnTo=%2Ftables%3Fq%3D1", rr.Header().Get("Location"))
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/tables", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = login(t, p, r, "good-code")
+	require.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+	assert.Equal(t, "/tables", rr.Header().Get("Location"))
+	session := findCookie(rr, SessionCookie)
+	require.NotNil(t, session)
+	assert.True(t, session.HttpOnly)
+	assert.True(t, session.Secure)
+	assert.Equal(t, -1, findCookie(rr, LoginCookie).MaxAge, "the login cookie is deleted")
+
+	req := httptest.NewRequest("GET", "/api/auth/me", nil)
+	req.AddCookie(session)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	var me Me
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&me))
+	assert.Equal(t, Me{User: User{Name: "jane@example.com", Method: MethodOIDC, Groups: []string{"sre"}}, Authenticated: true}, me)
+
+	// After logging out the session is gone
+	req = httptest.NewRequest("POST", LogoutPath, nil)
+	req.AddCookie(session)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	var logout LogoutResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&logout))
+	assert.Equal(t, p.srv.URL+"/logout?client_id=console", logout.LogoutURL)
+
+	req = httptest.NewRequest("GET", "/api/tables", nil)
+	req.AddCookie(session)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestOIDCLoginIdentifiesUsersBySubject(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   User
+	}{
+		{
+			name:   "UnverifiedEmail",
+			claims: jwt.MapClaims{"email": "admin@example.com", "preferred_username": "admin"},
+			want:   User{Name: "u-123", Method: MethodOIDC, DisplayName: "admin"},
+		},
+		{
+			name:   "VerifiedEmail",
+			claims: jwt.MapClaims{"email": "jane@example.com", "email_verified": "true", "preferred_username": "admin"},
+			want:   User{Name: "jane@example.com", Method: MethodOIDC, DisplayName: "admin"},
+		},
+		{
+			name:   "SubjectOnly",
+			claims: jwt.MapClaims{},
+			want:   User{Name: "u-123", Method: MethodOIDC},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakeProvider(t)
+			p.claims = tt.claims
+			o, err := NewOIDC(t.Context(), OIDCConfig{Issuer: p.srv.URL, ClientID: "console", SessionTTL: time.Hour}, p.srv.Client())
+			require.NoError(t, err)
+			r := newTestRouter(o, nil)
+
+			rr := login(t, p, r, "good-code")
+			require.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+			req := httptest.NewRequest("GET", "/api/auth/me", nil)
+			req.AddCookie(findCookie(rr, SessionCookie))
+			rr = httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			var me Me
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&me))
+			assert.Equal(t, tt.want, me.User)
+		})
+	}
+}
+
+func TestOIDCLoginUnderBasePath(t *testing.T) {
+	p := newFakeProvider(t)
+	o, err := NewOIDC(t.Context(), OIDCConfig{Issuer: p.srv.URL, ClientID: "console", SessionTTL: time.Hour}, p.srv.Client())
+	require.NoError(t, err)
+	r := basepath.Middleware("/armada")(newTestRouter(o, nil))
+
+	// Redirects and the session cookie are addressed under the base path
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/armada/tables", nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "/armada"+LoginPath+"?returnTo=%2Ftables", rr.Header().Get("Location"))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/armada"+LoginPath+"?returnTo=/tables", nil))
+	require.Equal(t, http.StatusFound, rr.Code)
+	authorize, err := url.Parse(rr.Header().Get("Location"))
+	require.NoError(t, err)
+	p.nonce = authorize.Query().Get("nonce")
+	login := findCookie(rr, LoginCookie)
+	require.NotNil(t, login)
+	assert.Equal(t, "/armada"+CallbackPath, login.Path)
+
+	rr = callback(r, "/armada"+CallbackPath+"?code=good-code&state="+url.QueryEscape(authorize.Query().Get("state")), login)
+	require.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+	assert.Equal(t, "/armada/tables", rr.Header().Get("Location"))
+	session := findCookie(rr, SessionCookie)
+	require.NotNil(t, session)
+	assert.Equal(t, "/armada/", session.Path)
+}
+
+func TestOIDCLoginRejectsInvalidCallbacks(t *testing.T) {
+	p := newFakeProvider(t)
+	o, err := NewOIDC(t.Context(), OIDCConfig{Issuer: p.srv.URL, ClientID: "console", SessionTTL: time.Hour}, p.srv.Client())
+	require.NoError(t, err)
+	r := newTestRouter(o, nil)
+
+	rr := login(t, p, r, "bad-code")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// The ID token must carry the nonce of the login
+	p.claims["nonce"] = "replayed"
+	rr = login(t, p, r, "good-code")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Contains(t, rr.Body.String(), "nonce")
+
+	delete(p.claims, "nonce")
+	target, cookie := startLogin(t, p, r, "good-code")
+	assert.Equal(t, http.StatusBadRequest, callback(r, CallbackPath+"?code=good-code&state=forged", cookie).Code)
+
+	// The callback of a login started by another browser doesn't log the victim in as its user
+	assert.Equal(t, http.StatusBadRequest, callback(r, target, nil).Code)
+	_, other := startLogin(t, p, r, "good-code")
+	assert.Equal(t, http.StatusBadRequest, callback(r, target, other).Code)
+}
+
+func TestOIDCLoginKeepsNoStateOnTheServer(t *testing.T) {
+	p := newFakeProvider(t)
+	o, err := NewOIDC(t.Context(), OIDCConfig{Issuer: p.srv.URL, ClientID: "console", SessionTTL: time.Hour}, p.srv.Client())
+	require.NoError(t, err)
+	r := newTestRouter(o, nil)
+
+	// Logins that are never completed can't keep others from logging in
+	for range 1100 {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", LoginPath, nil))
+		require.Equal(t, http.StatusFound, rr.Code)
+	}
+	rr := login(t, p, r, "good-code")
+	assert.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+}
+
+func TestOIDCFallsBackToBasicAuth(t *testing.T) {
+	p := newFakeProvider(t)
+	o, err := NewOIDC(t.Context(), OIDCConfig{Issuer: p.srv.URL, ClientID: "console", SessionTTL: time.Hour}, p.srv.Client())
+	require.NoError(t, err)
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash))
+	require.NoError(t, err)
+	r := newTestRouter(o, basic.Middleware)
+
+	req := httptest.NewRequest("GET", "/api/tables", nil)
+	req.SetBasicAuth("admin", "s3cret")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "admin", rr.Body.String())
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	p := newFakeProvider(t)
+	_, err := Discover(t.Context(), p.srv.Client(), p.srv.URL+"/")
+	assert.ErrorContains(t, err, "issuer")
+}
+
+func TestLocalPath(t *testing.T) {
+	assert.Equal(t, "/tables?q=1", localPath("/tables?q=1"))
+	assert.Equal(t, "/", localPath("https://evil.example.com"))
+	assert.Equal(t, "/", localPath("//evil.example.com"))
+	assert.Equal(t, "/", localPath(""))
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PrincipalMethods are the authentication methods whose users can be granted privileges by name.
+// API keys are granted the role they were created with instead.
+var PrincipalMethods = []string{MethodBasic, MethodOIDC, MethodBearer}
+
+// Principal identifies a user by how they authenticated and their name, written method:name,
+// e.g. oidc:jane@example.com. Users of different methods are different users even if their names
+// match, e.g. a local user or an API key created with the name of an OIDC user.
+type Principal struct {
+	Method string
+	Name   string
+}
+
+// String returns the principal as method:name
+func (p Principal) String() string {
+	return p.Method + ":" + p.Name
+}
+
+// Principal returns the principal of the user
+func (u User) Principal() Principal {
+	return Principal{Method: u.Method, Name: u.Name}
+}
+
+// ParsePrincipal parses a principal written as method:name, the method must be one of the
+// PrincipalMethods
+func ParsePrincipal(s string) (Principal, error) {
+	method, name, ok := strings.Cut(strings.TrimSpace(s), ":")
+	switch {
+	case !ok || name == "":
+		return Principal{}, fmt.Errorf("%q must be of the form method:name, e.g. oidc:%s", s, s)
+	case !slices.Contains(PrincipalMethods, method):
+		return Principal{}, fmt.Errorf("the method of %q must be one of %s", s, strings.Join(PrincipalMethods, ", "))
+	}
+	return Principal{Method: method, Name: name}, nil
+}
+
+// ParsePrincipals parses a comma-separated list of principals, dropping empty entries
+func ParsePrincipals(list string) ([]Principal, error) {
+	var principals []Principal
+	for _, entry := range strings.Split(list, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		p, err := ParsePrincipal(entry)
+		if err != nil {
+			return nil, err
+		}
+		principals = append(principals, p)
+	}
+	return principals, nil
+}
+
+// IsAny reports whether the user is one of the principals
+func (u User) IsAny(principals []Principal) bool {
+	return u.Name != "" && slices.Contains(principals, u.Principal())
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePrincipals(t *testing.T) {
+	principals, err := ParsePrincipals(" oidc:jane@example.com, ,basic:admin,bearer:ci:deploy")
+	require.NoError(t, err)
+	assert.Equal(t, []Principal{
+		{Method: MethodOIDC, Name: "jane@example.com"},
+		{Method: MethodBasic, Name: "admin"},
+		{Method: MethodBearer, Name: "ci:deploy"},
+	}, principals)
+	assert.Equal(t, "oidc:jane@example.com", principals[0].String())
+
+	for _, list := range []string{"alice", "oidc:", "apikey:ci", "ldap:alice"} {
+		_, err := ParsePrincipals(list)
+		assert.Error(t, err, list)
+	}
+}
+
+func TestUserIsAny(t *testing.T) {
+	admins := []Principal{{Method: MethodOIDC, Name: "alice"}}
+	assert.True(t, User{Name: "alice", Method: MethodOIDC}.IsAny(admins))
+	assert.False(t, User{Name: "alice", Method: MethodBasic}.IsAny(admins), "local users may be named like OIDC users")
+	assert.False(t, User{Name: "alice", Method: MethodAPIKey}.IsAny(admins), "API keys may be named like OIDC users")
+	assert.False(t, User{}.IsAny(admins))
+}
@@ -0,0 +1,326 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/metadata"
+)
+
+// SessionCookie is the name of the cookie carrying the session ID of a logged-in browser
+const SessionCookie = "console_session"
+
+// SessionsNamespace is the metadata namespace the sessions are stored in
+const SessionsNamespace = "sessions"
+
+const (
+	// touchInterval is how often the last use of a session is written to the store. The idle
+	// timeout is enforced with this precision, so polling browsers don't rewrite the store
+	// with every request.
+	touchInterval = time.Minute
+	// maxUserAgent bounds the length of the stored user agent of a session
+	maxUserAgent = 256
+)
+
+// Session is a logged-in browser
+type Session struct {
+	// ID is the secret carried by the session cookie. It is not stored, sessions are stored
+	// under their handle so a copy of the store can't be used to take them over.
+	ID string `json:"-"`
+	// Handle identifies the session when listing or ending it, it is derived from the ID.
+	Handle  string    `json:"handle"`
+	User    User      `json:"user"`
+	Created time.Time `json:"created"`
+	// LastSeen is when the session was last used, up to a minute ago.
+	LastSeen time.Time `json:"lastSeen"`
+	// Expires is when the session ends regardless of its use.
+	Expires time.Time `json:"expires"`
+	// UserAgent and Address describe the device the session was started from.
+	UserAgent string `json:"userAgent,omitempty"`
+	Address   string `json:"address,omitempty"`
+}
+
+// SessionOption configures optional behaviour of the SessionStore
+type SessionOption func(*SessionStore)
+
+// WithIdleTimeout ends sessions that haven't been used for the timeout, zero keeps them until they expire
+func WithIdleTimeout(timeout time.Duration) SessionOption {
+	return func(s *SessionStore) {
+		s.idle = timeout
+	}
+}
+
+// WithMaxSessions limits the concurrent sessions of a user, starting a further session ends
+// the least recently used ones. Zero disables the limit.
+func WithMaxSessions(n int) SessionOption {
+	return func(s *SessionStore) {
+		s.maxSessions = n
+	}
+}
+
+// WithSameSite sets the SameSite attribute of the session cookie. Lax, the default, sends the
+// cookie when following links from other sites; Strict doesn't, which breaks the redirect back
+// from an OpenID provider.
+func WithSameSite(mode http.SameSite) SessionOption {
+	return func(s *SessionStore) {
+		s.sameSite = mode
+	}
+}
+
+// WithSecureCookies makes browsers send the session cookie over HTTPS only
+func WithSecureCookies(secure bool) SessionOption {
+	return func(s *SessionStore) {
+		s.secure = secure
+	}
+}
+
+// SessionStore keeps the sessions of logged-in browsers in the metadata store, so they survive
+// restarts of the console when the store is persistent. It is safe for concurrent use.
+type SessionStore struct {
+	store       metadata.Store
+	ttl         time.Duration
+	idle        time.Duration
+	maxSessions int
+	secure      bool
+	sameSite    http.SameSite
+	now         func() time.Time
+
+	// mu serializes changes of the sessions, so the limit of a user is enforced consistently
+	mu sync.Mutex
+}
+
+// NewSessionStore creates a store whose sessions expire ttl after login
+func NewSessionStore(store metadata.Store, ttl time.Duration, opts ...SessionOption) *SessionStore {
+	s := &SessionStore{
+		store:    store,
+		ttl:      ttl,
+		sameSite: http.SameSiteLaxMode,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Create starts a session for the user on the device of the request
+func (s *SessionStore) Create(user User, r *http.Request) (Session, error) {
+	id, err := randomToken()
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	now := s.now()
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+	session := Session{
+		ID:        id,
+		Handle:    sessionHandle(id),
+		User:      user,
+		Created:   now,
+		LastSeen:  now,
+		Expires:   now.Add(s.ttl),
+		UserAgent: userAgent,
+		Address:   ClientAddress(r),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions, err := metadata.List[Session](s.store, SessionsNamespace)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	var own []Session
+	for handle, existing := range sessions {
+		// Ended sessions are swept whenever a new one starts, so they don't accumulate
+		if !s.active(existing, now) {
+			if err := s.store.Delete(SessionsNamespace, handle); err != nil {
+				return Session{}, fmt.Errorf("failed to remove ended session: %w", err)
+			}
+			continue
+		}
+		if sameUser(existing.User, user) {
+			own = append(own, existing)
+		}
+	}
+	if s.maxSessions > 0 && len(own) >= s.maxSessions {
+		slices.SortFunc(own, func(a, b Session) int { return a.LastSeen.Compare(b.LastSeen) })
+		for _, old := range own[:len(own)-s.maxSessions+1] {
+			if err := s.store.Delete(SessionsNamespace, old.Handle); err != nil {
+				return Session{}, fmt.Errorf("failed to end session: %w", err)
+			}
+		}
+	}
+	if err := metadata.Put(s.store, SessionsNamespace, session.Handle, session); err != nil {
+		return Session{}, fmt.Errorf("failed to store session: %w", err)
+	}
+	return session, nil
+}
+
+// Get returns a session that has neither expired nor been idle for too long, and records its use
+func (s *SessionStore) Get(id string) (Session, bool) {
+	handle := sessionHandle(id)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, err := metadata.Get[Session](s.store, SessionsNamespace, handle)
+	if err != nil {
+		return Session{}, false
+	}
+	if !s.active(session, now) {
+		// A session that failed to be removed is still refused and swept later
+		_ = s.store.Delete(SessionsNamespace, handle)
+		return Session{}, false
+	}
+	if now.Sub(session.LastSeen) >= touchInterval {
+		session.LastSeen = now
+		// A failed write only lets the session appear idle for longer
+		_ = metadata.Put(s.store, SessionsNamespace, handle, session)
+	}
+	session.ID = id
+	return session, true
+}
+
+// Delete ends a session
+func (s *SessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Delete(SessionsNamespace, sessionHandle(id))
+}
+
+// Sessions returns the active sessions of a user, the most recently used first
+func (s *SessionStore) Sessions(user User) ([]Session, error) {
+	sessions, err := metadata.List[Session](s.store, SessionsNamespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	now := s.now()
+	own := make([]Session, 0)
+	for _, session := range sessions {
+		if sameUser(session.User, user) && s.active(session, now) {
+			own = append(own, session)
+		}
+	}
+	slices.SortFunc(own, func(a, b Session) int { return b.LastSeen.Compare(a.LastSeen) })
+	return own, nil
+}
+
+// End ends the session of the user with the handle, it reports false if the user has no such session
+func (s *SessionStore) End(user User, handle string) (bool, error) {
+	n, err := s.endWhere(func(session Session) bool {
+		return session.Handle == handle && sameUser(session.User, user)
+	})
+	return n > 0, err
+}
+
+// EndOthers ends all sessions of the user except the one with the handle and returns how many ended
+func (s *SessionStore) EndOthers(user User, handle string) (int, error) {
+	return s.endWhere(func(session Session) bool {
+		return session.Handle != handle && sameUser(session.User, user)
+	})
+}
+
+// EndUser ends all sessions of the user with the name who authenticated with the method, e.g.
+// when their password changes, and returns how many ended
+func (s *SessionStore) EndUser(name, method string) (int, error) {
+	return s.endWhere(func(session Session) bool {
+		return session.User.Name == name && session.User.Method == method
+	})
+}
+
+// endWhere ends the sessions matching the filter and returns how many ended
+func (s *SessionStore) endWhere(match func(Session) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions, err := metadata.List[Session](s.store, SessionsNamespace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	ended := 0
+	for handle, session := range sessions {
+		if !match(session) {
+			continue
+		}
+		if err := s.store.Delete(SessionsNamespace, handle); err != nil {
+			return ended, fmt.Errorf("failed to end session: %w", err)
+		}
+		ended++
+	}
+	return ended, nil
+}
+
+// FromRequest returns the session of the request's session cookie, if any
+func (s *SessionStore) FromRequest(r *http.Request) (Session, bool) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return Session{}, false
+	}
+	return s.Get(cookie.Value)
+}
+
+// Middleware authenticates requests with the session cookie. Requests without a session are
+// passed to fallback, e.g. basic authentication.
+func (s *SessionStore) Middleware(fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		credentials := fallback(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if session, ok := s.FromRequest(r); ok {
+				next.ServeHTTP(w, r.WithContext(withSession(WithUser(r.Context(), session.User), session)))
+				return
+			}
+			credentials.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Cookie creates the cookie carrying a session ID for the request, it is deleted by an expiry
+// in the past. It is only sent to the console, also when it shares the host with other sites.
+func (s *SessionStore) Cookie(r *http.Request, id string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     SessionCookie,
+		Value:    id,
+		Path:     basepath.Path(r.Context(), "/"),
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.secure,
+		SameSite: s.sameSite,
+	}
+}
+
+// active reports whether a session has neither expired nor been idle for too long
+func (s *SessionStore) active(session Session, now time.Time) bool {
+	if !now.Before(session.Expires) {
+		return false
+	}
+	return s.idle <= 0 || now.Sub(session.LastSeen) < s.idle
+}
+
+// sameUser reports whether two users are the same, users of different authentication methods
+// are different even if their names match
+func sameUser(a, b User) bool {
+	return a.Name == b.Name && a.Method == b.Method
+}
+
+// sessionHandle derives the handle of a session from its ID
+func sessionHandle(id string) string {
+	digest := sha256.Sum256([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs and cookies
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
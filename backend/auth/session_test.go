@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSessionStoreTimeouts(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := metadata.NewMemoryStore()
+	s := NewSessionStore(store, 8*time.Hour, WithIdleTimeout(30*time.Minute))
+	s.now = func() time.Time { return now }
+
+	req := httptest.NewRequest("POST", SessionPath, nil)
+	req.Header.Set("User-Agent", "Firefox")
+	req.RemoteAddr = "10.0.0.1:50000"
+	session, err := s.Create(User{Name: "alice", Method: MethodBasic}, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Firefox", session.UserAgent)
+	assert.Equal(t, "10.0.0.1", session.Address)
+
+	// Only the handle is stored, the ID in the cookie can't be read from the store
+	raw, err := store.List(SessionsNamespace)
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+	assert.Contains(t, raw, session.Handle)
+	assert.NotContains(t, string(raw[session.Handle]), session.ID)
+
+	// Using the session keeps it alive beyond the idle timeout
+	for range 4 {
+		now = now.Add(20 * time.Minute)
+		_, ok := s.Get(session.ID)
+		require.True(t, ok)
+	}
+	now = now.Add(30 * time.Minute)
+	_, ok := s.Get(session.ID)
+	assert.False(t, ok, "idle sessions end")
+	_, err = store.Get(SessionsNamespace, session.Handle)
+	assert.ErrorIs(t, err, metadata.ErrNotFound)
+
+	// Used sessions end at their expiry
+	session, err = s.Create(User{Name: "alice", Method: MethodBasic}, req)
+	require.NoError(t, err)
+	for range 20 {
+		now = now.Add(29 * time.Minute)
+		if _, ok := s.Get(session.ID); !ok {
+			break
+		}
+	}
+	assert.False(t, now.Before(session.Expires))
+	_, ok = s.Get(session.ID)
+	assert.False(t, ok)
+}
+
+func TestSessionStoreSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := metadata.NewFileStore(dir)
+	require.NoError(t, err)
+	session, err := NewSessionStore(store, time.Hour).Create(User{Name: "alice", Method: MethodOIDC}, httptest.NewRequest("GET", CallbackPath, nil))
+	require.NoError(t, err)
+
+	reopened, err := metadata.NewFileStore(dir)
+	require.NoError(t, err)
+	got, ok := NewSessionStore(reopened, time.Hour).Get(session.ID)
+	require.True(t, ok)
+	assert.Equal(t, User{Name: "alice", Method: MethodOIDC}, got.User)
+}
+
+func TestSessionStoreLimitsSessionsPerUser(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSessionStore(metadata.NewMemoryStore(), time.Hour, WithMaxSessions(2))
+	s.now = func() time.Time { return now }
+	alice := User{Name: "alice", Method: MethodBasic}
+	req := httptest.NewRequest("POST", SessionPath, nil)
+
+	first, err := s.Create(alice, req)
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	second, err := s.Create(alice, req)
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	// Using the first session makes the second the least recently used
+	_, ok := s.Get(first.ID)
+	require.True(t, ok)
+	_, err = s.Create(User{Name: "bob", Method: MethodBasic}, req)
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	third, err := s.Create(alice, req)
+	require.NoError(t, err)
+
+	sessions, err := s.Sessions(alice)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, third.Handle, sessions[0].Handle)
+	assert.Equal(t, first.Handle, sessions[1].Handle)
+	_, ok = s.Get(second.ID)
+	assert.False(t, ok)
+}
+
+func TestSessionEndpoints(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	basic, err := NewBasicAuth("Armada Console", "admin", string(hash))
+	require.NoError(t, err)
+	sessions := NewSessionStore(metadata.NewMemoryStore(), time.Hour)
+	r := chi.NewRouter()
+	r.Use(sessions.Middleware(basic.Middleware))
+	NewHandler(nil, sessions).RegisterRoutes(r)
+
+	serve := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		} else {
+			req.SetBasicAuth("admin", "s3cret")
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Every login starts a session the browser continues with its cookie
+	var cookies []*http.Cookie
+	for range 3 {
+		rr := serve("POST", SessionPath, nil)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		require.Len(t, rr.Result().Cookies(), 1)
+		cookies = append(cookies, rr.Result().Cookies()[0])
+	}
+
+	rr := serve("GET", SessionsPath, cookies[0])
+	require.Equal(t, http.StatusOK, rr.Code)
+	var infos []SessionInfo
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&infos))
+	require.Len(t, infos, 3)
+	current := 0
+	for _, info := range infos {
+		if info.Current {
+			current++
+		}
+	}
+	assert.Equal(t, 1, current)
+	assert.NotContains(t, rr.Body.String(), cookies[0].Value)
+
+	rr = serve("POST", SessionsPath+"/logout-others", cookies[0])
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"ended": 2}`, rr.Body.String())
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", SessionsPath, cookies[1]).Code)
+
+	// Sessions of other users can't be ended
+	other, err := sessions.Create(User{Name: "admin", Method: MethodOIDC}, httptest.NewRequest("GET", CallbackPath, nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", SessionsPath+"/"+other.Handle, cookies[0]).Code)
+
+	rr = serve("POST", LogoutPath, cookies[0])
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Set-Cookie"), SessionCookie+"=;"))
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", SessionsPath, cookies[0]).Code)
+}
+
+func TestSelfServicePath(t *testing.T) {
+	assert.True(t, SelfServicePath(LogoutPath))
+	assert.True(t, SelfServicePath(SessionsPath+"/logout-others"))
+	assert.False(t, SelfServicePath("/api/auth/sessionsx"))
+	assert.False(t, SelfServicePath("/api/tables"))
+}
@@ -0,0 +1,75 @@
+// Package basepath serves the console under a URL prefix, e.g. when a reverse proxy mounts it at
+// https://ops.example.com/armada/.
+//
+// The middleware takes the prefix off request paths before routing, so routes, authentication and
+// the other middlewares match the same paths as when the console is served at the root. Paths sent
+// back to clients, e.g. in redirects or Location headers, get the prefix again with Path.
+package basepath
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// Clean normalizes a base path to a leading and no trailing slash, e.g. armada/ becomes /armada.
+// The root is the empty string.
+func Clean(base string) string {
+	base = strings.Trim(base, "/")
+	if base == "" {
+		return ""
+	}
+	return "/" + base
+}
+
+// FromContext returns the base path the request was served under, the empty string at the root
+func FromContext(ctx context.Context) string {
+	base, _ := ctx.Value(contextKey{}).(string)
+	return base
+}
+
+// Path returns the path p of the console as the client of the request addresses it, e.g.
+// /api/tables becomes /armada/api/tables when the console is served under /armada.
+func Path(ctx context.Context, p string) string {
+	return FromContext(ctx) + p
+}
+
+// Middleware serves next under base. It must run before all other middlewares, so they see the
+// path without the prefix. Requests outside of base are answered with 404 Not Found, requests to
+// base itself are redirected to base with a trailing slash, where relative URLs of the frontend resolve.
+func Middleware(base string) func(http.Handler) http.Handler {
+	base = Clean(base)
+	return func(next http.Handler) http.Handler {
+		if base == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == base {
+				target := base + "/"
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, http.StatusMovedPermanently)
+				return
+			}
+			rest, ok := strings.CutPrefix(r.URL.Path, base+"/")
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, base))
+			u := *r.URL
+			u.Path = "/" + rest
+			if u.RawPath != "" {
+				u.RawPath = "/" + strings.TrimPrefix(u.RawPath, base+"/")
+			}
+			r.URL = &u
+			// Handlers reading the request URI, e.g. the Swagger UI, see the path without the prefix as well
+			r.RequestURI = u.RequestURI()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
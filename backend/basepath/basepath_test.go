@@ -0,0 +1,88 @@
+package basepath
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "", Clean(""))
+	assert.Equal(t, "", Clean("/"))
+	assert.Equal(t, "/armada", Clean("/armada"))
+	assert.Equal(t, "/armada", Clean("armada/"))
+	assert.Equal(t, "/ops/armada", Clean("/ops/armada/"))
+}
+
+func TestPath(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, "/armada")
+	assert.Equal(t, "/armada/api/tables", Path(ctx, "/api/tables"))
+	assert.Equal(t, "/api/tables", Path(context.Background(), "/api/tables"))
+}
+
+func TestMiddleware(t *testing.T) {
+	var gotPath, gotURI, gotBase string
+	handler := Middleware("/armada/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotURI = r.RequestURI
+		gotBase = FromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		want     string
+		uri      string
+		location string
+	}{
+		{"API", "/armada/api/v1/tables?limit=5", http.StatusOK, "/api/v1/tables", "/api/v1/tables?limit=5", ""},
+		{"Frontend", "/armada/data/users", http.StatusOK, "/data/users", "/data/users", ""},
+		{"Index", "/armada/", http.StatusOK, "/", "/", ""},
+		{"WithoutSlash", "/armada?x=1", http.StatusMovedPermanently, "", "", "/armada/?x=1"},
+		{"Outside", "/api/tables", http.StatusNotFound, "", "", ""},
+		{"SharedPrefix", "/armadakv/api/tables", http.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotURI, gotBase = "", "", ""
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.want, gotPath)
+			assert.Equal(t, tt.uri, gotURI)
+			assert.Equal(t, tt.location, rec.Header().Get("Location"))
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "/armada", gotBase)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRoot(t *testing.T) {
+	var gotPath string
+	handler := Middleware("/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tables", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/api/tables", gotPath)
+}
+
+func TestMiddlewareEscapedPath(t *testing.T) {
+	var gotPath, gotRawPath string
+	handler := Middleware("/armada")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotRawPath = r.URL.RawPath
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/armada/api/kv/users/a%2Fb", nil))
+	assert.Equal(t, "/api/kv/users/a/b", gotPath)
+	assert.Equal(t, "/api/kv/users/a%2Fb", gotRawPath)
+}
@@ -0,0 +1,250 @@
+// Package bundle stores console API responses in a static archive and serves them
+// read-only, so the state of a cluster can be reviewed without access to it.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the version of the bundle layout written by this package
+const FormatVersion = 1
+
+// manifestName is the name of the manifest within the archive
+const manifestName = "manifest.json"
+
+// ErrReadOnly is returned for requests that would modify a snapshot
+var ErrReadOnly = errors.New("the console is serving a read-only snapshot")
+
+// Manifest describes the content of a bundle
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Source is the console the bundle was taken from
+	Source  string  `json:"source"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a single API response stored in a bundle
+type Entry struct {
+	Path string `json:"path"`
+	// Query is the encoded query string of the request, if any
+	Query       string `json:"query,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	// File is the name of the response body within the archive
+	File string `json:"file"`
+}
+
+// Writer writes a bundle as a gzip compressed tar archive
+type Writer struct {
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	manifest Manifest
+}
+
+// NewWriter starts a bundle taken from source
+func NewWriter(w io.Writer, source string) *Writer {
+	gz := gzip.NewWriter(w)
+	return &Writer{
+		gz: gz,
+		tw: tar.NewWriter(gz),
+		manifest: Manifest{
+			Version:   FormatVersion,
+			CreatedAt: time.Now().UTC(),
+			Source:    source,
+		},
+	}
+}
+
+// Add stores the response to a GET request of path with the given query
+func (w *Writer) Add(path string, query url.Values, status int, contentType string, body []byte) error {
+	name := "responses/" + strconv.Itoa(len(w.manifest.Entries)) + ".json"
+	if err := w.writeFile(name, body); err != nil {
+		return err
+	}
+	w.manifest.Entries = append(w.manifest.Entries, Entry{
+		Path:        path,
+		Query:       query.Encode(),
+		Status:      status,
+		ContentType: contentType,
+		File:        name,
+	})
+	return nil
+}
+
+// Close writes the manifest and finishes the archive. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	manifest, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := w.writeFile(manifestName, manifest); err != nil {
+		return err
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// writeFile adds a file to the archive
+func (w *Writer) writeFile(name string, data []byte) error {
+	err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: w.manifest.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// response is a stored response ready to be served
+type response struct {
+	Entry
+	body []byte
+}
+
+// Bundle is a loaded bundle
+type Bundle struct {
+	manifest Manifest
+	// responses maps request keys to stored responses
+	responses map[string]*response
+	// byPath maps paths to all responses stored for them, in bundle order
+	byPath map[string][]*response
+}
+
+// Open loads a bundle from a file
+func Open(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read loads a bundle from a gzip compressed tar archive
+func Read(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[header.Name] = data
+	}
+
+	raw, ok := files[manifestName]
+	if !ok {
+		return nil, errors.New("bundle has no manifest")
+	}
+	b := &Bundle{
+		responses: make(map[string]*response),
+		byPath:    make(map[string][]*response),
+	}
+	if err := json.Unmarshal(raw, &b.manifest); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if b.manifest.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", b.manifest.Version)
+	}
+	for _, entry := range b.manifest.Entries {
+		body, ok := files[entry.File]
+		if !ok {
+			return nil, fmt.Errorf("bundle is missing %s", entry.File)
+		}
+		resp := &response{Entry: entry, body: body}
+		b.responses[requestKey(entry.Path, entry.Query)] = resp
+		b.byPath[entry.Path] = append(b.byPath[entry.Path], resp)
+	}
+	return b, nil
+}
+
+// Manifest returns the description of the bundle
+func (b *Bundle) Manifest() Manifest {
+	return b.manifest
+}
+
+// ServeHTTP answers GET requests with the stored responses. Requests that would
+// modify the cluster are rejected since the snapshot is read-only.
+func (b *Bundle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Console-Snapshot", b.manifest.CreatedAt.Format(time.RFC3339))
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, ErrReadOnly.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := b.lookup(r.URL)
+	if resp == nil {
+		http.Error(w, "Not included in the snapshot: "+r.URL.Path, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", resp.ContentType)
+	w.WriteHeader(resp.Status)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(resp.body)
+	}
+}
+
+// lookup finds the stored response for a request. Requests match exactly, or
+// by the parameters that identify the data: clients compute time ranges and
+// paging parameters relative to now, which never match the stored requests.
+func (b *Bundle) lookup(u *url.URL) *response {
+	if resp, ok := b.responses[requestKey(u.Path, u.Query().Encode())]; ok {
+		return resp
+	}
+	candidates := b.byPath[u.Path]
+	if strings.HasPrefix(u.Path, "/api/metrics/") {
+		query := u.Query().Get("query")
+		for _, resp := range candidates {
+			stored, _ := url.ParseQuery(resp.Query)
+			if stored.Get("query") == query {
+				return resp
+			}
+		}
+		return nil
+	}
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+	return nil
+}
+
+// requestKey identifies a request by path and encoded query
+func requestKey(path, query string) string {
+	return path + "?" + query
+}
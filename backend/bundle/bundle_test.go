@@ -0,0 +1,122 @@
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConsole serves a minimal console API
+func fakeConsole(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/servers", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id":"node1"},{"id":"node2"}]`))
+	})
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"servers":[],"partial":false}`))
+	})
+	mux.HandleFunc("/api/metrics/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":"` + r.URL.Path + `"}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Failed to get tables", http.StatusInternalServerError)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestDumpAndServe(t *testing.T) {
+	console := fakeConsole(t)
+	defer console.Close()
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, console.URL)
+	err := Dump(context.Background(), console.Client(), console.URL, w, DumpOptions{
+		MetricsQueries: []string{`up{node_id="{node}"}`, "sum(up)"},
+		MetricsRange:   time.Hour,
+		MetricsStep:    time.Minute,
+		Header:         http.Header{"Authorization": {"Bearer token"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	b, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, console.URL, b.Manifest().Source)
+	// 8 state paths, 2 node resource summaries and 3 queries, each as instant and range query
+	assert.Len(t, b.Manifest().Entries, 17)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		b.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+		return rr
+	}
+
+	rr := serve("GET", "/api/status")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"servers":[],"partial":false}`, rr.Body.String())
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rr.Header().Get("X-Console-Snapshot"))
+
+	// Error responses are kept as they were
+	rr = serve("GET", "/api/tables")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	// Paging parameters fall back to the stored resource
+	rr = serve("GET", "/api/tables?limit=10")
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	// Metrics queries match by query regardless of the time range
+	rr = serve("GET", "/api/metrics/query_range?"+url.Values{
+		"query": {`up{node_id="node2"}`}, "start": {"1"}, "end": {"2"},
+	}.Encode())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "query_range")
+
+	rr = serve("GET", "/api/metrics/query?query=unknown")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	// Snapshots are read-only
+	rr = serve("DELETE", "/api/tables/users")
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := NewWriter(f, "test")
+	require.NoError(t, w.Add("/api/status", nil, http.StatusOK, "application/json", []byte(`{}`)))
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	b, err := Open(path)
+	require.NoError(t, err)
+	assert.Equal(t, FormatVersion, b.Manifest().Version)
+	assert.Len(t, b.Manifest().Entries, 1)
+}
+
+func TestReadInvalid(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("not a bundle")))
+	assert.Error(t, err)
+
+	// An archive without manifest
+	var buf bytes.Buffer
+	w := NewWriter(&buf, "test")
+	require.NoError(t, w.tw.Close())
+	require.NoError(t, w.gz.Close())
+	_, err = Read(&buf)
+	assert.ErrorContains(t, err, "no manifest")
+}
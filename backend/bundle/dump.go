@@ -0,0 +1,176 @@
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NodePlaceholder is replaced with the ID of every server in metrics query templates
+const NodePlaceholder = "{node}"
+
+// DefaultMetricsQueries are the per-server queries captured unless others are given
+var DefaultMetricsQueries = []string{
+	`rate(process_cpu_seconds_total{node_id="{node}"}[1m]) * 100`,
+	`sum(increase(go_memstats_alloc_bytes_total{node_id="{node}"}[1m])) / 1024 / 1024`,
+	`sum(regatta_table_storage_disk_usage_bytes{node_id="{node}"}) / 1024 / 1024`,
+	`network_throughput_mbps{node_id="{node}"}`,
+}
+
+// statePaths are the API resources captured in every bundle
+var statePaths = []string{
+	"/api/status",
+	"/api/cluster",
+	"/api/servers",
+	"/api/tables",
+	"/api/clusters",
+	"/api/audit",
+	"/api/diagnostics",
+	"/api/cluster/history",
+	"/api/maintenance",
+}
+
+// DumpOptions configures what is captured from a console
+type DumpOptions struct {
+	// MetricsQueries are captured as instant and range queries. Queries containing
+	// NodePlaceholder are captured once for every server.
+	MetricsQueries []string
+	// MetricsRange is the time range captured for range queries.
+	MetricsRange time.Duration
+	// MetricsStep is the resolution of range queries.
+	MetricsStep time.Duration
+	// Header is added to every request, e.g. for authentication.
+	Header http.Header
+}
+
+// Dump captures the state of the console at baseURL into the bundle.
+// Error responses are stored as they are so the snapshot shows what the UI would have shown.
+func Dump(ctx context.Context, client *http.Client, baseURL string, w *Writer, opts DumpOptions) error {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return fmt.Errorf("invalid console URL: %w", err)
+	}
+	d := dumper{client: client, base: base, w: w, header: opts.Header}
+
+	var servers []byte
+	for _, path := range statePaths {
+		body, err := d.capture(ctx, path, nil)
+		if err != nil {
+			return err
+		}
+		if path == "/api/servers" {
+			servers = body
+		}
+	}
+
+	nodes, err := serverIDs(servers)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if _, err := d.capture(ctx, "/api/servers/"+url.PathEscape(node)+"/resources", nil); err != nil {
+			return err
+		}
+	}
+
+	end := time.Now()
+	start := end.Add(-opts.MetricsRange)
+	for _, query := range expandQueries(opts.MetricsQueries, nodes) {
+		if _, err := d.capture(ctx, "/api/metrics/query", url.Values{"query": {query}}); err != nil {
+			return err
+		}
+		if opts.MetricsRange <= 0 {
+			continue
+		}
+		_, err := d.capture(ctx, "/api/metrics/query_range", url.Values{
+			"query": {query},
+			"start": {strconv.FormatInt(start.Unix(), 10)},
+			"end":   {strconv.FormatInt(end.Unix(), 10)},
+			"step":  {opts.MetricsStep.String()},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumper fetches responses from a console and adds them to a bundle
+type dumper struct {
+	client *http.Client
+	base   *url.URL
+	w      *Writer
+	header http.Header
+}
+
+// capture fetches a resource, stores it in the bundle and returns the body of successful responses
+func (d *dumper) capture(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	u := *d.base
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range d.header {
+		req.Header[name] = values
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := d.w.Add(path, query, resp.StatusCode, resp.Header.Get("Content-Type"), body); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	return body, nil
+}
+
+// serverIDs returns the IDs of the servers in a /api/servers response
+func serverIDs(body []byte) ([]string, error) {
+	if body == nil {
+		return nil, nil
+	}
+	var servers []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &servers); err != nil {
+		return nil, errors.New("unexpected response from /api/servers")
+	}
+	ids := make([]string, 0, len(servers))
+	for _, server := range servers {
+		ids = append(ids, server.ID)
+	}
+	return ids, nil
+}
+
+// expandQueries replaces the node placeholder in query templates with every node ID
+func expandQueries(templates, nodes []string) []string {
+	var queries []string
+	for _, template := range templates {
+		if !strings.Contains(template, NodePlaceholder) {
+			queries = append(queries, template)
+			continue
+		}
+		for _, node := range nodes {
+			queries = append(queries, strings.ReplaceAll(template, NodePlaceholder, node))
+		}
+	}
+	return queries
+}
@@ -0,0 +1,169 @@
+// Package cluster keeps track of the Armada clusters managed by the console.
+// Each cluster has a name, its seed addresses and the defaults used to land
+// users in the right place when they open the cluster in the UI.
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrClusterNotFound is returned when a cluster is not registered.
+	ErrClusterNotFound = errors.New("cluster not found")
+
+	// ErrClusterExists is returned when registering a cluster under a name that is already taken.
+	ErrClusterExists = errors.New("cluster already exists")
+)
+
+// Defaults are the per-cluster starting points of the UI.
+type Defaults struct {
+	// Table is the table opened by default, e.g. in the KV browser.
+	Table string `json:"table,omitempty"`
+	// KeyPrefixes are the prefix filters offered by default when browsing keys.
+	KeyPrefixes []string `json:"keyPrefixes,omitempty"`
+}
+
+// Validate checks that the defaults are usable
+func (d Defaults) Validate() error {
+	if strings.TrimSpace(d.Table) != d.Table {
+		return fmt.Errorf("table %q must not have leading or trailing whitespace", d.Table)
+	}
+	if slices.Contains(d.KeyPrefixes, "") {
+		return errors.New("key prefixes must not be empty")
+	}
+	return nil
+}
+
+// Cluster describes a registered Armada cluster.
+type Cluster struct {
+	// Name identifies the cluster in the API.
+	Name string `json:"name"`
+	// Seeds are the addresses used to connect to the cluster.
+	Seeds []string `json:"seeds"`
+	// Defaults are the starting points of the UI for this cluster.
+	Defaults Defaults `json:"defaults"`
+	// Tags label the cluster, e.g. env=prod, to filter clusters and their metrics by.
+	Tags Tags `json:"tags,omitempty"`
+}
+
+// clone returns a deep copy so callers can't modify registry state
+func (c Cluster) clone() Cluster {
+	c.Seeds = slices.Clone(c.Seeds)
+	c.Defaults.KeyPrefixes = slices.Clone(c.Defaults.KeyPrefixes)
+	c.Tags = maps.Clone(c.Tags)
+	return c
+}
+
+// Registry holds the registered clusters. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	clusters map[string]Cluster
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		clusters: make(map[string]Cluster),
+	}
+}
+
+// Register adds a cluster. It returns ErrClusterExists if the name is already registered.
+func (r *Registry) Register(c Cluster) error {
+	if c.Name == "" {
+		return errors.New("cluster name is required")
+	}
+	if err := c.Defaults.Validate(); err != nil {
+		return fmt.Errorf("invalid defaults for cluster %s: %w", c.Name, err)
+	}
+	if err := c.Tags.Validate(); err != nil {
+		return fmt.Errorf("invalid tags for cluster %s: %w", c.Name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.clusters[c.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrClusterExists, c.Name)
+	}
+	r.clusters[c.Name] = c.clone()
+	return nil
+}
+
+// Get returns the cluster with the given name
+func (r *Registry) Get(name string) (Cluster, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.clusters[name]
+	if !ok {
+		return Cluster{}, fmt.Errorf("%w: %s", ErrClusterNotFound, name)
+	}
+	return c.clone(), nil
+}
+
+// List returns all clusters sorted by name
+func (r *Registry) List() []Cluster {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	clusters := make([]Cluster, 0, len(r.clusters))
+	for _, c := range r.clusters {
+		clusters = append(clusters, c.clone())
+	}
+	slices.SortFunc(clusters, func(a, b Cluster) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return clusters
+}
+
+// SetDefaults replaces the defaults of a cluster
+func (r *Registry) SetDefaults(name string, defaults Defaults) error {
+	if err := defaults.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.clusters[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrClusterNotFound, name)
+	}
+	c.Defaults = Defaults{
+		Table:       defaults.Table,
+		KeyPrefixes: slices.Clone(defaults.KeyPrefixes),
+	}
+	r.clusters[name] = c
+	return nil
+}
+
+// SetSeeds replaces the seed addresses of a cluster, e.g. after discovery found new servers
+func (r *Registry) SetSeeds(name string, seeds []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.clusters[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrClusterNotFound, name)
+	}
+	c.Seeds = slices.Clone(seeds)
+	r.clusters[name] = c
+	return nil
+}
+
+// SetTags replaces the tags of a cluster
+func (r *Registry) SetTags(name string, tags Tags) error {
+	if err := tags.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.clusters[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrClusterNotFound, name)
+	}
+	c.Tags = maps.Clone(tags)
+	r.clusters[name] = c
+	return nil
+}
@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(Cluster{Name: "prod", Seeds: []string{"http://prod:5001"}}))
+	require.NoError(t, r.Register(Cluster{
+		Name:     "staging",
+		Seeds:    []string{"http://staging:5001"},
+		Defaults: Defaults{Table: "users", KeyPrefixes: []string{"user/"}},
+	}))
+
+	err := r.Register(Cluster{Name: "prod"})
+	assert.ErrorIs(t, err, ErrClusterExists)
+
+	clusters := r.List()
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "prod", clusters[0].Name)
+	assert.Equal(t, "staging", clusters[1].Name)
+
+	staging, err := r.Get("staging")
+	require.NoError(t, err)
+	assert.Equal(t, Defaults{Table: "users", KeyPrefixes: []string{"user/"}}, staging.Defaults)
+
+	_, err = r.Get("missing")
+	assert.ErrorIs(t, err, ErrClusterNotFound)
+}
+
+func TestRegistrySetDefaults(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(Cluster{Name: "prod"}))
+
+	require.NoError(t, r.SetDefaults("prod", Defaults{Table: "orders", KeyPrefixes: []string{"eu/", "us/"}}))
+	c, err := r.Get("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "orders", c.Defaults.Table)
+	assert.Equal(t, []string{"eu/", "us/"}, c.Defaults.KeyPrefixes)
+
+	assert.ErrorIs(t, r.SetDefaults("missing", Defaults{}), ErrClusterNotFound)
+	assert.Error(t, r.SetDefaults("prod", Defaults{KeyPrefixes: []string{""}}))
+	assert.Error(t, r.SetDefaults("prod", Defaults{Table: " orders"}))
+}
+
+func TestRegistryReturnsCopies(t *testing.T) {
+	r := NewRegistry()
+	seeds := []string{"http://a:5001"}
+	require.NoError(t, r.Register(Cluster{Name: "prod", Seeds: seeds}))
+	seeds[0] = "http://changed:5001"
+
+	c, err := r.Get("prod")
+	require.NoError(t, err)
+	c.Seeds[0] = "http://mutated:5001"
+
+	c, err = r.Get("prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://a:5001"}, c.Seeds)
+
+	require.NoError(t, r.SetSeeds("prod", []string{"http://b:5001"}))
+	c, err = r.Get("prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://b:5001"}, c.Seeds)
+}
+
+func TestRegistrySetTags(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(Cluster{Name: "prod", Tags: Tags{"env": "prod"}}))
+	assert.Error(t, r.Register(Cluster{Name: "bad", Tags: Tags{"node_id": "1"}}))
+
+	tags := Tags{"env": "prod", "region": "eu"}
+	require.NoError(t, r.SetTags("prod", tags))
+	tags["region"] = "us"
+	c, err := r.Get("prod")
+	require.NoError(t, err)
+	assert.Equal(t, Tags{"env": "prod", "region": "eu"}, c.Tags)
+
+	assert.ErrorIs(t, r.SetTags("missing", nil), ErrClusterNotFound)
+	assert.Error(t, r.SetTags("prod", Tags{"1env": "prod"}))
+}
@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+)
+
+// Namespace is the metadata namespace the imported clusters are stored in
+const Namespace = "clusters"
+
+// ErrInvalid is returned for cluster definitions that can't be stored
+var ErrInvalid = errors.New("invalid cluster")
+
+// namePattern matches the names of clusters, like the names of configured clusters
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// TLS configures the connection to the servers of an imported cluster
+type TLS struct {
+	// CAFile is the path on the console host of the PEM encoded certificates trusted for the
+	// cluster, the CA of the configuration if empty
+	CAFile string `json:"caFile,omitempty"`
+	// InsecureSkipVerify accepts any certificate of the servers, prefer CAFile
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// Definition is a cluster imported through the API. Imported clusters are connected at startup
+// like the configured ones.
+type Definition struct {
+	Name  string   `json:"name"`
+	Seeds []string `json:"seeds"`
+	TLS   TLS      `json:"tls,omitzero"`
+	// Token is a reference to the bearer token sent to the cluster, e.g. vault://armada/prod#token.
+	// Only references are stored, the token itself is resolved when connecting.
+	Token      string    `json:"token,omitempty"`
+	Defaults   Defaults  `json:"defaults"`
+	Tags       Tags      `json:"tags,omitempty"`
+	ImportedBy string    `json:"importedBy,omitempty"`
+	ImportedAt time.Time `json:"importedAt"`
+}
+
+// Cluster returns the definition as registered
+func (d Definition) Cluster() Cluster {
+	return Cluster{Name: d.Name, Seeds: d.Seeds, Defaults: d.Defaults, Tags: d.Tags}.clone()
+}
+
+// Validate checks that the cluster can be connected to, the token reference is checked by the caller
+func (d Definition) Validate() error {
+	var problems []string
+	if !namePattern.MatchString(d.Name) {
+		problems = append(problems, fmt.Sprintf("names may only contain letters, digits, '.', '_' and '-', got %q", d.Name))
+	}
+	if len(d.Seeds) == 0 {
+		problems = append(problems, "at least one seed address is required")
+	}
+	for _, seed := range d.Seeds {
+		if err := checkAddress(seed); err != nil {
+			problems = append(problems, err.Error())
+		}
+		// Tokens are only sent over TLS
+		if d.Token != "" && !strings.HasPrefix(seed, "https://") {
+			problems = append(problems, fmt.Sprintf("a token requires https:// seeds, got %q", seed))
+		}
+	}
+	if d.TLS.CAFile != "" && d.TLS.InsecureSkipVerify {
+		problems = append(problems, "tls.caFile must not be set together with tls.insecureSkipVerify")
+	}
+	if err := d.Defaults.Validate(); err != nil {
+		problems = append(problems, "defaults: "+err.Error())
+	}
+	if err := d.Tags.Validate(); err != nil {
+		problems = append(problems, "tags: "+err.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w %q: %s", ErrInvalid, d.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkAddress checks a seed address like the configured Armada addresses: a URL like
+// http://host:port or host:port
+func checkAddress(addr string) error {
+	if !strings.Contains(addr, "://") {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("seeds must be URLs like http://host:port or host:port, got %q", addr)
+		}
+		return nil
+	}
+	u, err := url.Parse(addr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("seeds must be URLs like http://host:port or host:port, got %q", addr)
+	}
+	return nil
+}
+
+// Store keeps the imported clusters in the metadata store
+type Store struct {
+	store metadata.Store
+}
+
+// NewStore creates a store of imported clusters
+func NewStore(store metadata.Store) *Store {
+	return &Store{store: store}
+}
+
+// Get returns the imported cluster with the given name, or ErrClusterNotFound
+func (s *Store) Get(name string) (Definition, error) {
+	d, err := metadata.Get[Definition](s.store, Namespace, name)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Definition{}, fmt.Errorf("%w: %s", ErrClusterNotFound, name)
+	}
+	return d, err
+}
+
+// Create stores a new cluster. It returns ErrClusterExists if a cluster of the name was imported before.
+func (s *Store) Create(d Definition) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.Get(d.Name); err == nil {
+		return fmt.Errorf("%w: %s", ErrClusterExists, d.Name)
+	} else if !errors.Is(err, ErrClusterNotFound) {
+		return err
+	}
+	return metadata.Put(s.store, Namespace, d.Name, d)
+}
+
+// List returns the imported clusters sorted by name
+func (s *Store) List() ([]Definition, error) {
+	stored, err := metadata.List[Definition](s.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	definitions := make([]Definition, 0, len(stored))
+	for _, d := range stored {
+		definitions = append(definitions, d)
+	}
+	slices.SortFunc(definitions, func(a, b Definition) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return definitions, nil
+}
@@ -0,0 +1,69 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	s := NewStore(metadata.NewMemoryStore())
+	require.NoError(t, s.Create(Definition{Name: "staging", Seeds: []string{"http://staging:5001"}}))
+	require.NoError(t, s.Create(Definition{
+		Name:     "prod-eu",
+		Seeds:    []string{"https://prod-eu-1:5001", "https://prod-eu-2:5001"},
+		TLS:      TLS{CAFile: "/etc/armada/ca.pem"},
+		Token:    "vault://armada/prod-eu#token",
+		Defaults: Defaults{Table: "orders"},
+	}))
+
+	err := s.Create(Definition{Name: "staging", Seeds: []string{"http://staging:5001"}})
+	assert.ErrorIs(t, err, ErrClusterExists)
+
+	definitions, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, definitions, 2)
+	assert.Equal(t, "prod-eu", definitions[0].Name)
+	assert.Equal(t, "staging", definitions[1].Name)
+	assert.Equal(t, Cluster{
+		Name:     "prod-eu",
+		Seeds:    []string{"https://prod-eu-1:5001", "https://prod-eu-2:5001"},
+		Defaults: Defaults{Table: "orders"},
+	}, definitions[0].Cluster())
+
+	_, err = s.Get("missing")
+	assert.ErrorIs(t, err, ErrClusterNotFound)
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     Definition
+		wantErr string
+	}{
+		{name: "Valid", def: Definition{Name: "prod", Seeds: []string{"armada:5001"}}},
+		{name: "InvalidName", def: Definition{Name: "prod eu", Seeds: []string{"http://armada:5001"}}, wantErr: "names may only contain"},
+		{name: "NoSeeds", def: Definition{Name: "prod"}, wantErr: "at least one seed"},
+		{name: "InvalidSeed", def: Definition{Name: "prod", Seeds: []string{"ftp://armada:5001"}}, wantErr: "seeds must be URLs"},
+		{name: "TokenWithoutTLS", def: Definition{Name: "prod", Seeds: []string{"http://armada:5001"}, Token: "env://TOKEN"}, wantErr: "requires https://"},
+		{
+			name:    "CAWithInsecure",
+			def:     Definition{Name: "prod", Seeds: []string{"https://armada:5001"}, TLS: TLS{CAFile: "ca.pem", InsecureSkipVerify: true}},
+			wantErr: "tls.caFile must not be set together",
+		},
+		{name: "InvalidDefaults", def: Definition{Name: "prod", Seeds: []string{"http://armada:5001"}, Defaults: Defaults{KeyPrefixes: []string{""}}}, wantErr: "defaults:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.def.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalid)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tagKeyPattern matches the keys of tags. Tags are added as labels to the metrics of a cluster,
+// so their keys must be valid label names.
+var tagKeyPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedTagKeys are the labels the console sets on scraped series itself
+var reservedTagKeys = []string{"cluster", "node_id", "node_name", "instance", "job"}
+
+// Tags are free-form labels of a cluster like env=prod or region=eu
+type Tags map[string]string
+
+// Validate checks that the tags can be added as labels to the metrics of the cluster
+func (t Tags) Validate() error {
+	for _, key := range slices.Sorted(maps.Keys(t)) {
+		switch {
+		case !tagKeyPattern.MatchString(key):
+			return fmt.Errorf("tag keys may only contain letters, digits and '_' and must not start with a digit, got %q", key)
+		case strings.HasPrefix(key, "__") || slices.Contains(reservedTagKeys, key):
+			return fmt.Errorf("tag key %q is reserved", key)
+		case t[key] == "":
+			return fmt.Errorf("tag %q must have a value", key)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the tags have every tag of the filter
+func (t Tags) Matches(filter Tags) bool {
+	for key, value := range filter {
+		if t[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTags parses tags written as key=value
+func ParseTags(values []string) (Tags, error) {
+	tags := make(Tags, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("tags must be written as key=value, got %q", v)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if _, ok := tags[key]; ok {
+			return nil, fmt.Errorf("tag %q is given more than once", key)
+		}
+		tags[key] = value
+	}
+	if err := tags.Validate(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags([]string{"env=prod", " region = eu "})
+	require.NoError(t, err)
+	assert.Equal(t, Tags{"env": "prod", "region": "eu"}, tags)
+
+	for _, values := range [][]string{
+		{"env"},
+		{"env="},
+		{"env=prod", "env=dev"},
+		{"cluster=prod"},
+		{"__name__=up"},
+		{"env-name=prod"},
+	} {
+		_, err := ParseTags(values)
+		assert.Error(t, err, values)
+	}
+}
+
+func TestTagsMatches(t *testing.T) {
+	tags := Tags{"env": "prod", "region": "eu"}
+	assert.True(t, tags.Matches(nil))
+	assert.True(t, tags.Matches(Tags{"env": "prod"}))
+	assert.True(t, tags.Matches(Tags{"env": "prod", "region": "eu"}))
+	assert.False(t, tags.Matches(Tags{"env": "dev"}))
+	assert.False(t, tags.Matches(Tags{"tier": "gold"}))
+	assert.False(t, Tags(nil).Matches(Tags{"env": "prod"}))
+}
@@ -0,0 +1,44 @@
+// Package coalesce deduplicates identical concurrent reads so that they share
+// a single upstream execution.
+package coalesce
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Group coalesces concurrent calls with the same key. The zero value is ready to use.
+type Group struct {
+	group singleflight.Group
+}
+
+// Do executes fn once for all concurrent callers passing the same key and hands
+// each of them the result. shared reports whether the result was shared with other callers.
+//
+// The execution is detached from the cancellation of the caller that started it,
+// so a client going away doesn't fail the requests piggybacking on its call.
+// Each caller still stops waiting when its own context is done.
+// Results are shared and must be treated as read-only.
+func Do[T any](ctx context.Context, g *Group, key string, fn func(ctx context.Context) (T, error)) (v T, shared bool, err error) {
+	detached := context.WithoutCancel(ctx)
+	ch := g.group.DoChan(key, func() (any, error) {
+		return fn(detached)
+	})
+
+	select {
+	case <-ctx.Done():
+		return v, false, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return v, res.Shared, res.Err
+		}
+		v, ok := res.Val.(T)
+		if !ok {
+			// Calls with the same key must return the same type
+			return v, res.Shared, fmt.Errorf("coalesce: unexpected result type %T for key %q", res.Val, key)
+		}
+		return v, res.Shared, nil
+	}
+}
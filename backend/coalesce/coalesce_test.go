@@ -0,0 +1,92 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoSharesConcurrentCalls(t *testing.T) {
+	var g Group
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 10
+	var started, done sync.WaitGroup
+	started.Add(callers)
+	done.Add(callers)
+	results := make([]int, callers)
+	for i := range callers {
+		go func() {
+			defer done.Done()
+			started.Done()
+			v, _, err := Do(context.Background(), &g, "status", func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			assert.NoError(t, err)
+			results[i] = v
+		}()
+	}
+	started.Wait()
+	// Give the callers time to join the in-flight call
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, v := range results {
+		assert.Equal(t, 42, v)
+	}
+}
+
+func TestDoDifferentKeys(t *testing.T) {
+	var g Group
+	a, _, err := Do(context.Background(), &g, "a", func(ctx context.Context) (string, error) { return "a", nil })
+	require.NoError(t, err)
+	b, _, err := Do(context.Background(), &g, "b", func(ctx context.Context) (string, error) { return "b", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "a", a)
+	assert.Equal(t, "b", b)
+}
+
+func TestDoError(t *testing.T) {
+	var g Group
+	boom := errors.New("boom")
+	_, _, err := Do(context.Background(), &g, "a", func(ctx context.Context) (int, error) { return 0, boom })
+	assert.ErrorIs(t, err, boom)
+
+	// Errors are not cached
+	v, _, err := Do(context.Background(), &g, "a", func(ctx context.Context) (int, error) { return 1, nil })
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+}
+
+func TestDoCallerCancellation(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	upstreamErr := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err := Do(ctx, &g, "a", func(ctx context.Context) (int, error) {
+		<-release
+		upstreamErr <- ctx.Err()
+		return 1, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The upstream call keeps running for other callers
+	close(release)
+	assert.NoError(t, <-upstreamErr)
+}
@@ -0,0 +1,1039 @@
+// Package config loads the console configuration.
+// Every setting has a built-in default which can be overridden by an optional
+// YAML configuration file, then by environment variables and finally by
+// command-line flags. The loader records
+// where each effective value came from so operators can verify their deployment
+// (e.g. Helm values) actually took effect.
+package config
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source describes where the effective value of a setting came from.
+type Source string
+
+const (
+	// SourceDefault means the built-in default value is used.
+	SourceDefault Source = "default"
+	// SourceFile means the value was read from the configuration file.
+	SourceFile Source = "file"
+	// SourceEnv means the value was read from an environment variable.
+	SourceEnv Source = "env"
+	// SourceFlag means the value was set by a command-line flag.
+	SourceFlag Source = "flag"
+)
+
+// redacted replaces the values of secret settings in reports
+const redacted = "<redacted>"
+
+// Config is the complete console configuration.
+//
+// Leaf fields are described by struct tags:
+//   - config: the key of the setting, nested under the key of the parent struct
+//   - env: the environment variable overriding the setting
+//   - deprecated: comma separated list of deprecated environment variables still honoured
+//   - flag: the command-line flag overriding the setting, if any
+//   - default: the built-in default value
+//   - secret: "true" if the value must never be reported
+type Config struct {
+	Server    ServerConfig    `config:"server"`
+	Armada    ArmadaConfig    `config:"armada"`
+	Discovery DiscoveryConfig `config:"discovery"`
+	Metrics   MetricsConfig   `config:"metrics"`
+	Reporting ReportingConfig `config:"reporting"`
+	Metadata  MetadataConfig  `config:"metadata"`
+	Audit     AuditConfig     `config:"audit"`
+	Log       LogConfig       `config:"log"`
+	HotKeys   HotKeysConfig   `config:"hotKeys"`
+	Growth    GrowthConfig    `config:"growth"`
+	Shadow    ShadowConfig    `config:"shadow"`
+	Auth      AuthConfig      `config:"auth"`
+	Embed     EmbedConfig     `config:"embed"`
+	Branding  BrandingConfig  `config:"branding"`
+	Tracing   TracingConfig   `config:"tracing"`
+	Analytics AnalyticsConfig `config:"analytics"`
+	Debug     DebugConfig     `config:"debug"`
+	Outbound  OutboundConfig  `config:"outbound"`
+	Secrets   SecretsConfig   `config:"secrets"`
+
+	// file is the path of the configuration file, if any
+	file string
+	// sources maps setting keys to the source of their effective value
+	sources map[string]Source
+	// references maps setting keys to the references to secrets their values were resolved from
+	references map[string]string
+	// warnings collects deprecation and other non-fatal configuration problems
+	warnings []string
+}
+
+// ServerConfig configures the HTTP server of the console.
+type ServerConfig struct {
+	// Port is the TCP port the HTTP server listens on.
+	Port string `config:"port" env:"PORT" flag:"port" default:"8080"`
+	// BasePath is the URL prefix the console is served under, e.g. /armada when a reverse
+	// proxy mounts it at https://ops.example.com/armada/. Empty serves it at the root.
+	BasePath string `config:"basePath" env:"BASE_PATH"`
+	// ReadOnly refuses all changes, e.g. writing keys or creating tables, whatever the role of the
+	// user, so the console can be shared with a wide audience for observability only.
+	ReadOnly bool `config:"readOnly" env:"READ_ONLY" default:"false"`
+	// Headless serves only the API, metrics and health endpoints but not the frontend, e.g. when
+	// the frontend is served from a CDN or the console only collects metrics.
+	Headless bool `config:"headless" env:"HEADLESS" flag:"headless" default:"false"`
+	// FrontendDir serves the frontend from a directory, e.g. frontend/dist, instead of the embedded
+	// bundle, so frontend changes only need a rebuild of the frontend during development.
+	FrontendDir string `config:"frontendDir" env:"FRONTEND_DIR" flag:"frontend-dir"`
+	// FrontendProxy forwards the frontend requests to a development server, e.g. the Vite dev
+	// server at http://localhost:3000, instead of serving the embedded bundle.
+	FrontendProxy string `config:"frontendProxy" env:"FRONTEND_PROXY" flag:"frontend-proxy"`
+	// TLSCertFile is the certificate served over HTTPS. HTTPS is enabled when it is set.
+	TLSCertFile string `config:"tlsCertFile" env:"TLS_CERT_FILE"`
+	// TLSKeyFile is the private key of the HTTPS certificate.
+	TLSKeyFile string `config:"tlsKeyFile" env:"TLS_KEY_FILE"`
+	// MaxResponseBuffer is the number of bytes of a response held in memory before it is streamed.
+	MaxResponseBuffer int `config:"maxResponseBuffer" env:"MAX_RESPONSE_BUFFER" default:"1048576"`
+	// ResponseFlushThreshold is the number of bytes written between flushes of a streamed response.
+	ResponseFlushThreshold int `config:"responseFlushThreshold" env:"RESPONSE_FLUSH_THRESHOLD" default:"65536"`
+	// ReadHeaderTimeout is the time allowed to read request headers.
+	ReadHeaderTimeout time.Duration `config:"readHeaderTimeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	// ReadTimeout is the time allowed to read an entire request, including the body.
+	ReadTimeout time.Duration `config:"readTimeout" env:"SERVER_READ_TIMEOUT" default:"30s"`
+	// WriteTimeout is the time allowed to write a response.
+	WriteTimeout time.Duration `config:"writeTimeout" env:"SERVER_WRITE_TIMEOUT" default:"60s"`
+	// IdleTimeout is how long keep-alive connections wait for the next request.
+	IdleTimeout time.Duration `config:"idleTimeout" env:"SERVER_IDLE_TIMEOUT" default:"2m"`
+	// MaxHeaderBytes limits the size of request headers.
+	MaxHeaderBytes int `config:"maxHeaderBytes" env:"SERVER_MAX_HEADER_BYTES" default:"1048576"`
+	// StreamTimeout replaces the read and write timeouts of long-running streaming endpoints.
+	StreamTimeout time.Duration `config:"streamTimeout" env:"SERVER_STREAM_TIMEOUT" default:"10m"`
+	// TrustedProxies are the CIDRs of the reverse proxies in front of the console, e.g. 10.0.0.0/8.
+	// Their requests are attributed to the client named in X-Forwarded-For, e.g. when failed
+	// logins are counted per client address.
+	TrustedProxies []string `config:"trustedProxies" env:"SERVER_TRUSTED_PROXIES"`
+}
+
+// ArmadaConfig configures the connection to the Armada cluster.
+type ArmadaConfig struct {
+	// URL is the address of the primary Armada server.
+	URL string `config:"url" env:"ARMADA_URL" flag:"armada-url" default:"http://localhost:5001"`
+	// ClusterName is the name of the cluster in the cluster registry.
+	ClusterName string `config:"clusterName" env:"ARMADA_CLUSTER_NAME" default:"default"`
+	// Clusters are further independent clusters served by the console, each given as name=url,
+	// e.g. staging=http://staging:5001. API requests select one with the cluster parameter.
+	Clusters []string `config:"clusters" env:"ARMADA_CLUSTERS"`
+	// Tags label the cluster as key=value entries, e.g. env=prod. They are added as labels to the
+	// metrics of the cluster and filter the fleet.
+	Tags []string `config:"tags" env:"ARMADA_CLUSTER_TAGS"`
+	// ClusterTags are the tags of further clusters, as name:key=value entries.
+	ClusterTags []string `config:"clusterTags" env:"ARMADA_CLUSTERS_TAGS"`
+	// DefaultTable is the table the UI opens by default for this cluster.
+	DefaultTable string `config:"defaultTable" env:"ARMADA_DEFAULT_TABLE"`
+	// DefaultKeyPrefixes are the key prefix filters offered by default when browsing this cluster.
+	DefaultKeyPrefixes []string `config:"defaultKeyPrefixes" env:"ARMADA_DEFAULT_KEY_PREFIXES"`
+	// RangeTimeout is the budget of a key range scan; the keys received within it are returned as a partial result.
+	RangeTimeout time.Duration `config:"rangeTimeout" env:"ARMADA_RANGE_TIMEOUT" default:"10s"`
+	// StatusTimeout bounds the status, cluster and servers endpoints, which are polled and should fail fast.
+	StatusTimeout time.Duration `config:"statusTimeout" env:"ARMADA_STATUS_TIMEOUT" default:"5s"`
+	// TablesTimeout bounds listing, creating and deleting tables.
+	TablesTimeout time.Duration `config:"tablesTimeout" env:"ARMADA_TABLES_TIMEOUT" default:"30s"`
+	// KVTimeout bounds reading, writing and deleting single keys; range scans are bounded by RangeTimeout.
+	KVTimeout time.Duration `config:"kvTimeout" env:"ARMADA_KV_TIMEOUT" default:"10s"`
+	// TLSCertFile is a PEM encoded client certificate presented to https Armada addresses requiring mutual TLS.
+	TLSCertFile string `config:"tlsCertFile" env:"ARMADA_TLS_CERT_FILE"`
+	// TLSKeyFile is the PEM encoded private key of TLSCertFile.
+	TLSKeyFile string `config:"tlsKeyFile" env:"ARMADA_TLS_KEY_FILE"`
+	// TLSCAFile holds the PEM encoded certificates trusted for https Armada addresses instead of the system roots.
+	// It may also be a directory of such files.
+	TLSCAFile string `config:"tlsCaFile" env:"ARMADA_TLS_CA_FILE"`
+	// TLSInsecureSkipVerify accepts any certificate of https Armada addresses, prefer TLSCAFile.
+	TLSInsecureSkipVerify bool `config:"tlsInsecureSkipVerify" env:"ARMADA_TLS_INSECURE_SKIP_VERIFY" default:"false"`
+	// ClusterTLSCAFiles replace TLSCAFile for further clusters, as name=path entries.
+	ClusterTLSCAFiles []string `config:"clusterTlsCaFiles" env:"ARMADA_CLUSTERS_TLS_CA_FILES"`
+	// ClusterTLSInsecureSkipVerify names further clusters whose certificates aren't verified.
+	ClusterTLSInsecureSkipVerify []string `config:"clusterTlsInsecureSkipVerify" env:"ARMADA_CLUSTERS_TLS_INSECURE_SKIP_VERIFY"`
+	// Token is a bearer token sent with every call to the Armada servers, which requires https addresses.
+	Token string `config:"token" env:"ARMADA_TOKEN" secret:"true"`
+	// TokenFile is read for the bearer token instead of Token. It is read again when it changes.
+	TokenFile string `config:"tokenFile" env:"ARMADA_TOKEN_FILE"`
+	// Indexes enables the index tables maintained by the console, which answer repeated value filters of key scans.
+	Indexes bool `config:"indexes" env:"ARMADA_INDEXES" default:"false"`
+	// VerifyReads also issues a sample of the reads to a second replica and logs and counts responses
+	// that differ. It is meant for diagnosing suspected data inconsistency.
+	VerifyReads bool `config:"verifyReads" env:"ARMADA_VERIFY_READS" default:"false"`
+	// VerifyReadsSampleRate is the fraction of reads verified, between 0 and 1.
+	VerifyReadsSampleRate float64 `config:"verifyReadsSampleRate" env:"ARMADA_VERIFY_READS_SAMPLE_RATE" default:"0.1"`
+	// HealthCheckInterval is how often every Armada server is probed, so that connections lost in an
+	// outage are replaced before the next request needs them. Zero disables the health checks.
+	HealthCheckInterval time.Duration `config:"healthCheckInterval" env:"ARMADA_HEALTH_CHECK_INTERVAL" default:"30s"`
+	// MemberDiscoveryInterval is how often the members of every cluster are listed again, so members
+	// that joined since are connected to. Zero only lists them when a new connection is made.
+	MemberDiscoveryInterval time.Duration `config:"memberDiscoveryInterval" env:"ARMADA_MEMBER_DISCOVERY_INTERVAL" default:"1m"`
+	// ReconnectMaxRetries is how many times a reconnect requested by an operator dials a server before giving up.
+	ReconnectMaxRetries int `config:"reconnectMaxRetries" env:"ARMADA_RECONNECT_MAX_RETRIES" default:"5"`
+	// ReconnectBaseDelay is the delay before redialing a server after the first failed attempt, it grows
+	// by 1.6 after every further one.
+	ReconnectBaseDelay time.Duration `config:"reconnectBaseDelay" env:"ARMADA_RECONNECT_BASE_DELAY" default:"500ms"`
+	// ReconnectMaxDelay caps the delay between attempts to redial a server.
+	ReconnectMaxDelay time.Duration `config:"reconnectMaxDelay" env:"ARMADA_RECONNECT_MAX_DELAY" default:"30s"`
+	// ReconnectJitter randomizes every delay by up to this fraction in either direction, between 0 and 1,
+	// so connections lost together don't redial together.
+	ReconnectJitter float64 `config:"reconnectJitter" env:"ARMADA_RECONNECT_JITTER" default:"0.2"`
+	// RetryMaxAttempts is how many times the idempotent calls Range, MemberList, Status and GetMetrics
+	// are attempted when they fail with UNAVAILABLE or DEADLINE_EXCEEDED, e.g. during a leader
+	// election. 1 disables retries.
+	RetryMaxAttempts int `config:"retryMaxAttempts" env:"ARMADA_RETRY_MAX_ATTEMPTS" default:"3"`
+	// RetryBaseDelay is the delay before the first retry of a call, it grows by 1.6 after every further one.
+	RetryBaseDelay time.Duration `config:"retryBaseDelay" env:"ARMADA_RETRY_BASE_DELAY" default:"100ms"`
+	// RetryMaxDelay caps the delay between the attempts of a call.
+	RetryMaxDelay time.Duration `config:"retryMaxDelay" env:"ARMADA_RETRY_MAX_DELAY" default:"1s"`
+	// RetryBudget is the fraction of the calls to a cluster that may be retried, between 0 and 1, so
+	// a cluster that is down isn't called more often than when it is up.
+	RetryBudget float64 `config:"retryBudget" env:"ARMADA_RETRY_BUDGET" default:"0.2"`
+	// BreakerFailures is how many calls to a server must fail in a row because it is down before
+	// further calls fail right away for BreakerCooldown, so status fan-outs don't wait for it. Zero
+	// disables the circuit breaker.
+	BreakerFailures int `config:"breakerFailures" env:"ARMADA_BREAKER_FAILURES" default:"5"`
+	// BreakerCooldown is how long calls to a server fail right away before a trial call is let through.
+	BreakerCooldown time.Duration `config:"breakerCooldown" env:"ARMADA_BREAKER_COOLDOWN" default:"30s"`
+	// KeepaliveTime is how long a connection may be idle before the server is pinged, so links dropped
+	// by a NAT or load balancer are detected. Zero disables the pings.
+	KeepaliveTime time.Duration `config:"keepaliveTime" env:"ARMADA_KEEPALIVE_TIME" default:"0s"`
+	// KeepaliveTimeout is how long a ping may go unanswered before the connection is closed.
+	KeepaliveTimeout time.Duration `config:"keepaliveTimeout" env:"ARMADA_KEEPALIVE_TIMEOUT" default:"20s"`
+	// KeepalivePermitWithoutStream also pings connections without active calls; the servers must allow it.
+	KeepalivePermitWithoutStream bool `config:"keepalivePermitWithoutStream" env:"ARMADA_KEEPALIVE_PERMIT_WITHOUT_STREAM" default:"false"`
+	// ConnectTimeout is how long the console waits at startup, and for servers found by discovery, until
+	// the connection is ready; servers that can't be reached in time fail the startup or are reported.
+	// Zero doesn't wait, connections are then established by the first request.
+	ConnectTimeout time.Duration `config:"connectTimeout" env:"ARMADA_CONNECT_TIMEOUT" default:"0s"`
+	// SlowCallThreshold is how long a call to a server may take before it is logged as a warning, every
+	// call is logged at debug level. Zero doesn't warn about slow calls.
+	SlowCallThreshold time.Duration `config:"slowCallThreshold" env:"ARMADA_SLOW_CALL_THRESHOLD" default:"0s"`
+	// MaxRecvMsgSize limits the size in bytes of the responses read from the servers, e.g. of metrics
+	// payloads and key ranges. Zero keeps the gRPC default of 4MB.
+	MaxRecvMsgSize int `config:"maxRecvMsgSize" env:"ARMADA_MAX_RECV_MSG_SIZE" default:"16777216"`
+	// MaxSendMsgSize limits the size in bytes of the requests sent to the servers, e.g. of written
+	// values. Zero keeps the gRPC default, which doesn't limit them.
+	MaxSendMsgSize int `config:"maxSendMsgSize" env:"ARMADA_MAX_SEND_MSG_SIZE" default:"0"`
+}
+
+// NamedCluster is a further cluster of ArmadaConfig.Clusters
+type NamedCluster struct {
+	Name string
+	URL  string
+	// TLSCAFile is the CA of the cluster from ClusterTLSCAFiles, or TLSCAFile
+	TLSCAFile string
+	// TLSInsecureSkipVerify is set if the cluster is in ClusterTLSInsecureSkipVerify, or by TLSInsecureSkipVerify
+	TLSInsecureSkipVerify bool
+	// Tags are the tags of the cluster from ClusterTags
+	Tags map[string]string
+}
+
+// NamedClusters returns the further clusters in the order they are configured.
+// Entries that are not of the form name=url are skipped, Validate reports them.
+func (a ArmadaConfig) NamedClusters() []NamedCluster {
+	caFiles := make(map[string]string, len(a.ClusterTLSCAFiles))
+	for _, entry := range a.ClusterTLSCAFiles {
+		if name, path, ok := strings.Cut(entry, "="); ok {
+			caFiles[strings.TrimSpace(name)] = strings.TrimSpace(path)
+		}
+	}
+	tags := make(map[string][]string, len(a.ClusterTags))
+	for _, entry := range a.ClusterTags {
+		if name, tag, ok := strings.Cut(entry, ":"); ok {
+			tags[strings.TrimSpace(name)] = append(tags[strings.TrimSpace(name)], tag)
+		}
+	}
+	clusters := make([]NamedCluster, 0, len(a.Clusters))
+	for _, entry := range a.Clusters {
+		name, url, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		c := NamedCluster{
+			Name:                  strings.TrimSpace(name),
+			URL:                   strings.TrimSpace(url),
+			TLSCAFile:             a.TLSCAFile,
+			TLSInsecureSkipVerify: a.TLSInsecureSkipVerify,
+		}
+		if path, ok := caFiles[c.Name]; ok {
+			c.TLSCAFile = path
+		}
+		if slices.Contains(a.ClusterTLSInsecureSkipVerify, c.Name) {
+			c.TLSInsecureSkipVerify = true
+		}
+		c.Tags = parseTags(tags[c.Name])
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
+
+// DefaultTags returns the tags of the default cluster from Tags
+func (a ArmadaConfig) DefaultTags() map[string]string {
+	return parseTags(a.Tags)
+}
+
+// parseTags returns the tags of key=value entries, or nil if there are none.
+// Entries that are not of the form key=value are skipped, Validate reports them.
+func parseTags(entries []string) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return tags
+}
+
+// DiscoveryConfig configures dynamic discovery of Armada seed addresses.
+type DiscoveryConfig struct {
+	// Mechanism selects the discovery mechanism: static, dns-srv or consul.
+	Mechanism string `config:"mechanism" env:"ARMADA_DISCOVERY" default:"static"`
+	// Interval is how often discovered seeds are refreshed.
+	Interval time.Duration `config:"interval" env:"ARMADA_DISCOVERY_INTERVAL" default:"1m"`
+	// Scheme is prepended to discovered addresses.
+	Scheme string `config:"scheme" env:"ARMADA_DISCOVERY_SCHEME" default:"http"`
+	// SRVRecord is the SRV record resolved by dns-srv discovery.
+	SRVRecord string `config:"srvRecord" env:"ARMADA_DISCOVERY_SRV_RECORD"`
+	// Consul configures consul discovery.
+	Consul ConsulConfig `config:"consul"`
+}
+
+// ConsulConfig configures discovery from the Consul service catalog.
+type ConsulConfig struct {
+	// Address is the Consul HTTP API address.
+	Address string `config:"address" env:"ARMADA_DISCOVERY_CONSUL_ADDR" default:"http://127.0.0.1:8500"`
+	// Service is the Consul service name of the Armada servers.
+	Service string `config:"service" env:"ARMADA_DISCOVERY_CONSUL_SERVICE" default:"armada"`
+	// Tag optionally filters service instances by tag.
+	Tag string `config:"tag" env:"ARMADA_DISCOVERY_CONSUL_TAG"`
+	// Datacenter optionally selects the Consul datacenter to query, the agent's own by default.
+	Datacenter string `config:"datacenter" env:"ARMADA_DISCOVERY_CONSUL_DATACENTER"`
+	// Token is the Consul ACL token.
+	Token string `config:"token" env:"CONSUL_HTTP_TOKEN" secret:"true"`
+}
+
+// MetadataConfig configures the store for console-side metadata such as table annotations.
+type MetadataConfig struct {
+	// Dir is the directory metadata is persisted in. Metadata is kept in memory only when empty.
+	Dir string `config:"dir" env:"METADATA_DIR" flag:"metadata-dir" default:"/tmp/armada-console"`
+	// TopologyRetention is how long the history of cluster members and table leaders is kept.
+	TopologyRetention time.Duration `config:"topologyRetention" env:"TOPOLOGY_RETENTION" default:"720h"`
+	// MaintenanceRetention is how long maintenance windows are kept after they ended.
+	MaintenanceRetention time.Duration `config:"maintenanceRetention" env:"MAINTENANCE_RETENTION" default:"2160h"`
+	// CleanupInterval is how often records beyond their retention are removed, e.g. from the
+	// audit log and the maintenance windows.
+	CleanupInterval time.Duration `config:"cleanupInterval" env:"RETENTION_CLEANUP_INTERVAL" default:"1h"`
+}
+
+// AuditConfig configures the audit log of destructive operations.
+// The log is written to audit.jsonl in the metadata directory.
+type AuditConfig struct {
+	// SnapshotSampleKeys is the number of keys sampled into the state snapshot taken before
+	// a table or key range is deleted. Sampling is disabled when 0.
+	SnapshotSampleKeys int `config:"snapshotSampleKeys" env:"AUDIT_SNAPSHOT_SAMPLE_KEYS" default:"0"`
+	// SigningKey signs the hash chain of the audit log, so a rewritten log can't be given a
+	// valid chain without it. The entries are chained with plain SHA-256 if it is empty.
+	SigningKey string `config:"signingKey" env:"AUDIT_SIGNING_KEY" secret:"true"`
+	// Retention is how long entries are kept, they are kept regardless of their age when 0.
+	Retention time.Duration `config:"retention" env:"AUDIT_RETENTION" default:"0s"`
+	// MaxBytes is the size the oldest entries are removed beyond, unlimited when 0. The
+	// default keeps the log small enough to be exported in full.
+	MaxBytes int64 `config:"maxBytes" env:"AUDIT_MAX_BYTES" default:"268435456"`
+}
+
+// LogConfig configures the console log.
+type LogConfig struct {
+	// Level is the minimum level of logged messages: debug, info, warn or error.
+	Level string `config:"level" env:"LOG_LEVEL" flag:"log-level" default:"debug"`
+}
+
+// AuthConfig configures authentication of the console. Users log in with HTTP basic
+// authentication or an OpenID Connect provider, machine clients may present JWT bearer
+// tokens of a trusted issuer instead. Authentication is disabled unless a username, an
+// OIDC issuer or a token issuer is set.
+type AuthConfig struct {
+	// Username is the name users log in with.
+	Username string `config:"username" env:"AUTH_USERNAME"`
+	// PasswordHash is the bcrypt hash of the password, e.g. created with `console hash-password`.
+	PasswordHash string `config:"passwordHash" env:"AUTH_PASSWORD_HASH" secret:"true"`
+	// Realm is the protection space reported to browsers.
+	Realm string `config:"realm" env:"AUTH_REALM" default:"Armada Console"`
+	// LocalUsers lets operators create further users with /api/admin/users, who log in with basic
+	// authentication like the configured user. They are stored in the metadata store.
+	LocalUsers bool `config:"localUsers" env:"AUTH_LOCAL_USERS" default:"false"`
+	// APIKeys lets operators create API keys with /api/admin/apikeys, which scripts and CI jobs
+	// send in the X-API-Key header or as bearer tokens.
+	APIKeys bool `config:"apiKeys" env:"AUTH_API_KEYS" default:"false"`
+	// APIKeysTable is the table of the cluster the digests of the API keys are stored in, so all
+	// consoles of the cluster share them. It is created if missing. The keys are kept in the
+	// metadata store when it is empty.
+	APIKeysTable string `config:"apiKeysTable" env:"AUTH_API_KEYS_TABLE"`
+	// LoginMaxFailures is the number of consecutive failed basic authentication logins after which
+	// an account is locked out, 0 disables the lockout of accounts.
+	LoginMaxFailures int `config:"loginMaxFailures" env:"AUTH_LOGIN_MAX_FAILURES" default:"5"`
+	// LoginMaxFailuresPerAddress is the number of consecutive failed logins after which a client
+	// address is locked out, 0 disables the lockout of addresses. Behind a reverse proxy all
+	// clients share the address of the proxy.
+	LoginMaxFailuresPerAddress int `config:"loginMaxFailuresPerAddress" env:"AUTH_LOGIN_MAX_FAILURES_PER_ADDRESS" default:"20"`
+	// LoginLockout is how long the first lockout lasts, it doubles with every further failure.
+	LoginLockout time.Duration `config:"loginLockout" env:"AUTH_LOGIN_LOCKOUT" default:"1m"`
+	// LoginMaxLockout is the longest lockout. Failures are forgotten this long after the last one.
+	LoginMaxLockout time.Duration `config:"loginMaxLockout" env:"AUTH_LOGIN_MAX_LOCKOUT" default:"1h"`
+	// OIDCIssuer is the URL of the OpenID Connect provider browsers log in with.
+	OIDCIssuer string `config:"oidcIssuer" env:"AUTH_OIDC_ISSUER"`
+	// OIDCClientID is the client ID of the console registered with the provider.
+	OIDCClientID string `config:"oidcClientId" env:"AUTH_OIDC_CLIENT_ID"`
+	// OIDCClientSecret is the client secret, it may be empty for public clients.
+	OIDCClientSecret string `config:"oidcClientSecret" env:"AUTH_OIDC_CLIENT_SECRET" secret:"true"`
+	// OIDCRedirectURL is the callback URL registered with the provider,
+	// e.g. https://console.example.com/api/auth/callback.
+	OIDCRedirectURL string `config:"oidcRedirectUrl" env:"AUTH_OIDC_REDIRECT_URL"`
+	// OIDCScopes are the space-separated scopes requested in addition to openid.
+	OIDCScopes string `config:"oidcScopes" env:"AUTH_OIDC_SCOPES" default:"profile email"`
+	// SessionTTL is how long users stay logged in after an OIDC login or starting a session with
+	// basic authentication, however actively they use the console.
+	SessionTTL time.Duration `config:"sessionTTL" env:"AUTH_SESSION_TTL" default:"8h"`
+	// SessionIdleTimeout ends sessions that haven't been used for this long, 0 disables it.
+	// It is enforced with a precision of a minute.
+	SessionIdleTimeout time.Duration `config:"sessionIdleTimeout" env:"AUTH_SESSION_IDLE_TIMEOUT" default:"1h"`
+	// SessionSameSite is the SameSite attribute of the session cookie: lax or strict. Strict cookies
+	// aren't sent when following links from other sites, which breaks the OIDC login.
+	SessionSameSite string `config:"sessionSameSite" env:"AUTH_SESSION_SAME_SITE" default:"lax"`
+	// MaxSessions is the number of concurrent sessions of a user, starting a further session ends
+	// the least recently used one. 0 disables the limit.
+	MaxSessions int `config:"maxSessions" env:"AUTH_MAX_SESSIONS" default:"10"`
+	// JWTIssuer is the issuer whose bearer tokens are accepted. Tokens are not accepted when it is empty.
+	JWTIssuer string `config:"jwtIssuer" env:"AUTH_JWT_ISSUER"`
+	// JWTJWKSURL is where the issuer publishes the keys its tokens are signed with.
+	JWTJWKSURL string `config:"jwtJwksUrl" env:"AUTH_JWT_JWKS_URL"`
+	// JWTAudience is the audience tokens must be issued for, any audience is accepted when empty.
+	JWTAudience string `config:"jwtAudience" env:"AUTH_JWT_AUDIENCE"`
+	// JWTUserClaim is the claim identifying the client, e.g. in the audit log.
+	JWTUserClaim string `config:"jwtUserClaim" env:"AUTH_JWT_USER_CLAIM" default:"sub"`
+	// JWKSRefresh is how often the keys are fetched again to pick up rotated keys.
+	JWKSRefresh time.Duration `config:"jwksRefresh" env:"AUTH_JWKS_REFRESH" default:"1h"`
+	// GroupsClaim is the claim of ID and bearer tokens listing the groups of the user.
+	GroupsClaim string `config:"groupsClaim" env:"AUTH_GROUPS_CLAIM" default:"groups"`
+	// DefaultRole is the role of users that are not operators by name or group: viewer or operator.
+	// It also applies when authentication is disabled.
+	DefaultRole string `config:"defaultRole" env:"AUTH_DEFAULT_ROLE" default:"operator"`
+	// Operators are the comma-separated users with the operator role as method:name, e.g.
+	// oidc:jane@example.com or basic:admin. The method is basic, oidc or bearer.
+	Operators string `config:"operators" env:"AUTH_OPERATORS"`
+	// OperatorGroups are the comma-separated groups whose members have the operator role.
+	OperatorGroups string `config:"operatorGroups" env:"AUTH_OPERATOR_GROUPS"`
+	// Guardrails are policies on the changes of clusters with a tag, as tag:rule entries, e.g.
+	// env=prod:read-only or env=prod:approve=table.delete. Read-only clusters may only be changed
+	// by guardrail admins, approved actions must be approved by a guardrail admin other than the
+	// user requesting them.
+	Guardrails []string `config:"guardrails" env:"AUTH_GUARDRAILS"`
+	// GuardrailAdmins are the comma-separated users who may change read-only clusters and approve
+	// actions as method:name, e.g. oidc:jane@example.com.
+	GuardrailAdmins string `config:"guardrailAdmins" env:"AUTH_GUARDRAIL_ADMINS"`
+	// GuardrailAdminGroups are the comma-separated groups whose members are guardrail admins.
+	GuardrailAdminGroups string `config:"guardrailAdminGroups" env:"AUTH_GUARDRAIL_ADMIN_GROUPS"`
+	// ApprovalTTL is how long a requested approval may be granted and used before it expires.
+	ApprovalTTL time.Duration `config:"approvalTTL" env:"AUTH_APPROVAL_TTL" default:"1h"`
+	// ClusterImportAdmins are the comma-separated users who may import clusters as method:name,
+	// e.g. oidc:jane@example.com. Nobody may import clusters without them.
+	ClusterImportAdmins string `config:"clusterImportAdmins" env:"AUTH_CLUSTER_IMPORT_ADMINS"`
+}
+
+// EmbedConfig configures the widgets other sites can embed with signed URLs.
+// Embedding is disabled unless a secret is set.
+type EmbedConfig struct {
+	// Secret is the key widget URLs are signed with, at least 32 characters.
+	Secret string `config:"secret" env:"EMBED_SECRET" secret:"true"`
+	// DefaultTTL is how long a signed URL is valid unless requested otherwise.
+	DefaultTTL time.Duration `config:"defaultTTL" env:"EMBED_DEFAULT_TTL" default:"1h"`
+	// MaxTTL is the longest validity that can be requested for a signed URL.
+	MaxTTL time.Duration `config:"maxTTL" env:"EMBED_MAX_TTL" default:"24h"`
+	// FrameAncestors are the space-separated origins allowed to frame widgets, e.g. https://portal.example.com.
+	FrameAncestors string `config:"frameAncestors" env:"EMBED_FRAME_ANCESTORS" default:"*"`
+}
+
+// BrandingConfig configures how the console presents itself, so it can be white-labeled per environment.
+type BrandingConfig struct {
+	// Title is shown in the header and the browser tab.
+	Title string `config:"title" env:"BRANDING_TITLE" default:"Armada Console"`
+	// LogoFile is an image shown above the navigation instead of the title, e.g. a PNG or SVG file.
+	LogoFile string `config:"logoFile" env:"BRANDING_LOGO_FILE"`
+	// Colors override colors of the palette as name=#rrggbb, e.g. primary=#0055aa. The names are
+	// primary, success, error, warning and info.
+	Colors []string `config:"colors" env:"BRANDING_COLORS"`
+	// FooterLinks are shown in the footer as label=URL, e.g. Runbook=https://wiki.example.com/armada.
+	FooterLinks []string `config:"footerLinks" env:"BRANDING_FOOTER_LINKS"`
+}
+
+// HotKeysConfig configures the sampling of key-value requests for the hot key analysis.
+type HotKeysConfig struct {
+	// SampleRate is the fraction of key-value requests sampled, between 0 and 1.
+	SampleRate float64 `config:"sampleRate" env:"HOT_KEYS_SAMPLE_RATE" default:"1"`
+	// Window is how long samples are kept.
+	Window time.Duration `config:"window" env:"HOT_KEYS_WINDOW" default:"1h"`
+}
+
+// GrowthConfig configures the recording of key counts used to report the growth of tables.
+// The history is stored in the TSDB and so kept for the metrics retention.
+type GrowthConfig struct {
+	// Interval is how often the keys of every table and of its key prefixes are counted.
+	Interval time.Duration `config:"interval" env:"GROWTH_INTERVAL" default:"1h"`
+	// PrefixSeparator ends the first segment of keys, which is the prefix keys are counted by.
+	PrefixSeparator string `config:"prefixSeparator" env:"GROWTH_PREFIX_SEPARATOR" default:"/"`
+	// MaxPrefixes caps the key prefixes counted per table.
+	MaxPrefixes int `config:"maxPrefixes" env:"GROWTH_MAX_PREFIXES" default:"100"`
+	// SizeCap is the table size in bytes the time to reach is projected for, 0 disables the projection.
+	SizeCap int64 `config:"sizeCap" env:"GROWTH_SIZE_CAP" default:"0"`
+}
+
+// ShadowConfig configures mirroring the writes made through the console to a secondary cluster.
+type ShadowConfig struct {
+	// Cluster is the further cluster the writes to the default cluster are mirrored to, empty disables mirroring.
+	Cluster string `config:"cluster" env:"SHADOW_CLUSTER"`
+	// QueueSize caps the writes waiting to be mirrored; writes beyond it are recorded as failures.
+	QueueSize int `config:"queueSize" env:"SHADOW_QUEUE_SIZE" default:"10000"`
+	// MaxFailures caps the failed writes kept for the report and retries.
+	MaxFailures int `config:"maxFailures" env:"SHADOW_MAX_FAILURES" default:"1000"`
+}
+
+// TracingConfig configures OpenTelemetry tracing of HTTP requests and the gRPC calls they make.
+type TracingConfig struct {
+	// Exporter selects where spans are sent: none, otlp-grpc or otlp-http.
+	Exporter string `config:"exporter" env:"TRACING_EXPORTER" default:"none"`
+	// Endpoint is the host:port of the OTLP collector, the exporter's default when empty.
+	Endpoint string `config:"endpoint" env:"TRACING_ENDPOINT"`
+	// Insecure sends spans to the collector without TLS.
+	Insecure bool `config:"insecure" env:"TRACING_INSECURE" default:"false"`
+	// SampleRatio is the fraction of traces started by the console that are recorded, between 0 and 1.
+	// Requests carrying a trace context follow the sampling decision of their caller.
+	SampleRatio float64 `config:"sampleRatio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+	// ServiceName identifies the console in the tracing backend.
+	ServiceName string `config:"serviceName" env:"TRACING_SERVICE_NAME" default:"armada-console"`
+}
+
+// AnalyticsConfig configures the opt-in usage analytics. Page views per feature and API
+// requests per route are counted in the metadata store; nothing leaves the console.
+type AnalyticsConfig struct {
+	// Enabled turns on counting usage.
+	Enabled bool `config:"enabled" env:"ANALYTICS_ENABLED" default:"false"`
+	// Retention is how long daily counts are kept.
+	Retention time.Duration `config:"retention" env:"ANALYTICS_RETENTION" default:"2160h"`
+}
+
+// DebugConfig configures the request replay tool. Recent API requests are kept in memory
+// with sensitive values redacted, so operators can re-execute them against a cluster.
+type DebugConfig struct {
+	// RecordRequests turns on recording API requests.
+	RecordRequests bool `config:"recordRequests" env:"DEBUG_RECORD_REQUESTS" default:"false"`
+	// RecordLimit is the number of most recent requests kept.
+	RecordLimit int `config:"recordLimit" env:"DEBUG_RECORD_LIMIT" default:"100"`
+}
+
+// OutboundConfig configures the HTTP requests of the console's integrations, e.g. to the OIDC
+// provider, Consul, Sentry or the tracing collector.
+type OutboundConfig struct {
+	// Headers are sent with every request as "Name: value" entries, e.g. a token an egress
+	// gateway expects.
+	Headers []string `config:"headers" env:"OUTBOUND_HEADERS" secret:"true"`
+	// Proxy is the URL of the proxy requests are sent through, e.g. http://proxy:3128.
+	// Empty uses the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	Proxy string `config:"proxy" env:"OUTBOUND_PROXY" secret:"true"`
+	// UserAgent identifies the console, armada-console/<version> if empty.
+	UserAgent string `config:"userAgent" env:"OUTBOUND_USER_AGENT"`
+	// Timeout bounds requests of integrations without a timeout of their own.
+	Timeout time.Duration `config:"timeout" env:"OUTBOUND_TIMEOUT" default:"30s"`
+}
+
+// HTTPHeaders returns the headers to send with every request.
+// Entries that are not of the form "Name: value" are skipped, Validate reports them.
+func (o OutboundConfig) HTTPHeaders() http.Header {
+	headers := make(http.Header)
+	for _, entry := range o.Headers {
+		name, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			continue
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return headers
+}
+
+// SecretsConfig configures the secret stores that references in other settings are resolved
+// from, e.g. vault://secret/data/armada#password. These settings can't be references themselves.
+type SecretsConfig struct {
+	// CacheTTL is how long resolved secrets are cached, so reloads don't hit the stores every time.
+	CacheTTL time.Duration `config:"cacheTtl" env:"SECRETS_CACHE_TTL" default:"5m"`
+	// RefreshInterval reloads the configuration periodically to pick up rotated secrets, zero
+	// disables the refresh.
+	RefreshInterval time.Duration `config:"refreshInterval" env:"SECRETS_REFRESH_INTERVAL" default:"0s"`
+	// VaultAddress is the URL of HashiCorp Vault, e.g. https://vault:8200. It enables vault:// references.
+	VaultAddress string `config:"vaultAddress" env:"VAULT_ADDR"`
+	// VaultToken authenticates the requests to Vault.
+	VaultToken string `config:"vaultToken" env:"VAULT_TOKEN" secret:"true"`
+	// VaultTokenFile is read on every request instead of VaultToken, e.g. the sink of a Vault agent.
+	VaultTokenFile string `config:"vaultTokenFile" env:"VAULT_TOKEN_FILE"`
+	// VaultNamespace is the Vault Enterprise namespace, if any.
+	VaultNamespace string `config:"vaultNamespace" env:"VAULT_NAMESPACE"`
+	// AWSRegion overrides the region of AWS Secrets Manager used for aws-sm:// references.
+	// Credentials and the region are taken from the environment or the shared AWS configuration.
+	AWSRegion string `config:"awsRegion" env:"SECRETS_AWS_REGION"`
+	// ImportTokens are the prefixes of the references the tokens of imported clusters may be, e.g.
+	// vault://secret/data/armada/. Files and environment variables can't be referenced, imported
+	// clusters can't have tokens without prefixes.
+	ImportTokens []string `config:"importTokens" env:"SECRETS_IMPORT_TOKENS"`
+}
+
+// ReportingConfig configures where panics are reported in addition to the log.
+type ReportingConfig struct {
+	// SentryDSN enables shipping panics to Sentry or a compatible service.
+	SentryDSN string `config:"sentryDsn" env:"SENTRY_DSN" secret:"true"`
+	// Environment tags reported events, e.g. "production" or "staging".
+	Environment string `config:"environment" env:"SENTRY_ENVIRONMENT" default:"production"`
+	// Release tags reported events with the deployed console version.
+	Release string `config:"release" env:"SENTRY_RELEASE"`
+}
+
+// MetricsConfig configures metrics collection and storage.
+type MetricsConfig struct {
+	// StorageDir is the directory of the local TSDB.
+	StorageDir string `config:"storageDir" env:"METRICS_DIR" flag:"metrics-dir" default:"/tmp/tsdb"`
+	// ScrapeInterval is how often metrics are collected from the Armada servers.
+	ScrapeInterval time.Duration `config:"scrapeInterval" env:"SCRAPE_INTERVAL" flag:"scrape-interval" default:"30s"`
+	// MaxScrapeBackoff caps how long scrapes of a failing cluster are suspended. The pause
+	// doubles from twice the scrape interval with every consecutive failure.
+	MaxScrapeBackoff time.Duration `config:"maxScrapeBackoff" env:"MAX_SCRAPE_BACKOFF" default:"10m"`
+	// Retention is how long collected metrics are kept.
+	Retention time.Duration `config:"retention" env:"METRICS_RETENTION" default:"24h"`
+	// BlockDuration is the time range covered by a single persisted TSDB block.
+	BlockDuration time.Duration `config:"blockDuration" env:"METRICS_BLOCK_DURATION" default:"2h"`
+	// TableStatsInterval is how often table statistics are sampled from the Armada servers.
+	TableStatsInterval time.Duration `config:"tableStatsInterval" env:"TABLE_STATS_INTERVAL" default:"1m"`
+	// QueryTimeout bounds the evaluation of a PromQL query.
+	QueryTimeout time.Duration `config:"queryTimeout" env:"METRICS_QUERY_TIMEOUT" default:"2m"`
+	// MaxRefreshInterval caps the polling interval suggested to clients. Suggestions start
+	// at the scrape interval and grow while data is unchanged or the console is busy.
+	MaxRefreshInterval time.Duration `config:"maxRefreshInterval" env:"MAX_REFRESH_INTERVAL" default:"5m"`
+	// MaxClockSkew is how far a server clock may be off the console clock before it is
+	// reported in the diagnostics. Skew is measured on every scrape.
+	MaxClockSkew time.Duration `config:"maxClockSkew" env:"MAX_CLOCK_SKEW" default:"2s"`
+	// DedupWindow enables dropping gauge samples that repeat the previous value of their series.
+	// A repeated value is still stored once per window, which must be shorter than the 5 minute
+	// query lookback. Zero stores every sample.
+	DedupWindow time.Duration `config:"dedupWindow" env:"METRICS_DEDUP_WINDOW" default:"0s"`
+	// DedupMaxSeries caps the series tracked for de-duplication per cluster, samples of
+	// further series are always stored.
+	DedupMaxSeries int `config:"dedupMaxSeries" env:"METRICS_DEDUP_MAX_SERIES" default:"100000"`
+	// MaxSeriesPerMetric stops storing a metric of a cluster once a scrape returns more series
+	// for it, e.g. because a label with a value per key was added. Zero disables the limit.
+	MaxSeriesPerMetric int `config:"maxSeriesPerMetric" env:"METRICS_MAX_SERIES_PER_METRIC" default:"10000"`
+	// MaxLabels stops storing a metric of a cluster once a scrape returns a series of it with
+	// more labels. Zero disables the limit.
+	MaxLabels int `config:"maxLabels" env:"METRICS_MAX_LABELS" default:"0"`
+	// CardinalityCooldown is how long a metric exceeding the limits isn't stored. It is stored
+	// again afterwards, until a scrape exceeds the limits again.
+	CardinalityCooldown time.Duration `config:"cardinalityCooldown" env:"METRICS_CARDINALITY_COOLDOWN" default:"1h"`
+}
+
+// Setting describes the effective value of a single setting.
+type Setting struct {
+	Key     string `json:"key"`
+	Value   any    `json:"value"`
+	Default string `json:"default,omitempty"`
+	Source  Source `json:"source"`
+	Env     string `json:"env,omitempty"`
+	Flag    string `json:"flag,omitempty"`
+	Secret  bool   `json:"secret,omitempty"`
+	// Reference is the reference to the secret the value was resolved from, e.g. vault://...
+	Reference string `json:"reference,omitempty"`
+}
+
+// SecretResolver resolves references to secrets in setting values.
+// The secrets.Resolver implements this interface.
+type SecretResolver interface {
+	// IsReference reports whether the value is a reference to a secret.
+	IsReference(value string) bool
+	// Resolve returns the value of the referenced secret.
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// field is a leaf setting discovered by walking the Config struct
+type field struct {
+	key        string
+	env        string
+	flag       string
+	deprecated []string
+	def        string
+	secret     bool
+	value      reflect.Value
+}
+
+// Load builds the configuration from defaults, the optional YAML file at path
+// and the environment looked up through lookupEnv. Empty environment variables
+// are treated as unset. An empty path means no configuration file is used.
+func Load(path string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{
+		file:    path,
+		sources: make(map[string]Source),
+	}
+	fields := cfg.fields()
+
+	// Apply the built-in defaults
+	for _, f := range fields {
+		if err := setValue(f.value, f.def); err != nil {
+			return nil, fmt.Errorf("invalid default for %s: %w", f.key, err)
+		}
+		cfg.sources[f.key] = SourceDefault
+	}
+
+	// Apply values from the configuration file
+	if path != "" {
+		fileValues, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			raw, ok := fileValues[f.key]
+			if !ok {
+				continue
+			}
+			if err := setValue(f.value, raw); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, f.key, err)
+			}
+			cfg.sources[f.key] = SourceFile
+			delete(fileValues, f.key)
+		}
+		for _, key := range slices.Sorted(maps.Keys(fileValues)) {
+			cfg.warnings = append(cfg.warnings, fmt.Sprintf("%s: unknown setting %q is ignored", path, key))
+		}
+	}
+
+	// Apply values from the environment, deprecated names first so current names win
+	for _, f := range fields {
+		for _, name := range append(slices.Clone(f.deprecated), f.env) {
+			if name == "" {
+				continue
+			}
+			raw, ok := lookupEnv(name)
+			if !ok || raw == "" {
+				continue
+			}
+			if err := setValue(f.value, raw); err != nil {
+				return nil, fmt.Errorf("environment variable %s: %w", name, err)
+			}
+			cfg.sources[f.key] = SourceEnv
+			if name != f.env {
+				cfg.warnings = append(cfg.warnings,
+					fmt.Sprintf("environment variable %s is deprecated, use %s instead", name, f.env))
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// LoadFromEnvironment loads the configuration using the process environment.
+// The configuration file is taken from the CONFIG_FILE environment variable.
+func LoadFromEnvironment() (*Config, error) {
+	return Load(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+}
+
+// File returns the path of the configuration file, or an empty string if none is used
+func (c *Config) File() string {
+	return c.file
+}
+
+// Source returns where the effective value of the setting with the given key came from
+func (c *Config) Source(key string) Source {
+	return c.sources[key]
+}
+
+// Warnings returns the non-fatal problems found while loading the configuration
+func (c *Config) Warnings() []string {
+	return slices.Clone(c.warnings)
+}
+
+// Settings returns the effective value and source of every setting.
+// Values of secret settings are redacted.
+func (c *Config) Settings() []Setting {
+	fields := c.fields()
+	settings := make([]Setting, 0, len(fields))
+	for _, f := range fields {
+		setting := Setting{
+			Key:     f.key,
+			Value:   reportValue(f.value),
+			Default: f.def,
+			Source:  c.sources[f.key],
+			Env:     f.env,
+			Flag:    f.flag,
+			Secret:  f.secret,
+		}
+		if f.secret && !f.value.IsZero() {
+			setting.Value = redacted
+		}
+		// Resolved values are secret whatever the setting, the reference is reported instead
+		if ref, ok := c.references[f.key]; ok {
+			setting.Value = redacted
+			setting.Reference = ref
+		}
+		settings = append(settings, setting)
+	}
+	return settings
+}
+
+// ResolveSecrets replaces the values of string settings and the items of list settings that
+// are references to secrets with the referenced secrets, e.g. a value vault://secret/data/armada#password
+// with the password stored in Vault. The settings of the secrets section are not resolved.
+// If a reference can't be resolved, an error naming the setting is returned.
+func (c *Config) ResolveSecrets(ctx context.Context, r SecretResolver) error {
+	for _, f := range c.fields() {
+		if strings.HasPrefix(f.key, "secrets.") {
+			continue
+		}
+		switch {
+		case f.value.Kind() == reflect.String:
+			raw := f.value.String()
+			if !r.IsReference(raw) {
+				continue
+			}
+			value, err := r.Resolve(ctx, raw)
+			if err != nil {
+				return fmt.Errorf("%s: %w", f.key, err)
+			}
+			f.value.SetString(value)
+			c.setReference(f.key, raw)
+		case f.value.Kind() == reflect.Slice && f.value.Type().Elem().Kind() == reflect.String:
+			items := f.value.Interface().([]string)
+			if !slices.ContainsFunc(items, r.IsReference) {
+				continue
+			}
+			resolved := make([]string, len(items))
+			var refs []string
+			for i, item := range items {
+				resolved[i] = item
+				if !r.IsReference(item) {
+					continue
+				}
+				value, err := r.Resolve(ctx, item)
+				if err != nil {
+					return fmt.Errorf("%s: %w", f.key, err)
+				}
+				resolved[i] = value
+				refs = append(refs, item)
+			}
+			f.value.Set(reflect.ValueOf(resolved))
+			// The other items may be secret, only the references are reported
+			c.setReference(f.key, strings.Join(refs, ","))
+		}
+	}
+	return nil
+}
+
+// setReference records the reference the value of the setting with the key was resolved from
+func (c *Config) setReference(key, ref string) {
+	if c.references == nil {
+		c.references = make(map[string]string)
+	}
+	c.references[key] = ref
+}
+
+// With returns a copy of c with the values of the settings with the given keys, and their sources,
+// taken from other, e.g. the settings of a reloaded configuration that took effect
+func (c *Config) With(other *Config, keys []string) *Config {
+	next := *c
+	next.sources = make(map[string]Source, len(c.sources))
+	maps.Copy(next.sources, c.sources)
+	next.references = maps.Clone(c.references)
+	theirs := other.fields()
+	for i, f := range next.fields() {
+		if !slices.Contains(keys, f.key) {
+			continue
+		}
+		f.value.Set(theirs[i].value)
+		next.sources[f.key] = other.sources[f.key]
+		delete(next.references, f.key)
+		if ref, ok := other.references[f.key]; ok {
+			next.setReference(f.key, ref)
+		}
+	}
+	return &next
+}
+
+// Changed returns the keys of the settings whose values differ between c and other,
+// e.g. to find out what a reloaded configuration changes. Sources are not compared.
+func (c *Config) Changed(other *Config) []string {
+	theirs := other.fields()
+	var keys []string
+	for i, f := range c.fields() {
+		if !reflect.DeepEqual(f.value.Interface(), theirs[i].value.Interface()) {
+			keys = append(keys, f.key)
+		}
+	}
+	return keys
+}
+
+// fields returns all leaf settings of the configuration
+func (c *Config) fields() []field {
+	var fields []field
+	collectFields(reflect.ValueOf(c).Elem(), "", &fields)
+	return fields
+}
+
+// collectFields walks a struct value and appends its tagged leaf fields
+func collectFields(v reflect.Value, prefix string, fields *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, ok := sf.Tag.Lookup("config")
+		if !ok {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Duration(0)) {
+			collectFields(fv, key, fields)
+			continue
+		}
+
+		var deprecated []string
+		if d := sf.Tag.Get("deprecated"); d != "" {
+			deprecated = strings.Split(d, ",")
+		}
+		*fields = append(*fields, field{
+			key:        key,
+			env:        sf.Tag.Get("env"),
+			flag:       sf.Tag.Get("flag"),
+			deprecated: deprecated,
+			def:        sf.Tag.Get("default"),
+			secret:     sf.Tag.Get("secret") == "true",
+			value:      fv,
+		})
+	}
+}
+
+// setValue parses raw and stores it in the field value
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		if raw == "" {
+			v.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		if raw == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// reportValue converts a field value into a JSON friendly representation
+func reportValue(v reflect.Value) any {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return v.Interface()
+}
+
+// readFile reads a YAML configuration file and flattens it into dotted keys
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", doc, values)
+	return values, nil
+}
+
+// flatten converts nested YAML mappings into dotted keys.
+// Sequences are joined with commas so they parse like environment variables.
+func flatten(prefix string, node map[string]any, values map[string]string) {
+	for name, value := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			flatten(key, v, values)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
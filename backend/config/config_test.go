@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envMap returns a lookup function backed by a map
+func envMap(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+// writeFile writes a configuration file into a temporary directory
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "console.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load("", envMap(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, "http://localhost:5001", cfg.Armada.URL)
+	assert.Equal(t, time.Minute, cfg.Discovery.Interval)
+	assert.Equal(t, 30*time.Second, cfg.Metrics.ScrapeInterval)
+	assert.Equal(t, SourceDefault, cfg.Source("server.port"))
+	assert.Empty(t, cfg.Warnings())
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, `
+server:
+  port: 9090
+armada:
+  url: http://file:5001
+metrics:
+  scrapeInterval: 1m
+`)
+	cfg, err := Load(path, envMap(map[string]string{
+		"ARMADA_URL": "http://env:5001",
+		"PORT":       "",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "9090", cfg.Server.Port, "empty environment variables are ignored")
+	assert.Equal(t, SourceFile, cfg.Source("server.port"))
+	assert.Equal(t, "http://env:5001", cfg.Armada.URL, "environment overrides the file")
+	assert.Equal(t, SourceEnv, cfg.Source("armada.url"))
+	assert.Equal(t, time.Minute, cfg.Metrics.ScrapeInterval)
+	assert.Equal(t, path, cfg.File())
+}
+
+func TestNamedClusters(t *testing.T) {
+	path := writeFile(t, `
+armada:
+  clusters:
+    - staging=http://staging:5001
+    - prod-eu = prod-eu:5001
+  tlsCaFile: /etc/armada/ca.pem
+  clusterTlsCaFiles:
+    - staging=/etc/armada/staging-ca
+  clusterTlsInsecureSkipVerify:
+    - staging
+`)
+	cfg, err := Load(path, envMap(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, []NamedCluster{
+		{Name: "staging", URL: "http://staging:5001", TLSCAFile: "/etc/armada/staging-ca", TLSInsecureSkipVerify: true},
+		{Name: "prod-eu", URL: "prod-eu:5001", TLSCAFile: "/etc/armada/ca.pem"},
+	}, cfg.Armada.NamedClusters())
+}
+
+func TestOutboundHTTPHeaders(t *testing.T) {
+	cfg, err := Load("", envMap(map[string]string{"OUTBOUND_HEADERS": "X-Team: ops, X-Route: a:b"}))
+	require.NoError(t, err)
+
+	headers := cfg.Outbound.HTTPHeaders()
+	assert.Equal(t, "ops", headers.Get("X-Team"))
+	assert.Equal(t, "a:b", headers.Get("X-Route"), "values may contain colons")
+}
+
+func TestLoadWarnsAboutUnknownKeys(t *testing.T) {
+	path := writeFile(t, `
+server:
+  prot: 9090
+`)
+	cfg, err := Load(path, envMap(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{path + `: unknown setting "server.prot" is ignored`}, cfg.Warnings())
+}
+
+func TestLoadErrors(t *testing.T) {
+	_, err := Load("", envMap(map[string]string{"SCRAPE_INTERVAL": "often"}))
+	assert.ErrorContains(t, err, "SCRAPE_INTERVAL")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"), envMap(nil))
+	assert.Error(t, err)
+
+	_, err = Load(writeFile(t, "server: ["), envMap(nil))
+	assert.Error(t, err)
+}
+
+func TestSettingsRedactsSecrets(t *testing.T) {
+	cfg, err := Load("", envMap(map[string]string{"CONSUL_HTTP_TOKEN": "s3cr3t"}))
+	require.NoError(t, err)
+
+	found := false
+	for _, setting := range cfg.Settings() {
+		if setting.Key == "discovery.consul.token" {
+			found = true
+			assert.Equal(t, redacted, setting.Value)
+			assert.Equal(t, SourceEnv, setting.Source)
+			assert.True(t, setting.Secret)
+		}
+		if setting.Key == "metrics.scrapeInterval" {
+			assert.Equal(t, "30s", setting.Value)
+		}
+	}
+	assert.True(t, found, "secret setting should be reported")
+}
+
+// staticSecrets resolves test:// references from a map
+type staticSecrets map[string]string
+
+func (s staticSecrets) IsReference(value string) bool {
+	return strings.HasPrefix(value, "test://")
+}
+
+func (s staticSecrets) Resolve(_ context.Context, value string) (string, error) {
+	secret, ok := s[value]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return secret, nil
+}
+
+func TestResolveSecrets(t *testing.T) {
+	cfg, err := Load("", envMap(map[string]string{
+		"ARMADA_URL":        "test://armada-url",
+		"CONSUL_HTTP_TOKEN": "test://consul-token",
+		"OUTBOUND_HEADERS":  "X-Team: ops, test://gateway-header",
+		"VAULT_TOKEN":       "test://vault-token",
+	}))
+	require.NoError(t, err)
+	secrets := staticSecrets{
+		"test://armada-url":     "http://armada:5001",
+		"test://consul-token":   "s3cr3t",
+		"test://gateway-header": "Authorization: Bearer abc",
+		"test://vault-token":    "unused",
+	}
+	require.NoError(t, cfg.ResolveSecrets(context.Background(), secrets))
+
+	assert.Equal(t, "http://armada:5001", cfg.Armada.URL)
+	assert.Equal(t, "s3cr3t", cfg.Discovery.Consul.Token)
+	assert.Equal(t, []string{"X-Team: ops", "Authorization: Bearer abc"}, cfg.Outbound.Headers)
+	assert.Equal(t, "test://vault-token", cfg.Secrets.VaultToken, "the settings of the stores are not resolved")
+
+	// Resolved values are redacted even if the setting isn't secret, the references are reported
+	settings := make(map[string]Setting)
+	for _, setting := range cfg.Settings() {
+		settings[setting.Key] = setting
+	}
+	assert.Equal(t, redacted, settings["armada.url"].Value)
+	assert.Equal(t, "test://armada-url", settings["armada.url"].Reference)
+	assert.Equal(t, "test://gateway-header", settings["outbound.headers"].Reference)
+	assert.Empty(t, settings["metrics.scrapeInterval"].Reference)
+
+	cfg, err = Load("", envMap(map[string]string{"AUTH_PASSWORD_HASH": "test://missing"}))
+	require.NoError(t, err)
+	assert.ErrorContains(t, cfg.ResolveSecrets(context.Background(), secrets), "auth.passwordHash")
+}
+
+func TestWith(t *testing.T) {
+	cfg, err := Load("", envMap(nil))
+	require.NoError(t, err)
+	other, err := Load("", envMap(map[string]string{"LOG_LEVEL": "warn", "METRICS_RETENTION": "48h"}))
+	require.NoError(t, err)
+
+	next := cfg.With(other, []string{"log.level"})
+	assert.Equal(t, "warn", next.Log.Level)
+	assert.Equal(t, SourceEnv, next.Source("log.level"))
+	assert.Equal(t, cfg.Metrics.Retention, next.Metrics.Retention)
+	assert.Equal(t, []string{"log.level"}, cfg.Changed(next))
+	assert.NotEqual(t, "warn", cfg.Log.Level, "the configuration is copied")
+	assert.Equal(t, SourceDefault, cfg.Source("log.level"))
+}
+
+func TestChanged(t *testing.T) {
+	cfg, err := Load("", envMap(nil))
+	require.NoError(t, err)
+	same, err := Load(writeFile(t, "metrics:\n  scrapeInterval: 30s\n"), envMap(nil))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Changed(same))
+
+	other, err := Load("", envMap(map[string]string{
+		"LOG_LEVEL":               "info",
+		"SCRAPE_INTERVAL":         "15s",
+		"AUTH_OIDC_CLIENT_SECRET": "s3cret",
+		"BRANDING_COLORS":         "primary=#0055aa",
+		"METRICS_RETENTION":       "24h",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metrics.scrapeInterval", "log.level", "auth.oidcClientSecret", "branding.colors"}, cfg.Changed(other))
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+)
+
+// Flags holds the settings overridden on the command line.
+// Only settings with a flag tag can be set this way.
+type Flags struct {
+	// values holds the raw flag values by setting key
+	values map[string]string
+}
+
+// RegisterFlags defines a flag on fs for every setting with a flag tag.
+// Flag values are checked when the command line is parsed and take
+// precedence over the environment and the configuration file once applied.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{values: make(map[string]string)}
+	for _, f := range (&Config{}).fields() {
+		if f.flag == "" {
+			continue
+		}
+		key, typ := f.key, f.value.Type()
+		usage := fmt.Sprintf("sets %s, overrides $%s", key, f.env)
+		if f.def != "" {
+			usage += fmt.Sprintf(" (default %q)", f.def)
+		}
+		set := func(raw string) error {
+			// Parse into a scratch value so malformed values are rejected by the flag parser
+			if err := setValue(reflect.New(typ).Elem(), raw); err != nil {
+				return err
+			}
+			flags.values[key] = raw
+			return nil
+		}
+		// Switches can be given without a value, e.g. --headless
+		if typ.Kind() == reflect.Bool {
+			fs.BoolFunc(f.flag, usage, set)
+		} else {
+			fs.Func(f.flag, usage, set)
+		}
+	}
+	return flags
+}
+
+// Apply overrides the settings of cfg with the flags given on the command line
+func (f *Flags) Apply(cfg *Config) error {
+	for _, field := range cfg.fields() {
+		raw, ok := f.values[field.key]
+		if !ok {
+			continue
+		}
+		if err := setValue(field.value, raw); err != nil {
+			return fmt.Errorf("flag --%s: %w", field.flag, err)
+		}
+		cfg.sources[field.key] = SourceFlag
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseFlags registers the configuration flags on a fresh flag set and parses args
+func parseFlags(t *testing.T, args ...string) (*Flags, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("console", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flags := RegisterFlags(fs)
+	return flags, fs.Parse(args)
+}
+
+func TestFlagsOverrideEnvironment(t *testing.T) {
+	flags, err := parseFlags(t, "--port", "9443", "--scrape-interval=15s", "--log-level", "warn")
+	require.NoError(t, err)
+
+	cfg, err := Load("", envMap(map[string]string{"PORT": "9090", "ARMADA_URL": "http://env:5001"}))
+	require.NoError(t, err)
+	require.NoError(t, flags.Apply(cfg))
+
+	assert.Equal(t, "9443", cfg.Server.Port)
+	assert.Equal(t, SourceFlag, cfg.Source("server.port"))
+	assert.Equal(t, 15*time.Second, cfg.Metrics.ScrapeInterval)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, "http://env:5001", cfg.Armada.URL, "settings without a flag keep their value")
+	assert.Equal(t, SourceEnv, cfg.Source("armada.url"))
+}
+
+func TestFlagsRejectMalformedValues(t *testing.T) {
+	_, err := parseFlags(t, "--scrape-interval", "often")
+	assert.Error(t, err)
+}
+
+func TestBoolFlagsWithoutValue(t *testing.T) {
+	flags, err := parseFlags(t, "--headless")
+	require.NoError(t, err)
+
+	cfg, err := Load("", envMap(nil))
+	require.NoError(t, err)
+	require.NoError(t, flags.Apply(cfg))
+	assert.True(t, cfg.Server.Headless)
+
+	flags, err = parseFlags(t, "--headless=false")
+	require.NoError(t, err)
+	cfg, err = Load("", envMap(map[string]string{"HEADLESS": "true"}))
+	require.NoError(t, err)
+	require.NoError(t, flags.Apply(cfg))
+	assert.False(t, cfg.Server.Headless)
+}
+
+func TestFlagOriginInValidationErrors(t *testing.T) {
+	flags, err := parseFlags(t, "--log-level", "verbose")
+	require.NoError(t, err)
+
+	cfg, err := Load("", envMap(nil))
+	require.NoError(t, err)
+	require.NoError(t, flags.Apply(cfg))
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "log.level")
+	assert.Contains(t, err.Error(), "set via flag --log-level")
+}
@@ -0,0 +1,865 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// logLevels are the supported values of log.level
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// tracingExporters are the supported values of tracing.exporter
+var tracingExporters = []string{"none", "otlp-grpc", "otlp-http"}
+
+// BrandingColors are the names of the palette colors that can be overridden
+var BrandingColors = []string{"primary", "success", "error", "warning", "info"}
+
+// hexColor matches colors like #0055aa
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// minEmbedSecretLength is the minimum length of the key widget URLs are signed with
+const minEmbedSecretLength = 32
+
+// minAuditSigningKeyLength is the minimum length of the key the audit log is signed with
+const minAuditSigningKeyLength = 32
+
+// minAuditMaxBytes is the smallest size limit of the audit log, smaller limits would leave
+// little more than the latest entry
+const minAuditMaxBytes = 1 << 20
+
+// minSessionIdleTimeout is the shortest idle timeout of sessions, their use is recorded once a minute
+const minSessionIdleTimeout = time.Minute
+
+// queryLookback is how far back the metrics query engine looks for the latest sample of a
+// series. Repeated values must be stored more often, or series vanish from instant queries.
+const queryLookback = 5 * time.Minute
+
+// consulDatacenter matches the names Consul accepts for datacenters
+var consulDatacenter = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// clusterName matches the names of clusters, which are passed as the cluster parameter of API requests
+var clusterName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// tagKey matches the keys of cluster tags, which are added as labels to the metrics of the cluster
+var tagKey = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedTagKeys are the labels the console sets on scraped series itself
+var reservedTagKeys = []string{"cluster", "node_id", "node_name", "instance", "job"}
+
+// guardrailAction matches the actions guardrails can require approval for, a changed resource
+// and how it is changed
+var guardrailAction = regexp.MustCompile(`^(table|kv|index|snapshot|maintenance|lock|server|shadow|cluster)\.(create|update|delete)$`)
+
+// headerName matches the names of HTTP headers
+var headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9a-zA-Z-]+$")
+
+// roles are the supported values of auth.defaultRole
+var roles = []string{"viewer", "operator"}
+
+// principalMethods are the authentication methods users can be named with, e.g. in auth.operators
+var principalMethods = []string{"basic", "oidc", "bearer"}
+
+// FieldError describes a problem with a single setting.
+type FieldError struct {
+	// Path is the key of the offending setting, e.g. "metrics.retention".
+	Path string `json:"path"`
+	// Message explains what is wrong and how to fix it.
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when the configuration violates one or more constraints.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error lists every problem on its own line, prefixed with the setting path
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		lines = append(lines, fe.Path+": "+fe.Message)
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// validator collects field errors while checking a configuration
+type validator struct {
+	cfg    *Config
+	errors []FieldError
+}
+
+// fail records a problem with a setting, mentioning where its value came from
+func (v *validator) fail(path, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if origin := v.origin(path); origin != "" {
+		message += " (" + origin + ")"
+	}
+	v.errors = append(v.errors, FieldError{Path: path, Message: message})
+}
+
+// origin describes where the value of a setting was set so the operator knows what to change
+func (v *validator) origin(path string) string {
+	switch v.cfg.sources[path] {
+	case SourceEnv:
+		for _, f := range v.cfg.fields() {
+			if f.key == path {
+				return "set via environment variable " + f.env
+			}
+		}
+	case SourceFile:
+		return "set in " + v.cfg.file
+	case SourceFlag:
+		for _, f := range v.cfg.fields() {
+			if f.key == path {
+				return "set via flag --" + f.flag
+			}
+		}
+	}
+	return ""
+}
+
+// Validate checks single-field and cross-field constraints of the configuration.
+// It returns a *ValidationError listing every problem, or nil if the configuration is valid.
+func (c *Config) Validate() error {
+	v := &validator{cfg: c}
+
+	v.validateServer(c.Server)
+	v.validateArmada(c.Armada, c.Server)
+	v.validateDiscovery(c.Discovery)
+	v.validateMetrics(c.Metrics)
+	v.validateReporting(c.Reporting)
+	v.validateMetadata(c.Metadata)
+	v.validateAudit(c.Audit)
+	v.validateLog(c.Log)
+	v.validateHotKeys(c.HotKeys)
+	v.validateGrowth(c.Growth)
+	v.validateShadow(c.Shadow, c.Armada)
+	v.validateAuth(c.Auth)
+	v.validateEmbed(c.Embed)
+	v.validateBranding(c.Branding)
+	v.validateTracing(c.Tracing)
+	v.validateAnalytics(c.Analytics)
+	v.validateDebug(c.Debug)
+	v.validateOutbound(c.Outbound)
+	v.validateSecrets(c.Secrets)
+
+	if len(v.errors) > 0 {
+		return &ValidationError{Errors: v.errors}
+	}
+	return nil
+}
+
+// validateServer checks the HTTP server settings
+func (v *validator) validateServer(s ServerConfig) {
+	if port, err := strconv.Atoi(s.Port); err != nil || port < 1 || port > 65535 {
+		v.fail("server.port", "must be a port number between 1 and 65535, got %q", s.Port)
+	}
+	if s.BasePath != "" {
+		clean := "/" + strings.Trim(s.BasePath, "/")
+		if !strings.HasPrefix(s.BasePath, "/") || path.Clean(clean) != clean || strings.ContainsAny(s.BasePath, "?#%") {
+			v.fail("server.basePath", "must be an absolute URL path such as /armada, got %q", s.BasePath)
+		}
+	}
+
+	switch {
+	case s.TLSCertFile != "" && s.TLSKeyFile == "":
+		v.fail("server.tlsKeyFile", "is required when server.tlsCertFile is set")
+	case s.TLSCertFile == "" && s.TLSKeyFile != "":
+		v.fail("server.tlsCertFile", "is required when server.tlsKeyFile is set")
+	}
+	v.checkReadable("server.tlsCertFile", s.TLSCertFile)
+	v.checkReadable("server.tlsKeyFile", s.TLSKeyFile)
+
+	switch {
+	case s.FrontendDir != "" && s.FrontendProxy != "":
+		v.fail("server.frontendProxy", "must not be set together with server.frontendDir")
+	case s.Headless && (s.FrontendDir != "" || s.FrontendProxy != ""):
+		v.fail("server.headless", "must not be set together with server.frontendDir or server.frontendProxy")
+	}
+	if s.FrontendDir != "" {
+		if info, err := os.Stat(s.FrontendDir); err != nil || !info.IsDir() {
+			v.fail("server.frontendDir", "must be a directory, got %q", s.FrontendDir)
+		} else {
+			v.checkReadable("server.frontendDir", filepath.Join(s.FrontendDir, "index.html"))
+		}
+	}
+	if s.FrontendProxy != "" {
+		v.checkURL("server.frontendProxy", s.FrontendProxy, "http", "https")
+	}
+
+	if s.MaxResponseBuffer <= 0 {
+		v.fail("server.maxResponseBuffer", "must be positive, got %d", s.MaxResponseBuffer)
+	}
+	if s.ResponseFlushThreshold <= 0 {
+		v.fail("server.responseFlushThreshold", "must be positive, got %d", s.ResponseFlushThreshold)
+	}
+
+	v.checkPositive("server.readHeaderTimeout", s.ReadHeaderTimeout)
+	v.checkPositive("server.readTimeout", s.ReadTimeout)
+	v.checkPositive("server.writeTimeout", s.WriteTimeout)
+	v.checkPositive("server.idleTimeout", s.IdleTimeout)
+	if s.ReadHeaderTimeout > 0 && s.ReadTimeout > 0 && s.ReadHeaderTimeout > s.ReadTimeout {
+		v.fail("server.readHeaderTimeout", "must not exceed server.readTimeout (%s), got %s", s.ReadTimeout, s.ReadHeaderTimeout)
+	}
+	if s.StreamTimeout < s.WriteTimeout || s.StreamTimeout < s.ReadTimeout {
+		v.fail("server.streamTimeout", "must be at least server.readTimeout and server.writeTimeout, got %s", s.StreamTimeout)
+	}
+	if s.MaxHeaderBytes < 4096 {
+		v.fail("server.maxHeaderBytes", "must be at least 4096, got %d", s.MaxHeaderBytes)
+	}
+	for _, cidr := range s.TrustedProxies {
+		if _, err := netip.ParsePrefix(strings.TrimSpace(cidr)); err != nil {
+			v.fail("server.trustedProxies", "entries must be CIDRs such as 10.0.0.0/8, got %q", cidr)
+		}
+	}
+}
+
+// validateArmada checks the Armada connection settings
+func (v *validator) validateArmada(a ArmadaConfig, s ServerConfig) {
+	if a.ClusterName == "" {
+		v.fail("armada.clusterName", "must not be empty")
+	}
+	v.checkPositive("armada.rangeTimeout", a.RangeTimeout)
+	// A partial result is only useful if it can still be written
+	if s.WriteTimeout > 0 && a.RangeTimeout >= s.WriteTimeout {
+		v.fail("armada.rangeTimeout", "must be less than server.writeTimeout, got %s", a.RangeTimeout)
+	}
+	// A timed out request should still be answered with an error
+	for _, t := range []struct {
+		path    string
+		timeout time.Duration
+	}{
+		{"armada.statusTimeout", a.StatusTimeout},
+		{"armada.tablesTimeout", a.TablesTimeout},
+		{"armada.kvTimeout", a.KVTimeout},
+	} {
+		v.checkPositive(t.path, t.timeout)
+		if s.WriteTimeout > 0 && t.timeout >= s.WriteTimeout {
+			v.fail(t.path, "must be less than server.writeTimeout, got %s", t.timeout)
+		}
+	}
+	switch {
+	case a.TLSCertFile != "" && a.TLSKeyFile == "":
+		v.fail("armada.tlsKeyFile", "is required when armada.tlsCertFile is set")
+	case a.TLSCertFile == "" && a.TLSKeyFile != "":
+		v.fail("armada.tlsCertFile", "is required when armada.tlsKeyFile is set")
+	}
+	v.checkReadable("armada.tlsCertFile", a.TLSCertFile)
+	v.checkReadable("armada.tlsKeyFile", a.TLSKeyFile)
+	// The CA may also be a directory of certificates
+	v.checkReadable("armada.tlsCaFile", a.TLSCAFile)
+	v.checkArmadaAddress("armada.url", a.URL)
+
+	names := []string{a.ClusterName}
+	for _, entry := range a.Clusters {
+		name, addr, ok := strings.Cut(entry, "=")
+		name, addr = strings.TrimSpace(name), strings.TrimSpace(addr)
+		switch {
+		case !ok:
+			v.fail("armada.clusters", "entries must be of the form name=url, got %q", entry)
+			continue
+		case !clusterName.MatchString(name):
+			v.fail("armada.clusters", "cluster names may only contain letters, digits, '.', '_' and '-', got %q", name)
+		case slices.Contains(names, name):
+			v.fail("armada.clusters", "cluster %q is configured more than once", name)
+		}
+		names = append(names, name)
+		v.checkArmadaAddress("armada.clusters", addr)
+	}
+
+	if a.Token != "" && a.TokenFile != "" {
+		v.fail("armada.tokenFile", "must not be set together with armada.token")
+	}
+	v.checkReadable("armada.tokenFile", a.TokenFile)
+	// Tokens are only sent over TLS
+	if a.Token != "" || a.TokenFile != "" {
+		addrs := []string{a.URL}
+		for _, c := range a.NamedClusters() {
+			addrs = append(addrs, c.URL)
+		}
+		for _, addr := range addrs {
+			if !strings.HasPrefix(addr, "https://") {
+				v.fail("armada.token", "requires https:// addresses, got %q", addr)
+			}
+		}
+	}
+	if a.HealthCheckInterval < 0 {
+		v.fail("armada.healthCheckInterval", "must not be negative, got %s", a.HealthCheckInterval)
+	}
+	if a.MemberDiscoveryInterval < 0 {
+		v.fail("armada.memberDiscoveryInterval", "must not be negative, got %s", a.MemberDiscoveryInterval)
+	}
+	if a.ReconnectMaxRetries < 1 {
+		v.fail("armada.reconnectMaxRetries", "must be at least 1, got %d", a.ReconnectMaxRetries)
+	}
+	if a.ReconnectBaseDelay <= 0 {
+		v.fail("armada.reconnectBaseDelay", "must be positive, got %s", a.ReconnectBaseDelay)
+	}
+	if a.ReconnectMaxDelay < a.ReconnectBaseDelay {
+		v.fail("armada.reconnectMaxDelay", "must not be less than armada.reconnectBaseDelay (%s), got %s", a.ReconnectBaseDelay, a.ReconnectMaxDelay)
+	}
+	if a.ReconnectJitter < 0 || a.ReconnectJitter > 1 {
+		v.fail("armada.reconnectJitter", "must be between 0 and 1, got %g", a.ReconnectJitter)
+	}
+	if a.RetryMaxAttempts < 1 {
+		v.fail("armada.retryMaxAttempts", "must be at least 1, got %d", a.RetryMaxAttempts)
+	}
+	if a.RetryBaseDelay <= 0 {
+		v.fail("armada.retryBaseDelay", "must be positive, got %s", a.RetryBaseDelay)
+	}
+	if a.RetryMaxDelay < a.RetryBaseDelay {
+		v.fail("armada.retryMaxDelay", "must not be less than armada.retryBaseDelay (%s), got %s", a.RetryBaseDelay, a.RetryMaxDelay)
+	}
+	if a.RetryBudget <= 0 || a.RetryBudget > 1 {
+		v.fail("armada.retryBudget", "must be greater than 0 and at most 1, got %g", a.RetryBudget)
+	}
+	if a.BreakerFailures < 0 {
+		v.fail("armada.breakerFailures", "must not be negative, got %d", a.BreakerFailures)
+	}
+	if a.BreakerFailures > 0 && a.BreakerCooldown <= 0 {
+		v.fail("armada.breakerCooldown", "must be positive, got %s", a.BreakerCooldown)
+	}
+	// gRPC raises shorter keepalive intervals to 10s
+	if a.KeepaliveTime < 0 || (a.KeepaliveTime > 0 && a.KeepaliveTime < 10*time.Second) {
+		v.fail("armada.keepaliveTime", "must be 0 or at least 10s, got %s", a.KeepaliveTime)
+	}
+	if a.KeepaliveTime > 0 && a.KeepaliveTimeout <= 0 {
+		v.fail("armada.keepaliveTimeout", "must be positive, got %s", a.KeepaliveTimeout)
+	}
+	if a.ConnectTimeout < 0 {
+		v.fail("armada.connectTimeout", "must not be negative, got %s", a.ConnectTimeout)
+	}
+	if a.SlowCallThreshold < 0 {
+		v.fail("armada.slowCallThreshold", "must not be negative, got %s", a.SlowCallThreshold)
+	}
+	if a.MaxRecvMsgSize < 0 {
+		v.fail("armada.maxRecvMsgSize", "must not be negative, got %d", a.MaxRecvMsgSize)
+	}
+	if a.MaxSendMsgSize < 0 {
+		v.fail("armada.maxSendMsgSize", "must not be negative, got %d", a.MaxSendMsgSize)
+	}
+	if a.VerifyReads && (a.VerifyReadsSampleRate <= 0 || a.VerifyReadsSampleRate > 1) {
+		v.fail("armada.verifyReadsSampleRate", "must be greater than 0 and at most 1, got %g", a.VerifyReadsSampleRate)
+	}
+
+	// The default cluster is configured by armada.tlsCaFile and armada.tlsInsecureSkipVerify
+	further := names[1:]
+	for _, entry := range a.ClusterTLSCAFiles {
+		name, path, ok := strings.Cut(entry, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		switch {
+		case !ok:
+			v.fail("armada.clusterTlsCaFiles", "entries must be of the form name=path, got %q", entry)
+		case !slices.Contains(further, name):
+			v.fail("armada.clusterTlsCaFiles", "cluster %q is not in armada.clusters", name)
+		default:
+			v.checkReadable("armada.clusterTlsCaFiles", path)
+		}
+	}
+	for _, name := range a.ClusterTLSInsecureSkipVerify {
+		if !slices.Contains(further, name) {
+			v.fail("armada.clusterTlsInsecureSkipVerify", "cluster %q is not in armada.clusters", name)
+		}
+	}
+
+	v.checkTags("armada.tags", a.Tags)
+	clusterTags := make(map[string][]string)
+	for _, entry := range a.ClusterTags {
+		name, tag, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		switch {
+		case !ok:
+			v.fail("armada.clusterTags", "entries must be of the form name:key=value, got %q", entry)
+		case !slices.Contains(further, name):
+			v.fail("armada.clusterTags", "cluster %q is not in armada.clusters", name)
+		default:
+			clusterTags[name] = append(clusterTags[name], tag)
+		}
+	}
+	for _, name := range further {
+		v.checkTags("armada.clusterTags", clusterTags[name])
+	}
+}
+
+// checkTags verifies the key=value tags of a cluster
+func (v *validator) checkTags(path string, entries []string) {
+	var keys []string
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case !ok || value == "":
+			v.fail(path, "tags must be of the form key=value, got %q", entry)
+		case !tagKey.MatchString(key):
+			v.fail(path, "tag keys may only contain letters, digits and '_' and must not start with a digit, got %q", key)
+		case strings.HasPrefix(key, "__") || slices.Contains(reservedTagKeys, key):
+			v.fail(path, "tag key %q is reserved", key)
+		case slices.Contains(keys, key):
+			v.fail(path, "tag %q is given more than once", key)
+		}
+		keys = append(keys, key)
+	}
+}
+
+// checkArmadaAddress verifies the address of an Armada server.
+// Armada addresses may also be given without a scheme, e.g. "localhost:5001".
+func (v *validator) checkArmadaAddress(path, addr string) {
+	if addr != "" && !strings.Contains(addr, "://") {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			v.fail(path, "must be a URL like http://host:port or host:port, got %q", addr)
+		}
+		return
+	}
+	v.checkURL(path, addr, "http", "https")
+}
+
+// validateDiscovery checks the seed discovery settings
+func (v *validator) validateDiscovery(d DiscoveryConfig) {
+	mechanisms := []string{"static", "dns-srv", "consul"}
+	if !slices.Contains(mechanisms, d.Mechanism) {
+		v.fail("discovery.mechanism", "must be one of %s, got %q", strings.Join(mechanisms, ", "), d.Mechanism)
+		return
+	}
+	if d.Mechanism == "static" {
+		return
+	}
+
+	if d.Interval <= 0 {
+		v.fail("discovery.interval", "must be positive when %s discovery is enabled, got %s", d.Mechanism, d.Interval)
+	}
+	if d.Scheme != "http" && d.Scheme != "https" {
+		v.fail("discovery.scheme", "must be http or https, got %q", d.Scheme)
+	}
+
+	switch d.Mechanism {
+	case "dns-srv":
+		if d.SRVRecord == "" {
+			v.fail("discovery.srvRecord", "is required when discovery.mechanism is dns-srv")
+		}
+	case "consul":
+		v.checkURL("discovery.consul.address", d.Consul.Address, "http", "https")
+		if d.Consul.Service == "" {
+			v.fail("discovery.consul.service", "is required when discovery.mechanism is consul")
+		}
+		if d.Consul.Datacenter != "" && !consulDatacenter.MatchString(d.Consul.Datacenter) {
+			v.fail("discovery.consul.datacenter", "may only contain lowercase letters, digits, '-' and '_', got %q", d.Consul.Datacenter)
+		}
+	}
+}
+
+// validateMetrics checks the metrics collection and storage settings
+func (v *validator) validateMetrics(m MetricsConfig) {
+	if m.StorageDir == "" {
+		v.fail("metrics.storageDir", "must not be empty")
+	}
+	if m.ScrapeInterval <= 0 {
+		v.fail("metrics.scrapeInterval", "must be positive, got %s", m.ScrapeInterval)
+	}
+	v.checkPositive("metrics.queryTimeout", m.QueryTimeout)
+	if m.MaxScrapeBackoff < m.ScrapeInterval {
+		v.fail("metrics.maxScrapeBackoff", "must be at least metrics.scrapeInterval (%s), got %s", m.ScrapeInterval, m.MaxScrapeBackoff)
+	}
+	v.checkPositive("metrics.tableStatsInterval", m.TableStatsInterval)
+	v.checkPositive("metrics.maxClockSkew", m.MaxClockSkew)
+	if m.MaxRefreshInterval < m.ScrapeInterval {
+		v.fail("metrics.maxRefreshInterval", "must be at least metrics.scrapeInterval (%s), got %s", m.ScrapeInterval, m.MaxRefreshInterval)
+	}
+	if m.BlockDuration <= 0 {
+		v.fail("metrics.blockDuration", "must be positive, got %s", m.BlockDuration)
+	} else if m.Retention < m.BlockDuration {
+		v.fail("metrics.retention", "must be at least metrics.blockDuration (%s), got %s", m.BlockDuration, m.Retention)
+	}
+	if m.DedupWindow < 0 || m.DedupWindow >= queryLookback {
+		v.fail("metrics.dedupWindow", "must be between 0s and the query lookback of %s, got %s", queryLookback, m.DedupWindow)
+	}
+	if m.DedupWindow > 0 && m.DedupMaxSeries <= 0 {
+		v.fail("metrics.dedupMaxSeries", "must be positive when metrics.dedupWindow is set, got %d", m.DedupMaxSeries)
+	}
+	if m.MaxSeriesPerMetric < 0 {
+		v.fail("metrics.maxSeriesPerMetric", "must not be negative, got %d", m.MaxSeriesPerMetric)
+	}
+	if m.MaxLabels < 0 {
+		v.fail("metrics.maxLabels", "must not be negative, got %d", m.MaxLabels)
+	}
+	v.checkPositive("metrics.cardinalityCooldown", m.CardinalityCooldown)
+}
+
+// validateReporting checks the panic reporting settings
+func (v *validator) validateReporting(r ReportingConfig) {
+	if r.SentryDSN == "" {
+		return
+	}
+	// The DSN contains a key, so it is never echoed back
+	u, err := url.Parse(r.SentryDSN)
+	if err != nil || u.Host == "" || u.User == nil || (u.Scheme != "http" && u.Scheme != "https") {
+		v.fail("reporting.sentryDsn", "must be a DSN like https://<key>@<host>/<project>")
+	}
+}
+
+// validateMetadata checks the metadata store settings
+func (v *validator) validateMetadata(m MetadataConfig) {
+	v.checkPositive("metadata.topologyRetention", m.TopologyRetention)
+	v.checkPositive("metadata.maintenanceRetention", m.MaintenanceRetention)
+	v.checkPositive("metadata.cleanupInterval", m.CleanupInterval)
+}
+
+// validateAudit checks the audit log settings
+func (v *validator) validateAudit(a AuditConfig) {
+	if a.SnapshotSampleKeys < 0 || a.SnapshotSampleKeys > 1000 {
+		v.fail("audit.snapshotSampleKeys", "must be between 0 and 1000, got %d", a.SnapshotSampleKeys)
+	}
+	if a.SigningKey != "" && len(a.SigningKey) < minAuditSigningKeyLength {
+		v.fail("audit.signingKey", "must be at least %d characters long", minAuditSigningKeyLength)
+	}
+	if a.Retention < 0 {
+		v.fail("audit.retention", "must not be negative, got %s", a.Retention)
+	}
+	if a.MaxBytes != 0 && a.MaxBytes < minAuditMaxBytes {
+		v.fail("audit.maxBytes", "must be 0 or at least %d, got %d", minAuditMaxBytes, a.MaxBytes)
+	}
+}
+
+// validateLog checks the log settings
+func (v *validator) validateLog(l LogConfig) {
+	if !slices.Contains(logLevels, l.Level) {
+		v.fail("log.level", "must be one of %s, got %q", strings.Join(logLevels, ", "), l.Level)
+	}
+}
+
+// validateHotKeys checks the hot key sampling settings
+func (v *validator) validateHotKeys(h HotKeysConfig) {
+	if h.SampleRate <= 0 || h.SampleRate > 1 {
+		v.fail("hotKeys.sampleRate", "must be greater than 0 and at most 1, got %g", h.SampleRate)
+	}
+	v.checkPositive("hotKeys.window", h.Window)
+}
+
+// validateGrowth checks the recording of table growth
+func (v *validator) validateGrowth(g GrowthConfig) {
+	v.checkPositive("growth.interval", g.Interval)
+	if g.MaxPrefixes < 0 {
+		v.fail("growth.maxPrefixes", "must not be negative, got %d", g.MaxPrefixes)
+	}
+	if g.SizeCap < 0 {
+		v.fail("growth.sizeCap", "must not be negative, got %d", g.SizeCap)
+	}
+}
+
+// validateShadow checks the mirroring of writes to a secondary cluster
+func (v *validator) validateShadow(s ShadowConfig, a ArmadaConfig) {
+	if s.Cluster == "" {
+		return
+	}
+	found := false
+	for _, c := range a.NamedClusters() {
+		found = found || c.Name == s.Cluster
+	}
+	if !found {
+		v.fail("shadow.cluster", "cluster %q is not in armada.clusters", s.Cluster)
+	}
+	if s.QueueSize <= 0 {
+		v.fail("shadow.queueSize", "must be positive, got %d", s.QueueSize)
+	}
+	if s.MaxFailures <= 0 {
+		v.fail("shadow.maxFailures", "must be positive, got %d", s.MaxFailures)
+	}
+}
+
+// validateAuth checks the authentication settings
+func (v *validator) validateAuth(a AuthConfig) {
+	switch {
+	case a.Username != "" && a.PasswordHash == "":
+		v.fail("auth.passwordHash", "is required when auth.username is set")
+	case a.Username == "" && a.PasswordHash != "":
+		v.fail("auth.username", "is required when auth.passwordHash is set")
+	case a.PasswordHash != "":
+		// The hash is a secret, so it is never echoed back
+		if _, err := bcrypt.Cost([]byte(a.PasswordHash)); err != nil {
+			v.fail("auth.passwordHash", "must be a bcrypt hash, create one with `console hash-password`")
+		}
+	}
+	if a.LoginMaxFailures < 0 {
+		v.fail("auth.loginMaxFailures", "must not be negative, got %d", a.LoginMaxFailures)
+	}
+	if a.LoginMaxFailuresPerAddress < 0 {
+		v.fail("auth.loginMaxFailuresPerAddress", "must not be negative, got %d", a.LoginMaxFailuresPerAddress)
+	}
+	v.checkPositive("auth.loginLockout", a.LoginLockout)
+	if a.LoginMaxLockout < a.LoginLockout {
+		v.fail("auth.loginMaxLockout", "must be at least auth.loginLockout (%s), got %s", a.LoginLockout, a.LoginMaxLockout)
+	}
+	// The configured user is the first operator, who creates the other users
+	if a.LocalUsers && a.Username == "" {
+		v.fail("auth.localUsers", "requires auth.username")
+	}
+	// Keys are created by operators who logged in otherwise
+	if a.APIKeys && a.Username == "" && a.OIDCIssuer == "" {
+		v.fail("auth.apiKeys", "requires auth.username or auth.oidcIssuer")
+	}
+	if a.OIDCIssuer != "" {
+		v.checkURL("auth.oidcIssuer", a.OIDCIssuer, "https", "http")
+		if a.OIDCClientID == "" {
+			v.fail("auth.oidcClientId", "is required when auth.oidcIssuer is set")
+		}
+		v.checkURL("auth.oidcRedirectUrl", a.OIDCRedirectURL, "https", "http")
+	}
+	// Sessions are started by OIDC logins and by users of basic authentication
+	if a.OIDCIssuer != "" || a.Username != "" {
+		v.checkPositive("auth.sessionTTL", a.SessionTTL)
+		if a.SessionIdleTimeout < 0 || (a.SessionIdleTimeout > 0 && a.SessionIdleTimeout < minSessionIdleTimeout) {
+			v.fail("auth.sessionIdleTimeout", "must be 0 or at least %s, got %s", minSessionIdleTimeout, a.SessionIdleTimeout)
+		}
+		switch a.SessionSameSite {
+		case "lax":
+		case "strict":
+			if a.OIDCIssuer != "" {
+				v.fail("auth.sessionSameSite", "must be lax with auth.oidcIssuer, the redirect back from the provider doesn't carry strict cookies")
+			}
+		default:
+			v.fail("auth.sessionSameSite", "must be lax or strict, got %q", a.SessionSameSite)
+		}
+		if a.MaxSessions < 0 {
+			v.fail("auth.maxSessions", "must not be negative, got %d", a.MaxSessions)
+		}
+	}
+	if a.JWTIssuer != "" || a.JWTJWKSURL != "" {
+		if a.JWTIssuer == "" {
+			v.fail("auth.jwtIssuer", "is required when auth.jwtJwksUrl is set")
+		}
+		v.checkURL("auth.jwtJwksUrl", a.JWTJWKSURL, "https", "http")
+		if a.JWTUserClaim == "" {
+			v.fail("auth.jwtUserClaim", "must not be empty")
+		}
+		v.checkPositive("auth.jwksRefresh", a.JWKSRefresh)
+	}
+	if !slices.Contains(roles, a.DefaultRole) {
+		v.fail("auth.defaultRole", "must be one of %s, got %q", strings.Join(roles, ", "), a.DefaultRole)
+	}
+	v.checkPrincipals("auth.operators", a.Operators)
+	v.checkPrincipals("auth.guardrailAdmins", a.GuardrailAdmins)
+	v.checkPrincipals("auth.clusterImportAdmins", a.ClusterImportAdmins)
+	v.checkGuardrails(a)
+}
+
+// checkPrincipals verifies a comma-separated list of users named as method:name. Names alone
+// are refused, local users and API keys could be created with them.
+func (v *validator) checkPrincipals(path, list string) {
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		method, name, ok := strings.Cut(entry, ":")
+		if !ok || name == "" || !slices.Contains(principalMethods, method) {
+			v.fail(path, "entries must be of the form method:name with a method of %s, e.g. oidc:%s, got %q",
+				strings.Join(principalMethods, ", "), strings.TrimPrefix(entry, method+":"), entry)
+		}
+	}
+}
+
+// checkGuardrails verifies the tag:rule entries of the guardrails. Actions requiring approval
+// can only be approved by guardrail admins, so there must be some.
+func (v *validator) checkGuardrails(a AuthConfig) {
+	var approvals bool
+	for _, entry := range a.Guardrails {
+		tag, rule, ok := strings.Cut(entry, ":")
+		key, value, tagged := strings.Cut(tag, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		rule = strings.TrimSpace(rule)
+		action, approve := strings.CutPrefix(rule, "approve=")
+		switch {
+		case !ok || !tagged || value == "":
+			v.fail("auth.guardrails", "entries must be of the form key=value:rule, got %q", entry)
+		case !tagKey.MatchString(key):
+			v.fail("auth.guardrails", "tag keys may only contain letters, digits and '_' and must not start with a digit, got %q", key)
+		case approve && !guardrailAction.MatchString(strings.TrimSpace(action)):
+			v.fail("auth.guardrails", "approvals must name an action like table.delete, got %q", action)
+		case approve:
+			approvals = true
+		case rule != "read-only":
+			v.fail("auth.guardrails", "rules must be read-only or approve=<action>, got %q", rule)
+		}
+	}
+	if approvals {
+		if strings.Trim(a.GuardrailAdmins, ", ") == "" && strings.Trim(a.GuardrailAdminGroups, ", ") == "" {
+			v.fail("auth.guardrailAdmins", "is required when auth.guardrails require approvals, nobody could approve them")
+		}
+		v.checkPositive("auth.approvalTTL", a.ApprovalTTL)
+	}
+}
+
+// validateEmbed checks the widget embedding settings
+func (v *validator) validateEmbed(e EmbedConfig) {
+	if e.Secret == "" {
+		return
+	}
+	if len(e.Secret) < minEmbedSecretLength {
+		v.fail("embed.secret", "must be at least %d characters long", minEmbedSecretLength)
+	}
+	v.checkPositive("embed.defaultTTL", e.DefaultTTL)
+	v.checkPositive("embed.maxTTL", e.MaxTTL)
+	if e.DefaultTTL > e.MaxTTL {
+		v.fail("embed.defaultTTL", "must not exceed embed.maxTTL (%s), got %s", e.MaxTTL, e.DefaultTTL)
+	}
+	if strings.TrimSpace(e.FrameAncestors) == "" {
+		v.fail("embed.frameAncestors", "must not be empty")
+	}
+}
+
+// validateBranding checks the branding settings
+func (v *validator) validateBranding(b BrandingConfig) {
+	if strings.TrimSpace(b.Title) == "" {
+		v.fail("branding.title", "must not be empty")
+	}
+	v.checkReadable("branding.logoFile", b.LogoFile)
+	for _, color := range b.Colors {
+		name, value, ok := strings.Cut(color, "=")
+		if !ok || !slices.Contains(BrandingColors, strings.TrimSpace(name)) {
+			v.fail("branding.colors", "must be name=#rrggbb with a name of %s, got %q", strings.Join(BrandingColors, ", "), color)
+			continue
+		}
+		if !hexColor.MatchString(strings.TrimSpace(value)) {
+			v.fail("branding.colors", "%s must be a color like #0055aa, got %q", strings.TrimSpace(name), value)
+		}
+	}
+	for _, link := range b.FooterLinks {
+		label, target, ok := strings.Cut(link, "=")
+		if !ok || strings.TrimSpace(label) == "" {
+			v.fail("branding.footerLinks", "must be label=URL, got %q", link)
+			continue
+		}
+		v.checkURL("branding.footerLinks", strings.TrimSpace(target), "https", "http")
+	}
+}
+
+// validateTracing checks the tracing settings
+func (v *validator) validateTracing(t TracingConfig) {
+	if !slices.Contains(tracingExporters, t.Exporter) {
+		v.fail("tracing.exporter", "must be one of %s, got %q", strings.Join(tracingExporters, ", "), t.Exporter)
+		return
+	}
+	if t.Exporter == "none" {
+		return
+	}
+	if t.Endpoint != "" {
+		if _, _, err := net.SplitHostPort(t.Endpoint); err != nil || strings.Contains(t.Endpoint, "/") {
+			v.fail("tracing.endpoint", "must be host:port, got %q", t.Endpoint)
+		}
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		v.fail("tracing.sampleRatio", "must be between 0 and 1, got %g", t.SampleRatio)
+	}
+	if t.ServiceName == "" {
+		v.fail("tracing.serviceName", "must not be empty")
+	}
+}
+
+// validateAnalytics checks the usage analytics settings
+func (v *validator) validateAnalytics(a AnalyticsConfig) {
+	if a.Enabled && a.Retention < 24*time.Hour {
+		v.fail("analytics.retention", "must be at least 24h, counts are kept per day, got %s", a.Retention)
+	}
+}
+
+// validateDebug checks the request replay settings
+func (v *validator) validateDebug(d DebugConfig) {
+	if d.RecordRequests && (d.RecordLimit < 1 || d.RecordLimit > 10000) {
+		v.fail("debug.recordLimit", "must be between 1 and 10000, got %d", d.RecordLimit)
+	}
+}
+
+// validateOutbound checks the settings of outbound HTTP requests
+func (v *validator) validateOutbound(o OutboundConfig) {
+	for _, entry := range o.Headers {
+		name, _, ok := strings.Cut(entry, ":")
+		if !ok || !headerName.MatchString(strings.TrimSpace(name)) {
+			// The entry may hold a credential, so only the name is reported
+			v.fail("outbound.headers", "entries must be of the form \"Name: value\", got one named %q", strings.TrimSpace(name))
+		}
+	}
+	if o.Proxy != "" {
+		u, err := url.Parse(o.Proxy)
+		switch {
+		case err != nil || u.Host == "":
+			v.fail("outbound.proxy", "must be a URL like http://proxy:3128")
+		case !slices.Contains([]string{"http", "https", "socks5"}, u.Scheme):
+			v.fail("outbound.proxy", "must use one of the schemes http, https, socks5, got %q", u.Scheme)
+		}
+	}
+	v.checkPositive("outbound.timeout", o.Timeout)
+}
+
+// validateSecrets checks the settings of the secret stores
+func (v *validator) validateSecrets(s SecretsConfig) {
+	if s.CacheTTL < 0 {
+		v.fail("secrets.cacheTtl", "must not be negative, got %s", s.CacheTTL)
+	}
+	if s.RefreshInterval < 0 {
+		v.fail("secrets.refreshInterval", "must not be negative, got %s", s.RefreshInterval)
+	}
+	if s.VaultAddress != "" {
+		v.checkURL("secrets.vaultAddress", s.VaultAddress, "http", "https")
+	}
+	if s.VaultToken != "" && s.VaultTokenFile != "" {
+		v.fail("secrets.vaultTokenFile", "must not be set together with secrets.vaultToken")
+	}
+	v.checkReadable("secrets.vaultTokenFile", s.VaultTokenFile)
+	for _, prefix := range s.ImportTokens {
+		scheme, path, ok := strings.Cut(prefix, "://")
+		switch {
+		case !ok || scheme == "" || path == "":
+			v.fail("secrets.importTokens", "entries must be references like vault://secret/data/armada/, got %q", prefix)
+		case scheme == "file" || scheme == "env":
+			v.fail("secrets.importTokens", "imported clusters must not read files or environment variables of the console, got %q", prefix)
+		case strings.Contains(prefix, ".."):
+			v.fail("secrets.importTokens", "entries must not contain '..', got %q", prefix)
+		}
+	}
+}
+
+// checkPositive verifies that a duration setting is greater than zero
+func (v *validator) checkPositive(path string, d time.Duration) {
+	if d <= 0 {
+		v.fail(path, "must be positive, got %s", d)
+	}
+}
+
+// checkURL verifies that a setting holds an absolute URL with one of the allowed schemes
+func (v *validator) checkURL(path, raw string, schemes ...string) {
+	if raw == "" {
+		v.fail(path, "must not be empty")
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		v.fail(path, "must be a URL like %s://host:port, got %q", schemes[0], raw)
+		return
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		v.fail(path, "must use one of the schemes %s, got %q", strings.Join(schemes, ", "), u.Scheme)
+	}
+}
+
+// checkReadable verifies that a configured file exists and can be read
+func (v *validator) checkReadable(path, file string) {
+	if file == "" {
+		return
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		v.fail(path, "cannot read file %q: %v", file, err)
+		return
+	}
+	_ = f.Close()
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validationPaths loads a configuration from env and returns the paths of all validation errors
+func validationPaths(t *testing.T, env map[string]string) []string {
+	t.Helper()
+	cfg, err := Load("", envMap(env))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	if err == nil {
+		return nil
+	}
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr), "Validate should return a *ValidationError")
+	paths := make([]string, 0, len(verr.Errors))
+	for _, fe := range verr.Errors {
+		paths = append(paths, fe.Path)
+	}
+	return paths
+}
+
+func TestValidateDefaults(t *testing.T) {
+	assert.Empty(t, validationPaths(t, nil))
+}
+
+func TestValidate(t *testing.T) {
+	certFile := writeFile(t, "cert")
+	frontendDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(frontendDir, "index.html"), []byte("<html></html>"), 0o600))
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "BadPort", env: map[string]string{"PORT": "http"}, want: []string{"server.port"}},
+		{name: "PortOutOfRange", env: map[string]string{"PORT": "70000"}, want: []string{"server.port"}},
+		{name: "ArmadaHostPort", env: map[string]string{"ARMADA_URL": "localhost:5001"}},
+		{name: "ArmadaBadScheme", env: map[string]string{"ARMADA_URL": "ftp://armada:5001"}, want: []string{"armada.url"}},
+		{name: "Clusters", env: map[string]string{"ARMADA_CLUSTERS": "staging=http://staging:5001, prod-eu=prod-eu:5001"}},
+		{name: "ClustersWithoutName", env: map[string]string{"ARMADA_CLUSTERS": "http://staging:5001"}, want: []string{"armada.clusters"}},
+		{name: "ClustersInvalidName", env: map[string]string{"ARMADA_CLUSTERS": "prod eu=http://prod:5001"}, want: []string{"armada.clusters"}},
+		{name: "ClustersDuplicate", env: map[string]string{"ARMADA_CLUSTERS": "default=http://staging:5001"}, want: []string{"armada.clusters"}},
+		{name: "ClustersBadURL", env: map[string]string{"ARMADA_CLUSTERS": "staging=ftp://staging:5001"}, want: []string{"armada.clusters"}},
+		{name: "OutboundHeaders", env: map[string]string{"OUTBOUND_HEADERS": "X-Team: ops, Authorization: Bearer abc"}},
+		{name: "OutboundHeaderWithoutValue", env: map[string]string{"OUTBOUND_HEADERS": "X-Team"}, want: []string{"outbound.headers"}},
+		{name: "OutboundHeaderInvalidName", env: map[string]string{"OUTBOUND_HEADERS": "X Team: ops"}, want: []string{"outbound.headers"}},
+		{name: "OutboundProxy", env: map[string]string{"OUTBOUND_PROXY": "http://proxy:3128"}},
+		{name: "OutboundProxyBadScheme", env: map[string]string{"OUTBOUND_PROXY": "ftp://proxy:21"}, want: []string{"outbound.proxy"}},
+		{name: "OutboundTimeout", env: map[string]string{"OUTBOUND_TIMEOUT": "0s"}, want: []string{"outbound.timeout"}},
+		{name: "BasePath", env: map[string]string{"BASE_PATH": "/armada/"}},
+		{name: "BasePathRelative", env: map[string]string{"BASE_PATH": "armada"}, want: []string{"server.basePath"}},
+		{name: "BasePathDotSegments", env: map[string]string{"BASE_PATH": "/ops/../armada"}, want: []string{"server.basePath"}},
+		{name: "TLSCertWithoutKey", env: map[string]string{"TLS_CERT_FILE": certFile}, want: []string{"server.tlsKeyFile"}},
+		{name: "TLSKeyWithoutCert", env: map[string]string{"TLS_KEY_FILE": certFile}, want: []string{"server.tlsCertFile"}},
+		{
+			name: "TLSMissingFiles",
+			env: map[string]string{
+				"TLS_CERT_FILE": filepath.Join(t.TempDir(), "missing.crt"),
+				"TLS_KEY_FILE":  certFile,
+			},
+			want: []string{"server.tlsCertFile"},
+		},
+		{name: "FrontendDir", env: map[string]string{"FRONTEND_DIR": frontendDir}},
+		{name: "FrontendDirWithoutIndex", env: map[string]string{"FRONTEND_DIR": t.TempDir()}, want: []string{"server.frontendDir"}},
+		{name: "FrontendDirIsFile", env: map[string]string{"FRONTEND_DIR": certFile}, want: []string{"server.frontendDir"}},
+		{name: "FrontendProxy", env: map[string]string{"FRONTEND_PROXY": "http://localhost:3000"}},
+		{name: "TrustedProxyNotCIDR", env: map[string]string{"SERVER_TRUSTED_PROXIES": "10.0.0.0/8,10.0.0.1"}, want: []string{"server.trustedProxies"}},
+		{name: "FrontendProxyBadScheme", env: map[string]string{"FRONTEND_PROXY": "localhost:3000"}, want: []string{"server.frontendProxy"}},
+		{name: "FrontendDirAndProxy", env: map[string]string{"FRONTEND_DIR": frontendDir, "FRONTEND_PROXY": "http://localhost:3000"}, want: []string{"server.frontendProxy"}},
+		{name: "HeadlessWithFrontendDir", env: map[string]string{"HEADLESS": "true", "FRONTEND_DIR": frontendDir}, want: []string{"server.headless"}},
+		{name: "AuditSigningKey", env: map[string]string{"AUDIT_SIGNING_KEY": "0123456789abcdef0123456789abcdef"}},
+		{name: "AuditSigningKeyTooShort", env: map[string]string{"AUDIT_SIGNING_KEY": "short"}, want: []string{"audit.signingKey"}},
+		{name: "Vault", env: map[string]string{"VAULT_ADDR": "https://vault:8200", "VAULT_TOKEN": "s.token"}},
+		{name: "VaultBadAddress", env: map[string]string{"VAULT_ADDR": "vault:8200"}, want: []string{"secrets.vaultAddress"}},
+		{name: "VaultTokenAndFile", env: map[string]string{"VAULT_TOKEN": "s.token", "VAULT_TOKEN_FILE": certFile}, want: []string{"secrets.vaultTokenFile"}},
+		{name: "SecretsNegativeRefresh", env: map[string]string{"SECRETS_REFRESH_INTERVAL": "-1m"}, want: []string{"secrets.refreshInterval"}},
+		{name: "ImportTokens", env: map[string]string{"SECRETS_IMPORT_TOKENS": "vault://secret/data/armada/,aws-sm://prod/armada/"}},
+		{name: "ImportTokensFile", env: map[string]string{"SECRETS_IMPORT_TOKENS": "file:///run/secrets/"}, want: []string{"secrets.importTokens"}},
+		{name: "ImportTokensEnv", env: map[string]string{"SECRETS_IMPORT_TOKENS": "env://"}, want: []string{"secrets.importTokens"}},
+		{name: "ImportTokensParent", env: map[string]string{"SECRETS_IMPORT_TOKENS": "vault://secret/data/armada/../"}, want: []string{"secrets.importTokens"}},
+		{name: "NegativeWriteTimeout", env: map[string]string{"SERVER_WRITE_TIMEOUT": "-1s"}, want: []string{"server.writeTimeout"}},
+		{name: "HeaderTimeoutAboveRead", env: map[string]string{"SERVER_READ_HEADER_TIMEOUT": "1m"}, want: []string{"server.readHeaderTimeout"}},
+		{name: "StreamTimeoutTooShort", env: map[string]string{"SERVER_STREAM_TIMEOUT": "10s"}, want: []string{"server.streamTimeout"}},
+		{name: "TinyHeaderLimit", env: map[string]string{"SERVER_MAX_HEADER_BYTES": "100"}, want: []string{"server.maxHeaderBytes"}},
+		{name: "SentryDSN", env: map[string]string{"SENTRY_DSN": "https://key@sentry.example.com/1"}},
+		{name: "SentryDSNWithoutKey", env: map[string]string{"SENTRY_DSN": "https://sentry.example.com/1"}, want: []string{"reporting.sentryDsn"}},
+		{name: "MaxRefreshBelowScrape", env: map[string]string{"MAX_REFRESH_INTERVAL": "10s"}, want: []string{"metrics.maxRefreshInterval"}},
+		{name: "MaxScrapeBackoffBelowScrape", env: map[string]string{"MAX_SCRAPE_BACKOFF": "10s"}, want: []string{"metrics.maxScrapeBackoff"}},
+		{name: "DedupWindowBeyondLookback", env: map[string]string{"METRICS_DEDUP_WINDOW": "5m"}, want: []string{"metrics.dedupWindow"}},
+		{name: "DedupWithoutSeries", env: map[string]string{"METRICS_DEDUP_WINDOW": "2m", "METRICS_DEDUP_MAX_SERIES": "0"}, want: []string{"metrics.dedupMaxSeries"}},
+		{name: "NegativeMaxSeriesPerMetric", env: map[string]string{"METRICS_MAX_SERIES_PER_METRIC": "-1"}, want: []string{"metrics.maxSeriesPerMetric"}},
+		{name: "CardinalityCooldownZero", env: map[string]string{"METRICS_CARDINALITY_COOLDOWN": "0s"}, want: []string{"metrics.cardinalityCooldown"}},
+		{name: "ArmadaTLSCertWithoutKey", env: map[string]string{"ARMADA_TLS_CERT_FILE": certFile}, want: []string{"armada.tlsKeyFile"}},
+		{name: "ArmadaTLSMissingCA", env: map[string]string{"ARMADA_TLS_CA_FILE": "/nonexistent/ca.pem"}, want: []string{"armada.tlsCaFile"}},
+		{name: "ClusterTLSCAFile", env: map[string]string{"ARMADA_CLUSTERS": "staging=https://staging:5001", "ARMADA_CLUSTERS_TLS_CA_FILES": "staging=" + certFile}},
+		{name: "ClusterTLSCAFileUnknownCluster", env: map[string]string{"ARMADA_CLUSTERS_TLS_CA_FILES": "staging=" + certFile}, want: []string{"armada.clusterTlsCaFiles"}},
+		{name: "ClusterTLSInsecureUnknownCluster", env: map[string]string{"ARMADA_CLUSTERS_TLS_INSECURE_SKIP_VERIFY": "staging"}, want: []string{"armada.clusterTlsInsecureSkipVerify"}},
+		{name: "ClusterTags", env: map[string]string{"ARMADA_CLUSTER_TAGS": "env=prod,region=eu", "ARMADA_CLUSTERS": "staging=http://staging:5001", "ARMADA_CLUSTERS_TAGS": "staging:env=staging"}},
+		{name: "ClusterTagsInvalidKey", env: map[string]string{"ARMADA_CLUSTER_TAGS": "env-name=prod"}, want: []string{"armada.tags"}},
+		{name: "ClusterTagsReservedKey", env: map[string]string{"ARMADA_CLUSTER_TAGS": "cluster=prod"}, want: []string{"armada.tags"}},
+		{name: "ClusterTagsDuplicateKey", env: map[string]string{"ARMADA_CLUSTER_TAGS": "env=prod,env=dev"}, want: []string{"armada.tags"}},
+		{name: "ClusterTagsUnknownCluster", env: map[string]string{"ARMADA_CLUSTERS_TAGS": "staging:env=staging"}, want: []string{"armada.clusterTags"}},
+		{name: "ClusterTagsWithoutValue", env: map[string]string{"ARMADA_CLUSTERS": "staging=http://staging:5001", "ARMADA_CLUSTERS_TAGS": "staging:env"}, want: []string{"armada.clusterTags"}},
+		{name: "ArmadaToken", env: map[string]string{"ARMADA_URL": "https://armada:5001", "ARMADA_TOKEN": "secret"}},
+		{name: "ArmadaTokenPlainText", env: map[string]string{"ARMADA_TOKEN": "secret"}, want: []string{"armada.token"}},
+		{name: "ArmadaTokenAndFile", env: map[string]string{"ARMADA_URL": "https://armada:5001", "ARMADA_TOKEN": "secret", "ARMADA_TOKEN_FILE": certFile}, want: []string{"armada.tokenFile"}},
+		{name: "MemberDiscoveryIntervalNegative", env: map[string]string{"ARMADA_MEMBER_DISCOVERY_INTERVAL": "-1m"}, want: []string{"armada.memberDiscoveryInterval"}},
+		{name: "HealthCheckIntervalNegative", env: map[string]string{"ARMADA_HEALTH_CHECK_INTERVAL": "-1s"}, want: []string{"armada.healthCheckInterval"}},
+		{name: "ReconnectMaxRetriesZero", env: map[string]string{"ARMADA_RECONNECT_MAX_RETRIES": "0"}, want: []string{"armada.reconnectMaxRetries"}},
+		{name: "ReconnectBaseDelayZero", env: map[string]string{"ARMADA_RECONNECT_BASE_DELAY": "0s"}, want: []string{"armada.reconnectBaseDelay"}},
+		{name: "ReconnectMaxDelayBelowBase", env: map[string]string{"ARMADA_RECONNECT_BASE_DELAY": "1m", "ARMADA_RECONNECT_MAX_DELAY": "30s"}, want: []string{"armada.reconnectMaxDelay"}},
+		{name: "RetryMaxAttemptsZero", env: map[string]string{"ARMADA_RETRY_MAX_ATTEMPTS": "0"}, want: []string{"armada.retryMaxAttempts"}},
+		{name: "RetryMaxDelayBelowBase", env: map[string]string{"ARMADA_RETRY_BASE_DELAY": "2s", "ARMADA_RETRY_MAX_DELAY": "1s"}, want: []string{"armada.retryMaxDelay"}},
+		{name: "RetryBudgetZero", env: map[string]string{"ARMADA_RETRY_BUDGET": "0"}, want: []string{"armada.retryBudget"}},
+		{name: "BreakerFailuresNegative", env: map[string]string{"ARMADA_BREAKER_FAILURES": "-1"}, want: []string{"armada.breakerFailures"}},
+		{name: "BreakerCooldownZero", env: map[string]string{"ARMADA_BREAKER_COOLDOWN": "0s"}, want: []string{"armada.breakerCooldown"}},
+		{name: "BreakerDisabled", env: map[string]string{"ARMADA_BREAKER_FAILURES": "0", "ARMADA_BREAKER_COOLDOWN": "0s"}},
+		{name: "ReconnectJitterTooLarge", env: map[string]string{"ARMADA_RECONNECT_JITTER": "1.5"}, want: []string{"armada.reconnectJitter"}},
+		{name: "KeepaliveTimeTooShort", env: map[string]string{"ARMADA_KEEPALIVE_TIME": "1s"}, want: []string{"armada.keepaliveTime"}},
+		{name: "ConnectTimeoutNegative", env: map[string]string{"ARMADA_CONNECT_TIMEOUT": "-1s"}, want: []string{"armada.connectTimeout"}},
+		{name: "SlowCallThresholdNegative", env: map[string]string{"ARMADA_SLOW_CALL_THRESHOLD": "-1s"}, want: []string{"armada.slowCallThreshold"}},
+		{name: "MaxRecvMsgSizeNegative", env: map[string]string{"ARMADA_MAX_RECV_MSG_SIZE": "-1"}, want: []string{"armada.maxRecvMsgSize"}},
+		{name: "KeepaliveTimeoutZero", env: map[string]string{"ARMADA_KEEPALIVE_TIME": "30s", "ARMADA_KEEPALIVE_TIMEOUT": "0s"}, want: []string{"armada.keepaliveTimeout"}},
+		{name: "VerifyReadsSampleRate", env: map[string]string{"ARMADA_VERIFY_READS": "true", "ARMADA_VERIFY_READS_SAMPLE_RATE": "2"}, want: []string{"armada.verifyReadsSampleRate"}},
+		{name: "GrowthIntervalZero", env: map[string]string{"GROWTH_INTERVAL": "0s"}, want: []string{"growth.interval"}},
+		{name: "NegativeSizeCap", env: map[string]string{"GROWTH_SIZE_CAP": "-1"}, want: []string{"growth.sizeCap"}},
+		{name: "ShadowCluster", env: map[string]string{"ARMADA_CLUSTERS": "staging=http://staging:5001", "SHADOW_CLUSTER": "staging"}},
+		{name: "ShadowUnknownCluster", env: map[string]string{"SHADOW_CLUSTER": "staging"}, want: []string{"shadow.cluster"}},
+		{name: "ShadowQueueSize", env: map[string]string{"ARMADA_CLUSTERS": "staging=http://staging:5001", "SHADOW_CLUSTER": "staging", "SHADOW_QUEUE_SIZE": "0"}, want: []string{"shadow.queueSize"}},
+		{name: "StatusTimeoutZero", env: map[string]string{"ARMADA_STATUS_TIMEOUT": "0s"}, want: []string{"armada.statusTimeout"}},
+		{name: "KVTimeoutBeyondWriteTimeout", env: map[string]string{"ARMADA_KV_TIMEOUT": "2m"}, want: []string{"armada.kvTimeout"}},
+		{name: "QueryTimeoutZero", env: map[string]string{"METRICS_QUERY_TIMEOUT": "0s"}, want: []string{"metrics.queryTimeout"}},
+		{name: "TopologyRetentionZero", env: map[string]string{"TOPOLOGY_RETENTION": "0s"}, want: []string{"metadata.topologyRetention"}},
+		{name: "MaintenanceRetentionZero", env: map[string]string{"MAINTENANCE_RETENTION": "0s"}, want: []string{"metadata.maintenanceRetention"}},
+		{name: "CleanupIntervalZero", env: map[string]string{"RETENTION_CLEANUP_INTERVAL": "0s"}, want: []string{"metadata.cleanupInterval"}},
+		{name: "AuditRetention", env: map[string]string{"AUDIT_RETENTION": "8760h", "AUDIT_MAX_BYTES": "0"}},
+		{name: "AuditRetentionNegative", env: map[string]string{"AUDIT_RETENTION": "-1h"}, want: []string{"audit.retention"}},
+		{name: "AuditMaxBytesTooSmall", env: map[string]string{"AUDIT_MAX_BYTES": "4096"}, want: []string{"audit.maxBytes"}},
+		{name: "HotKeysSampleRateTooLarge", env: map[string]string{"HOT_KEYS_SAMPLE_RATE": "1.5"}, want: []string{"hotKeys.sampleRate"}},
+		{name: "AuthWithoutPassword", env: map[string]string{"AUTH_USERNAME": "admin"}, want: []string{"auth.passwordHash"}},
+		{name: "NegativeLoginMaxFailures", env: map[string]string{"AUTH_LOGIN_MAX_FAILURES": "-1"}, want: []string{"auth.loginMaxFailures"}},
+		{name: "LoginLockoutBeyondMax", env: map[string]string{"AUTH_LOGIN_LOCKOUT": "2h"}, want: []string{"auth.loginMaxLockout"}},
+		{name: "LocalUsersWithoutUsername", env: map[string]string{"AUTH_LOCAL_USERS": "true"}, want: []string{"auth.localUsers"}},
+		{name: "APIKeysWithoutLogin", env: map[string]string{"AUTH_API_KEYS": "true"}, want: []string{"auth.apiKeys"}},
+		{
+			name: "SessionIdleTimeoutTooShort",
+			env:  map[string]string{"AUTH_OIDC_ISSUER": "https://login.example.com", "AUTH_OIDC_CLIENT_ID": "console", "AUTH_OIDC_REDIRECT_URL": "https://console.example.com/api/auth/callback", "AUTH_SESSION_IDLE_TIMEOUT": "30s"},
+			want: []string{"auth.sessionIdleTimeout"},
+		},
+		{
+			name: "NegativeMaxSessions",
+			env:  map[string]string{"AUTH_OIDC_ISSUER": "https://login.example.com", "AUTH_OIDC_CLIENT_ID": "console", "AUTH_OIDC_REDIRECT_URL": "https://console.example.com/api/auth/callback", "AUTH_MAX_SESSIONS": "-1"},
+			want: []string{"auth.maxSessions"},
+		},
+		{
+			name: "StrictSessionsWithOIDC",
+			env:  map[string]string{"AUTH_OIDC_ISSUER": "https://login.example.com", "AUTH_OIDC_CLIENT_ID": "console", "AUTH_OIDC_REDIRECT_URL": "https://console.example.com/api/auth/callback", "AUTH_SESSION_SAME_SITE": "strict"},
+			want: []string{"auth.sessionSameSite"},
+		},
+		{
+			name: "InvalidSessionSameSite",
+			env:  map[string]string{"AUTH_OIDC_ISSUER": "https://login.example.com", "AUTH_OIDC_CLIENT_ID": "console", "AUTH_OIDC_REDIRECT_URL": "https://console.example.com/api/auth/callback", "AUTH_SESSION_SAME_SITE": "none"},
+			want: []string{"auth.sessionSameSite"},
+		},
+		{name: "AuthPlaintextPassword", env: map[string]string{"AUTH_USERNAME": "admin", "AUTH_PASSWORD_HASH": "hunter2"}, want: []string{"auth.passwordHash"}},
+		{
+			name: "OIDC",
+			env: map[string]string{
+				"AUTH_OIDC_ISSUER":       "https://login.example.com",
+				"AUTH_OIDC_CLIENT_ID":    "console",
+				"AUTH_OIDC_REDIRECT_URL": "https://console.example.com/api/auth/callback",
+			},
+		},
+		{
+			name: "OIDCWithoutClient",
+			env:  map[string]string{"AUTH_OIDC_ISSUER": "https://login.example.com"},
+			want: []string{"auth.oidcClientId", "auth.oidcRedirectUrl"},
+		},
+		{name: "JWTWithoutKeys", env: map[string]string{"AUTH_JWT_ISSUER": "https://idp.example.com"}, want: []string{"auth.jwtJwksUrl"}},
+		{name: "JWTWithoutIssuer", env: map[string]string{"AUTH_JWT_JWKS_URL": "https://idp.example.com/jwks.json"}, want: []string{"auth.jwtIssuer"}},
+		{name: "UnknownDefaultRole", env: map[string]string{"AUTH_DEFAULT_ROLE": "admin"}, want: []string{"auth.defaultRole"}},
+		{name: "Operators", env: map[string]string{"AUTH_OPERATORS": "oidc:jane@example.com, basic:admin"}},
+		{name: "OperatorsWithoutMethod", env: map[string]string{"AUTH_OPERATORS": "oidc:jane@example.com,admin"}, want: []string{"auth.operators"}},
+		{name: "OperatorsAPIKey", env: map[string]string{"AUTH_OPERATORS": "apikey:ci"}, want: []string{"auth.operators"}},
+		{name: "Guardrails", env: map[string]string{"AUTH_GUARDRAILS": "env=prod:read-only,env=prod:approve=table.delete", "AUTH_GUARDRAIL_ADMIN_GROUPS": "sre"}},
+		{name: "GuardrailsReadOnlyWithoutAdmins", env: map[string]string{"AUTH_GUARDRAILS": "env=prod:read-only"}},
+		{name: "GuardrailsWithoutTag", env: map[string]string{"AUTH_GUARDRAILS": "read-only"}, want: []string{"auth.guardrails"}},
+		{name: "GuardrailsUnknownRule", env: map[string]string{"AUTH_GUARDRAILS": "env=prod:frozen"}, want: []string{"auth.guardrails"}},
+		{name: "GuardrailsUnknownAction", env: map[string]string{"AUTH_GUARDRAILS": "env=prod:approve=table.drop", "AUTH_GUARDRAIL_ADMINS": "oidc:alice"}, want: []string{"auth.guardrails"}},
+		{name: "GuardrailAdminsWithoutMethod", env: map[string]string{"AUTH_GUARDRAIL_ADMINS": "alice"}, want: []string{"auth.guardrailAdmins"}},
+		{name: "ClusterImportAdminsWithoutMethod", env: map[string]string{"AUTH_CLUSTER_IMPORT_ADMINS": "alice"}, want: []string{"auth.clusterImportAdmins"}},
+		{name: "GuardrailsApprovalWithoutAdmins", env: map[string]string{"AUTH_GUARDRAILS": "env=prod:approve=table.delete"}, want: []string{"auth.guardrailAdmins"}},
+		{name: "GuardrailsApprovalTTLZero", env: map[string]string{"AUTH_GUARDRAILS": "env=prod:approve=table.delete", "AUTH_GUARDRAIL_ADMINS": "oidc:alice", "AUTH_APPROVAL_TTL": "0s"}, want: []string{"auth.approvalTTL"}},
+		{name: "EmbedSecretTooShort", env: map[string]string{"EMBED_SECRET": "secret"}, want: []string{"embed.secret"}},
+		{
+			name: "EmbedDefaultTTLAboveMax",
+			env: map[string]string{
+				"EMBED_SECRET":      "0123456789abcdef0123456789abcdef",
+				"EMBED_DEFAULT_TTL": "48h",
+			},
+			want: []string{"embed.defaultTTL"},
+		},
+		{name: "UnknownBrandingColor", env: map[string]string{"BRANDING_COLORS": "primary=#0055aa,accent=#ffffff"}, want: []string{"branding.colors"}},
+		{name: "InvalidBrandingColor", env: map[string]string{"BRANDING_COLORS": "primary=blue"}, want: []string{"branding.colors"}},
+		{name: "FooterLinkWithoutURL", env: map[string]string{"BRANDING_FOOTER_LINKS": "Runbook"}, want: []string{"branding.footerLinks"}},
+		{name: "MissingLogoFile", env: map[string]string{"BRANDING_LOGO_FILE": "/nonexistent/logo.svg"}, want: []string{"branding.logoFile"}},
+		{name: "UnknownTracingExporter", env: map[string]string{"TRACING_EXPORTER": "jaeger"}, want: []string{"tracing.exporter"}},
+		{
+			name: "InvalidTracingSettings",
+			env: map[string]string{
+				"TRACING_EXPORTER":     "otlp-grpc",
+				"TRACING_ENDPOINT":     "http://collector",
+				"TRACING_SAMPLE_RATIO": "2",
+			},
+			want: []string{"tracing.endpoint", "tracing.sampleRatio"},
+		},
+		{
+			name: "AnalyticsRetentionTooShort",
+			env:  map[string]string{"ANALYTICS_ENABLED": "true", "ANALYTICS_RETENTION": "1h"},
+			want: []string{"analytics.retention"},
+		},
+		{name: "RangeTimeoutBeyondWriteTimeout", env: map[string]string{"ARMADA_RANGE_TIMEOUT": "2m"}, want: []string{"armada.rangeTimeout"}},
+		{
+			name: "DebugRecordLimitTooLarge",
+			env:  map[string]string{"DEBUG_RECORD_REQUESTS": "true", "DEBUG_RECORD_LIMIT": "100000"},
+			want: []string{"debug.recordLimit"},
+		},
+		{name: "AuditSampleTooLarge", env: map[string]string{"AUDIT_SNAPSHOT_SAMPLE_KEYS": "5000"}, want: []string{"audit.snapshotSampleKeys"}},
+		{name: "UnknownDiscovery", env: map[string]string{"ARMADA_DISCOVERY": "zookeeper"}, want: []string{"discovery.mechanism"}},
+		{name: "SRVWithoutRecord", env: map[string]string{"ARMADA_DISCOVERY": "dns-srv"}, want: []string{"discovery.srvRecord"}},
+		{
+			name: "ConsulBadAddress",
+			env: map[string]string{
+				"ARMADA_DISCOVERY":             "consul",
+				"ARMADA_DISCOVERY_CONSUL_ADDR": "consul:8500",
+			},
+			want: []string{"discovery.consul.address"},
+		},
+		{
+			name: "ConsulDatacenter",
+			env:  map[string]string{"ARMADA_DISCOVERY": "consul", "ARMADA_DISCOVERY_CONSUL_DATACENTER": "eu-west_1"},
+		},
+		{
+			name: "ConsulBadDatacenter",
+			env:  map[string]string{"ARMADA_DISCOVERY": "consul", "ARMADA_DISCOVERY_CONSUL_DATACENTER": "eu west?"},
+			want: []string{"discovery.consul.datacenter"},
+		},
+		{name: "ZeroScrapeInterval", env: map[string]string{"SCRAPE_INTERVAL": "0s"}, want: []string{"metrics.scrapeInterval"}},
+		{
+			name: "RetentionShorterThanBlock",
+			env:  map[string]string{"METRICS_RETENTION": "1h", "METRICS_BLOCK_DURATION": "2h"},
+			want: []string{"metrics.retention"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validationPaths(t, tt.env))
+		})
+	}
+}
+
+func TestValidationErrorMentionsSource(t *testing.T) {
+	cfg, err := Load("", envMap(map[string]string{"METRICS_RETENTION": "1h"}))
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metrics.retention: must be at least metrics.blockDuration (2h0m0s), got 1h0m0s")
+	assert.Contains(t, err.Error(), "set via environment variable METRICS_RETENTION")
+}
@@ -0,0 +1,431 @@
+// Package conventions recognizes keys written by infrastructure components after well-known
+// layouts, such as etcd style locks, election keys and leases, and decodes what they mean, so
+// operators browsing a table can tell which component stored a key and why. The built-in
+// conventions can be complemented with custom ones kept in the metadata store.
+package conventions
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/armadakv/console/backend/metadata"
+)
+
+// Namespace is the metadata namespace the custom conventions are stored in
+const Namespace = "conventions"
+
+// maxSamples is the number of matching keys kept per convention in a summary
+const maxSamples = 5
+
+var (
+	// ErrNotFound is returned when a convention does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for conventions that can't be stored
+	ErrInvalid = errors.New("invalid")
+	// ErrBuiltin is returned when changing or removing a built-in convention
+	ErrBuiltin = errors.New("is built in")
+)
+
+// validName matches the names of conventions
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,62}$`)
+
+// validField matches the placeholders of a pattern: a field name, optionally followed by :hex
+// for a hexadecimal number or ... for the rest of the key
+var validField = regexp.MustCompile(`^\{([A-Za-z][A-Za-z0-9_]*)(:hex|\.\.\.)?\}$`)
+
+// Kind is what a key following a convention is used for
+type Kind string
+
+const (
+	KindLease    Kind = "lease"
+	KindLock     Kind = "lock"
+	KindElection Kind = "election"
+	KindResource Kind = "resource"
+	KindOther    Kind = "other"
+)
+
+// kinds are the valid kinds of a convention
+var kinds = []Kind{KindLease, KindLock, KindElection, KindResource, KindOther}
+
+// Convention describes the layout of the keys an infrastructure component writes
+type Convention struct {
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// Pattern is the layout of the keys as segments separated by /. A segment is either literal
+	// or a placeholder: {field} matches one segment, {field:hex} a hexadecimal number that is
+	// decoded to decimal in field and kept as is in fieldHex, and a final {field...} the rest of
+	// the key.
+	Pattern string `json:"pattern"`
+	// Table restricts the convention to one table, it applies to all tables if empty.
+	Table string `json:"table,omitempty"`
+	// Component is the infrastructure component writing the keys, e.g. etcd.
+	Component string `json:"component"`
+	// Description explains a matching key, placeholders like {field} are replaced by the fields
+	// decoded from the key.
+	Description string `json:"description"`
+	// Builtin is set for the conventions shipped with the console, which can't be changed.
+	Builtin bool `json:"builtin"`
+}
+
+// Builtin are the conventions recognized without configuration. More specific patterns come
+// first, as the first matching convention wins.
+var Builtin = []Convention{
+	{
+		Name:        "etcd-lock",
+		Kind:        KindLock,
+		Pattern:     "locks/{name}/{lease:hex}",
+		Component:   "etcd concurrency",
+		Description: "Lock {name} held by the owner of lease {lease}",
+		Builtin:     true,
+	},
+	{
+		Name:        "etcd-election",
+		Kind:        KindElection,
+		Pattern:     "elections/{name}/{lease:hex}",
+		Component:   "etcd concurrency",
+		Description: "Candidate in election {name} campaigning with lease {lease}",
+		Builtin:     true,
+	},
+	{
+		Name:        "lease",
+		Kind:        KindLease,
+		Pattern:     "leases/{lease:hex}",
+		Component:   "lease keeper",
+		Description: "Lease {lease}, keys attached to it expire with it",
+		Builtin:     true,
+	},
+	{
+		Name:        "kubernetes-namespaced",
+		Kind:        KindResource,
+		Pattern:     "/registry/{resource}/{namespace}/{name}",
+		Component:   "Kubernetes API server",
+		Description: "Kubernetes {resource} {name} in namespace {namespace}",
+		Builtin:     true,
+	},
+	{
+		Name:        "kubernetes",
+		Kind:        KindResource,
+		Pattern:     "/registry/{resource}/{name}",
+		Component:   "Kubernetes API server",
+		Description: "Cluster scoped Kubernetes {resource} {name}",
+		Builtin:     true,
+	},
+}
+
+// segment is a compiled segment of a pattern
+type segment struct {
+	literal string
+	field   string
+	hex     bool
+	rest    bool
+}
+
+// compile splits a pattern into its segments
+func compile(pattern string) ([]segment, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("%w pattern: must be set", ErrInvalid)
+	}
+	parts := strings.Split(pattern, "/")
+	segments := make([]segment, 0, len(parts))
+	fields := make(map[string]bool)
+	for i, part := range parts {
+		if !strings.ContainsAny(part, "{}") {
+			segments = append(segments, segment{literal: part})
+			continue
+		}
+		m := validField.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("%w pattern %q: segment %q must be literal or a single {field}, {field:hex} or {field...}", ErrInvalid, pattern, part)
+		}
+		if fields[m[1]] {
+			return nil, fmt.Errorf("%w pattern %q: field %s is used twice", ErrInvalid, pattern, m[1])
+		}
+		fields[m[1]] = true
+		s := segment{field: m[1], hex: m[2] == ":hex", rest: m[2] == "..."}
+		if s.rest && i != len(parts)-1 {
+			return nil, fmt.Errorf("%w pattern %q: {%s...} must be the last segment", ErrInvalid, pattern, m[1])
+		}
+		segments = append(segments, s)
+	}
+	return segments, nil
+}
+
+// match decodes the fields of key, it returns false if key doesn't follow the segments
+func match(segments []segment, key string) (map[string]string, bool) {
+	parts := strings.Split(key, "/")
+	fields := make(map[string]string)
+	for i, s := range segments {
+		if i >= len(parts) {
+			return nil, false
+		}
+		part := parts[i]
+		switch {
+		case s.rest:
+			rest := strings.Join(parts[i:], "/")
+			if rest == "" {
+				return nil, false
+			}
+			fields[s.field] = rest
+			return fields, true
+		case s.field == "":
+			if part != s.literal {
+				return nil, false
+			}
+		case part == "":
+			return nil, false
+		case s.hex:
+			n, err := strconv.ParseUint(part, 16, 64)
+			if err != nil {
+				return nil, false
+			}
+			fields[s.field] = strconv.FormatUint(n, 10)
+			fields[s.field+"Hex"] = part
+		default:
+			fields[s.field] = part
+		}
+	}
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+	return fields, true
+}
+
+// describe replaces the placeholders of a description by the fields
+func describe(description string, fields map[string]string) string {
+	pairs := make([]string, 0, 2*len(fields))
+	for field, value := range fields {
+		pairs = append(pairs, "{"+field+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(description)
+}
+
+// Registry keeps the custom conventions in the metadata store
+type Registry struct {
+	store metadata.Store
+	// mu serializes changes
+	mu sync.Mutex
+}
+
+// NewRegistry creates a Registry storing the custom conventions in store
+func NewRegistry(store metadata.Store) *Registry {
+	return &Registry{store: store}
+}
+
+// List returns the custom conventions by name followed by the built-in ones, in the order
+// they are matched
+func (r *Registry) List() ([]Convention, error) {
+	stored, err := metadata.List[Convention](r.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Convention, 0, len(stored)+len(Builtin))
+	for _, c := range stored {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return append(list, Builtin...), nil
+}
+
+// Get returns a convention, custom or built in
+func (r *Registry) Get(name string) (Convention, error) {
+	for _, c := range Builtin {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	c, err := metadata.Get[Convention](r.store, Namespace, name)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Convention{}, fmt.Errorf("convention %s %w", name, ErrNotFound)
+	}
+	return c, err
+}
+
+// Put creates or replaces a custom convention
+func (r *Registry) Put(c Convention) (Convention, error) {
+	if !validName.MatchString(c.Name) {
+		return Convention{}, fmt.Errorf("%w convention name %q: use up to 63 letters, digits, _ and -", ErrInvalid, c.Name)
+	}
+	if isBuiltin(c.Name) {
+		return Convention{}, fmt.Errorf("convention %s %w", c.Name, ErrBuiltin)
+	}
+	if !validKind(c.Kind) {
+		return Convention{}, fmt.Errorf("%w convention kind %q: use one of %v", ErrInvalid, c.Kind, kinds)
+	}
+	if _, err := compile(c.Pattern); err != nil {
+		return Convention{}, err
+	}
+	c.Builtin = false
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := metadata.Put(r.store, Namespace, c.Name, c); err != nil {
+		return Convention{}, err
+	}
+	return c, nil
+}
+
+// Delete removes a custom convention
+func (r *Registry) Delete(name string) error {
+	if isBuiltin(name) {
+		return fmt.Errorf("convention %s %w", name, ErrBuiltin)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := metadata.Get[Convention](r.store, Namespace, name); err != nil {
+		if errors.Is(err, metadata.ErrNotFound) {
+			return fmt.Errorf("convention %s %w", name, ErrNotFound)
+		}
+		return err
+	}
+	return r.store.Delete(Namespace, name)
+}
+
+// Matcher returns a Matcher for the current conventions. Stored conventions whose pattern
+// doesn't compile anymore are skipped.
+func (r *Registry) Matcher() (*Matcher, error) {
+	list, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	return NewMatcher(list), nil
+}
+
+// isBuiltin reports whether name is the name of a built-in convention
+func isBuiltin(name string) bool {
+	for _, c := range Builtin {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validKind reports whether kind is one of kinds
+func validKind(kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// compiled is a convention with its compiled pattern
+type compiled struct {
+	convention Convention
+	segments   []segment
+}
+
+// Matcher finds the convention a key follows
+type Matcher struct {
+	conventions []compiled
+}
+
+// NewMatcher creates a Matcher trying the conventions in order. Conventions with an invalid
+// pattern are skipped.
+func NewMatcher(conventions []Convention) *Matcher {
+	m := &Matcher{}
+	for _, c := range conventions {
+		if segments, err := compile(c.Pattern); err == nil {
+			m.conventions = append(m.conventions, compiled{convention: c, segments: segments})
+		}
+	}
+	return m
+}
+
+// Match is a key following a convention
+type Match struct {
+	Key        string `json:"key"`
+	Convention string `json:"convention"`
+	Kind       Kind   `json:"kind"`
+	Component  string `json:"component"`
+	// Fields are the values decoded from the key.
+	Fields map[string]string `json:"fields"`
+	// Description explains the key.
+	Description string `json:"description"`
+}
+
+// Match returns how the first convention that applies to the table and that key follows
+// decodes the key, nil if it follows none
+func (m *Matcher) Match(table, key string) *Match {
+	for _, c := range m.conventions {
+		if c.convention.Table != "" && c.convention.Table != table {
+			continue
+		}
+		fields, ok := match(c.segments, key)
+		if !ok {
+			continue
+		}
+		return &Match{
+			Key:         key,
+			Convention:  c.convention.Name,
+			Kind:        c.convention.Kind,
+			Component:   c.convention.Component,
+			Fields:      fields,
+			Description: describe(c.convention.Description, fields),
+		}
+	}
+	return nil
+}
+
+// Usage counts the keys of a table following one convention
+type Usage struct {
+	Convention string `json:"convention"`
+	Kind       Kind   `json:"kind"`
+	Component  string `json:"component"`
+	Keys       int    `json:"keys"`
+	// Samples are the first matching keys, decoded.
+	Samples []Match `json:"samples"`
+}
+
+// Summary describes which conventions the keys of a table follow
+type Summary struct {
+	Table string `json:"table"`
+	// Scanned is the number of keys examined.
+	Scanned int `json:"scanned"`
+	// Matched is the number of keys following a convention.
+	Matched int `json:"matched"`
+	// Truncated is set when the table has more keys than were scanned.
+	Truncated bool `json:"truncated"`
+	// Conventions are the conventions found, by the number of keys following them.
+	Conventions []Usage `json:"conventions"`
+}
+
+// Summarize counts the keys of the table following each convention
+func (m *Matcher) Summarize(table string, keys []string) Summary {
+	summary := Summary{Table: table, Scanned: len(keys), Conventions: []Usage{}}
+	usage := make(map[string]*Usage)
+	for _, key := range keys {
+		match := m.Match(table, key)
+		if match == nil {
+			continue
+		}
+		summary.Matched++
+		u, ok := usage[match.Convention]
+		if !ok {
+			u = &Usage{Convention: match.Convention, Kind: match.Kind, Component: match.Component}
+			usage[match.Convention] = u
+		}
+		u.Keys++
+		if len(u.Samples) < maxSamples {
+			u.Samples = append(u.Samples, *match)
+		}
+	}
+	for _, u := range usage {
+		summary.Conventions = append(summary.Conventions, *u)
+	}
+	sort.Slice(summary.Conventions, func(i, j int) bool {
+		a, b := summary.Conventions[i], summary.Conventions[j]
+		if a.Keys != b.Keys {
+			return a.Keys > b.Keys
+		}
+		return a.Convention < b.Convention
+	})
+	return summary
+}
@@ -0,0 +1,106 @@
+package conventions
+
+import (
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatcherBuiltin(t *testing.T) {
+	m := NewMatcher(Builtin)
+
+	match := m.Match("locks", "locks/scheduler/694d7d1b5a2c0e04")
+	require.NotNil(t, match)
+	assert.Equal(t, "etcd-lock", match.Convention)
+	assert.Equal(t, KindLock, match.Kind)
+	assert.Equal(t, map[string]string{"name": "scheduler", "lease": "7587858503619579396", "leaseHex": "694d7d1b5a2c0e04"}, match.Fields)
+	assert.Equal(t, "Lock scheduler held by the owner of lease 7587858503619579396", match.Description)
+
+	match = m.Match("k8s", "/registry/pods/default/web-0")
+	require.NotNil(t, match)
+	assert.Equal(t, "kubernetes-namespaced", match.Convention)
+	assert.Equal(t, "Kubernetes pods web-0 in namespace default", match.Description)
+
+	match = m.Match("k8s", "/registry/namespaces/default")
+	require.NotNil(t, match)
+	assert.Equal(t, "kubernetes", match.Convention)
+
+	assert.Nil(t, m.Match("locks", "locks/scheduler/not-hex"), "the lease must be hexadecimal")
+	assert.Nil(t, m.Match("locks", "locks/scheduler/1/extra"), "keys with more segments don't match")
+	assert.Nil(t, m.Match("locks", "locks//1"), "fields can't be empty")
+	assert.Nil(t, m.Match("users", "user:1"))
+}
+
+func TestMatcherCustom(t *testing.T) {
+	m := NewMatcher([]Convention{
+		{Name: "jobs", Kind: KindOther, Pattern: "jobs/{queue}/{path...}", Table: "work", Description: "Job {path} of {queue}"},
+		{Name: "broken", Kind: KindOther, Pattern: "a/{b"},
+	})
+
+	match := m.Match("work", "jobs/mail/2024/01/42")
+	require.NotNil(t, match)
+	assert.Equal(t, "Job 2024/01/42 of mail", match.Description)
+	assert.Nil(t, m.Match("other", "jobs/mail/42"), "the convention applies to its table only")
+	assert.Nil(t, m.Match("work", "jobs/mail"), "the rest of the key can't be empty")
+}
+
+func TestCompile(t *testing.T) {
+	for _, pattern := range []string{"", "a/{b", "a/{b}{c}", "{a...}/b", "{a}/{a}", "a/{b:int}"} {
+		_, err := compile(pattern)
+		assert.ErrorIs(t, err, ErrInvalid, pattern)
+	}
+	_, err := compile("/registry/{resource}/{rest...}")
+	assert.NoError(t, err)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(metadata.NewMemoryStore())
+
+	list, err := r.List()
+	require.NoError(t, err)
+	assert.Equal(t, Builtin, list)
+
+	_, err = r.Put(Convention{Name: "etcd-lock", Kind: KindLock, Pattern: "x/{y}"})
+	assert.ErrorIs(t, err, ErrBuiltin)
+	_, err = r.Put(Convention{Name: "jobs", Kind: "queue", Pattern: "x/{y}"})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = r.Put(Convention{Name: "jobs", Kind: KindOther, Pattern: "x/{y"})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = r.Put(Convention{Name: "bad name", Kind: KindOther, Pattern: "x/{y}"})
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// Custom conventions are matched before the built-in ones
+	c, err := r.Put(Convention{Name: "scheduler-lock", Kind: KindLock, Pattern: "locks/scheduler/{owner}", Component: "scheduler", Builtin: true})
+	require.NoError(t, err)
+	assert.False(t, c.Builtin)
+	m, err := r.Matcher()
+	require.NoError(t, err)
+	assert.Equal(t, "scheduler-lock", m.Match("", "locks/scheduler/1").Convention)
+	assert.Equal(t, "etcd-lock", m.Match("", "locks/other/1").Convention)
+
+	got, err := r.Get("scheduler-lock")
+	require.NoError(t, err)
+	assert.Equal(t, c, got)
+
+	assert.ErrorIs(t, r.Delete("lease"), ErrBuiltin)
+	require.NoError(t, r.Delete("scheduler-lock"))
+	assert.ErrorIs(t, r.Delete("scheduler-lock"), ErrNotFound)
+	_, err = r.Get("scheduler-lock")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestSummarize(t *testing.T) {
+	m := NewMatcher(Builtin)
+	keys := []string{"leases/1", "leases/2", "locks/a/3", "users/1", "leases/4", "leases/5", "leases/6", "leases/7"}
+
+	summary := m.Summarize("t", keys)
+	assert.Equal(t, 8, summary.Scanned)
+	assert.Equal(t, 7, summary.Matched)
+	require.Len(t, summary.Conventions, 2)
+	assert.Equal(t, "lease", summary.Conventions[0].Convention)
+	assert.Equal(t, 6, summary.Conventions[0].Keys)
+	assert.Len(t, summary.Conventions[0].Samples, maxSamples)
+	assert.Equal(t, "etcd-lock", summary.Conventions[1].Convention)
+}
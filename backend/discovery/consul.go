@@ -0,0 +1,112 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConsulDiscoverer discovers seed addresses from the Consul service catalog.
+type ConsulDiscoverer struct {
+	// Address is the base URL of the Consul HTTP API, e.g. "http://127.0.0.1:8500".
+	Address string
+
+	// Service is the name of the Armada service registered in Consul.
+	Service string
+
+	// Tag optionally restricts the lookup to service instances with this tag.
+	Tag string
+
+	// Datacenter optionally selects the Consul datacenter to query.
+	Datacenter string
+
+	// Token is an optional ACL token sent with every request.
+	Token string
+
+	// Scheme is prepended to every discovered address, e.g. "http" or "https".
+	Scheme string
+
+	// HTTPClient is used to call the Consul API.
+	HTTPClient *http.Client
+}
+
+// consulCatalogService is the subset of the Consul catalog service entry used for discovery
+type consulCatalogService struct {
+	Address        string `json:"Address"`
+	ServiceAddress string `json:"ServiceAddress"`
+	ServicePort    int    `json:"ServicePort"`
+}
+
+// NewConsulDiscoverer creates a discoverer for the given Consul agent and service name
+func NewConsulDiscoverer(address, service, scheme string) *ConsulDiscoverer {
+	return &ConsulDiscoverer{
+		Address:    address,
+		Service:    service,
+		Scheme:     scheme,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Discover queries the Consul catalog and returns one address per service instance
+func (d *ConsulDiscoverer) Discover(ctx context.Context) ([]string, error) {
+	query := url.Values{}
+	if d.Tag != "" {
+		query.Set("tag", d.Tag)
+	}
+	if d.Datacenter != "" {
+		query.Set("dc", d.Datacenter)
+	}
+
+	endpoint := strings.TrimSuffix(d.Address, "/") + "/v1/catalog/service/" + url.PathEscape(d.Service)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Consul request: %w", err)
+	}
+	if d.Token != "" {
+		req.Header.Set("X-Consul-Token", d.Token)
+	}
+
+	resp, err := d.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Consul catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("consul catalog returned status %d for service %s", resp.StatusCode, d.Service)
+	}
+
+	var services []consulCatalogService
+	if err := json.NewDecoder(resp.Body).Decode(&services); err != nil {
+		return nil, fmt.Errorf("failed to decode Consul catalog response: %w", err)
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("consul service %s has no instances", d.Service)
+	}
+
+	seeds := make([]string, 0, len(services))
+	for _, service := range services {
+		// The service address takes precedence, the node address is the fallback
+		host := service.ServiceAddress
+		if host == "" {
+			host = service.Address
+		}
+		seeds = append(seeds, withScheme(d.Scheme, net.JoinHostPort(host, strconv.Itoa(service.ServicePort))))
+	}
+	return seeds, nil
+}
+
+// Name returns the name of the discovery mechanism
+func (d *ConsulDiscoverer) Name() string {
+	return "consul"
+}
@@ -0,0 +1,52 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsulDiscoverer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/catalog/service/armada", r.URL.Path)
+		assert.Equal(t, "prod", r.URL.Query().Get("tag"))
+		assert.Equal(t, "secret", r.Header.Get("X-Consul-Token"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"Address": "10.0.0.1", "ServiceAddress": "", "ServicePort": 5001},
+			{"Address": "10.0.0.2", "ServiceAddress": "10.1.0.2", "ServicePort": 5001}
+		]`))
+	}))
+	defer server.Close()
+
+	d := NewConsulDiscoverer(server.URL, "armada", "http")
+	d.Tag = "prod"
+	d.Token = "secret"
+
+	seeds, err := d.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://10.0.0.1:5001", "http://10.1.0.2:5001"}, seeds)
+}
+
+func TestConsulDiscovererErrors(t *testing.T) {
+	status := http.StatusInternalServerError
+	body := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	d := NewConsulDiscoverer(server.URL, "armada", "http")
+
+	_, err := d.Discover(context.Background())
+	assert.Error(t, err, "non-200 responses should fail")
+
+	status, body = http.StatusOK, "[]"
+	_, err = d.Discover(context.Background())
+	assert.Error(t, err, "an empty catalog should fail")
+}
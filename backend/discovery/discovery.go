@@ -0,0 +1,213 @@
+// Package discovery provides mechanisms for finding the seed addresses of Armada clusters.
+// Seeds can be configured statically, resolved from DNS SRV records or looked up in the
+// Consul service catalog. A Refresher periodically re-runs discovery and feeds the
+// resulting addresses into the connection pool, the same way static seeds are used, and
+// closes the connections of seeds that disappeared.
+package discovery
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Discoverer finds seed addresses of Armada servers.
+type Discoverer interface {
+	// Discover returns the currently known seed addresses.
+	// Addresses are returned in the same form as ARMADA_URL, e.g. "http://host:5001".
+	Discover(ctx context.Context) ([]string, error)
+
+	// Name returns a short name of the discovery mechanism used for logging.
+	Name() string
+}
+
+// SeedSink consumes discovered seed addresses.
+// The armada.ConnectionPool implements this interface.
+type SeedSink interface {
+	// InitializeConnections establishes connections to the given server addresses.
+	InitializeConnections(ctx context.Context, serverAddresses []string) map[string]error
+
+	// EvictAddress closes the connection to the server at the address.
+	EvictAddress(address string) error
+}
+
+// SeedRegistry keeps the seeds of the registered clusters.
+// The cluster.Registry implements this interface.
+type SeedRegistry interface {
+	// SetSeeds replaces the seed addresses of a cluster.
+	SetSeeds(name string, seeds []string) error
+}
+
+// RefresherOption configures optional behaviour of the Refresher
+type RefresherOption func(*Refresher)
+
+// WithSeedRegistry records the discovered seeds as the seeds of the cluster in the registry,
+// e.g. so clusters are listed and replayed with their current seeds
+func WithSeedRegistry(registry SeedRegistry, cluster string) RefresherOption {
+	return func(r *Refresher) {
+		r.registry = registry
+		r.cluster = cluster
+	}
+}
+
+// StaticDiscoverer returns a fixed list of seed addresses.
+type StaticDiscoverer struct {
+	Seeds []string
+}
+
+// Discover returns the configured seed addresses
+func (s *StaticDiscoverer) Discover(_ context.Context) ([]string, error) {
+	return slices.Clone(s.Seeds), nil
+}
+
+// Name returns the name of the discovery mechanism
+func (s *StaticDiscoverer) Name() string {
+	return "static"
+}
+
+// Refresher periodically runs a Discoverer and feeds new seeds into a SeedSink.
+type Refresher struct {
+	discoverer Discoverer
+	sink       SeedSink
+	interval   time.Duration
+	logger     *zap.Logger
+	// registry keeps the seeds of the cluster, it may be nil
+	registry SeedRegistry
+	cluster  string
+
+	// mu protects seeds
+	mu sync.RWMutex
+	// seeds holds the result of the last successful discovery
+	seeds []string
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRefresher creates a new Refresher that runs discovery at the given interval
+func NewRefresher(discoverer Discoverer, sink SeedSink, interval time.Duration, logger *zap.Logger, opts ...RefresherOption) *Refresher {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	r := &Refresher{
+		discoverer: discoverer,
+		sink:       sink,
+		interval:   interval,
+		logger:     logger.Named("discovery").With(zap.String("mechanism", discoverer.Name())),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start begins periodic discovery in the background
+func (r *Refresher) Start(ctx context.Context) {
+	go r.run(ctx)
+}
+
+// Stop stops periodic discovery
+func (r *Refresher) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// Seeds returns the seed addresses found by the last successful discovery
+func (r *Refresher) Seeds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.seeds)
+}
+
+// run performs discovery immediately and then at every interval
+func (r *Refresher) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.Refresh(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Refresh(ctx)
+		case <-r.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh runs discovery once and connects to any newly discovered seeds.
+// Seeds that could not be connected to are retried on the next refresh.
+// The connections of seeds that disappeared from discovery are closed, servers that are
+// still members of the cluster are connected again by the pool's discovery of the members.
+func (r *Refresher) Refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	seeds, err := r.discoverer.Discover(ctx)
+	if err != nil {
+		r.logger.Warn("Seed discovery failed, keeping previous seeds", zap.Error(err))
+		return
+	}
+	if len(seeds) == 0 {
+		// Rather than taken for every seed leaving, an empty result is ignored
+		r.logger.Warn("Seed discovery found no seeds, keeping previous seeds")
+		return
+	}
+
+	r.mu.RLock()
+	added := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		if !slices.Contains(r.seeds, seed) {
+			added = append(added, seed)
+		}
+	}
+	var departed []string
+	for _, seed := range r.seeds {
+		if !slices.Contains(seeds, seed) {
+			departed = append(departed, seed)
+		}
+	}
+	r.mu.RUnlock()
+
+	if r.registry != nil {
+		if err := r.registry.SetSeeds(r.cluster, seeds); err != nil {
+			r.logger.Warn("Failed to record discovered seeds", zap.String("cluster", r.cluster), zap.Error(err))
+		}
+	}
+	if len(departed) > 0 {
+		r.logger.Info("Seeds disappeared from discovery, closing their connections", zap.Strings("seeds", departed))
+		for _, seed := range departed {
+			// The connection may be gone already, e.g. if the member left the cluster
+			_ = r.sink.EvictAddress(seed)
+		}
+	}
+
+	var failed map[string]error
+	if len(added) > 0 {
+		r.logger.Info("Discovered new seeds", zap.Strings("seeds", added))
+		failed = r.sink.InitializeConnections(ctx, added)
+		for addr, err := range failed {
+			r.logger.Warn("Failed to connect to discovered seed",
+				zap.String("address", addr),
+				zap.Error(err))
+		}
+	} else {
+		r.logger.Debug("No new seeds discovered", zap.Int("seedCount", len(seeds)))
+	}
+
+	r.mu.Lock()
+	r.seeds = slices.DeleteFunc(slices.Clone(seeds), func(seed string) bool {
+		_, ok := failed[seed]
+		return ok
+	})
+	r.mu.Unlock()
+}
@@ -0,0 +1,149 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeDiscoverer returns a configurable list of seeds
+type fakeDiscoverer struct {
+	mu    sync.Mutex
+	seeds []string
+	err   error
+}
+
+func (f *fakeDiscoverer) Discover(_ context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seeds, f.err
+}
+
+func (f *fakeDiscoverer) Name() string {
+	return "fake"
+}
+
+// fakeSink records the addresses it was asked to connect to and to evict
+type fakeSink struct {
+	mu        sync.Mutex
+	requested [][]string
+	evicted   []string
+	failing   map[string]bool
+}
+
+func (f *fakeSink) InitializeConnections(_ context.Context, addresses []string) map[string]error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requested = append(f.requested, addresses)
+
+	errs := make(map[string]error)
+	for _, addr := range addresses {
+		if f.failing[addr] {
+			errs[addr] = errors.New("connection refused")
+		}
+	}
+	return errs
+}
+
+func (f *fakeSink) EvictAddress(address string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.evicted = append(f.evicted, address)
+	return nil
+}
+
+func TestStaticDiscoverer(t *testing.T) {
+	d := &StaticDiscoverer{Seeds: []string{"http://a:5001", "http://b:5001"}}
+
+	seeds, err := d.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a:5001", "http://b:5001"}, seeds)
+	assert.Equal(t, "static", d.Name())
+}
+
+func TestRefresherOnlyConnectsNewSeeds(t *testing.T) {
+	d := &fakeDiscoverer{seeds: []string{"http://a:5001"}}
+	sink := &fakeSink{}
+	r := NewRefresher(d, sink, time.Minute, zap.NewNop())
+
+	r.Refresh(context.Background())
+	r.Refresh(context.Background())
+
+	d.seeds = []string{"http://a:5001", "http://b:5001"}
+	r.Refresh(context.Background())
+
+	assert.Equal(t, [][]string{{"http://a:5001"}, {"http://b:5001"}}, sink.requested)
+	assert.Equal(t, []string{"http://a:5001", "http://b:5001"}, r.Seeds())
+}
+
+func TestRefresherRetriesFailedSeeds(t *testing.T) {
+	d := &fakeDiscoverer{seeds: []string{"http://a:5001"}}
+	sink := &fakeSink{failing: map[string]bool{"http://a:5001": true}}
+	r := NewRefresher(d, sink, time.Minute, zap.NewNop())
+
+	r.Refresh(context.Background())
+	assert.Empty(t, r.Seeds())
+
+	sink.failing = nil
+	r.Refresh(context.Background())
+	assert.Equal(t, []string{"http://a:5001"}, r.Seeds())
+	assert.Len(t, sink.requested, 2)
+}
+
+func TestRefresherKeepsSeedsOnError(t *testing.T) {
+	d := &fakeDiscoverer{seeds: []string{"http://a:5001"}}
+	r := NewRefresher(d, &fakeSink{}, time.Minute, zap.NewNop())
+
+	r.Refresh(context.Background())
+	d.err = errors.New("dns failure")
+	r.Refresh(context.Background())
+
+	assert.Equal(t, []string{"http://a:5001"}, r.Seeds())
+}
+
+func TestRefresherEvictsDepartedSeeds(t *testing.T) {
+	registry := cluster.NewRegistry()
+	require.NoError(t, registry.Register(cluster.Cluster{Name: "default", Seeds: []string{"http://armada:5001"}}))
+	d := &fakeDiscoverer{seeds: []string{"http://a:5001", "http://b:5001"}}
+	sink := &fakeSink{}
+	r := NewRefresher(d, sink, time.Minute, zap.NewNop(), WithSeedRegistry(registry, "default"))
+
+	r.Refresh(context.Background())
+	c, err := registry.Get("default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://a:5001", "http://b:5001"}, c.Seeds)
+
+	d.seeds = []string{"http://b:5001", "http://c:5001"}
+	r.Refresh(context.Background())
+	assert.Equal(t, []string{"http://a:5001"}, sink.evicted)
+	assert.Equal(t, []string{"http://b:5001", "http://c:5001"}, r.Seeds())
+	c, err = registry.Get("default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://b:5001", "http://c:5001"}, c.Seeds)
+
+	// An empty result isn't taken for every seed leaving
+	d.seeds = nil
+	r.Refresh(context.Background())
+	assert.Equal(t, []string{"http://a:5001"}, sink.evicted)
+	assert.Equal(t, []string{"http://b:5001", "http://c:5001"}, r.Seeds())
+}
+
+func TestRefresherStartStop(t *testing.T) {
+	d := &fakeDiscoverer{seeds: []string{"http://a:5001"}}
+	r := NewRefresher(d, &fakeSink{}, 10*time.Millisecond, zap.NewNop())
+
+	r.Start(context.Background())
+	assert.Eventually(t, func() bool {
+		return len(r.Seeds()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	r.Stop()
+	r.Stop() // Stop is idempotent
+}
@@ -0,0 +1,68 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SRVResolver resolves DNS SRV records.
+// net.Resolver implements this interface.
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// SRVDiscoverer discovers seed addresses from DNS SRV records.
+type SRVDiscoverer struct {
+	// Record is the fully qualified SRV record name, e.g. "_grpc._tcp.armada.example.com".
+	Record string
+
+	// Scheme is prepended to every discovered address, e.g. "http" or "https".
+	Scheme string
+
+	// Resolver is used to look up the records. It defaults to net.DefaultResolver.
+	Resolver SRVResolver
+}
+
+// NewSRVDiscoverer creates a discoverer for the given SRV record name and scheme
+func NewSRVDiscoverer(record, scheme string) *SRVDiscoverer {
+	return &SRVDiscoverer{
+		Record:   record,
+		Scheme:   scheme,
+		Resolver: net.DefaultResolver,
+	}
+}
+
+// Discover resolves the SRV record and returns one address per target
+func (d *SRVDiscoverer) Discover(ctx context.Context) ([]string, error) {
+	// Passing empty service and proto makes the resolver look up the record name directly
+	_, records, err := d.Resolver.LookupSRV(ctx, "", "", d.Record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve SRV record %s: %w", d.Record, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("SRV record %s has no targets", d.Record)
+	}
+
+	seeds := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		seeds = append(seeds, withScheme(d.Scheme, net.JoinHostPort(host, strconv.Itoa(int(record.Port)))))
+	}
+	return seeds, nil
+}
+
+// Name returns the name of the discovery mechanism
+func (d *SRVDiscoverer) Name() string {
+	return "dns-srv"
+}
+
+// withScheme prefixes an address with the given URL scheme, if any
+func withScheme(scheme, address string) string {
+	if scheme == "" {
+		return address
+	}
+	return scheme + "://" + address
+}
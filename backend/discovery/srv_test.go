@@ -0,0 +1,48 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResolver returns canned SRV records
+type fakeResolver struct {
+	records []*net.SRV
+	err     error
+	name    string
+}
+
+func (f *fakeResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	f.name = name
+	return "", f.records, f.err
+}
+
+func TestSRVDiscoverer(t *testing.T) {
+	resolver := &fakeResolver{records: []*net.SRV{
+		{Target: "armada-0.example.com.", Port: 5001},
+		{Target: "armada-1.example.com.", Port: 5002},
+	}}
+	d := NewSRVDiscoverer("_grpc._tcp.armada.example.com", "https")
+	d.Resolver = resolver
+
+	seeds, err := d.Discover(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "_grpc._tcp.armada.example.com", resolver.name)
+	assert.Equal(t, []string{"https://armada-0.example.com:5001", "https://armada-1.example.com:5002"}, seeds)
+}
+
+func TestSRVDiscovererErrors(t *testing.T) {
+	d := NewSRVDiscoverer("_grpc._tcp.armada.example.com", "http")
+
+	d.Resolver = &fakeResolver{err: errors.New("no such host")}
+	_, err := d.Discover(context.Background())
+	assert.Error(t, err)
+
+	d.Resolver = &fakeResolver{}
+	_, err = d.Discover(context.Background())
+	assert.Error(t, err)
+}
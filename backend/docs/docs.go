@@ -0,0 +1,7936 @@
+// Package docs Code generated by swaggo/swag. DO NOT EDIT
+package docs
+
+import "github.com/swaggo/swag"
+
+const docTemplate = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "contact": {},
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/api/admin/analytics": {
+            "get": {
+                "description": "Get the page views per feature and API requests per route of the last days",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get usage report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of days reported, including today (default 30)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/analytics.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid days",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Analytics are disabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/apikeys": {
+            "get": {
+                "description": "List the API keys with their role, expiry and last use. The keys themselves are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/apikeys.Key"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a key for scripts and CI jobs, sent in the X-API-Key header or as a bearer token. The key is only returned in this response, a digest of it is stored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create API key",
+                "parameters": [
+                    {
+                        "description": "API key",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateAPIKeyResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new API key"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "API key already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/apikeys/{id}": {
+            "delete": {
+                "description": "Delete an API key, requests using it are refused",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/config": {
+            "get": {
+                "description": "Get the effective configuration with the source of every value, secrets are redacted. Settings changed by a reload that aren't in effect yet are listed as pending.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ConfigResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/reload": {
+            "post": {
+                "description": "Reload the configuration file and apply the changed seed list, scrape interval, TLS certificate and log level without a restart. Other changes are listed as requiring a restart.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reload configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reload.Result"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid configuration",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/roles": {
+            "get": {
+                "description": "List the built-in viewer and operator roles and the roles created in the console",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/accounts.Role"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a role granting the viewer or operator access level",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create role",
+                "parameters": [
+                    {
+                        "description": "Role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/accounts.Role"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.Role"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new role"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid role",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Role already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/roles/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.Role"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Change the description and the access level of a role, built-in roles can't be changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/accounts.Role"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.Role"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid role",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Built-in roles can't be deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Role is assigned to users",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users": {
+            "get": {
+                "description": "List the users managed in the console. Password hashes are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/accounts.User"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a user who logs in with basic authentication. The password is stored as a bcrypt hash.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.User"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new user"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.User"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Assign roles to a user and disable or enable their account. Disabled users can't log in and their sessions are ended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Roles and state",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/accounts.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid roles",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a user and end their sessions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/users/{name}/password": {
+            "put": {
+                "description": "Replace the password of a user, it is stored as a bcrypt hash. The sessions of the user are ended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analysis/hotkeys": {
+            "get": {
+                "description": "Report the most requested key prefixes of the key-value requests made through the console",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analysis"
+                ],
+                "summary": "Find hot keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only report keys of this table",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time window, e.g. 15m (default)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Separator of key segments (default /)",
+                        "name": "separator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of segments grouping keys (default 1)",
+                        "name": "depth",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of prefixes (default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/hotkeys.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analysis/read-verification": {
+            "get": {
+                "description": "Report the reads of the default cluster compared with a second replica and the mismatches found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analysis"
+                ],
+                "summary": "Get read verification counts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/armada.VerificationStats"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analytics": {
+            "get": {
+                "description": "Report whether usage analytics are enabled, so the frontend only records page views if they are",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get analytics status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.AnalyticsStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analytics/pageviews": {
+            "post": {
+                "description": "Count a page view of a console feature",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Record a page view",
+                "parameters": [
+                    {
+                        "description": "Viewed feature",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PageViewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid feature",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Analytics are disabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/approvals": {
+            "get": {
+                "description": "List the changes held back by guardrails that neither expired nor were used, oldest first. Granted approvals carry approvedBy and approvedAt.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "approvals"
+                ],
+                "summary": "List approvals",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.Approval"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/approvals/{id}": {
+            "delete": {
+                "description": "Remove a pending or granted approval, so the change can't be made with it. Guardrail admins may reject any approval, other users only their own.",
+                "tags": [
+                    "approvals"
+                ],
+                "summary": "Reject approval",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Not a guardrail admin nor the requesting user",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Approval not found or expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/approvals/{id}/approve": {
+            "post": {
+                "description": "Approve a change held back by a guardrail. Only guardrail admins may approve, and not their own requests. The requesting user then repeats the change with the approval ID in the X-Approval-ID header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "approvals"
+                ],
+                "summary": "Grant approval",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Approval ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.Approval"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a guardrail admin or own request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Approval not found or expired",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "List audit entries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. table.delete",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Resource, e.g. tables/users",
+                        "name": "resource",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the newest entry already seen",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries (default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/audit.Entry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit/export": {
+            "get": {
+                "description": "Export all audit entries including their snapshots as JSON lines, oldest first. Every entry carries the hash of its predecessor, so the export can be verified later with POST /api/audit/verify.",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Export audit log",
+                "responses": {
+                    "200": {
+                        "description": "Audit entries as JSON lines",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit/verify": {
+            "get": {
+                "description": "Verify that no audit entry was changed, inserted or removed since it was recorded by checking the hash chain of the log",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Verify audit log",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Verification"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Verify the hash chain of an export of the audit log, e.g. one archived for compliance",
+                "consumes": [
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Verify audit log export",
+                "parameters": [
+                    {
+                        "description": "Audit entries as JSON lines, as returned by GET /api/audit/export",
+                        "name": "export",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Verification"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid export",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit/{id}": {
+            "get": {
+                "description": "Get an audit entry including the state captured before the operation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Get audit entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Audit entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Entry"
+                        }
+                    },
+                    "404": {
+                        "description": "Audit entry not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/callback": {
+            "get": {
+                "description": "Called by the browser when the OIDC provider redirects it back, starts the session",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Complete login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the page the login started from"
+                    },
+                    "400": {
+                        "description": "Invalid or expired login",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Login failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/csrf": {
+            "get": {
+                "description": "Get the token to send in the X-CSRF-Token header with every change made with the session cookie",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get CSRF token",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.CSRFResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/login": {
+            "get": {
+                "description": "Redirect the browser to the OIDC provider to log in",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Console path to return to after the login",
+                        "name": "returnTo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the provider"
+                    }
+                }
+            }
+        },
+        "/api/auth/logout": {
+            "post": {
+                "description": "End the session, the response carries the URL to log out at the OIDC provider",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LogoutResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/me": {
+            "get": {
+                "description": "Get the logged-in user and their role",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.Me"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/session": {
+            "post": {
+                "description": "Start a session for the user of the basic authentication credentials, the response sets the session cookie",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start session",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/auth.Session"
+                        }
+                    },
+                    "400": {
+                        "description": "Sessions are started with basic authentication",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/sessions": {
+            "get": {
+                "description": "List the active sessions of the logged-in user with the devices they were started from",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/auth.SessionInfo"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/sessions/logout-others": {
+            "post": {
+                "description": "End all sessions of the logged-in user except the current one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out other sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/auth.EndedSessions"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/sessions/{handle}": {
+            "delete": {
+                "description": "End a session of the logged-in user, e.g. on a lost device",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "End session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session handle",
+                        "name": "handle",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/branding": {
+            "get": {
+                "description": "Get the title, logo, color palette and footer links the console is branded with",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "branding"
+                ],
+                "summary": "Get branding",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BrandingResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/branding/logo": {
+            "get": {
+                "description": "Get the logo the console is branded with",
+                "produces": [
+                    "image/png",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "branding"
+                ],
+                "summary": "Get logo",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "No logo configured",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cluster": {
+            "get": {
+                "description": "Get the members of the cluster and the node the console is connected to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cluster"
+                ],
+                "summary": "Get cluster info",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/armada.ClusterInfo"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get cluster info",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/cluster/history": {
+            "get": {
+                "description": "Get the cluster members and table leaders in effect at a time, or all snapshots between from and to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cluster"
+                ],
+                "summary": "Get topology history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Time of the single snapshot to return (RFC3339 or unix timestamp)",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range, one day before to by default (RFC3339 or unix timestamp)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, now by default (RFC3339 or unix timestamp)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/topology.Snapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "No topology was recorded at that time",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/clusters": {
+            "get": {
+                "description": "List the registered clusters; API requests select one with the cluster parameter, e.g. /api/tables?cluster=staging",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clusters"
+                ],
+                "summary": "List clusters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list clusters with all of these tags, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ClustersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tags",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/clusters/import": {
+            "post": {
+                "description": "Register clusters from a YAML or JSON list, either a list of clusters or an object with a clusters list. Only cluster import admins may import clusters. Every cluster is validated on its own: valid clusters are stored and connected after the next restart, the others are reported. With dryRun=true nothing is stored. Tokens must be references to secrets under the allowed prefixes, e.g. vault://armada/prod#token, and can't be combined with tls.insecureSkipVerify.",
+                "consumes": [
+                    "application/json",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clusters"
+                ],
+                "summary": "Import clusters",
+                "parameters": [
+                    {
+                        "description": "Clusters, at most 500",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.ImportCluster"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the clusters",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ClusterImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cluster list",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a cluster import admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Importing clusters is not enabled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/clusters/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clusters"
+                ],
+                "summary": "Get cluster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cluster name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cluster.Cluster"
+                        }
+                    },
+                    "404": {
+                        "description": "Cluster not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/clusters/{name}/defaults": {
+            "put": {
+                "description": "Replace the table and key prefixes the UI opens first for the cluster",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clusters"
+                ],
+                "summary": "Set cluster defaults",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cluster name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Defaults",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/cluster.Defaults"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cluster.Defaults"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid defaults",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Cluster not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/clusters/{name}/tags": {
+            "put": {
+                "description": "Replace the tags of the cluster, e.g. {\"env\": \"prod\", \"region\": \"eu\"}. Tags filter the fleet and are added as labels to the metrics scraped from then on. Tags set here last until the next restart, configured tags are set again then.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clusters"
+                ],
+                "summary": "Set cluster tags",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Cluster name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Tags",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/cluster.Tags"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/cluster.Tags"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tags",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Cluster not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conventions": {
+            "get": {
+                "description": "List the custom key conventions followed by the built-in ones, in the order keys are matched against them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conventions"
+                ],
+                "summary": "List key conventions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/conventions.Convention"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conventions/match": {
+            "get": {
+                "description": "Decode a key with the first convention it follows, e.g. the lock and lease of an etcd lock key",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conventions"
+                ],
+                "summary": "Match key against conventions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table of the key, for conventions restricted to a table",
+                        "name": "table",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key",
+                        "name": "key",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/conventions.Match"
+                        }
+                    },
+                    "404": {
+                        "description": "Key follows no convention",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conventions/usage": {
+            "get": {
+                "description": "Scan the first keys of a table and count the keys following each convention, with decoded samples, to tell which infrastructure components stored them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conventions"
+                ],
+                "summary": "Get key convention usage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table",
+                        "name": "table",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only scan keys with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of keys to scan, 1000 by default and at most 10000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/conventions.Summary"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/conventions/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conventions"
+                ],
+                "summary": "Get key convention",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Convention name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/conventions.Convention"
+                        }
+                    },
+                    "404": {
+                        "description": "Convention not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace a custom key convention. Its pattern lists the segments of the keys separated by /: literal segments, {field} for any segment, {field:hex} for a hexadecimal number and a final {field...} for the rest of the key. Placeholders in the description are replaced by the decoded fields.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conventions"
+                ],
+                "summary": "Put key convention",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Convention name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Convention, its name is taken from the path",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/conventions.Convention"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/conventions.Convention"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid convention",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Convention is built in",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conventions"
+                ],
+                "summary": "Delete key convention",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Convention name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Convention not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Convention is built in",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/debug/pool": {
+            "get": {
+                "description": "Report the Armada servers the console is connected to with their node IDs, addresses, connection states, reconnect counts and last errors, e.g. to find out why a server is marked unhealthy",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debug"
+                ],
+                "summary": "Get connection pool",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.PoolResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/debug/replay/{id}": {
+            "post": {
+                "description": "Re-execute a recorded API request against a cluster, capturing the response, timing and gRPC traces",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debug"
+                ],
+                "summary": "Replay recorded request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Target cluster",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/replay.ReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/replay.Result"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Request or cluster not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Request was redacted or truncated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Failed to connect to the cluster",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/debug/requests": {
+            "get": {
+                "description": "List the recently recorded API requests, most recent first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debug"
+                ],
+                "summary": "List recorded requests",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/replay.Request"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/debug/requests/{id}": {
+            "get": {
+                "description": "Get a recorded API request with its sanitized body",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debug"
+                ],
+                "summary": "Get recorded request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/replay.Request"
+                        }
+                    },
+                    "403": {
+                        "description": "The operator role is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Request not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/diagnostics": {
+            "get": {
+                "description": "Report clock skew, firing alert rules and other findings; findings covered by a maintenance window are listed as silenced. Findings and alerts carry the runbook URL and Markdown remediation of their check, stored runbooks replace those the checks ship with.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "diagnostics"
+                ],
+                "summary": "Get diagnostics report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.DiagnosticsReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/embed/sign": {
+            "post": {
+                "description": "Create a short-lived signed URL of a widget that can be embedded without logging in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "embed"
+                ],
+                "summary": "Sign a widget URL",
+                "parameters": [
+                    {
+                        "description": "Widget to sign",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/embed.SignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/embed.SignResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/fleet": {
+            "get": {
+                "description": "Summarize every registered cluster with its health, version range, node count, database size, firing alerts and scrape status, assembled from cached samples",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "clusters"
+                ],
+                "summary": "Get fleet summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list clusters with all of these tags, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.FleetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tags",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/indexes": {
+            "get": {
+                "description": "List the index tables with the state of their last build",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "List indexes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/indexes.Index"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create an index table __idx_\u003cname\u003e listing the keys of a table whose JSON value matches a filter. It is built in the background and rebuilt at the interval; key scans with an equivalent filter are answered from it once built.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "Create index",
+                "parameters": [
+                    {
+                        "description": "Index",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateIndexRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/indexes.Index"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new index"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid index",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Index or its table already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/indexes/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "Get index",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Index name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/indexes.Index"
+                        }
+                    },
+                    "404": {
+                        "description": "Index not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "Delete index",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Index name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Index not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/indexes/{name}/rebuild": {
+            "post": {
+                "description": "Rebuild an index in the background now instead of at its interval",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "Rebuild index",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Index name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/indexes.Index"
+                        }
+                    },
+                    "404": {
+                        "description": "Index not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/kv/{table}": {
+            "get": {
+                "description": "List up to 100 key-value pairs of a table, selected by a prefix or a range and optionally a filter of their JSON values. Scans exceeding the range timeout return the pairs received so far.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "kv"
+                ],
+                "summary": "List key-value pairs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First key of the range",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, exclusive",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Continuation cursor of a truncated scan with the same filter",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "auto",
+                            "decompress"
+                        ],
+                        "type": "string",
+                        "description": "Decode stored values",
+                        "name": "decode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "JSONPath of a field to return instead of the whole JSON value, e.g. $.user.name; repeat for further fields",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Predicate the JSON values must match, e.g. status=='active' \u0026\u0026 retries\u003e3",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format: json (default), or csv or xlsx to download a row per pair with its key and value, or its projected fields",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pairs, or ProjectedKeyValuePair items if fields are projected",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/armada.KeyValuePair"
+                            }
+                        },
+                        "headers": {
+                            "X-Continuation-Cursor": {
+                                "type": "string",
+                                "description": "Cursor continuing a truncated scan"
+                            },
+                            "X-Index": {
+                                "type": "string",
+                                "description": "Index the filtered scan was answered from"
+                            },
+                            "X-Truncated": {
+                                "type": "string",
+                                "description": "true if the scan timed out, or a filtered scan read its maximum of pairs"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter or path",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "504": {
+                        "description": "Timed out before any pair was received",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "kv"
+                ],
+                "summary": "Put key-value pair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated encodings applied before storing, e.g. base64,gzip, or preserve",
+                        "name": "transform",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the current value must match",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "* to only create the key",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Key-value pair",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/armada.KeyValuePair"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the stored value"
+                            }
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the stored value"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "Table is read-only",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a single key, or all keys with a prefix",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "kv"
+                ],
+                "summary": "Delete keys",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to delete",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefix of the keys to delete",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the current value must match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of deleted keys, if deleted by prefix",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeletePrefixResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Key not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Table is protected",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "Table is read-only",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Count the keys selected by the filter with a count-only range and estimate their size from a sample",
+                "tags": [
+                    "kv"
+                ],
+                "summary": "Estimate key scan",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "First key of the range",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range, exclusive",
+                        "name": "end",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estimate in the X-Estimated-Keys and X-Estimated-Bytes headers",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/kv/{table}/{key}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "kv"
+                ],
+                "summary": "Get key-value pair",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "table",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "auto",
+                            "decompress"
+                        ],
+                        "type": "string",
+                        "description": "Decode the stored value",
+                        "name": "decode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached value",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/armada.KeyValuePair"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "ETag of the value"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Value unchanged"
+                    },
+                    "404": {
+                        "description": "Key not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Key was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/locks": {
+            "get": {
+                "description": "List the locks and elections of a table whose keys follow a lock or election convention, with their holder and waiters. The contender created first holds the lock; observedSince is when the console first saw a contender in its position.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locks"
+                ],
+                "summary": "Inspect locks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table",
+                        "name": "table",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only inspect keys with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/locks.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Table is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/locks/release": {
+            "post": {
+                "description": "Delete the key holding a lock or leading an election, handing it to the next waiter, e.g. when its holder died without its lease expiring. The key must be repeated in confirm and still hold the lock at the inspected create revision.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locks"
+                ],
+                "summary": "Force release lock",
+                "parameters": [
+                    {
+                        "description": "Lock holder",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReleaseLockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Released holder",
+                        "schema": {
+                            "$ref": "#/definitions/locks.Contender"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Lock key not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Key doesn't hold the lock",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/maintenance": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "List maintenance windows",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC3339 or unix timestamp)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC3339 or unix timestamp)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return the windows in effect now",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/maintenance.Window"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "Schedule maintenance window",
+                "parameters": [
+                    {
+                        "description": "Maintenance window",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/maintenance.Window"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/maintenance.Window"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new window"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid maintenance window",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/maintenance/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "Get maintenance window",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Window ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/maintenance.Window"
+                        }
+                    },
+                    "404": {
+                        "description": "Maintenance window not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel a maintenance window or end it early",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "maintenance"
+                ],
+                "summary": "Delete maintenance window",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Window ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Maintenance window not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/batch_query": {
+            "post": {
+                "description": "Evaluate many instant and range queries in one request, e.g. all panels of a dashboard. The queries are evaluated concurrently and share a deadline; every query succeeds or fails on its own and its result is returned under its ID. A query is a range query when start or end is given.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Batch query",
+                "parameters": [
+                    {
+                        "description": "Queries, at most 100",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/metrics.BatchQueryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.BatchQueryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/heatmap": {
+            "get": {
+                "description": "Convert the bucket series of a histogram into a time × bucket matrix of per-second rates. Buckets are merged by their numeric upper bound across series and de-cumulated, so each cell counts the observations between the previous bound and its own. Times without samples are 0.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Histogram heatmap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Histogram name, with or without the _bucket suffix, e.g. armada_request_duration_seconds",
+                        "name": "metric",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Label matchers restricting the bucket series, e.g. {method=\\",
+                        "name": "selector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rate window (default: 5m)",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start timestamp (RFC3339 or unix timestamp)",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End timestamp (RFC3339 or unix timestamp)",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query resolution step width (default: 1m)",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict the histogram to these clusters, e.g. a:5001,b:5001",
+                        "name": "clusters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict the histogram to clusters with these tags, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.HeatmapResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/query": {
+            "get": {
+                "description": "Execute a PromQL query against stored metrics at a specific time",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Query stored metrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PromQL query to execute, required unless panel is given",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of a saved panel whose query is executed, its unit and thresholds are returned in annotations",
+                        "name": "panel",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query evaluation timestamp (RFC3339 or unix timestamp)",
+                        "name": "time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict every selector of the query to these clusters, e.g. a:5001,b:5001",
+                        "name": "clusters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict every selector of the query to clusters with these tags, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Aggregate the result by these labels, e.g. cluster",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Aggregation of groupBy: sum (default), avg, min, max or count",
+                        "name": "aggregate",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format: json (default), or csv or xlsx to download a row per sample with its timestamp, labels and value",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.QueryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Panel not found",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/query_range": {
+            "get": {
+                "description": "Execute a PromQL query against stored metrics over a specified time range",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Query stored metrics over a time range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PromQL query to execute, required unless panel is given",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of a saved panel whose query is executed, its unit and thresholds are returned in annotations",
+                        "name": "panel",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start timestamp (RFC3339 or unix timestamp)",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End timestamp (RFC3339 or unix timestamp)",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query resolution step width in duration format (e.g. 15s, 1m, 1h) or seconds (default: 1m)",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Also return the result this far back, aligned to the range, e.g. 24h or 7d",
+                        "name": "compareOffset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict every selector of the query to these clusters, e.g. a:5001,b:5001",
+                        "name": "clusters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict every selector of the query to clusters with these tags, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Aggregate the result by these labels, e.g. cluster",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Aggregation of groupBy: sum (default), avg, min, max or count",
+                        "name": "aggregate",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "Response format: json (default), or csv or xlsx to download a row per sample with its timestamp, labels and value",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.QueryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Panel not found",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/suggest": {
+            "get": {
+                "description": "Suggest PromQL queries suited to the type and labels of a stored metric",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Suggest queries for a metric",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Metric name",
+                        "name": "metric",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.Suggestions"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/targets": {
+            "get": {
+                "description": "Returns the health of the last scrape of every cluster and how long failing clusters are backed off",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "List scrape targets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/metrics.Target"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/metrics/topk": {
+            "get": {
+                "description": "Evaluate a PromQL query and return only its K highest series, with the remaining series summed up in other. Instant queries are ranked by their value; range queries, given start and end, by the average of their samples.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Top K series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "PromQL query returning series",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of series to return (default: 10, at most 1000)",
+                        "name": "k",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Evaluation timestamp of an instant query (RFC3339 or unix timestamp)",
+                        "name": "time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start timestamp of a range query (RFC3339 or unix timestamp)",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End timestamp of a range query (RFC3339 or unix timestamp)",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Query resolution step width of a range query (default: 1m)",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict every selector of the query to these clusters, e.g. a:5001,b:5001",
+                        "name": "clusters",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Restrict every selector of the query to clusters with these tags, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.TopKResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/navigation": {
+            "get": {
+                "description": "Get the menu of the console with the pages of the enabled features the caller may use, so the frontend shows exactly what is accessible",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "navigation"
+                ],
+                "summary": "Get navigation",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.NavigationResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/panels": {
+            "get": {
+                "description": "List the saved metric queries with the unit and thresholds of their values",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "panels"
+                ],
+                "summary": "List panels",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/panels.Panel"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/panels/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "panels"
+                ],
+                "summary": "Get panel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Panel name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/panels.Panel"
+                        }
+                    },
+                    "404": {
+                        "description": "Panel not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace a saved metric query. Its unit, e.g. seconds or bytes, and its warning and critical thresholds are returned as annotations by /api/metrics/query and /api/metrics/query_range when they are given the panel name, so every chart of the query renders the same axes and colors. Values above the thresholds are bad unless the direction is below.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "panels"
+                ],
+                "summary": "Put panel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Panel name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Panel, its name is taken from the path",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/panels.Panel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/panels.Panel"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid panel",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "panels"
+                ],
+                "summary": "Delete panel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Panel name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Panel not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/rpc": {
+            "get": {
+                "description": "Subscribe to cluster events and query the API with JSON-RPC 2.0 messages over a WebSocket",
+                "tags": [
+                    "rpc"
+                ],
+                "summary": "JSON-RPC over WebSocket",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    }
+                }
+            }
+        },
+        "/api/runbooks": {
+            "get": {
+                "description": "List the stored runbooks of alert rules and diagnostic checks. Checks without a stored runbook report the one they ship with, if any.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runbooks"
+                ],
+                "summary": "List runbooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/runbooks.Runbook"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/runbooks/{check}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runbooks"
+                ],
+                "summary": "Get runbook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Alert rule or diagnostic check, e.g. leader-flapping",
+                        "name": "check",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/runbooks.Runbook"
+                        }
+                    },
+                    "404": {
+                        "description": "Runbook not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Create or replace the runbook of an alert rule or diagnostic check: a link to the runbook, e.g. in the team's wiki, and first steps in Markdown. Findings and alerts of the check in /api/diagnostics carry it instead of the runbook the check ships with.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runbooks"
+                ],
+                "summary": "Put runbook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Alert rule or diagnostic check, e.g. leader-flapping",
+                        "name": "check",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Runbook, its check is taken from the path",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/runbooks.Runbook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/runbooks.Runbook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid runbook",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove the stored runbook of a check, its findings carry the runbook the check ships with again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runbooks"
+                ],
+                "summary": "Delete runbook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Alert rule or diagnostic check, e.g. leader-flapping",
+                        "name": "check",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Runbook not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/servers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cluster"
+                ],
+                "summary": "List servers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/armada.Server"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get servers",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/servers/{id}/connection": {
+            "delete": {
+                "description": "Close the connection to a server and forget its addresses and reconnect history. The server is connected to again when it is used next or the cluster members are discovered again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debug"
+                ],
+                "summary": "Drop server connection",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Server ID, or its address if the ID isn't known",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dropped server",
+                        "schema": {
+                            "$ref": "#/definitions/armada.ServerInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Server not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/servers/{id}/reconnect": {
+            "post": {
+                "description": "Dial a server again and replace its connection once it answers, e.g. after a certificate rotation. Failed attempts are retried up to ARMADA_RECONNECT_MAX_RETRIES times with a growing delay; the old connection is kept if the server can't be reached.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "debug"
+                ],
+                "summary": "Reconnect to server",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Server ID, or its address if the ID isn't known",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/armada.ServerInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "Server not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Server unreachable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/servers/{id}/resources": {
+            "get": {
+                "description": "Summarize CPU, memory, disk, file descriptor and goroutine usage of a node from stored metrics",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Node resource utilization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Node ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ServerResources"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/metrics.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/shadow": {
+            "get": {
+                "description": "Report the writes mirrored to the secondary cluster and the writes that couldn't be mirrored",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shadow"
+                ],
+                "summary": "Get shadowing report",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/shadow.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/shadow/failures": {
+            "delete": {
+                "description": "Discard the writes that couldn't be mirrored, e.g. after syncing the secondary cluster otherwise",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shadow"
+                ],
+                "summary": "Discard failed mirrored writes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ShadowClearResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/shadow/retry": {
+            "post": {
+                "description": "Queue the writes that couldn't be mirrored again, after the writes made since",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shadow"
+                ],
+                "summary": "Retry failed mirrored writes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ShadowRetryResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/snapshots": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "snapshots"
+                ],
+                "summary": "List snapshots",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/snapshots.Summary"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Evaluate the queries of a dashboard like /api/metrics/batch_query and store their results with the queries and time range they came from. Snapshots can't be changed, so a shared link shows exactly what its author saw, even after the metrics left the retention. Queries that fail are stored with their error.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "snapshots"
+                ],
+                "summary": "Create snapshot",
+                "parameters": [
+                    {
+                        "description": "Queries of the dashboard, at most 100",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateSnapshotRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/snapshots.Snapshot"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new snapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid snapshot",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/snapshots/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "snapshots"
+                ],
+                "summary": "Get snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/snapshots.Snapshot"
+                        }
+                    },
+                    "404": {
+                        "description": "Snapshot not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "snapshots"
+                ],
+                "summary": "Delete snapshot",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Snapshot ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Snapshot not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/status": {
+            "get": {
+                "description": "Get the status of every server of the cluster. Servers that can't be reached are reported with an error and mark the response as partial.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cluster"
+                ],
+                "summary": "Get cluster status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Revision of a previous response, only the servers changed since are returned",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Tags the cluster must have, e.g. env=prod,region=eu",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tags",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Cluster does not have the tags",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get servers",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tables": {
+            "get": {
+                "description": "List the tables with their sampled statistics and annotations",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "List tables",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the table name",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "size"
+                        ],
+                        "type": "string",
+                        "description": "Sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, all tables by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of tables to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.TableListItem"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the adjacent pages"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching tables"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get tables",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Create table",
+                "parameters": [
+                    {
+                        "description": "Table to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateTableRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateTableResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "Path of the new table"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Table already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "Writes are frozen by a maintenance window",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tables/{name}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Get table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TableListItem"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Table was deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a table, its state is kept with the audit entry",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Delete table",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Table is protected",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "423": {
+                        "description": "Table is read-only",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tables/{name}/growth": {
+            "get": {
+                "description": "Report the daily key counts and sizes of a table with their changes, the key prefixes that gained the most keys and when the table reaches the size cap at its current growth. The history is kept for the metrics retention.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Get table growth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of days, including today (default 7)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of prefixes (default 10)",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size cap in bytes, overrides GROWTH_SIZE_CAP",
+                        "name": "cap",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/growth.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Growth is not recorded for this cluster",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tables/{name}/metadata": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Update table metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Purpose, and creator and creation time of tables not created through the console",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.TableMetadataRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TableMetadata"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tables/{name}/protection": {
+            "put": {
+                "description": "PUT protects the table from being deleted, also by prefix, DELETE removes the protection",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Protect table from deletion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TableMetadata"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "PUT protects the table from being deleted, also by prefix, DELETE removes the protection",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Protect table from deletion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TableMetadata"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tables/{name}/read-only": {
+            "put": {
+                "description": "PUT makes the console refuse writes to the table with 423 Locked, DELETE makes it writable again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Make table read-only",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TableMetadata"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "PUT makes the console refuse writes to the table with 423 Locked, DELETE makes it writable again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tables"
+                ],
+                "summary": "Make table read-only",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Table name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TableMetadata"
+                        }
+                    },
+                    "404": {
+                        "description": "Table not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the console process is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Report whether the console has a healthy connection to the Armada cluster and its metrics storage is open",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "accounts.Role": {
+            "type": "object",
+            "properties": {
+                "builtIn": {
+                    "description": "BuiltIn roles can't be changed or deleted.",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "grants": {
+                    "description": "Grants is the access level of the users with the role.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/auth.Role"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "accounts.User": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "disabled": {
+                    "description": "Disabled users can't log in.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "Roles are the names of the roles assigned to the user. The user has the highest access\nlevel granted by any of them.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.Count": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "analytics.DayTotals": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "pageViews": {
+                    "type": "integer"
+                },
+                "queries": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analytics.Report": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "Days are the totals of the days with any usage, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.DayTotals"
+                    }
+                },
+                "from": {
+                    "description": "From and To are the first and last day of the report",
+                    "type": "string"
+                },
+                "pageViews": {
+                    "description": "PageViews are the page views by feature, most viewed first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.Count"
+                    }
+                },
+                "queries": {
+                    "description": "Queries are the API requests by route, most requested first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analytics.Count"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.AnalyticsStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.Approval": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "approvedAt": {
+                    "type": "string"
+                },
+                "approvedBy": {
+                    "description": "ApprovedBy is the principal of the admin who granted the approval",
+                    "type": "string"
+                },
+                "cluster": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "requestedAt": {
+                    "type": "string"
+                },
+                "target": {
+                    "description": "Target is the path and query of the request, its parameters sorted",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "User is the principal requesting the change as method:name, e.g. oidc:jane@example.com",
+                    "type": "string"
+                }
+            }
+        },
+        "api.BrandingResponse": {
+            "type": "object",
+            "properties": {
+                "colors": {
+                    "description": "Colors maps palette names such as primary to the colors replacing the built-in ones",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "footerLinks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.FooterLink"
+                    }
+                },
+                "logoUrl": {
+                    "description": "LogoURL is where the logo is served, empty if no logo is configured",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ClusterImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Created is the number of clusters imported, they are connected after the next restart",
+                    "type": "integer"
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ClusterImportResult"
+                    }
+                }
+            }
+        },
+        "api.ClusterImportResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is created, valid for dry runs, exists or invalid",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ClustersResponse": {
+            "type": "object",
+            "properties": {
+                "clusters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/cluster.Cluster"
+                    }
+                },
+                "default": {
+                    "description": "Default is the cluster serving requests without the cluster parameter",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ConfigResponse": {
+            "type": "object",
+            "properties": {
+                "file": {
+                    "type": "string"
+                },
+                "pending": {
+                    "description": "Pending are the keys of the settings changed by a reload that aren't in effect, because\nthey require a restart or failed to apply",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "settings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/config.Setting"
+                    }
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.CreateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "description": "ExpiresIn is a duration like 720h after which the key stops working, it works until revoked if empty.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/auth.Role"
+                }
+            }
+        },
+        "api.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is when the key stops working, it works until revoked if nil.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "description": "LastUsedAt is when the key was last used, up to a few minutes ago.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name identifies the client using the key, e.g. in the audit log.",
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role is the access level granted by the key.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/auth.Role"
+                        }
+                    ]
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateIndexRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "type": "string"
+                },
+                "interval": {
+                    "description": "Interval is how often the index is rebuilt, e.g. 1h; 15m if empty.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateSnapshotRequest": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.SnapshotQuery"
+                    }
+                },
+                "start": {
+                    "description": "Start, End and Step are the range of range queries",
+                    "type": "string"
+                },
+                "step": {
+                    "type": "string"
+                },
+                "time": {
+                    "description": "Time is the evaluation time of instant queries",
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "Timeout is the deadline shared by all queries, e.g. 10s, at most the query timeout",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateTableRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "purpose": {
+                    "description": "Purpose optionally describes what the table is used for",
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateTableResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.DeletePrefixResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.DiagnosticFinding": {
+            "type": "object",
+            "properties": {
+                "check": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "remediation": {
+                    "description": "Remediation are first steps in Markdown, if any",
+                    "type": "string"
+                },
+                "runbookUrl": {
+                    "description": "RunbookURL links to the runbook of the check, if any",
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "silencedBy": {
+                    "description": "SilencedBy is the ID of the maintenance window silencing the finding, if any",
+                    "type": "string"
+                },
+                "subject": {
+                    "description": "Subject is the server or resource the finding is about",
+                    "type": "string"
+                }
+            }
+        },
+        "api.DiagnosticsReport": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "description": "Alerts are the firing alerts of the configured alert rules",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.Alert"
+                    }
+                },
+                "clockSkew": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.ClockSkew"
+                    }
+                },
+                "findings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DiagnosticFinding"
+                    }
+                },
+                "generatedAt": {
+                    "type": "string"
+                },
+                "silenced": {
+                    "description": "Silenced are the findings expected during an active maintenance window",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DiagnosticFinding"
+                    }
+                }
+            }
+        },
+        "api.FleetCluster": {
+            "type": "object",
+            "properties": {
+                "dbSize": {
+                    "description": "DBSize is the total size of the databases of all tables in bytes",
+                    "type": "integer"
+                },
+                "firingAlerts": {
+                    "type": "integer"
+                },
+                "health": {
+                    "description": "Health is one of healthy, degraded, unreachable or unknown",
+                    "type": "string"
+                },
+                "maxVersion": {
+                    "type": "string"
+                },
+                "minVersion": {
+                    "description": "MinVersion and MaxVersion are the oldest and newest Armada version the servers run,\nthey differ during rolling upgrades",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nodes": {
+                    "description": "Nodes is the number of cluster members, NodesUp the number of those that answered",
+                    "type": "integer"
+                },
+                "nodesUp": {
+                    "type": "integer"
+                },
+                "sampledAt": {
+                    "description": "SampledAt is when the state of the cluster was last sampled",
+                    "type": "string"
+                },
+                "scrape": {
+                    "$ref": "#/definitions/api.FleetScrape"
+                },
+                "tables": {
+                    "type": "integer"
+                },
+                "tags": {
+                    "$ref": "#/definitions/cluster.Tags"
+                }
+            }
+        },
+        "api.FleetResponse": {
+            "type": "object",
+            "properties": {
+                "clusters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.FleetCluster"
+                    }
+                },
+                "generatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.FleetScrape": {
+            "type": "object",
+            "properties": {
+                "down": {
+                    "type": "integer"
+                },
+                "lastScrape": {
+                    "description": "LastScrape is the most recent scrape of any target of the cluster",
+                    "type": "string"
+                },
+                "unknown": {
+                    "type": "integer"
+                },
+                "up": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.FooterLink": {
+            "type": "object",
+            "properties": {
+                "label": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Checks maps the name of every readiness check to \"ok\" or its error",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "description": "Status is \"ok\" or \"unavailable\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ImportCluster": {
+            "type": "object",
+            "properties": {
+                "defaults": {
+                    "$ref": "#/definitions/cluster.Defaults"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "seeds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "$ref": "#/definitions/cluster.Tags"
+                },
+                "tls": {
+                    "$ref": "#/definitions/cluster.TLS"
+                },
+                "token": {
+                    "description": "Token is a reference to the bearer token of the cluster, e.g. vault://armada/prod#token",
+                    "type": "string"
+                }
+            }
+        },
+        "api.NavigationItem": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.NavigationItem"
+                    }
+                },
+                "icon": {
+                    "description": "Icon names the icon of the item, the frontend falls back to a generic one for unknown names",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID identifies the item, e.g. to keep it expanded across reloads",
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path is the route of the page in the console, empty for groups of items",
+                    "type": "string"
+                }
+            }
+        },
+        "api.NavigationResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.NavigationItem"
+                    }
+                },
+                "readOnly": {
+                    "description": "ReadOnly is set when the console refuses all changes, whatever the role",
+                    "type": "boolean"
+                },
+                "role": {
+                    "$ref": "#/definitions/auth.Role"
+                }
+            }
+        },
+        "api.PageViewRequest": {
+            "type": "object",
+            "properties": {
+                "feature": {
+                    "description": "Feature is the name of the viewed feature, e.g. \"data\" or \"settings\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.PasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PoolResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the address the console connects to the cluster through",
+                    "type": "string"
+                },
+                "healthyConnections": {
+                    "description": "HealthyConnections is the number of connections that are ready or idle and passed their last health check",
+                    "type": "integer"
+                },
+                "servers": {
+                    "description": "Servers are the servers the console knows, by ID",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/armada.ServerInfo"
+                    }
+                }
+            }
+        },
+        "api.ReleaseLockRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "Confirm must repeat the key, as releasing a lock held by a live process breaks mutual exclusion",
+                    "type": "string"
+                },
+                "createRevision": {
+                    "description": "CreateRevision is the create revision of the holder as inspected, the release is refused if\nthe key was created again since",
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ServerStatus": {
+            "type": "object",
+            "properties": {
+                "config": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "error": {
+                    "description": "Error is set when the status of the server could not be retrieved",
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/armada.TableStatus"
+                    }
+                }
+            }
+        },
+        "api.ShadowClearResponse": {
+            "type": "object",
+            "properties": {
+                "cleared": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ShadowRetryResponse": {
+            "type": "object",
+            "properties": {
+                "retried": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.SnapshotQuery": {
+            "type": "object",
+            "properties": {
+                "aggregate": {
+                    "description": "Aggregate is the aggregation of groupBy: sum (default), avg, min, max or count",
+                    "type": "string"
+                },
+                "clusters": {
+                    "description": "Clusters restricts every selector of the query to these clusters",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "groupBy": {
+                    "description": "GroupBy aggregates the result by these labels",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "ID identifies the result of the query in the response, unique within the batch",
+                    "type": "string"
+                },
+                "panel": {
+                    "description": "Panel is the name of a saved panel whose query is executed",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is the PromQL query, required unless panel is given",
+                    "type": "string"
+                },
+                "start": {
+                    "description": "Start and End are the timestamps of a range query (RFC3339 or unix timestamp)",
+                    "type": "string"
+                },
+                "step": {
+                    "description": "Step is the resolution of a range query, e.g. 15s or 1m (default: 1m)",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags restricts every selector of the query to clusters with these tags, e.g. {\"env\": \"prod\"}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "time": {
+                    "description": "Time is the evaluation timestamp of an instant query (RFC3339 or unix timestamp), now if empty",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "delta": {
+                    "description": "Delta is true when Servers only contains the servers changed since the requested revision",
+                    "type": "boolean"
+                },
+                "partial": {
+                    "description": "Partial is true when the status of at least one server could not be retrieved",
+                    "type": "boolean"
+                },
+                "removed": {
+                    "description": "Removed lists the IDs of servers that left the cluster since the requested revision",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "revision": {
+                    "description": "Revision identifies this state, pass it as since to only get the servers changed afterwards",
+                    "type": "string"
+                },
+                "servers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ServerStatus"
+                    }
+                },
+                "suggestedRefreshSeconds": {
+                    "description": "SuggestedRefreshSeconds hints how long clients should wait before polling again",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "Tags are the tags of the cluster",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/cluster.Tags"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.TableListItem": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID is the unique identifier of the table.",
+                    "type": "string"
+                },
+                "maintenanceWindow": {
+                    "description": "MaintenanceWindow is the ID of the window freezing writes, if any",
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "Metadata are the console-side annotations, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.TableMetadata"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name is the name of the table.",
+                    "type": "string"
+                },
+                "readOnly": {
+                    "description": "ReadOnly is set while the console refuses writes, because the table is marked\nread-only or its writes are frozen by a maintenance window",
+                    "type": "boolean"
+                },
+                "stats": {
+                    "description": "Stats are the latest sampled statistics, if available",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/stats.TableStats"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.TableMetadata": {
+            "type": "object",
+            "properties": {
+                "backfilled": {
+                    "description": "Backfilled is true when the annotations were added after the table was created",
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "description": "CreatedAt is when the table was created, zero if unknown",
+                    "type": "string"
+                },
+                "createdBy": {
+                    "description": "CreatedBy is the user who created the table through the console",
+                    "type": "string"
+                },
+                "protected": {
+                    "description": "Protected tables can't be deleted, nor can keys be deleted from them by prefix",
+                    "type": "boolean"
+                },
+                "purpose": {
+                    "description": "Purpose describes what the table is used for",
+                    "type": "string"
+                },
+                "readOnly": {
+                    "description": "ReadOnly tables refuse writes through the console, e.g. during a migration,\neven if the cluster permits them",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.TableMetadataRequest": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "purpose": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "apikeys.Key": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt is when the key stops working, it works until revoked if nil.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "description": "LastUsedAt is when the key was last used, up to a few minutes ago.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name identifies the client using the key, e.g. in the audit log.",
+                    "type": "string"
+                },
+                "role": {
+                    "description": "Role is the access level granted by the key.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/auth.Role"
+                        }
+                    ]
+                }
+            }
+        },
+        "armada.ClusterInfo": {
+            "type": "object",
+            "properties": {
+                "members": {
+                    "description": "Members is a list of all servers in the cluster.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/armada.Server"
+                    }
+                },
+                "nodeAddress": {
+                    "description": "NodeAddress is the address of the current node.",
+                    "type": "string"
+                },
+                "nodeId": {
+                    "description": "NodeID is the ID of the current node.",
+                    "type": "string"
+                }
+            }
+        },
+        "armada.KeyValuePair": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "description": "Key is the key of the pair.",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Value is the value associated with the key.",
+                    "type": "string"
+                }
+            }
+        },
+        "armada.Server": {
+            "type": "object",
+            "properties": {
+                "clientURLs": {
+                    "description": "ClientURLs is the list of URLs the server exposes to clients for communication.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "ID is the unique identifier of the server.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is the human-readable name of the server.",
+                    "type": "string"
+                },
+                "peerURLs": {
+                    "description": "PeerURLs is the list of URLs the server exposes to the cluster for communication.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "armada.ServerInfo": {
+            "type": "object",
+            "properties": {
+                "addresses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "circuit": {
+                    "description": "Circuit is the state of the circuit breaker of the server: closed, open or half-open, the\nworst of its addresses. It is empty without a circuit breaker.",
+                    "type": "string"
+                },
+                "circuitOpenUntil": {
+                    "description": "CircuitOpenUntil is when an open circuit lets a trial call through",
+                    "type": "string"
+                },
+                "connectionState": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "description": "LastError is the last error connecting to or discovering through the server, empty if there was none",
+                    "type": "string"
+                },
+                "lastErrorAt": {
+                    "description": "LastErrorAt is when LastError happened",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "primaryAddress": {
+                    "type": "string"
+                },
+                "probeError": {
+                    "description": "ProbeError is the error of the last failed health check, empty if the server answered it",
+                    "type": "string"
+                },
+                "reconnects": {
+                    "description": "Reconnects counts the connections made to the server after the first one, over all its addresses",
+                    "type": "integer"
+                }
+            }
+        },
+        "armada.TableStatus": {
+            "type": "object",
+            "properties": {
+                "dbSize": {
+                    "description": "DBSize is the size of the backend database physically allocated in bytes.",
+                    "type": "integer"
+                },
+                "leader": {
+                    "description": "Leader is the member ID which the responding member believes is the current leader.",
+                    "type": "string"
+                },
+                "logSize": {
+                    "description": "LogSize is the size of the raft log in bytes.",
+                    "type": "integer"
+                },
+                "raftAppliedIndex": {
+                    "description": "RaftAppliedIndex is the current raft applied index.",
+                    "type": "integer"
+                },
+                "raftIndex": {
+                    "description": "RaftIndex is the current raft committed index.",
+                    "type": "integer"
+                },
+                "raftTerm": {
+                    "description": "RaftTerm is the current raft term.",
+                    "type": "integer"
+                }
+            }
+        },
+        "armada.VerificationStats": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "description": "Checked is the number of reads compared with a second replica",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Failed is the number of sampled reads that couldn't be compared, e.g. without a second replica",
+                    "type": "integer"
+                },
+                "lastMismatchAt": {
+                    "description": "LastMismatchAt is when the last mismatch was found, nil if none was",
+                    "type": "string"
+                },
+                "mismatches": {
+                    "description": "Mismatches is the number of compared reads whose responses differed",
+                    "type": "integer"
+                }
+            }
+        },
+        "audit.Entry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action names the operation, e.g. \"table.delete\".",
+                    "type": "string"
+                },
+                "details": {
+                    "description": "Details holds operation specific parameters.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "description": "Error describes why the operation failed or was denied.",
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "Hash covers the entry including PrevHash, it is assigned by the log.",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is assigned by the log in increasing order.",
+                    "type": "integer"
+                },
+                "outcome": {
+                    "description": "Outcome is one of OutcomeSuccess, OutcomeFailure or OutcomeDenied.",
+                    "type": "string"
+                },
+                "prevHash": {
+                    "description": "PrevHash is the hash of the previous entry, empty for the first one.",
+                    "type": "string"
+                },
+                "resource": {
+                    "description": "Resource identifies the affected resource, e.g. \"tables/users\".",
+                    "type": "string"
+                },
+                "snapshot": {
+                    "description": "Snapshot is the state captured before a destructive operation, if any.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "time": {
+                    "description": "Time is when the operation finished.",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "User is the user who performed the operation.",
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Verification": {
+            "type": "object",
+            "properties": {
+                "brokenAt": {
+                    "description": "BrokenAt is the ID of the first entry whose hash or link doesn't match",
+                    "type": "integer"
+                },
+                "entries": {
+                    "description": "Entries is the number of verified entries",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Error describes why the chain is broken",
+                    "type": "string"
+                },
+                "firstId": {
+                    "description": "FirstID and LastID are the IDs of the first and the last entry. Entries before the first\none may have been removed by retention, the chain is verified from there on.",
+                    "type": "integer"
+                },
+                "lastHash": {
+                    "description": "LastHash is the hash of the last entry. Keeping it elsewhere detects the removal of\nentries from the end of the log.",
+                    "type": "string"
+                },
+                "lastId": {
+                    "type": "integer"
+                },
+                "unchained": {
+                    "description": "Unchained is the number of leading entries without a hash, e.g. recorded before the log\nwas chained. Their integrity can't be verified, so the log doesn't verify until retention\nremoved them.",
+                    "type": "integer"
+                },
+                "valid": {
+                    "description": "Valid is true if all entries are chained and their hashes match and link to their\npredecessor",
+                    "type": "boolean"
+                }
+            }
+        },
+        "auth.CSRFResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "description": "Token must be sent in the X-CSRF-Token header with every change, it is empty for\nrequests that aren't authenticated by a session",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.EndedSessions": {
+            "type": "object",
+            "properties": {
+                "ended": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auth.LogoutResponse": {
+            "type": "object",
+            "properties": {
+                "logoutUrl": {
+                    "description": "LogoutURL ends the session at the provider, empty if there is none to end",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.Me": {
+            "type": "object",
+            "properties": {
+                "authenticated": {
+                    "description": "Authenticated is false when authentication is disabled",
+                    "type": "boolean"
+                },
+                "displayName": {
+                    "description": "DisplayName is a readable name to show, e.g. the preferred username of an OIDC login. Users\nmay be able to change it, so it must never be used to authorize them.",
+                    "type": "string"
+                },
+                "groups": {
+                    "description": "Groups are the groups of the user reported by the identity provider, if any.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "description": "Method is how the user authenticated, e.g. MethodBasic.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name identifies the user, e.g. a login name or the subject of a token.",
+                    "type": "string"
+                },
+                "readOnly": {
+                    "description": "ReadOnly is true when the console refuses all changes, whatever the role",
+                    "type": "boolean"
+                },
+                "role": {
+                    "description": "Role is what the user may do, empty if roles are not enforced",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/auth.Role"
+                        }
+                    ]
+                }
+            }
+        },
+        "auth.Role": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "operator"
+            ],
+            "x-enum-varnames": [
+                "RoleViewer",
+                "RoleOperator"
+            ]
+        },
+        "auth.Session": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "expires": {
+                    "description": "Expires is when the session ends regardless of its use.",
+                    "type": "string"
+                },
+                "handle": {
+                    "description": "Handle identifies the session when listing or ending it, it is derived from the ID.",
+                    "type": "string"
+                },
+                "lastSeen": {
+                    "description": "LastSeen is when the session was last used, up to a minute ago.",
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/auth.User"
+                },
+                "userAgent": {
+                    "description": "UserAgent and Address describe the device the session was started from.",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.SessionInfo": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "created": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current marks the session of the request",
+                    "type": "boolean"
+                },
+                "expires": {
+                    "description": "Expires is when the session ends regardless of its use.",
+                    "type": "string"
+                },
+                "handle": {
+                    "description": "Handle identifies the session when listing or ending it, it is derived from the ID.",
+                    "type": "string"
+                },
+                "lastSeen": {
+                    "description": "LastSeen is when the session was last used, up to a minute ago.",
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/auth.User"
+                },
+                "userAgent": {
+                    "description": "UserAgent and Address describe the device the session was started from.",
+                    "type": "string"
+                }
+            }
+        },
+        "auth.User": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "description": "DisplayName is a readable name to show, e.g. the preferred username of an OIDC login. Users\nmay be able to change it, so it must never be used to authorize them.",
+                    "type": "string"
+                },
+                "groups": {
+                    "description": "Groups are the groups of the user reported by the identity provider, if any.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "method": {
+                    "description": "Method is how the user authenticated, e.g. MethodBasic.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name identifies the user, e.g. a login name or the subject of a token.",
+                    "type": "string"
+                }
+            }
+        },
+        "cluster.Cluster": {
+            "type": "object",
+            "properties": {
+                "defaults": {
+                    "description": "Defaults are the starting points of the UI for this cluster.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/cluster.Defaults"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name identifies the cluster in the API.",
+                    "type": "string"
+                },
+                "seeds": {
+                    "description": "Seeds are the addresses used to connect to the cluster.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tags": {
+                    "description": "Tags label the cluster, e.g. env=prod, to filter clusters and their metrics by.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/cluster.Tags"
+                        }
+                    ]
+                }
+            }
+        },
+        "cluster.Defaults": {
+            "type": "object",
+            "properties": {
+                "keyPrefixes": {
+                    "description": "KeyPrefixes are the prefix filters offered by default when browsing keys.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "table": {
+                    "description": "Table is the table opened by default, e.g. in the KV browser.",
+                    "type": "string"
+                }
+            }
+        },
+        "cluster.TLS": {
+            "type": "object",
+            "properties": {
+                "caFile": {
+                    "description": "CAFile is the path on the console host of the PEM encoded certificates trusted for the\ncluster, the CA of the configuration if empty",
+                    "type": "string"
+                },
+                "insecureSkipVerify": {
+                    "description": "InsecureSkipVerify accepts any certificate of the servers, prefer CAFile",
+                    "type": "boolean"
+                }
+            }
+        },
+        "cluster.Tags": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "config.Setting": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "type": "string"
+                },
+                "env": {
+                    "type": "string"
+                },
+                "flag": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "reference": {
+                    "description": "Reference is the reference to the secret the value was resolved from, e.g. vault://...",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "boolean"
+                },
+                "source": {
+                    "$ref": "#/definitions/config.Source"
+                },
+                "value": {}
+            }
+        },
+        "config.Source": {
+            "type": "string",
+            "enum": [
+                "default",
+                "file",
+                "env",
+                "flag"
+            ],
+            "x-enum-varnames": [
+                "SourceDefault",
+                "SourceFile",
+                "SourceEnv",
+                "SourceFlag"
+            ]
+        },
+        "conventions.Convention": {
+            "type": "object",
+            "properties": {
+                "builtin": {
+                    "description": "Builtin is set for the conventions shipped with the console, which can't be changed.",
+                    "type": "boolean"
+                },
+                "component": {
+                    "description": "Component is the infrastructure component writing the keys, e.g. etcd.",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Description explains a matching key, placeholders like {field} are replaced by the fields\ndecoded from the key.",
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/conventions.Kind"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pattern": {
+                    "description": "Pattern is the layout of the keys as segments separated by /. A segment is either literal\nor a placeholder: {field} matches one segment, {field:hex} a hexadecimal number that is\ndecoded to decimal in field and kept as is in fieldHex, and a final {field...} the rest of\nthe key.",
+                    "type": "string"
+                },
+                "table": {
+                    "description": "Table restricts the convention to one table, it applies to all tables if empty.",
+                    "type": "string"
+                }
+            }
+        },
+        "conventions.Kind": {
+            "type": "string",
+            "enum": [
+                "lease",
+                "lock",
+                "election",
+                "resource",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "KindLease",
+                "KindLock",
+                "KindElection",
+                "KindResource",
+                "KindOther"
+            ]
+        },
+        "conventions.Match": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "type": "string"
+                },
+                "convention": {
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Description explains the key.",
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Fields are the values decoded from the key.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/conventions.Kind"
+                }
+            }
+        },
+        "conventions.Summary": {
+            "type": "object",
+            "properties": {
+                "conventions": {
+                    "description": "Conventions are the conventions found, by the number of keys following them.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/conventions.Usage"
+                    }
+                },
+                "matched": {
+                    "description": "Matched is the number of keys following a convention.",
+                    "type": "integer"
+                },
+                "scanned": {
+                    "description": "Scanned is the number of keys examined.",
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the table has more keys than were scanned.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "conventions.Usage": {
+            "type": "object",
+            "properties": {
+                "component": {
+                    "type": "string"
+                },
+                "convention": {
+                    "type": "string"
+                },
+                "keys": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/conventions.Kind"
+                },
+                "samples": {
+                    "description": "Samples are the first matching keys, decoded.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/conventions.Match"
+                    }
+                }
+            }
+        },
+        "embed.SignRequest": {
+            "type": "object",
+            "properties": {
+                "params": {
+                    "description": "Params select what the widget shows, e.g. the query of a chart or the name of a table",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ttl": {
+                    "description": "TTL is how long the URL is valid, e.g. \"30m\"; the configured default if empty",
+                    "type": "string"
+                },
+                "widget": {
+                    "type": "string"
+                }
+            }
+        },
+        "embed.SignResponse": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL is the path and query of the widget on the host of the console, including its base path",
+                    "type": "string"
+                }
+            }
+        },
+        "growth.Day": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "bytesDelta": {
+                    "description": "BytesDelta is the change since the previous recorded day, 0 for the first one",
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "keys": {
+                    "type": "integer"
+                },
+                "keysDelta": {
+                    "description": "KeysDelta is the change since the previous recorded day, 0 for the first one",
+                    "type": "integer"
+                }
+            }
+        },
+        "growth.PrefixGrowth": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "integer"
+                },
+                "keysDelta": {
+                    "type": "integer"
+                },
+                "prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "growth.Report": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "bytesPerDay": {
+                    "description": "BytesPerDay is the average growth of the size over the report",
+                    "type": "number"
+                },
+                "capReachedAt": {
+                    "description": "CapReachedAt projects when the table reaches SizeCap at BytesPerDay. It is nil if the table\ndoesn't grow or only reaches it in more than 10 years, and the current time if it already\nexceeds the cap.",
+                    "type": "string"
+                },
+                "days": {
+                    "description": "Days are the days with recorded counts, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/growth.Day"
+                    }
+                },
+                "keys": {
+                    "description": "Keys and Bytes are the last recorded counts",
+                    "type": "integer"
+                },
+                "recordedAt": {
+                    "description": "RecordedAt is when the last counts were recorded, nil if there are none",
+                    "type": "string"
+                },
+                "sizeCap": {
+                    "description": "SizeCap is the size the projection is made for, 0 if none",
+                    "type": "integer"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "topPrefixes": {
+                    "description": "TopPrefixes are the prefixes that gained the most keys, most first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/growth.PrefixGrowth"
+                    }
+                }
+            }
+        },
+        "hotkeys.PrefixStats": {
+            "type": "object",
+            "properties": {
+                "estimated": {
+                    "description": "Estimated extrapolates the number of requests from the sample rate.",
+                    "type": "number"
+                },
+                "hot": {
+                    "description": "Hot is set when the prefix receives the majority of a table's requests\nwhile other prefixes of the table are requested as well.",
+                    "type": "boolean"
+                },
+                "ops": {
+                    "description": "Ops breaks the samples down by request kind.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "samples": {
+                    "description": "Samples is the number of sampled requests.",
+                    "type": "integer"
+                },
+                "share": {
+                    "description": "Share is the fraction of the table's samples that hit this prefix.",
+                    "type": "number"
+                },
+                "skewFactor": {
+                    "description": "SkewFactor compares the prefix with an even spread over the table's prefixes; 1 is even.",
+                    "type": "number"
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "hotkeys.Report": {
+            "type": "object",
+            "properties": {
+                "overflow": {
+                    "description": "Overflow counts samples not attributed to a key because too many distinct keys were requested.",
+                    "type": "integer"
+                },
+                "prefixes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/hotkeys.PrefixStats"
+                    }
+                },
+                "sampleRate": {
+                    "type": "number"
+                },
+                "samples": {
+                    "description": "Samples is the number of sampled requests in the window.",
+                    "type": "integer"
+                },
+                "skewed": {
+                    "description": "Skewed is set when at least one prefix is hot.",
+                    "type": "boolean"
+                },
+                "window": {
+                    "$ref": "#/definitions/time.Duration"
+                }
+            }
+        },
+        "indexes.Index": {
+            "type": "object",
+            "properties": {
+                "attemptedAt": {
+                    "description": "AttemptedAt is when the last build started, successful or not.",
+                    "type": "string"
+                },
+                "builtAt": {
+                    "description": "BuiltAt is when the last successful build started, the index reflects the table as of\nthen. Scans only use indexes that have been built.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is why the last build failed, if it did.",
+                    "type": "string"
+                },
+                "filter": {
+                    "description": "Filter is the canonical form of the filter, scans with an equivalent filter use the index.",
+                    "type": "string"
+                },
+                "interval": {
+                    "description": "Interval is how often the index is rebuilt.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "keys": {
+                    "description": "Keys is the number of keys the last successful build found.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "table": {
+                    "description": "Table is the indexed table.",
+                    "type": "string"
+                }
+            }
+        },
+        "locks.Contender": {
+            "type": "object",
+            "properties": {
+                "createRevision": {
+                    "type": "integer"
+                },
+                "fields": {
+                    "description": "Fields are the values decoded from the key by its convention, e.g. the lease.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                },
+                "modRevision": {
+                    "type": "integer"
+                },
+                "observedSince": {
+                    "description": "ObservedSince is when the console first saw the key in this position. Armada doesn't\nstore when keys were written, so it is no later than the acquisition and resets when\nthe console restarts.",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Value is the value of the key, e.g. the proposal of an election candidate.",
+                    "type": "string"
+                }
+            }
+        },
+        "locks.Lock": {
+            "type": "object",
+            "properties": {
+                "convention": {
+                    "type": "string"
+                },
+                "holder": {
+                    "description": "Holder holds the lock or leads the election.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/locks.Contender"
+                        }
+                    ]
+                },
+                "kind": {
+                    "$ref": "#/definitions/conventions.Kind"
+                },
+                "prefix": {
+                    "description": "Prefix is the prefix the contenders write their keys below.",
+                    "type": "string"
+                },
+                "waiters": {
+                    "description": "Waiters are the other contenders in the order they arrived.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/locks.Contender"
+                    }
+                }
+            }
+        },
+        "locks.Report": {
+            "type": "object",
+            "properties": {
+                "locks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/locks.Lock"
+                    }
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the table has more keys than were read.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "maintenance.Window": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "description": "Cluster restricts the window to one cluster of the registry, all clusters are affected when empty.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "freezeWrites": {
+                    "description": "FreezeWrites makes the affected tables read-only in the console.",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "silence": {
+                    "description": "Silence lists the diagnostic checks and alert rules silenced for the affected\nresources, all of them are silenced when empty.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start": {
+                    "type": "string"
+                },
+                "tables": {
+                    "description": "Tables restricts the window to some tables, the whole cluster is affected when empty.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.Alert": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "remediation": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                },
+                "runbookUrl": {
+                    "description": "RunbookURL and Remediation are those of the rule",
+                    "type": "string"
+                },
+                "severity": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "summary": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "metrics.BatchQuery": {
+            "type": "object",
+            "properties": {
+                "aggregate": {
+                    "description": "Aggregate is the aggregation of groupBy: sum (default), avg, min, max or count",
+                    "type": "string"
+                },
+                "clusters": {
+                    "description": "Clusters restricts every selector of the query to these clusters",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "groupBy": {
+                    "description": "GroupBy aggregates the result by these labels",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "description": "ID identifies the result of the query in the response, unique within the batch",
+                    "type": "string"
+                },
+                "panel": {
+                    "description": "Panel is the name of a saved panel whose query is executed",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is the PromQL query, required unless panel is given",
+                    "type": "string"
+                },
+                "start": {
+                    "description": "Start and End are the timestamps of a range query (RFC3339 or unix timestamp)",
+                    "type": "string"
+                },
+                "step": {
+                    "description": "Step is the resolution of a range query, e.g. 15s or 1m (default: 1m)",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Tags restricts every selector of the query to clusters with these tags, e.g. {\"env\": \"prod\"}",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "time": {
+                    "description": "Time is the evaluation timestamp of an instant query (RFC3339 or unix timestamp), now if empty",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.BatchQueryRequest": {
+            "type": "object",
+            "properties": {
+                "queries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.BatchQuery"
+                    }
+                },
+                "timeout": {
+                    "description": "Timeout is the deadline shared by all queries, e.g. 10s, at most the query timeout",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.BatchQueryResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/metrics.BatchQueryResult"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.BatchQueryResult": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "description": "Annotations are the unit and thresholds of the panel the query was taken from, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/panels.Annotations"
+                        }
+                    ]
+                },
+                "data": {
+                    "$ref": "#/definitions/metrics.QueryResult"
+                },
+                "error": {
+                    "description": "Error tells why the query failed",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is the evaluated PromQL query, taken from the panel if one is given and restricted to the clusters",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Query status (success, error)",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.BlockedMetric": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "type": "string"
+                },
+                "labels": {
+                    "description": "Labels are the label names likely causing the explosion, e.g. a label with a distinct\nvalue on nearly every series",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason describes the limit that was exceeded",
+                    "type": "string"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "until": {
+                    "description": "Until is when the metric is stored again, unless it still exceeds the limits",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.ClockSkew": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "type": "string"
+                },
+                "measuredAt": {
+                    "type": "string"
+                },
+                "nodeId": {
+                    "type": "string"
+                },
+                "nodeName": {
+                    "type": "string"
+                },
+                "skew": {
+                    "description": "Skew is how far the server clock is ahead of the console clock, negative if it is behind",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "uncertainty": {
+                    "description": "Uncertainty bounds the measurement error caused by the request round trip\nand the resolution of the server timestamp",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                }
+            }
+        },
+        "metrics.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error message",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Always \"error\"",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.HeatmapResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "description": "Buckets are the upper bounds of the buckets in ascending order, the last one is usually +Inf",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "max": {
+                    "description": "Max is the largest value of the matrix, e.g. to scale colors",
+                    "type": "number"
+                },
+                "query": {
+                    "description": "Query is the PromQL query the heatmap was computed from",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Query status (success, error)",
+                    "type": "string"
+                },
+                "times": {
+                    "description": "Times are the timestamps of the rows in unix milliseconds, spaced by the step",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "values": {
+                    "description": "Values holds a row per time with the per-second rate of observations falling into each bucket,\ni.e. above the previous bound and up to its own",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number",
+                            "format": "float64"
+                        }
+                    }
+                }
+            }
+        },
+        "metrics.QueryResponse": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "description": "Annotations are the unit and thresholds of the panel the query was given by, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/panels.Annotations"
+                        }
+                    ]
+                },
+                "compareOffset": {
+                    "description": "CompareOffset is how far the comparison of a range query is shifted back, e.g. 24h",
+                    "type": "string"
+                },
+                "comparison": {
+                    "description": "Comparison is the range query result CompareOffset earlier, with timestamps aligned to Data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/metrics.QueryResult"
+                        }
+                    ]
+                },
+                "data": {
+                    "description": "The query result data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/metrics.QueryResult"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "Query status (success, error)",
+                    "type": "string"
+                },
+                "suggestedRefreshSeconds": {
+                    "description": "SuggestedRefreshSeconds hints how long clients should wait before polling again",
+                    "type": "integer"
+                }
+            }
+        },
+        "metrics.QueryResult": {
+            "type": "object",
+            "properties": {
+                "result": {
+                    "description": "The query result value (Vector, Matrix, Scalar, or String)"
+                },
+                "resultType": {
+                    "$ref": "#/definitions/parser.ValueType"
+                },
+                "stats": {
+                    "description": "Query execution stats",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/metrics.QueryStats"
+                        }
+                    ]
+                }
+            }
+        },
+        "metrics.QueryStats": {
+            "type": "object",
+            "properties": {
+                "executionTime": {
+                    "description": "Total execution time",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "samplesLoaded": {
+                    "description": "Number of samples loaded",
+                    "type": "integer"
+                }
+            }
+        },
+        "metrics.QuerySuggestion": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.ServerResources": {
+            "type": "object",
+            "properties": {
+                "cpuPercent": {
+                    "type": "number"
+                },
+                "diskBytes": {
+                    "type": "number"
+                },
+                "goroutines": {
+                    "type": "number"
+                },
+                "maxFds": {
+                    "type": "number"
+                },
+                "memoryBytes": {
+                    "type": "number"
+                },
+                "nodeId": {
+                    "type": "string"
+                },
+                "openFds": {
+                    "type": "number"
+                },
+                "time": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.Suggestions": {
+            "type": "object",
+            "properties": {
+                "family": {
+                    "description": "Family is the metric family, e.g. without the _bucket suffix of histogram series",
+                    "type": "string"
+                },
+                "help": {
+                    "type": "string"
+                },
+                "inferred": {
+                    "description": "Inferred is set when the type was guessed from the name as the servers did not announce it",
+                    "type": "boolean"
+                },
+                "labels": {
+                    "description": "Labels are the label names of the stored series of the family",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.QuerySuggestion"
+                    }
+                },
+                "type": {
+                    "$ref": "#/definitions/model.MetricType"
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.Target": {
+            "type": "object",
+            "properties": {
+                "backoff": {
+                    "description": "Backoff is how long scrapes are suspended after the last failure, zero while the target is up",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "blocked": {
+                    "description": "Blocked are the metrics of the target not stored because they exceed the cardinality limits",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/metrics.BlockedMetric"
+                    }
+                },
+                "cluster": {
+                    "type": "string"
+                },
+                "consecutiveFailures": {
+                    "description": "ConsecutiveFailures counts the failed scrapes since the last successful one",
+                    "type": "integer"
+                },
+                "health": {
+                    "$ref": "#/definitions/metrics.TargetHealth"
+                },
+                "lastError": {
+                    "description": "LastError is the error of the last scrape, if it failed",
+                    "type": "string"
+                },
+                "lastScrape": {
+                    "type": "string"
+                },
+                "nextScrape": {
+                    "description": "NextScrape is when the target is scraped again at the earliest",
+                    "type": "string"
+                }
+            }
+        },
+        "metrics.TargetHealth": {
+            "type": "string",
+            "enum": [
+                "unknown",
+                "up",
+                "down"
+            ],
+            "x-enum-varnames": [
+                "TargetUnknown",
+                "TargetUp",
+                "TargetDown"
+            ]
+        },
+        "metrics.TopKResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "The K highest series, highest first",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/metrics.QueryResult"
+                        }
+                    ]
+                },
+                "other": {
+                    "description": "Other is the sum of the remaining series, with the same result type as Data, unless there are none",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/metrics.QueryResult"
+                        }
+                    ]
+                },
+                "otherSeries": {
+                    "description": "OtherSeries is the number of series summed up in Other",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Query status (success, error)",
+                    "type": "string"
+                },
+                "suggestedRefreshSeconds": {
+                    "description": "SuggestedRefreshSeconds hints how long clients should wait before polling again",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.MetricType": {
+            "type": "string",
+            "enum": [
+                "counter",
+                "gauge",
+                "histogram",
+                "gaugehistogram",
+                "summary",
+                "info",
+                "stateset",
+                "unknown"
+            ],
+            "x-enum-varnames": [
+                "MetricTypeCounter",
+                "MetricTypeGauge",
+                "MetricTypeHistogram",
+                "MetricTypeGaugeHistogram",
+                "MetricTypeSummary",
+                "MetricTypeInfo",
+                "MetricTypeStateset",
+                "MetricTypeUnknown"
+            ]
+        },
+        "panels.Annotations": {
+            "type": "object",
+            "properties": {
+                "thresholds": {
+                    "description": "Thresholds color values beyond them, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/panels.Thresholds"
+                        }
+                    ]
+                },
+                "unit": {
+                    "description": "Unit is the unit of the values, e.g. seconds or bytes, to label and scale axes",
+                    "type": "string"
+                }
+            }
+        },
+        "panels.Direction": {
+            "type": "string",
+            "enum": [
+                "above",
+                "below"
+            ],
+            "x-enum-varnames": [
+                "Above",
+                "Below"
+            ]
+        },
+        "panels.Panel": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is the PromQL query of the panel",
+                    "type": "string"
+                },
+                "thresholds": {
+                    "description": "Thresholds color values beyond them, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/panels.Thresholds"
+                        }
+                    ]
+                },
+                "title": {
+                    "type": "string"
+                },
+                "unit": {
+                    "description": "Unit is the unit of the values, e.g. seconds or bytes, to label and scale axes",
+                    "type": "string"
+                }
+            }
+        },
+        "panels.Thresholds": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "number"
+                },
+                "direction": {
+                    "description": "Direction is the side of the thresholds bad values are on, above if empty",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/panels.Direction"
+                        }
+                    ]
+                },
+                "warning": {
+                    "type": "number"
+                }
+            }
+        },
+        "parser.ValueType": {
+            "type": "string",
+            "enum": [
+                "none",
+                "vector",
+                "scalar",
+                "matrix",
+                "string"
+            ],
+            "x-enum-varnames": [
+                "ValueTypeNone",
+                "ValueTypeVector",
+                "ValueTypeScalar",
+                "ValueTypeMatrix",
+                "ValueTypeString"
+            ]
+        },
+        "reload.Result": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Applied are the keys of the changed settings that took effect",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "errors": {
+                    "description": "Errors are the failures of hooks, their settings may be applied partially or not at all",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "restartRequired": {
+                    "description": "RestartRequired are the keys of the changed settings that take effect after a restart",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "replay.ReplayRequest": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "description": "Cluster is the name of the cluster, the default cluster if empty",
+                    "type": "string"
+                }
+            }
+        },
+        "replay.Request": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "duration": {
+                    "$ref": "#/definitions/time.Duration"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "Path is the path and query of the request",
+                    "type": "string"
+                },
+                "redacted": {
+                    "description": "Redacted is set when sensitive values of the query or body were replaced",
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "Status and Duration describe the original response",
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "truncated": {
+                    "description": "Truncated is set when the body exceeded the recorded size",
+                    "type": "boolean"
+                },
+                "user": {
+                    "description": "User is the user who made the request, if authenticated",
+                    "type": "string"
+                }
+            }
+        },
+        "replay.Result": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "bodyTruncated": {
+                    "description": "BodyTruncated is set when the response exceeded the returned size",
+                    "type": "boolean"
+                },
+                "cluster": {
+                    "type": "string"
+                },
+                "duration": {
+                    "$ref": "#/definitions/time.Duration"
+                },
+                "request": {
+                    "$ref": "#/definitions/replay.Request"
+                },
+                "spans": {
+                    "description": "Spans are ordered by their start",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/replay.Span"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "replay.Span": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "duration": {
+                    "$ref": "#/definitions/time.Duration"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "offset": {
+                    "description": "Offset is the time between the start of the replay and the start of the span",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "parentId": {
+                    "type": "string"
+                },
+                "spanId": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "runbooks.Runbook": {
+            "type": "object",
+            "properties": {
+                "check": {
+                    "description": "Check is the name of the alert rule or diagnostic check",
+                    "type": "string"
+                },
+                "remediation": {
+                    "description": "Remediation are first steps in Markdown, shown with the findings",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL links to the runbook, e.g. in the team's wiki",
+                    "type": "string"
+                }
+            }
+        },
+        "shadow.Failure": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts counts how often mirroring the write was tried",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "failedAt": {
+                    "type": "string"
+                },
+                "op": {
+                    "$ref": "#/definitions/shadow.Op"
+                }
+            }
+        },
+        "shadow.Op": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "At is when the write was made on the primary cluster",
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Key is the key or prefix written, empty for table operations",
+                    "type": "string"
+                },
+                "table": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/shadow.OpType"
+                }
+            }
+        },
+        "shadow.OpType": {
+            "type": "string",
+            "enum": [
+                "put",
+                "delete",
+                "deletePrefix",
+                "createTable",
+                "deleteTable"
+            ],
+            "x-enum-varnames": [
+                "OpPut",
+                "OpDelete",
+                "OpDeletePrefix",
+                "OpCreateTable",
+                "OpDeleteTable"
+            ]
+        },
+        "shadow.Report": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "description": "Cluster is the secondary cluster the writes are mirrored to",
+                    "type": "string"
+                },
+                "droppedFailures": {
+                    "description": "DroppedFailures counts failures that were discarded to keep at most the configured number",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Failed counts the attempts that failed, including writes that didn't fit into the queue",
+                    "type": "integer"
+                },
+                "failures": {
+                    "description": "Failures are the writes that weren't mirrored yet, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/shadow.Failure"
+                    }
+                },
+                "mirrored": {
+                    "description": "Mirrored counts the writes applied to the secondary cluster",
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "Pending is the number of writes waiting to be mirrored",
+                    "type": "integer"
+                }
+            }
+        },
+        "snapshots.Panel": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "description": "Annotations are the unit and thresholds of the saved panel the query was taken from, if any",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/panels.Annotations"
+                        }
+                    ]
+                },
+                "error": {
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query is the evaluated PromQL query, e.g. the query of a saved panel at the time",
+                    "type": "string"
+                },
+                "request": {
+                    "description": "Request is the query as the dashboard sent it, with its evaluation time filled in",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/metrics.BatchQuery"
+                        }
+                    ]
+                },
+                "result": {
+                    "description": "Result is the query result as returned by /api/metrics/query and /api/metrics/query_range",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "description": "Status is success or error",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "snapshots.Snapshot": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "panels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/snapshots.Panel"
+                    }
+                },
+                "start": {
+                    "description": "Start, End and Step are the range of range queries",
+                    "type": "string"
+                },
+                "step": {
+                    "type": "string"
+                },
+                "time": {
+                    "description": "Time is the evaluation time of instant queries",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "snapshots.Summary": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "panels": {
+                    "description": "Panels is the number of panels of the snapshot",
+                    "type": "integer"
+                },
+                "start": {
+                    "description": "Start, End and Step are the range of range queries",
+                    "type": "string"
+                },
+                "step": {
+                    "type": "string"
+                },
+                "time": {
+                    "description": "Time is the evaluation time of instant queries",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "stats.TableStats": {
+            "type": "object",
+            "properties": {
+                "dbSize": {
+                    "description": "DBSize is the size of the table's database in bytes.",
+                    "type": "integer"
+                },
+                "logSize": {
+                    "description": "LogSize is the size of the table's raft log in bytes.",
+                    "type": "integer"
+                },
+                "sampledAt": {
+                    "description": "SampledAt is when the statistics were collected.",
+                    "type": "string"
+                }
+            }
+        },
+        "time.Duration": {
+            "type": "integer",
+            "format": "int64",
+            "enum": [
+                -9223372036854775808,
+                9223372036854775807,
+                1,
+                1000,
+                1000000,
+                1000000000,
+                60000000000,
+                3600000000000
+            ],
+            "x-enum-varnames": [
+                "minDuration",
+                "maxDuration",
+                "Nanosecond",
+                "Microsecond",
+                "Millisecond",
+                "Second",
+                "Minute",
+                "Hour"
+            ]
+        },
+        "topology.Leadership": {
+            "type": "object",
+            "properties": {
+                "leader": {
+                    "description": "Leader is the ID of the leading member, empty while the table has no leader.",
+                    "type": "string"
+                },
+                "term": {
+                    "description": "Term is the raft term the leader was elected in.",
+                    "type": "integer"
+                }
+            }
+        },
+        "topology.Member": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "topology.Snapshot": {
+            "type": "object",
+            "properties": {
+                "lastSeen": {
+                    "description": "LastSeen is when the topology was last observed.",
+                    "type": "string"
+                },
+                "leaderChanges": {
+                    "description": "LeaderChanges counts the leader elections of every table since it was first recorded.\nThe counts are carried over from snapshot to snapshot, so they are not lost when old\nsnapshots are pruned.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer",
+                        "format": "int64"
+                    }
+                },
+                "members": {
+                    "description": "Members are the servers of the cluster, ordered by ID.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/topology.Member"
+                    }
+                },
+                "since": {
+                    "description": "Since is when the topology was first observed.",
+                    "type": "string"
+                },
+                "tables": {
+                    "description": "Tables maps table names to their leadership.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/topology.Leadership"
+                    }
+                }
+            }
+        }
+    }
+}`
+
+// SwaggerInfo holds exported Swagger Info so clients can modify it
+var SwaggerInfo = &swag.Spec{
+	Version:          "v1",
+	Host:             "",
+	BasePath:         "/",
+	Schemes:          []string{},
+	Title:            "Armada Console API",
+	Description:      "REST API of the Armada console. The routes are served under /api/v1 and the unversioned /api alias of v1.",
+	InfoInstanceName: "swagger",
+	SwaggerTemplate:  docTemplate,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+}
@@ -0,0 +1,348 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/zap"
+)
+
+// PathPrefix is where widgets are served. Requests below it are authorized by their signature.
+const PathPrefix = "/embed/"
+
+// Widgets
+const (
+	WidgetChart  = "chart"
+	WidgetHealth = "health"
+	WidgetTable  = "table"
+)
+
+const (
+	// statusTimeout bounds asking the servers for their status
+	statusTimeout = 5 * time.Second
+	// refreshInterval is how often embedded widgets reload themselves
+	refreshInterval = time.Minute
+)
+
+// RangeQuerier evaluates PromQL queries over a time range. The metrics.QueryEngine implements it.
+type RangeQuerier interface {
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (metrics.QueryResult, error)
+}
+
+// TableStatsSource provides sampled table statistics. The stats.Sampler implements it.
+type TableStatsSource interface {
+	Table(name string) (stats.TableStats, bool)
+}
+
+// Option configures optional behaviour of the Handler
+type Option func(*Handler)
+
+// WithTTL sets the validity of signed URLs unless requested otherwise, and the longest validity that may be requested
+func WithTTL(defaultTTL, maxTTL time.Duration) Option {
+	return func(h *Handler) {
+		h.defaultTTL = defaultTTL
+		h.maxTTL = maxTTL
+	}
+}
+
+// WithFrameAncestors sets the space-separated origins allowed to frame widgets
+func WithFrameAncestors(origins string) Option {
+	return func(h *Handler) {
+		h.frameAncestors = origins
+	}
+}
+
+// WithCharts enables the chart widget, which plots a range query
+func WithCharts(querier RangeQuerier) Option {
+	return func(h *Handler) {
+		h.charts = querier
+	}
+}
+
+// WithHealth enables the cluster health tile, which asks every server for its status
+func WithHealth(source stats.Source) Option {
+	return func(h *Handler) {
+		h.status = source
+	}
+}
+
+// WithTableStats enables the table statistics widget
+func WithTableStats(source TableStatsSource) Option {
+	return func(h *Handler) {
+		h.tables = source
+	}
+}
+
+// Handler serves widgets and signs their URLs
+type Handler struct {
+	signer         *Signer
+	logger         *zap.Logger
+	defaultTTL     time.Duration
+	maxTTL         time.Duration
+	frameAncestors string
+
+	charts RangeQuerier
+	status stats.Source
+	tables TableStatsSource
+}
+
+// NewHandler creates a widget handler verifying URLs with the signer
+func NewHandler(signer *Signer, logger *zap.Logger, opts ...Option) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	h := &Handler{
+		signer:         signer,
+		logger:         logger,
+		defaultTTL:     time.Hour,
+		maxTTL:         24 * time.Hour,
+		frameAncestors: "*",
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers the widgets and the signing endpoint
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get(PathPrefix+"{widget}", h.handleWidget)
+	r.Post("/api/embed/sign", h.handleSign)
+}
+
+// widgets returns the names of the enabled widgets
+func (h *Handler) widgets() []string {
+	var widgets []string
+	if h.charts != nil {
+		widgets = append(widgets, WidgetChart)
+	}
+	if h.status != nil {
+		widgets = append(widgets, WidgetHealth)
+	}
+	if h.tables != nil {
+		widgets = append(widgets, WidgetTable)
+	}
+	return widgets
+}
+
+// SignRequest selects the widget to sign a URL for
+type SignRequest struct {
+	Widget string `json:"widget"`
+	// Params select what the widget shows, e.g. the query of a chart or the name of a table
+	Params map[string]string `json:"params,omitempty"`
+	// TTL is how long the URL is valid, e.g. "30m"; the configured default if empty
+	TTL string `json:"ttl,omitempty"`
+}
+
+// SignResponse is a signed widget URL
+type SignResponse struct {
+	// URL is the path and query of the widget on the host of the console, including its base path
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// handleSign signs the URL of a widget
+// @Summary Sign a widget URL
+// @Description Create a short-lived signed URL of a widget that can be embedded without logging in
+// @Tags embed
+// @Accept json
+// @Produce json
+// @Param request body SignRequest true "Widget to sign"
+// @Success 200 {object} SignResponse
+// @Failure 400 {string} string "Invalid request"
+// @Router /api/embed/sign [post]
+func (h *Handler) handleSign(w http.ResponseWriter, r *http.Request) {
+	var req SignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !slices.Contains(h.widgets(), req.Widget) {
+		http.Error(w, "Unknown widget, must be one of "+strings.Join(h.widgets(), ", "), http.StatusBadRequest)
+		return
+	}
+	params := url.Values{}
+	for name, value := range req.Params {
+		params.Set(name, value)
+	}
+	if err := h.checkParams(req.Widget, params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl := h.defaultTTL
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 || ttl > h.maxTTL {
+			http.Error(w, "ttl must be a positive duration of at most "+h.maxTTL.String(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	query, expires := h.signer.Sign(req.Widget, params, ttl)
+	h.logger.Info("Signed widget URL",
+		zap.String("widget", req.Widget),
+		zap.Time("expires", expires))
+	chix.NewRender(w).JSON(SignResponse{
+		URL:       basepath.Path(r.Context(), PathPrefix+req.Widget+"?"+query.Encode()),
+		ExpiresAt: expires.UTC(),
+	})
+}
+
+// checkParams verifies that the params select something the widget can show
+func (h *Handler) checkParams(widget string, params url.Values) error {
+	switch widget {
+	case WidgetChart:
+		if params.Get("query") == "" {
+			return errors.New("the chart widget requires the query param")
+		}
+		if _, err := parser.ParseExpr(params.Get("query")); err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		if _, err := chartRange(params); err != nil {
+			return err
+		}
+	case WidgetTable:
+		if params.Get("table") == "" {
+			return errors.New("the table widget requires the table param")
+		}
+	}
+	return nil
+}
+
+// handleWidget verifies the signature of a widget URL and renders the widget
+func (h *Handler) handleWidget(w http.ResponseWriter, r *http.Request) {
+	widget := chi.URLParam(r, "widget")
+	// The signature must not leak to other sites through links, and widgets must only be framed by the allowed sites
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors "+h.frameAncestors)
+
+	if !slices.Contains(h.widgets(), widget) {
+		h.renderMessage(w, http.StatusNotFound, "Unknown widget")
+		return
+	}
+	query := r.URL.Query()
+	if err := h.signer.Verify(widget, query); err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, ErrMissingSignature) {
+			status = http.StatusUnauthorized
+		}
+		h.renderMessage(w, status, "This widget link is not valid: "+err.Error())
+		return
+	}
+
+	var (
+		p   page
+		err error
+	)
+	switch widget {
+	case WidgetChart:
+		p, err = h.chartPage(r.Context(), query)
+	case WidgetHealth:
+		p, err = h.healthPage(r.Context())
+	case WidgetTable:
+		p, err = h.tablePage(query)
+	}
+	if errors.Is(err, errNotFound) {
+		h.renderMessage(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to render widget", zap.String("widget", widget), zap.Error(err))
+		h.renderMessage(w, http.StatusInternalServerError, "Failed to load the widget")
+		return
+	}
+	p.Refresh = int(refreshInterval.Seconds())
+	h.render(w, http.StatusOK, p)
+}
+
+// renderMessage renders a page showing only a message, e.g. an error
+func (h *Handler) renderMessage(w http.ResponseWriter, status int, message string) {
+	h.render(w, status, page{Title: "Armada", Message: message})
+}
+
+// render writes a widget page
+func (h *Handler) render(w http.ResponseWriter, status int, p page) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := pageTemplate.Execute(w, p); err != nil {
+		h.logger.Warn("Failed to write widget", zap.Error(err))
+	}
+}
+
+// page is the data of the widget template
+type page struct {
+	Title string
+	// Refresh is the number of seconds after which the page reloads, 0 disables reloading
+	Refresh int
+	// Message is shown instead of a widget, e.g. an error
+	Message string
+	Chart   *chart
+	Health  *health
+	Table   *tableStats
+}
+
+var pageTemplate = template.Must(template.New("widget").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">{{end}}
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; padding: 12px; font-family: system-ui, sans-serif; color: #111827; background: #fff; }
+h1 { margin: 0 0 8px; font-size: 14px; font-weight: 600; }
+.muted { color: #6b7280; font-size: 12px; }
+.value { font-size: 28px; font-weight: 600; }
+.ok { color: #15803d; } .degraded { color: #b45309; } .down { color: #b91c1c; }
+.grid { display: flex; gap: 24px; }
+.legend { display: flex; flex-wrap: wrap; gap: 4px 12px; font-size: 11px; margin-top: 4px; }
+.swatch { display: inline-block; width: 8px; height: 8px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Message}}<p class="muted">{{.}}</p>{{end}}
+{{with .Chart}}
+{{if .Series}}
+<svg viewBox="0 0 {{.Width}} {{.Height}}" width="100%" preserveAspectRatio="none" role="img">
+<line x1="0" y1="{{.Height}}" x2="{{.Width}}" y2="{{.Height}}" stroke="#e5e7eb"/>
+{{range .Series}}<polyline fill="none" stroke="{{.Color}}" stroke-width="1.5" points="{{.Points}}"/>
+{{end}}</svg>
+<div class="muted">min {{.Min}} · max {{.Max}} · last {{.Range}}</div>
+<div class="legend">{{range .Series}}<span><span class="swatch" style="background: {{.Color}}"></span>{{.Label}}</span>{{end}}</div>
+{{else}}<p class="muted">No data in the last {{.Range}}</p>{{end}}
+{{end}}
+{{with .Health}}
+<div class="value {{.Class}}">{{.Status}}</div>
+<div class="grid">
+<div><div class="value">{{.ServersUp}}/{{.Servers}}</div><div class="muted">servers up</div></div>
+<div><div class="value">{{.Tables}}</div><div class="muted">tables</div></div>
+<div><div class="value">{{.Leaderless}}</div><div class="muted">without leader</div></div>
+</div>
+{{end}}
+{{with .Table}}
+<div class="grid">
+<div><div class="value">{{.DBSize}}</div><div class="muted">database</div></div>
+<div><div class="value">{{.LogSize}}</div><div class="muted">raft log</div></div>
+</div>
+<p class="muted">Sampled {{.SampledAt}}</p>
+{{end}}
+</body>
+</html>
+`))
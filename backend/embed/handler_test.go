@@ -0,0 +1,163 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type fakeQuerier struct {
+	query string
+}
+
+func (f *fakeQuerier) QueryRange(_ context.Context, query string, start, end time.Time, _ time.Duration) (metrics.QueryResult, error) {
+	f.query = query
+	return metrics.QueryResult{Value: promql.Matrix{{
+		Metric: labels.FromStrings("instance", "armada-0"),
+		Floats: []promql.FPoint{{T: start.UnixMilli(), F: 1}, {T: end.UnixMilli(), F: 3}},
+	}}}, nil
+}
+
+type fakeSource struct{}
+
+func (fakeSource) GetAllServers(context.Context) ([]armada.Server, error) {
+	return []armada.Server{
+		{ID: "1", ClientURLs: []string{"http://armada-0:8443"}},
+		{ID: "2", ClientURLs: []string{"http://armada-1:8443"}},
+	}, nil
+}
+
+func (fakeSource) GetStatus(_ context.Context, address string) (*armada.Status, error) {
+	if address == "http://armada-1:8443" {
+		return nil, errors.New("connection refused")
+	}
+	return &armada.Status{Status: "ok", Tables: map[string]armada.TableStatus{"users": {Leader: "1"}}}, nil
+}
+
+type fakeTables map[string]stats.TableStats
+
+func (f fakeTables) Table(name string) (stats.TableStats, bool) {
+	s, ok := f[name]
+	return s, ok
+}
+
+func newTestRouter(opts ...Option) (*chi.Mux, *fakeQuerier) {
+	querier := &fakeQuerier{}
+	opts = append([]Option{
+		WithCharts(querier),
+		WithHealth(fakeSource{}),
+		WithTableStats(fakeTables{"users": {DBSize: 2048, LogSize: 512, SampledAt: time.Unix(0, 0)}}),
+	}, opts...)
+	r := chi.NewRouter()
+	NewHandler(NewSigner(testSecret), nil, opts...).RegisterRoutes(r)
+	return r, querier
+}
+
+func sign(t *testing.T, r http.Handler, body string) (*httptest.ResponseRecorder, SignResponse) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/embed/sign", strings.NewReader(body)))
+	var resp SignResponse
+	if rr.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	}
+	return rr, resp
+}
+
+func get(r http.Handler, target string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	return rr
+}
+
+func TestSignAndRenderWidgets(t *testing.T) {
+	r, querier := newTestRouter(WithFrameAncestors("https://portal.example.com"))
+
+	rr, resp := sign(t, r, `{"widget":"chart","params":{"query":"up","title":"Servers up"},"ttl":"30m"}`)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), resp.ExpiresAt, 2*time.Second)
+	assert.True(t, strings.HasPrefix(resp.URL, "/embed/chart?"))
+
+	rr = get(r, resp.URL)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "up", querier.query)
+	assert.Contains(t, rr.Body.String(), "Servers up")
+	assert.Contains(t, rr.Body.String(), "<polyline")
+	assert.Contains(t, rr.Header().Get("Content-Security-Policy"), "frame-ancestors https://portal.example.com")
+	assert.Equal(t, "no-referrer", rr.Header().Get("Referrer-Policy"))
+
+	_, resp = sign(t, r, `{"widget":"health"}`)
+	rr = get(r, resp.URL)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "Degraded")
+	assert.Contains(t, rr.Body.String(), "1/2")
+
+	_, resp = sign(t, r, `{"widget":"table","params":{"table":"users"}}`)
+	rr = get(r, resp.URL)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), "2.0 KiB")
+
+	_, resp = sign(t, r, `{"widget":"table","params":{"table":"orders"}}`)
+	assert.Equal(t, http.StatusNotFound, get(r, resp.URL).Code)
+}
+
+func TestSignRejectsInvalidRequests(t *testing.T) {
+	r, _ := newTestRouter(WithTTL(time.Hour, 2*time.Hour))
+	for name, body := range map[string]string{
+		"malformed":        `{`,
+		"unknown widget":   `{"widget":"logs"}`,
+		"missing query":    `{"widget":"chart"}`,
+		"invalid query":    `{"widget":"chart","params":{"query":"sum("}}`,
+		"range too long":   `{"widget":"chart","params":{"query":"up","range":"30d"}}`,
+		"missing table":    `{"widget":"table"}`,
+		"ttl above max":    `{"widget":"health","ttl":"3h"}`,
+		"ttl not positive": `{"widget":"health","ttl":"-1m"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr, _ := sign(t, r, body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestWidgetRequiresValidSignature(t *testing.T) {
+	r, _ := newTestRouter()
+	assert.Equal(t, http.StatusUnauthorized, get(r, "/embed/health").Code)
+	assert.Equal(t, http.StatusForbidden, get(r, "/embed/health?expires=9999999999&sig=forged").Code)
+	assert.Equal(t, http.StatusNotFound, get(r, "/embed/logs").Code)
+
+	// A URL signed for one widget can't be used for another
+	_, resp := sign(t, r, `{"widget":"table","params":{"table":"users"}}`)
+	u, err := url.Parse(resp.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, get(r, "/embed/health?"+u.RawQuery).Code)
+
+	// Widgets that are not enabled are unknown
+	r = chi.NewRouter()
+	NewHandler(NewSigner(testSecret), nil).RegisterRoutes(r)
+	rr, _ := sign(t, r, `{"widget":"health"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "3.0 GiB", formatBytes(3<<30))
+}
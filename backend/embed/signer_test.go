@@ -0,0 +1,38 @@
+package embed
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignerVerify(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewSigner("0123456789abcdef0123456789abcdef")
+	s.now = func() time.Time { return now }
+
+	query, expires := s.Sign(WidgetTable, url.Values{"table": {"users"}}, time.Hour)
+	assert.Equal(t, now.Add(time.Hour), expires)
+	assert.NoError(t, s.Verify(WidgetTable, query))
+
+	// The signature covers the widget and every param
+	assert.ErrorIs(t, s.Verify(WidgetChart, query), ErrInvalidSignature)
+	tampered := url.Values{}
+	for k, v := range query {
+		tampered[k] = v
+	}
+	tampered.Set("table", "orders")
+	assert.ErrorIs(t, s.Verify(WidgetTable, tampered), ErrInvalidSignature)
+	tampered = url.Values{"table": {"users"}, "expires": {"9999999999"}, "sig": query["sig"]}
+	assert.ErrorIs(t, s.Verify(WidgetTable, tampered), ErrInvalidSignature)
+
+	other := NewSigner("another secret of at least 32 characters")
+	assert.ErrorIs(t, other.Verify(WidgetTable, query), ErrInvalidSignature)
+
+	assert.ErrorIs(t, s.Verify(WidgetTable, url.Values{"table": {"users"}}), ErrMissingSignature)
+
+	now = now.Add(time.Hour)
+	assert.ErrorIs(t, s.Verify(WidgetTable, query), ErrExpired)
+}
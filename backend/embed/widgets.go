@@ -0,0 +1,244 @@
+package embed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/prometheus/prometheus/promql"
+)
+
+// errNotFound is returned when a widget shows something that does not exist
+var errNotFound = errors.New("not found")
+
+const (
+	// defaultChartRange is the time range plotted unless the range param is set
+	defaultChartRange = time.Hour
+	// maxChartRange is the longest time range that can be plotted
+	maxChartRange = 7 * 24 * time.Hour
+	// chartPoints is the number of points plotted per series
+	chartPoints = 120
+	// maxChartSeries bounds the number of series plotted, so a broad query doesn't make the chart unreadable
+	maxChartSeries = 8
+	chartWidth     = 600
+	chartHeight    = 160
+)
+
+// chartColors are assigned to the series in order
+var chartColors = []string{"#2563eb", "#16a34a", "#dc2626", "#9333ea", "#ea580c", "#0891b2", "#ca8a04", "#db2777"}
+
+// chart is a plotted range query
+type chart struct {
+	Width, Height int
+	Range         time.Duration
+	Min, Max      string
+	Series        []chartSeries
+}
+
+// chartSeries is a plotted series
+type chartSeries struct {
+	Label  string
+	Color  string
+	Points string
+}
+
+// chartRange returns the time range selected by the range param of a chart
+func chartRange(params url.Values) (time.Duration, error) {
+	raw := params.Get("range")
+	if raw == "" {
+		return defaultChartRange, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 || d > maxChartRange {
+		return 0, fmt.Errorf("range must be a positive duration of at most %s", maxChartRange)
+	}
+	return d, nil
+}
+
+// chartPage plots the query of the chart widget
+func (h *Handler) chartPage(ctx context.Context, params url.Values) (page, error) {
+	query := params.Get("query")
+	d, err := chartRange(params)
+	if err != nil {
+		return page{}, err
+	}
+	end := time.Now()
+	step := max(d/chartPoints, 15*time.Second)
+	result, err := h.charts.QueryRange(ctx, query, end.Add(-d), end, step)
+	if err != nil {
+		return page{}, err
+	}
+
+	title := params.Get("title")
+	if title == "" {
+		title = query
+	}
+	var matrix promql.Matrix
+	switch v := result.Value.(type) {
+	case promql.Matrix:
+		matrix = v
+	case nil:
+	default:
+		return page{}, fmt.Errorf("unexpected result type %s", result.Value.Type())
+	}
+	return page{Title: title, Chart: plot(matrix, end.Add(-d), end)}, nil
+}
+
+// plot scales the series of a matrix to the chart area
+func plot(matrix promql.Matrix, start, end time.Time) *chart {
+	c := &chart{Width: chartWidth, Height: chartHeight, Range: end.Sub(start)}
+	if len(matrix) > maxChartSeries {
+		matrix = matrix[:maxChartSeries]
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, series := range matrix {
+		for _, p := range series.Floats {
+			lo, hi = min(lo, p.F), max(hi, p.F)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return c
+	}
+	c.Min, c.Max = formatValue(lo), formatValue(hi)
+	// A flat line is drawn in the middle of the chart
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+
+	span := float64(end.Sub(start).Milliseconds())
+	for i, series := range matrix {
+		if len(series.Floats) == 0 {
+			continue
+		}
+		points := make([]string, 0, len(series.Floats))
+		for _, p := range series.Floats {
+			x := float64(p.T-start.UnixMilli()) / span * chartWidth
+			y := chartHeight - (p.F-lo)/(hi-lo)*chartHeight
+			points = append(points, strconv.FormatFloat(x, 'f', 1, 64)+","+strconv.FormatFloat(y, 'f', 1, 64))
+		}
+		c.Series = append(c.Series, chartSeries{
+			Label:  series.Metric.String(),
+			Color:  chartColors[i%len(chartColors)],
+			Points: strings.Join(points, " "),
+		})
+	}
+	return c
+}
+
+// formatValue formats a sample value compactly
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// health summarizes whether the cluster serves requests
+type health struct {
+	Status     string
+	Class      string
+	Servers    int
+	ServersUp  int
+	Tables     int
+	Leaderless int
+}
+
+// healthPage asks every server for its status and summarizes the cluster health
+func (h *Handler) healthPage(ctx context.Context) (page, error) {
+	ctx, cancel := context.WithTimeout(ctx, statusTimeout)
+	defer cancel()
+	servers, err := h.status.GetAllServers(ctx)
+	if err != nil {
+		return page{}, err
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		statuses []*armada.Status
+	)
+	for _, server := range servers {
+		if len(server.ClientURLs) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := h.status.GetStatus(ctx, server.ClientURLs[0])
+			if err != nil || status.Status == "error" {
+				return
+			}
+			mu.Lock()
+			statuses = append(statuses, status)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return page{Title: "Cluster health", Health: summarizeHealth(len(servers), statuses)}, nil
+}
+
+// summarizeHealth derives the health of the cluster from the statuses of the servers that answered
+func summarizeHealth(servers int, statuses []*armada.Status) *health {
+	led := make(map[string]bool)
+	for _, status := range statuses {
+		for name, table := range status.Tables {
+			led[name] = led[name] || table.Leader != ""
+		}
+	}
+	h := &health{Servers: servers, ServersUp: len(statuses), Tables: len(led)}
+	for _, hasLeader := range led {
+		if !hasLeader {
+			h.Leaderless++
+		}
+	}
+
+	switch {
+	case h.ServersUp == 0:
+		h.Status, h.Class = "Down", "down"
+	case h.ServersUp < h.Servers || h.Leaderless > 0:
+		h.Status, h.Class = "Degraded", "degraded"
+	default:
+		h.Status, h.Class = "Healthy", "ok"
+	}
+	return h
+}
+
+// tableStats are the sampled statistics of a table, formatted for display
+type tableStats struct {
+	DBSize    string
+	LogSize   string
+	SampledAt string
+}
+
+// tablePage shows the sampled statistics of the table selected by the table param
+func (h *Handler) tablePage(params url.Values) (page, error) {
+	name := params.Get("table")
+	s, ok := h.tables.Table(name)
+	if !ok {
+		return page{}, fmt.Errorf("table %q %w", name, errNotFound)
+	}
+	return page{Title: "Table " + name, Table: &tableStats{
+		DBSize:    formatBytes(s.DBSize),
+		LogSize:   formatBytes(s.LogSize),
+		SampledAt: s.SampledAt.UTC().Format(time.RFC3339),
+	}}, nil
+}
+
+// formatBytes formats a size with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
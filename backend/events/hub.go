@@ -0,0 +1,143 @@
+// Package events distributes cluster events, such as topology changes and audited
+// operations, to subscribers in real time. Events are not stored: subscribers only
+// receive events published while they are subscribed.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	// TypeTopology is published when the members or table leaders of the cluster changed.
+	// Its data is the new topology.Snapshot.
+	TypeTopology = "topology.changed"
+	// TypeAudit is published for every operation recorded in the audit log. Its data is the audit.Entry.
+	TypeAudit = "audit.recorded"
+	// TypeCardinality is published when a metric of a cluster is no longer stored because it
+	// exceeds the cardinality limits. Its data is the metrics.BlockedMetric.
+	TypeCardinality = "metrics.cardinality_exceeded"
+	// TypeMemberRemoved is published when a node left the cluster and the console closed its
+	// connection. Its data is the armada.RemovedMember.
+	TypeMemberRemoved = "cluster.member_removed"
+)
+
+// Types lists the event types subscribers can filter by
+var Types = []string{TypeTopology, TypeAudit, TypeCardinality, TypeMemberRemoved}
+
+// Event is something that happened in the cluster or the console
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data,omitempty"`
+}
+
+// Publisher accepts events. The Hub implements it.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Subscription receives the events of the types it subscribed to
+type Subscription struct {
+	hub   *Hub
+	types []string
+	ch    chan Event
+
+	// mu protects dropped
+	mu      sync.Mutex
+	dropped uint64
+}
+
+// Events returns the channel the events are delivered on. It is closed when the subscription is closed.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Dropped returns the number of events dropped since the last call because the subscriber
+// did not keep up
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dropped := s.dropped
+	s.dropped = 0
+	return dropped
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+}
+
+// wants reports whether the subscription receives events of the type
+func (s *Subscription) wants(eventType string) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, eventType)
+}
+
+// Hub fans published events out to its subscriptions. Publishing never blocks: events
+// are dropped for subscribers whose buffer is full, so a slow consumer can't stall the
+// components publishing events.
+type Hub struct {
+	now func() time.Time
+
+	// mu protects subs
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// NewHub creates a hub without subscriptions
+func NewHub() *Hub {
+	return &Hub{
+		now:  time.Now,
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe creates a subscription buffering up to buffer events of the given types,
+// or of all types if none are given
+func (h *Hub) Subscribe(buffer int, types ...string) *Subscription {
+	s := &Subscription{hub: h, types: types, ch: make(chan Event, buffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	return s
+}
+
+// unsubscribe removes a subscription and closes its channel, it is safe to call repeatedly
+func (h *Hub) unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.ch)
+	}
+}
+
+// Publish delivers an event to the subscriptions of its type. The time is set if it is zero.
+func (h *Hub) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = h.now().UTC()
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs {
+		if !s.wants(e.Type) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.mu.Lock()
+			s.dropped++
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Subscribers returns the number of subscriptions
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHubFiltersByType(t *testing.T) {
+	hub := NewHub()
+	all := hub.Subscribe(4)
+	audits := hub.Subscribe(4, TypeAudit)
+
+	hub.Publish(Event{Type: TypeTopology, Data: "t"})
+	hub.Publish(Event{Type: TypeAudit, Data: "a"})
+
+	require.Len(t, all.Events(), 2)
+	require.Len(t, audits.Events(), 1)
+	e := <-audits.Events()
+	assert.Equal(t, "a", e.Data)
+	assert.False(t, e.Time.IsZero())
+}
+
+func TestHubDropsEventsForSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(1)
+	for range 3 {
+		hub.Publish(Event{Type: TypeAudit})
+	}
+	assert.Len(t, sub.Events(), 1)
+	assert.Equal(t, uint64(2), sub.Dropped())
+	assert.Equal(t, uint64(0), sub.Dropped())
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(1)
+	assert.Equal(t, 1, hub.Subscribers())
+	sub.Close()
+	sub.Close()
+	assert.Equal(t, 0, hub.Subscribers())
+	_, open := <-sub.Events()
+	assert.False(t, open)
+	hub.Publish(Event{Type: TypeAudit})
+}
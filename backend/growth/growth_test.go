@@ -0,0 +1,155 @@
+package growth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTable counts its keys like the Armada client
+type fakeTable struct {
+	keys []string
+}
+
+func (f *fakeTable) GetKeyValuePairs(_ context.Context, _, _, start, _ string, limit int) ([]armada.KeyValuePair, error) {
+	var pairs []armada.KeyValuePair
+	for _, key := range f.keys {
+		if key >= start && len(pairs) < limit {
+			pairs = append(pairs, armada.KeyValuePair{Key: key})
+		}
+	}
+	return pairs, nil
+}
+
+func (f *fakeTable) EstimateKeyValuePairs(_ context.Context, _, prefix, _, _ string) (*armada.RangeEstimate, error) {
+	var keys int64
+	for _, key := range f.keys {
+		if strings.HasPrefix(key, prefix) {
+			keys++
+		}
+	}
+	return &armada.RangeEstimate{Keys: keys}, nil
+}
+
+type fakeTables map[string]stats.TableStats
+
+func (f fakeTables) Tables() map[string]stats.TableStats {
+	return f
+}
+
+// fakeStorage keeps the samples in memory
+type fakeStorage struct {
+	samples []metrics.ConsoleSample
+}
+
+func (f *fakeStorage) AppendSamples(_ context.Context, samples []metrics.ConsoleSample) error {
+	f.samples = append(f.samples, samples...)
+	return nil
+}
+
+func (f *fakeStorage) ReadSamples(_ context.Context, name string, match map[string]string, start, end time.Time) ([]metrics.ConsoleSample, error) {
+	var samples []metrics.ConsoleSample
+	for _, s := range f.samples {
+		matches := s.Name == name && !s.Time.Before(start) && !s.Time.After(end)
+		for label, value := range match {
+			matches = matches && s.Labels[label] == value
+		}
+		if matches {
+			samples = append(samples, s)
+		}
+	}
+	slices.SortStableFunc(samples, func(a, b metrics.ConsoleSample) int {
+		return strings.Compare(a.Labels["prefix"], b.Labels["prefix"])
+	})
+	return samples, nil
+}
+
+func TestRecord(t *testing.T) {
+	table := &fakeTable{}
+	for i := range 30 {
+		table.keys = append(table.keys, fmt.Sprintf("order/%02d", i))
+	}
+	for i := range 5 {
+		table.keys = append(table.keys, fmt.Sprintf("user/%02d", i))
+	}
+	table.keys = append(table.keys, "config", "\xff\xff")
+	slices.Sort(table.keys)
+	storage := &fakeStorage{}
+	r := NewRecorder(table, fakeTables{"shop": {DBSize: 4096}}, storage, time.Minute, nil, WithPrefixes("/", 10))
+
+	r.Record(context.Background())
+	counts := make(map[string]float64)
+	for _, s := range storage.samples {
+		assert.Equal(t, "shop", s.Labels["table"])
+		counts[s.Name+" "+s.Labels["prefix"]] = s.Value
+	}
+	assert.Equal(t, map[string]float64{
+		metrics.TableBytesMetric + " ":         4096,
+		metrics.TableKeysMetric + " ":          37,
+		metrics.PrefixKeysMetric + " config":   1,
+		metrics.PrefixKeysMetric + " order/":   30,
+		metrics.PrefixKeysMetric + " user/":    5,
+		metrics.PrefixKeysMetric + " \xff\xff": 1,
+	}, counts)
+
+	// Only the first prefixes are counted
+	storage.samples = nil
+	WithPrefixes("/", 2)(r)
+	r.Record(context.Background())
+	var prefixes []string
+	for _, s := range storage.samples {
+		if s.Name == metrics.PrefixKeysMetric {
+			prefixes = append(prefixes, s.Labels["prefix"])
+		}
+	}
+	assert.ElementsMatch(t, []string{"config", "order/"}, prefixes)
+}
+
+func TestAnalyze(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	storage := &fakeStorage{}
+	// Every day the table gains 100 keys and 1 MiB, orders grow faster than users
+	for day := 10; day >= 0; day-- {
+		at := now.AddDate(0, 0, -day)
+		keys := float64(1000 + 100*(10-day))
+		require.NoError(t, storage.AppendSamples(context.Background(), []metrics.ConsoleSample{
+			{Name: metrics.TableKeysMetric, Labels: map[string]string{"table": "shop"}, Time: at, Value: keys},
+			{Name: metrics.TableBytesMetric, Labels: map[string]string{"table": "shop"}, Time: at, Value: keys * (1 << 20) / 100},
+			{Name: metrics.PrefixKeysMetric, Labels: map[string]string{"table": "shop", "prefix": "order/"}, Time: at, Value: keys - 200},
+			{Name: metrics.PrefixKeysMetric, Labels: map[string]string{"table": "shop", "prefix": "user/"}, Time: at, Value: 200},
+			{Name: metrics.TableKeysMetric, Labels: map[string]string{"table": "other"}, Time: at, Value: 1},
+		}))
+	}
+
+	report, err := Analyze(context.Background(), storage, Query{Table: "shop", Days: 7, TopPrefixes: 5, SizeCap: 30 << 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000), report.Keys)
+	assert.Equal(t, int64(20<<20), report.Bytes)
+	require.Len(t, report.Days, 7)
+	assert.Equal(t, Day{Date: "2025-03-04", Keys: 1400, KeysDelta: 100, Bytes: 14 << 20, BytesDelta: 1 << 20}, report.Days[0])
+	assert.Equal(t, "2025-03-10", report.Days[6].Date)
+	assert.InDelta(t, 1<<20, report.BytesPerDay, 1)
+	assert.Equal(t, []PrefixGrowth{{Prefix: "order/", Keys: 1800, KeysDelta: 700}}, report.TopPrefixes,
+		"prefixes that didn't grow are left out")
+	require.NotNil(t, report.CapReachedAt)
+	assert.WithinDuration(t, now.AddDate(0, 0, 10), *report.CapReachedAt, time.Minute)
+
+	report, err = Analyze(context.Background(), storage, Query{Table: "shop", Days: 7, SizeCap: 10 << 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, now, *report.CapReachedAt, "the cap is already exceeded")
+
+	report, err = Analyze(context.Background(), storage, Query{Table: "missing", Days: 7, TopPrefixes: 5, SizeCap: 10 << 20}, now)
+	require.NoError(t, err)
+	assert.Empty(t, report.Days)
+	assert.Nil(t, report.RecordedAt)
+	assert.Nil(t, report.CapReachedAt)
+}
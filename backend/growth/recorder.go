@@ -0,0 +1,214 @@
+// Package growth follows how the keyspace of tables grows. A recorder periodically counts
+// the keys of every table and of its key prefixes and stores them in the TSDB together with
+// the sampled table sizes, reports derive daily deltas and projections from that history.
+package growth
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"go.uber.org/zap"
+)
+
+// Client counts the keys of tables. The armada.Client implements this interface.
+type Client interface {
+	// GetKeyValuePairs is used to find the first key after a prefix.
+	GetKeyValuePairs(ctx context.Context, table, prefix, start, end string, limit int) ([]armada.KeyValuePair, error)
+	// EstimateKeyValuePairs counts the keys of a table or of a prefix.
+	EstimateKeyValuePairs(ctx context.Context, table, prefix, start, end string) (*armada.RangeEstimate, error)
+}
+
+// TableSource provides the tables and their sizes. The stats.Sampler implements this interface.
+type TableSource interface {
+	Tables() map[string]stats.TableStats
+}
+
+// MetricSink stores the counts. The metrics.MetricsManager implements it.
+type MetricSink interface {
+	AppendSamples(ctx context.Context, samples []metrics.ConsoleSample) error
+}
+
+// RecorderOption configures optional behaviour of the Recorder
+type RecorderOption func(*Recorder)
+
+// WithPrefixes counts the keys by their first segment, which ends with the separator, for at most
+// maxPrefixes prefixes per table. Keys without the separator are a prefix of their own.
+func WithPrefixes(separator string, maxPrefixes int) RecorderOption {
+	return func(r *Recorder) {
+		r.separator = separator
+		r.maxPrefixes = maxPrefixes
+	}
+}
+
+// Recorder periodically stores the key count and size of every table as metrics.TableKeysMetric
+// and metrics.TableBytesMetric, and the key count of its prefixes as metrics.PrefixKeysMetric.
+type Recorder struct {
+	client   Client
+	tables   TableSource
+	sink     MetricSink
+	interval time.Duration
+	logger   *zap.Logger
+	// separator ends the prefixes keys are counted by
+	separator string
+	// maxPrefixes caps the prefixes counted per table, 0 counts none
+	maxPrefixes int
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRecorder creates a Recorder counting the keys at the given interval
+func NewRecorder(client Client, tables TableSource, sink MetricSink, interval time.Duration, logger *zap.Logger, opts ...RecorderOption) *Recorder {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	r := &Recorder{
+		client:   client,
+		tables:   tables,
+		sink:     sink,
+		interval: interval,
+		logger:   logger.Named("growth"),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start begins periodic recording in the background
+func (r *Recorder) Start(ctx context.Context) {
+	go r.run(ctx)
+}
+
+// Stop stops periodic recording
+func (r *Recorder) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// run records at every interval. The first round waits for an interval, so the table
+// statistics have been sampled by then.
+func (r *Recorder) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.Record(ctx)
+		case <-r.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Record counts the keys of every sampled table once and stores the counts. Tables that
+// can't be counted within the interval are skipped until the next round.
+func (r *Recorder) Record(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+
+	now := time.Now()
+	tables := r.tables.Tables()
+	var samples []metrics.ConsoleSample
+	for _, table := range slices.Sorted(maps.Keys(tables)) {
+		tableLabels := map[string]string{"table": table}
+		samples = append(samples, metrics.ConsoleSample{
+			Name: metrics.TableBytesMetric, Labels: tableLabels, Time: now, Value: float64(tables[table].DBSize),
+		})
+		estimate, err := r.client.EstimateKeyValuePairs(ctx, table, "", "", "")
+		if err != nil {
+			r.logger.Warn("Failed to count keys", zap.String("table", table), zap.Error(err))
+			continue
+		}
+		samples = append(samples, metrics.ConsoleSample{
+			Name: metrics.TableKeysMetric, Labels: tableLabels, Time: now, Value: float64(estimate.Keys),
+		})
+		counts, err := r.countPrefixes(ctx, table)
+		if err != nil {
+			r.logger.Warn("Failed to count keys by prefix", zap.String("table", table), zap.Error(err))
+		}
+		for prefix, keys := range counts {
+			samples = append(samples, metrics.ConsoleSample{
+				Name:   metrics.PrefixKeysMetric,
+				Labels: map[string]string{"table": table, "prefix": prefix},
+				Time:   now,
+				Value:  float64(keys),
+			})
+		}
+	}
+	if len(samples) == 0 {
+		return
+	}
+	if err := r.sink.AppendSamples(context.Background(), samples); err != nil {
+		r.logger.Warn("Failed to store key counts", zap.Error(err))
+		return
+	}
+	r.logger.Debug("Recorded key counts", zap.Int("tables", len(tables)), zap.Int("samples", len(samples)))
+}
+
+// countPrefixes counts the keys of the first prefixes of a table. It skips from prefix to prefix,
+// so only two requests are made per prefix however many keys it has. The prefixes counted before
+// an error are returned with it.
+func (r *Recorder) countPrefixes(ctx context.Context, table string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	start, end := "", ""
+	for len(counts) < r.maxPrefixes {
+		pairs, err := r.client.GetKeyValuePairs(ctx, table, "", start, end, 1)
+		if err != nil {
+			return counts, err
+		}
+		if len(pairs) == 0 {
+			return counts, nil
+		}
+		prefix := prefixOf(pairs[0].Key, r.separator)
+		estimate, err := r.client.EstimateKeyValuePairs(ctx, table, prefix, "", "")
+		if err != nil {
+			return counts, err
+		}
+		counts[prefix] = estimate.Keys
+		next, ok := prefixEnd(prefix)
+		if !ok {
+			return counts, nil
+		}
+		// The scan continues until the end of the table
+		start, end = next, "\x00"
+	}
+	return counts, nil
+}
+
+// prefixOf returns the first segment of key including the separator, or the whole key without one
+func prefixOf(key, separator string) string {
+	if separator == "" {
+		return key
+	}
+	i := strings.Index(key, separator)
+	if i < 0 {
+		return key
+	}
+	return key[:i+len(separator)]
+}
+
+// prefixEnd returns the first key after all keys starting with prefix. There is none if the
+// prefix only consists of 0xff bytes.
+func prefixEnd(prefix string) (string, bool) {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1]), true
+		}
+	}
+	return "", false
+}
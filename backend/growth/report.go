@@ -0,0 +1,193 @@
+package growth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/armadakv/console/backend/metrics"
+)
+
+// maxProjection is how far ahead the time to reach the size cap is projected
+const maxProjection = 10 * 365 * 24 * time.Hour
+
+// SampleReader reads the recorded counts. The metrics.MetricsManager implements it.
+type SampleReader interface {
+	ReadSamples(ctx context.Context, name string, match map[string]string, start, end time.Time) ([]metrics.ConsoleSample, error)
+}
+
+// Day is the last recorded state of a table on a day, in UTC
+type Day struct {
+	Date string `json:"date"`
+	Keys int64  `json:"keys"`
+	// KeysDelta is the change since the previous recorded day, 0 for the first one
+	KeysDelta int64 `json:"keysDelta"`
+	Bytes     int64 `json:"bytes"`
+	// BytesDelta is the change since the previous recorded day, 0 for the first one
+	BytesDelta int64 `json:"bytesDelta"`
+}
+
+// PrefixGrowth is the change of the key count of a prefix over the report
+type PrefixGrowth struct {
+	Prefix    string `json:"prefix"`
+	Keys      int64  `json:"keys"`
+	KeysDelta int64  `json:"keysDelta"`
+}
+
+// Report describes how a table grew over the last days
+type Report struct {
+	Table string `json:"table"`
+	// Keys and Bytes are the last recorded counts
+	Keys  int64 `json:"keys"`
+	Bytes int64 `json:"bytes"`
+	// RecordedAt is when the last counts were recorded, nil if there are none
+	RecordedAt *time.Time `json:"recordedAt,omitempty"`
+	// Days are the days with recorded counts, oldest first
+	Days []Day `json:"days"`
+	// TopPrefixes are the prefixes that gained the most keys, most first
+	TopPrefixes []PrefixGrowth `json:"topPrefixes"`
+	// BytesPerDay is the average growth of the size over the report
+	BytesPerDay float64 `json:"bytesPerDay"`
+	// SizeCap is the size the projection is made for, 0 if none
+	SizeCap int64 `json:"sizeCap,omitempty"`
+	// CapReachedAt projects when the table reaches SizeCap at BytesPerDay. It is nil if the table
+	// doesn't grow or only reaches it in more than 10 years, and the current time if it already
+	// exceeds the cap.
+	CapReachedAt *time.Time `json:"capReachedAt,omitempty"`
+}
+
+// Query selects what a report covers
+type Query struct {
+	Table string
+	// Days is the number of days covered, including today
+	Days int
+	// TopPrefixes caps the reported prefixes
+	TopPrefixes int
+	// SizeCap is the size the time to reach is projected for, 0 disables the projection
+	SizeCap int64
+}
+
+// Analyze reports the growth of a table from the recorded counts as of now
+func Analyze(ctx context.Context, reader SampleReader, q Query, now time.Time) (Report, error) {
+	report := Report{Table: q.Table, Days: make([]Day, 0), TopPrefixes: make([]PrefixGrowth, 0), SizeCap: q.SizeCap}
+	// The day before the first one is the baseline of its delta
+	start := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -q.Days)
+	match := map[string]string{"table": q.Table}
+
+	keys, err := reader.ReadSamples(ctx, metrics.TableKeysMetric, match, start, now)
+	if err != nil {
+		return report, fmt.Errorf("failed to read key counts: %w", err)
+	}
+	bytes, err := reader.ReadSamples(ctx, metrics.TableBytesMetric, match, start, now)
+	if err != nil {
+		return report, fmt.Errorf("failed to read table sizes: %w", err)
+	}
+	prefixes, err := reader.ReadSamples(ctx, metrics.PrefixKeysMetric, match, start, now)
+	if err != nil {
+		return report, fmt.Errorf("failed to read prefix key counts: %w", err)
+	}
+
+	days := make(map[string]*Day)
+	for _, s := range keys {
+		day := dayOf(days, s.Time)
+		day.Keys = int64(s.Value)
+	}
+	for _, s := range bytes {
+		day := dayOf(days, s.Time)
+		day.Bytes = int64(s.Value)
+	}
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	for i, date := range dates {
+		day := days[date]
+		if i > 0 {
+			day.KeysDelta = day.Keys - days[dates[i-1]].Keys
+			day.BytesDelta = day.Bytes - days[dates[i-1]].Bytes
+		}
+		report.Days = append(report.Days, *day)
+	}
+	// The baseline day is only used for the deltas
+	if len(report.Days) > q.Days {
+		report.Days = report.Days[len(report.Days)-q.Days:]
+	}
+
+	if len(keys) > 0 {
+		last := keys[len(keys)-1]
+		report.Keys = int64(last.Value)
+		report.RecordedAt = &last.Time
+	}
+	if len(bytes) > 0 {
+		first, last := bytes[0], bytes[len(bytes)-1]
+		report.Bytes = int64(last.Value)
+		if elapsed := last.Time.Sub(first.Time); elapsed > 0 {
+			report.BytesPerDay = (last.Value - first.Value) / elapsed.Hours() * 24
+		}
+		report.CapReachedAt = projectCap(report, now)
+	}
+	report.TopPrefixes = topPrefixes(prefixes, q.TopPrefixes)
+	return report, nil
+}
+
+// dayOf returns the day a sample was recorded on, creating it if needed
+func dayOf(days map[string]*Day, t time.Time) *Day {
+	date := t.UTC().Format(time.DateOnly)
+	day, ok := days[date]
+	if !ok {
+		day = &Day{Date: date}
+		days[date] = day
+	}
+	return day
+}
+
+// topPrefixes returns the limit prefixes whose key count grew the most between their first and
+// last sample. Prefixes that didn't grow are left out.
+func topPrefixes(samples []metrics.ConsoleSample, limit int) []PrefixGrowth {
+	first := make(map[string]float64)
+	last := make(map[string]float64)
+	for _, s := range samples {
+		prefix := s.Labels["prefix"]
+		if _, ok := first[prefix]; !ok {
+			first[prefix] = s.Value
+		}
+		last[prefix] = s.Value
+	}
+	growth := make([]PrefixGrowth, 0, len(last))
+	for prefix, keys := range last {
+		if delta := int64(keys - first[prefix]); delta > 0 {
+			growth = append(growth, PrefixGrowth{Prefix: prefix, Keys: int64(keys), KeysDelta: delta})
+		}
+	}
+	sort.Slice(growth, func(i, j int) bool {
+		if growth[i].KeysDelta != growth[j].KeysDelta {
+			return growth[i].KeysDelta > growth[j].KeysDelta
+		}
+		return growth[i].Prefix < growth[j].Prefix
+	})
+	if len(growth) > limit {
+		growth = growth[:limit]
+	}
+	return growth
+}
+
+// projectCap returns when the table reaches the size cap at its current growth, if within maxProjection
+func projectCap(report Report, now time.Time) *time.Time {
+	if report.SizeCap <= 0 {
+		return nil
+	}
+	if report.Bytes >= report.SizeCap {
+		return &now
+	}
+	if report.BytesPerDay <= 0 {
+		return nil
+	}
+	remaining := time.Duration(float64(report.SizeCap-report.Bytes) / report.BytesPerDay * float64(24*time.Hour))
+	if remaining <= 0 || remaining > maxProjection {
+		return nil
+	}
+	at := now.Add(remaining)
+	return &at
+}
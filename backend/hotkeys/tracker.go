@@ -0,0 +1,226 @@
+// Package hotkeys samples the key-value requests passing through the console and
+// reports the hottest key prefixes over a time window, highlighting skewed workloads.
+// Armada servers do not expose per-key request counters, so only the traffic proxied
+// by the console itself can be observed.
+package hotkeys
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op is the kind of a sampled request
+type Op string
+
+// Sampled request kinds
+const (
+	OpRead   Op = "read"
+	OpScan   Op = "scan"
+	OpWrite  Op = "write"
+	OpDelete Op = "delete"
+)
+
+const (
+	// bucketsPerWindow is the number of buckets the retained window is split into
+	bucketsPerWindow = 60
+	// maxKeysPerBucket bounds the memory used by a bucket, further keys are counted as overflow
+	maxKeysPerBucket = 10000
+	// minHotSamples is the number of samples of a table below which no prefix is reported as hot
+	minHotSamples = 20
+)
+
+// sampleKey identifies a sampled key
+type sampleKey struct {
+	table string
+	key   string
+}
+
+// opCounts counts the sampled requests of a key by kind
+type opCounts map[Op]uint64
+
+// bucket holds the samples of one slice of the window
+type bucket struct {
+	start    time.Time
+	counts   map[sampleKey]opCounts
+	overflow uint64
+}
+
+// Tracker samples requests into time buckets covering the retained window
+type Tracker struct {
+	rate   float64
+	window time.Duration
+	width  time.Duration
+	now    func() time.Time
+
+	// mu protects buckets
+	mu      sync.Mutex
+	buckets []bucket
+}
+
+// NewTracker creates a Tracker that samples the given fraction of requests and retains them for window
+func NewTracker(sampleRate float64, window time.Duration) *Tracker {
+	return &Tracker{
+		rate:    sampleRate,
+		window:  window,
+		width:   max(window/bucketsPerWindow, time.Second),
+		now:     time.Now,
+		buckets: make([]bucket, bucketsPerWindow),
+	}
+}
+
+// Record samples a request for a key. A nil tracker records nothing.
+func (t *Tracker) Record(table, key string, op Op) {
+	if t == nil || (t.rate < 1 && rand.Float64() >= t.rate) {
+		return
+	}
+	now := t.now()
+	start := now.Truncate(t.width)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := &t.buckets[int(start.UnixNano()/int64(t.width))%len(t.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start, counts: make(map[sampleKey]opCounts)}
+	}
+	sk := sampleKey{table: table, key: key}
+	counts, ok := b.counts[sk]
+	if !ok {
+		if len(b.counts) >= maxKeysPerBucket {
+			b.overflow++
+			return
+		}
+		counts = make(opCounts)
+		b.counts[sk] = counts
+	}
+	counts[op]++
+}
+
+// Query selects what is aggregated in a report
+type Query struct {
+	// Table restricts the report to one table, all tables are reported when empty.
+	Table string
+	// Window is how far back samples are aggregated, capped at the retained window.
+	Window time.Duration
+	// Separator splits keys into segments, e.g. "/".
+	Separator string
+	// Depth is the number of leading key segments forming a prefix.
+	Depth int
+	// Limit is the maximum number of prefixes reported.
+	Limit int
+}
+
+// PrefixStats are the sampled requests of a key prefix
+type PrefixStats struct {
+	Table  string `json:"table"`
+	Prefix string `json:"prefix"`
+	// Samples is the number of sampled requests.
+	Samples uint64 `json:"samples"`
+	// Estimated extrapolates the number of requests from the sample rate.
+	Estimated float64 `json:"estimated"`
+	// Ops breaks the samples down by request kind.
+	Ops map[Op]uint64 `json:"ops"`
+	// Share is the fraction of the table's samples that hit this prefix.
+	Share float64 `json:"share"`
+	// SkewFactor compares the prefix with an even spread over the table's prefixes; 1 is even.
+	SkewFactor float64 `json:"skewFactor"`
+	// Hot is set when the prefix receives the majority of a table's requests
+	// while other prefixes of the table are requested as well.
+	Hot bool `json:"hot"`
+}
+
+// Report lists the hottest key prefixes
+type Report struct {
+	Window     time.Duration `json:"window"`
+	SampleRate float64       `json:"sampleRate"`
+	// Samples is the number of sampled requests in the window.
+	Samples uint64 `json:"samples"`
+	// Overflow counts samples not attributed to a key because too many distinct keys were requested.
+	Overflow uint64 `json:"overflow"`
+	// Skewed is set when at least one prefix is hot.
+	Skewed   bool          `json:"skewed"`
+	Prefixes []PrefixStats `json:"prefixes"`
+}
+
+// Top aggregates the samples of the window by key prefix, hottest first
+func (t *Tracker) Top(q Query) Report {
+	window := min(q.Window, t.window)
+	if window <= 0 {
+		window = t.window
+	}
+	cutoff := t.now().Add(-window)
+
+	report := Report{Window: window, SampleRate: t.rate, Prefixes: make([]PrefixStats, 0)}
+	byPrefix := make(map[sampleKey]*PrefixStats)
+	tableSamples := make(map[string]uint64)
+	tablePrefixes := make(map[string]int)
+
+	t.mu.Lock()
+	for _, b := range t.buckets {
+		// A bucket is included if any part of it lies within the window
+		if b.counts == nil || !b.start.Add(t.width).After(cutoff) {
+			continue
+		}
+		report.Overflow += b.overflow
+		for sk, counts := range b.counts {
+			if q.Table != "" && sk.table != q.Table {
+				continue
+			}
+			pk := sampleKey{table: sk.table, key: prefixOf(sk.key, q.Separator, q.Depth)}
+			stats, ok := byPrefix[pk]
+			if !ok {
+				stats = &PrefixStats{Table: pk.table, Prefix: pk.key, Ops: make(map[Op]uint64)}
+				byPrefix[pk] = stats
+				tablePrefixes[pk.table]++
+			}
+			for op, n := range counts {
+				stats.Ops[op] += n
+				stats.Samples += n
+				tableSamples[pk.table] += n
+				report.Samples += n
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	for _, stats := range byPrefix {
+		total := tableSamples[stats.Table]
+		distinct := tablePrefixes[stats.Table]
+		stats.Estimated = float64(stats.Samples) / t.rate
+		stats.Share = float64(stats.Samples) / float64(total)
+		stats.SkewFactor = stats.Share * float64(distinct)
+		stats.Hot = total >= minHotSamples && distinct > 1 && stats.Share > 0.5
+		report.Skewed = report.Skewed || stats.Hot
+		report.Prefixes = append(report.Prefixes, *stats)
+	}
+	slices.SortFunc(report.Prefixes, func(a, b PrefixStats) int {
+		if c := cmp.Compare(b.Samples, a.Samples); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Table+"\x00"+a.Prefix, b.Table+"\x00"+b.Prefix)
+	})
+	if q.Limit > 0 && len(report.Prefixes) > q.Limit {
+		report.Prefixes = report.Prefixes[:q.Limit]
+	}
+	return report
+}
+
+// prefixOf returns the first depth segments of key including the trailing separator.
+// Keys with fewer segments are returned whole; without a separator the whole key is the prefix.
+func prefixOf(key, separator string, depth int) string {
+	if separator == "" || depth <= 0 {
+		return key
+	}
+	end := 0
+	for range depth {
+		i := strings.Index(key[end:], separator)
+		if i < 0 {
+			return key
+		}
+		end += i + len(separator)
+	}
+	return key[:end]
+}
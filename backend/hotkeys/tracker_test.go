@@ -0,0 +1,78 @@
+package hotkeys
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTracker creates a tracker sampling every request with a controllable clock
+func newTestTracker(now *time.Time) *Tracker {
+	t := NewTracker(1, time.Hour)
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestPrefixOf(t *testing.T) {
+	assert.Equal(t, "users/", prefixOf("users/42/profile", "/", 1))
+	assert.Equal(t, "users/42/", prefixOf("users/42/profile", "/", 2))
+	assert.Equal(t, "users/42", prefixOf("users/42", "/", 2))
+	assert.Equal(t, "plain", prefixOf("plain", "/", 1))
+	assert.Equal(t, "users/42", prefixOf("users/42", "", 1))
+}
+
+func TestTopReportsHotPrefix(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+
+	for i := range 30 {
+		tracker.Record("users", fmt.Sprintf("session/%d", i%3), OpRead)
+	}
+	tracker.Record("users", "profile/1", OpWrite)
+	tracker.Record("users", "profile/2", OpRead)
+	tracker.Record("orders", "2025/01", OpScan)
+
+	report := tracker.Top(Query{Separator: "/", Depth: 1, Window: 15 * time.Minute})
+	assert.Equal(t, uint64(33), report.Samples)
+	assert.True(t, report.Skewed)
+	require.Len(t, report.Prefixes, 3)
+
+	hottest := report.Prefixes[0]
+	assert.Equal(t, "users", hottest.Table)
+	assert.Equal(t, "session/", hottest.Prefix)
+	assert.Equal(t, uint64(30), hottest.Samples)
+	assert.Equal(t, uint64(30), hottest.Ops[OpRead])
+	assert.InDelta(t, 30.0/32, hottest.Share, 1e-9)
+	assert.True(t, hottest.Hot)
+	assert.False(t, report.Prefixes[1].Hot)
+
+	// Only the requested table is reported
+	report = tracker.Top(Query{Table: "orders", Separator: "/", Depth: 1, Window: time.Hour})
+	require.Len(t, report.Prefixes, 1)
+	assert.False(t, report.Prefixes[0].Hot, "a table with a single prefix is not skewed")
+}
+
+func TestTopOnlyAggregatesWindow(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now)
+
+	tracker.Record("users", "old", OpRead)
+	now = now.Add(30 * time.Minute)
+	tracker.Record("users", "new", OpRead)
+
+	report := tracker.Top(Query{Window: 10 * time.Minute, Limit: 10})
+	require.Len(t, report.Prefixes, 1)
+	assert.Equal(t, "new", report.Prefixes[0].Prefix)
+
+	// Samples older than the retained window are overwritten
+	now = now.Add(2 * time.Hour)
+	assert.Empty(t, tracker.Top(Query{Window: time.Hour}).Prefixes)
+}
+
+func TestRecordOnNilTracker(t *testing.T) {
+	var tracker *Tracker
+	assert.NotPanics(t, func() { tracker.Record("users", "key", OpRead) })
+}
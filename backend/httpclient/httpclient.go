@@ -0,0 +1,153 @@
+// Package httpclient creates the HTTP clients of the console's outbound integrations, e.g. the
+// OIDC provider, Consul discovery or Sentry. All of them identify the console with the same
+// User-Agent, send the configured extra headers and go through the configured proxy, so egress
+// gateways and firewalls can tell the console's traffic apart.
+package httpclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"slices"
+	"time"
+)
+
+// Transport timeouts, requests are bounded as a whole by the timeout of their client
+const (
+	dialTimeout           = 10 * time.Second
+	tlsHandshakeTimeout   = 10 * time.Second
+	responseHeaderTimeout = 30 * time.Second
+	idleConnTimeout       = 90 * time.Second
+)
+
+// proxySchemes are the supported schemes of proxy URLs
+var proxySchemes = []string{"http", "https", "socks5"}
+
+// Options configure the outbound HTTP clients
+type Options struct {
+	// Headers are sent with every request, e.g. a token an egress gateway expects
+	Headers http.Header
+	// Proxy is the URL of the proxy requests are sent through. Empty uses the proxy of the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+	Proxy string
+	// UserAgent identifies the console, DefaultUserAgent if empty
+	UserAgent string
+	// Timeout bounds every request of the clients not created with their own timeout
+	Timeout time.Duration
+}
+
+// Factory creates HTTP clients sharing one transport, so connections to the same host are reused.
+// It is safe for concurrent use.
+type Factory struct {
+	transport *http.Transport
+	headers   http.Header
+	timeout   time.Duration
+}
+
+// NewFactory creates a factory of clients configured by opts
+func NewFactory(opts Options) (*Factory, error) {
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		u, err := url.Parse(opts.Proxy)
+		if err != nil || u.Host == "" || !slices.Contains(proxySchemes, u.Scheme) {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	headers := opts.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent()
+	}
+	headers.Set("User-Agent", userAgent)
+
+	return &Factory{
+		transport: &http.Transport{
+			Proxy:                 proxy,
+			DialContext:           (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   tlsHandshakeTimeout,
+			ResponseHeaderTimeout: responseHeaderTimeout,
+			IdleConnTimeout:       idleConnTimeout,
+			MaxIdleConns:          100,
+			ExpectContinueTimeout: time.Second,
+		},
+		headers: headers,
+		timeout: opts.Timeout,
+	}, nil
+}
+
+// Client returns a client whose requests are bounded by timeout, the timeout of the options if
+// it is zero
+func (f *Factory) Client(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = f.timeout
+	}
+	return &http.Client{Transport: f.Transport(), Timeout: timeout}
+}
+
+// Transport returns the transport sending the headers and the User-Agent with every request,
+// for libraries that create their own clients
+func (f *Factory) Transport() http.RoundTripper {
+	return &headerTransport{base: f.transport, headers: f.headers}
+}
+
+// Proxy returns the proxy of the requests, for libraries that create their own transports
+func (f *Factory) Proxy() func(*http.Request) (*url.URL, error) {
+	return f.transport.Proxy
+}
+
+// Headers returns the headers sent with every request including the User-Agent, for libraries
+// that take them as a map
+func (f *Factory) Headers() map[string]string {
+	headers := make(map[string]string, len(f.headers))
+	for name := range f.headers {
+		headers[name] = f.headers.Get(name)
+	}
+	return headers
+}
+
+// UserAgent returns the User-Agent the console identifies itself with
+func (f *Factory) UserAgent() string {
+	return f.headers.Get("User-Agent")
+}
+
+// headerTransport adds headers to every request it sends
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+// RoundTrip sends the request with the headers. Headers set by the caller take precedence, except
+// the User-Agent libraries set to identify themselves.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	for name, values := range t.headers {
+		if _, ok := req.Header[name]; !ok || name == "User-Agent" {
+			req.Header[name] = slices.Clone(values)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// DefaultUserAgent identifies the console and its version, e.g. armada-console/v1.4.0
+func DefaultUserAgent() string {
+	return "armada-console/" + Version()
+}
+
+// Version returns the version of the console binary from its build information, dev if the
+// binary was not built from a tagged module version
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return "dev"
+	}
+	return info.Main.Version
+}
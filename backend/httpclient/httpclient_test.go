@@ -0,0 +1,60 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSendsHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	f, err := NewFactory(Options{
+		Headers: http.Header{"X-Team": {"ops"}, "Authorization": {"Bearer gateway"}},
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	client := f.Client(0)
+	assert.Equal(t, time.Second, client.Timeout)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer caller")
+	req.Header.Set("User-Agent", "library/1.0")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "ops", got.Get("X-Team"))
+	assert.Equal(t, "Bearer caller", got.Get("Authorization"), "headers of the caller take precedence")
+	assert.True(t, strings.HasPrefix(got.Get("User-Agent"), "armada-console/"), got.Get("User-Agent"))
+	assert.Equal(t, "library/1.0", req.Header.Get("User-Agent"), "the request of the caller is not modified")
+}
+
+func TestCustomUserAgent(t *testing.T) {
+	f, err := NewFactory(Options{UserAgent: "ops-console/2"})
+	require.NoError(t, err)
+	assert.Equal(t, "ops-console/2", f.UserAgent())
+	assert.Equal(t, map[string]string{"User-Agent": "ops-console/2"}, f.Headers())
+	assert.Equal(t, 5*time.Second, f.Client(5*time.Second).Timeout)
+}
+
+func TestProxy(t *testing.T) {
+	f, err := NewFactory(Options{Proxy: "http://proxy.internal:3128"})
+	require.NoError(t, err)
+	proxy, err := f.Proxy()(httptest.NewRequest(http.MethodGet, "https://idp.example.com/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "proxy.internal:3128", proxy.Host)
+
+	_, err = NewFactory(Options{Proxy: "ftp://proxy.internal"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,379 @@
+// Package indexes maintains index tables listing the keys of a table whose JSON values match a
+// filter. They are rebuilt on a schedule by scanning the table, so the key scan endpoint can answer
+// a repeated filter from the index table instead of reading and evaluating every value.
+package indexes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/query"
+	"go.uber.org/zap"
+)
+
+// Namespace is the metadata namespace the index definitions are stored in
+const Namespace = "indexes"
+
+// TablePrefix starts the names of the index tables, which are named after their index
+const TablePrefix = "__idx_"
+
+const (
+	// DefaultInterval is how often an index is rebuilt unless configured otherwise
+	DefaultInterval = 15 * time.Minute
+	// MinInterval is the shortest interval an index can be rebuilt at
+	MinInterval = time.Minute
+	// checkInterval is how often the builder looks for indexes due for a rebuild
+	checkInterval = time.Minute
+	// scanBatch is the number of pairs read at a time while scanning a table
+	scanBatch = 1000
+)
+
+var (
+	// ErrNotFound is returned when an index does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrExists is returned when creating an index with the name of another index or table
+	ErrExists = errors.New("already exists")
+	// ErrInvalid is returned for indexes that can't be created
+	ErrInvalid = errors.New("invalid")
+)
+
+// validName matches the names of indexes, which are part of the name of their table
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,62}$`)
+
+// Index lists the keys of a table whose JSON value matches a filter
+type Index struct {
+	Name string `json:"name"`
+	// Table is the indexed table.
+	Table string `json:"table"`
+	// Filter is the canonical form of the filter, scans with an equivalent filter use the index.
+	Filter string `json:"filter"`
+	// Interval is how often the index is rebuilt.
+	Interval  time.Duration `json:"interval"`
+	CreatedBy string        `json:"createdBy,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+	// BuiltAt is when the last successful build started, the index reflects the table as of
+	// then. Scans only use indexes that have been built.
+	BuiltAt *time.Time `json:"builtAt,omitempty"`
+	// AttemptedAt is when the last build started, successful or not.
+	AttemptedAt *time.Time `json:"attemptedAt,omitempty"`
+	// Keys is the number of keys the last successful build found.
+	Keys int `json:"keys"`
+	// Error is why the last build failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// IndexTable returns the name of the table of the index
+func IndexTable(name string) string {
+	return TablePrefix + name
+}
+
+// Client is the part of the Armada client the builder maintains index tables with
+type Client interface {
+	CreateTable(ctx context.Context, tableName string) (string, error)
+	DeleteTable(ctx context.Context, tableName string) error
+	GetKeyValuePairs(ctx context.Context, table, prefix, start, end string, limit int) ([]armada.KeyValuePair, error)
+	PutKeyValue(ctx context.Context, table, key, value string) error
+	DeleteKey(ctx context.Context, table, key string) error
+}
+
+// Builder keeps the index definitions in the metadata store and rebuilds the index tables in the
+// background. It is safe for concurrent use.
+type Builder struct {
+	client Client
+	store  metadata.Store
+	logger *zap.Logger
+	now    func() time.Time
+
+	// mu serializes changes of the definitions, so names stay unique
+	mu sync.Mutex
+	// trigger receives the names of indexes to rebuild now
+	trigger chan string
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewBuilder creates a Builder maintaining index tables of the cluster of the client
+func NewBuilder(client Client, store metadata.Store, logger *zap.Logger) *Builder {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Builder{
+		client:  client,
+		store:   store,
+		logger:  logger.Named("index-builder"),
+		now:     time.Now,
+		trigger: make(chan string, 16),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start begins rebuilding the indexes in the background
+func (b *Builder) Start(ctx context.Context) {
+	go b.run(ctx)
+}
+
+// Stop stops rebuilding the indexes
+func (b *Builder) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// Indexes returns all indexes ordered by name
+func (b *Builder) Indexes() ([]Index, error) {
+	records, err := metadata.List[Index](b.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]Index, 0, len(records))
+	for _, idx := range records {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return indexes, nil
+}
+
+// Get returns an index, or ErrNotFound
+func (b *Builder) Get(name string) (Index, error) {
+	idx, err := metadata.Get[Index](b.store, Namespace, name)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Index{}, fmt.Errorf("index %s %w", name, ErrNotFound)
+	}
+	return idx, err
+}
+
+// Create stores an index, creates its table and schedules its first build. The filter is stored
+// in its canonical form and a zero interval is replaced by DefaultInterval.
+func (b *Builder) Create(ctx context.Context, idx Index) (Index, error) {
+	if !validName.MatchString(idx.Name) {
+		return Index{}, fmt.Errorf("%w index name %q: use up to 63 letters, digits, _ and -", ErrInvalid, idx.Name)
+	}
+	if idx.Table == "" || strings.HasPrefix(idx.Table, TablePrefix) {
+		return Index{}, fmt.Errorf("%w index: table must be set and not be an index table, got %q", ErrInvalid, idx.Table)
+	}
+	filter, err := query.ParseFilter(idx.Filter)
+	if err != nil {
+		return Index{}, fmt.Errorf("%w index: %w", ErrInvalid, err)
+	}
+	if idx.Interval == 0 {
+		idx.Interval = DefaultInterval
+	}
+	if idx.Interval < MinInterval {
+		return Index{}, fmt.Errorf("%w index: interval must be at least %s, got %s", ErrInvalid, MinInterval, idx.Interval)
+	}
+	idx.Filter = filter.String()
+	idx.CreatedAt = b.now().UTC()
+	idx.BuiltAt, idx.AttemptedAt, idx.Keys, idx.Error = nil, nil, 0, ""
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.Get(idx.Name); err == nil {
+		return Index{}, fmt.Errorf("index %s %w", idx.Name, ErrExists)
+	} else if !errors.Is(err, ErrNotFound) {
+		return Index{}, err
+	}
+	// An existing table of the name may hold other data, it is never taken over
+	if _, err := b.client.CreateTable(ctx, IndexTable(idx.Name)); errors.Is(err, armada.ErrTableExists) {
+		return Index{}, fmt.Errorf("table %s %w", IndexTable(idx.Name), ErrExists)
+	} else if err != nil {
+		return Index{}, fmt.Errorf("failed to create index table: %w", err)
+	}
+	if err := metadata.Put(b.store, Namespace, idx.Name, idx); err != nil {
+		return Index{}, err
+	}
+	b.Rebuild(idx.Name)
+	return idx, nil
+}
+
+// Delete removes an index and its table
+func (b *Builder) Delete(ctx context.Context, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, err := b.Get(name); err != nil {
+		return err
+	}
+	if err := b.store.Delete(Namespace, name); err != nil {
+		return err
+	}
+	if err := b.client.DeleteTable(ctx, IndexTable(name)); err != nil && !errors.Is(err, armada.ErrTableNotFound) {
+		return fmt.Errorf("failed to delete index table: %w", err)
+	}
+	return nil
+}
+
+// Rebuild schedules a build of the index, it is skipped if too many builds are pending
+func (b *Builder) Rebuild(name string) {
+	select {
+	case b.trigger <- name:
+	default:
+		b.logger.Warn("Too many pending index builds, skipping", zap.String("index", name))
+	}
+}
+
+// Lookup returns the built index of the table with the filter, if any
+func (b *Builder) Lookup(table string, filter query.Filter) (Index, bool) {
+	indexes, err := b.Indexes()
+	if err != nil {
+		b.logger.Warn("Failed to read indexes", zap.Error(err))
+		return Index{}, false
+	}
+	canonical := filter.String()
+	for _, idx := range indexes {
+		if idx.Table == table && idx.Filter == canonical && idx.BuiltAt != nil {
+			return idx, true
+		}
+	}
+	return Index{}, false
+}
+
+// Build rebuilds an index now: it scans the table, adds the keys of matching values to the index
+// table and removes the keys of values that no longer match. The outcome is stored with the index.
+func (b *Builder) Build(ctx context.Context, name string) error {
+	idx, err := b.Get(name)
+	if err != nil {
+		return err
+	}
+	filter, err := query.ParseFilter(idx.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter of index %s: %w", name, err)
+	}
+	started := b.now().UTC()
+	keys, buildErr := b.build(ctx, idx, filter)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// The index may have been deleted during the build
+	if idx, err = b.Get(name); err != nil {
+		return err
+	}
+	idx.AttemptedAt = &started
+	if buildErr != nil {
+		idx.Error = buildErr.Error()
+	} else {
+		idx.BuiltAt, idx.Keys, idx.Error = &started, keys, ""
+	}
+	if err := metadata.Put(b.store, Namespace, name, idx); err != nil {
+		return err
+	}
+	return buildErr
+}
+
+// build brings the index table up to date and returns the number of matching keys
+func (b *Builder) build(ctx context.Context, idx Index, filter query.Filter) (int, error) {
+	matching := make(map[string]bool)
+	err := b.scan(ctx, idx.Table, func(pair armada.KeyValuePair) error {
+		if doc, err := query.DecodeDocument(pair.Value); err == nil && filter.Match(doc) {
+			matching[pair.Key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to scan table %s: %w", idx.Table, err)
+	}
+
+	table := IndexTable(idx.Name)
+	indexed := make(map[string]bool)
+	err = b.scan(ctx, table, func(pair armada.KeyValuePair) error {
+		indexed[pair.Key] = true
+		if matching[pair.Key] {
+			return nil
+		}
+		return b.client.DeleteKey(ctx, table, pair.Key)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update index table %s: %w", table, err)
+	}
+	for key := range matching {
+		if indexed[key] {
+			continue
+		}
+		if err := b.client.PutKeyValue(ctx, table, key, ""); err != nil {
+			return 0, fmt.Errorf("failed to update index table %s: %w", table, err)
+		}
+	}
+	return len(matching), nil
+}
+
+// scan calls fn with every pair of a table in key order
+func (b *Builder) scan(ctx context.Context, table string, fn func(armada.KeyValuePair) error) error {
+	start, end := "", ""
+	for {
+		pairs, err := b.client.GetKeyValuePairs(ctx, table, "", start, end, scanBatch)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			if err := fn(pair); err != nil {
+				return err
+			}
+		}
+		if len(pairs) < scanBatch {
+			return nil
+		}
+		// Continue after the last key up to the end of the table
+		start, end = pairs[len(pairs)-1].Key+"\x00", "\x00"
+	}
+}
+
+// run rebuilds the indexes that are due, and the ones triggered by Rebuild, until stopped
+func (b *Builder) run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	b.buildDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.done:
+			return
+		case name := <-b.trigger:
+			b.buildLogged(ctx, name)
+		case <-ticker.C:
+			b.buildDue(ctx)
+		}
+	}
+}
+
+// buildDue rebuilds the indexes whose last build started longer than their interval ago, so
+// failing builds are retried at the interval too
+func (b *Builder) buildDue(ctx context.Context) {
+	indexes, err := b.Indexes()
+	if err != nil {
+		b.logger.Error("Failed to read indexes", zap.Error(err))
+		return
+	}
+	now := b.now()
+	for _, idx := range indexes {
+		if idx.AttemptedAt == nil || now.Sub(*idx.AttemptedAt) >= idx.Interval {
+			b.buildLogged(ctx, idx.Name)
+		}
+	}
+}
+
+// buildLogged rebuilds an index within its interval and logs the outcome
+func (b *Builder) buildLogged(ctx context.Context, name string) {
+	idx, err := b.Get(name)
+	if err != nil {
+		// Deleted since the build was scheduled
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, idx.Interval)
+	defer cancel()
+	started := time.Now()
+	if err := b.Build(ctx, name); err != nil {
+		b.logger.Error("Failed to build index", zap.Error(err), zap.String("index", name), zap.String("table", idx.Table))
+		return
+	}
+	b.logger.Info("Built index", zap.String("index", name), zap.String("table", idx.Table),
+		zap.Duration("duration", time.Since(started)))
+}
@@ -0,0 +1,136 @@
+package indexes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCluster keeps tables in memory
+type fakeCluster struct {
+	tables map[string]map[string]string
+}
+
+func (c *fakeCluster) CreateTable(_ context.Context, name string) (string, error) {
+	if _, ok := c.tables[name]; ok {
+		return "", armada.ErrTableExists
+	}
+	c.tables[name] = make(map[string]string)
+	return name, nil
+}
+
+func (c *fakeCluster) DeleteTable(_ context.Context, name string) error {
+	if _, ok := c.tables[name]; !ok {
+		return armada.ErrTableNotFound
+	}
+	delete(c.tables, name)
+	return nil
+}
+
+func (c *fakeCluster) GetKeyValuePairs(_ context.Context, table, _, start, _ string, limit int) ([]armada.KeyValuePair, error) {
+	pairs, ok := c.tables[table]
+	if !ok {
+		return nil, armada.ErrTableNotFound
+	}
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		if key >= start {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	result := make([]armada.KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, armada.KeyValuePair{Key: key, Value: pairs[key]})
+	}
+	return result, nil
+}
+
+func (c *fakeCluster) PutKeyValue(_ context.Context, table, key, value string) error {
+	c.tables[table][key] = value
+	return nil
+}
+
+func (c *fakeCluster) DeleteKey(_ context.Context, table, key string) error {
+	delete(c.tables[table], key)
+	return nil
+}
+
+func TestBuilder(t *testing.T) {
+	ctx := context.Background()
+	cluster := &fakeCluster{tables: map[string]map[string]string{"jobs": {}}}
+	for i := range 2500 {
+		status := "done"
+		if i%100 == 0 {
+			status = "active"
+		}
+		cluster.tables["jobs"][fmt.Sprintf("job/%04d", i)] = fmt.Sprintf(`{"status":%q}`, status)
+	}
+	cluster.tables["jobs"]["raw"] = "not json"
+	b := NewBuilder(cluster, metadata.NewMemoryStore(), nil)
+
+	idx, err := b.Create(ctx, Index{Name: "active-jobs", Table: "jobs", Filter: `status=='active'`})
+	require.NoError(t, err)
+	assert.Equal(t, `$.status == "active"`, idx.Filter, "the filter is stored in its canonical form")
+	assert.Equal(t, DefaultInterval, idx.Interval)
+	assert.Contains(t, cluster.tables, IndexTable("active-jobs"))
+
+	_, err = b.Create(ctx, Index{Name: "active-jobs", Table: "jobs", Filter: `status`})
+	assert.ErrorIs(t, err, ErrExists)
+	_, err = b.Create(ctx, Index{Name: "bad", Table: "jobs", Filter: `status ==`})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = b.Create(ctx, Index{Name: "nested", Table: IndexTable("active-jobs"), Filter: `status`})
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = b.Create(ctx, Index{Name: "fast", Table: "jobs", Filter: `status`, Interval: time.Second})
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	filter, err := query.ParseFilter(`$.status == "active"`)
+	require.NoError(t, err)
+	_, ok := b.Lookup("jobs", filter)
+	assert.False(t, ok, "indexes are only used once built")
+
+	require.NoError(t, b.Build(ctx, "active-jobs"))
+	idx, ok = b.Lookup("jobs", filter)
+	require.True(t, ok)
+	assert.Equal(t, 25, idx.Keys)
+	assert.Len(t, cluster.tables[IndexTable("active-jobs")], 25)
+	assert.Contains(t, cluster.tables[IndexTable("active-jobs")], "job/0100")
+
+	// Rebuilds add new matches and remove values that no longer match
+	cluster.tables["jobs"]["job/0100"] = `{"status":"done"}`
+	cluster.tables["jobs"]["job/0101"] = `{"status":"active"}`
+	require.NoError(t, b.Build(ctx, "active-jobs"))
+	assert.NotContains(t, cluster.tables[IndexTable("active-jobs")], "job/0100")
+	assert.Contains(t, cluster.tables[IndexTable("active-jobs")], "job/0101")
+
+	// Failed builds keep the last successful one
+	delete(cluster.tables, "jobs")
+	assert.Error(t, b.Build(ctx, "active-jobs"))
+	idx, err = b.Get("active-jobs")
+	require.NoError(t, err)
+	assert.NotEmpty(t, idx.Error)
+	assert.Equal(t, 25, idx.Keys)
+
+	require.NoError(t, b.Delete(ctx, "active-jobs"))
+	assert.NotContains(t, cluster.tables, IndexTable("active-jobs"))
+	assert.ErrorIs(t, b.Delete(ctx, "active-jobs"), ErrNotFound)
+}
+
+func TestBuilderRefusesExistingTables(t *testing.T) {
+	cluster := &fakeCluster{tables: map[string]map[string]string{"jobs": {}, IndexTable("jobs"): {"k": "v"}}}
+	b := NewBuilder(cluster, metadata.NewMemoryStore(), nil)
+	_, err := b.Create(context.Background(), Index{Name: "jobs", Table: "jobs", Filter: `status`})
+	assert.ErrorIs(t, err, ErrExists)
+	assert.Equal(t, map[string]string{"k": "v"}, cluster.tables[IndexTable("jobs")])
+}
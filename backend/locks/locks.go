@@ -0,0 +1,234 @@
+// Package locks inspects the distributed locks and elections applications keep in Armada after
+// one of the lock or election conventions, such as etcd's concurrency package: every contender
+// writes a key below the prefix of the lock, the contender whose key was created first holds the
+// lock or leads the election and the others wait in the order they arrived.
+package locks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/conventions"
+)
+
+// maxKeys is the most keys read for an inspection
+const maxKeys = 10000
+
+var (
+	// ErrNotFound is returned when releasing a key that does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned when releasing a key that follows no lock or election convention
+	ErrInvalid = errors.New("invalid")
+	// ErrNotHolder is returned when releasing a key that doesn't hold its lock (anymore)
+	ErrNotHolder = errors.New("does not hold the lock")
+)
+
+// Client is the part of the Armada client locks are read and released with
+type Client interface {
+	GetKeyRevisions(ctx context.Context, table, prefix string, limit int) ([]armada.KeyRevision, error)
+	DeleteKey(ctx context.Context, table, key string) error
+}
+
+// Contender is a key competing for a lock or an election
+type Contender struct {
+	Key string `json:"key"`
+	// Value is the value of the key, e.g. the proposal of an election candidate.
+	Value string `json:"value"`
+	// Fields are the values decoded from the key by its convention, e.g. the lease.
+	Fields         map[string]string `json:"fields"`
+	CreateRevision int64             `json:"createRevision"`
+	ModRevision    int64             `json:"modRevision"`
+	// ObservedSince is when the console first saw the key in this position. Armada doesn't
+	// store when keys were written, so it is no later than the acquisition and resets when
+	// the console restarts.
+	ObservedSince time.Time `json:"observedSince"`
+}
+
+// Lock is a lock or an election with its contenders
+type Lock struct {
+	// Prefix is the prefix the contenders write their keys below.
+	Prefix     string           `json:"prefix"`
+	Convention string           `json:"convention"`
+	Kind       conventions.Kind `json:"kind"`
+	// Holder holds the lock or leads the election.
+	Holder Contender `json:"holder"`
+	// Waiters are the other contenders in the order they arrived.
+	Waiters []Contender `json:"waiters"`
+}
+
+// Report lists the locks and elections of a table
+type Report struct {
+	Table  string `json:"table"`
+	Prefix string `json:"prefix,omitempty"`
+	// Truncated is set when the table has more keys than were read.
+	Truncated bool   `json:"truncated"`
+	Locks     []Lock `json:"locks"`
+}
+
+// Inspector finds the locks and elections of a table and releases stuck ones
+type Inspector struct {
+	client   Client
+	registry *conventions.Registry
+	now      func() time.Time
+
+	// mu protects observed
+	mu sync.Mutex
+	// observed holds when a contender was first seen in its position, by table, key,
+	// create revision and position
+	observed map[string]time.Time
+}
+
+// NewInspector creates an Inspector recognizing locks with the conventions of the registry
+func NewInspector(client Client, registry *conventions.Registry) *Inspector {
+	return &Inspector{
+		client:   client,
+		registry: registry,
+		now:      time.Now,
+		observed: make(map[string]time.Time),
+	}
+}
+
+// Inspect lists the locks and elections of a table whose keys start with prefix, by prefix
+func (i *Inspector) Inspect(ctx context.Context, table, prefix string) (Report, error) {
+	matcher, err := i.registry.Matcher()
+	if err != nil {
+		return Report{}, err
+	}
+	// One more key than read tells whether the table has more
+	revisions, err := i.client.GetKeyRevisions(ctx, table, prefix, maxKeys+1)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Report{Table: table, Prefix: prefix, Locks: []Lock{}}
+	if len(revisions) > maxKeys {
+		revisions = revisions[:maxKeys]
+		report.Truncated = true
+	}
+
+	locks := group(matcher, table, revisions)
+	now := i.now()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	seen := make(map[string]bool)
+	for _, lock := range locks {
+		lock.Holder.ObservedSince = i.observe(seen, table, lock.Holder, "holder", now)
+		for n := range lock.Waiters {
+			lock.Waiters[n].ObservedSince = i.observe(seen, table, lock.Waiters[n], "waiter", now)
+		}
+		report.Locks = append(report.Locks, *lock)
+	}
+	// Contenders that are gone won't be seen again, unless a scan was cut short
+	if !report.Truncated {
+		scanned := table + "\x00" + prefix
+		for id := range i.observed {
+			if strings.HasPrefix(id, scanned) && !seen[id] {
+				delete(i.observed, id)
+			}
+		}
+	}
+	sort.Slice(report.Locks, func(a, b int) bool { return report.Locks[a].Prefix < report.Locks[b].Prefix })
+	return report, nil
+}
+
+// observe returns when the contender was first seen in its position. The caller must hold mu.
+func (i *Inspector) observe(seen map[string]bool, table string, c Contender, position string, now time.Time) time.Time {
+	id := fmt.Sprintf("%s\x00%s\x00%d\x00%s", table, c.Key, c.CreateRevision, position)
+	seen[id] = true
+	since, ok := i.observed[id]
+	if !ok {
+		since = now
+		i.observed[id] = since
+	}
+	return since
+}
+
+// Release deletes the key holding a lock, handing the lock to the next waiter. It is refused
+// unless the key still holds its lock with the create revision the caller saw, so a contender
+// that acquired the lock meanwhile isn't released by mistake.
+func (i *Inspector) Release(ctx context.Context, table, key string, createRevision int64) (Contender, error) {
+	prefix, ok := lockPrefix(key)
+	if !ok {
+		return Contender{}, fmt.Errorf("%w key %q: not below a lock prefix", ErrInvalid, key)
+	}
+	report, err := i.Inspect(ctx, table, prefix)
+	if err != nil {
+		return Contender{}, err
+	}
+	for _, lock := range report.Locks {
+		if lock.Prefix != prefix {
+			continue
+		}
+		if lock.Holder.Key != key {
+			for _, waiter := range lock.Waiters {
+				if waiter.Key == key {
+					return Contender{}, fmt.Errorf("key %s %w, %s does", key, ErrNotHolder, lock.Holder.Key)
+				}
+			}
+			break
+		}
+		if lock.Holder.CreateRevision != createRevision {
+			return Contender{}, fmt.Errorf("key %s %w at revision %d, it was created again at revision %d",
+				key, ErrNotHolder, createRevision, lock.Holder.CreateRevision)
+		}
+		if err := i.client.DeleteKey(ctx, table, key); err != nil {
+			return Contender{}, err
+		}
+		return lock.Holder, nil
+	}
+	return Contender{}, fmt.Errorf("lock key %s %w", key, ErrNotFound)
+}
+
+// group collects the keys following a lock or election convention by their prefix and orders
+// the contenders by creation. Keys of equal create revision, e.g. from servers that don't report
+// revisions, are ordered by key.
+func group(matcher *conventions.Matcher, table string, revisions []armada.KeyRevision) map[string]*Lock {
+	locks := make(map[string]*Lock)
+	contenders := make(map[string][]Contender)
+	for _, kv := range revisions {
+		match := matcher.Match(table, kv.Key)
+		if match == nil || match.Kind != conventions.KindLock && match.Kind != conventions.KindElection {
+			continue
+		}
+		prefix, ok := lockPrefix(kv.Key)
+		if !ok {
+			continue
+		}
+		if _, ok := locks[prefix]; !ok {
+			locks[prefix] = &Lock{Prefix: prefix, Convention: match.Convention, Kind: match.Kind, Waiters: []Contender{}}
+		}
+		contenders[prefix] = append(contenders[prefix], Contender{
+			Key:            kv.Key,
+			Value:          kv.Value,
+			Fields:         match.Fields,
+			CreateRevision: kv.CreateRevision,
+			ModRevision:    kv.ModRevision,
+		})
+	}
+	for prefix, list := range contenders {
+		sort.Slice(list, func(a, b int) bool {
+			if list[a].CreateRevision != list[b].CreateRevision {
+				return list[a].CreateRevision < list[b].CreateRevision
+			}
+			return list[a].Key < list[b].Key
+		})
+		locks[prefix].Holder = list[0]
+		locks[prefix].Waiters = append(locks[prefix].Waiters, list[1:]...)
+	}
+	return locks
+}
+
+// lockPrefix returns the prefix contenders of the lock of key write their keys below: the key
+// up to and including its last /
+func lockPrefix(key string) (string, bool) {
+	n := strings.LastIndex(key, "/")
+	if n < 0 {
+		return "", false
+	}
+	return key[:n+1], true
+}
@@ -0,0 +1,109 @@
+package locks
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/conventions"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the keys of a single table with their create revisions
+type fakeClient struct {
+	keys map[string]armada.KeyRevision
+}
+
+func (c *fakeClient) put(key, value string, revision int64) {
+	c.keys[key] = armada.KeyRevision{Key: key, Value: value, CreateRevision: revision, ModRevision: revision}
+}
+
+func (c *fakeClient) GetKeyRevisions(_ context.Context, _, prefix string, limit int) ([]armada.KeyRevision, error) {
+	var revisions []armada.KeyRevision
+	for key, kv := range c.keys {
+		if strings.HasPrefix(key, prefix) {
+			revisions = append(revisions, kv)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Key < revisions[j].Key })
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
+
+func (c *fakeClient) DeleteKey(_ context.Context, _, key string) error {
+	delete(c.keys, key)
+	return nil
+}
+
+func TestInspect(t *testing.T) {
+	client := &fakeClient{keys: make(map[string]armada.KeyRevision)}
+	client.put("locks/scheduler/1a", "", 12)
+	client.put("locks/scheduler/0f", "", 10)
+	client.put("locks/scheduler/2b", "", 15)
+	client.put("elections/leader/3c", "node2", 20)
+	client.put("settings/retention", "7d", 1)
+	inspector := NewInspector(client, conventions.NewRegistry(metadata.NewMemoryStore()))
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	inspector.now = func() time.Time { return start }
+
+	report, err := inspector.Inspect(context.Background(), "coordination", "")
+	require.NoError(t, err)
+	require.Len(t, report.Locks, 2)
+	election, lock := report.Locks[0], report.Locks[1]
+	assert.Equal(t, "elections/leader/", election.Prefix)
+	assert.Equal(t, conventions.KindElection, election.Kind)
+	assert.Equal(t, "node2", election.Holder.Value)
+	assert.Empty(t, election.Waiters)
+
+	assert.Equal(t, "locks/scheduler/", lock.Prefix)
+	assert.Equal(t, "etcd-lock", lock.Convention)
+	assert.Equal(t, "locks/scheduler/0f", lock.Holder.Key, "the key created first holds the lock")
+	assert.Equal(t, "15", lock.Holder.Fields["lease"])
+	require.Len(t, lock.Waiters, 2)
+	assert.Equal(t, "locks/scheduler/1a", lock.Waiters[0].Key)
+	assert.Equal(t, "locks/scheduler/2b", lock.Waiters[1].Key)
+	assert.Equal(t, start, lock.Holder.ObservedSince)
+
+	// Contenders keep the time they were first seen at until they move up
+	client.put("locks/scheduler/4d", "", 30)
+	inspector.now = func() time.Time { return start.Add(time.Minute) }
+	report, err = inspector.Inspect(context.Background(), "coordination", "locks/")
+	require.NoError(t, err)
+	require.Len(t, report.Locks, 1)
+	lock = report.Locks[0]
+	assert.Equal(t, start, lock.Holder.ObservedSince)
+	assert.Equal(t, start, lock.Waiters[0].ObservedSince)
+	assert.Equal(t, start.Add(time.Minute), lock.Waiters[2].ObservedSince)
+}
+
+func TestRelease(t *testing.T) {
+	client := &fakeClient{keys: make(map[string]armada.KeyRevision)}
+	client.put("locks/scheduler/0f", "", 10)
+	client.put("locks/scheduler/1a", "", 12)
+	inspector := NewInspector(client, conventions.NewRegistry(metadata.NewMemoryStore()))
+	ctx := context.Background()
+
+	_, err := inspector.Release(ctx, "coordination", "locks/scheduler/1a", 12)
+	assert.ErrorIs(t, err, ErrNotHolder, "waiters can't be released")
+	_, err = inspector.Release(ctx, "coordination", "locks/scheduler/0f", 9)
+	assert.ErrorIs(t, err, ErrNotHolder, "a holder created again isn't released")
+	_, err = inspector.Release(ctx, "coordination", "locks/other/0f", 10)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = inspector.Release(ctx, "coordination", "scheduler", 10)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	released, err := inspector.Release(ctx, "coordination", "locks/scheduler/0f", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "locks/scheduler/0f", released.Key)
+	report, err := inspector.Inspect(ctx, "coordination", "")
+	require.NoError(t, err)
+	require.Len(t, report.Locks, 1)
+	assert.Equal(t, "locks/scheduler/1a", report.Locks[0].Holder.Key, "the next waiter holds the lock")
+}
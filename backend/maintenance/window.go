@@ -0,0 +1,213 @@
+// Package maintenance schedules maintenance windows. While a window is active it
+// silences the diagnostics of the affected resources, optionally freezes writes to
+// the affected tables, and is shown on the dashboards so that operators know why
+// the cluster behaves unusually.
+package maintenance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+)
+
+// Namespace is the metadata namespace the windows are stored in
+const Namespace = "maintenance"
+
+// ErrNotFound is returned when a maintenance window does not exist
+var ErrNotFound = errors.New("maintenance window not found")
+
+// Window is a scheduled maintenance of a cluster or of some of its tables.
+type Window struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	// Cluster restricts the window to one cluster of the registry, all clusters are affected when empty.
+	Cluster string `json:"cluster,omitempty"`
+	// Tables restricts the window to some tables, the whole cluster is affected when empty.
+	Tables []string `json:"tables,omitempty"`
+	// Silence lists the diagnostic checks and alert rules silenced for the affected
+	// resources, all of them are silenced when empty.
+	Silence []string `json:"silence,omitempty"`
+	// FreezeWrites makes the affected tables read-only in the console.
+	FreezeWrites bool      `json:"freezeWrites"`
+	CreatedBy    string    `json:"createdBy,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Validate checks that the window can be scheduled
+func (w Window) Validate() error {
+	switch {
+	case w.Title == "":
+		return errors.New("title is required")
+	case w.Start.IsZero() || w.End.IsZero():
+		return errors.New("start and end are required")
+	case !w.End.After(w.Start):
+		return errors.New("end must be after start")
+	}
+	return nil
+}
+
+// ActiveAt reports whether the window is in effect at t
+func (w Window) ActiveAt(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Affects reports whether a table of a cluster is in the scope of the window.
+// An empty table stands for the cluster itself, which is only affected by cluster-wide windows.
+func (w Window) Affects(cluster, table string) bool {
+	if w.Cluster != "" && cluster != "" && w.Cluster != cluster {
+		return false
+	}
+	return len(w.Tables) == 0 || (table != "" && slices.Contains(w.Tables, table))
+}
+
+// Silences reports whether a diagnostic finding of check about subject is silenced by the window.
+// Windows restricted to some tables only silence findings about these tables.
+func (w Window) Silences(check, subject string) bool {
+	if len(w.Silence) > 0 && !slices.Contains(w.Silence, check) {
+		return false
+	}
+	return len(w.Tables) == 0 || slices.Contains(w.Tables, subject)
+}
+
+// Scheduler stores maintenance windows in the metadata store
+type Scheduler struct {
+	store metadata.Store
+}
+
+// NewScheduler creates a Scheduler backed by the store
+func NewScheduler(store metadata.Store) *Scheduler {
+	return &Scheduler{store: store}
+}
+
+// Create validates and stores a new window, assigning its ID
+func (s *Scheduler) Create(w Window) (Window, error) {
+	if err := w.Validate(); err != nil {
+		return Window{}, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Window{}, fmt.Errorf("failed to generate maintenance window ID: %w", err)
+	}
+	w.ID = hex.EncodeToString(id)
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now().UTC()
+	}
+	if err := metadata.Put(s.store, Namespace, w.ID, w); err != nil {
+		return Window{}, err
+	}
+	return w, nil
+}
+
+// Get returns a window by ID, or ErrNotFound
+func (s *Scheduler) Get(id string) (Window, error) {
+	w, err := metadata.Get[Window](s.store, Namespace, id)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Window{}, ErrNotFound
+	}
+	return w, err
+}
+
+// Delete removes a window, e.g. to end a maintenance early. It returns ErrNotFound if it does not exist.
+func (s *Scheduler) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return s.store.Delete(Namespace, id)
+}
+
+// List returns the windows overlapping the range from to, ordered by start.
+// A zero from or to leaves the range open on that side.
+func (s *Scheduler) List(from, to time.Time) ([]Window, error) {
+	records, err := metadata.List[Window](s.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	windows := make([]Window, 0, len(records))
+	for _, w := range slices.Collect(maps.Values(records)) {
+		if (!from.IsZero() && !w.End.After(from)) || (!to.IsZero() && w.Start.After(to)) {
+			continue
+		}
+		windows = append(windows, w)
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		if !windows[i].Start.Equal(windows[j].Start) {
+			return windows[i].Start.Before(windows[j].Start)
+		}
+		return windows[i].ID < windows[j].ID
+	})
+	return windows, nil
+}
+
+// Active returns the windows in effect at t
+func (s *Scheduler) Active(t time.Time) ([]Window, error) {
+	windows, err := s.List(t, t)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(windows, func(w Window) bool { return !w.ActiveAt(t) }), nil
+}
+
+// Frozen returns the active window freezing writes to a table of a cluster, if any.
+// Pass an empty table to check for a freeze of the whole cluster.
+func (s *Scheduler) Frozen(cluster, table string, t time.Time) (Window, bool, error) {
+	windows, err := s.Active(t)
+	if err != nil {
+		return Window{}, false, err
+	}
+	for _, w := range windows {
+		if w.FreezeWrites && w.Affects(cluster, table) {
+			return w, true, nil
+		}
+	}
+	return Window{}, false, nil
+}
+
+// Silenced returns the active window silencing a diagnostic finding, if any
+func (s *Scheduler) Silenced(check, subject string, t time.Time) (Window, bool, error) {
+	windows, err := s.Active(t)
+	if err != nil {
+		return Window{}, false, err
+	}
+	for _, w := range windows {
+		if w.Silences(check, subject) {
+			return w, true, nil
+		}
+	}
+	return Window{}, false, nil
+}
+
+// Prune deletes the windows that ended before the cutoff. It returns the number of deleted
+// windows and the size of their records.
+func (s *Scheduler) Prune(cutoff time.Time) (int, int64, error) {
+	records, err := s.store.List(Namespace)
+	if err != nil {
+		return 0, 0, err
+	}
+	removed, reclaimed := 0, int64(0)
+	for id, raw := range records {
+		var w Window
+		if err := json.Unmarshal(raw, &w); err != nil {
+			return removed, reclaimed, fmt.Errorf("failed to decode maintenance window %s: %w", id, err)
+		}
+		if !w.End.Before(cutoff) {
+			continue
+		}
+		if err := s.store.Delete(Namespace, id); err != nil {
+			return removed, reclaimed, err
+		}
+		removed++
+		reclaimed += int64(len(raw))
+	}
+	return removed, reclaimed, nil
+}
@@ -0,0 +1,84 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2025, 3, 1, 2, 0, 0, 0, time.UTC)
+
+func TestWindowValidate(t *testing.T) {
+	assert.Error(t, Window{Start: start, End: start.Add(time.Hour)}.Validate(), "title is required")
+	assert.Error(t, Window{Title: "upgrade", Start: start, End: start}.Validate(), "end must be after start")
+	assert.NoError(t, Window{Title: "upgrade", Start: start, End: start.Add(time.Hour)}.Validate())
+}
+
+func TestWindowScope(t *testing.T) {
+	cluster := Window{Title: "upgrade", Cluster: "prod"}
+	assert.True(t, cluster.Affects("prod", "users"))
+	assert.True(t, cluster.Affects("prod", ""))
+	assert.False(t, cluster.Affects("staging", "users"))
+
+	tables := Window{Title: "compaction", Tables: []string{"users"}, Silence: []string{"leader-flapping"}}
+	assert.True(t, tables.Affects("prod", "users"))
+	assert.False(t, tables.Affects("prod", "orders"))
+	assert.False(t, tables.Affects("prod", ""), "creating tables is only frozen by cluster-wide windows")
+	assert.True(t, tables.Silences("leader-flapping", "users"))
+	assert.False(t, tables.Silences("leader-flapping", "orders"))
+	assert.False(t, tables.Silences("clock-skew", "users"))
+}
+
+func TestSchedulerActiveWindows(t *testing.T) {
+	s := NewScheduler(metadata.NewMemoryStore())
+	upgrade, err := s.Create(Window{Title: "upgrade", Start: start, End: start.Add(time.Hour), FreezeWrites: true})
+	require.NoError(t, err)
+	assert.NotEmpty(t, upgrade.ID)
+	_, err = s.Create(Window{Title: "compaction", Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour), Tables: []string{"users"}})
+	require.NoError(t, err)
+
+	_, frozen, err := s.Frozen("prod", "users", start.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.False(t, frozen, "windows have no effect before they start")
+
+	w, frozen, err := s.Frozen("prod", "users", start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, frozen)
+	assert.Equal(t, upgrade.ID, w.ID)
+
+	_, silenced, err := s.Silenced("clock-skew", "node1", start.Add(2*time.Hour+time.Minute))
+	require.NoError(t, err)
+	assert.False(t, silenced, "the compaction window only silences findings about its tables")
+	_, silenced, err = s.Silenced("leader-flapping", "users", start.Add(2*time.Hour+time.Minute))
+	require.NoError(t, err)
+	assert.True(t, silenced)
+
+	windows, err := s.List(start.Add(30*time.Minute), start.Add(5*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "upgrade", windows[0].Title)
+
+	require.NoError(t, s.Delete(upgrade.ID))
+	assert.ErrorIs(t, s.Delete(upgrade.ID), ErrNotFound)
+}
+
+func TestSchedulerPrune(t *testing.T) {
+	s := NewScheduler(metadata.NewMemoryStore())
+	old, err := s.Create(Window{Title: "upgrade", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	recent, err := s.Create(Window{Title: "compaction", Start: start.Add(24 * time.Hour), End: start.Add(25 * time.Hour)})
+	require.NoError(t, err)
+
+	removed, reclaimed, err := s.Prune(start.Add(12 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.Positive(t, reclaimed)
+
+	_, err = s.Get(old.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = s.Get(recent.ID)
+	assert.NoError(t, err, "windows ending after the cutoff are kept")
+}
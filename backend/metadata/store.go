@@ -0,0 +1,281 @@
+// Package metadata stores console-side metadata such as table annotations.
+// Records are JSON documents grouped into namespaces and addressed by key.
+// The FileStore keeps every namespace in its own file so the metadata survives
+// restarts; the MemoryStore is used in tests and when no directory is configured.
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// ErrNotFound is returned when a record does not exist.
+var ErrNotFound = errors.New("metadata record not found")
+
+// Store persists metadata records.
+type Store interface {
+	// Get returns the record stored under key in namespace, or ErrNotFound.
+	Get(namespace, key string) (json.RawMessage, error)
+
+	// Put creates or replaces a record.
+	Put(namespace, key string, value json.RawMessage) error
+
+	// Delete removes a record. Deleting a missing record is not an error.
+	Delete(namespace, key string) error
+
+	// List returns all records of a namespace by key.
+	List(namespace string) (map[string]json.RawMessage, error)
+}
+
+// Get decodes the record stored under key in namespace into a value of type T
+func Get[T any](s Store, namespace, key string) (T, error) {
+	var v T
+	raw, err := s.Get(namespace, key)
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, fmt.Errorf("failed to decode metadata %s/%s: %w", namespace, key, err)
+	}
+	return v, nil
+}
+
+// Put encodes v as JSON and stores it under key in namespace
+func Put[T any](s Store, namespace, key string, v T) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata %s/%s: %w", namespace, key, err)
+	}
+	return s.Put(namespace, key, raw)
+}
+
+// List decodes all records of a namespace into values of type T
+func List[T any](s Store, namespace string) (map[string]T, error) {
+	raw, err := s.List(namespace)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]T, len(raw))
+	for key, data := range raw {
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata %s/%s: %w", namespace, key, err)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// MemoryStore keeps records in memory only.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	namespaces map[string]map[string]json.RawMessage
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		namespaces: make(map[string]map[string]json.RawMessage),
+	}
+}
+
+// Get returns a record
+func (m *MemoryStore) Get(namespace, key string) (json.RawMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	raw, ok := m.namespaces[namespace][key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, namespace, key)
+	}
+	return raw, nil
+}
+
+// Put creates or replaces a record
+func (m *MemoryStore) Put(namespace, key string, value json.RawMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.namespaces[namespace] == nil {
+		m.namespaces[namespace] = make(map[string]json.RawMessage)
+	}
+	m.namespaces[namespace][key] = append(json.RawMessage(nil), value...)
+	return nil
+}
+
+// Delete removes a record
+func (m *MemoryStore) Delete(namespace, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.namespaces[namespace], key)
+	return nil
+}
+
+// List returns all records of a namespace
+func (m *MemoryStore) List(namespace string) (map[string]json.RawMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.namespaces[namespace]), nil
+}
+
+// namespacePattern restricts namespace names so they map to safe file names
+var namespacePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// FileStore persists every namespace as a JSON file in a directory.
+// Namespaces are loaded on first use and cached; every change rewrites the
+// namespace file atomically.
+type FileStore struct {
+	dir string
+
+	mu     sync.Mutex
+	memory *MemoryStore
+	loaded map[string]bool
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+	return &FileStore{
+		dir:    dir,
+		memory: NewMemoryStore(),
+		loaded: make(map[string]bool),
+	}, nil
+}
+
+// Get returns a record
+func (f *FileStore) Get(namespace, key string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(namespace); err != nil {
+		return nil, err
+	}
+	return f.memory.Get(namespace, key)
+}
+
+// Put creates or replaces a record and writes the namespace file
+func (f *FileStore) Put(namespace, key string, value json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(namespace); err != nil {
+		return err
+	}
+	previous, getErr := f.memory.Get(namespace, key)
+	if err := f.memory.Put(namespace, key, value); err != nil {
+		return err
+	}
+	if err := f.save(namespace); err != nil {
+		// Keep memory consistent with the file
+		if getErr != nil {
+			_ = f.memory.Delete(namespace, key)
+		} else {
+			_ = f.memory.Put(namespace, key, previous)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes a record and writes the namespace file
+func (f *FileStore) Delete(namespace, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(namespace); err != nil {
+		return err
+	}
+	previous, err := f.memory.Get(namespace, key)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err := f.memory.Delete(namespace, key); err != nil {
+		return err
+	}
+	if err := f.save(namespace); err != nil {
+		// Keep memory consistent with the file
+		_ = f.memory.Put(namespace, key, previous)
+		return err
+	}
+	return nil
+}
+
+// List returns all records of a namespace
+func (f *FileStore) List(namespace string) (map[string]json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.load(namespace); err != nil {
+		return nil, err
+	}
+	return f.memory.List(namespace)
+}
+
+// path returns the file of a namespace
+func (f *FileStore) path(namespace string) string {
+	return filepath.Join(f.dir, namespace+".json")
+}
+
+// load reads a namespace file into memory unless it was already loaded
+func (f *FileStore) load(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid metadata namespace %q", namespace)
+	}
+	if f.loaded[namespace] {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path(namespace))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read metadata namespace %s: %w", namespace, err)
+	}
+	if len(data) > 0 {
+		var records map[string]json.RawMessage
+		if err := json.Unmarshal(data, &records); err != nil {
+			return fmt.Errorf("failed to parse metadata namespace %s: %w", namespace, err)
+		}
+		for key, raw := range records {
+			if err := f.memory.Put(namespace, key, raw); err != nil {
+				return err
+			}
+		}
+	}
+	f.loaded[namespace] = true
+	return nil
+}
+
+// save writes a namespace file atomically by renaming a temporary file over it
+func (f *FileStore) save(namespace string) error {
+	records, err := f.memory.List(namespace)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata namespace %s: %w", namespace, err)
+	}
+
+	tmp, err := os.CreateTemp(f.dir, namespace+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write metadata namespace %s: %w", namespace, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metadata namespace %s: %w", namespace, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metadata namespace %s: %w", namespace, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metadata namespace %s: %w", namespace, err)
+	}
+	if err := os.Rename(tmp.Name(), f.path(namespace)); err != nil {
+		return fmt.Errorf("failed to write metadata namespace %s: %w", namespace, err)
+	}
+	return nil
+}
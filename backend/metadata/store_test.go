@@ -0,0 +1,80 @@
+package metadata
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	Owner string `json:"owner"`
+}
+
+// testStore runs the behaviour shared by all Store implementations
+func testStore(t *testing.T, s Store) {
+	_, err := s.Get("tables", "users")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, Put(s, "tables", "users", record{Owner: "alice"}))
+	require.NoError(t, Put(s, "tables", "orders", record{Owner: "bob"}))
+
+	got, err := Get[record](s, "tables", "users")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.Owner)
+
+	all, err := List[record](s, "tables")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]record{"users": {Owner: "alice"}, "orders": {Owner: "bob"}}, all)
+
+	require.NoError(t, s.Delete("tables", "users"))
+	require.NoError(t, s.Delete("tables", "users"), "deleting a missing record is not an error")
+	_, err = s.Get("tables", "users")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	empty, err := s.List("clusters")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	testStore(t, s)
+}
+
+func TestFileStorePersists(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFileStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, Put(s, "tables", "users", record{Owner: "alice"}))
+
+	reopened, err := NewFileStore(dir)
+	require.NoError(t, err)
+	got, err := Get[record](reopened, "tables", "users")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", got.Owner)
+
+	data, err := os.ReadFile(filepath.Join(dir, "tables.json"))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(data))
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, matches, "temporary files should be cleaned up")
+}
+
+func TestFileStoreRejectsUnsafeNamespaces(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	assert.Error(t, s.Put("../escape", "key", json.RawMessage(`{}`)))
+	_, err = s.List("Tables")
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+// tableTimeout bounds every request of the TableStore to the cluster
+const tableTimeout = 10 * time.Second
+
+// TableClient is the part of the Armada client the TableStore keeps its records with
+type TableClient interface {
+	GetKeyValue(ctx context.Context, table, key string) (*armada.KeyValuePair, error)
+	PutKeyValue(ctx context.Context, table, key, value string) error
+	DeleteKey(ctx context.Context, table, key string) error
+	GetKeyValuePairs(ctx context.Context, table, prefix, start, end string, limit int) ([]armada.KeyValuePair, error)
+}
+
+// TableStore keeps records in a table of the Armada cluster, so they are shared by all
+// consoles connected to it. Records are stored under the key namespace/key.
+type TableStore struct {
+	client TableClient
+	table  string
+}
+
+// NewTableStore creates a store keeping its records in a table of the cluster, the table must exist
+func NewTableStore(client TableClient, table string) *TableStore {
+	return &TableStore{client: client, table: table}
+}
+
+// Get returns a record
+func (t *TableStore) Get(namespace, key string) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tableTimeout)
+	defer cancel()
+	pair, err := t.client.GetKeyValue(ctx, t.table, namespace+"/"+key)
+	if errors.Is(err, armada.ErrKeyNotFound) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, namespace, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata %s/%s from table %s: %w", namespace, key, t.table, err)
+	}
+	return json.RawMessage(pair.Value), nil
+}
+
+// Put creates or replaces a record
+func (t *TableStore) Put(namespace, key string, value json.RawMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), tableTimeout)
+	defer cancel()
+	if err := t.client.PutKeyValue(ctx, t.table, namespace+"/"+key, string(value)); err != nil {
+		return fmt.Errorf("failed to write metadata %s/%s to table %s: %w", namespace, key, t.table, err)
+	}
+	return nil
+}
+
+// Delete removes a record
+func (t *TableStore) Delete(namespace, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), tableTimeout)
+	defer cancel()
+	err := t.client.DeleteKey(ctx, t.table, namespace+"/"+key)
+	if err != nil && !errors.Is(err, armada.ErrKeyNotFound) {
+		return fmt.Errorf("failed to delete metadata %s/%s from table %s: %w", namespace, key, t.table, err)
+	}
+	return nil
+}
+
+// List returns all records of a namespace
+func (t *TableStore) List(namespace string) (map[string]json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tableTimeout)
+	defer cancel()
+	prefix := namespace + "/"
+	// A limit of zero scans the whole namespace
+	pairs, err := t.client.GetKeyValuePairs(ctx, t.table, prefix, "", "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metadata %s from table %s: %w", namespace, t.table, err)
+	}
+	records := make(map[string]json.RawMessage, len(pairs))
+	for _, pair := range pairs {
+		records[strings.TrimPrefix(pair.Key, prefix)] = json.RawMessage(pair.Value)
+	}
+	return records, nil
+}
@@ -0,0 +1,52 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+// fakeTable keeps the pairs of a single table in memory
+type fakeTable struct {
+	table string
+	pairs map[string]string
+}
+
+func (f *fakeTable) GetKeyValue(_ context.Context, table, key string) (*armada.KeyValuePair, error) {
+	value, ok := f.pairs[key]
+	if table != f.table || !ok {
+		return nil, fmt.Errorf("%w: %s", armada.ErrKeyNotFound, key)
+	}
+	return &armada.KeyValuePair{Key: key, Value: value}, nil
+}
+
+func (f *fakeTable) PutKeyValue(_ context.Context, table, key, value string) error {
+	if table != f.table {
+		return armada.ErrTableNotFound
+	}
+	f.pairs[key] = value
+	return nil
+}
+
+func (f *fakeTable) DeleteKey(_ context.Context, _, key string) error {
+	delete(f.pairs, key)
+	return nil
+}
+
+func (f *fakeTable) GetKeyValuePairs(_ context.Context, _, prefix, _, _ string, _ int) ([]armada.KeyValuePair, error) {
+	var pairs []armada.KeyValuePair
+	for key, value := range f.pairs {
+		if strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, armada.KeyValuePair{Key: key, Value: value})
+		}
+	}
+	return pairs, nil
+}
+
+func TestTableStore(t *testing.T) {
+	client := &fakeTable{table: "console", pairs: make(map[string]string)}
+	testStore(t, NewTableStore(client, "console"))
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+)
+
+// LeaderChangesMetric is the name of the counter of leader changes per table.
+// It is derived from the topology history by the console.
+const LeaderChangesMetric = "armada_console_leader_changes_total"
+
+// AlertRule is a PromQL expression evaluated against the stored metrics.
+// Every series the expression returns is a firing alert.
+type AlertRule struct {
+	// Name identifies the rule, e.g. in diagnostics findings.
+	Name string `json:"name"`
+	// Expr is the PromQL expression.
+	Expr string `json:"expr"`
+	// Severity is reported with every alert of the rule.
+	Severity string `json:"severity"`
+	// Subject is the label naming what an alert is about, e.g. "table".
+	Subject string `json:"subject"`
+	// Summary describes a firing alert. {{ $value }} and {{ $labels.<name> }} are replaced
+	// with the value and labels of the series.
+	Summary string `json:"summary"`
+	// RunbookURL links to the runbook of the rule, if any.
+	RunbookURL string `json:"runbookUrl,omitempty"`
+	// Remediation are first steps in Markdown, reported with every alert of the rule.
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// DefaultAlertRules are the alert rules the console ships with
+var DefaultAlertRules = []AlertRule{
+	{
+		// Flapping leadership is a classic early symptom of network or disk problems
+		Name:     "leader-flapping",
+		Expr:     "increase(" + LeaderChangesMetric + "[15m]) > 3",
+		Severity: "warning",
+		Subject:  "table",
+		Summary: "Leadership of table {{ $labels.table }} changed {{ $value }} times in the last 15 minutes, " +
+			"which often indicates network or disk problems",
+		Remediation: "1. Check the network between the nodes for packet loss and latency spikes\n" +
+			"2. Check the disk latency of the nodes, slow fsyncs delay heartbeats\n" +
+			"3. Look for nodes restarting in the topology history",
+	},
+}
+
+// Alert is a series returned by an alert rule
+type Alert struct {
+	Rule     string            `json:"rule"`
+	Severity string            `json:"severity"`
+	Subject  string            `json:"subject"`
+	Labels   map[string]string `json:"labels"`
+	Value    float64           `json:"value"`
+	Summary  string            `json:"summary"`
+	// RunbookURL and Remediation are those of the rule
+	RunbookURL  string `json:"runbookUrl,omitempty"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Alerts evaluates the rules at the given time and returns the firing alerts.
+// Rules that fail to evaluate are reported in the error, the alerts of the other rules are still returned.
+func (q *QueryEngine) Alerts(ctx context.Context, rules []AlertRule, ts time.Time) ([]Alert, error) {
+	var alerts []Alert
+	var errs []error
+	for _, rule := range rules {
+		result, err := q.Query(ctx, rule.Expr, ts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to evaluate alert rule %s: %w", rule.Name, err))
+			continue
+		}
+		vector, ok := result.Value.(promql.Vector)
+		if !ok {
+			errs = append(errs, fmt.Errorf("alert rule %s must return an instant vector, got %s", rule.Name, result.Type))
+			continue
+		}
+		for _, sample := range vector {
+			lbls := sample.Metric.Map()
+			alerts = append(alerts, Alert{
+				Rule:        rule.Name,
+				Severity:    rule.Severity,
+				Subject:     lbls[rule.Subject],
+				Labels:      lbls,
+				Value:       sample.F,
+				Summary:     expandSummary(rule.Summary, lbls, sample.F),
+				RunbookURL:  rule.RunbookURL,
+				Remediation: rule.Remediation,
+			})
+		}
+	}
+	return alerts, errors.Join(errs...)
+}
+
+// expandSummary replaces the value and label placeholders of an alert summary
+func expandSummary(summary string, lbls map[string]string, value float64) string {
+	replacements := []string{"{{ $value }}", strconv.FormatFloat(value, 'g', 4, 64)}
+	for name, v := range lbls {
+		replacements = append(replacements, "{{ $labels."+name+" }}", v)
+	}
+	return strings.NewReplacer(replacements...).Replace(summary)
+}
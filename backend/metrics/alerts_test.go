@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLeaderFlappingAlert(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	// "users" elects a new leader every minute, "orders" is stable
+	now := time.Now()
+	var samples []ConsoleSample
+	for i := 0; i <= 10; i++ {
+		at := now.Add(time.Duration(i-10) * time.Minute)
+		samples = append(samples,
+			ConsoleSample{Name: LeaderChangesMetric, Labels: map[string]string{"table": "users"}, Time: at, Value: float64(i)},
+			ConsoleSample{Name: LeaderChangesMetric, Labels: map[string]string{"table": "orders"}, Time: at, Value: 1},
+		)
+	}
+	require.NoError(t, manager.AppendSamples(context.Background(), samples))
+
+	alerts, err := NewQueryEngine(manager.GetStorage(), zap.NewNop()).Alerts(context.Background(), DefaultAlertRules, now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "leader-flapping", alerts[0].Rule)
+	assert.Equal(t, "users", alerts[0].Subject)
+	assert.Contains(t, alerts[0].Summary, "Leadership of table users changed")
+	assert.NotContains(t, alerts[0].Summary, "{{")
+	assert.Equal(t, DefaultAlertRules[0].Remediation, alerts[0].Remediation)
+}
+
+func TestAlertsReportBrokenRules(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	rules := append([]AlertRule{{Name: "broken", Expr: "rate("}}, DefaultAlertRules...)
+	alerts, err := NewQueryEngine(manager.GetStorage(), zap.NewNop()).Alerts(context.Background(), rules, time.Now())
+	assert.ErrorContains(t, err, "alert rule broken")
+	assert.Empty(t, alerts)
+}
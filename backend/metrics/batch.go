@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/coalesce"
+	"github.com/armadakv/console/backend/panels"
+	"go.uber.org/zap"
+)
+
+// BatchQueryPath evaluates a batch of queries. It only reads metrics, so viewers may post to it
+// even in read-only mode.
+const BatchQueryPath = "/api/metrics/batch_query"
+
+const (
+	// maxBatchQueries limits the queries of a batch, more than a dashboard shows at once
+	maxBatchQueries = 100
+	// batchConcurrency limits the queries of a batch evaluated at the same time
+	batchConcurrency = 8
+)
+
+// BatchQuery is a query of a batch, an instant query unless start or end is given
+type BatchQuery struct {
+	// ID identifies the result of the query in the response, unique within the batch
+	ID string `json:"id"`
+	// Query is the PromQL query, required unless panel is given
+	Query string `json:"query,omitempty"`
+	// Panel is the name of a saved panel whose query is executed
+	Panel string `json:"panel,omitempty"`
+	// Time is the evaluation timestamp of an instant query (RFC3339 or unix timestamp), now if empty
+	Time string `json:"time,omitempty"`
+	// Start and End are the timestamps of a range query (RFC3339 or unix timestamp)
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	// Step is the resolution of a range query, e.g. 15s or 1m (default: 1m)
+	Step string `json:"step,omitempty"`
+	// Clusters restricts every selector of the query to these clusters
+	Clusters []string `json:"clusters,omitempty"`
+	// Tags restricts every selector of the query to clusters with these tags, e.g. {"env": "prod"}
+	Tags map[string]string `json:"tags,omitempty"`
+	// GroupBy aggregates the result by these labels
+	GroupBy []string `json:"groupBy,omitempty"`
+	// Aggregate is the aggregation of groupBy: sum (default), avg, min, max or count
+	Aggregate string `json:"aggregate,omitempty"`
+}
+
+// BatchQueryRequest holds the queries of a batch
+type BatchQueryRequest struct {
+	Queries []BatchQuery `json:"queries"`
+	// Timeout is the deadline shared by all queries, e.g. 10s, at most the query timeout
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// BatchQueryResult is the result of a query of a batch
+type BatchQueryResult struct {
+	Status string       `json:"status"` // Query status (success, error)
+	Data   *QueryResult `json:"data,omitempty"`
+	// Query is the evaluated PromQL query, taken from the panel if one is given and restricted to the clusters
+	Query string `json:"query,omitempty"`
+	// Error tells why the query failed
+	Error string `json:"error,omitempty"`
+	// Annotations are the unit and thresholds of the panel the query was taken from, if any
+	Annotations *panels.Annotations `json:"annotations,omitempty"`
+}
+
+// BatchQueryResponse holds the results of a batch by the IDs of their queries
+type BatchQueryResponse struct {
+	Status  string                      `json:"status"`
+	Results map[string]BatchQueryResult `json:"results"`
+}
+
+// params returns the query as the parameters of /query and /query_range
+func (q BatchQuery) params() url.Values {
+	params := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	set("query", q.Query)
+	set("panel", q.Panel)
+	set("time", q.Time)
+	set("start", q.Start)
+	set("end", q.End)
+	set("step", q.Step)
+	set("aggregate", q.Aggregate)
+	for _, cluster := range q.Clusters {
+		params.Add("clusters", cluster)
+	}
+	for key, value := range q.Tags {
+		params.Add("tags", key+"="+value)
+	}
+	for _, label := range q.GroupBy {
+		params.Add("groupBy", label)
+	}
+	return params
+}
+
+// handleBatchQuery evaluates the queries of a batch concurrently under a shared deadline
+// @Summary Batch query
+// @Description Evaluate many instant and range queries in one request, e.g. all panels of a dashboard. The queries are evaluated concurrently and share a deadline; every query succeeds or fails on its own and its result is returned under its ID. A query is a range query when start or end is given.
+// @Tags metrics
+// @Accept json
+// @Produce json
+// @Param request body BatchQueryRequest true "Queries, at most 100"
+// @Success 200 {object} BatchQueryResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/metrics/batch_query [post]
+func (h *MetricsHandler) handleBatchQuery(w http.ResponseWriter, r *http.Request) {
+	var req BatchQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		renderError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	resp, err := h.EvaluateBatch(r.Context(), req)
+	if err != nil {
+		renderRequestError(w, err)
+		return
+	}
+	renderJSON(w, resp)
+}
+
+// EvaluateBatch evaluates the queries of a batch concurrently under a shared deadline. Every
+// query succeeds or fails on its own, only an invalid batch is returned as an error.
+func (h *MetricsHandler) EvaluateBatch(ctx context.Context, req BatchQueryRequest) (BatchQueryResponse, error) {
+	if len(req.Queries) == 0 || len(req.Queries) > maxBatchQueries {
+		return BatchQueryResponse{}, &requestError{http.StatusBadRequest, "Expected between 1 and 100 queries"}
+	}
+	ids := make(map[string]bool, len(req.Queries))
+	for _, q := range req.Queries {
+		if q.ID == "" {
+			return BatchQueryResponse{}, &requestError{http.StatusBadRequest, "Every query requires an id"}
+		}
+		if ids[q.ID] {
+			return BatchQueryResponse{}, &requestError{http.StatusBadRequest, "Duplicate query id " + q.ID}
+		}
+		ids[q.ID] = true
+	}
+
+	timeout := h.queryTimeout
+	if timeout <= 0 {
+		timeout = defaultQueryTimeout
+	}
+	if req.Timeout != "" {
+		requested, err := parseDuration(req.Timeout)
+		if err != nil || requested <= 0 {
+			return BatchQueryResponse{}, &requestError{http.StatusBadRequest, "Invalid timeout format"}
+		}
+		timeout = min(timeout, requested)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	h.logger.Debug("Executing batch query",
+		zap.Int("queries", len(req.Queries)),
+		zap.Duration("timeout", timeout))
+
+	results := make([]BatchQueryResult, len(req.Queries))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, q := range req.Queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				results[i] = h.batchQuery(ctx, q)
+			case <-ctx.Done():
+				results[i] = BatchQueryResult{Status: "error", Error: "Batch deadline exceeded"}
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := BatchQueryResponse{Status: "success", Results: make(map[string]BatchQueryResult, len(results))}
+	for i, q := range req.Queries {
+		resp.Results[q.ID] = results[i]
+	}
+	return resp, nil
+}
+
+// batchQuery evaluates a query of a batch, sharing the result with identical /query and
+// /query_range requests
+func (h *MetricsHandler) batchQuery(ctx context.Context, q BatchQuery) BatchQueryResult {
+	params := q.params()
+	queryStr, annotations, err := h.resolveQuery(params)
+	if err != nil {
+		return BatchQueryResult{Status: "error", Error: err.Error()}
+	}
+	queryStr, err = scopeQuery(params, queryStr)
+	if err != nil {
+		return BatchQueryResult{Status: "error", Error: err.Error()}
+	}
+
+	var key string
+	var run func(ctx context.Context) (QueryResult, error)
+	if q.Start != "" || q.End != "" {
+		start, end, step, err := rangeParams(params)
+		if err != nil {
+			return BatchQueryResult{Status: "error", Query: queryStr, Error: err.Error()}
+		}
+		key = rangeKey(queryStr, start, end, step)
+		run = func(ctx context.Context) (QueryResult, error) {
+			return h.queryEngine.QueryRange(ctx, queryStr, start, end, step)
+		}
+	} else {
+		ts := time.Now()
+		if q.Time != "" {
+			if ts, err = parseTime(q.Time); err != nil {
+				return BatchQueryResult{Status: "error", Query: queryStr, Error: "Invalid time format"}
+			}
+		}
+		key = instantKey(queryStr, ts)
+		run = func(ctx context.Context) (QueryResult, error) {
+			return h.queryEngine.Query(ctx, queryStr, ts)
+		}
+	}
+
+	result, _, err := coalesce.Do(ctx, &h.queries, key, run)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return BatchQueryResult{Status: "error", Query: queryStr, Error: "Batch deadline exceeded"}
+		}
+		h.logger.Error("Batch query execution failed",
+			zap.String("id", q.ID),
+			zap.String("query", queryStr),
+			zap.Error(err))
+		return BatchQueryResult{Status: "error", Query: queryStr, Error: "Query execution failed"}
+	}
+	return BatchQueryResult{Status: "success", Data: &result, Query: queryStr, Annotations: annotations}
+}
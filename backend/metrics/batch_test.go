@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/panels"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleBatchQuery(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	now := time.Now().Truncate(time.Minute)
+	appender := manager.GetStorage().Appender(t.Context())
+	for _, ts := range []time.Time{now.Add(-time.Minute), now} {
+		_, err = appender.Append(0, labels.FromStrings("__name__", "armada_tables", "cluster", "a:5001"), ts.UnixMilli(), 5)
+		require.NoError(t, err)
+		_, err = appender.Append(0, labels.FromStrings("__name__", "armada_tables", "cluster", "b:5001"), ts.UnixMilli(), 3)
+		require.NoError(t, err)
+	}
+	require.NoError(t, appender.Commit())
+
+	store := panels.NewStore(metadata.NewMemoryStore())
+	_, err = store.Put(panels.Panel{Name: "tables", Query: "sum(armada_tables)", Annotations: panels.Annotations{Unit: "count"}})
+	require.NoError(t, err)
+	handler := NewMetricsHandler(manager, zap.NewNop(), WithPanels(store))
+
+	nowParam, startParam := strconv.FormatInt(now.Unix(), 10), strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)
+	body, err := json.Marshal(BatchQueryRequest{Queries: []BatchQuery{
+		{ID: "instant", Query: "armada_tables", Time: nowParam, Clusters: []string{"a:5001"}},
+		{ID: "range", Panel: "tables", Start: startParam, End: nowParam, Step: "1m"},
+		{ID: "invalid", Query: "sum(", Time: nowParam},
+		{ID: "missing", Panel: "unknown"},
+	}})
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.handleBatchQuery(rr, httptest.NewRequest("POST", "/api/metrics/batch_query", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response struct {
+		Results map[string]struct {
+			Status string `json:"status"`
+			Data   struct {
+				Result []struct {
+					Metric map[string]string `json:"metric"`
+					Value  [2]any            `json:"value"`
+					Values [][2]any          `json:"values"`
+				} `json:"result"`
+			} `json:"data"`
+			Error       string              `json:"error"`
+			Annotations *panels.Annotations `json:"annotations"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Results, 4)
+
+	instant := response.Results["instant"]
+	assert.Equal(t, "success", instant.Status)
+	require.Len(t, instant.Data.Result, 1, "the query is scoped to its clusters")
+	assert.Equal(t, "a:5001", instant.Data.Result[0].Metric["cluster"])
+	assert.Equal(t, "5", instant.Data.Result[0].Value[1])
+
+	rangeResult := response.Results["range"]
+	assert.Equal(t, "success", rangeResult.Status)
+	require.Len(t, rangeResult.Data.Result, 1)
+	assert.Len(t, rangeResult.Data.Result[0].Values, 2)
+	require.NotNil(t, rangeResult.Annotations)
+	assert.Equal(t, "count", rangeResult.Annotations.Unit)
+
+	assert.Equal(t, "error", response.Results["invalid"].Status)
+	assert.Equal(t, "Query execution failed", response.Results["invalid"].Error)
+	assert.Equal(t, "error", response.Results["missing"].Status)
+	assert.Equal(t, "Panel unknown not found", response.Results["missing"].Error)
+
+	for _, body := range []string{
+		`{"queries":[]}`,
+		`{"queries":[{"query":"up"}]}`,
+		`{"queries":[{"id":"a","query":"up"},{"id":"a","query":"up"}]}`,
+		`{"queries":[{"id":"a","query":"up"}],"timeout":"soon"}`,
+		`queries`,
+	} {
+		rr = httptest.NewRecorder()
+		handler.handleBatchQuery(rr, httptest.NewRequest("POST", "/api/metrics/batch_query", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// BlockedMetric is a metric of a cluster that isn't stored for a while, because a scrape
+// returned more series or labels for it than allowed
+type BlockedMetric struct {
+	Cluster string `json:"cluster"`
+	Metric  string `json:"metric"`
+	// Reason describes the limit that was exceeded
+	Reason string `json:"reason"`
+	// Labels are the label names likely causing the explosion, e.g. a label with a distinct
+	// value on nearly every series
+	Labels []string  `json:"labels"`
+	Since  time.Time `json:"since"`
+	// Until is when the metric is stored again, unless it still exceeds the limits
+	Until time.Time `json:"until"`
+}
+
+// cardinalityGuard stops storing the metrics of a cluster that exceed the series or label
+// limits, protecting the TSDB shared by all clusters from cardinality explosions
+type cardinalityGuard struct {
+	cluster   string
+	maxSeries int
+	maxLabels int
+	cooldown  time.Duration
+
+	// mu protects blocked
+	mu      sync.Mutex
+	blocked map[string]BlockedMetric
+}
+
+// newCardinalityGuard creates a guard for the cluster. A limit of zero disables it.
+func newCardinalityGuard(cluster string, maxSeries, maxLabels int, cooldown time.Duration) *cardinalityGuard {
+	return &cardinalityGuard{
+		cluster:   cluster,
+		maxSeries: maxSeries,
+		maxLabels: maxLabels,
+		cooldown:  cooldown,
+		blocked:   make(map[string]BlockedMetric),
+	}
+}
+
+// list returns the metrics currently blocked, sorted by name
+func (g *cardinalityGuard) list(now time.Time) []BlockedMetric {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var blocked []BlockedMetric
+	for _, name := range slices.Sorted(maps.Keys(g.blocked)) {
+		if b := g.blocked[name]; now.Before(b.Until) {
+			blocked = append(blocked, b)
+		}
+	}
+	return blocked
+}
+
+// scrape starts checking the series of a scrape at now
+func (g *cardinalityGuard) scrape(now time.Time) *cardinalityScrape {
+	if g == nil {
+		return nil
+	}
+	return &cardinalityScrape{guard: g, now: now, metrics: make(map[string]*metricSeries)}
+}
+
+// cardinalityScrape counts the series of every metric during a single scrape
+type cardinalityScrape struct {
+	guard   *cardinalityGuard
+	now     time.Time
+	metrics map[string]*metricSeries
+	// tripped are the metrics blocked during the scrape
+	tripped []BlockedMetric
+}
+
+// metricSeries counts the series of a metric and the distinct values of their labels
+type metricSeries struct {
+	count  int
+	values map[string]map[string]struct{}
+}
+
+// allow reports whether a series of the scrape may be stored. Every series is expected
+// once per scrape, as in the Prometheus text format.
+func (s *cardinalityScrape) allow(metric string, lbls labels.Labels) bool {
+	if s == nil {
+		return true
+	}
+	g := s.guard
+	g.mu.Lock()
+	b, blocked := g.blocked[metric]
+	if blocked && !s.now.Before(b.Until) {
+		// The cooldown passed, the metric is stored again until it exceeds the limits again
+		delete(g.blocked, metric)
+		blocked = false
+	}
+	g.mu.Unlock()
+	if blocked {
+		return false
+	}
+
+	if g.maxLabels > 0 {
+		// The metric name isn't counted as a label
+		var names []string
+		lbls.Range(func(l labels.Label) {
+			if l.Name != labels.MetricName {
+				names = append(names, l.Name)
+			}
+		})
+		if len(names) > g.maxLabels {
+			s.trip(metric, fmt.Sprintf("a series has %d labels, the limit is %d", len(names), g.maxLabels), names)
+			return false
+		}
+	}
+	if g.maxSeries <= 0 {
+		return true
+	}
+
+	m, ok := s.metrics[metric]
+	if !ok {
+		m = &metricSeries{values: make(map[string]map[string]struct{})}
+		s.metrics[metric] = m
+	}
+	m.count++
+	lbls.Range(func(l labels.Label) {
+		if l.Name == labels.MetricName {
+			return
+		}
+		values, ok := m.values[l.Name]
+		if !ok {
+			values = make(map[string]struct{})
+			m.values[l.Name] = values
+		}
+		values[l.Value] = struct{}{}
+	})
+	if m.count <= g.maxSeries {
+		return true
+	}
+
+	// Labels with a distinct value on most series, such as a key or request ID, cause the explosion
+	var names []string
+	for name, values := range m.values {
+		if len(values) > m.count/2 {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	s.trip(metric, fmt.Sprintf("more than %d series in a scrape", g.maxSeries), names)
+	delete(s.metrics, metric)
+	return false
+}
+
+// trip blocks the metric for the cooldown
+func (s *cardinalityScrape) trip(metric, reason string, names []string) {
+	if names == nil {
+		names = []string{}
+	}
+	b := BlockedMetric{
+		Cluster: s.guard.cluster,
+		Metric:  metric,
+		Reason:  reason,
+		Labels:  names,
+		Since:   s.now,
+		Until:   s.now.Add(s.guard.cooldown),
+	}
+	s.guard.mu.Lock()
+	s.guard.blocked[metric] = b
+	s.guard.mu.Unlock()
+	s.tripped = append(s.tripped, b)
+}
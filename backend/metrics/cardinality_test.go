@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/events"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestCardinalityGuardSeriesLimit(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := newCardinalityGuard("a:8080", 3, 0, time.Hour)
+
+	s := g.scrape(now)
+	for i := range 3 {
+		assert.True(t, s.allow("armada_requests_total", labels.FromStrings("__name__", "armada_requests_total", "table", "users", "key", fmt.Sprint(i))))
+	}
+	assert.True(t, s.allow("armada_tables", labels.FromStrings("__name__", "armada_tables")))
+	assert.False(t, s.allow("armada_requests_total", labels.FromStrings("__name__", "armada_requests_total", "table", "users", "key", "3")))
+	assert.Equal(t, []BlockedMetric{{
+		Cluster: "a:8080",
+		Metric:  "armada_requests_total",
+		Reason:  "more than 3 series in a scrape",
+		Labels:  []string{"key"},
+		Since:   now,
+		Until:   now.Add(time.Hour),
+	}}, s.tripped)
+
+	// The metric stays blocked in later scrapes, other metrics are stored
+	s = g.scrape(now.Add(time.Minute))
+	assert.False(t, s.allow("armada_requests_total", labels.FromStrings("__name__", "armada_requests_total", "table", "users")))
+	assert.True(t, s.allow("armada_tables", labels.FromStrings("__name__", "armada_tables")))
+	assert.Empty(t, s.tripped)
+	assert.Len(t, g.list(now.Add(time.Minute)), 1)
+
+	// After the cooldown the metric is stored again
+	s = g.scrape(now.Add(time.Hour))
+	assert.True(t, s.allow("armada_requests_total", labels.FromStrings("__name__", "armada_requests_total", "table", "users")))
+	assert.Empty(t, g.list(now.Add(time.Hour)))
+}
+
+func TestCardinalityGuardLabelLimit(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	g := newCardinalityGuard("a:8080", 0, 2, time.Hour)
+
+	s := g.scrape(now)
+	assert.True(t, s.allow("armada_tables", labels.FromStrings("__name__", "armada_tables", "a", "1", "b", "2")))
+	assert.False(t, s.allow("armada_tables", labels.FromStrings("__name__", "armada_tables", "a", "1", "b", "2", "c", "3")))
+	assert.Len(t, s.tripped, 1)
+	assert.Equal(t, []string{"a", "b", "c"}, s.tripped[0].Labels)
+	assert.Equal(t, "a series has 3 labels, the limit is 2", s.tripped[0].Reason)
+}
+
+func TestCardinalityGuardDisabled(t *testing.T) {
+	var g *cardinalityGuard
+	s := g.scrape(time.Now())
+	assert.True(t, s.allow("armada_tables", labels.FromStrings("__name__", "armada_tables")))
+	assert.Nil(t, g.list(time.Now()))
+}
+
+func TestStoreMetricsBlocksHighCardinality(t *testing.T) {
+	mockPool := &mockClusterPool{}
+	mockPool.On("GetConnection", mock.Anything, "a:8080").Return(&armada.ServerConnection{NodeID: "1"}, nil)
+	hub := events.NewHub()
+	sub := hub.Subscribe(4, events.TypeCardinality)
+	defer sub.Close()
+
+	manager, err := NewMetricsManager(mockPool, 15*time.Second, createTempDir(t), zap.NewNop(),
+		WithCardinalityLimits(2, 0, time.Hour),
+		WithPublisher(hub))
+	assert.NoError(t, err)
+	defer manager.Stop()
+	manager.mu.Lock()
+	manager.addCluster(context.Background(), "a:8080")
+	collector := manager.collectors["a:8080"]
+	manager.mu.Unlock()
+
+	var data strings.Builder
+	data.WriteString("# TYPE armada_tables gauge\narmada_tables 2\n")
+	for i := range 5 {
+		fmt.Fprintf(&data, "armada_key_reads_total{key=\"k%d\"} 1\n", i)
+	}
+	err = collector.storeMetricsInTSDB(context.Background(), &armada.MetricsData{
+		Source:    "a:8080",
+		Data:      data.String(),
+		Timestamp: time.Now(),
+	})
+	assert.NoError(t, err)
+
+	select {
+	case e := <-sub.Events():
+		blocked := e.Data.(BlockedMetric)
+		assert.Equal(t, "armada_key_reads_total", blocked.Metric)
+		assert.Equal(t, []string{"key"}, blocked.Labels)
+	default:
+		t.Fatal("expected an event for the blocked metric")
+	}
+
+	targets := manager.Targets()
+	assert.Len(t, targets, 1)
+	assert.Len(t, targets[0].Blocked, 1)
+
+	// Only the series up to the limit were stored
+	q, err := manager.GetStorage().Querier(0, time.Now().Add(time.Minute).UnixMilli())
+	assert.NoError(t, err)
+	defer q.Close()
+	set := q.Select(context.Background(), false, nil, labels.MustNewMatcher(labels.MatchEqual, "__name__", "armada_key_reads_total"))
+	series := 0
+	for set.Next() {
+		series++
+	}
+	assert.Equal(t, 2, series)
+}
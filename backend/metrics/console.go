@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// RetentionRemovedMetric is the name of the counter of records the console removed from its
+// artifacts, e.g. the audit log, because they exceeded their retention
+const RetentionRemovedMetric = "armada_console_retention_removed_total"
+
+// RetentionReclaimedMetric is the name of the counter of bytes reclaimed by removing records
+// beyond their retention
+const RetentionReclaimedMetric = "armada_console_retention_reclaimed_bytes_total"
+
+// TableKeysMetric is the name of the gauge of the number of keys of a table, counted by the console
+const TableKeysMetric = "armada_console_table_keys"
+
+// TableBytesMetric is the name of the gauge of the sampled database size of a table in bytes
+const TableBytesMetric = "armada_console_table_bytes"
+
+// PrefixKeysMetric is the name of the gauge of the number of keys of a table with a key prefix
+const PrefixKeysMetric = "armada_console_table_prefix_keys"
+
+// ConsoleSample is a sample of a metric derived by the console itself rather than scraped from a server
+type ConsoleSample struct {
+	Name   string
+	Labels map[string]string
+	Time   time.Time
+	Value  float64
+}
+
+// AppendSamples stores console-derived samples in the TSDB next to the scraped metrics,
+// so they can be queried and alerted on like any other series
+func (m *MetricsManager) AppendSamples(ctx context.Context, samples []ConsoleSample) error {
+	appender := m.storage.Appender(ctx)
+	for _, s := range samples {
+		builder := labels.NewBuilder(labels.FromStrings("__name__", s.Name))
+		for name, value := range s.Labels {
+			builder.Set(name, value)
+		}
+		if _, err := appender.Append(0, builder.Labels(), s.Time.UnixMilli(), s.Value); err != nil {
+			_ = appender.Rollback()
+			return fmt.Errorf("failed to append %s: %w", s.Name, err)
+		}
+	}
+	if err := appender.Commit(); err != nil {
+		return fmt.Errorf("failed to commit console samples: %w", err)
+	}
+	return nil
+}
+
+// ReadSamples returns the samples of a metric between start and end whose labels equal those of match.
+// The samples of every series are ordered by time, the series follow each other.
+func (m *MetricsManager) ReadSamples(ctx context.Context, name string, match map[string]string, start, end time.Time) ([]ConsoleSample, error) {
+	q, err := m.storage.Querier(start.UnixMilli(), end.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open querier: %w", err)
+	}
+	defer q.Close()
+
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, name)}
+	for label, value := range match {
+		matchers = append(matchers, labels.MustNewMatcher(labels.MatchEqual, label, value))
+	}
+	var samples []ConsoleSample
+	set := q.Select(ctx, false, &storage.SelectHints{Start: start.UnixMilli(), End: end.UnixMilli()}, matchers...)
+	var it chunkenc.Iterator
+	for set.Next() {
+		series := set.At()
+		seriesLabels := series.Labels().Map()
+		delete(seriesLabels, labels.MetricName)
+		it = series.Iterator(it)
+		for it.Next() == chunkenc.ValFloat {
+			t, v := it.At()
+			samples = append(samples, ConsoleSample{Name: name, Labels: seriesLabels, Time: time.UnixMilli(t), Value: v})
+		}
+		if err := it.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+	if err := set.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return samples, nil
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReadSamples(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	now := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, manager.AppendSamples(context.Background(), []ConsoleSample{
+		{Name: TableKeysMetric, Labels: map[string]string{"table": "users"}, Time: now.Add(-2 * time.Hour), Value: 1},
+		{Name: TableKeysMetric, Labels: map[string]string{"table": "users"}, Time: now.Add(-time.Hour), Value: 2},
+		{Name: TableKeysMetric, Labels: map[string]string{"table": "users"}, Time: now, Value: 3},
+		{Name: TableKeysMetric, Labels: map[string]string{"table": "orders"}, Time: now, Value: 10},
+	}))
+
+	samples, err := manager.ReadSamples(context.Background(), TableKeysMetric, map[string]string{"table": "users"},
+		now.Add(-90*time.Minute), now)
+	require.NoError(t, err)
+	assert.Equal(t, []ConsoleSample{
+		{Name: TableKeysMetric, Labels: map[string]string{"table": "users"}, Time: now.Add(-time.Hour), Value: 2},
+		{Name: TableKeysMetric, Labels: map[string]string{"table": "users"}, Time: now, Value: 3},
+	}, samples)
+}
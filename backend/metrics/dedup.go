@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"math"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+)
+
+// sampleDeduper drops gauge samples repeating the value last stored for their series.
+// A repeated value is stored again once window has passed since the series was last
+// stored, so queries never lose sight of a series within their lookback. It tracks at
+// most maxSeries series, samples of further series are always stored.
+type sampleDeduper struct {
+	mu        sync.Mutex
+	window    int64
+	maxSeries int
+	series    map[uint64]trackedSeries
+}
+
+// trackedSeries is the last value stored for a series and when it was stored and last seen
+type trackedSeries struct {
+	lbls   labels.Labels
+	value  float64
+	stored int64
+	seen   int64
+}
+
+// newSampleDeduper creates a deduper storing repeated values at least every window milliseconds
+func newSampleDeduper(window int64, maxSeries int) *sampleDeduper {
+	return &sampleDeduper{
+		window:    window,
+		maxSeries: maxSeries,
+		series:    make(map[uint64]trackedSeries),
+	}
+}
+
+// keep reports whether a sample must be stored. Only gauges are dropped: counters,
+// histograms, summaries and untyped series are always stored, since rate and increase
+// need every sample of a range to detect resets and extrapolate.
+//
+// When a gauge changes after repeated values were dropped, keep also returns the last
+// dropped sample. The caller stores it before the new sample, so the change isn't spread
+// over the dropped samples by delta or deriv.
+func (d *sampleDeduper) keep(typ model.MetricType, lbls labels.Labels, timestamp int64, v float64) (store bool, repeat *promql.FPoint) {
+	if d == nil || typ != model.MetricTypeGauge || value.IsStaleNaN(v) {
+		return true, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hash := lbls.Hash()
+	last, ok := d.series[hash]
+	if ok && !labels.Equal(last.lbls, lbls) {
+		// A hash collision, the series is not tracked rather than evicting the other one
+		return true, nil
+	}
+	if !ok && len(d.series) >= d.maxSeries {
+		return true, nil
+	}
+	if ok && timestamp >= last.seen && math.Float64bits(last.value) == math.Float64bits(v) &&
+		timestamp-last.stored < d.window {
+		last.seen = timestamp
+		d.series[hash] = last
+		return false, nil
+	}
+	if ok && last.seen > last.stored && last.seen < timestamp {
+		repeat = &promql.FPoint{T: last.seen, F: last.value}
+	}
+	d.series[hash] = trackedSeries{lbls: lbls, value: v, stored: timestamp, seen: timestamp}
+	return true, repeat
+}
+
+// prune forgets the series not seen within the window before timestamp, e.g. because
+// they are no longer reported, so they don't count against maxSeries
+func (d *sampleDeduper) prune(timestamp int64) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for hash, last := range d.series {
+		if timestamp-last.seen >= d.window {
+			delete(d.series, hash)
+		}
+	}
+}
+
+// forget stops tracking a series, e.g. because storing its sample failed
+func (d *sampleDeduper) forget(lbls labels.Labels) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.series[lbls.Hash()]; ok && labels.Equal(last.lbls, lbls) {
+		delete(d.series, lbls.Hash())
+	}
+}
+
+// reset forgets all series, e.g. because the samples of a scrape were not committed
+func (d *sampleDeduper) reset() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	clear(d.series)
+}
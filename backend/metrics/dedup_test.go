@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestSampleDeduperGauges(t *testing.T) {
+	d := newSampleDeduper(time.Minute.Milliseconds(), 10)
+	lbls := labels.FromStrings("__name__", "armada_tables", "cluster", "a")
+	keep := func(ts int64, v float64) bool {
+		store, _ := d.keep(model.MetricTypeGauge, lbls, ts, v)
+		return store
+	}
+
+	assert.True(t, keep(0, 3))
+	assert.False(t, keep(15_000, 3))
+	assert.False(t, keep(45_000, 3))
+	// A repeated value is stored once the window has passed
+	assert.True(t, keep(60_000, 3))
+	assert.False(t, keep(75_000, 3))
+
+	// The last dropped sample is stored before a change
+	store, repeat := d.keep(model.MetricTypeGauge, lbls, 90_000, 4)
+	assert.True(t, store)
+	assert.Equal(t, &promql.FPoint{T: 75_000, F: 3}, repeat)
+	// There's nothing to repeat if the previous value was stored
+	store, repeat = d.keep(model.MetricTypeGauge, lbls, 105_000, 5)
+	assert.True(t, store)
+	assert.Nil(t, repeat)
+
+	// NaN repeats like any other value, stale markers are always stored
+	assert.True(t, keep(120_000, math.NaN()))
+	assert.False(t, keep(135_000, math.NaN()))
+	assert.True(t, keep(150_000, math.Float64frombits(value.StaleNaN)))
+}
+
+func TestSampleDeduperKeepsOtherTypes(t *testing.T) {
+	d := newSampleDeduper(time.Minute.Milliseconds(), 10)
+	for _, typ := range []model.MetricType{model.MetricTypeCounter, model.MetricTypeHistogram, model.MetricTypeSummary, model.MetricTypeUnknown, ""} {
+		lbls := labels.FromStrings("__name__", "armada_requests_total", "type", string(typ))
+		for ts := int64(0); ts < 60_000; ts += 15_000 {
+			store, repeat := d.keep(typ, lbls, ts, 7)
+			assert.True(t, store, "type %q", typ)
+			assert.Nil(t, repeat)
+		}
+	}
+}
+
+func TestSampleDeduperBounds(t *testing.T) {
+	d := newSampleDeduper(time.Minute.Milliseconds(), 1)
+	a := labels.FromStrings("__name__", "armada_tables", "cluster", "a")
+	b := labels.FromStrings("__name__", "armada_tables", "cluster", "b")
+
+	d.keep(model.MetricTypeGauge, a, 0, 1)
+	d.keep(model.MetricTypeGauge, b, 0, 1)
+	// b isn't tracked, so its repeated values are stored
+	store, _ := d.keep(model.MetricTypeGauge, b, 15_000, 1)
+	assert.True(t, store)
+	store, _ = d.keep(model.MetricTypeGauge, a, 15_000, 1)
+	assert.False(t, store)
+
+	// a is no longer reported, pruning makes room for b
+	d.prune(75_000)
+	assert.Empty(t, d.series)
+	d.keep(model.MetricTypeGauge, b, 75_000, 1)
+	store, _ = d.keep(model.MetricTypeGauge, b, 90_000, 1)
+	assert.False(t, store)
+
+	// A series whose sample couldn't be stored is stored again
+	d.forget(b)
+	store, _ = d.keep(model.MetricTypeGauge, b, 105_000, 1)
+	assert.True(t, store)
+}
+
+func TestStoreMetricsDeduplicatesGauges(t *testing.T) {
+	mockPool := &mockClusterPool{}
+	mockPool.On("GetConnection", mock.Anything, "a:8080").Return(&armada.ServerConnection{NodeID: "1"}, nil)
+
+	manager, err := NewMetricsManager(mockPool, 15*time.Second, createTempDir(t), zap.NewNop(),
+		WithDeduplication(time.Minute, 100))
+	assert.NoError(t, err)
+	defer manager.Stop()
+	manager.mu.Lock()
+	manager.addCluster(context.Background(), "a:8080")
+	collector := manager.collectors["a:8080"]
+	manager.mu.Unlock()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	scrapes := []string{"2", "2", "2", "5"}
+	for i, tables := range scrapes {
+		err := collector.storeMetricsInTSDB(context.Background(), &armada.MetricsData{
+			Source: "a:8080",
+			Data: "# TYPE armada_tables gauge\narmada_tables " + tables + "\n" +
+				"# TYPE armada_requests_total counter\narmada_requests_total 10\n",
+			Timestamp: start.Add(time.Duration(i) * 15 * time.Second),
+		})
+		assert.NoError(t, err)
+	}
+
+	points := func(name string) []promql.FPoint {
+		q, err := manager.GetStorage().Querier(start.UnixMilli(), start.Add(time.Hour).UnixMilli())
+		assert.NoError(t, err)
+		defer q.Close()
+		set := q.Select(context.Background(), false, nil, labels.MustNewMatcher(labels.MatchEqual, "__name__", name))
+		var result []promql.FPoint
+		for set.Next() {
+			it := set.At().Iterator(nil)
+			for it.Next() == chunkenc.ValFloat {
+				ts, v := it.At()
+				result = append(result, promql.FPoint{T: ts, F: v})
+			}
+		}
+		assert.NoError(t, set.Err())
+		return result
+	}
+
+	ms := func(i int) int64 { return start.Add(time.Duration(i) * 15 * time.Second).UnixMilli() }
+	// The first value, the last repeat before the change and the change are stored
+	assert.Equal(t, []promql.FPoint{{T: ms(0), F: 2}, {T: ms(2), F: 2}, {T: ms(3), F: 5}}, points("armada_tables"))
+	assert.Len(t, points("armada_requests_total"), len(scrapes))
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"iter"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/render"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// renderTable exports a query result as a table with a row per sample: its timestamp, a column
+// per label of the series and its value
+func renderTable(w http.ResponseWriter, format render.Format, name string, result QueryResult) error {
+	var series []labels.Labels
+	switch value := result.Value.(type) {
+	case promql.Vector:
+		for _, sample := range value {
+			series = append(series, sample.Metric)
+		}
+	case promql.Matrix:
+		for _, s := range value {
+			series = append(series, s.Metric)
+		}
+	}
+	names := labelNames(series)
+
+	columns := make([]render.Column, 0, len(names)+2)
+	columns = append(columns, render.Column{Name: "timestamp"})
+	for _, n := range names {
+		columns = append(columns, render.Column{Name: n})
+	}
+	columns = append(columns, render.Column{Name: "value", Numeric: true})
+
+	filename := name + "-" + time.Now().UTC().Format("20060102-150405")
+	return render.Table(w, format, filename, columns, tableRows(result.Value, names))
+}
+
+// labelNames returns the names of the labels of the series, the metric name first
+func labelNames(series []labels.Labels) []string {
+	var names []string
+	for _, lbls := range series {
+		lbls.Range(func(l labels.Label) {
+			if !slices.Contains(names, l.Name) {
+				names = append(names, l.Name)
+			}
+		})
+	}
+	slices.Sort(names)
+	if i := slices.Index(names, labels.MetricName); i > 0 {
+		names = slices.Insert(slices.Delete(names, i, i+1), 0, labels.MetricName)
+	}
+	return names
+}
+
+// tableRows returns the rows of the samples of a query result
+func tableRows(value parser.Value, names []string) iter.Seq[[]string] {
+	return func(yield func([]string) bool) {
+		row := func(t int64, lbls labels.Labels, f float64, h *histogram.FloatHistogram) []string {
+			cells := make([]string, 0, len(names)+2)
+			cells = append(cells, time.UnixMilli(t).UTC().Format(time.RFC3339Nano))
+			for _, n := range names {
+				cells = append(cells, lbls.Get(n))
+			}
+			if h != nil {
+				return append(cells, h.String())
+			}
+			return append(cells, strconv.FormatFloat(f, 'f', -1, 64))
+		}
+
+		switch value := value.(type) {
+		case promql.Vector:
+			for _, sample := range value {
+				if !yield(row(sample.T, sample.Metric, sample.F, sample.H)) {
+					return
+				}
+			}
+		case promql.Matrix:
+			for _, series := range value {
+				for _, point := range series.Floats {
+					if !yield(row(point.T, series.Metric, point.F, nil)) {
+						return
+					}
+				}
+				for _, point := range series.Histograms {
+					if !yield(row(point.T, series.Metric, 0, point.H)) {
+						return
+					}
+				}
+			}
+		case promql.Scalar:
+			yield(row(value.T, labels.EmptyLabels(), value.V, nil))
+		case promql.String:
+			yield([]string{time.UnixMilli(value.T).UTC().Format(time.RFC3339Nano), value.V})
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleQueryExport(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	now := time.Unix(time.Now().Unix(), 0).Truncate(time.Minute)
+	appender := manager.GetStorage().Appender(t.Context())
+	for _, ts := range []time.Time{now.Add(-time.Minute), now} {
+		_, err = appender.Append(0, labels.FromStrings("__name__", "armada_tables", "cluster", "a:5001"), ts.UnixMilli(), 5)
+		require.NoError(t, err)
+		_, err = appender.Append(0, labels.FromStrings("__name__", "armada_tables", "cluster", "b:5001", "role", "leader"), ts.UnixMilli(), 2.5)
+		require.NoError(t, err)
+	}
+	require.NoError(t, appender.Commit())
+	handler := NewMetricsHandler(manager, zap.NewNop())
+
+	rr := httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/query?query=armada_tables&time=%d&format=csv", now.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment; filename=query-")
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	ts := now.UTC().Format(time.RFC3339Nano)
+	assert.Equal(t, [][]string{
+		{"timestamp", "__name__", "cluster", "role", "value"},
+		{ts, "armada_tables", "a:5001", "", "5"},
+		{ts, "armada_tables", "b:5001", "leader", "2.5"},
+	}, records)
+
+	// Range queries get a row per sample
+	rr = httptest.NewRecorder()
+	handler.handleQueryRange(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/query_range?query=sum(armada_tables)&start=%d&end=%d&step=1m&format=csv",
+		now.Add(-time.Minute).Unix(), now.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	records, err = csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"timestamp", "value"},
+		{now.Add(-time.Minute).UTC().Format(time.RFC3339Nano), "7.5"},
+		{ts, "7.5"},
+	}, records)
+
+	rr = httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/query?query=armada_tables&time=%d&format=xlsx", now.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	_, err = zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	assert.NoError(t, err)
+
+	rr = httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", "/api/metrics/query?query=armada_tables&format=pdf", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -1,12 +1,20 @@
 package metrics
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/armadakv/console/backend/coalesce"
+	"github.com/armadakv/console/backend/panels"
+	"github.com/armadakv/console/backend/polling"
+	"github.com/armadakv/console/backend/render"
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/common/model"
 	"go.uber.org/zap"
 )
 
@@ -15,22 +23,58 @@ type MetricsHandler struct {
 	logger         *zap.Logger
 	metricsManager *MetricsManager
 	queryEngine    *QueryEngine
+	// queries coalesces identical concurrent queries, e.g. from several open dashboards
+	queries coalesce.Group
+	// refresh suggests polling intervals to clients, it may be nil
+	refresh *polling.Advisor
+	// queryTimeout bounds the evaluation of queries, the query engine's default if zero
+	queryTimeout time.Duration
+	// panels are the saved queries requests can name instead of giving the query, it may be nil
+	panels *panels.Store
+}
+
+// HandlerOption configures optional dependencies of the MetricsHandler
+type HandlerOption func(*MetricsHandler)
+
+// WithRefreshAdvisor makes query responses include a suggested refresh interval
+func WithRefreshAdvisor(advisor *polling.Advisor) HandlerOption {
+	return func(h *MetricsHandler) {
+		h.refresh = advisor
+	}
+}
+
+// WithQueryTimeout bounds the evaluation of queries by the timeout
+func WithQueryTimeout(timeout time.Duration) HandlerOption {
+	return func(h *MetricsHandler) {
+		h.queryTimeout = timeout
+	}
+}
+
+// WithPanels lets queries name a saved panel instead of giving the query, and annotates their
+// responses with the unit and thresholds of the panel
+func WithPanels(store *panels.Store) HandlerOption {
+	return func(h *MetricsHandler) {
+		h.panels = store
+	}
 }
 
 // NewMetricsHandler creates a new metrics handler
-func NewMetricsHandler(metricsManager *MetricsManager, logger *zap.Logger) *MetricsHandler {
+func NewMetricsHandler(metricsManager *MetricsManager, logger *zap.Logger, opts ...HandlerOption) *MetricsHandler {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 
-	// Create a query engine for the TSDB
-	queryEngine := NewQueryEngine(metricsManager.GetStorage(), logger)
-
-	return &MetricsHandler{
+	h := &MetricsHandler{
 		logger:         logger.Named("metrics-handler"),
 		metricsManager: metricsManager,
-		queryEngine:    queryEngine,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	// Create a query engine for the TSDB
+	h.queryEngine = NewQueryEngine(metricsManager.GetStorage(), logger, WithEvaluationTimeout(h.queryTimeout))
+	return h
 }
 
 // RegisterRoutes registers the metrics handler routes to the given router
@@ -38,7 +82,13 @@ func (h *MetricsHandler) RegisterRoutes(r chi.Router) {
 	metricsRouter := chi.NewRouter()
 	metricsRouter.Get("/query", h.handleQuery)
 	metricsRouter.Get("/query_range", h.handleQueryRange)
+	metricsRouter.Post("/batch_query", h.handleBatchQuery)
+	metricsRouter.Get("/heatmap", h.handleHeatmap)
+	metricsRouter.Get("/topk", h.handleTopK)
+	metricsRouter.Get("/suggest", h.handleSuggest)
+	metricsRouter.Get("/targets", h.handleTargets)
 	r.Mount("/api/metrics", metricsRouter)
+	r.Get("/api/servers/{id}/resources", h.handleServerResources)
 }
 
 // LiveMetricsResponse is the response format for live metrics
@@ -52,6 +102,14 @@ type LiveMetricsResponse struct {
 type QueryResponse struct {
 	Status string      `json:"status"` // Query status (success, error)
 	Data   QueryResult `json:"data"`   // The query result data
+	// SuggestedRefreshSeconds hints how long clients should wait before polling again
+	SuggestedRefreshSeconds int `json:"suggestedRefreshSeconds,omitempty"`
+	// CompareOffset is how far the comparison of a range query is shifted back, e.g. 24h
+	CompareOffset string `json:"compareOffset,omitempty"`
+	// Comparison is the range query result CompareOffset earlier, with timestamps aligned to Data
+	Comparison *QueryResult `json:"comparison,omitempty"`
+	// Annotations are the unit and thresholds of the panel the query was given by, if any
+	Annotations *panels.Annotations `json:"annotations,omitempty"`
 }
 
 // QueryStatsResponse contains statistics about a query execution
@@ -77,19 +135,35 @@ type ErrorResponse struct {
 // @Summary Query stored metrics
 // @Description Execute a PromQL query against stored metrics at a specific time
 // @Tags metrics
-// @Produce json
-// @Param query query string true "PromQL query to execute"
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param query query string false "PromQL query to execute, required unless panel is given"
+// @Param panel query string false "Name of a saved panel whose query is executed, its unit and thresholds are returned in annotations"
 // @Param time query string false "Query evaluation timestamp (RFC3339 or unix timestamp)"
+// @Param clusters query []string false "Restrict every selector of the query to these clusters, e.g. a:5001,b:5001" collectionFormat(csv)
+// @Param tags query []string false "Restrict every selector of the query to clusters with these tags, e.g. env=prod,region=eu" collectionFormat(csv)
+// @Param groupBy query []string false "Aggregate the result by these labels, e.g. cluster" collectionFormat(csv)
+// @Param aggregate query string false "Aggregation of groupBy: sum (default), avg, min, max or count"
+// @Param format query string false "Response format: json (default), or csv or xlsx to download a row per sample with its timestamp, labels and value" Enums(json, csv, xlsx)
 // @Success 200 {object} QueryResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Panel not found"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/metrics/query [get]
 func (h *MetricsHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	queryStr := r.URL.Query().Get("query")
-	if queryStr == "" {
-		renderError(w, http.StatusBadRequest, "Missing required parameter 'query'")
+	queryStr, annotations, ok := h.requestQuery(w, r.URL.Query())
+	if !ok {
+		return
+	}
+	queryStr, err := scopeQuery(r.URL.Query(), queryStr)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, tabular, err := render.TableFormat(r)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, "Invalid format, expected json, csv or xlsx")
 		return
 	}
 
@@ -99,7 +173,6 @@ func (h *MetricsHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 	if timeParam == "" {
 		ts = time.Now()
 	} else {
-		var err error
 		// Try parsing as RFC3339
 		ts, err = time.Parse(time.RFC3339, timeParam)
 		if err != nil {
@@ -118,7 +191,10 @@ func (h *MetricsHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 		zap.Time("time", ts))
 
 	// Execute the query
-	result, err := h.queryEngine.Query(ctx, queryStr, ts)
+	key := instantKey(queryStr, ts)
+	result, _, err := coalesce.Do(ctx, &h.queries, key, func(ctx context.Context) (QueryResult, error) {
+		return h.queryEngine.Query(ctx, queryStr, ts)
+	})
 	if err != nil {
 		h.logger.Error("Query execution failed",
 			zap.String("query", queryStr),
@@ -127,82 +203,102 @@ func (h *MetricsHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if tabular {
+		if err := renderTable(w, format, "query", result); err != nil {
+			h.logger.Warn("Failed to export query result", zap.String("query", queryStr), zap.Error(err))
+		}
+		return
+	}
+
 	// Format the response
 	resp := QueryResponse{
-		Status: "success",
-		Data:   result,
+		Status:                  "success",
+		Data:                    result,
+		SuggestedRefreshSeconds: h.refresh.Suggest("query\x00"+queryStr, result),
+		Annotations:             annotations,
 	}
 
 	renderJSON(w, resp)
 }
 
+// handleTargets returns the scrape state of every cluster
+// @Summary List scrape targets
+// @Description Returns the health of the last scrape of every cluster and how long failing clusters are backed off
+// @Tags metrics
+// @Produce json
+// @Success 200 {array} Target
+// @Router /api/metrics/targets [get]
+func (h *MetricsHandler) handleTargets(w http.ResponseWriter, r *http.Request) {
+	renderJSON(w, h.metricsManager.Targets())
+}
+
 // handleQueryRange handles range queries against stored metrics
 // @Summary Query stored metrics over a time range
 // @Description Execute a PromQL query against stored metrics over a specified time range
 // @Tags metrics
-// @Produce json
-// @Param query query string true "PromQL query to execute"
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param query query string false "PromQL query to execute, required unless panel is given"
+// @Param panel query string false "Name of a saved panel whose query is executed, its unit and thresholds are returned in annotations"
 // @Param start query string true "Start timestamp (RFC3339 or unix timestamp)"
 // @Param end query string true "End timestamp (RFC3339 or unix timestamp)"
 // @Param step query string false "Query resolution step width in duration format (e.g. 15s, 1m, 1h) or seconds (default: 1m)"
+// @Param compareOffset query string false "Also return the result this far back, aligned to the range, e.g. 24h or 7d"
+// @Param clusters query []string false "Restrict every selector of the query to these clusters, e.g. a:5001,b:5001" collectionFormat(csv)
+// @Param tags query []string false "Restrict every selector of the query to clusters with these tags, e.g. env=prod,region=eu" collectionFormat(csv)
+// @Param groupBy query []string false "Aggregate the result by these labels, e.g. cluster" collectionFormat(csv)
+// @Param aggregate query string false "Aggregation of groupBy: sum (default), avg, min, max or count"
+// @Param format query string false "Response format: json (default), or csv or xlsx to download a row per sample with its timestamp, labels and value" Enums(json, csv, xlsx)
 // @Success 200 {object} QueryResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Panel not found"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/metrics/query_range [get]
 func (h *MetricsHandler) handleQueryRange(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	queryStr := r.URL.Query().Get("query")
-	if queryStr == "" {
-		renderError(w, http.StatusBadRequest, "Missing required parameter 'query'")
+	queryStr, annotations, ok := h.requestQuery(w, r.URL.Query())
+	if !ok {
 		return
 	}
-
-	// Parse start time
-	startParam := r.URL.Query().Get("start")
-	if startParam == "" {
-		renderError(w, http.StatusBadRequest, "Missing required parameter 'start'")
+	queryStr, err := scopeQuery(r.URL.Query(), queryStr)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	startTime, err := parseTime(startParam)
+	format, tabular, err := render.TableFormat(r)
 	if err != nil {
-		renderError(w, http.StatusBadRequest, "Invalid start time format")
+		renderError(w, http.StatusBadRequest, "Invalid format, expected json, csv or xlsx")
 		return
 	}
 
-	// Parse end time
-	endParam := r.URL.Query().Get("end")
-	if endParam == "" {
-		renderError(w, http.StatusBadRequest, "Missing required parameter 'end'")
-		return
-	}
-	endTime, err := parseTime(endParam)
-	if err != nil {
-		renderError(w, http.StatusBadRequest, "Invalid end time format")
+	startTime, endTime, step, ok := parseRange(w, r.URL.Query())
+	if !ok {
 		return
 	}
 
-	// Parse step
-	stepParam := r.URL.Query().Get("step")
-	var step time.Duration
-	if stepParam == "" {
-		step = time.Minute // Default step
-	} else {
-		step, err = parseDuration(stepParam)
-		if err != nil {
-			renderError(w, http.StatusBadRequest, "Invalid step format")
+	// Parse the offset of the comparison, e.g. 24h for day-over-day or 7d for week-over-week
+	var compareOffset time.Duration
+	if offsetParam := r.URL.Query().Get("compareOffset"); offsetParam != "" {
+		offset, err := model.ParseDuration(offsetParam)
+		if err != nil || offset <= 0 {
+			renderError(w, http.StatusBadRequest, "Invalid compareOffset, expected a positive duration such as 24h or 7d")
 			return
 		}
+		compareOffset = time.Duration(offset)
 	}
 
 	h.logger.Debug("Executing range query",
 		zap.String("query", queryStr),
 		zap.Time("start", startTime),
 		zap.Time("end", endTime),
-		zap.Duration("step", step))
+		zap.Duration("step", step),
+		zap.Duration("compareOffset", compareOffset))
 
 	// Execute the query
-	result, err := h.queryEngine.QueryRange(ctx, queryStr, startTime, endTime, step)
+	key := rangeKey(queryStr, startTime, endTime, step)
+	result, _, err := coalesce.Do(ctx, &h.queries, key, func(ctx context.Context) (QueryResult, error) {
+		return h.queryEngine.QueryRange(ctx, queryStr, startTime, endTime, step)
+	})
 	if err != nil {
 		h.logger.Error("Range query execution failed",
 			zap.String("query", queryStr),
@@ -211,10 +307,36 @@ func (h *MetricsHandler) handleQueryRange(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if tabular {
+		if err := renderTable(w, format, "query_range", result); err != nil {
+			h.logger.Warn("Failed to export range query result", zap.String("query", queryStr), zap.Error(err))
+		}
+		return
+	}
+
 	// Format the response
 	resp := QueryResponse{
-		Status: "success",
-		Data:   result,
+		Status:                  "success",
+		Data:                    result,
+		SuggestedRefreshSeconds: h.refresh.Suggest("query_range\x00"+queryStr+"\x00"+step.String(), result),
+		Annotations:             annotations,
+	}
+
+	if compareOffset > 0 {
+		key += "\x00" + compareOffset.String()
+		comparison, _, err := coalesce.Do(ctx, &h.queries, key, func(ctx context.Context) (QueryResult, error) {
+			return h.queryEngine.QueryRangeShifted(ctx, queryStr, startTime, endTime, step, compareOffset)
+		})
+		if err != nil {
+			h.logger.Error("Comparison range query execution failed",
+				zap.String("query", queryStr),
+				zap.Duration("compareOffset", compareOffset),
+				zap.Error(err))
+			renderError(w, http.StatusInternalServerError, "Range query execution failed")
+			return
+		}
+		resp.CompareOffset = model.Duration(compareOffset).String()
+		resp.Comparison = &comparison
 	}
 
 	renderJSON(w, resp)
@@ -222,6 +344,113 @@ func (h *MetricsHandler) handleQueryRange(w http.ResponseWriter, r *http.Request
 
 // Helper functions
 
+// requestError is an invalid request, answered with its status and message
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// renderRequestError answers with the status and message of a requestError, other errors are internal
+func renderRequestError(w http.ResponseWriter, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		renderError(w, reqErr.status, reqErr.message)
+		return
+	}
+	renderError(w, http.StatusInternalServerError, err.Error())
+}
+
+// requestQuery returns the query of a request, given directly or by the name of a saved panel
+// whose annotations are returned with it. Invalid requests are answered and reported as not ok.
+func (h *MetricsHandler) requestQuery(w http.ResponseWriter, params url.Values) (string, *panels.Annotations, bool) {
+	queryStr, annotations, err := h.resolveQuery(params)
+	if err != nil {
+		renderRequestError(w, err)
+		return "", nil, false
+	}
+	return queryStr, annotations, true
+}
+
+// resolveQuery returns the query given by the parameters, directly or by the name of a saved panel
+// whose annotations are returned with it
+func (h *MetricsHandler) resolveQuery(params url.Values) (string, *panels.Annotations, error) {
+	queryStr, name := params.Get("query"), params.Get("panel")
+	switch {
+	case name != "" && queryStr != "":
+		return "", nil, &requestError{http.StatusBadRequest, "Parameters 'query' and 'panel' are mutually exclusive"}
+	case name != "":
+		if h.panels == nil {
+			return "", nil, &requestError{http.StatusNotFound, "Panel " + name + " not found"}
+		}
+		panel, err := h.panels.Get(name)
+		if errors.Is(err, panels.ErrNotFound) {
+			return "", nil, &requestError{http.StatusNotFound, "Panel " + name + " not found"}
+		}
+		if err != nil {
+			h.logger.Error("Failed to load panel", zap.String("panel", name), zap.Error(err))
+			return "", nil, &requestError{http.StatusInternalServerError, "Failed to load panel"}
+		}
+		return panel.Query, &panel.Annotations, nil
+	case queryStr == "":
+		return "", nil, &requestError{http.StatusBadRequest, "Missing required parameter 'query'"}
+	default:
+		return queryStr, nil, nil
+	}
+}
+
+// parseRange reads the start, end and step of a range query, the step defaults to a minute.
+// Invalid parameters are answered with 400 Bad Request and reported as not ok.
+func parseRange(w http.ResponseWriter, params url.Values) (start, end time.Time, step time.Duration, ok bool) {
+	start, end, step, err := rangeParams(params)
+	if err != nil {
+		renderRequestError(w, err)
+		return start, end, step, false
+	}
+	return start, end, step, true
+}
+
+// rangeParams returns the start, end and step of a range query, the step defaults to a minute
+func rangeParams(params url.Values) (start, end time.Time, step time.Duration, err error) {
+	startParam := params.Get("start")
+	if startParam == "" {
+		return start, end, step, &requestError{http.StatusBadRequest, "Missing required parameter 'start'"}
+	}
+	if start, err = parseTime(startParam); err != nil {
+		return start, end, step, &requestError{http.StatusBadRequest, "Invalid start time format"}
+	}
+
+	endParam := params.Get("end")
+	if endParam == "" {
+		return start, end, step, &requestError{http.StatusBadRequest, "Missing required parameter 'end'"}
+	}
+	if end, err = parseTime(endParam); err != nil {
+		return start, end, step, &requestError{http.StatusBadRequest, "Invalid end time format"}
+	}
+
+	step = time.Minute
+	if stepParam := params.Get("step"); stepParam != "" {
+		if step, err = parseDuration(stepParam); err != nil {
+			return start, end, step, &requestError{http.StatusBadRequest, "Invalid step format"}
+		}
+	}
+	return start, end, step, nil
+}
+
+// instantKey identifies an instant query, so identical concurrent queries are evaluated once
+func instantKey(query string, ts time.Time) string {
+	return "query\x00" + query + "\x00" + strconv.FormatInt(ts.UnixMilli(), 10)
+}
+
+// rangeKey identifies a range query, so identical concurrent queries are evaluated once
+func rangeKey(query string, start, end time.Time, step time.Duration) string {
+	return "query_range\x00" + query + "\x00" + strconv.FormatInt(start.UnixMilli(), 10) +
+		"\x00" + strconv.FormatInt(end.UnixMilli(), 10) + "\x00" + step.String()
+}
+
 // parseTime parses a time string in RFC3339 or Unix timestamp format
 func parseTime(timeStr string) (time.Time, error) {
 	// Try parsing as RFC3339
@@ -254,12 +483,11 @@ func parseDuration(durationStr string) (time.Duration, error) {
 	return time.Duration(seconds * float64(time.Second)), nil
 }
 
-// renderJSON renders an object as JSON response
+// renderJSON renders an object as JSON response.
+// Large results such as range query matrices are streamed instead of buffered in memory.
 func renderJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(v); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	_ = render.JSON(w, http.StatusOK, v)
 }
 
 // renderError renders an error response
@@ -3,16 +3,21 @@ package metrics
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/panels"
 	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -107,6 +112,7 @@ func TestMetricsHandlerRegisterRoutes(t *testing.T) {
 	}{
 		{"GET", "/api/metrics/query"},
 		{"GET", "/api/metrics/query_range"},
+		{"GET", "/api/metrics/targets"},
 	}
 
 	for _, tc := range testCases {
@@ -301,6 +307,104 @@ func TestHandleQueryRangeWithValidParameters(t *testing.T) {
 	assert.Contains(t, []string{"success", "error"}, response["status"])
 }
 
+func TestHandleQueryRangeCompareOffset(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	// The same series a day ago and now
+	end := time.Now().Truncate(time.Minute)
+	appender := manager.GetStorage().Appender(t.Context())
+	lbls := labels.FromStrings("__name__", "armada_tables")
+	_, err = appender.Append(0, lbls, end.Add(-24*time.Hour).UnixMilli(), 3)
+	require.NoError(t, err)
+	_, err = appender.Append(0, lbls, end.UnixMilli(), 5)
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	handler := NewMetricsHandler(manager, zap.NewNop())
+	query := fmt.Sprintf("/api/metrics/query_range?query=armada_tables&start=%d&end=%d&step=1m", end.Add(-time.Minute).Unix(), end.Unix())
+
+	rr := httptest.NewRecorder()
+	handler.handleQueryRange(rr, httptest.NewRequest("GET", query+"&compareOffset=1d", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response struct {
+		Data          json.RawMessage `json:"data"`
+		CompareOffset string          `json:"compareOffset"`
+		Comparison    struct {
+			Result []struct {
+				Values [][2]any `json:"values"`
+			} `json:"result"`
+		} `json:"comparison"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	assert.Equal(t, "1d", response.CompareOffset)
+	// The sample of the day before is aligned to the end of the range
+	require.Len(t, response.Comparison.Result, 1)
+	values := response.Comparison.Result[0].Values
+	require.NotEmpty(t, values)
+	assert.Equal(t, float64(end.Unix()), values[len(values)-1][0])
+	assert.Equal(t, "3", values[len(values)-1][1])
+
+	for _, offset := range []string{"yesterday", "-24h", "0s"} {
+		rr = httptest.NewRecorder()
+		handler.handleQueryRange(rr, httptest.NewRequest("GET", query+"&compareOffset="+offset, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, offset)
+	}
+}
+
+func TestHandleQueryPanel(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	now := time.Now().Truncate(time.Minute)
+	appender := manager.GetStorage().Appender(t.Context())
+	_, err = appender.Append(0, labels.FromStrings("__name__", "armada_tables"), now.UnixMilli(), 5)
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	store := panels.NewStore(metadata.NewMemoryStore())
+	warning := 10.0
+	_, err = store.Put(panels.Panel{Name: "tables", Query: "armada_tables", Annotations: panels.Annotations{
+		Unit:       "count",
+		Thresholds: &panels.Thresholds{Warning: &warning},
+	}})
+	require.NoError(t, err)
+	handler := NewMetricsHandler(manager, zap.NewNop(), WithPanels(store))
+
+	rr := httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/query?panel=tables&time=%d", now.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var response struct {
+		Annotations *panels.Annotations `json:"annotations"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.NotNil(t, response.Annotations)
+	assert.Equal(t, "count", response.Annotations.Unit)
+	assert.InDelta(t, 10, *response.Annotations.Thresholds.Warning, 0)
+	assert.Contains(t, rr.Body.String(), `"value":[`)
+
+	rr = httptest.NewRecorder()
+	handler.handleQueryRange(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/query_range?panel=tables&start=%d&end=%d", now.Add(-time.Minute).Unix(), now.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Contains(t, rr.Body.String(), `"unit":"count"`)
+
+	// Queries given directly aren't annotated
+	rr = httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", "/api/metrics/query?query=armada_tables", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NotContains(t, rr.Body.String(), "annotations")
+
+	rr = httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", "/api/metrics/query?panel=missing", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", "/api/metrics/query?panel=tables&query=up", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
 func TestLiveMetricsResponse(t *testing.T) {
 	timestamp := time.Now()
 	response := LiveMetricsResponse{
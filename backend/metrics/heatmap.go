@@ -0,0 +1,228 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/coalesce"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/zap"
+)
+
+// defaultHeatmapWindow is the rate window of heatmaps unless another window is requested
+const defaultHeatmapWindow = 5 * time.Minute
+
+// metricName matches the names of metrics
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// HeatmapResponse is a histogram over time as a time × bucket matrix
+type HeatmapResponse struct {
+	Status string `json:"status"` // Query status (success, error)
+	// Query is the PromQL query the heatmap was computed from
+	Query string `json:"query"`
+	// Buckets are the upper bounds of the buckets in ascending order, the last one is usually +Inf
+	Buckets []string `json:"buckets"`
+	// Times are the timestamps of the rows in unix milliseconds, spaced by the step
+	Times []int64 `json:"times"`
+	// Values holds a row per time with the per-second rate of observations falling into each bucket,
+	// i.e. above the previous bound and up to its own
+	Values [][]float64 `json:"values"`
+	// Max is the largest value of the matrix, e.g. to scale colors
+	Max float64 `json:"max"`
+}
+
+// handleHeatmap converts a histogram into a heatmap
+// @Summary Histogram heatmap
+// @Description Convert the bucket series of a histogram into a time × bucket matrix of per-second rates. Buckets are merged by their numeric upper bound across series and de-cumulated, so each cell counts the observations between the previous bound and its own. Times without samples are 0.
+// @Tags metrics
+// @Produce json
+// @Param metric query string true "Histogram name, with or without the _bucket suffix, e.g. armada_request_duration_seconds"
+// @Param selector query string false "Label matchers restricting the bucket series, e.g. {method=\"Put\"}"
+// @Param window query string false "Rate window (default: 5m)"
+// @Param start query string true "Start timestamp (RFC3339 or unix timestamp)"
+// @Param end query string true "End timestamp (RFC3339 or unix timestamp)"
+// @Param step query string false "Query resolution step width (default: 1m)"
+// @Param clusters query []string false "Restrict the histogram to these clusters, e.g. a:5001,b:5001" collectionFormat(csv)
+// @Param tags query []string false "Restrict the histogram to clusters with these tags, e.g. env=prod,region=eu" collectionFormat(csv)
+// @Success 200 {object} HeatmapResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/metrics/heatmap [get]
+func (h *MetricsHandler) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	name := strings.TrimSuffix(params.Get("metric"), "_bucket")
+	if name == "" {
+		renderError(w, http.StatusBadRequest, "Missing required parameter 'metric'")
+		return
+	}
+	if !metricName.MatchString(name) {
+		renderError(w, http.StatusBadRequest, "Invalid metric name")
+		return
+	}
+	var matchers []*labels.Matcher
+	if selector := params.Get("selector"); selector != "" {
+		var err error
+		matchers, err = parser.ParseMetricSelector(selector)
+		if err != nil {
+			renderError(w, http.StatusBadRequest, "Invalid selector: "+err.Error())
+			return
+		}
+		for _, m := range matchers {
+			if m.Name == labels.MetricName || m.Name == labels.BucketLabel {
+				renderError(w, http.StatusBadRequest, "The selector can't match the metric name or le")
+				return
+			}
+		}
+	}
+
+	window := defaultHeatmapWindow
+	if windowParam := params.Get("window"); windowParam != "" {
+		var err error
+		window, err = parseDuration(windowParam)
+		if err != nil || window <= 0 {
+			renderError(w, http.StatusBadRequest, "Invalid window format")
+			return
+		}
+	}
+
+	start, end, step, ok := parseRange(w, params)
+	if !ok {
+		return
+	}
+	if step < time.Millisecond {
+		renderError(w, http.StatusBadRequest, "Invalid step format")
+		return
+	}
+	if end.Before(start) {
+		renderError(w, http.StatusBadRequest, "End time is before start time")
+		return
+	}
+	if end.Sub(start) > maxQueryRange {
+		end = start.Add(maxQueryRange)
+	}
+
+	tags, err := tagMatchers(params)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	queryStr, err := queryScope{clusters: listParam(params, "clusters"), tags: tags}.apply(heatmapQuery(name, matchers, window))
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Debug("Executing heatmap query",
+		zap.String("query", queryStr),
+		zap.Time("start", start),
+		zap.Time("end", end),
+		zap.Duration("step", step))
+
+	key := rangeKey(queryStr, start, end, step)
+	result, _, err := coalesce.Do(ctx, &h.queries, key, func(ctx context.Context) (QueryResult, error) {
+		return h.queryEngine.QueryRange(ctx, queryStr, start, end, step)
+	})
+	if err != nil {
+		h.logger.Error("Heatmap query execution failed",
+			zap.String("query", queryStr),
+			zap.Error(err))
+		renderError(w, http.StatusInternalServerError, "Heatmap query execution failed")
+		return
+	}
+	matrix, _ := result.Value.(promql.Matrix)
+
+	resp := heatmap(matrix, start, end, step)
+	resp.Status = "success"
+	resp.Query = queryStr
+	renderJSON(w, resp)
+}
+
+// heatmapQuery returns the query for the bucket rates of a histogram summed by their upper bound
+func heatmapQuery(name string, matchers []*labels.Matcher, window time.Duration) string {
+	bucket := name + "_bucket"
+	selector := &parser.VectorSelector{
+		Name:          bucket,
+		LabelMatchers: append([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, bucket)}, matchers...),
+	}
+	return (&parser.AggregateExpr{
+		Op:       parser.SUM,
+		Grouping: []string{labels.BucketLabel},
+		Expr: &parser.Call{
+			Func: parser.Functions["rate"],
+			Args: parser.Expressions{&parser.MatrixSelector{VectorSelector: selector, Range: window}},
+		},
+	}).String()
+}
+
+// heatmap converts the cumulative bucket rates of a range query into a matrix with a row per step
+// from start to end. Series whose le isn't a number are skipped, series with the same bound,
+// e.g. le="1" and le="1.0", are added up.
+func heatmap(matrix promql.Matrix, start, end time.Time, step time.Duration) HeatmapResponse {
+	startMs, stepMs := start.UnixMilli(), step.Milliseconds()
+	rows := int((end.UnixMilli()-startMs)/stepMs) + 1
+
+	cumulative := make(map[float64][]float64)
+	for _, series := range matrix {
+		bound, err := strconv.ParseFloat(series.Metric.Get(labels.BucketLabel), 64)
+		if err != nil || math.IsNaN(bound) {
+			continue
+		}
+		counts, ok := cumulative[bound]
+		if !ok {
+			counts = make([]float64, rows)
+			cumulative[bound] = counts
+		}
+		for _, point := range series.Floats {
+			if i := (point.T - startMs) / stepMs; i >= 0 && i < int64(rows) {
+				counts[i] += point.F
+			}
+		}
+	}
+
+	bounds := make([]float64, 0, len(cumulative))
+	for bound := range cumulative {
+		bounds = append(bounds, bound)
+	}
+	slices.Sort(bounds)
+
+	resp := HeatmapResponse{
+		Buckets: make([]string, len(bounds)),
+		Times:   make([]int64, rows),
+		Values:  make([][]float64, rows),
+	}
+	for j, bound := range bounds {
+		resp.Buckets[j] = formatBound(bound)
+	}
+	for i := range rows {
+		resp.Times[i] = startMs + int64(i)*stepMs
+		row := make([]float64, len(bounds))
+		previous := 0.0
+		for j, bound := range bounds {
+			count := cumulative[bound][i]
+			// Buckets are cumulative, but rates of counters reset at different times can dip below the previous bucket
+			row[j] = max(count-previous, 0)
+			previous = max(count, previous)
+			resp.Max = max(resp.Max, row[j])
+		}
+		resp.Values[i] = row
+	}
+	return resp
+}
+
+// formatBound formats the upper bound of a bucket the way Prometheus formats le labels
+func formatBound(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(bound, 'g', -1, 64)
+}
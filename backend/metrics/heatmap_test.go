@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleHeatmap(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	// Two instances of a histogram observing 3/s and 1/s, the second one formats its bounds differently
+	base := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	buckets := []struct {
+		labels    labels.Labels
+		perMinute float64
+	}{
+		{labels.FromStrings("__name__", "armada_request_duration_seconds_bucket", "instance", "a", "le", "0.1"), 60},
+		{labels.FromStrings("__name__", "armada_request_duration_seconds_bucket", "instance", "a", "le", "1"), 120},
+		{labels.FromStrings("__name__", "armada_request_duration_seconds_bucket", "instance", "a", "le", "+Inf"), 180},
+		{labels.FromStrings("__name__", "armada_request_duration_seconds_bucket", "instance", "b", "le", "0.1"), 60},
+		{labels.FromStrings("__name__", "armada_request_duration_seconds_bucket", "instance", "b", "le", "1.0"), 60},
+		{labels.FromStrings("__name__", "armada_request_duration_seconds_bucket", "instance", "b", "le", "+Inf"), 60},
+	}
+	appender := manager.GetStorage().Appender(t.Context())
+	for _, bucket := range buckets {
+		for i := range 11 {
+			_, err = appender.Append(0, bucket.labels, base.Add(time.Duration(i)*time.Minute).UnixMilli(), float64(i)*bucket.perMinute)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, appender.Commit())
+
+	handler := NewMetricsHandler(manager, zap.NewNop())
+	start, end := base.Add(5*time.Minute), base.Add(9*time.Minute)
+	query := fmt.Sprintf("/api/metrics/heatmap?metric=armada_request_duration_seconds_bucket&start=%d&end=%d&step=1m", start.Unix(), end.Unix())
+
+	rr := httptest.NewRecorder()
+	handler.handleHeatmap(rr, httptest.NewRequest("GET", query, nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp HeatmapResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, `sum by (le) (rate(armada_request_duration_seconds_bucket[5m]))`, resp.Query)
+	assert.Equal(t, []string{"0.1", "1", "+Inf"}, resp.Buckets)
+	require.Len(t, resp.Times, 5)
+	assert.Equal(t, start.UnixMilli(), resp.Times[0])
+	require.Len(t, resp.Values, 5)
+	for _, row := range resp.Values {
+		require.Len(t, row, 3)
+		assert.InDelta(t, 2, row[0], 0.01)
+		assert.InDelta(t, 1, row[1], 0.01)
+		assert.InDelta(t, 1, row[2], 0.01)
+	}
+	assert.InDelta(t, 2, resp.Max, 0.01)
+
+	rr = httptest.NewRecorder()
+	handler.handleHeatmap(rr, httptest.NewRequest("GET", query+`&selector={instance="b"}`, nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.InDelta(t, 1, resp.Values[0][0], 0.01)
+	assert.InDelta(t, 0, resp.Values[0][1], 0.01)
+
+	for _, params := range []string{
+		"metric=armada-latency&start=1&end=2",
+		"metric=armada_request_duration_seconds&start=1&end=2&selector={le=\"1\"}",
+		"metric=armada_request_duration_seconds&start=1&end=2&window=0s",
+		"metric=armada_request_duration_seconds&start=2&end=1",
+		"start=1&end=2",
+	} {
+		rr = httptest.NewRecorder()
+		handler.handleHeatmap(rr, httptest.NewRequest("GET", "/api/metrics/heatmap?"+params, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, params)
+	}
+}
+
+func TestHeatmapFillsGaps(t *testing.T) {
+	start := time.Unix(1000, 0)
+	matrix := promql.Matrix{
+		{Metric: labels.FromStrings("le", "+Inf"), Floats: []promql.FPoint{{T: start.Add(time.Minute).UnixMilli(), F: 5}}},
+		{Metric: labels.FromStrings("le", "0.5"), Floats: []promql.FPoint{{T: start.Add(time.Minute).UnixMilli(), F: 3}}},
+		{Metric: labels.FromStrings("le", "unknown"), Floats: []promql.FPoint{{T: start.UnixMilli(), F: 7}}},
+	}
+
+	resp := heatmap(matrix, start, start.Add(2*time.Minute), time.Minute)
+	assert.Equal(t, []string{"0.5", "+Inf"}, resp.Buckets)
+	assert.Equal(t, [][]float64{{0, 0}, {3, 2}, {0, 0}}, resp.Values)
+	assert.InDelta(t, 3, resp.Max, 0)
+}
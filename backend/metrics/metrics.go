@@ -2,13 +2,18 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/armadakv/console/backend/armada"
 	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/armadakv/console/backend/events"
 
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/textparse"
@@ -23,13 +28,41 @@ type ClusterPool interface {
 
 // MetricsManager manages metrics collection and storage for multiple Armada clusters
 type MetricsManager struct {
-	storage        *tsdb.DB
-	clusterPool    ClusterPool
+	storage     *tsdb.DB
+	clusterPool ClusterPool
+	// scrapeInterval is protected by mu, it changes when the configuration is reloaded
 	scrapeInterval time.Duration
-	logger         *zap.Logger
-	done           chan struct{}
-	collectors     map[string]*MetricsCollector
-	stopOnce       sync.Once
+	// intervalChanged wakes the collection loop up to apply a new scrape interval
+	intervalChanged chan struct{}
+	// maxBackoff caps how long scrapes of a failing cluster are suspended
+	maxBackoff time.Duration
+	logger     *zap.Logger
+	done       chan struct{}
+	// mu protects collectors, the scrape interval and the start of collections
+	mu         sync.Mutex
+	collectors map[string]*MetricsCollector
+	// running counts the collection loop and in-flight collections, Stop waits for them before closing the TSDB
+	running   sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+	// stopped is set once Stop is called, the TSDB is closed right after the running collections finish
+	stopped atomic.Bool
+	// skews keeps the clock skew measured during the last scrape of each cluster
+	skews *skewTracker
+	// metadata keeps the type, help and unit of every scraped metric family
+	metadata *metadataTracker
+	// dedupWindow is how long repeated gauge values are dropped, zero if they are always stored
+	dedupWindow time.Duration
+	// dedupMaxSeries caps the series tracked for de-duplication per cluster
+	dedupMaxSeries int
+	// maxSeriesPerMetric, maxLabels and cardinalityCooldown configure the cardinalityGuard of every cluster
+	maxSeriesPerMetric  int
+	maxLabels           int
+	cardinalityCooldown time.Duration
+	// publisher receives an event when a metric is blocked, it may be nil
+	publisher events.Publisher
+	// targetLabels returns the labels added to the series of a server address, it may be nil
+	targetLabels func(addr string) map[string]string
 }
 
 // MetricsCollector handles metrics collection for a single cluster
@@ -38,52 +71,192 @@ type MetricsCollector struct {
 	manager     *MetricsManager
 	logger      *zap.Logger
 	pool        ClusterPool
+	// ctx bounds the collections of the cluster, cancel aborts them when the cluster is removed
+	ctx    context.Context
+	cancel context.CancelFunc
+	// state backs off from scraping the cluster while it keeps failing
+	state scrapeState
+	// dedup drops repeated gauge values, nil if de-duplication is disabled
+	dedup *sampleDeduper
+	// cardinality blocks metrics exceeding the series or label limits, nil if there are no limits
+	cardinality *cardinalityGuard
+}
+
+// Option configures optional behaviour of the MetricsManager
+type Option func(*options)
+
+// options holds the optional settings of the MetricsManager
+type options struct {
+	retention     time.Duration
+	blockDuration time.Duration
+	maxBackoff    time.Duration
+	dedupWindow   time.Duration
+	dedupMax      int
+	maxSeries     int
+	maxLabels     int
+	cooldown      time.Duration
+	publisher     events.Publisher
+	targetLabels  func(addr string) map[string]string
+}
+
+// WithRetention sets how long collected metrics are kept in the TSDB (default 1 day)
+func WithRetention(retention time.Duration) Option {
+	return func(o *options) {
+		o.retention = retention
+	}
+}
+
+// WithBlockDuration sets the time range covered by a single TSDB block (default 2 hours)
+func WithBlockDuration(blockDuration time.Duration) Option {
+	return func(o *options) {
+		o.blockDuration = blockDuration
+	}
+}
+
+// WithMaxBackoff caps how long scrapes of a failing cluster are suspended (default 10 minutes)
+func WithMaxBackoff(maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithDeduplication drops gauge samples repeating the previous value of their series, storing
+// the value again at least every window. The window must be shorter than the query lookback
+// of 5 minutes. At most maxSeries series are tracked per cluster. Counters, histograms and
+// summaries are always stored.
+func WithDeduplication(window time.Duration, maxSeries int) Option {
+	return func(o *options) {
+		o.dedupWindow = window
+		o.dedupMax = maxSeries
+	}
+}
+
+// WithCardinalityLimits stops storing a metric of a cluster for the cooldown once a scrape
+// returns more than maxSeries series for it, or a series with more than maxLabels labels.
+// A limit of zero disables it.
+func WithCardinalityLimits(maxSeries, maxLabels int, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.maxSeries = maxSeries
+		o.maxLabels = maxLabels
+		o.cooldown = cooldown
+	}
+}
+
+// WithPublisher makes the manager publish an events.TypeCardinality event when a metric is blocked
+func WithPublisher(p events.Publisher) Option {
+	return func(o *options) {
+		o.publisher = p
+	}
+}
+
+// WithTargetLabels adds the labels labels returns for the address of a server to every series
+// scraped from it, e.g. the tags of its cluster. They replace scraped labels of the same name.
+// labels is called for every scrape, so changed labels apply from the next scrape on.
+func WithTargetLabels(labels func(addr string) map[string]string) Option {
+	return func(o *options) {
+		o.targetLabels = labels
+	}
 }
 
 // NewMetricsManager creates a new metrics manager that periodically collects metrics
 // from all discovered Armada clusters and stores them in a local TSDB
-func NewMetricsManager(clusterPool ClusterPool, scrapeInterval time.Duration, storageDir string, logger *zap.Logger) (*MetricsManager, error) {
+func NewMetricsManager(clusterPool ClusterPool, scrapeInterval time.Duration, storageDir string, logger *zap.Logger, opts ...Option) (*MetricsManager, error) {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
 
+	o := options{
+		retention:     24 * time.Hour,
+		blockDuration: 2 * time.Hour,
+		maxBackoff:    10 * time.Minute,
+		cooldown:      time.Hour,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Create TSDB storage
-	opts := tsdb.DefaultOptions()
-	opts.RetentionDuration = 24 * 60 * 60 * 1000 // 1 day in milliseconds
-	opts.MinBlockDuration = 2 * 60 * 60 * 1000   // 2 hours in milliseconds
+	tsdbOpts := tsdb.DefaultOptions()
+	tsdbOpts.RetentionDuration = o.retention.Milliseconds()
+	tsdbOpts.MinBlockDuration = o.blockDuration.Milliseconds()
 
-	db, err := tsdb.Open(storageDir, nil, nil, opts, nil)
+	db, err := tsdb.Open(storageDir, nil, nil, tsdbOpts, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open TSDB: %w", err)
 	}
 
 	manager := &MetricsManager{
-		storage:        db,
-		clusterPool:    clusterPool,
-		scrapeInterval: scrapeInterval,
-		logger:         logger.Named("metrics-manager"),
-		done:           make(chan struct{}),
-		collectors:     make(map[string]*MetricsCollector),
+		storage:             db,
+		clusterPool:         clusterPool,
+		scrapeInterval:      scrapeInterval,
+		intervalChanged:     make(chan struct{}, 1),
+		maxBackoff:          o.maxBackoff,
+		logger:              logger.Named("metrics-manager"),
+		done:                make(chan struct{}),
+		collectors:          make(map[string]*MetricsCollector),
+		skews:               newSkewTracker(),
+		metadata:            newMetadataTracker(),
+		dedupWindow:         o.dedupWindow,
+		dedupMaxSeries:      o.dedupMax,
+		maxSeriesPerMetric:  o.maxSeries,
+		maxLabels:           o.maxLabels,
+		cardinalityCooldown: o.cooldown,
+		publisher:           o.publisher,
+		targetLabels:        o.targetLabels,
 	}
 
 	return manager, nil
 }
 
-// Start begins metrics collection from all clusters at the configured interval
+// Start begins metrics collection from all clusters at the configured interval.
+// Only the first call starts collecting, calls after Stop are ignored.
 func (m *MetricsManager) Start(ctx context.Context) {
-	go m.runCollectionLoop(ctx)
+	m.startOnce.Do(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.stopped.Load() {
+			return
+		}
+		m.running.Add(1)
+		go func() {
+			defer m.running.Done()
+			m.runCollectionLoop(ctx)
+		}()
+	})
 }
 
-// Stop stops the metrics collection process
+// Stop stops the metrics collection process. It cancels running collections and waits
+// for them to finish before closing the TSDB. It is safe to call more than once.
 func (m *MetricsManager) Stop() {
 	m.stopOnce.Do(func() {
+		m.mu.Lock()
+		m.stopped.Store(true)
 		close(m.done)
+		for _, collector := range m.collectors {
+			collector.cancel()
+		}
+		m.mu.Unlock()
+
+		m.running.Wait()
 		if err := m.storage.Close(); err != nil {
 			m.logger.Error("Error closing TSDB", zap.Error(err))
 		}
 	})
 }
 
+// CheckStorage returns an error if the TSDB can't be queried, e.g. because it was closed
+func (m *MetricsManager) CheckStorage() error {
+	if m.stopped.Load() {
+		return errors.New("TSDB is closed")
+	}
+	now := time.Now().UnixMilli()
+	q, err := m.storage.Querier(now, now)
+	if err != nil {
+		return fmt.Errorf("failed to query TSDB: %w", err)
+	}
+	return q.Close()
+}
+
 // GetStorage returns the underlying TSDB storage
 func (m *MetricsManager) GetStorage() *tsdb.DB {
 	return m.storage
@@ -91,7 +264,7 @@ func (m *MetricsManager) GetStorage() *tsdb.DB {
 
 // runCollectionLoop periodically discovers clusters and collects metrics from them
 func (m *MetricsManager) runCollectionLoop(ctx context.Context) {
-	ticker := time.NewTicker(m.scrapeInterval)
+	ticker := time.NewTicker(m.interval())
 	defer ticker.Stop()
 
 	// Do an initial collection immediately
@@ -101,6 +274,8 @@ func (m *MetricsManager) runCollectionLoop(ctx context.Context) {
 		select {
 		case <-ticker.C:
 			m.collectFromAllClusters(ctx)
+		case <-m.intervalChanged:
+			ticker.Reset(m.interval())
 		case <-m.done:
 			return
 		case <-ctx.Done():
@@ -109,6 +284,25 @@ func (m *MetricsManager) runCollectionLoop(ctx context.Context) {
 	}
 }
 
+// SetScrapeInterval changes how often metrics are collected. The next collection starts
+// one new interval after the change.
+func (m *MetricsManager) SetScrapeInterval(interval time.Duration) {
+	m.mu.Lock()
+	m.scrapeInterval = interval
+	m.mu.Unlock()
+	select {
+	case m.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// interval returns the current scrape interval
+func (m *MetricsManager) interval() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.scrapeInterval
+}
+
 // collectFromAllClusters discovers all clusters and collects metrics from them
 func (m *MetricsManager) collectFromAllClusters(ctx context.Context) {
 	clusters, err := m.discoverClusters(ctx)
@@ -117,6 +311,13 @@ func (m *MetricsManager) collectFromAllClusters(ctx context.Context) {
 		return
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// Collections must not start once Stop waits for the running ones
+	if m.stopped.Load() {
+		return
+	}
+
 	// Add new clusters
 	for _, addr := range clusters {
 		if _, exists := m.collectors[addr]; !exists {
@@ -126,24 +327,32 @@ func (m *MetricsManager) collectFromAllClusters(ctx context.Context) {
 
 	// Remove clusters that no longer exist
 	for addr := range m.collectors {
-		found := false
-		for _, discoveredAddr := range clusters {
-			if addr == discoveredAddr {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if !slices.Contains(clusters, addr) {
 			m.removeCluster(addr)
 		}
 	}
 
-	// Collect metrics from all clusters
+	// Collect metrics from all clusters, except those backing off or still busy with the previous scrape
+	now := time.Now()
 	for _, collector := range m.collectors {
-		go collector.collect(ctx)
+		if !collector.state.begin(now, m.scrapeInterval) {
+			continue
+		}
+		m.running.Add(1)
+		go func() {
+			defer m.running.Done()
+			collector.collect(collector.ctx)
+		}()
 	}
 }
 
+// clusters returns the addresses of the clusters metrics are collected from
+func (m *MetricsManager) clusters() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Sorted(maps.Keys(m.collectors))
+}
+
 // discoverClusters returns a list of all Armada cluster addresses
 func (m *MetricsManager) discoverClusters(ctx context.Context) ([]string, error) {
 	// This needs to be implemented based on how clusters are discovered in the console
@@ -151,45 +360,108 @@ func (m *MetricsManager) discoverClusters(ctx context.Context) ([]string, error)
 	return m.clusterPool.GetKnownAddresses(), nil
 }
 
-// addCluster creates a new metrics collector for a cluster
+// addCluster creates a new metrics collector for a cluster. The caller must hold m.mu.
 func (m *MetricsManager) addCluster(ctx context.Context, addr string) {
 	m.logger.Info("Adding metrics collector for cluster", zap.String("address", addr))
 
+	ctx, cancel := context.WithCancel(ctx)
 	collector := &MetricsCollector{
 		clusterAddr: addr,
 		pool:        m.clusterPool,
 		manager:     m,
 		logger:      m.logger.Named("collector").With(zap.String("cluster", addr)),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	if m.dedupWindow > 0 {
+		collector.dedup = newSampleDeduper(m.dedupWindow.Milliseconds(), m.dedupMaxSeries)
+	}
+	if m.maxSeriesPerMetric > 0 || m.maxLabels > 0 {
+		collector.cardinality = newCardinalityGuard(addr, m.maxSeriesPerMetric, m.maxLabels, m.cardinalityCooldown)
 	}
 
 	m.collectors[addr] = collector
 }
 
-// removeCluster removes a metrics collector for a cluster
+// removeCluster removes a metrics collector for a cluster and aborts its running collection.
+// The caller must hold m.mu.
 func (m *MetricsManager) removeCluster(addr string) {
 	m.logger.Info("Removing metrics collector for cluster", zap.String("address", addr))
-	delete(m.collectors, addr)
+	if collector, ok := m.collectors[addr]; ok {
+		collector.cancel()
+		delete(m.collectors, addr)
+	}
+	m.skews.forget(addr)
+}
+
+// ClockSkews returns the clock skew of every scraped server measured during its last scrape
+func (m *MetricsManager) ClockSkews() []ClockSkew {
+	return m.skews.list()
 }
 
-// collect gathers metrics from a single Armada cluster and stores them in TSDB
+// collect gathers metrics from a single Armada cluster and stores them in TSDB. Failures are
+// logged when the cluster starts failing; while it keeps failing, it is scraped less often and
+// the failures are only logged at debug level.
 func (c *MetricsCollector) collect(ctx context.Context) {
 	c.logger.Debug("Collecting metrics")
 
+	started := time.Now()
+	err := c.scrape(ctx)
+	if err != nil && ctx.Err() != nil {
+		// The cluster was removed or the manager is stopping, which says nothing about the cluster
+		c.state.abort()
+		return
+	}
+	interval, maxBackoff := c.manager.interval(), c.manager.maxBackoff
+	previousFailures := c.state.finish(started, err, interval, maxBackoff)
+	switch {
+	case err != nil && previousFailures == 0:
+		c.logger.Error("Failed to collect metrics, backing off",
+			zap.String("address", c.clusterAddr),
+			zap.Duration("backoff", backoffDelay(interval, maxBackoff, 1)),
+			zap.Error(err))
+	case err != nil:
+		c.logger.Debug("Failed to collect metrics again",
+			zap.String("address", c.clusterAddr),
+			zap.Int("consecutiveFailures", previousFailures+1),
+			zap.Duration("backoff", backoffDelay(interval, maxBackoff, previousFailures+1)),
+			zap.Error(err))
+	case previousFailures > 0:
+		c.logger.Info("Collecting metrics again after failures",
+			zap.String("address", c.clusterAddr),
+			zap.Int("failures", previousFailures))
+	}
+}
+
+// scrape fetches the metrics of the cluster and stores them. Only failures to get the
+// metrics from the cluster are returned, failures to store them are logged.
+func (c *MetricsCollector) scrape(ctx context.Context) error {
 	// Set a timeout for metrics collection
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	conn, err := c.pool.GetConnection(ctx, c.clusterAddr)
 	if err != nil {
-		c.logger.Error("Failed to get connection to cluster", zap.String("address", c.clusterAddr), zap.Error(err))
-		return
+		return fmt.Errorf("failed to get connection to cluster: %w", err)
 	}
 	// Get metrics from the cluster
+	sent := time.Now()
 	resp, err := conn.MetricsClient.GetMetrics(ctx, &regattapb.MetricsRequest{})
 	if err != nil {
-		c.logger.Error("Failed to collect metrics", zap.String("address", c.clusterAddr), zap.Error(err))
-		return
+		return fmt.Errorf("failed to get metrics: %w", err)
 	}
+	received := time.Now()
+
+	// The server timestamp tells how far the server clock is off, which skews TTLs and metric alignment
+	skew, uncertainty := measureSkew(time.Unix(resp.Timestamp, 0), sent, received)
+	c.manager.skews.record(ClockSkew{
+		Cluster:     c.clusterAddr,
+		NodeID:      conn.NodeID,
+		NodeName:    conn.NodeName,
+		Skew:        skew,
+		Uncertainty: uncertainty,
+		MeasuredAt:  received,
+	})
 
 	md := &armada.MetricsData{
 		Source:    c.clusterAddr,
@@ -201,6 +473,7 @@ func (c *MetricsCollector) collect(ctx context.Context) {
 	if err := c.storeMetricsInTSDB(ctx, md); err != nil {
 		c.logger.Error("Failed to store metrics in TSDB", zap.Error(err))
 	}
+	return nil
 }
 
 // storeMetricsInTSDB parses the Prometheus text format metrics and stores them in TSDB
@@ -236,9 +509,18 @@ func (c *MetricsCollector) storeMetricsInTSDB(ctx context.Context, metrics *arma
 	if conn != nil && conn.NodeName != "" {
 		extraLabels = append(extraLabels, labels.Label{Name: "node_name", Value: conn.NodeName})
 	}
+	if c.manager.targetLabels != nil {
+		for name, value := range c.manager.targetLabels(c.clusterAddr) {
+			extraLabels = append(extraLabels, labels.Label{Name: name, Value: value})
+		}
+	}
 
-	// Track metrics parsed
+	// Track metrics parsed, dropped as repeated values and blocked for exceeding the cardinality limits
 	metricCount := 0
+	skipped := 0
+	blocked := 0
+	cardinality := c.cardinality.scrape(time.Now())
+	families := make(map[string]MetricMetadata)
 	timestamp := metrics.Timestamp.UnixMilli()
 
 	// Process all metrics
@@ -256,6 +538,13 @@ func (c *MetricsCollector) storeMetricsInTSDB(ctx context.Context, metrics *arma
 			// Get series information
 			_, _, val := parser.Series()
 			parser.Labels(&lbls)
+			// The type of a family is announced before its series, gauges are named like their family
+			name := lbls.Get(labels.MetricName)
+			typ := families[name].Type
+			if !cardinality.allow(name, lbls) {
+				blocked++
+				continue
+			}
 
 			// Add our extra labels
 			lblsBuilder := labels.NewBuilder(lbls)
@@ -264,9 +553,25 @@ func (c *MetricsCollector) storeMetricsInTSDB(ctx context.Context, metrics *arma
 			}
 			lbls = lblsBuilder.Labels()
 
+			store, repeat := c.dedup.keep(typ, lbls, timestamp, val)
+			if !store {
+				metricCount++
+				skipped++
+				continue
+			}
+			if repeat != nil {
+				// Keep the change of a gauge sharp by storing the last dropped value first
+				if _, err = appender.Append(0, lbls, repeat.T, repeat.F); err != nil {
+					c.logger.Warn("Failed to append repeated metric value",
+						zap.String("metric", lbls.Get("__name__")),
+						zap.Error(err))
+				}
+			}
+
 			// Add sample to TSDB
 			_, err = appender.Append(0, lbls, timestamp, val)
 			if err != nil {
+				c.dedup.forget(lbls)
 				c.logger.Warn("Failed to append metric",
 					zap.String("metric", lbls.Get("__name__")),
 					zap.Error(err))
@@ -275,11 +580,32 @@ func (c *MetricsCollector) storeMetricsInTSDB(ctx context.Context, metrics *arma
 
 			metricCount++
 
-		case textparse.EntryHelp, textparse.EntryType, textparse.EntryComment, textparse.EntryUnit:
-			// Skip metadata entries
+		case textparse.EntryType:
+			name, typ := parser.Type()
+			md := families[string(name)]
+			md.Type = typ
+			families[string(name)] = md
+
+		case textparse.EntryHelp:
+			name, help := parser.Help()
+			md := families[string(name)]
+			md.Help = string(help)
+			families[string(name)] = md
+
+		case textparse.EntryUnit:
+			name, unit := parser.Unit()
+			md := families[string(name)]
+			md.Unit = string(unit)
+			families[string(name)] = md
+
+		case textparse.EntryComment:
 			continue
 		}
 	}
+	c.manager.metadata.update(families)
+	if cardinality != nil {
+		c.reportBlocked(cardinality.tripped)
+	}
 
 	// Add a metric counting how many metrics we processed
 	countLblsBuilder := labels.NewBuilder(labels.FromStrings(
@@ -298,16 +624,48 @@ func (c *MetricsCollector) storeMetricsInTSDB(ctx context.Context, metrics *arma
 		c.logger.Warn("Failed to append sample count metric", zap.Error(err))
 	}
 
+	// Record the clock skew measured during this scrape. It is timestamped with the console
+	// clock, which is the reference the skew is measured against.
+	if skew, ok := c.manager.skews.get(c.clusterAddr); ok {
+		skewLblsBuilder := labels.NewBuilder(labels.FromStrings("__name__", ClockSkewMetric))
+		for _, lbl := range extraLabels {
+			skewLblsBuilder.Set(lbl.Name, lbl.Value)
+		}
+		_, err = appender.Append(0, skewLblsBuilder.Labels(), skew.MeasuredAt.UnixMilli(), skew.Skew.Seconds())
+		if err != nil {
+			c.logger.Warn("Failed to append clock skew metric", zap.Error(err))
+		}
+	}
+
 	// Commit samples to TSDB
 	if err := appender.Commit(); err != nil {
+		// The dropped samples may have been the only ones within the window
+		c.dedup.reset()
 		return fmt.Errorf("failed to commit metrics: %w", err)
 	}
+	c.dedup.prune(timestamp)
 
 	c.logger.Debug("Successfully stored metrics in TSDB",
 		zap.Int("samples", metricCount),
+		zap.Int("deduplicated", skipped),
+		zap.Int("blocked", blocked),
 		zap.String("cluster", c.clusterAddr),
 		zap.String("nodeID", conn.NodeID),
 		zap.String("nodeName", conn.NodeName))
 
 	return nil
 }
+
+// reportBlocked logs the metrics blocked during a scrape and publishes an event for each
+func (c *MetricsCollector) reportBlocked(blocked []BlockedMetric) {
+	for _, b := range blocked {
+		c.logger.Warn("Metric exceeds the cardinality limits, not storing it",
+			zap.String("metric", b.Metric),
+			zap.String("reason", b.Reason),
+			zap.Strings("labels", b.Labels),
+			zap.Time("until", b.Until))
+		if c.manager.publisher != nil {
+			c.manager.publisher.Publish(events.Event{Type: events.TypeCardinality, Time: b.Since, Data: b})
+		}
+	}
+}
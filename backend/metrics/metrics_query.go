@@ -21,28 +21,49 @@ type QueryEngine struct {
 	queryable storage.Queryable
 }
 
+// defaultQueryTimeout bounds queries unless another timeout is configured
+const defaultQueryTimeout = 2 * time.Minute
+
+// maxQueryRange limits the time range of range queries, longer ranges are cut at the end
+const maxQueryRange = 7 * 24 * time.Hour
+
+// QueryEngineOption configures optional behaviour of the QueryEngine
+type QueryEngineOption func(*QueryEngine)
+
+// WithEvaluationTimeout bounds the evaluation of every query by the timeout instead of
+// defaultQueryTimeout
+func WithEvaluationTimeout(timeout time.Duration) QueryEngineOption {
+	return func(q *QueryEngine) {
+		if timeout > 0 {
+			q.timeout = timeout
+		}
+	}
+}
+
 // NewQueryEngine creates a new query engine for metrics TSDB
-func NewQueryEngine(db *tsdb.DB, logger *zap.Logger) *QueryEngine {
+func NewQueryEngine(db *tsdb.DB, logger *zap.Logger, opts ...QueryEngineOption) *QueryEngine {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
+	q := &QueryEngine{
+		logger:    logger.Named("query-engine"),
+		timeout:   defaultQueryTimeout,
+		queryable: db,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
 
 	// Create a Prometheus query engine with settings calibrated for our use case
 	engineOpts := promql.EngineOpts{
 		Logger:        nil,
 		Reg:           nil,
 		MaxSamples:    50000000,
-		Timeout:       2 * time.Minute,
+		Timeout:       q.timeout,
 		LookbackDelta: 5 * time.Minute,
 	}
-	engine := promql.NewEngine(engineOpts)
-
-	return &QueryEngine{
-		engine:    engine,
-		logger:    logger.Named("query-engine"),
-		timeout:   2 * time.Minute,
-		queryable: db,
-	}
+	q.engine = promql.NewEngine(engineOpts)
+	return q
 }
 
 // QueryResult contains the result of a metrics query
@@ -130,12 +151,11 @@ func (q *QueryEngine) QueryRange(ctx context.Context, queryStr string, start, en
 	}
 
 	// Limit time range to prevent excessive queries
-	maxDuration := 7 * 24 * time.Hour // 7 days
-	if end.Sub(start) > maxDuration {
+	if end.Sub(start) > maxQueryRange {
 		q.logger.Warn("Time range too large, limiting to maximum duration",
 			zap.Duration("requested_duration", end.Sub(start)),
-			zap.Duration("maximum_duration", maxDuration))
-		end = start.Add(maxDuration)
+			zap.Duration("maximum_duration", maxQueryRange))
+		end = start.Add(maxQueryRange)
 	}
 
 	q.logger.Debug("Executing range query",
@@ -184,6 +204,28 @@ func (q *QueryEngine) QueryRange(ctx context.Context, queryStr string, start, en
 	return result, nil
 }
 
+// QueryRangeShifted executes a range query over the time range moved back by offset, e.g. the
+// same hours of the previous day. The timestamps of the result are moved forward by offset, so
+// its series align with those of the range itself.
+func (q *QueryEngine) QueryRangeShifted(ctx context.Context, queryStr string, start, end time.Time, step, offset time.Duration) (QueryResult, error) {
+	result, err := q.QueryRange(ctx, queryStr, start.Add(-offset), end.Add(-offset), step)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	if matrix, ok := result.Value.(promql.Matrix); ok {
+		shift := offset.Milliseconds()
+		for i := range matrix {
+			for j := range matrix[i].Floats {
+				matrix[i].Floats[j].T += shift
+			}
+			for j := range matrix[i].Histograms {
+				matrix[i].Histograms[j].T += shift
+			}
+		}
+	}
+	return result, nil
+}
+
 // approximateSamplesFromResult estimates the number of samples based on the result type
 func approximateSamplesFromResult(value parser.Value) int {
 	if value == nil {
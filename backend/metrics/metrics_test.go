@@ -9,8 +9,10 @@ import (
 
 	"github.com/armadakv/console/backend/armada"
 	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -71,6 +73,15 @@ func TestNewMetricsManager(t *testing.T) {
 	manager.Stop()
 }
 
+func TestMetricsManagerCheckStorage(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	assert.NoError(t, err)
+
+	assert.NoError(t, manager.CheckStorage())
+	manager.Stop()
+	assert.Error(t, manager.CheckStorage())
+}
+
 func TestNewMetricsManagerWithNilLogger(t *testing.T) {
 	mockPool := &mockClusterPool{}
 	tempDir := createTempDir(t)
@@ -301,7 +312,7 @@ func TestMetricsManagerWithMultipleClusters(t *testing.T) {
 	time.Sleep(200 * time.Millisecond)
 
 	// Verify that collectors were created for each cluster
-	assert.Len(t, manager.collectors, len(addresses))
+	assert.Equal(t, addresses, manager.clusters())
 
 	mockPool.AssertExpectations(t)
 }
@@ -363,3 +374,89 @@ func TestMetricsManagerConcurrency(t *testing.T) {
 
 	mockPool.AssertExpectations(t)
 }
+
+func TestMetricsManagerRemoveClusterCancelsCollector(t *testing.T) {
+	mockPool := &mockClusterPool{}
+	manager, err := NewMetricsManager(mockPool, time.Minute, createTempDir(t), zap.NewNop())
+	assert.NoError(t, err)
+	defer manager.Stop()
+
+	manager.mu.Lock()
+	manager.addCluster(context.Background(), "cluster1:8080")
+	collector := manager.collectors["cluster1:8080"]
+	assert.NoError(t, collector.ctx.Err())
+	manager.removeCluster("cluster1:8080")
+	manager.mu.Unlock()
+
+	assert.ErrorIs(t, collector.ctx.Err(), context.Canceled)
+	assert.Empty(t, manager.clusters())
+}
+
+func TestMetricsManagerStopCancelsCollectors(t *testing.T) {
+	mockPool := &mockClusterPool{}
+	mockPool.On("GetKnownAddresses").Return([]string{"cluster1:8080"})
+	// The connection blocks until the collection is cancelled
+	collecting := make(chan struct{})
+	mockPool.On("GetConnection", mock.Anything, "cluster1:8080").
+		Run(func(args mock.Arguments) {
+			close(collecting)
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return((*armada.ServerConnection)(nil), context.Canceled)
+
+	manager, err := NewMetricsManager(mockPool, time.Minute, createTempDir(t), zap.NewNop())
+	assert.NoError(t, err)
+
+	manager.Start(context.Background())
+	<-collecting
+
+	stopped := make(chan struct{})
+	go func() {
+		manager.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not cancel the running collection")
+	}
+
+	// Starting a stopped manager is a no-op
+	manager.Start(context.Background())
+	assert.Error(t, manager.CheckStorage())
+}
+
+func TestCollectAddsTargetLabels(t *testing.T) {
+	mockMetricsClient := &mockMetricsClient{}
+	mockPool := &mockClusterPool{}
+	mockPool.On("GetConnection", mock.Anything, "test-addr").Return(&armada.ServerConnection{
+		MetricsClient: mockMetricsClient,
+		NodeID:        "node1",
+	}, nil)
+	mockMetricsClient.On("GetMetrics", mock.Anything, mock.AnythingOfType("*regattapb.MetricsRequest")).Return(&regattapb.MetricsResponse{
+		MetricsData: "test_metric{env=\"scraped\"} 1.0\n",
+		Timestamp:   time.Now().Unix(),
+	}, nil)
+
+	manager, err := NewMetricsManager(mockPool, time.Minute, createTempDir(t), zap.NewNop(),
+		WithTargetLabels(func(addr string) map[string]string {
+			if addr != "test-addr" {
+				return nil
+			}
+			return map[string]string{"env": "prod", "region": "eu"}
+		}))
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	collector := &MetricsCollector{clusterAddr: "test-addr", manager: manager, logger: zap.NewNop(), pool: mockPool}
+	collector.collect(context.Background())
+
+	// The labels of the target replace scraped labels of the same name
+	result, err := NewQueryEngine(manager.GetStorage(), zap.NewNop()).Query(context.Background(), `test_metric{env="prod",region="eu"}`, time.Now())
+	require.NoError(t, err)
+	vector, ok := result.Value.(promql.Vector)
+	require.True(t, ok)
+	require.Len(t, vector, 1)
+	assert.Equal(t, "node1", vector[0].Metric.Get("node_id"))
+	assert.Equal(t, "test-addr", vector[0].Metric.Get("cluster"))
+}
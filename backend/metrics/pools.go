@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/armadakv/console/backend/armada"
+)
+
+// Pools scrapes the servers of several independent clusters into one storage. Every server is
+// connected through the pool of its cluster, the series are told apart by the cluster label
+// holding the server address.
+type Pools []ClusterPool
+
+// GetKnownAddresses returns the addresses known to any of the pools
+func (p Pools) GetKnownAddresses() []string {
+	var addrs []string
+	for _, pool := range p {
+		addrs = append(addrs, pool.GetKnownAddresses()...)
+	}
+	slices.Sort(addrs)
+	return slices.Compact(addrs)
+}
+
+// GetConnection connects to the server through the first pool knowing its address
+func (p Pools) GetConnection(ctx context.Context, addr string) (*armada.ServerConnection, error) {
+	for _, pool := range p {
+		if slices.Contains(pool.GetKnownAddresses(), addr) {
+			return pool.GetConnection(ctx, addr)
+		}
+	}
+	return nil, fmt.Errorf("no cluster knows the server %s", addr)
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPools(t *testing.T) {
+	staging := &mockClusterPool{}
+	staging.On("GetKnownAddresses").Return([]string{"staging-1:5001", "shared:5001"})
+	staging.On("GetConnection", context.Background(), "shared:5001").Return(&armada.ServerConnection{NodeID: "s"}, nil)
+	prod := &mockClusterPool{}
+	prod.On("GetKnownAddresses").Return([]string{"prod-1:5001", "shared:5001"})
+	prod.On("GetConnection", context.Background(), "prod-1:5001").Return(&armada.ServerConnection{NodeID: "p"}, nil)
+	pools := Pools{staging, prod}
+
+	assert.Equal(t, []string{"prod-1:5001", "shared:5001", "staging-1:5001"}, pools.GetKnownAddresses())
+
+	conn, err := pools.GetConnection(context.Background(), "prod-1:5001")
+	require.NoError(t, err)
+	assert.Equal(t, "p", conn.NodeID)
+
+	// An address known to several pools is connected through the first one
+	conn, err = pools.GetConnection(context.Background(), "shared:5001")
+	require.NoError(t, err)
+	assert.Equal(t, "s", conn.NodeID)
+
+	_, err = pools.GetConnection(context.Background(), "gone:5001")
+	assert.Error(t, err)
+}
@@ -32,6 +32,9 @@ func TestNewQueryEngine(t *testing.T) {
 	assert.NotNil(t, queryEngine.logger)
 	assert.Equal(t, 2*time.Minute, queryEngine.timeout)
 	assert.NotNil(t, queryEngine.queryable)
+
+	assert.Equal(t, 30*time.Second, NewQueryEngine(manager.GetStorage(), logger, WithEvaluationTimeout(30*time.Second)).timeout)
+	assert.Equal(t, 2*time.Minute, NewQueryEngine(manager.GetStorage(), logger, WithEvaluationTimeout(0)).timeout)
 }
 
 func TestNewQueryEngineWithNilLogger(t *testing.T) {
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/prometheus/promql"
+	"go.uber.org/zap"
+)
+
+// resourceQuery is a curated query summarizing one resource of a node.
+// The node ID placeholder %s is substituted with the quoted node ID.
+type resourceQuery struct {
+	query string
+	set   func(*ServerResources, float64)
+}
+
+// resourceQueries are the queries backing the node resources summary
+var resourceQueries = []resourceQuery{
+	{
+		query: `sum(rate(process_cpu_seconds_total{node_id=%s}[1m])) * 100`,
+		set:   func(r *ServerResources, v float64) { r.CPUPercent = &v },
+	},
+	{
+		query: `sum(process_resident_memory_bytes{node_id=%s})`,
+		set:   func(r *ServerResources, v float64) { r.MemoryBytes = &v },
+	},
+	{
+		query: `sum(regatta_table_storage_disk_usage_bytes{node_id=%s})`,
+		set:   func(r *ServerResources, v float64) { r.DiskBytes = &v },
+	},
+	{
+		query: `sum(process_open_fds{node_id=%s})`,
+		set:   func(r *ServerResources, v float64) { r.OpenFDs = &v },
+	},
+	{
+		query: `sum(process_max_fds{node_id=%s})`,
+		set:   func(r *ServerResources, v float64) { r.MaxFDs = &v },
+	},
+	{
+		query: `sum(go_goroutines{node_id=%s})`,
+		set:   func(r *ServerResources, v float64) { r.Goroutines = &v },
+	},
+}
+
+// ServerResources summarizes the resource utilization of a node.
+// Values are nil when the node doesn't report the underlying metric.
+type ServerResources struct {
+	NodeID      string    `json:"nodeId"`
+	Time        time.Time `json:"time"`
+	CPUPercent  *float64  `json:"cpuPercent"`
+	MemoryBytes *float64  `json:"memoryBytes"`
+	DiskBytes   *float64  `json:"diskBytes"`
+	OpenFDs     *float64  `json:"openFds"`
+	MaxFDs      *float64  `json:"maxFds"`
+	Goroutines  *float64  `json:"goroutines"`
+}
+
+// ServerResources runs the curated resource queries for a node at the given time
+func (e *QueryEngine) ServerResources(ctx context.Context, nodeID string, ts time.Time) (ServerResources, error) {
+	resources := ServerResources{NodeID: nodeID, Time: ts}
+	for _, rq := range resourceQueries {
+		query := fmt.Sprintf(rq.query, strconv.Quote(nodeID))
+		result, err := e.Query(ctx, query, ts)
+		if err != nil {
+			return ServerResources{}, fmt.Errorf("query %q: %w", query, err)
+		}
+		// Aggregations over no series yield an empty vector, which leaves the value unset
+		if vector, ok := result.Value.(promql.Vector); ok && len(vector) > 0 {
+			rq.set(&resources, vector[0].F)
+		}
+	}
+	return resources, nil
+}
+
+// handleServerResources returns the resource utilization summary of a node
+// @Summary Node resource utilization
+// @Description Summarize CPU, memory, disk, file descriptor and goroutine usage of a node from stored metrics
+// @Tags metrics
+// @Produce json
+// @Param id path string true "Node ID"
+// @Success 200 {object} ServerResources
+// @Failure 500 {object} ErrorResponse
+// @Router /api/servers/{id}/resources [get]
+func (h *MetricsHandler) handleServerResources(w http.ResponseWriter, r *http.Request) {
+	nodeID := chi.URLParam(r, "id")
+	if nodeID == "" {
+		renderError(w, http.StatusBadRequest, "Missing node ID")
+		return
+	}
+
+	resources, err := h.queryEngine.ServerResources(r.Context(), nodeID, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to query node resources", zap.String("node", nodeID), zap.Error(err))
+		renderError(w, http.StatusInternalServerError, "Failed to query node resources")
+		return
+	}
+
+	renderJSON(w, resources)
+}
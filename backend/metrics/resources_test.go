@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleServerResources(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	// Store samples for two nodes
+	now := time.Now()
+	appender := manager.GetStorage().Appender(t.Context())
+	for _, s := range []struct {
+		name, node string
+		value      float64
+	}{
+		{"process_resident_memory_bytes", "node1", 512 << 20},
+		{"go_goroutines", "node1", 42},
+		{"process_open_fds", "node1", 100},
+		{"regatta_table_storage_disk_usage_bytes", "node1", 1000},
+		{"go_goroutines", "node2", 7},
+	} {
+		lbls := labels.FromStrings("__name__", s.name, "node_id", s.node, "table", "t1")
+		_, err := appender.Append(0, lbls, now.Add(-10*time.Second).UnixMilli(), s.value)
+		require.NoError(t, err)
+	}
+	lbls := labels.FromStrings("__name__", "regatta_table_storage_disk_usage_bytes", "node_id", "node1", "table", "t2")
+	_, err = appender.Append(0, lbls, now.Add(-10*time.Second).UnixMilli(), 500)
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	r := chi.NewRouter()
+	NewMetricsHandler(manager, zap.NewNop()).RegisterRoutes(r)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/servers/node1/resources", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resources ServerResources
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resources))
+	assert.Equal(t, "node1", resources.NodeID)
+	require.NotNil(t, resources.MemoryBytes)
+	assert.Equal(t, float64(512<<20), *resources.MemoryBytes)
+	require.NotNil(t, resources.Goroutines)
+	assert.Equal(t, float64(42), *resources.Goroutines)
+	require.NotNil(t, resources.DiskBytes)
+	assert.Equal(t, float64(1500), *resources.DiskBytes, "disk usage is summed over tables")
+	require.NotNil(t, resources.OpenFDs)
+	assert.Equal(t, float64(100), *resources.OpenFDs)
+	// Metrics that aren't reported are left unset
+	assert.Nil(t, resources.MaxFDs)
+	assert.Nil(t, resources.CPUPercent)
+
+	// Node IDs are quoted, so they can't alter the queries
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", `/api/servers/x%22%7D%20or%20up%7B/resources`, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resources))
+	assert.Nil(t, resources.Goroutines)
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// clusterLabel is the label identifying the cluster of every stored series
+const clusterLabel = "cluster"
+
+// labelName matches the names of labels grouped by
+var labelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// aggregations are the operators grouping a scoped query may use, sum is the default
+var aggregations = map[string]parser.ItemType{
+	"sum":   parser.SUM,
+	"avg":   parser.AVG,
+	"min":   parser.MIN,
+	"max":   parser.MAX,
+	"count": parser.COUNT,
+}
+
+// queryScope narrows a query to clusters and aggregates it by labels, so fleet-wide panels don't
+// need hand-written regex matchers
+type queryScope struct {
+	// clusters restricts every selector of the query to these clusters, all if empty
+	clusters []string
+	// tags restricts every selector of the query to the series of clusters with these tags
+	tags []*labels.Matcher
+	// groupBy aggregates the result by these labels, e.g. cluster, unless empty
+	groupBy   []string
+	aggregate parser.ItemType
+}
+
+// scopeQuery applies the scope given by the parameters of a request to the query
+func scopeQuery(params url.Values, query string) (string, error) {
+	scope, err := scopeFromParams(params)
+	if err != nil {
+		return "", err
+	}
+	return scope.apply(query)
+}
+
+// scopeFromParams reads the scope of a query from the clusters, tags, groupBy and aggregate
+// parameters. Lists are given as repeated or comma separated values, e.g. clusters=a,b.
+func scopeFromParams(params url.Values) (queryScope, error) {
+	tags, err := tagMatchers(params)
+	if err != nil {
+		return queryScope{}, err
+	}
+	scope := queryScope{
+		clusters:  listParam(params, "clusters"),
+		tags:      tags,
+		groupBy:   listParam(params, "groupBy"),
+		aggregate: parser.SUM,
+	}
+	for _, name := range scope.groupBy {
+		if !labelName.MatchString(name) {
+			return queryScope{}, fmt.Errorf("invalid groupBy label %q", name)
+		}
+	}
+	if name := params.Get("aggregate"); name != "" {
+		op, ok := aggregations[name]
+		if !ok {
+			return queryScope{}, fmt.Errorf("invalid aggregate %q, expected one of sum, avg, min, max or count", name)
+		}
+		if len(scope.groupBy) == 0 {
+			return queryScope{}, errors.New("aggregate requires groupBy")
+		}
+		scope.aggregate = op
+	}
+	return scope, nil
+}
+
+// tagMatchers returns matchers of the cluster tags given as key=value pairs in the tags parameter,
+// e.g. tags=env=prod,region=eu. Tags are stored as labels of the scraped series.
+func tagMatchers(params url.Values) ([]*labels.Matcher, error) {
+	var matchers []*labels.Matcher
+	for _, tag := range listParam(params, "tags") {
+		key, value, ok := strings.Cut(tag, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || value == "" || !labelName.MatchString(key) {
+			return nil, fmt.Errorf("invalid tag %q, expected key=value", tag)
+		}
+		matcher, err := labels.NewMatcher(labels.MatchEqual, key, value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// listParam returns the values of a list parameter given as repeated or comma separated values
+func listParam(params url.Values, name string) []string {
+	var values []string
+	for _, value := range params[name] {
+		for v := range strings.SplitSeq(value, ",") {
+			if v = strings.TrimSpace(v); v != "" && !slices.Contains(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// apply returns the query restricted to the clusters and tags of the scope and aggregated by its
+// labels. The query is returned unchanged if the scope is empty.
+func (s queryScope) apply(query string) (string, error) {
+	if len(s.clusters) == 0 && len(s.tags) == 0 && len(s.groupBy) == 0 {
+		return query, nil
+	}
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query: %w", err)
+	}
+
+	matchers := s.tags
+	if len(s.clusters) > 0 {
+		matcher, err := clusterMatcher(s.clusters)
+		if err != nil {
+			return "", err
+		}
+		matchers = append([]*labels.Matcher{matcher}, matchers...)
+	}
+	if len(matchers) > 0 {
+		parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+			// Selectors of range vectors are visited as well
+			if vs, ok := node.(*parser.VectorSelector); ok {
+				vs.LabelMatchers = append(vs.LabelMatchers, matchers...)
+			}
+			return nil
+		})
+	}
+
+	if len(s.groupBy) > 0 {
+		if expr.Type() != parser.ValueTypeVector {
+			return "", fmt.Errorf("groupBy requires a query returning series, got a %s", expr.Type())
+		}
+		expr = &parser.AggregateExpr{Op: s.aggregate, Expr: expr, Grouping: s.groupBy}
+	}
+	return expr.String(), nil
+}
+
+// clusterMatcher matches the series of the clusters
+func clusterMatcher(clusters []string) (*labels.Matcher, error) {
+	if len(clusters) == 1 {
+		return labels.NewMatcher(labels.MatchEqual, clusterLabel, clusters[0])
+	}
+	quoted := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		quoted[i] = regexp.QuoteMeta(cluster)
+	}
+	return labels.NewMatcher(labels.MatchRegexp, clusterLabel, strings.Join(quoted, "|"))
+}
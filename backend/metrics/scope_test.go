@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestScopeQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		params url.Values
+		want   string
+		err    bool
+	}{
+		{
+			name:  "Unscoped",
+			query: "rate(armada_requests_total[5m])",
+			want:  "rate(armada_requests_total[5m])",
+		},
+		{
+			name:   "SingleCluster",
+			query:  "armada_tables",
+			params: url.Values{"clusters": {"a:5001"}},
+			want:   `armada_tables{cluster="a:5001"}`,
+		},
+		{
+			name:   "ClustersAreEscaped",
+			query:  "rate(armada_requests_total[5m]) / armada_tables",
+			params: url.Values{"clusters": {"a.example.com:5001,b:5001"}},
+			want:   `rate(armada_requests_total{cluster=~"a\\.example\\.com:5001|b:5001"}[5m]) / armada_tables{cluster=~"a\\.example\\.com:5001|b:5001"}`,
+		},
+		{
+			name:   "RepeatedClusters",
+			query:  "armada_tables",
+			params: url.Values{"clusters": {"a:5001", "b:5001"}},
+			want:   `armada_tables{cluster=~"a:5001|b:5001"}`,
+		},
+		{
+			name:   "GroupBy",
+			query:  "rate(armada_requests_total[5m])",
+			params: url.Values{"clusters": {"a:5001,b:5001"}, "groupBy": {"cluster"}},
+			want:   `sum by (cluster) (rate(armada_requests_total{cluster=~"a:5001|b:5001"}[5m]))`,
+		},
+		{
+			name:   "Aggregate",
+			query:  "armada_tables",
+			params: url.Values{"groupBy": {"cluster,node_name"}, "aggregate": {"max"}},
+			want:   `max by (cluster, node_name) (armada_tables)`,
+		},
+		{
+			name:   "Tags",
+			query:  "rate(armada_requests_total[5m]) / armada_tables",
+			params: url.Values{"tags": {"env=prod,region=eu"}},
+			want:   `rate(armada_requests_total{env="prod",region="eu"}[5m]) / armada_tables{env="prod",region="eu"}`,
+		},
+		{
+			name:   "TagsAndClusters",
+			query:  "armada_tables",
+			params: url.Values{"clusters": {"a:5001"}, "tags": {"env=prod"}, "groupBy": {"region"}},
+			want:   `sum by (region) (armada_tables{cluster="a:5001",env="prod"})`,
+		},
+		{name: "TagWithoutValue", query: "up", params: url.Values{"tags": {"env"}}, err: true},
+		{name: "InvalidTagKey", query: "up", params: url.Values{"tags": {"env-name=prod"}}, err: true},
+		{name: "InvalidQuery", query: "sum(", params: url.Values{"clusters": {"a"}}, err: true},
+		{name: "InvalidLabel", query: "up", params: url.Values{"groupBy": {"cluster-name"}}, err: true},
+		{name: "InvalidAggregate", query: "up", params: url.Values{"groupBy": {"cluster"}, "aggregate": {"median"}}, err: true},
+		{name: "AggregateWithoutGroupBy", query: "up", params: url.Values{"aggregate": {"max"}}, err: true},
+		{name: "GroupByScalar", query: "1 + 1", params: url.Values{"groupBy": {"cluster"}}, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scopeQuery(tt.params, tt.query)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHandleQueryClusters(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	now := time.Now()
+	appender := manager.GetStorage().Appender(t.Context())
+	for cluster, value := range map[string]float64{"a:5001": 1, "b:5001": 2, "c:5001": 4} {
+		for _, node := range []string{"1", "2"} {
+			lbls := labels.FromStrings("__name__", "armada_tables", "cluster", cluster, "node_id", node)
+			_, err := appender.Append(0, lbls, now.Add(-10*time.Second).UnixMilli(), value)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, appender.Commit())
+
+	handler := NewMetricsHandler(manager, zap.NewNop())
+	rr := httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", "/api/metrics/query?query=armada_tables&clusters=a:5001,b:5001&groupBy=cluster", nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var response struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  [2]any            `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	sums := map[string]any{}
+	for _, sample := range response.Data.Result {
+		sums[sample.Metric["cluster"]] = sample.Value[1]
+	}
+	assert.Equal(t, map[string]any{"a:5001": "2", "b:5001": "4"}, sums)
+
+	rr = httptest.NewRecorder()
+	handler.handleQuery(rr, httptest.NewRequest("GET", "/api/metrics/query?query=armada_tables&groupBy=cluster&aggregate=median", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
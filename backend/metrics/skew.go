@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClockSkewMetric is the name of the metric recording the measured clock skew of each node
+const ClockSkewMetric = "armada_console_clock_skew_seconds"
+
+// serverTimestampResolution is the resolution of the timestamps reported by the servers
+const serverTimestampResolution = time.Second
+
+// ClockSkew is the clock offset of a server relative to the console
+type ClockSkew struct {
+	Cluster  string `json:"cluster"`
+	NodeID   string `json:"nodeId,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+	// Skew is how far the server clock is ahead of the console clock, negative if it is behind
+	Skew time.Duration `json:"skew"`
+	// Uncertainty bounds the measurement error caused by the request round trip
+	// and the resolution of the server timestamp
+	Uncertainty time.Duration `json:"uncertainty"`
+	MeasuredAt  time.Time     `json:"measuredAt"`
+}
+
+// Exceeds reports whether the skew is larger than max even when accounting for the measurement uncertainty
+func (s ClockSkew) Exceeds(max time.Duration) bool {
+	return s.Skew.Abs()-s.Uncertainty > max
+}
+
+// measureSkew estimates the clock skew from a server timestamp taken while a request
+// sent at sent was in flight and answered at received. The server timestamp is truncated
+// to whole seconds, so its middle is compared with the middle of the round trip.
+func measureSkew(serverTime, sent, received time.Time) (skew, uncertainty time.Duration) {
+	rtt := received.Sub(sent)
+	midpoint := sent.Add(rtt / 2)
+	skew = serverTime.Add(serverTimestampResolution / 2).Sub(midpoint)
+	uncertainty = rtt/2 + serverTimestampResolution/2
+	return skew, uncertainty
+}
+
+// skewTracker keeps the latest clock skew measured for each cluster address
+type skewTracker struct {
+	mu    sync.RWMutex
+	skews map[string]ClockSkew
+}
+
+// newSkewTracker creates an empty tracker
+func newSkewTracker() *skewTracker {
+	return &skewTracker{skews: make(map[string]ClockSkew)}
+}
+
+// record stores the latest measurement of a cluster address
+func (t *skewTracker) record(skew ClockSkew) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.skews[skew.Cluster] = skew
+}
+
+// get returns the latest measurement of a cluster address
+func (t *skewTracker) get(cluster string) (ClockSkew, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	skew, ok := t.skews[cluster]
+	return skew, ok
+}
+
+// forget removes the measurement of a cluster address that is no longer scraped
+func (t *skewTracker) forget(cluster string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.skews, cluster)
+}
+
+// list returns all measurements sorted by cluster address
+func (t *skewTracker) list() []ClockSkew {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	skews := make([]ClockSkew, 0, len(t.skews))
+	for _, skew := range t.skews {
+		skews = append(skews, skew)
+	}
+	slices.SortFunc(skews, func(a, b ClockSkew) int {
+		return strings.Compare(a.Cluster, b.Cluster)
+	})
+	return skews
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMeasureSkew(t *testing.T) {
+	sent := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	received := sent.Add(200 * time.Millisecond)
+
+	// A server 5 seconds ahead reports the truncated second of its clock
+	skew, uncertainty := measureSkew(sent.Add(5*time.Second), sent, received)
+	assert.Equal(t, 5*time.Second+400*time.Millisecond, skew)
+	assert.Equal(t, 600*time.Millisecond, uncertainty)
+
+	// A server 3 seconds behind
+	skew, _ = measureSkew(sent.Add(-3*time.Second), sent, received)
+	assert.Equal(t, -3*time.Second+400*time.Millisecond, skew)
+}
+
+func TestClockSkewExceeds(t *testing.T) {
+	s := ClockSkew{Skew: -3 * time.Second, Uncertainty: 600 * time.Millisecond}
+	assert.True(t, s.Exceeds(2*time.Second))
+	assert.False(t, s.Exceeds(2500*time.Millisecond))
+}
+
+func TestCollectMeasuresClockSkew(t *testing.T) {
+	mockMetricsClient := &mockMetricsClient{}
+	mockPool := &mockClusterPool{}
+	mockPool.On("GetConnection", mock.Anything, "test-addr").Return(&armada.ServerConnection{
+		MetricsClient: mockMetricsClient,
+		NodeID:        "node1",
+	}, nil)
+	mockMetricsClient.On("GetMetrics", mock.Anything, mock.AnythingOfType("*regattapb.MetricsRequest")).Return(&regattapb.MetricsResponse{
+		MetricsData: "test_metric 1.0\n",
+		Timestamp:   time.Now().Add(time.Minute).Unix(),
+	}, nil)
+
+	manager, err := NewMetricsManager(mockPool, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	collector := &MetricsCollector{clusterAddr: "test-addr", manager: manager, logger: zap.NewNop(), pool: mockPool}
+	collector.collect(context.Background())
+
+	skews := manager.ClockSkews()
+	require.Len(t, skews, 1)
+	assert.Equal(t, "node1", skews[0].NodeID)
+	assert.InDelta(t, time.Minute.Seconds(), skews[0].Skew.Seconds(), 1.5)
+	assert.True(t, skews[0].Exceeds(time.Second))
+
+	// The skew is stored as a metric as well
+	result, err := NewQueryEngine(manager.GetStorage(), zap.NewNop()).Query(context.Background(), ClockSkewMetric, time.Now())
+	require.NoError(t, err)
+	vector, ok := result.Value.(promql.Vector)
+	require.True(t, ok)
+	require.Len(t, vector, 1)
+	assert.Equal(t, "node1", vector[0].Metric.Get("node_id"))
+	assert.InDelta(t, time.Minute.Seconds(), vector[0].F, 1.5)
+}
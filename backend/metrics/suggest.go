@@ -0,0 +1,280 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"go.uber.org/zap"
+)
+
+// histogramSuffixes are appended to the family name of histogram and summary series
+var histogramSuffixes = []string{"_bucket", "_sum", "_count"}
+
+// groupingLabels are the labels suggested queries aggregate by, in order of preference
+var groupingLabels = []string{"node_name", "table", "cluster"}
+
+// MetricMetadata describes a metric family as announced by the servers
+type MetricMetadata struct {
+	Type model.MetricType `json:"type"`
+	Help string           `json:"help,omitempty"`
+	Unit string           `json:"unit,omitempty"`
+}
+
+// consoleMetadata describes the metrics recorded by the console itself
+var consoleMetadata = map[string]MetricMetadata{
+	ClockSkewMetric:     {Type: model.MetricTypeGauge, Help: "Clock offset of the server relative to the console.", Unit: "seconds"},
+	LeaderChangesMetric: {Type: model.MetricTypeCounter, Help: "Number of leader changes of a table observed by the console."},
+	RetentionRemovedMetric: {
+		Type: model.MetricTypeCounter, Help: "Number of records the console removed from an artifact beyond its retention.",
+	},
+	RetentionReclaimedMetric: {
+		Type: model.MetricTypeCounter, Help: "Space the console reclaimed by removing records beyond their retention.", Unit: "bytes",
+	},
+	"armada_metrics_sample_count": {
+		Type: model.MetricTypeGauge, Help: "Number of samples in the last scrape of the server.",
+	},
+}
+
+// metadataTracker keeps the metadata of every scraped metric family
+type metadataTracker struct {
+	mu       sync.RWMutex
+	families map[string]MetricMetadata
+}
+
+// newMetadataTracker creates a tracker knowing the console's own metrics
+func newMetadataTracker() *metadataTracker {
+	t := &metadataTracker{families: make(map[string]MetricMetadata, len(consoleMetadata))}
+	for name, md := range consoleMetadata {
+		t.families[name] = md
+	}
+	return t
+}
+
+// update merges the metadata announced in a scrape
+func (t *metadataTracker) update(families map[string]MetricMetadata) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, md := range families {
+		t.families[name] = md
+	}
+}
+
+// lookup returns the family a metric or one of its histogram series belongs to
+func (t *metadataTracker) lookup(metric string) (family string, md MetricMetadata, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if md, ok := t.families[metric]; ok {
+		return metric, md, true
+	}
+	for _, suffix := range histogramSuffixes {
+		if base, found := strings.CutSuffix(metric, suffix); found {
+			if md, ok := t.families[base]; ok && (md.Type == model.MetricTypeHistogram || md.Type == model.MetricTypeSummary) {
+				return base, md, true
+			}
+		}
+	}
+	return "", MetricMetadata{}, false
+}
+
+// MetricMetadata returns the metadata of a metric family, or of the family a histogram
+// or summary series belongs to, as announced in the last scrape that included it
+func (m *MetricsManager) MetricMetadata(metric string) (family string, md MetricMetadata, ok bool) {
+	return m.metadata.lookup(metric)
+}
+
+// QuerySuggestion is a query template suited to the type of a metric
+type QuerySuggestion struct {
+	Title       string `json:"title"`
+	Query       string `json:"query"`
+	Description string `json:"description,omitempty"`
+}
+
+// Suggestions lists query templates for a metric
+type Suggestions struct {
+	Metric string `json:"metric"`
+	// Family is the metric family, e.g. without the _bucket suffix of histogram series
+	Family string           `json:"family"`
+	Type   model.MetricType `json:"type"`
+	Help   string           `json:"help,omitempty"`
+	Unit   string           `json:"unit,omitempty"`
+	// Inferred is set when the type was guessed from the name as the servers did not announce it
+	Inferred bool `json:"inferred"`
+	// Labels are the label names of the stored series of the family
+	Labels      []string          `json:"labels"`
+	Suggestions []QuerySuggestion `json:"suggestions"`
+}
+
+// Suggest returns query templates for a metric based on its type and stored labels.
+// It returns false if neither metadata nor series of the metric are known.
+func (m *MetricsManager) Suggest(ctx context.Context, metric string) (Suggestions, bool, error) {
+	family, md, known := m.MetricMetadata(metric)
+	inferred := false
+	if !known {
+		family, md.Type = inferType(metric)
+		inferred = true
+	}
+
+	names := []string{family}
+	if md.Type == model.MetricTypeHistogram || md.Type == model.MetricTypeSummary {
+		for _, suffix := range histogramSuffixes {
+			names = append(names, family+suffix)
+		}
+	}
+	labelNames, err := m.labelNames(ctx, names)
+	if err != nil {
+		return Suggestions{}, false, err
+	}
+	if !known && labelNames == nil {
+		return Suggestions{}, false, nil
+	}
+
+	s := Suggestions{
+		Metric:   metric,
+		Family:   family,
+		Type:     md.Type,
+		Help:     md.Help,
+		Unit:     md.Unit,
+		Inferred: inferred,
+		Labels:   slices.DeleteFunc(slices.Clone(labelNames), func(l string) bool { return l == "le" || l == "quantile" }),
+	}
+	s.Suggestions = suggestQueries(family, md.Type, groupingLabel(labelNames))
+	if s.Labels == nil {
+		s.Labels = make([]string, 0)
+	}
+	return s, true, nil
+}
+
+// labelNames returns the label names of the stored series of the metrics, or nil if there are none
+func (m *MetricsManager) labelNames(ctx context.Context, metrics []string) ([]string, error) {
+	q, err := m.storage.Querier(math.MinInt64, math.MaxInt64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open querier: %w", err)
+	}
+	defer q.Close()
+
+	// Metric names only contain [a-zA-Z0-9_:], none of which needs escaping in a regular expression
+	matcher, err := labels.NewMatcher(labels.MatchRegexp, labels.MetricName, strings.Join(metrics, "|"))
+	if err != nil {
+		return nil, err
+	}
+	names, _, err := q.LabelNames(ctx, &storage.LabelHints{}, matcher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label names: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	return slices.DeleteFunc(names, func(l string) bool { return l == labels.MetricName }), nil
+}
+
+// inferType guesses the type of a metric from the naming conventions of Prometheus
+func inferType(metric string) (family string, t model.MetricType) {
+	if base, ok := strings.CutSuffix(metric, "_bucket"); ok {
+		return base, model.MetricTypeHistogram
+	}
+	if strings.HasSuffix(metric, "_total") {
+		return metric, model.MetricTypeCounter
+	}
+	return metric, model.MetricTypeGauge
+}
+
+// groupingLabel picks the label suggested queries aggregate by, if any
+func groupingLabel(labelNames []string) string {
+	for _, l := range groupingLabels {
+		if slices.Contains(labelNames, l) {
+			return l
+		}
+	}
+	return ""
+}
+
+// suggestQueries returns the query templates suited to a metric type
+func suggestQueries(family string, t model.MetricType, by string) []QuerySuggestion {
+	sumBy := "sum"
+	avgBy := "avg"
+	if by != "" {
+		sumBy = "sum by (" + by + ")"
+		avgBy = "avg by (" + by + ")"
+	}
+
+	switch t {
+	case model.MetricTypeCounter:
+		return []QuerySuggestion{
+			{Title: "Per-second rate", Query: fmt.Sprintf("%s (rate(%s[5m]))", sumBy, family),
+				Description: "Counters only grow, their rate shows how fast"},
+			{Title: "Increase over the last hour", Query: fmt.Sprintf("%s (increase(%s[1h]))", sumBy, family)},
+			{Title: "Total rate", Query: fmt.Sprintf("sum(rate(%s[5m]))", family)},
+		}
+	case model.MetricTypeHistogram, model.MetricTypeGaugeHistogram:
+		suggestions := make([]QuerySuggestion, 0, 5)
+		bucketsBy := "sum by (le)"
+		if by != "" {
+			bucketsBy = "sum by (le, " + by + ")"
+		}
+		for _, q := range []string{"0.5", "0.9", "0.99"} {
+			suggestions = append(suggestions, QuerySuggestion{
+				Title: "p" + strings.TrimPrefix(q, "0."),
+				Query: fmt.Sprintf("histogram_quantile(%s, %s (rate(%s_bucket[5m])))", q, bucketsBy, family),
+			})
+		}
+		return append(suggestions,
+			QuerySuggestion{Title: "Average", Query: fmt.Sprintf("%s (rate(%s_sum[5m])) / %s (rate(%s_count[5m]))", sumBy, family, sumBy, family)},
+			QuerySuggestion{Title: "Observations per second", Query: fmt.Sprintf("%s (rate(%s_count[5m]))", sumBy, family)},
+		)
+	case model.MetricTypeSummary:
+		return []QuerySuggestion{
+			{Title: "p99", Query: fmt.Sprintf(`max by (%s) (%s{quantile="0.99"})`, cmp.Or(by, "cluster"), family),
+				Description: "Summary quantiles are computed by the servers and cannot be aggregated"},
+			{Title: "Average", Query: fmt.Sprintf("%s (rate(%s_sum[5m])) / %s (rate(%s_count[5m]))", sumBy, family, sumBy, family)},
+			{Title: "Observations per second", Query: fmt.Sprintf("%s (rate(%s_count[5m]))", sumBy, family)},
+		}
+	default:
+		return []QuerySuggestion{
+			{Title: "Current value", Query: fmt.Sprintf("%s (%s)", avgBy, family)},
+			{Title: "Maximum over the last hour", Query: fmt.Sprintf("max_over_time(%s[1h])", family)},
+			{Title: "Change over the last hour", Query: fmt.Sprintf("delta(%s[1h])", family),
+				Description: "Meaningful for gauges only"},
+		}
+	}
+}
+
+// handleSuggest returns query templates for a metric, powering the guided query builder
+// @Summary Suggest queries for a metric
+// @Description Suggest PromQL queries suited to the type and labels of a stored metric
+// @Tags metrics
+// @Produce json
+// @Param metric query string true "Metric name"
+// @Success 200 {object} Suggestions
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/metrics/suggest [get]
+func (h *MetricsHandler) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	metric := r.URL.Query().Get("metric")
+	if !model.IsValidLegacyMetricName(metric) {
+		renderError(w, http.StatusBadRequest, "Parameter 'metric' must be a metric name")
+		return
+	}
+
+	suggestions, ok, err := h.metricsManager.Suggest(r.Context(), metric)
+	if err != nil {
+		h.logger.Error("Failed to suggest queries", zap.String("metric", metric), zap.Error(err))
+		renderError(w, http.StatusInternalServerError, "Failed to suggest queries")
+		return
+	}
+	if !ok {
+		renderError(w, http.StatusNotFound, "Unknown metric: "+metric)
+		return
+	}
+
+	renderJSON(w, suggestions)
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleSuggest(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	manager.metadata.update(map[string]MetricMetadata{
+		"grpc_server_handling_seconds": {Type: model.MetricTypeHistogram, Help: "Latency of handled RPCs.", Unit: "seconds"},
+		"grpc_server_handled_total":    {Type: model.MetricTypeCounter},
+	})
+	appender := manager.GetStorage().Appender(t.Context())
+	ts := time.Now().Add(-10 * time.Second).UnixMilli()
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("__name__", "grpc_server_handling_seconds_bucket", "node_name", "a", "grpc_method", "Get", "le", "0.1"),
+		labels.FromStrings("__name__", "grpc_server_handled_total", "node_name", "a", "grpc_code", "OK"),
+		labels.FromStrings("__name__", "raft_log_entries", "cluster", "c1"),
+	} {
+		_, err := appender.Append(0, lbls, ts, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, appender.Commit())
+
+	r := chi.NewRouter()
+	NewMetricsHandler(manager, zap.NewNop()).RegisterRoutes(r)
+	suggest := func(metric string) (int, Suggestions) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/api/metrics/suggest?metric="+metric, nil))
+		var s Suggestions
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&s))
+		}
+		return rr.Code, s
+	}
+
+	// Series of a histogram are resolved to their family
+	code, s := suggest("grpc_server_handling_seconds_bucket")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "grpc_server_handling_seconds", s.Family)
+	assert.Equal(t, model.MetricTypeHistogram, s.Type)
+	assert.False(t, s.Inferred)
+	assert.Equal(t, []string{"grpc_method", "node_name"}, s.Labels)
+	assert.Equal(t, "histogram_quantile(0.99, sum by (le, node_name) (rate(grpc_server_handling_seconds_bucket[5m])))", s.Suggestions[2].Query)
+
+	code, s = suggest("grpc_server_handled_total")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "sum by (node_name) (rate(grpc_server_handled_total[5m]))", s.Suggestions[0].Query)
+
+	// Without metadata the type is inferred from the name
+	code, s = suggest("raft_log_entries")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, s.Inferred)
+	assert.Equal(t, model.MetricTypeGauge, s.Type)
+	assert.Equal(t, "avg by (cluster) (raft_log_entries)", s.Suggestions[0].Query)
+
+	code, _ = suggest("unknown_metric")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = suggest("up%7Bjob%3D%22x%22%7D")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TargetHealth is the outcome of the last scrape of a target
+type TargetHealth string
+
+const (
+	// TargetUnknown is the health of a target that was not scraped yet
+	TargetUnknown TargetHealth = "unknown"
+	// TargetUp is the health of a target whose last scrape succeeded
+	TargetUp TargetHealth = "up"
+	// TargetDown is the health of a target whose last scrape failed
+	TargetDown TargetHealth = "down"
+)
+
+// Target is the scrape state of a cluster metrics are collected from
+type Target struct {
+	Cluster    string       `json:"cluster"`
+	Health     TargetHealth `json:"health"`
+	LastScrape time.Time    `json:"lastScrape,omitzero"`
+	// LastError is the error of the last scrape, if it failed
+	LastError string `json:"lastError,omitempty"`
+	// ConsecutiveFailures counts the failed scrapes since the last successful one
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// Backoff is how long scrapes are suspended after the last failure, zero while the target is up
+	Backoff time.Duration `json:"backoff"`
+	// NextScrape is when the target is scraped again at the earliest
+	NextScrape time.Time `json:"nextScrape,omitzero"`
+	// Blocked are the metrics of the target not stored because they exceed the cardinality limits
+	Blocked []BlockedMetric `json:"blocked,omitempty"`
+}
+
+// scrapeState tracks the scrapes of a target and suspends them while it keeps failing
+type scrapeState struct {
+	mu         sync.Mutex
+	scraping   bool
+	lastScrape time.Time
+	lastError  string
+	failures   int
+	backoff    time.Duration
+}
+
+// begin reports whether a scrape is due at now and marks it as running if so.
+// A target is not scraped while a previous scrape is still running or it is backing off.
+func (s *scrapeState) begin(now time.Time, interval time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scraping || now.Before(s.nextScrape(interval)) {
+		return false
+	}
+	s.scraping = true
+	return true
+}
+
+// finish records the outcome of a scrape started at started. It returns the number of
+// consecutive failures before the scrape, so callers can log when a target fails or recovers.
+func (s *scrapeState) finish(started time.Time, err error, interval, maxBackoff time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.failures
+	s.scraping = false
+	s.lastScrape = started
+	if err == nil {
+		s.lastError = ""
+		s.failures = 0
+		s.backoff = 0
+		return previous
+	}
+	s.lastError = err.Error()
+	s.failures++
+	s.backoff = backoffDelay(interval, maxBackoff, s.failures)
+	return previous
+}
+
+// abort marks a scrape as no longer running without recording an outcome
+func (s *scrapeState) abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scraping = false
+}
+
+// nextScrape returns when the target is due again. Scrapes run on ticks of the interval,
+// so half an interval of slack keeps a tick arriving slightly early from being skipped.
+func (s *scrapeState) nextScrape(interval time.Duration) time.Time {
+	if s.backoff == 0 {
+		return time.Time{}
+	}
+	return s.lastScrape.Add(s.backoff - interval/2)
+}
+
+// target returns the scrape state of the cluster as reported to clients
+func (s *scrapeState) target(cluster string, interval time.Duration) Target {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := Target{
+		Cluster:             cluster,
+		Health:              TargetUnknown,
+		LastScrape:          s.lastScrape,
+		LastError:           s.lastError,
+		ConsecutiveFailures: s.failures,
+		Backoff:             s.backoff,
+	}
+	switch {
+	case s.failures > 0:
+		t.Health = TargetDown
+		t.NextScrape = s.lastScrape.Add(s.backoff)
+	case !s.lastScrape.IsZero():
+		t.Health = TargetUp
+		t.NextScrape = s.lastScrape.Add(interval)
+	}
+	return t
+}
+
+// backoffDelay returns how long scrapes are suspended after the given number of consecutive
+// failures: twice the interval after the first failure, doubling with every further one up to
+// maxBackoff. It is never shorter than the interval.
+func backoffDelay(interval, maxBackoff time.Duration, failures int) time.Duration {
+	delay := interval
+	for range failures {
+		if delay >= maxBackoff/2 {
+			return max(maxBackoff, interval)
+		}
+		delay *= 2
+	}
+	return delay
+}
+
+// Targets returns the scrape state of every cluster metrics are collected from, sorted by address
+func (m *MetricsManager) Targets() []Target {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	targets := make([]Target, 0, len(m.collectors))
+	for addr, collector := range m.collectors {
+		target := collector.state.target(addr, m.scrapeInterval)
+		target.Blocked = collector.cardinality.list(now)
+		targets = append(targets, target)
+	}
+	slices.SortFunc(targets, func(a, b Target) int {
+		return strings.Compare(a.Cluster, b.Cluster)
+	})
+	return targets
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	regattapb "github.com/armadakv/console/backend/armada/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 30 * time.Second},
+		{failures: 1, want: time.Minute},
+		{failures: 2, want: 2 * time.Minute},
+		{failures: 4, want: 8 * time.Minute},
+		{failures: 5, want: 10 * time.Minute},
+		{failures: 100, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoffDelay(30*time.Second, 10*time.Minute, tt.failures), "failures=%d", tt.failures)
+	}
+
+	// A cap below the interval doesn't make scrapes more frequent
+	assert.Equal(t, 30*time.Second, backoffDelay(30*time.Second, 10*time.Second, 3))
+}
+
+func TestScrapeStateBackoff(t *testing.T) {
+	interval := 30 * time.Second
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var s scrapeState
+
+	assert.Equal(t, TargetUnknown, s.target("a", interval).Health)
+	assert.True(t, s.begin(start, interval))
+	// A scrape still running is not started again
+	assert.False(t, s.begin(start.Add(interval), interval))
+
+	assert.Equal(t, 0, s.finish(start, errors.New("unavailable"), interval, 10*time.Minute))
+	target := s.target("a", interval)
+	assert.Equal(t, TargetDown, target.Health)
+	assert.Equal(t, "unavailable", target.LastError)
+	assert.Equal(t, 1, target.ConsecutiveFailures)
+	assert.Equal(t, time.Minute, target.Backoff)
+	assert.Equal(t, start.Add(time.Minute), target.NextScrape)
+
+	// The next tick is skipped, the one after is due even if it arrives slightly early
+	assert.False(t, s.begin(start.Add(interval), interval))
+	assert.True(t, s.begin(start.Add(2*interval-time.Second), interval))
+
+	assert.Equal(t, 1, s.finish(start.Add(time.Minute), errors.New("unavailable"), interval, 10*time.Minute))
+	assert.Equal(t, 2*time.Minute, s.target("a", interval).Backoff)
+
+	// Success resets the back-off
+	assert.True(t, s.begin(start.Add(3*time.Minute), interval))
+	assert.Equal(t, 2, s.finish(start.Add(3*time.Minute), nil, interval, 10*time.Minute))
+	target = s.target("a", interval)
+	assert.Equal(t, TargetUp, target.Health)
+	assert.Empty(t, target.LastError)
+	assert.Zero(t, target.ConsecutiveFailures)
+	assert.Zero(t, target.Backoff)
+	assert.True(t, s.begin(start.Add(3*time.Minute+interval), interval))
+}
+
+func TestMetricsManagerTargets(t *testing.T) {
+	metricsClient := &mockMetricsClient{}
+	metricsClient.On("GetMetrics", mock.Anything, mock.Anything).Return(&regattapb.MetricsResponse{
+		MetricsData: "test_metric 1.0\n",
+		Timestamp:   time.Now().Unix(),
+	}, nil)
+	mockPool := &mockClusterPool{}
+	mockPool.On("GetConnection", mock.Anything, "up:8080").Return(&armada.ServerConnection{MetricsClient: metricsClient}, nil)
+	mockPool.On("GetConnection", mock.Anything, "down:8080").Return((*armada.ServerConnection)(nil), errors.New("connection refused"))
+
+	manager, err := NewMetricsManager(mockPool, time.Minute, createTempDir(t), zap.NewNop())
+	assert.NoError(t, err)
+	defer manager.Stop()
+
+	manager.mu.Lock()
+	manager.addCluster(context.Background(), "up:8080")
+	manager.addCluster(context.Background(), "down:8080")
+	collectors := []*MetricsCollector{manager.collectors["up:8080"], manager.collectors["down:8080"]}
+	manager.mu.Unlock()
+	for _, collector := range collectors {
+		assert.True(t, collector.state.begin(time.Now(), manager.scrapeInterval))
+		collector.collect(collector.ctx)
+	}
+
+	targets := manager.Targets()
+	assert.Len(t, targets, 2)
+	assert.Equal(t, "down:8080", targets[0].Cluster)
+	assert.Equal(t, TargetDown, targets[0].Health)
+	assert.Contains(t, targets[0].LastError, "connection refused")
+	assert.Equal(t, 2*time.Minute, targets[0].Backoff)
+	assert.Equal(t, "up:8080", targets[1].Cluster)
+	assert.Equal(t, TargetUp, targets[1].Health)
+	assert.Zero(t, targets[1].Backoff)
+}
@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/armadakv/console/backend/coalesce"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultTopK is the number of series returned unless another k is requested
+	defaultTopK = 10
+	// maxTopK limits k, panels with more series aren't readable anyway
+	maxTopK = 1000
+)
+
+// TopKResponse holds the K highest series of a query and the rest summed up
+type TopKResponse struct {
+	Status string      `json:"status"` // Query status (success, error)
+	Data   QueryResult `json:"data"`   // The K highest series, highest first
+	// Other is the sum of the remaining series, with the same result type as Data, unless there are none
+	Other *QueryResult `json:"other,omitempty"`
+	// OtherSeries is the number of series summed up in Other
+	OtherSeries int `json:"otherSeries"`
+	// SuggestedRefreshSeconds hints how long clients should wait before polling again
+	SuggestedRefreshSeconds int `json:"suggestedRefreshSeconds,omitempty"`
+}
+
+// handleTopK evaluates a query and keeps its K highest series. The result is shared with other
+// requests for the same query, so it is sorted and summed up in copies.
+// @Summary Top K series
+// @Description Evaluate a PromQL query and return only its K highest series, with the remaining series summed up in other. Instant queries are ranked by their value; range queries, given start and end, by the average of their samples.
+// @Tags metrics
+// @Produce json
+// @Param query query string true "PromQL query returning series"
+// @Param k query int false "Number of series to return (default: 10, at most 1000)"
+// @Param time query string false "Evaluation timestamp of an instant query (RFC3339 or unix timestamp)"
+// @Param start query string false "Start timestamp of a range query (RFC3339 or unix timestamp)"
+// @Param end query string false "End timestamp of a range query (RFC3339 or unix timestamp)"
+// @Param step query string false "Query resolution step width of a range query (default: 1m)"
+// @Param clusters query []string false "Restrict every selector of the query to these clusters, e.g. a:5001,b:5001" collectionFormat(csv)
+// @Param tags query []string false "Restrict every selector of the query to clusters with these tags, e.g. env=prod,region=eu" collectionFormat(csv)
+// @Success 200 {object} TopKResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/metrics/topk [get]
+func (h *MetricsHandler) handleTopK(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := r.URL.Query()
+
+	queryStr := params.Get("query")
+	if queryStr == "" {
+		renderError(w, http.StatusBadRequest, "Missing required parameter 'query'")
+		return
+	}
+	queryStr, err := scopeQuery(params, queryStr)
+	if err != nil {
+		renderError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	k := defaultTopK
+	if kParam := params.Get("k"); kParam != "" {
+		k, err = strconv.Atoi(kParam)
+		if err != nil || k < 1 || k > maxTopK {
+			renderError(w, http.StatusBadRequest, "Invalid k, expected a number between 1 and 1000")
+			return
+		}
+	}
+
+	// The keys match the ones of query and query_range, so the panels share their results
+	var key string
+	var run func(ctx context.Context) (QueryResult, error)
+	if params.Has("start") || params.Has("end") {
+		start, end, step, ok := parseRange(w, params)
+		if !ok {
+			return
+		}
+		key = rangeKey(queryStr, start, end, step)
+		run = func(ctx context.Context) (QueryResult, error) {
+			return h.queryEngine.QueryRange(ctx, queryStr, start, end, step)
+		}
+	} else {
+		ts := time.Now()
+		if timeParam := params.Get("time"); timeParam != "" {
+			ts, err = parseTime(timeParam)
+			if err != nil {
+				renderError(w, http.StatusBadRequest, "Invalid time format")
+				return
+			}
+		}
+		key = instantKey(queryStr, ts)
+		run = func(ctx context.Context) (QueryResult, error) {
+			return h.queryEngine.Query(ctx, queryStr, ts)
+		}
+	}
+
+	h.logger.Debug("Executing top k query",
+		zap.String("query", queryStr),
+		zap.Int("k", k))
+
+	result, _, err := coalesce.Do(ctx, &h.queries, key, run)
+	if err != nil {
+		h.logger.Error("Top k query execution failed",
+			zap.String("query", queryStr),
+			zap.Error(err))
+		renderError(w, http.StatusInternalServerError, "Query execution failed")
+		return
+	}
+
+	resp := TopKResponse{Status: "success"}
+	switch value := result.Value.(type) {
+	case promql.Vector:
+		top, other, n := topKVector(value, k)
+		resp.Data = QueryResult{Type: parser.ValueTypeVector, Value: top, Stats: result.Stats}
+		if n > 0 {
+			resp.Other = &QueryResult{Type: parser.ValueTypeVector, Value: promql.Vector{other}}
+			resp.OtherSeries = n
+		}
+	case promql.Matrix:
+		top, other, n := topKMatrix(value, k)
+		resp.Data = QueryResult{Type: parser.ValueTypeMatrix, Value: top, Stats: result.Stats}
+		if n > 0 {
+			resp.Other = &QueryResult{Type: parser.ValueTypeMatrix, Value: promql.Matrix{other}}
+			resp.OtherSeries = n
+		}
+	default:
+		renderError(w, http.StatusBadRequest, "The query must return series, not a "+string(result.Type))
+		return
+	}
+	resp.SuggestedRefreshSeconds = h.refresh.Suggest("topk\x00"+queryStr, result)
+
+	renderJSON(w, resp)
+}
+
+// topKVector returns the k highest samples of a vector, the sum of the others and their number.
+// NaN ranks lowest.
+func topKVector(vector promql.Vector, k int) (promql.Vector, promql.Sample, int) {
+	sorted := slices.Clone(vector)
+	slices.SortStableFunc(sorted, func(a, b promql.Sample) int {
+		return compareRank(a.F, b.F, a.Metric, b.Metric)
+	})
+	if len(sorted) <= k {
+		return sorted, promql.Sample{}, 0
+	}
+
+	other := promql.Sample{Metric: labels.EmptyLabels(), T: sorted[k].T}
+	for _, sample := range sorted[k:] {
+		if !math.IsNaN(sample.F) {
+			other.F += sample.F
+		}
+	}
+	return sorted[:k], other, len(sorted) - k
+}
+
+// topKMatrix returns the k series of a matrix with the highest average, the others summed up
+// per timestamp and their number. Series without samples rank lowest.
+func topKMatrix(matrix promql.Matrix, k int) (promql.Matrix, promql.Series, int) {
+	averages := make(map[*promql.Series]float64, len(matrix))
+	sorted := make([]*promql.Series, len(matrix))
+	for i := range matrix {
+		series := &matrix[i]
+		sorted[i] = series
+		averages[series] = math.NaN()
+		var sum float64
+		var n int
+		for _, point := range series.Floats {
+			if !math.IsNaN(point.F) {
+				sum += point.F
+				n++
+			}
+		}
+		if n > 0 {
+			averages[series] = sum / float64(n)
+		}
+	}
+	slices.SortStableFunc(sorted, func(a, b *promql.Series) int {
+		return compareRank(averages[a], averages[b], a.Metric, b.Metric)
+	})
+
+	n := min(k, len(sorted))
+	top := make(promql.Matrix, n)
+	for i := range n {
+		top[i] = *sorted[i]
+	}
+	if len(sorted) <= k {
+		return top, promql.Series{}, 0
+	}
+
+	sums := make(map[int64]float64)
+	for _, series := range sorted[k:] {
+		for _, point := range series.Floats {
+			if !math.IsNaN(point.F) {
+				sums[point.T] += point.F
+			}
+		}
+	}
+	other := promql.Series{Metric: labels.EmptyLabels(), Floats: make([]promql.FPoint, 0, len(sums))}
+	for t, sum := range sums {
+		other.Floats = append(other.Floats, promql.FPoint{T: t, F: sum})
+	}
+	slices.SortFunc(other.Floats, func(a, b promql.FPoint) int { return cmp.Compare(a.T, b.T) })
+	return top, other, len(sorted) - k
+}
+
+// compareRank orders values from the highest to the lowest with NaN last, and equal values by their labels
+func compareRank(a, b float64, aLabels, bLabels labels.Labels) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+	case math.IsNaN(a):
+		return 1
+	case math.IsNaN(b):
+		return -1
+	case a != b:
+		return cmp.Compare(b, a)
+	}
+	return labels.Compare(aLabels, bLabels)
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestHandleTopK(t *testing.T) {
+	manager, err := NewMetricsManager(&mockClusterPool{}, time.Minute, createTempDir(t), zap.NewNop())
+	require.NoError(t, err)
+	defer manager.Stop()
+
+	// Four tables with 1 to 4 keys, table c grows in the last minute
+	end := time.Now().Truncate(time.Minute)
+	appender := manager.GetStorage().Appender(t.Context())
+	for i, table := range []string{"a", "b", "c", "d"} {
+		lbls := labels.FromStrings("__name__", "armada_table_keys", "table", table)
+		_, err = appender.Append(0, lbls, end.Add(-time.Minute).UnixMilli(), float64(i+1))
+		require.NoError(t, err)
+		value := float64(i + 1)
+		if table == "c" {
+			value = 9
+		}
+		_, err = appender.Append(0, lbls, end.UnixMilli(), value)
+		require.NoError(t, err)
+	}
+	require.NoError(t, appender.Commit())
+
+	handler := NewMetricsHandler(manager, zap.NewNop())
+
+	type result struct {
+		Metric map[string]string `json:"metric"`
+		Value  [2]any            `json:"value"`
+		Values [][2]any          `json:"values"`
+	}
+	var response struct {
+		Data struct {
+			Result []result `json:"result"`
+		} `json:"data"`
+		Other struct {
+			Result []result `json:"result"`
+		} `json:"other"`
+		OtherSeries int `json:"otherSeries"`
+	}
+
+	// Instant queries are ranked by their value
+	rr := httptest.NewRecorder()
+	handler.handleTopK(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/topk?query=armada_table_keys&k=2&time=%d", end.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Data.Result, 2)
+	assert.Equal(t, "c", response.Data.Result[0].Metric["table"])
+	assert.Equal(t, "d", response.Data.Result[1].Metric["table"])
+	assert.Equal(t, 2, response.OtherSeries)
+	require.Len(t, response.Other.Result, 1)
+	assert.Empty(t, response.Other.Result[0].Metric)
+	assert.Equal(t, "3", response.Other.Result[0].Value[1])
+
+	// Range queries are ranked by their average
+	rr = httptest.NewRecorder()
+	handler.handleTopK(rr, httptest.NewRequest("GET", fmt.Sprintf("/api/metrics/topk?query=armada_table_keys&k=1&start=%d&end=%d&step=1m",
+		end.Add(-time.Minute).Unix(), end.Unix()), nil))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Len(t, response.Data.Result, 1)
+	assert.Equal(t, "c", response.Data.Result[0].Metric["table"])
+	assert.Equal(t, 3, response.OtherSeries)
+	require.Len(t, response.Other.Result, 1)
+	assert.Equal(t, [][2]any{{float64(end.Add(-time.Minute).Unix()), "7"}, {float64(end.Unix()), "7"}}, response.Other.Result[0].Values)
+
+	for _, params := range []string{
+		"query=armada_table_keys&k=0",
+		"query=armada_table_keys&k=many",
+		"query=armada_table_keys&start=1",
+		"query=1",
+		"k=1",
+	} {
+		rr = httptest.NewRecorder()
+		handler.handleTopK(rr, httptest.NewRequest("GET", "/api/metrics/topk?"+params, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, params)
+	}
+}
+
+func TestTopKVectorRanksNaNLast(t *testing.T) {
+	vector := promql.Vector{
+		{Metric: labels.FromStrings("table", "a"), F: math.NaN()},
+		{Metric: labels.FromStrings("table", "b"), F: 1},
+		{Metric: labels.FromStrings("table", "c"), F: 2},
+		{Metric: labels.FromStrings("table", "d"), F: 2},
+	}
+
+	top, other, n := topKVector(vector, 2)
+	require.Len(t, top, 2)
+	assert.Equal(t, "c", top[0].Metric.Get("table"), "equal values are ordered by their labels")
+	assert.Equal(t, "d", top[1].Metric.Get("table"))
+	assert.Equal(t, 2, n)
+	assert.InDelta(t, 1, other.F, 0)
+	assert.True(t, math.IsNaN(vector[0].F), "the vector isn't modified")
+}
@@ -0,0 +1,161 @@
+// Package panels keeps saved metric queries, e.g. the panels of a dashboard, together with the
+// unit of their values and the thresholds beyond which they are a warning or critical, so every
+// chart of a query renders the same axes and colors without repeating them in the frontend.
+package panels
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// Namespace is the metadata namespace the panels are stored in
+const Namespace = "panels"
+
+var (
+	// ErrNotFound is returned when a panel does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for panels that can't be stored
+	ErrInvalid = errors.New("invalid")
+)
+
+// validName matches the names of panels
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,62}$`)
+
+// validUnit matches unit hints, e.g. seconds, bytes, percent or ops/s
+var validUnit = regexp.MustCompile(`^[A-Za-z%][A-Za-z0-9%/_.]{0,31}$`)
+
+// Direction tells which side of its thresholds a value is bad on
+type Direction string
+
+const (
+	// Above marks values above the thresholds, e.g. latencies
+	Above Direction = "above"
+	// Below marks values below the thresholds, e.g. free disk space
+	Below Direction = "below"
+)
+
+// Thresholds are the values at which a panel turns into a warning and becomes critical
+type Thresholds struct {
+	Warning  *float64 `json:"warning,omitempty"`
+	Critical *float64 `json:"critical,omitempty"`
+	// Direction is the side of the thresholds bad values are on, above if empty
+	Direction Direction `json:"direction,omitempty"`
+}
+
+// Annotations tell charts how to render the values of a query
+type Annotations struct {
+	// Unit is the unit of the values, e.g. seconds or bytes, to label and scale axes
+	Unit string `json:"unit,omitempty"`
+	// Thresholds color values beyond them, if any
+	Thresholds *Thresholds `json:"thresholds,omitempty"`
+}
+
+// Panel is a saved query with the annotations of its values
+type Panel struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+	// Query is the PromQL query of the panel
+	Query string `json:"query"`
+	Annotations
+}
+
+// Store keeps the panels in the metadata store
+type Store struct {
+	store metadata.Store
+	// mu serializes changes to the panels
+	mu sync.Mutex
+}
+
+// NewStore creates a panel store on top of the metadata store
+func NewStore(store metadata.Store) *Store {
+	return &Store{store: store}
+}
+
+// List returns the panels ordered by name
+func (s *Store) List() ([]Panel, error) {
+	stored, err := metadata.List[Panel](s.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Panel, 0, len(stored))
+	for _, p := range stored {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Get returns a panel
+func (s *Store) Get(name string) (Panel, error) {
+	p, err := metadata.Get[Panel](s.store, Namespace, name)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Panel{}, fmt.Errorf("panel %s %w", name, ErrNotFound)
+	}
+	return p, err
+}
+
+// Put creates or replaces a panel
+func (s *Store) Put(p Panel) (Panel, error) {
+	if err := validate(p); err != nil {
+		return Panel{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := metadata.Put(s.store, Namespace, p.Name, p); err != nil {
+		return Panel{}, err
+	}
+	return p, nil
+}
+
+// Delete removes a panel
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := metadata.Get[Panel](s.store, Namespace, name); err != nil {
+		if errors.Is(err, metadata.ErrNotFound) {
+			return fmt.Errorf("panel %s %w", name, ErrNotFound)
+		}
+		return err
+	}
+	return s.store.Delete(Namespace, name)
+}
+
+// validate checks the name, query and annotations of a panel
+func validate(p Panel) error {
+	if !validName.MatchString(p.Name) {
+		return fmt.Errorf("%w panel name %q: use up to 63 letters, digits, _ and -", ErrInvalid, p.Name)
+	}
+	if p.Query == "" {
+		return fmt.Errorf("%w panel %s: the query is required", ErrInvalid, p.Name)
+	}
+	if _, err := parser.ParseExpr(p.Query); err != nil {
+		return fmt.Errorf("%w panel query: %s", ErrInvalid, err.Error())
+	}
+	if p.Unit != "" && !validUnit.MatchString(p.Unit) {
+		return fmt.Errorf("%w panel unit %q: use up to 32 letters, digits, %%, /, _ and ., e.g. seconds or ops/s", ErrInvalid, p.Unit)
+	}
+	t := p.Thresholds
+	if t == nil {
+		return nil
+	}
+	switch t.Direction {
+	case "", Above:
+		if t.Warning != nil && t.Critical != nil && *t.Warning > *t.Critical {
+			return fmt.Errorf("%w panel thresholds: the warning must not be above the critical threshold", ErrInvalid)
+		}
+	case Below:
+		if t.Warning != nil && t.Critical != nil && *t.Warning < *t.Critical {
+			return fmt.Errorf("%w panel thresholds: the warning must not be below the critical threshold", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w panel threshold direction %q: use above or below", ErrInvalid, t.Direction)
+	}
+	return nil
+}
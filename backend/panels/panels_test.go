@@ -0,0 +1,64 @@
+package panels
+
+import (
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(metadata.NewMemoryStore())
+
+	latency := Panel{
+		Name:  "put-latency",
+		Title: "Put latency",
+		Query: `histogram_quantile(0.99, sum by (le) (rate(grpc_server_handling_seconds_bucket{grpc_method="Put"}[5m])))`,
+		Annotations: Annotations{
+			Unit:       "seconds",
+			Thresholds: &Thresholds{Warning: ptr(0.1), Critical: ptr(0.5)},
+		},
+	}
+	_, err := store.Put(latency)
+	require.NoError(t, err)
+	_, err = store.Put(Panel{Name: "free-disk", Query: "armada_disk_free_bytes", Annotations: Annotations{
+		Unit:       "bytes",
+		Thresholds: &Thresholds{Warning: ptr(10e9), Critical: ptr(1e9), Direction: Below},
+	}})
+	require.NoError(t, err)
+
+	got, err := store.Get("put-latency")
+	require.NoError(t, err)
+	assert.Equal(t, latency, got)
+	list, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "free-disk", list[0].Name)
+
+	require.NoError(t, store.Delete("free-disk"))
+	_, err = store.Get("free-disk")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, store.Delete("free-disk"), ErrNotFound)
+}
+
+func TestStoreRejectsInvalidPanels(t *testing.T) {
+	store := NewStore(metadata.NewMemoryStore())
+
+	for name, p := range map[string]Panel{
+		"name":      {Name: "../panel", Query: "up"},
+		"no query":  {Name: "empty"},
+		"query":     {Name: "broken", Query: "sum(up"},
+		"unit":      {Name: "unit", Query: "up", Annotations: Annotations{Unit: "<b>seconds</b>"}},
+		"direction": {Name: "direction", Query: "up", Annotations: Annotations{Thresholds: &Thresholds{Direction: "sideways"}}},
+		"above":     {Name: "above", Query: "up", Annotations: Annotations{Thresholds: &Thresholds{Warning: ptr(2), Critical: ptr(1)}}},
+		"below":     {Name: "below", Query: "up", Annotations: Annotations{Thresholds: &Thresholds{Warning: ptr(1), Critical: ptr(2), Direction: Below}}},
+	} {
+		_, err := store.Put(p)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+}
@@ -0,0 +1,49 @@
+package panics
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Recoverer returns a middleware that recovers from panics, hands them to the reporter
+// and answers with 500 Internal Server Error. It replaces chi's middleware.Recoverer.
+//
+// Like the standard library, it lets http.ErrAbortHandler through so that
+// handlers can still abort a response deliberately.
+func Recoverer(reporter Reporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, s := withScope(r.Context())
+			r = r.WithContext(ctx)
+
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				user, session := s.identity()
+				reporter.ReportPanic(ctx, &Report{
+					Value:     rvr,
+					Stack:     debug.Stack(),
+					Request:   r,
+					RequestID: middleware.GetReqID(ctx),
+					User:      user,
+					Session:   session,
+				})
+
+				// Upgraded connections have no response to write to
+				if r.Header.Get("Connection") != "Upgrade" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
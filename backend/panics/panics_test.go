@@ -0,0 +1,145 @@
+package panics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recordingReporter keeps every report it receives
+type recordingReporter struct {
+	reports []*Report
+}
+
+func (r *recordingReporter) ReportPanic(_ context.Context, report *Report) {
+	r.reports = append(r.reports, report)
+}
+
+func TestRecoverer(t *testing.T) {
+	reporter := &recordingReporter{}
+	handler := middleware.RequestID(Recoverer(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetUser(r.Context(), "alice", "session-1")
+		panic("boom")
+	})))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/tables", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	require.Len(t, reporter.reports, 1)
+	report := reporter.reports[0]
+	assert.Equal(t, "boom", report.Value)
+	assert.Contains(t, string(report.Stack), "panics_test.go")
+	assert.Equal(t, "/api/tables", report.Request.URL.Path)
+	assert.NotEmpty(t, report.RequestID)
+	assert.Equal(t, "alice", report.User)
+	assert.Equal(t, "session-1", report.Session)
+}
+
+func TestRecovererWithoutPanic(t *testing.T) {
+	reporter := &recordingReporter{}
+	handler := Recoverer(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, reporter.reports)
+}
+
+func TestRecovererAbortHandler(t *testing.T) {
+	reporter := &recordingReporter{}
+	handler := Recoverer(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Empty(t, reporter.reports)
+}
+
+func TestSetUserWithoutScope(t *testing.T) {
+	assert.NotPanics(t, func() { SetUser(context.Background(), "alice", "") })
+}
+
+func TestLogReporter(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	reporter := NewLogReporter(zap.New(core))
+
+	reporter.ReportPanic(context.Background(), &Report{
+		Value:   "boom",
+		Stack:   []byte("goroutine 1"),
+		Request: httptest.NewRequest(http.MethodPut, "/api/kv/table1", nil),
+		User:    "alice",
+	})
+
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "boom", fields["panic"])
+	assert.Equal(t, "PUT", fields["method"])
+	assert.Equal(t, "/api/kv/table1", fields["path"])
+	assert.Equal(t, "alice", fields["user"])
+	assert.NotContains(t, fields, "session")
+}
+
+// captureTransport records events instead of sending them
+type captureTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (c *captureTransport) Flush(time.Duration) bool              { return true }
+func (c *captureTransport) FlushWithContext(context.Context) bool { return true }
+func (c *captureTransport) Configure(sentry.ClientOptions)        {}
+func (c *captureTransport) Close()                                {}
+func (c *captureTransport) SendEvent(event *sentry.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+}
+
+func TestSentryReporter(t *testing.T) {
+	transport := &captureTransport{}
+	reporter, err := NewSentryReporter(SentryOptions{
+		DSN:         "https://key@sentry.example.com/1",
+		Environment: "staging",
+		Transport:   transport,
+	})
+	require.NoError(t, err)
+
+	reporter.ReportPanic(context.Background(), &Report{
+		Value:     "boom",
+		Request:   httptest.NewRequest(http.MethodGet, "/api/status", nil),
+		RequestID: "req-1",
+		User:      "alice",
+		Session:   "session-1",
+	})
+
+	require.Len(t, transport.events, 1)
+	event := transport.events[0]
+	assert.Equal(t, "staging", event.Environment)
+	assert.Equal(t, sentry.LevelFatal, event.Level)
+	assert.Equal(t, "alice", event.User.Username)
+	assert.Equal(t, "req-1", event.Tags["request_id"])
+	assert.Equal(t, "session-1", event.Tags["session"])
+	require.NotNil(t, event.Request)
+	assert.Contains(t, event.Request.URL, "/api/status")
+}
+
+func TestSentryReporterInvalidDSN(t *testing.T) {
+	_, err := NewSentryReporter(SentryOptions{DSN: "not a dsn"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+// Package panics recovers from panics in HTTP handlers and reports them.
+// Every panic is logged with its stack trace and the request it happened in;
+// deployments can additionally ship reports to Sentry or a Sentry compatible service.
+package panics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Report describes a recovered panic.
+type Report struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+	// Request is the request being served when the panic happened.
+	Request *http.Request
+	// RequestID is the ID assigned to the request by the request ID middleware, if any.
+	RequestID string
+	// User is the authenticated user, if known.
+	User string
+	// Session is the ID of the user's session, if known.
+	Session string
+}
+
+// Reporter handles recovered panics.
+type Reporter interface {
+	// ReportPanic is called from the recovering goroutine while the panic is being handled.
+	ReportPanic(ctx context.Context, report *Report)
+}
+
+// LogReporter logs panics as structured error entries.
+type LogReporter struct {
+	logger *zap.Logger
+}
+
+// NewLogReporter creates a reporter writing to the given logger
+func NewLogReporter(logger *zap.Logger) *LogReporter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LogReporter{logger: logger}
+}
+
+// ReportPanic logs the panic together with its request context
+func (l *LogReporter) ReportPanic(_ context.Context, report *Report) {
+	fields := []zap.Field{
+		zap.String("panic", fmt.Sprint(report.Value)),
+		zap.ByteString("stack", report.Stack),
+	}
+	if report.Request != nil {
+		fields = append(fields,
+			zap.String("method", report.Request.Method),
+			zap.String("path", report.Request.URL.Path),
+			zap.String("remoteAddr", report.Request.RemoteAddr),
+			zap.String("userAgent", report.Request.UserAgent()),
+		)
+	}
+	if report.RequestID != "" {
+		fields = append(fields, zap.String("requestID", report.RequestID))
+	}
+	if report.User != "" {
+		fields = append(fields, zap.String("user", report.User))
+	}
+	if report.Session != "" {
+		fields = append(fields, zap.String("session", report.Session))
+	}
+	l.logger.Error("Recovered from panic", fields...)
+}
+
+// MultiReporter forwards every report to all of its reporters.
+type MultiReporter []Reporter
+
+// ReportPanic forwards the report to every reporter in order
+func (m MultiReporter) ReportPanic(ctx context.Context, report *Report) {
+	for _, r := range m {
+		r.ReportPanic(ctx, report)
+	}
+}
+
+// scope carries identity information added by later middleware so it can be attached to reports
+type scope struct {
+	mu      sync.Mutex
+	user    string
+	session string
+}
+
+type scopeKey struct{}
+
+// withScope returns a context holding a new, empty scope
+func withScope(ctx context.Context) (context.Context, *scope) {
+	s := &scope{}
+	return context.WithValue(ctx, scopeKey{}, s), s
+}
+
+// SetUser records the authenticated user and session of the request in ctx,
+// so that panics raised while serving it are attributed to them.
+// It does nothing if ctx was not created by the Recoverer middleware.
+func SetUser(ctx context.Context, user, session string) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+	s.session = session
+}
+
+// identity returns the user and session recorded in the scope
+func (s *scope) identity() (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.user, s.session
+}
@@ -0,0 +1,71 @@
+package panics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SentryOptions configures reporting to Sentry.
+type SentryOptions struct {
+	// DSN is the Sentry (or Sentry compatible) project DSN.
+	DSN string
+	// Environment tags every event, e.g. "production" or "staging".
+	Environment string
+	// Release tags every event with the console version.
+	Release string
+	// Transport overrides how events are sent. It is only needed in tests.
+	Transport sentry.Transport
+	// HTTPTransport sends the events over HTTP, e.g. through a proxy. Sentry's default if nil.
+	HTTPTransport http.RoundTripper
+}
+
+// SentryReporter ships panics to Sentry.
+type SentryReporter struct {
+	hub *sentry.Hub
+}
+
+// NewSentryReporter creates a reporter for the given DSN
+func NewSentryReporter(opts SentryOptions) (*SentryReporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              opts.DSN,
+		Environment:      opts.Environment,
+		Release:          opts.Release,
+		AttachStacktrace: true,
+		Transport:        opts.Transport,
+		HTTPTransport:    opts.HTTPTransport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sentry client: %w", err)
+	}
+	return &SentryReporter{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+// ReportPanic sends the panic with its request and user to Sentry
+func (s *SentryReporter) ReportPanic(ctx context.Context, report *Report) {
+	// Each report gets its own hub so concurrent panics don't share a scope
+	hub := s.hub.Clone()
+	scope := hub.Scope()
+	scope.SetLevel(sentry.LevelFatal)
+	if report.Request != nil {
+		scope.SetRequest(report.Request)
+	}
+	if report.RequestID != "" {
+		scope.SetTag("request_id", report.RequestID)
+	}
+	if report.User != "" {
+		scope.SetUser(sentry.User{Username: report.User})
+	}
+	if report.Session != "" {
+		scope.SetTag("session", report.Session)
+	}
+	hub.RecoverWithContext(ctx, report.Value)
+}
+
+// Flush waits until buffered events are sent or the timeout expires
+func (s *SentryReporter) Flush(timeout time.Duration) bool {
+	return s.hub.Flush(timeout)
+}
@@ -0,0 +1,124 @@
+// Package polling derives refresh hints that let clients adapt how often they poll the console.
+package polling
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxBackoffSteps bounds how often the interval is doubled for unchanged data
+	maxBackoffSteps = 4
+	// maxResources bounds the number of tracked resources, e.g. distinct metrics queries
+	maxResources = 1024
+)
+
+// resourceState tracks how often a resource was served without changes
+type resourceState struct {
+	fingerprint uint64
+	unchanged   int
+}
+
+// Advisor suggests polling intervals. The suggestion starts at the base interval,
+// typically the scrape interval, doubles each time a resource is served unchanged
+// and grows with the number of requests the console is serving concurrently.
+// It never exceeds the maximum interval.
+type Advisor struct {
+	loadThreshold int64
+
+	inFlight atomic.Int64
+
+	// mu protects the intervals and resources
+	mu           sync.Mutex
+	baseInterval time.Duration
+	maxInterval  time.Duration
+	resources    map[string]*resourceState
+}
+
+// NewAdvisor creates an advisor suggesting intervals between base and max.
+// Every loadThreshold concurrent requests add another base interval to the suggestion.
+func NewAdvisor(base, maxInterval time.Duration, loadThreshold int) *Advisor {
+	return &Advisor{
+		baseInterval:  base,
+		maxInterval:   max(base, maxInterval),
+		loadThreshold: int64(max(loadThreshold, 1)),
+		resources:     make(map[string]*resourceState),
+	}
+}
+
+// SetBaseInterval changes the interval suggestions start at, e.g. after the scrape interval was reloaded
+func (a *Advisor) SetBaseInterval(base time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.baseInterval = base
+	a.maxInterval = max(base, a.maxInterval)
+}
+
+// Track is a middleware counting the requests in flight as a measure of server load.
+// WebSocket connections are not counted, they stay open without loading the server.
+func (a *Advisor) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.inFlight.Add(1)
+		defer a.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Suggest records the current content of a resource and returns the suggested
+// number of seconds until the client polls it again.
+// A nil advisor suggests nothing and returns 0.
+func (a *Advisor) Suggest(resource string, content any) int {
+	if a == nil {
+		return 0
+	}
+
+	fingerprint, ok := fingerprintOf(content)
+
+	a.mu.Lock()
+	state, found := a.resources[resource]
+	if !found {
+		if len(a.resources) >= maxResources {
+			clear(a.resources)
+		}
+		state = &resourceState{}
+		a.resources[resource] = state
+	}
+	switch {
+	case !ok:
+		state.unchanged = 0
+	case found && state.fingerprint == fingerprint:
+		state.unchanged = min(state.unchanged+1, maxBackoffSteps)
+	default:
+		state.unchanged = 0
+	}
+	state.fingerprint = fingerprint
+	unchanged := state.unchanged
+	base, maxInterval := a.baseInterval, a.maxInterval
+	a.mu.Unlock()
+
+	interval := base << unchanged
+	// The request asking for the suggestion is counted as well
+	interval += base * time.Duration((a.inFlight.Load()-1)/a.loadThreshold)
+	interval = min(interval, maxInterval)
+
+	seconds := int((interval + time.Second - 1) / time.Second)
+	return max(seconds, 1)
+}
+
+// fingerprintOf hashes the JSON representation of content
+func fingerprintOf(content any) (uint64, bool) {
+	h := fnv.New64a()
+	if err := json.NewEncoder(h).Encode(content); err != nil {
+		return 0, false
+	}
+	return h.Sum64(), true
+}
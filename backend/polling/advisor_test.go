@@ -0,0 +1,76 @@
+package polling
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestBacksOffWhileUnchanged(t *testing.T) {
+	a := NewAdvisor(5*time.Second, time.Minute, 10)
+
+	assert.Equal(t, 5, a.Suggest("status", "v1"))
+	assert.Equal(t, 10, a.Suggest("status", "v1"))
+	assert.Equal(t, 20, a.Suggest("status", "v1"))
+	assert.Equal(t, 40, a.Suggest("status", "v1"))
+	// Capped at the maximum interval
+	assert.Equal(t, 60, a.Suggest("status", "v1"))
+	assert.Equal(t, 60, a.Suggest("status", "v1"))
+
+	// A change resets the back-off
+	assert.Equal(t, 5, a.Suggest("status", "v2"))
+
+	// Resources are tracked independently
+	assert.Equal(t, 5, a.Suggest("metrics", "v1"))
+}
+
+func TestSuggestGrowsWithLoad(t *testing.T) {
+	a := NewAdvisor(5*time.Second, time.Minute, 2)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	handler := a.Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	}))
+	for range 4 {
+		go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	for range 4 {
+		<-started
+	}
+
+	// The suggesting request itself is in flight as well, 5 in total
+	var suggestion int
+	a.Track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suggestion = a.Suggest("status", "v1")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	close(release)
+
+	assert.Equal(t, 15, suggestion)
+}
+
+func TestSuggestNilAdvisor(t *testing.T) {
+	var a *Advisor
+	assert.Equal(t, 0, a.Suggest("status", "v1"))
+}
+
+func TestSuggestRoundsUp(t *testing.T) {
+	a := NewAdvisor(1500*time.Millisecond, time.Minute, 10)
+	assert.Equal(t, 2, a.Suggest("status", "v1"))
+}
+
+func TestSetBaseInterval(t *testing.T) {
+	a := NewAdvisor(5*time.Second, time.Minute, 10)
+	assert.Equal(t, 5, a.Suggest("status", "v1"))
+
+	a.SetBaseInterval(15 * time.Second)
+	assert.Equal(t, 30, a.Suggest("status", "v1"))
+
+	// The maximum interval is never below the base interval
+	a.SetBaseInterval(2 * time.Minute)
+	assert.Equal(t, 120, a.Suggest("status", "v2"))
+}
@@ -0,0 +1,477 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxFilterLength bounds the length of a filter
+const MaxFilterLength = 1024
+
+// A filter selects the JSON documents matching a predicate, e.g.
+//
+//	status == "active" && (retries > 3 || !$.flags.archived)
+//
+// Fields are paths as accepted by ParsePath, the leading $. may be left out. They are compared
+// with ==, !=, <, <=, > and >= to strings, numbers, true, false, null or other fields, and
+// combined with &&, || and !. A field on its own matches if it is present and neither false,
+// null, 0 nor "". Comparisons involving a field the document lacks, or values of different types,
+// are false.
+
+// Filter is a parsed filter expression
+type Filter interface {
+	// Match reports whether a decoded JSON document matches
+	Match(doc any) bool
+	// String returns the filter in a canonical form, so filters written differently compare equal
+	String() string
+}
+
+// andFilter matches if both operands match
+type andFilter struct{ left, right Filter }
+
+func (f andFilter) Match(doc any) bool { return f.left.Match(doc) && f.right.Match(doc) }
+
+func (f andFilter) String() string { return "(" + f.left.String() + " && " + f.right.String() + ")" }
+
+// orFilter matches if either operand matches
+type orFilter struct{ left, right Filter }
+
+func (f orFilter) Match(doc any) bool { return f.left.Match(doc) || f.right.Match(doc) }
+
+func (f orFilter) String() string { return "(" + f.left.String() + " || " + f.right.String() + ")" }
+
+// notFilter matches if its operand doesn't
+type notFilter struct{ operand Filter }
+
+func (f notFilter) Match(doc any) bool { return !f.operand.Match(doc) }
+
+func (f notFilter) String() string { return "!" + f.operand.String() }
+
+// truthyFilter matches if the field is present and neither false, null, 0 nor ""
+type truthyFilter struct{ field Path }
+
+func (f truthyFilter) Match(doc any) bool {
+	v, ok := f.field.Lookup(doc)
+	if !ok {
+		return false
+	}
+	switch v := scalar(v).(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+func (f truthyFilter) String() string { return f.field.String() }
+
+// operand is a field of the document or a literal value
+type operand struct {
+	field   *Path
+	literal any
+}
+
+// value returns the value of the operand in the document, or false if the field is missing
+func (o operand) value(doc any) (any, bool) {
+	if o.field == nil {
+		return o.literal, true
+	}
+	v, ok := o.field.Lookup(doc)
+	return scalar(v), ok
+}
+
+// String returns the field, or the literal as written in JSON
+func (o operand) String() string {
+	if o.field != nil {
+		return o.field.String()
+	}
+	encoded, _ := json.Marshal(o.literal)
+	return string(encoded)
+}
+
+// compareFilter matches if the comparison of its operands holds
+type compareFilter struct {
+	left, right operand
+	op          string
+}
+
+func (f compareFilter) Match(doc any) bool {
+	a, ok := f.left.value(doc)
+	if !ok {
+		return false
+	}
+	b, ok := f.right.value(doc)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "==":
+		return equalValues(a, b)
+	case "!=":
+		return sameType(a, b) && !equalValues(a, b)
+	}
+	var c int
+	switch a := a.(type) {
+	case float64:
+		b, ok := b.(float64)
+		if !ok {
+			return false
+		}
+		c = compareOrdered(a, b)
+	case string:
+		b, ok := b.(string)
+		if !ok {
+			return false
+		}
+		c = strings.Compare(a, b)
+	default:
+		return false
+	}
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func (f compareFilter) String() string {
+	return f.left.String() + " " + f.op + " " + f.right.String()
+}
+
+// scalar converts JSON numbers to float64, so they compare with number literals
+func scalar(v any) any {
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// sameType reports whether two scalar values are of the same JSON type
+func sameType(a, b any) bool {
+	switch a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		_, ok := b.(bool)
+		return ok
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	case string:
+		_, ok := b.(string)
+		return ok
+	default:
+		return false
+	}
+}
+
+// equalValues reports whether two scalar values are equal, objects and arrays never are
+func equalValues(a, b any) bool {
+	switch a := a.(type) {
+	case nil:
+		return b == nil
+	case bool, float64, string:
+		return a == b
+	default:
+		return false
+	}
+}
+
+// compareOrdered compares two numbers
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// filterToken is a lexical element of a filter
+type filterToken struct {
+	// kind is one of path, string, number, literal, op or end
+	kind string
+	text string
+	// value is the value of string, number and literal tokens
+	value any
+	pos   int
+}
+
+// filterParser parses filters by recursive descent: || binds weaker than &&, which binds weaker than !
+type filterParser struct {
+	tokens []filterToken
+	next   int
+}
+
+// ParseFilter parses a filter
+func ParseFilter(input string) (Filter, error) {
+	if len(input) > MaxFilterLength {
+		return nil, fmt.Errorf("invalid filter: longer than %d characters", MaxFilterLength)
+	}
+	tokens, err := lexFilter(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "end" {
+		return nil, p.errorAt(t, "unexpected %q", t.text)
+	}
+	return f, nil
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.next] }
+
+func (p *filterParser) take() filterToken {
+	t := p.tokens[p.next]
+	if t.kind != "end" {
+		p.next++
+	}
+	return t
+}
+
+func (p *filterParser) errorAt(t filterToken, format string, args ...any) error {
+	return fmt.Errorf("invalid filter at position %d: %s", t.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "||" && p.peek().kind == "op" {
+		p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "&&" && p.peek().kind == "op" {
+		p.take()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	t := p.peek()
+	if t.kind == "op" && t.text == "!" {
+		p.take()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter{operand: operand}, nil
+	}
+	if t.kind == "op" && t.text == "(" {
+		p.take()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.take(); closing.text != ")" || closing.kind != "op" {
+			return nil, p.errorAt(closing, "expected )")
+		}
+		return f, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (Filter, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == "op" && slices.Contains(comparisonOperators, t.text) {
+		p.take()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if left.field == nil && right.field == nil {
+			return nil, p.errorAt(t, "a comparison needs a field")
+		}
+		return compareFilter{left: left, right: right, op: t.text}, nil
+	}
+	if left.field == nil {
+		return nil, p.errorAt(t, "expected a comparison operator")
+	}
+	return truthyFilter{field: *left.field}, nil
+}
+
+func (p *filterParser) parseOperand() (operand, error) {
+	t := p.take()
+	switch t.kind {
+	case "path":
+		raw := t.text
+		if !strings.HasPrefix(raw, "$") {
+			raw = "$." + raw
+		}
+		field, err := ParsePath(raw)
+		if err != nil {
+			return operand{}, p.errorAt(t, "%v", err)
+		}
+		return operand{field: &field}, nil
+	case "string", "number", "literal":
+		return operand{literal: t.value}, nil
+	case "end":
+		return operand{}, p.errorAt(t, "unexpected end")
+	default:
+		return operand{}, p.errorAt(t, "expected a field or a value, got %q", t.text)
+	}
+}
+
+// comparisonOperators are the operators comparing two operands
+var comparisonOperators = []string{"==", "!=", "<", "<=", ">", ">="}
+
+// filterOperators are the operators of filters, two-character ones first
+var filterOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"}
+
+// lexFilter splits a filter into tokens
+func lexFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			s, n, err := lexString(input[i:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter at position %d: %w", i+1, err)
+			}
+			tokens = append(tokens, filterToken{kind: "string", text: input[i : i+n], value: s, pos: i})
+			i += n
+		case isDigit(c) || (c == '-' && i+1 < len(input) && isDigit(input[i+1])):
+			end := i + 1
+			for end < len(input) && (isDigit(input[end]) || strings.IndexByte(".eE+-", input[end]) >= 0) {
+				end++
+			}
+			n, err := strconv.ParseFloat(input[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter at position %d: invalid number %q", i+1, input[i:end])
+			}
+			tokens = append(tokens, filterToken{kind: "number", text: input[i:end], value: n, pos: i})
+			i = end
+		case c == '$' || c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z'):
+			end, err := lexPath(input, i)
+			if err != nil {
+				return nil, err
+			}
+			text := input[i:end]
+			switch text {
+			case "true", "false":
+				tokens = append(tokens, filterToken{kind: "literal", text: text, value: text == "true", pos: i})
+			case "null":
+				tokens = append(tokens, filterToken{kind: "literal", text: text, pos: i})
+			default:
+				tokens = append(tokens, filterToken{kind: "path", text: text, pos: i})
+			}
+			i = end
+		default:
+			op := ""
+			for _, candidate := range filterOperators {
+				if strings.HasPrefix(input[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("invalid filter at position %d: unexpected %q", i+1, c)
+			}
+			tokens = append(tokens, filterToken{kind: "op", text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, filterToken{kind: "end", pos: len(input)}), nil
+}
+
+// lexPath returns the end of the field path starting at i, including bracketed steps
+func lexPath(input string, i int) (int, error) {
+	end := i + 1
+	for end < len(input) {
+		c := input[end]
+		switch {
+		case c == '.' || isNameChar(c):
+			end++
+		case c == '[':
+			inner := end + 1
+			if inner < len(input) && (input[inner] == '\'' || input[inner] == '"') {
+				_, n, err := lexString(input[inner:])
+				if err != nil {
+					return 0, fmt.Errorf("invalid filter at position %d: %w", inner+1, err)
+				}
+				inner += n
+			}
+			closing := strings.IndexByte(input[inner:], ']')
+			if closing < 0 {
+				return 0, fmt.Errorf("invalid filter at position %d: unterminated bracket", end+1)
+			}
+			end = inner + closing + 1
+		default:
+			return end, nil
+		}
+	}
+	return end, nil
+}
+
+// lexString reads a quoted string at the start of s and returns its content and length.
+// Backslashes escape the quote and themselves.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+// isDigit reports whether c is a decimal digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
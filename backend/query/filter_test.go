@@ -0,0 +1,79 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	doc := map[string]any{
+		"status":  "active",
+		"retries": json.Number("4"),
+		"owner":   map[string]any{"name": "bob", "team": nil},
+		"tags":    []any{"urgent"},
+		"flags":   map[string]any{"archived": false},
+		"limit":   json.Number("4.0"),
+	}
+	tests := []struct {
+		filter  string
+		want    bool
+		wantErr bool
+	}{
+		{filter: `status=="active" && retries>3`, want: true},
+		{filter: `status == 'active' && retries > 4`, want: false},
+		{filter: `$.owner.name == "bob"`, want: true},
+		{filter: `owner.name != "alice"`, want: true},
+		{filter: `tags[0] == "urgent"`, want: true},
+		{filter: `owner.team == null`, want: true},
+		{filter: `flags.archived`, want: false},
+		{filter: `!flags.archived && status`, want: true},
+		{filter: `missing != "x"`, want: false},
+		{filter: `!(missing == "x")`, want: true},
+		{filter: `retries == "4"`, want: false},
+		{filter: `retries != "4"`, want: false},
+		{filter: `retries == limit`, want: true},
+		{filter: `retries >= -1.5e1 || status < "a"`, want: true},
+		{filter: `status == "inactive" || (retries <= 4 && owner)`, want: true},
+		{filter: `status ==`, wantErr: true},
+		{filter: `"a" == "a"`, wantErr: true},
+		{filter: `status = "active"`, wantErr: true},
+		{filter: `(status == "active"`, wantErr: true},
+		{filter: `status == "active`, wantErr: true},
+		{filter: `tags[*] == "urgent"`, wantErr: true},
+		{filter: `status == "active" status`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := ParseFilter(tt.filter)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Match(doc); got != tt.want {
+				t.Errorf("match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterString(t *testing.T) {
+	a, err := ParseFilter(`status=='active'&&retries>3.0 || !$.archived`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseFilter(`(status == "active" && $.retries > 3) || !archived`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Errorf("equivalent filters have different forms %q and %q", a.String(), b.String())
+	}
+	if want := `(($.status == "active" && $.retries > 3) || !$.archived)`; a.String() != want {
+		t.Errorf("String() = %q, want %q", a.String(), want)
+	}
+}
@@ -0,0 +1,128 @@
+// Package query implements the JSONPaths and filter expressions selecting fields and documents
+// among the JSON values of a table.
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pathStep selects a member of an object by name or an element of an array by index
+type pathStep struct {
+	name  string
+	index int
+	array bool
+}
+
+// Path is a JSONPath selecting a single value, e.g. $.user.addresses[0].city
+type Path struct {
+	raw   string
+	steps []pathStep
+}
+
+// ParsePath parses a JSONPath of member names and array indexes. Names are given after a
+// dot, or quoted in brackets if they contain other characters than letters, digits, _ and -,
+// e.g. $['first name']. Wildcards, slices and filters are not supported.
+func ParsePath(raw string) (Path, error) {
+	path := Path{raw: raw}
+	rest, ok := strings.CutPrefix(raw, "$")
+	if !ok {
+		return Path{}, fmt.Errorf("invalid path %q: must start with $", raw)
+	}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := 1
+			for end < len(rest) && isNameChar(rest[end]) {
+				end++
+			}
+			if end == 1 {
+				return Path{}, fmt.Errorf("invalid path %q: empty member name", raw)
+			}
+			path.steps = append(path.steps, pathStep{name: rest[1:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return Path{}, fmt.Errorf("invalid path %q: unterminated bracket", raw)
+			}
+			step, err := parseBracket(rest[1:end])
+			if err != nil {
+				return Path{}, fmt.Errorf("invalid path %q: %w", raw, err)
+			}
+			path.steps = append(path.steps, step)
+			rest = rest[end+1:]
+		default:
+			return Path{}, fmt.Errorf("invalid path %q: unexpected %q", raw, rest[0])
+		}
+	}
+	if len(path.steps) == 0 {
+		return Path{}, fmt.Errorf("invalid path %q: selects the whole value", raw)
+	}
+	return path, nil
+}
+
+// parseBracket parses the content of a bracket, an array index or a quoted member name
+func parseBracket(s string) (pathStep, error) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return pathStep{name: s[1 : len(s)-1]}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 {
+		return pathStep{}, fmt.Errorf("%q is neither an array index nor a quoted member name", s)
+	}
+	return pathStep{index: index, array: true}, nil
+}
+
+// isNameChar reports whether c may appear in a member name following a dot
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// String returns the path as it was written
+func (p Path) String() string {
+	return p.raw
+}
+
+// Lookup returns the value the path selects in a decoded JSON document
+func (p Path) Lookup(doc any) (any, bool) {
+	v := doc
+	for _, step := range p.steps {
+		switch node := v.(type) {
+		case map[string]any:
+			if step.array {
+				return nil, false
+			}
+			var ok bool
+			if v, ok = node[step.name]; !ok {
+				return nil, false
+			}
+		case []any:
+			if !step.array || step.index >= len(node) {
+				return nil, false
+			}
+			v = node[step.index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// DecodeDocument decodes a JSON value keeping numbers as written
+func DecodeDocument(value string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errors.New("value is not a JSON document")
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("value is not a single JSON document")
+	}
+	return doc, nil
+}
@@ -0,0 +1,44 @@
+package query
+
+import "testing"
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []pathStep
+		wantErr bool
+	}{
+		{path: "$.user.name", want: []pathStep{{name: "user"}, {name: "name"}}},
+		{path: "$.items[2].sku", want: []pathStep{{name: "items"}, {index: 2, array: true}, {name: "sku"}}},
+		{path: "$['first name']", want: []pathStep{{name: "first name"}}},
+		{path: `$["a.b"][0]`, want: []pathStep{{name: "a.b"}, {index: 0, array: true}}},
+		{path: "user.name", wantErr: true},
+		{path: "$", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$.items[*]", wantErr: true},
+		{path: "$.items[-1]", wantErr: true},
+		{path: "$.items[0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParsePath(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", got.steps)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.steps) != len(tt.want) {
+				t.Fatalf("Expected steps %+v, got %+v", tt.want, got.steps)
+			}
+			for i := range tt.want {
+				if got.steps[i] != tt.want[i] {
+					t.Errorf("Expected steps %+v, got %+v", tt.want, got.steps)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package reload
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+)
+
+// Certificate is a TLS certificate that can be replaced while the server is running,
+// e.g. after it was renewed
+type Certificate struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// LoadCertificate reads a certificate and its private key from PEM encoded files
+func LoadCertificate(certFile, keyFile string) (*Certificate, error) {
+	c := &Certificate{}
+	if err := c.Load(certFile, keyFile); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load replaces the certificate with the one in the files. The current certificate is kept if they can't be read.
+func (c *Certificate) Load(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	return nil
+}
+
+// GetCertificate returns the current certificate, it is meant for tls.Config.GetCertificate
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
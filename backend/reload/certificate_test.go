@@ -0,0 +1,66 @@
+package reload
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate for name and its key into dir
+func writeCertificate(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "old.example.com")
+	cert, err := LoadCertificate(certFile, keyFile)
+	require.NoError(t, err)
+
+	commonName := func() string {
+		current, err := cert.GetCertificate(nil)
+		require.NoError(t, err)
+		parsed, err := x509.ParseCertificate(current.Certificate[0])
+		require.NoError(t, err)
+		return parsed.Subject.CommonName
+	}
+	assert.Equal(t, "old.example.com", commonName())
+
+	// A renewed certificate replaces the current one
+	writeCertificate(t, dir, "new.example.com")
+	require.NoError(t, cert.Load(certFile, keyFile))
+	assert.Equal(t, "new.example.com", commonName())
+
+	// A broken certificate is rejected and the current one kept
+	require.NoError(t, os.WriteFile(certFile, []byte("garbage"), 0o600))
+	assert.Error(t, cert.Load(certFile, keyFile))
+	assert.Equal(t, "new.example.com", commonName())
+}
@@ -0,0 +1,183 @@
+// Package reload applies a changed configuration to the running console, e.g. on SIGHUP.
+// Components register hooks for the settings they can change in place. Changes of all
+// other settings are reported and take effect after a restart, so in-memory state such
+// as the head of the TSDB survives a reload.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/config"
+	"go.uber.org/zap"
+)
+
+// Loader loads and validates the configuration
+type Loader func() (*config.Config, error)
+
+// Hook applies the settings of a reloaded configuration
+type Hook func(ctx context.Context, cfg *config.Config) error
+
+// Result describes the outcome of a reload
+type Result struct {
+	Time time.Time `json:"time"`
+	// Applied are the keys of the changed settings that took effect
+	Applied []string `json:"applied"`
+	// RestartRequired are the keys of the changed settings that take effect after a restart
+	RestartRequired []string `json:"restartRequired"`
+	// Errors are the failures of hooks, their settings may be applied partially or not at all
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+// hook is a registered Hook and the settings it applies
+type hook struct {
+	name   string
+	keys   []string
+	always bool
+	apply  Hook
+}
+
+// Reloader reloads the configuration and runs the hooks of the changed settings
+type Reloader struct {
+	load   Loader
+	logger *zap.Logger
+
+	// mu serializes reloads and protects current, loaded and hooks
+	mu sync.Mutex
+	// current is the configuration in effect: the one the console was started with and the
+	// settings applied by reloads since
+	current *config.Config
+	// loaded is the configuration loaded last, including settings requiring a restart and
+	// settings whose hooks failed
+	loaded *config.Config
+	hooks  []hook
+}
+
+// NewReloader creates a reloader for the configuration cfg the console was started with
+func NewReloader(cfg *config.Config, load Loader, logger *zap.Logger) *Reloader {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Reloader{
+		load:    load,
+		logger:  logger.Named("reload"),
+		current: cfg,
+		loaded:  cfg,
+	}
+}
+
+// Handle registers a hook applying the settings with the given keys.
+// It runs when a reload changes one of them.
+func (r *Reloader) Handle(name string, apply Hook, keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook{name: name, keys: keys, apply: apply})
+}
+
+// HandleAlways registers a hook applying the settings with the given keys that runs on
+// every reload, e.g. to re-read files that may have changed under the same path.
+func (r *Reloader) HandleAlways(name string, apply Hook, keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook{name: name, keys: keys, always: true, apply: apply})
+}
+
+// Current returns the configuration in effect. Settings of reloads that require a restart or
+// whose hooks failed keep the values they had before.
+func (r *Reloader) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Loaded returns the configuration loaded last, which takes effect completely after a restart
+func (r *Reloader) Loaded() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loaded
+}
+
+// Reload loads the configuration and applies the changed settings. If the configuration
+// can't be loaded or is invalid, an error is returned and nothing changes.
+func (r *Reloader) Reload(ctx context.Context) (Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		r.logger.Error("Failed to reload configuration, keeping the current one", zap.Error(err))
+		return Result{}, fmt.Errorf("failed to reload configuration: %w", err)
+	}
+
+	changed := r.current.Changed(next)
+	result := Result{
+		Time:            time.Now(),
+		Applied:         []string{},
+		RestartRequired: []string{},
+		Errors:          []string{},
+		Warnings:        next.Warnings(),
+	}
+	if result.Warnings == nil {
+		result.Warnings = []string{}
+	}
+	handled := make(map[string]bool)
+	for _, h := range r.hooks {
+		var keys []string
+		for _, key := range h.keys {
+			handled[key] = true
+			if slices.Contains(changed, key) {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 && !h.always {
+			continue
+		}
+		if err := h.apply(ctx, next); err != nil {
+			r.logger.Error("Failed to apply reloaded settings", zap.String("hook", h.name), zap.Error(err))
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", h.name, err))
+			continue
+		}
+		result.Applied = append(result.Applied, keys...)
+	}
+	for _, key := range changed {
+		if !handled[key] {
+			result.RestartRequired = append(result.RestartRequired, key)
+		}
+	}
+	slices.Sort(result.Applied)
+	// Only the applied settings take effect, the others are reported as changed again on the
+	// next reload, so failed hooks are retried
+	r.current = r.current.With(next, result.Applied)
+	r.loaded = next
+
+	r.logger.Info("Reloaded configuration",
+		zap.Strings("applied", result.Applied),
+		zap.Strings("restartRequired", result.RestartRequired),
+		zap.Int("errors", len(result.Errors)))
+	return result, nil
+}
+
+// Notify reloads the configuration whenever one of the signals is received, until ctx is done
+func (r *Reloader) Notify(ctx context.Context, signals ...os.Signal) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, signals...)
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case s := <-sig:
+				r.logger.Info("Received reload signal", zap.String("signal", s.String()))
+				// Failures are logged, the current configuration stays in effect
+				_, _ = r.Reload(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,124 @@
+package reload
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadEnv returns a loader reading the configuration from the environment in env
+func loadEnv(env map[string]string) Loader {
+	return func() (*config.Config, error) {
+		cfg, err := config.Load("", func(name string) (string, bool) {
+			v, ok := env[name]
+			return v, ok
+		})
+		if err != nil {
+			return nil, err
+		}
+		return cfg, cfg.Validate()
+	}
+}
+
+func TestReload(t *testing.T) {
+	env := map[string]string{}
+	cfg, err := loadEnv(env)()
+	require.NoError(t, err)
+	r := NewReloader(cfg, loadEnv(env), nil)
+
+	var levels []string
+	r.Handle("log", func(_ context.Context, cfg *config.Config) error {
+		levels = append(levels, cfg.Log.Level)
+		return nil
+	}, "log.level")
+	tlsRuns := 0
+	r.HandleAlways("tls", func(context.Context, *config.Config) error {
+		tlsRuns++
+		return nil
+	}, "server.tlsCertFile", "server.tlsKeyFile")
+	r.Handle("metrics", func(context.Context, *config.Config) error {
+		return errors.New("collector is stopped")
+	}, "metrics.scrapeInterval")
+
+	// Nothing changed, only the hook running on every reload runs
+	result, err := r.Reload(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.RestartRequired)
+	assert.Empty(t, levels)
+	assert.Equal(t, 1, tlsRuns)
+
+	env["LOG_LEVEL"] = "warn"
+	env["SCRAPE_INTERVAL"] = "15s"
+	env["METRICS_RETENTION"] = "48h"
+	result, err = r.Reload(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log.level"}, result.Applied)
+	assert.Equal(t, []string{"metrics.retention"}, result.RestartRequired)
+	assert.Equal(t, []string{"metrics: collector is stopped"}, result.Errors)
+	assert.Equal(t, []string{"warn"}, levels)
+	assert.Equal(t, 2, tlsRuns)
+
+	// Only the applied settings are in effect, the others are in the loaded configuration
+	assert.Equal(t, "warn", r.Current().Log.Level)
+	assert.Equal(t, config.SourceEnv, r.Current().Source("log.level"))
+	assert.Equal(t, cfg.Metrics.ScrapeInterval, r.Current().Metrics.ScrapeInterval)
+	assert.Equal(t, cfg.Metrics.Retention, r.Current().Metrics.Retention)
+	assert.Equal(t, 48*time.Hour, r.Loaded().Metrics.Retention)
+	assert.NotEqual(t, "warn", cfg.Log.Level, "the configuration the console was started with is kept")
+
+	// Settings that didn't take effect are still changed, failed hooks are retried
+	result, err = r.Reload(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, []string{"metrics.retention"}, result.RestartRequired)
+	assert.Equal(t, []string{"metrics: collector is stopped"}, result.Errors)
+	assert.Equal(t, []string{"warn"}, levels)
+}
+
+func TestReloadInvalidConfiguration(t *testing.T) {
+	env := map[string]string{}
+	cfg, err := loadEnv(env)()
+	require.NoError(t, err)
+	r := NewReloader(cfg, loadEnv(env), nil)
+	r.Handle("log", func(context.Context, *config.Config) error {
+		t.Error("hooks must not run for an invalid configuration")
+		return nil
+	}, "log.level")
+
+	env["LOG_LEVEL"] = "verbose"
+	_, err = r.Reload(context.Background())
+	assert.Error(t, err)
+	assert.Same(t, cfg, r.Current())
+}
+
+func TestNotify(t *testing.T) {
+	env := map[string]string{}
+	cfg, err := loadEnv(env)()
+	require.NoError(t, err)
+	r := NewReloader(cfg, loadEnv(env), nil)
+	reloaded := make(chan string, 1)
+	r.Handle("log", func(_ context.Context, cfg *config.Config) error {
+		reloaded <- cfg.Log.Level
+		return nil
+	}, "log.level")
+
+	env["LOG_LEVEL"] = "error"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Notify(ctx, syscall.SIGHUP)
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+	select {
+	case level := <-reloaded:
+		assert.Equal(t, "error", level)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration was not reloaded on SIGHUP")
+	}
+}
@@ -0,0 +1,185 @@
+// Package render writes HTTP responses while bounding the memory used for buffering.
+// Small responses are buffered completely and sent with a Content-Length header.
+// Once a response grows beyond the configured buffer size it is streamed to the
+// client using chunked transfer encoding, flushing every time the flush threshold
+// is reached, so large query results and exports never have to fit in memory at once.
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	// contentTypeJSON is the content type used for JSON responses unless the handler set one
+	contentTypeJSON = "application/json; charset=utf-8"
+
+	// DefaultMaxBuffer is the default number of bytes buffered before a response is streamed
+	DefaultMaxBuffer = 1 << 20
+
+	// DefaultFlushThreshold is the default number of bytes written between flushes when streaming
+	DefaultFlushThreshold = 64 << 10
+)
+
+// Options controls response buffering.
+type Options struct {
+	// MaxBuffer is the maximum number of bytes held in memory before the response is streamed.
+	MaxBuffer int
+	// FlushThreshold is the number of bytes written between flushes once streaming.
+	FlushThreshold int
+}
+
+// defaults holds the options used by JSON and JSONArray
+var defaults atomic.Pointer[Options]
+
+func init() {
+	defaults.Store(&Options{
+		MaxBuffer:      DefaultMaxBuffer,
+		FlushThreshold: DefaultFlushThreshold,
+	})
+}
+
+// Configure sets the options used by all subsequent responses.
+// Non-positive values are replaced with the package defaults.
+func Configure(opts Options) {
+	if opts.MaxBuffer <= 0 {
+		opts.MaxBuffer = DefaultMaxBuffer
+	}
+	if opts.FlushThreshold <= 0 {
+		opts.FlushThreshold = DefaultFlushThreshold
+	}
+	defaults.Store(&opts)
+}
+
+// ErrStreamAborted is returned when encoding fails after part of the response was already sent.
+// The status code cannot be changed at that point, so the response is truncated.
+var ErrStreamAborted = errors.New("response aborted after streaming started")
+
+// JSON encodes v as the JSON response body with the given status code.
+// If encoding fails before anything was sent, a 500 error is written instead.
+func JSON(w http.ResponseWriter, status int, v any) error {
+	bw := newBoundedWriter(w, status, *defaults.Load())
+	if err := json.NewEncoder(bw).Encode(v); err != nil {
+		return bw.fail(err)
+	}
+	return bw.finish()
+}
+
+// JSONArray streams items as a JSON array, encoding one element at a time.
+// Unlike JSON it never needs the encoded form of the whole slice in memory.
+func JSONArray[T any](w http.ResponseWriter, status int, items []T) error {
+	bw := newBoundedWriter(w, status, *defaults.Load())
+	enc := json.NewEncoder(bw)
+
+	if _, err := bw.Write([]byte{'['}); err != nil {
+		return bw.fail(err)
+	}
+	for i := range items {
+		if i > 0 {
+			if _, err := bw.Write([]byte{','}); err != nil {
+				return bw.fail(err)
+			}
+		}
+		if err := enc.Encode(items[i]); err != nil {
+			return bw.fail(err)
+		}
+	}
+	if _, err := bw.Write([]byte("]\n")); err != nil {
+		return bw.fail(err)
+	}
+	return bw.finish()
+}
+
+// boundedWriter buffers a response up to a limit and streams it once the limit is exceeded
+type boundedWriter struct {
+	w      http.ResponseWriter
+	status int
+	opts   Options
+
+	buf       []byte
+	streaming bool
+	unflushed int
+}
+
+// newBoundedWriter creates a writer for a single response
+func newBoundedWriter(w http.ResponseWriter, status int, opts Options) *boundedWriter {
+	return &boundedWriter{
+		w:      w,
+		status: status,
+		opts:   opts,
+	}
+}
+
+// Write buffers p, switching to streaming when the buffer would exceed its limit
+func (b *boundedWriter) Write(p []byte) (int, error) {
+	if !b.streaming {
+		if len(b.buf)+len(p) <= b.opts.MaxBuffer {
+			b.buf = append(b.buf, p...)
+			return len(p), nil
+		}
+		if err := b.startStreaming(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := b.w.Write(p)
+	b.unflushed += n
+	if b.unflushed >= b.opts.FlushThreshold {
+		b.flush()
+	}
+	return n, err
+}
+
+// startStreaming sends the headers and the buffered data without a Content-Length
+func (b *boundedWriter) startStreaming() error {
+	b.streaming = true
+	b.setContentType()
+	b.w.WriteHeader(b.status)
+
+	buffered := b.buf
+	b.buf = nil
+	_, err := b.w.Write(buffered)
+	b.flush()
+	return err
+}
+
+// flush pushes the written data to the client if the response writer supports it
+func (b *boundedWriter) flush() {
+	if f, ok := b.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	b.unflushed = 0
+}
+
+// finish completes the response
+func (b *boundedWriter) finish() error {
+	if b.streaming {
+		b.flush()
+		return nil
+	}
+
+	b.setContentType()
+	b.w.Header().Set("Content-Length", strconv.Itoa(len(b.buf)))
+	b.w.WriteHeader(b.status)
+	_, err := b.w.Write(b.buf)
+	return err
+}
+
+// fail reports an encoding error, answering with 500 if nothing has been sent yet
+func (b *boundedWriter) fail(err error) error {
+	if b.streaming {
+		return errors.Join(ErrStreamAborted, err)
+	}
+	http.Error(b.w, err.Error(), http.StatusInternalServerError)
+	return err
+}
+
+// setContentType sets the JSON content type unless the handler already chose one
+func (b *boundedWriter) setContentType() {
+	if b.w.Header().Get("Content-Type") == "" {
+		b.w.Header().Set("Content-Type", contentTypeJSON)
+	}
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withOptions configures the package for the duration of a test
+func withOptions(t *testing.T, opts Options) {
+	t.Helper()
+	previous := *defaults.Load()
+	Configure(opts)
+	t.Cleanup(func() { Configure(previous) })
+}
+
+func TestJSONSmallResponseIsBuffered(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	err := JSON(rr, http.StatusCreated, map[string]string{"name": "table1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "18", rr.Header().Get("Content-Length"))
+	assert.False(t, rr.Flushed)
+	assert.JSONEq(t, `{"name":"table1"}`, rr.Body.String())
+}
+
+func TestJSONKeepsContentType(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set("Content-Type", "application/json")
+
+	require.NoError(t, JSON(rr, http.StatusOK, []int{1, 2, 3}))
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+}
+
+func TestJSONLargeResponseIsStreamed(t *testing.T) {
+	withOptions(t, Options{MaxBuffer: 64, FlushThreshold: 32})
+	rr := httptest.NewRecorder()
+
+	value := strings.Repeat("x", 500)
+	require.NoError(t, JSON(rr, http.StatusOK, map[string]string{"value": value}))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Content-Length"))
+	assert.True(t, rr.Flushed)
+
+	var decoded map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &decoded))
+	assert.Equal(t, value, decoded["value"])
+}
+
+func TestJSONArray(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		require.NoError(t, JSONArray[string](rr, http.StatusOK, nil))
+		assert.JSONEq(t, `[]`, rr.Body.String())
+	})
+
+	t.Run("Streamed", func(t *testing.T) {
+		withOptions(t, Options{MaxBuffer: 16, FlushThreshold: 16})
+		rr := httptest.NewRecorder()
+
+		items := make([]map[string]int, 100)
+		for i := range items {
+			items[i] = map[string]int{"i": i}
+		}
+		require.NoError(t, JSONArray(rr, http.StatusOK, items))
+
+		assert.True(t, rr.Flushed)
+		var decoded []map[string]int
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &decoded))
+		assert.Equal(t, items, decoded)
+	})
+}
+
+func TestJSONEncodeError(t *testing.T) {
+	t.Run("BeforeStreaming", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		err := JSON(rr, http.StatusOK, map[string]any{"bad": make(chan int)})
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrStreamAborted)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+
+	t.Run("AfterStreaming", func(t *testing.T) {
+		withOptions(t, Options{MaxBuffer: 16, FlushThreshold: 16})
+		rr := httptest.NewRecorder()
+
+		items := []any{strings.Repeat("x", 64), make(chan int)}
+		err := JSONArray(rr, http.StatusOK, items)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrStreamAborted))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestConfigureDefaults(t *testing.T) {
+	withOptions(t, Options{})
+	assert.Equal(t, Options{MaxBuffer: DefaultMaxBuffer, FlushThreshold: DefaultFlushThreshold}, *defaults.Load())
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Format is a tabular format responses can be exported in
+type Format string
+
+const (
+	// CSV is comma separated values as described in RFC 4180
+	CSV Format = "csv"
+	// XLSX is an Office Open XML workbook with a single sheet
+	XLSX Format = "xlsx"
+)
+
+// contentTypes are the content types of the tabular formats
+var contentTypes = map[Format]string{
+	CSV:  "text/csv; charset=utf-8",
+	XLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// TableFormat returns the tabular format selected by the format query parameter.
+// JSON, the default, is reported as not ok; unknown formats are an error.
+func TableFormat(r *http.Request) (Format, bool, error) {
+	switch format := Format(r.URL.Query().Get("format")); format {
+	case "", "json":
+		return "", false, nil
+	case CSV, XLSX:
+		return format, true, nil
+	default:
+		return "", false, fmt.Errorf("unsupported format %q, use json, csv or xlsx", format)
+	}
+}
+
+// Column is a column of a table
+type Column struct {
+	Name string
+	// Numeric columns are written as numbers where their values parse as one, so spreadsheets
+	// can calculate with them
+	Numeric bool
+}
+
+// tableWriter writes the rows of a table in a format
+type tableWriter interface {
+	row(cells []string) error
+	close() error
+}
+
+// Table streams the rows as an attachment in the format, named filename with the extension of
+// the format. Like JSON the response is buffered up to a limit and streamed beyond it, so
+// exports of any size never have to fit in memory at once.
+func Table(w http.ResponseWriter, format Format, filename string, columns []Column, rows iter.Seq[[]string]) error {
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": filename + "." + string(format),
+	}))
+	bw := newBoundedWriter(w, http.StatusOK, *defaults.Load())
+
+	var tw tableWriter
+	if format == XLSX {
+		tw = newXLSXWriter(bw, columns)
+	} else {
+		tw = newCSVWriter(bw, columns)
+	}
+	err := writeTable(tw, columns, rows)
+	if err != nil {
+		if !bw.streaming {
+			w.Header().Del("Content-Disposition")
+		}
+		return bw.fail(err)
+	}
+	return bw.finish()
+}
+
+// writeTable writes the header and the rows of a table
+func writeTable(tw tableWriter, columns []Column, rows iter.Seq[[]string]) error {
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+	}
+	if err := tw.row(header); err != nil {
+		return err
+	}
+	for cells := range rows {
+		if err := tw.row(cells); err != nil {
+			return err
+		}
+	}
+	return tw.close()
+}
+
+// number returns the value of a cell of a numeric column, ok is false for cells that aren't
+// finite numbers
+func number(column Column, cell string) (float64, bool) {
+	if !column.Numeric {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(cell, 64)
+	return f, err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+}
+
+// neutralize prefixes text that spreadsheets would evaluate as a formula with a quote, so an
+// exported key or label can't run a formula when the file is opened
+func neutralize(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	return "'" + cell
+}
+
+// csvWriter writes tables as CSV
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+	header  bool
+}
+
+func newCSVWriter(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns, header: true}
+}
+
+func (c *csvWriter) row(cells []string) error {
+	if c.header {
+		c.header = false
+		return c.w.Write(cells)
+	}
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		if i < len(c.columns) {
+			if _, ok := number(c.columns[i], cell); ok {
+				escaped[i] = cell
+				continue
+			}
+		}
+		escaped[i] = neutralize(cell)
+	}
+	return c.w.Write(escaped)
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportColumns = []Column{{Name: "key"}, {Name: "value", Numeric: true}}
+
+var exportRows = [][]string{
+	{"user/1", "42"},
+	{`=HYPERLINK("http://example.com")`, "-1.5"},
+	{"-quoted, \"comma\"\nnewline", "NaN"},
+}
+
+func TestTableFormat(t *testing.T) {
+	for query, want := range map[string]Format{"": "", "format=json": "", "format=csv": CSV, "format=xlsx": XLSX} {
+		format, ok, err := TableFormat(httptest.NewRequest("GET", "/?"+query, nil))
+		require.NoError(t, err, query)
+		assert.Equal(t, want, format, query)
+		assert.Equal(t, want != "", ok, query)
+	}
+	_, _, err := TableFormat(httptest.NewRequest("GET", "/?format=xml", nil))
+	assert.Error(t, err)
+}
+
+func TestTableCSV(t *testing.T) {
+	rr := httptest.NewRecorder()
+	require.NoError(t, Table(rr, CSV, "keys users", exportColumns, slices.Values(exportRows)))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="keys users.csv"`, rr.Header().Get("Content-Disposition"))
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"key", "value"},
+		{"user/1", "42"},
+		{`'=HYPERLINK("http://example.com")`, "-1.5"},
+		{"'-quoted, \"comma\"\nnewline", "NaN"},
+	}, records, "formulas are neutralized, numbers are kept")
+}
+
+func TestTableXLSX(t *testing.T) {
+	withOptions(t, Options{MaxBuffer: 64, FlushThreshold: 32})
+	rr := httptest.NewRecorder()
+	require.NoError(t, Table(rr, XLSX, "query", exportColumns, slices.Values(exportRows)))
+
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", rr.Header().Get("Content-Type"))
+	assert.True(t, rr.Flushed, "large workbooks are streamed")
+
+	body := rr.Body.Bytes()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	var sheet string
+	for _, f := range archive.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			sheet = string(data)
+		}
+	}
+	assert.Len(t, archive.File, 5)
+	assert.Contains(t, sheet, `<c r="B1" t="inlineStr"><is><t xml:space="preserve">value</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>42</v></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">=HYPERLINK(&#34;http://example.com&#34;)</t>`)
+	assert.Contains(t, sheet, `<c r="B4" t="inlineStr"><is><t xml:space="preserve">NaN</t></is></c>`)
+	assert.True(t, strings.HasSuffix(sheet, `</sheetData></worksheet>`))
+}
+
+func TestColumnName(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, want, columnName(index))
+	}
+}
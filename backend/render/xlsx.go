@@ -0,0 +1,126 @@
+package render
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCellLength is the number of characters a cell of a spreadsheet holds, longer text is cut
+const maxCellLength = 32767
+
+// The parts of a workbook besides its sheet, which is streamed
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxWriter writes tables as a workbook. Text is stored inline in the cells rather than in a
+// table of shared strings, so rows are written as they come. Spreadsheets never evaluate inline
+// strings, so unlike CSV they need no protection against formulas.
+type xlsxWriter struct {
+	zw      *zip.Writer
+	sheet   *bufio.Writer
+	columns []Column
+	rows    int
+	err     error
+}
+
+func newXLSXWriter(w io.Writer, columns []Column) *xlsxWriter {
+	x := &xlsxWriter{zw: zip.NewWriter(w), columns: columns}
+	for _, part := range xlsxParts {
+		var pw io.Writer
+		if pw, x.err = x.zw.Create(part.name); x.err != nil {
+			return x
+		}
+		if _, x.err = io.WriteString(pw, part.content); x.err != nil {
+			return x
+		}
+	}
+	var sheet io.Writer
+	if sheet, x.err = x.zw.Create("xl/worksheets/sheet1.xml"); x.err != nil {
+		return x
+	}
+	x.sheet = bufio.NewWriter(sheet)
+	_, x.err = x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return x
+}
+
+func (x *xlsxWriter) row(cells []string) error {
+	if x.err != nil {
+		return x.err
+	}
+	header := x.rows == 0
+	x.rows++
+	row := strconv.Itoa(x.rows)
+	x.sheet.WriteString(`<row r="` + row + `">`)
+	for i, cell := range cells {
+		ref := columnName(i) + row
+		if !header && i < len(x.columns) {
+			if _, ok := number(x.columns[i], cell); ok {
+				x.sheet.WriteString(`<c r="` + ref + `"><v>` + cell + `</v></c>`)
+				continue
+			}
+		}
+		x.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+		if x.err = xml.EscapeText(x.sheet, []byte(cutCell(cell))); x.err != nil {
+			return x.err
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, x.err = x.sheet.WriteString(`</row>`)
+	return x.err
+}
+
+func (x *xlsxWriter) close() error {
+	if x.err != nil {
+		return x.err
+	}
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+// columnName returns the name of the column at the index, e.g. A, Z, AA
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// cutCell makes a cell valid UTF-8 and cuts it to the length spreadsheets hold
+func cutCell(cell string) string {
+	cell = strings.ToValidUTF8(cell, "�")
+	if utf8.RuneCountInString(cell) <= maxCellLength {
+		return cell
+	}
+	return string([]rune(cell)[:maxCellLength])
+}
@@ -0,0 +1,291 @@
+package replay
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-rat/chix"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	// replayTimeout bounds the execution of a replayed request
+	replayTimeout = 30 * time.Second
+	// maxResponseBytes is the largest response body returned by a replay
+	maxResponseBytes = 1 << 20
+)
+
+// Target serves replayed requests against a cluster. Every gRPC call made to the cluster
+// must be traced with the given provider. The returned function releases the target.
+// It returns cluster.ErrClusterNotFound if the cluster is not registered.
+type Target func(ctx context.Context, cluster string, provider trace.TracerProvider) (http.Handler, func() error, error)
+
+// ReplayRequest selects the cluster a recorded request is replayed against
+type ReplayRequest struct {
+	// Cluster is the name of the cluster, the default cluster if empty
+	Cluster string `json:"cluster"`
+}
+
+// Span is a timed operation of a replayed request, e.g. a gRPC call
+type Span struct {
+	Name     string `json:"name"`
+	SpanID   string `json:"spanId"`
+	ParentID string `json:"parentId,omitempty"`
+	// Offset is the time between the start of the replay and the start of the span
+	Offset     time.Duration     `json:"offset"`
+	Duration   time.Duration     `json:"duration"`
+	Status     string            `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Result is the outcome of a replayed request
+type Result struct {
+	Request Request `json:"request"`
+	Cluster string  `json:"cluster"`
+	Status  int     `json:"status"`
+	Body    string  `json:"body"`
+	// BodyTruncated is set when the response exceeded the returned size
+	BodyTruncated bool          `json:"bodyTruncated,omitempty"`
+	Duration      time.Duration `json:"duration"`
+	// Spans are ordered by their start
+	Spans []Span `json:"spans"`
+}
+
+// Handler lists recorded requests and replays them
+type Handler struct {
+	recorder       *Recorder
+	target         Target
+	defaultCluster string
+	logger         *zap.Logger
+}
+
+// NewHandler creates a new replay API handler. Requests are replayed against the default
+// cluster unless another one is chosen.
+func NewHandler(recorder *Recorder, target Target, defaultCluster string, logger *zap.Logger) *Handler {
+	return &Handler{
+		recorder:       recorder,
+		target:         target,
+		defaultCluster: defaultCluster,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes registers the replay routes
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/api/debug/requests", h.operator(h.handleList))
+	r.Get("/api/debug/requests/{id}", h.operator(h.handleGet))
+	r.Post("/api/debug/replay/{id}", h.operator(h.handleReplay))
+}
+
+// operator restricts a handler to operators, recorded requests may reveal what other users did
+func (h *Handler) operator(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if role, ok := auth.RoleFromContext(r.Context()); ok && role != auth.RoleOperator {
+			http.Error(w, "Forbidden: the operator role is required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleList lists the recorded requests
+// @Summary List recorded requests
+// @Description List the recently recorded API requests, most recent first
+// @Tags debug
+// @Produce json
+// @Success 200 {array} replay.Request
+// @Failure 403 {string} string "The operator role is required"
+// @Router /api/debug/requests [get]
+func (h *Handler) handleList(w http.ResponseWriter, r *http.Request) {
+	chix.NewRender(w).JSON(h.recorder.List())
+}
+
+// handleGet returns a recorded request
+// @Summary Get recorded request
+// @Description Get a recorded API request with its sanitized body
+// @Tags debug
+// @Produce json
+// @Param id path string true "Request ID"
+// @Success 200 {object} replay.Request
+// @Failure 403 {string} string "The operator role is required"
+// @Failure 404 {string} string "Request not found"
+// @Router /api/debug/requests/{id} [get]
+func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.recorder.Get(chi.URLParam(r, "id"))
+	if !ok {
+		http.Error(w, "Request not found", http.StatusNotFound)
+		return
+	}
+	chix.NewRender(w).JSON(req)
+}
+
+// handleReplay re-executes a recorded request and reports its timing and gRPC calls
+// @Summary Replay recorded request
+// @Description Re-execute a recorded API request against a cluster, capturing the response, timing and gRPC traces
+// @Tags debug
+// @Accept json
+// @Produce json
+// @Param id path string true "Request ID"
+// @Param request body ReplayRequest false "Target cluster"
+// @Success 200 {object} replay.Result
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 403 {string} string "The operator role is required"
+// @Failure 404 {string} string "Request or cluster not found"
+// @Failure 409 {string} string "Request was redacted or truncated"
+// @Failure 502 {string} string "Failed to connect to the cluster"
+// @Router /api/debug/replay/{id} [post]
+func (h *Handler) handleReplay(w http.ResponseWriter, r *http.Request) {
+	recorded, ok := h.recorder.Get(chi.URLParam(r, "id"))
+	if !ok {
+		http.Error(w, "Request not found", http.StatusNotFound)
+		return
+	}
+	if !recorded.Replayable() {
+		// Replaying with placeholders instead of the original values would change what the request does
+		http.Error(w, "Request was redacted or truncated and can't be replayed", http.StatusConflict)
+		return
+	}
+	var req ReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Cluster == "" {
+		req.Cluster = h.defaultCluster
+	}
+
+	collector := &spanCollector{}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(collector),
+	)
+	defer func() {
+		_ = provider.Shutdown(context.Background())
+	}()
+
+	// The replay gets a fresh route context, chi would otherwise reuse the one of this request
+	ctx, cancel := context.WithTimeout(context.WithValue(r.Context(), chi.RouteCtxKey, chi.NewRouteContext()), replayTimeout)
+	defer cancel()
+
+	target, release, err := h.target(ctx, req.Cluster, provider)
+	if errors.Is(err, cluster.ErrClusterNotFound) {
+		http.Error(w, "Cluster not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to connect to the replay cluster", zap.Error(err), zap.String("cluster", req.Cluster))
+		http.Error(w, "Failed to connect to the cluster", http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		if err := release(); err != nil {
+			h.logger.Warn("Failed to release the replay cluster", zap.Error(err), zap.String("cluster", req.Cluster))
+		}
+	}()
+
+	start := time.Now()
+	ctx, span := provider.Tracer("github.com/armadakv/console/backend/replay").Start(ctx, recorded.Method+" "+recorded.Path)
+	replayed := httptest.NewRequestWithContext(ctx, recorded.Method, recorded.Path, strings.NewReader(recorded.Body))
+	if recorded.ContentType != "" {
+		replayed.Header.Set("Content-Type", recorded.ContentType)
+	}
+	response := httptest.NewRecorder()
+	target.ServeHTTP(response, replayed)
+	duration := time.Since(start)
+	span.SetAttributes(attribute.Int("http.response.status_code", response.Code))
+	if response.Code >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(response.Code))
+	}
+	span.End()
+
+	h.logger.Info("Replayed request",
+		zap.String("id", recorded.ID),
+		zap.String("path", recorded.Path),
+		zap.String("cluster", req.Cluster),
+		zap.String("user", auth.UserName(r.Context())),
+		zap.Int("status", response.Code),
+		zap.Duration("duration", duration))
+
+	result := Result{
+		Request:  recorded,
+		Cluster:  req.Cluster,
+		Status:   response.Code,
+		Duration: duration,
+		Spans:    collector.result(start),
+	}
+	body := response.Body.Bytes()
+	if len(body) > maxResponseBytes {
+		body = body[:maxResponseBytes]
+		result.BodyTruncated = true
+	}
+	result.Body = string(bytes.ToValidUTF8(body, nil))
+	chix.NewRender(w).JSON(result)
+}
+
+// spanCollector keeps the spans ended during a replay
+type spanCollector struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (c *spanCollector) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (c *spanCollector) OnEnd(s sdktrace.ReadOnlySpan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.spans = append(c.spans, s)
+}
+
+func (c *spanCollector) Shutdown(context.Context) error { return nil }
+
+func (c *spanCollector) ForceFlush(context.Context) error { return nil }
+
+// result converts the collected spans ordered by their start, relative to the start of the replay
+func (c *spanCollector) result(start time.Time) []Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	spans := make([]Span, 0, len(c.spans))
+	for _, s := range c.spans {
+		span := Span{
+			Name:     s.Name(),
+			SpanID:   s.SpanContext().SpanID().String(),
+			Offset:   s.StartTime().Sub(start),
+			Duration: s.EndTime().Sub(s.StartTime()),
+			Status:   s.Status().Code.String(),
+			Error:    s.Status().Description,
+		}
+		if s.Parent().IsValid() {
+			span.ParentID = s.Parent().SpanID().String()
+		}
+		if attrs := s.Attributes(); len(attrs) > 0 {
+			span.Attributes = make(map[string]string, len(attrs))
+			for _, attr := range attrs {
+				span.Attributes[string(attr.Key)] = attr.Value.Emit()
+			}
+		}
+		spans = append(spans, span)
+	}
+	slices.SortStableFunc(spans, func(a, b Span) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	return spans
+}
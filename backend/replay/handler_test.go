@@ -0,0 +1,167 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// fakeTarget echoes replayed requests and traces a fake gRPC call for every one
+type fakeTarget struct {
+	clusters map[string]bool
+	released int
+}
+
+func (f *fakeTarget) serve(ctx context.Context, name string, provider trace.TracerProvider) (http.Handler, func() error, error) {
+	if !f.clusters[name] {
+		return nil, nil, cluster.ErrClusterNotFound
+	}
+	r := chi.NewRouter()
+	r.Post("/api/kv/{table}", func(w http.ResponseWriter, r *http.Request) {
+		_, span := provider.Tracer("test").Start(r.Context(), "armada.KV/Put")
+		span.End()
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"table":"` + chi.URLParam(r, "table") + `","body":` + string(body) + `}`))
+	})
+	return r, func() error {
+		f.released++
+		return nil
+	}, nil
+}
+
+func newTestHandler(t *testing.T) (*Recorder, *fakeTarget, http.Handler) {
+	t.Helper()
+	rec := NewRecorder(10)
+	target := &fakeTarget{clusters: map[string]bool{"default": true, "staging": true}}
+	r := chi.NewRouter()
+	r.Use(rec.Middleware)
+	r.Post("/api/kv/{table}", func(w http.ResponseWriter, r *http.Request) {})
+	NewHandler(rec, target.serve, "default", zap.NewNop()).RegisterRoutes(r)
+	return rec, target, r
+}
+
+func TestReplay(t *testing.T) {
+	rec, target, router := newTestHandler(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/kv/users", strings.NewReader(`{"key":"a"}`)))
+	require.Len(t, rec.List(), 1)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/debug/replay/1", strings.NewReader(`{"cluster":"staging"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var result Result
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "staging", result.Cluster)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.JSONEq(t, `{"table":"users","body":{"key":"a"}}`, result.Body)
+	require.Len(t, result.Spans, 2)
+	root, call := result.Spans[0], result.Spans[1]
+	assert.Equal(t, "POST /api/kv/users", root.Name)
+	assert.Empty(t, root.ParentID)
+	assert.Equal(t, "armada.KV/Put", call.Name)
+	assert.Equal(t, root.SpanID, call.ParentID)
+	assert.GreaterOrEqual(t, call.Offset, root.Offset)
+	assert.Equal(t, 1, target.released)
+
+	// Replays aren't recorded themselves
+	assert.Len(t, rec.List(), 1)
+}
+
+func TestReplayDefaultCluster(t *testing.T) {
+	_, _, router := newTestHandler(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/kv/users", strings.NewReader(`{}`)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/debug/replay/1", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result Result
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "default", result.Cluster)
+}
+
+func TestReplayErrors(t *testing.T) {
+	_, _, router := newTestHandler(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/kv/users", strings.NewReader(`{}`)))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/kv/users", strings.NewReader(`{"token":"secret"}`)))
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{name: "UnknownRequest", path: "/api/debug/replay/42", want: http.StatusNotFound},
+		{name: "UnknownCluster", path: "/api/debug/replay/1", body: `{"cluster":"prod"}`, want: http.StatusNotFound},
+		{name: "InvalidBody", path: "/api/debug/replay/1", body: `{`, want: http.StatusBadRequest},
+		{name: "Redacted", path: "/api/debug/replay/2", want: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestReplayTargetFailure(t *testing.T) {
+	rec := NewRecorder(10)
+	rec.add(Request{Method: http.MethodGet, Path: "/api/tables"})
+	failing := func(context.Context, string, trace.TracerProvider) (http.Handler, func() error, error) {
+		return nil, nil, errors.New("connection refused")
+	}
+	r := chi.NewRouter()
+	NewHandler(rec, failing, "default", zap.NewNop()).RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/debug/replay/1", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestRecordedRequests(t *testing.T) {
+	_, _, router := newTestHandler(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/kv/users", strings.NewReader(`{}`)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/debug/requests", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var requests []Request
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &requests))
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/api/kv/users", requests[0].Path)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/debug/requests/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/debug/requests/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReplayRequiresOperator(t *testing.T) {
+	_, _, router := newTestHandler(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/debug/requests", nil),
+		httptest.NewRequest(http.MethodGet, "/api/debug/requests/1", nil),
+		httptest.NewRequest(http.MethodPost, "/api/debug/replay/1", nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req.WithContext(auth.WithRole(req.Context(), auth.RoleViewer)))
+		assert.Equal(t, http.StatusForbidden, w.Code, req.URL.Path)
+	}
+}
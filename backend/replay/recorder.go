@@ -0,0 +1,210 @@
+// Package replay records recent API requests and re-executes them against a chosen cluster
+// while capturing the timing of the request and of every gRPC call it makes, so issues
+// reported by users can be reproduced quickly. Recording is opt-in; credentials are never
+// recorded and sensitive fields of query strings and JSON bodies are redacted.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/auth"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// redacted replaces the values of sensitive fields
+const redacted = "REDACTED"
+
+// maxBodyBytes is the largest request body recorded in full
+const maxBodyBytes = 64 << 10
+
+// sensitiveName matches the names of query parameters and JSON fields whose values are redacted
+var sensitiveName = regexp.MustCompile(`(?i)pass|secret|token|auth|credential|cookie|session|api_?key`)
+
+// skippedPrefixes are API paths that are not recorded: the replay endpoints themselves,
+// long-lived WebSocket connections and the login flow
+var skippedPrefixes = []string{"/api/debug/", "/api/rpc", "/api/auth/"}
+
+// Request is a recorded API request
+type Request struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// User is the user who made the request, if authenticated
+	User   string `json:"user,omitempty"`
+	Method string `json:"method"`
+	// Path is the path and query of the request
+	Path        string `json:"path"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body,omitempty"`
+	// Redacted is set when sensitive values of the query or body were replaced
+	Redacted bool `json:"redacted,omitempty"`
+	// Truncated is set when the body exceeded the recorded size
+	Truncated bool `json:"truncated,omitempty"`
+	// Status and Duration describe the original response
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Replayable reports whether the request was recorded faithfully enough to be replayed
+func (r Request) Replayable() bool {
+	return !r.Redacted && !r.Truncated
+}
+
+// Recorder keeps the most recent API requests in memory
+type Recorder struct {
+	limit int
+
+	// mu protects requests and seq
+	mu       sync.Mutex
+	requests []Request
+	seq      uint64
+}
+
+// NewRecorder creates a Recorder keeping the given number of requests
+func NewRecorder(limit int) *Recorder {
+	return &Recorder{limit: limit}
+}
+
+// Middleware records the API requests passing through it. It must run after authentication
+// to record the user of a request.
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || slices.ContainsFunc(skippedPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(r.URL.Path, prefix)
+		}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var body []byte
+		if r.Body != nil {
+			// The handler still reads the whole body, the recorded part is put in front of the rest
+			body, _ = io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		start := time.Now()
+		next.ServeHTTP(ww, r)
+
+		req := Request{
+			Time:        start,
+			User:        auth.UserName(r.Context()),
+			Method:      r.Method,
+			ContentType: r.Header.Get("Content-Type"),
+			Status:      ww.Status(),
+			Duration:    time.Since(start),
+		}
+		if req.Status == 0 {
+			req.Status = http.StatusOK
+		}
+		req.Path, req.Redacted = sanitizePath(r.URL)
+		var bodyRedacted bool
+		req.Body, bodyRedacted, req.Truncated = sanitizeBody(body)
+		req.Redacted = req.Redacted || bodyRedacted
+		rec.add(req)
+	})
+}
+
+// add stores a request, dropping the oldest one beyond the limit
+func (rec *Recorder) add(req Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.seq++
+	req.ID = strconv.FormatUint(rec.seq, 10)
+	rec.requests = append(rec.requests, req)
+	if len(rec.requests) > rec.limit {
+		rec.requests = slices.Delete(rec.requests, 0, len(rec.requests)-rec.limit)
+	}
+}
+
+// List returns the recorded requests, most recent first
+func (rec *Recorder) List() []Request {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	requests := slices.Clone(rec.requests)
+	slices.Reverse(requests)
+	return requests
+}
+
+// Get returns a recorded request by ID
+func (rec *Recorder) Get(id string) (Request, bool) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	for _, req := range rec.requests {
+		if req.ID == id {
+			return req, true
+		}
+	}
+	return Request{}, false
+}
+
+// sanitizePath returns the path and query of a URL with the values of sensitive parameters redacted
+func sanitizePath(u *url.URL) (string, bool) {
+	query := u.Query()
+	changed := false
+	for name, values := range query {
+		if sensitiveName.MatchString(name) {
+			for i := range values {
+				values[i] = redacted
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		// The original query is kept, so the request is replayed exactly as it was made
+		return u.RequestURI(), false
+	}
+	return u.Path + "?" + query.Encode(), true
+}
+
+// sanitizeBody redacts the values of sensitive fields of a JSON body and truncates large bodies
+func sanitizeBody(body []byte) (sanitized string, redactedFields, truncated bool) {
+	if len(body) > maxBodyBytes {
+		return string(body[:maxBodyBytes]), false, true
+	}
+	var doc any
+	if len(body) == 0 || json.Unmarshal(body, &doc) != nil {
+		return string(body), false, false
+	}
+	if !redact(doc) {
+		return string(body), false, false
+	}
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", true, false
+	}
+	return string(out), true, false
+}
+
+// redact replaces the values of sensitive fields of a decoded JSON document and reports whether any were found
+func redact(doc any) bool {
+	found := false
+	switch v := doc.(type) {
+	case map[string]any:
+		for name, value := range v {
+			if sensitiveName.MatchString(name) {
+				v[name] = redacted
+				found = true
+				continue
+			}
+			found = redact(value) || found
+		}
+	case []any:
+		for _, value := range v {
+			found = redact(value) || found
+		}
+	}
+	return found
+}
@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderMiddleware(t *testing.T) {
+	rec := NewRecorder(10)
+	var received string
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	body := `{"table":"users","key":"a","value":"b"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/kv/put?limit=5", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The handler still gets the whole body
+	assert.Equal(t, body, received)
+	requests := rec.List()
+	require.Len(t, requests, 1)
+	got := requests[0]
+	assert.Equal(t, "1", got.ID)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/api/kv/put?limit=5", got.Path)
+	assert.Equal(t, "application/json", got.ContentType)
+	assert.Equal(t, body, got.Body)
+	assert.Equal(t, http.StatusCreated, got.Status)
+	assert.True(t, got.Replayable())
+}
+
+func TestRecorderSkipsPaths(t *testing.T) {
+	rec := NewRecorder(10)
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, path := range []string{"/", "/assets/index.js", "/api/debug/requests", "/api/rpc", "/api/auth/me"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Empty(t, rec.List())
+}
+
+func TestRecorderRedacts(t *testing.T) {
+	rec := NewRecorder(10)
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/things?access_token=abc&key=k1",
+		strings.NewReader(`{"key":"k1","nested":[{"Password":"hunter2"}],"apiKey":"x"}`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := rec.List()[0]
+	assert.True(t, got.Redacted)
+	assert.False(t, got.Replayable())
+	assert.Equal(t, "/api/things?access_token=REDACTED&key=k1", got.Path)
+	assert.JSONEq(t, `{"key":"k1","nested":[{"Password":"REDACTED"}],"apiKey":"REDACTED"}`, got.Body)
+	assert.NotContains(t, got.Body, "hunter2")
+}
+
+func TestRecorderTruncates(t *testing.T) {
+	rec := NewRecorder(10)
+	var received int
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = len(body)
+	}))
+
+	body := strings.Repeat("a", maxBodyBytes+100)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/api/kv/put", strings.NewReader(body)))
+
+	assert.Equal(t, len(body), received)
+	got := rec.List()[0]
+	assert.True(t, got.Truncated)
+	assert.Len(t, got.Body, maxBodyBytes)
+}
+
+func TestRecorderKeepsMostRecent(t *testing.T) {
+	rec := NewRecorder(3)
+	handler := rec.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := range 5 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/tables?n="+strconv.Itoa(i), nil))
+	}
+
+	requests := rec.List()
+	require.Len(t, requests, 3)
+	assert.Equal(t, []string{"5", "4", "3"}, []string{requests[0].ID, requests[1].ID, requests[2].ID})
+	_, ok := rec.Get("1")
+	assert.False(t, ok)
+	got, ok := rec.Get("4")
+	require.True(t, ok)
+	assert.Equal(t, "/api/tables?n=3", got.Path)
+}
@@ -0,0 +1,180 @@
+// Package retention removes console-side artifacts beyond their retention, so a long-lived
+// console doesn't grow without bound. The Cleaner periodically prunes every registered
+// artifact, e.g. the audit log or past maintenance windows, and counts the removed records
+// and the reclaimed space per artifact as console metrics.
+package retention
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/metrics"
+	"go.uber.org/zap"
+)
+
+// PruneFunc removes the records of an artifact created before the cutoff, or beyond other
+// limits such as a maximum size. A zero cutoff keeps records of any age. It returns the number
+// of removed records and the reclaimed space in bytes.
+type PruneFunc func(cutoff time.Time) (removed int, reclaimed int64, err error)
+
+// Result is what pruning an artifact removed
+type Result struct {
+	// Removed is the number of removed records.
+	Removed int `json:"removed"`
+	// Reclaimed is the reclaimed space in bytes.
+	Reclaimed int64 `json:"reclaimed"`
+}
+
+// MetricSink stores metrics derived by the console. The metrics.MetricsManager implements it.
+type MetricSink interface {
+	AppendSamples(ctx context.Context, samples []metrics.ConsoleSample) error
+}
+
+// CleanerOption configures optional behaviour of the Cleaner
+type CleanerOption func(*Cleaner)
+
+// WithMetricSink makes the Cleaner write the removed records and the reclaimed space of every
+// artifact as the metrics.RetentionRemovedMetric and metrics.RetentionReclaimedMetric counters
+// after every run
+func WithMetricSink(sink MetricSink) CleanerOption {
+	return func(c *Cleaner) {
+		c.sink = sink
+	}
+}
+
+// policy is a registered artifact
+type policy struct {
+	artifact string
+	maxAge   time.Duration
+	prune    PruneFunc
+}
+
+// Cleaner periodically prunes the registered artifacts
+type Cleaner struct {
+	interval time.Duration
+	logger   *zap.Logger
+	sink     MetricSink
+	now      func() time.Time
+	policies []policy
+
+	// mu protects totals
+	mu sync.Mutex
+	// totals are the records removed from every artifact since the console started
+	totals map[string]Result
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCleaner creates a Cleaner pruning the artifacts at the given interval
+func NewCleaner(interval time.Duration, logger *zap.Logger, opts ...CleanerOption) *Cleaner {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	c := &Cleaner{
+		interval: interval,
+		logger:   logger.Named("retention"),
+		now:      time.Now,
+		totals:   make(map[string]Result),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Add registers an artifact whose records are kept for maxAge, zero keeps them regardless of
+// their age. Artifacts must be added before the Cleaner is started.
+func (c *Cleaner) Add(artifact string, maxAge time.Duration, prune PruneFunc) {
+	c.policies = append(c.policies, policy{artifact: artifact, maxAge: maxAge, prune: prune})
+	c.totals[artifact] = Result{}
+}
+
+// Start prunes the artifacts immediately and then at every interval
+func (c *Cleaner) Start(ctx context.Context) {
+	go c.run(ctx)
+}
+
+// Stop stops periodic pruning
+func (c *Cleaner) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+// Totals returns the records removed from every artifact since the console started
+func (c *Cleaner) Totals() map[string]Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.totals)
+}
+
+// run prunes immediately and then at every interval
+func (c *Cleaner) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.Clean(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			c.Clean(ctx)
+		case <-c.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Clean prunes every artifact once. An artifact that fails to be pruned is logged and retried
+// in the next run, the other artifacts are pruned regardless.
+func (c *Cleaner) Clean(ctx context.Context) {
+	now := c.now()
+	for _, p := range c.policies {
+		var cutoff time.Time
+		if p.maxAge > 0 {
+			cutoff = now.Add(-p.maxAge)
+		}
+		removed, reclaimed, err := p.prune(cutoff)
+		if err != nil {
+			c.logger.Warn("Failed to prune expired records", zap.String("artifact", p.artifact), zap.Error(err))
+		}
+		if removed > 0 {
+			c.logger.Info("Pruned expired records", zap.String("artifact", p.artifact),
+				zap.Int("removed", removed), zap.Int64("reclaimedBytes", reclaimed))
+		}
+
+		c.mu.Lock()
+		total := c.totals[p.artifact]
+		total.Removed += removed
+		total.Reclaimed += reclaimed
+		c.totals[p.artifact] = total
+		c.mu.Unlock()
+	}
+
+	if c.sink != nil {
+		if err := c.sink.AppendSamples(ctx, c.samples(now)); err != nil {
+			c.logger.Warn("Failed to store retention metrics", zap.Error(err))
+		}
+	}
+}
+
+// samples returns the totals as samples of the retention counters
+func (c *Cleaner) samples(t time.Time) []metrics.ConsoleSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	samples := make([]metrics.ConsoleSample, 0, 2*len(c.totals))
+	for artifact, total := range c.totals {
+		labels := map[string]string{"artifact": artifact}
+		samples = append(samples,
+			metrics.ConsoleSample{Name: metrics.RetentionRemovedMetric, Labels: labels, Time: t, Value: float64(total.Removed)},
+			metrics.ConsoleSample{Name: metrics.RetentionReclaimedMetric, Labels: labels, Time: t, Value: float64(total.Reclaimed)})
+	}
+	return samples
+}
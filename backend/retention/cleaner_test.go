@@ -0,0 +1,56 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	samples []metrics.ConsoleSample
+}
+
+func (r *recordingSink) AppendSamples(_ context.Context, samples []metrics.ConsoleSample) error {
+	r.samples = append(r.samples, samples...)
+	return nil
+}
+
+func TestCleaner(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	sink := &recordingSink{}
+	c := NewCleaner(time.Hour, nil, WithMetricSink(sink))
+	c.now = func() time.Time { return now }
+
+	var cutoffs []time.Time
+	c.Add("audit", 0, func(cutoff time.Time) (int, int64, error) {
+		cutoffs = append(cutoffs, cutoff)
+		return 2, 512, nil
+	})
+	c.Add("maintenance", 24*time.Hour, func(cutoff time.Time) (int, int64, error) {
+		cutoffs = append(cutoffs, cutoff)
+		return 0, 0, errors.New("disk full")
+	})
+
+	c.Clean(context.Background())
+	c.Clean(context.Background())
+
+	assert.Equal(t, []time.Time{{}, now.Add(-24 * time.Hour), {}, now.Add(-24 * time.Hour)}, cutoffs,
+		"artifacts without a maximum age are pruned with a zero cutoff")
+	assert.Equal(t, map[string]Result{"audit": {Removed: 4, Reclaimed: 1024}, "maintenance": {}}, c.Totals(),
+		"a failing artifact doesn't stop the others")
+
+	latest := make(map[string]float64)
+	for _, s := range sink.samples {
+		latest[s.Name+"/"+s.Labels["artifact"]] = s.Value
+	}
+	assert.Equal(t, map[string]float64{
+		metrics.RetentionRemovedMetric + "/audit":         4,
+		metrics.RetentionReclaimedMetric + "/audit":       1024,
+		metrics.RetentionRemovedMetric + "/maintenance":   0,
+		metrics.RetentionReclaimedMetric + "/maintenance": 0,
+	}, latest)
+}
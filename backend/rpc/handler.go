@@ -0,0 +1,423 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Path is where clients open the WebSocket
+const Path = "/api/rpc"
+
+const (
+	// maxMessageSize bounds the size of messages from clients
+	maxMessageSize = 64 << 10
+	// maxSubscriptions bounds the subscriptions of a connection
+	maxSubscriptions = 16
+	// subscriptionBuffer is the number of events buffered for a subscription before they are dropped
+	subscriptionBuffer = 64
+	// queryTimeout bounds a query of the REST API
+	queryTimeout = 30 * time.Second
+	// writeTimeout bounds writing a message to a client
+	writeTimeout = 10 * time.Second
+	// pongTimeout is how long a client may take to answer a ping before the connection is closed
+	pongTimeout = 60 * time.Second
+	// pingInterval is how often clients are pinged, shorter than pongTimeout
+	pingInterval = pongTimeout * 9 / 10
+)
+
+// forwardedHeaders carry the credentials of the WebSocket's opening request to queries
+var forwardedHeaders = []string{"Authorization", "Cookie"}
+
+// Handler serves JSON-RPC over WebSocket connections. Clients call
+//
+//   - subscribe with {"types": [...]} to receive events as "event" notifications,
+//     returning {"subscription": "<id>"}; all event types are delivered if types is empty
+//   - unsubscribe with {"subscription": "<id>"} to stop receiving them
+//   - get with {"path": "/api/tables"} to query any GET endpoint of the REST API,
+//     returning its JSON response
+type Handler struct {
+	hub    *events.Hub
+	api    http.Handler
+	logger *zap.Logger
+
+	upgrader websocket.Upgrader
+}
+
+// NewHandler creates a handler publishing the events of hub and answering queries with api,
+// usually the router the REST API is served by
+func NewHandler(hub *events.Hub, api http.Handler, logger *zap.Logger) *Handler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Handler{
+		hub:    hub,
+		api:    api,
+		logger: logger,
+		// The default origin check rejects pages of other sites from using the session of a logged-in browser
+		upgrader: websocket.Upgrader{ReadBufferSize: 4096, WriteBufferSize: 4096},
+	}
+}
+
+// RegisterRoutes registers the WebSocket endpoint
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get(Path, h.handleWebSocket)
+}
+
+// handleWebSocket upgrades the request and serves the connection until it closes
+// @Summary JSON-RPC over WebSocket
+// @Description Subscribe to cluster events and query the API with JSON-RPC 2.0 messages over a WebSocket
+// @Tags rpc
+// @Success 101
+// @Router /api/rpc [get]
+func (h *Handler) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already answered the request
+		h.logger.Debug("Failed to upgrade WebSocket", zap.Error(err))
+		return
+	}
+
+	header := make(http.Header)
+	for _, name := range forwardedHeaders {
+		if values := r.Header.Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	c := newConn(h, ws, header, auth.ClientAddress(r))
+	h.logger.Debug("RPC client connected", zap.String("remote", r.RemoteAddr))
+	c.serve()
+	h.logger.Debug("RPC client disconnected", zap.String("remote", r.RemoteAddr))
+}
+
+// conn is a connected client
+type conn struct {
+	handler *Handler
+	ws      *websocket.Conn
+	// header carries the client's credentials to queries
+	header http.Header
+	// ctx is canceled when the connection closes
+	ctx    context.Context
+	cancel context.CancelFunc
+	// out queues the messages for the writer
+	out chan any
+
+	// mu protects subs and nextID
+	mu     sync.Mutex
+	subs   map[string]*events.Subscription
+	nextID int
+}
+
+func newConn(h *Handler, ws *websocket.Conn, header http.Header, address string) *conn {
+	// Queries are served as new requests, so they must not inherit the routing state of the upgrade
+	// request. They are attributed to the client, e.g. when failed logins are counted.
+	ctx, cancel := context.WithCancel(auth.WithClientAddress(context.Background(), address))
+	return &conn{
+		handler: h,
+		ws:      ws,
+		header:  header,
+		ctx:     ctx,
+		cancel:  cancel,
+		out:     make(chan any, subscriptionBuffer),
+		subs:    make(map[string]*events.Subscription),
+	}
+}
+
+// serve reads requests until the client disconnects
+func (c *conn) serve() {
+	defer c.close()
+	go c.write()
+
+	c.ws.SetReadLimit(maxMessageSize)
+	_ = c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	for {
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if reply := c.handleMessage(msg); reply != nil {
+			c.send(reply)
+		}
+	}
+}
+
+// close ends the subscriptions and stops the writer, which closes the socket
+func (c *conn) close() {
+	c.mu.Lock()
+	for id, sub := range c.subs {
+		sub.Close()
+		delete(c.subs, id)
+	}
+	c.mu.Unlock()
+	c.cancel()
+}
+
+// write sends queued messages and pings to the client. It is the only writer of the socket.
+func (c *conn) write() {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.ws.Close()
+	}()
+	for {
+		select {
+		case msg := <-c.out:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				c.cancel()
+				return
+			}
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				c.cancel()
+				return
+			}
+		case <-c.ctx.Done():
+			_ = c.ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+			return
+		}
+	}
+}
+
+// send queues a message for the client, it is dropped once the connection closed
+func (c *conn) send(msg any) {
+	select {
+	case c.out <- msg:
+	case <-c.ctx.Done():
+	}
+}
+
+// handleMessage handles a single request or a batch and returns the reply, if any
+func (c *conn) handleMessage(msg []byte) any {
+	if !isBatch(msg) {
+		var req Request
+		if err := json.Unmarshal(msg, &req); err != nil {
+			return Response{JSONRPC: Version, ID: json.RawMessage("null"),
+				Error: &Error{Code: CodeParseError, Message: "Parse error: " + err.Error()}}
+		}
+		if resp, ok := c.handleRequest(req); ok {
+			return resp
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(msg, &batch); err != nil {
+		return Response{JSONRPC: Version, ID: json.RawMessage("null"),
+			Error: &Error{Code: CodeParseError, Message: "Parse error: " + err.Error()}}
+	}
+	if len(batch) == 0 {
+		return Response{JSONRPC: Version, ID: json.RawMessage("null"),
+			Error: &Error{Code: CodeInvalidRequest, Message: "Empty batch"}}
+	}
+	responses := make([]Response, 0, len(batch))
+	for _, raw := range batch {
+		var req Request
+		if err := json.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, Response{JSONRPC: Version, ID: json.RawMessage("null"),
+				Error: &Error{Code: CodeInvalidRequest, Message: "Invalid request: " + err.Error()}})
+			continue
+		}
+		if resp, ok := c.handleRequest(req); ok {
+			responses = append(responses, resp)
+		}
+	}
+	// A batch of notifications gets no reply at all
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// handleRequest calls the requested method. It returns false for notifications, which get no response.
+func (c *conn) handleRequest(req Request) (Response, bool) {
+	resp := Response{JSONRPC: Version, ID: req.ID}
+	if req.JSONRPC != Version || req.Method == "" {
+		resp.Error = &Error{Code: CodeInvalidRequest, Message: "Invalid request: jsonrpc must be 2.0 and method must be set"}
+		if resp.ID == nil {
+			resp.ID = json.RawMessage("null")
+		}
+		return resp, true
+	}
+
+	result, rpcErr := c.call(req.Method, req.Params)
+	if req.ID == nil {
+		return Response{}, false
+	}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp, true
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: CodeInternalError, Message: "Failed to encode result"}
+		return resp, true
+	}
+	resp.Result = raw
+	return resp, true
+}
+
+// call dispatches a method call
+func (c *conn) call(method string, params json.RawMessage) (any, *Error) {
+	switch method {
+	case "subscribe":
+		return c.subscribe(params)
+	case "unsubscribe":
+		return c.unsubscribe(params)
+	case "get":
+		return c.get(params)
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "Method not found: " + method}
+	}
+}
+
+// SubscribeParams selects the events of a subscription
+type SubscribeParams struct {
+	// Types are the event types to receive, all types if empty
+	Types []string `json:"types,omitempty"`
+}
+
+// SubscribeResult identifies a new subscription
+type SubscribeResult struct {
+	Subscription string `json:"subscription"`
+}
+
+// EventParams are the params of the "event" notification delivering an event
+type EventParams struct {
+	Subscription string       `json:"subscription"`
+	Event        events.Event `json:"event"`
+	// Dropped is the number of events dropped before this one because the client did not keep up
+	Dropped uint64 `json:"dropped,omitempty"`
+}
+
+// subscribe starts delivering events as notifications
+func (c *conn) subscribe(params json.RawMessage) (any, *Error) {
+	var p SubscribeParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	for _, t := range p.Types {
+		if !slices.Contains(events.Types, t) {
+			return nil, invalidParams("Unknown event type %q, must be one of %s", t, strings.Join(events.Types, ", "))
+		}
+	}
+
+	c.mu.Lock()
+	if len(c.subs) >= maxSubscriptions {
+		c.mu.Unlock()
+		return nil, &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("At most %d subscriptions per connection", maxSubscriptions)}
+	}
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	sub := c.handler.hub.Subscribe(subscriptionBuffer, p.Types...)
+	c.subs[id] = sub
+	c.mu.Unlock()
+
+	go func() {
+		for e := range sub.Events() {
+			c.send(Notification{JSONRPC: Version, Method: "event", Params: EventParams{
+				Subscription: id,
+				Event:        e,
+				Dropped:      sub.Dropped(),
+			}})
+		}
+	}()
+	return SubscribeResult{Subscription: id}, nil
+}
+
+// UnsubscribeParams identify the subscription to end
+type UnsubscribeParams struct {
+	Subscription string `json:"subscription"`
+}
+
+// unsubscribe ends a subscription
+func (c *conn) unsubscribe(params json.RawMessage) (any, *Error) {
+	var p UnsubscribeParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	sub, ok := c.subs[p.Subscription]
+	delete(c.subs, p.Subscription)
+	c.mu.Unlock()
+	if !ok {
+		return nil, invalidParams("Unknown subscription %q", p.Subscription)
+	}
+	sub.Close()
+	return true, nil
+}
+
+// GetParams select the REST endpoint to query
+type GetParams struct {
+	// Path is the path and query of a GET endpoint, e.g. /api/v1/kv/users?prefix=a
+	Path string `json:"path"`
+}
+
+// get queries a GET endpoint of the REST API with the credentials of the connection
+func (c *conn) get(params json.RawMessage) (any, *Error) {
+	var p GetParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	// The RPC endpoint itself can't be queried, under any API version
+	if path, _ := apiversion.Split(p.Path); !strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, Path) {
+		return nil, invalidParams("Path must be an API endpoint below /api/")
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, queryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Path, nil)
+	if err != nil {
+		return nil, invalidParams("Invalid path: %v", err)
+	}
+	req.Header = c.header.Clone()
+	req.Header.Set("Accept", "application/json")
+	req.RequestURI = p.Path
+
+	rec := newRecorder()
+	c.handler.api.ServeHTTP(rec, req)
+	if rec.status < 200 || rec.status > 299 {
+		return nil, &Error{Code: CodeRequestFailed, Message: errorMessage(rec), Data: map[string]int{"status": rec.status}}
+	}
+	if !json.Valid(rec.body.Bytes()) {
+		return nil, &Error{Code: CodeRequestFailed, Message: "Endpoint did not answer with JSON"}
+	}
+	return json.RawMessage(rec.body.Bytes()), nil
+}
+
+// errorMessage extracts the message of an API error response
+func errorMessage(rec *recorder) string {
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(rec.body.Bytes(), &body) == nil {
+		if body.Message != "" {
+			return body.Message
+		}
+		if body.Error != "" {
+			return body.Error
+		}
+	}
+	if msg := strings.TrimSpace(rec.body.String()); msg != "" {
+		return msg
+	}
+	return http.StatusText(rec.status)
+}
@@ -0,0 +1,141 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/events"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestServer serves the RPC endpoint next to a small REST API requiring a token
+func newTestServer(t *testing.T, hub *events.Hub) *websocket.Conn {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/api/tables", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"users"}]`))
+	})
+	r.Get("/api/tables/{name}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Table not found", http.StatusNotFound)
+	})
+	NewHandler(hub, r, zap.NewNop()).RegisterRoutes(r)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+Path,
+		http.Header{"Authorization": {"Bearer secret"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	return ws
+}
+
+// roundTrip sends a message and decodes the reply into v
+func roundTrip(t *testing.T, ws *websocket.Conn, msg string, v any) {
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte(msg)))
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.ReadJSON(v))
+}
+
+func TestRPCGet(t *testing.T) {
+	ws := newTestServer(t, events.NewHub())
+
+	var resp Response
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":1,"method":"get","params":{"path":"/api/tables"}}`, &resp)
+	assert.Nil(t, resp.Error)
+	assert.JSONEq(t, `1`, string(resp.ID))
+	assert.JSONEq(t, `[{"name":"users"}]`, string(resp.Result))
+
+	resp = Response{}
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":2,"method":"get","params":{"path":"/api/tables/orders"}}`, &resp)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeRequestFailed, resp.Error.Code)
+	assert.Equal(t, "Table not found", resp.Error.Message)
+
+	resp = Response{}
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":3,"method":"get","params":{"path":"https://example.com/"}}`, &resp)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeInvalidParams, resp.Error.Code)
+
+	resp = Response{}
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":4,"method":"get","params":{"path":"/api/v1/rpc"}}`, &resp)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeInvalidParams, resp.Error.Code)
+}
+
+func TestRPCErrors(t *testing.T) {
+	ws := newTestServer(t, events.NewHub())
+
+	var resp Response
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":"a","method":"delete"}`, &resp)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeMethodNotFound, resp.Error.Code)
+
+	resp = Response{}
+	roundTrip(t, ws, `{not json`, &resp)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeParseError, resp.Error.Code)
+
+	// Notifications in a batch are not answered
+	var batch []Response
+	roundTrip(t, ws, `[{"jsonrpc":"2.0","method":"get","params":{"path":"/api/tables"}},
+		{"jsonrpc":"2.0","id":7,"method":"subscribe","params":{"types":["nope"]}}]`, &batch)
+	require.Len(t, batch, 1)
+	assert.JSONEq(t, `7`, string(batch[0].ID))
+	assert.Equal(t, CodeInvalidParams, batch[0].Error.Code)
+}
+
+func TestRPCSubscribe(t *testing.T) {
+	hub := events.NewHub()
+	ws := newTestServer(t, hub)
+
+	var resp Response
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":1,"method":"subscribe","params":{"types":["audit.recorded"]}}`, &resp)
+	require.Nil(t, resp.Error)
+	var sub SubscribeResult
+	require.NoError(t, json.Unmarshal(resp.Result, &sub))
+
+	hub.Publish(events.Event{Type: events.TypeTopology})
+	hub.Publish(events.Event{Type: events.TypeAudit, Data: map[string]string{"action": "table.delete"}})
+	var n struct {
+		Method string `json:"method"`
+		Params struct {
+			Subscription string       `json:"subscription"`
+			Event        events.Event `json:"event"`
+		} `json:"params"`
+	}
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.ReadJSON(&n))
+	assert.Equal(t, "event", n.Method)
+	assert.Equal(t, sub.Subscription, n.Params.Subscription)
+	assert.Equal(t, events.TypeAudit, n.Params.Event.Type)
+	assert.Equal(t, map[string]any{"action": "table.delete"}, n.Params.Event.Data)
+
+	resp = Response{}
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":2,"method":"unsubscribe","params":{"subscription":"`+sub.Subscription+`"}}`, &resp)
+	require.Nil(t, resp.Error)
+	assert.Equal(t, 0, hub.Subscribers())
+
+	// Closing the connection ends its subscriptions
+	resp = Response{}
+	roundTrip(t, ws, `{"jsonrpc":"2.0","id":3,"method":"subscribe"}`, &resp)
+	require.Nil(t, resp.Error)
+	assert.Equal(t, 1, hub.Subscribers())
+	require.NoError(t, ws.Close())
+	assert.Eventually(t, func() bool { return hub.Subscribers() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,79 @@
+// Package rpc serves the console's event feed and query APIs to programmatic clients,
+// such as bots and terminal UIs, as JSON-RPC 2.0 over a WebSocket.
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the JSON-RPC version spoken by the server
+const Version = "2.0"
+
+// Error codes defined by JSON-RPC 2.0 and the server
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	// CodeRequestFailed is returned when the API answered a query with an error status
+	CodeRequestFailed = -32000
+)
+
+// Request is a call of a method. Requests without an ID are notifications and get no response.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response answers a request with either a result or an error
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a message from the server that is not a response, e.g. an event
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Error is the error of a failed call
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// invalidParams creates the error of a call with params the method can't use
+func invalidParams(format string, args ...any) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+// isBatch reports whether a message holds a batch of requests rather than a single one
+func isBatch(msg []byte) bool {
+	trimmed := bytes.TrimLeft(msg, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// decodeParams decodes the params of a request into v. Missing params leave v unchanged.
+func decodeParams(params json.RawMessage, v any) *Error {
+	if len(params) == 0 || bytes.Equal(params, []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return invalidParams("Invalid params: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package runbooks keeps the runbook links and remediation notes of alert rules and diagnostic
+// checks, so on-call engineers find what to do next right beside a finding instead of searching
+// the wiki for it.
+package runbooks
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/armadakv/console/backend/metadata"
+)
+
+// Namespace is the metadata namespace the runbooks are stored in
+const Namespace = "runbooks"
+
+// MaxRemediationLength is the longest remediation note in bytes
+const MaxRemediationLength = 16 << 10
+
+var (
+	// ErrNotFound is returned when a check has no stored runbook
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for runbooks that can't be stored
+	ErrInvalid = errors.New("invalid")
+)
+
+// validCheck matches the names of alert rules and diagnostic checks, e.g. leader-flapping
+var validCheck = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// Runbook tells on-call engineers how to handle the findings of a check
+type Runbook struct {
+	// Check is the name of the alert rule or diagnostic check
+	Check string `json:"check"`
+	// URL links to the runbook, e.g. in the team's wiki
+	URL string `json:"url,omitempty"`
+	// Remediation are first steps in Markdown, shown with the findings
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Store keeps the runbooks in the metadata store. A stored runbook replaces the one a check ships with.
+type Store struct {
+	store metadata.Store
+	// mu serializes changes to the runbooks
+	mu sync.Mutex
+}
+
+// NewStore creates a runbook store on top of the metadata store
+func NewStore(store metadata.Store) *Store {
+	return &Store{store: store}
+}
+
+// List returns the stored runbooks ordered by check
+func (s *Store) List() ([]Runbook, error) {
+	stored, err := metadata.List[Runbook](s.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Runbook, 0, len(stored))
+	for _, rb := range stored {
+		list = append(list, rb)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Check < list[j].Check })
+	return list, nil
+}
+
+// Get returns the stored runbook of a check
+func (s *Store) Get(check string) (Runbook, error) {
+	rb, err := metadata.Get[Runbook](s.store, Namespace, check)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Runbook{}, fmt.Errorf("runbook of %s %w", check, ErrNotFound)
+	}
+	return rb, err
+}
+
+// Put creates or replaces the runbook of a check
+func (s *Store) Put(rb Runbook) (Runbook, error) {
+	if err := validate(rb); err != nil {
+		return Runbook{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := metadata.Put(s.store, Namespace, rb.Check, rb); err != nil {
+		return Runbook{}, err
+	}
+	return rb, nil
+}
+
+// Delete removes the runbook of a check, so it has the one it ships with again
+func (s *Store) Delete(check string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := metadata.Get[Runbook](s.store, Namespace, check); err != nil {
+		if errors.Is(err, metadata.ErrNotFound) {
+			return fmt.Errorf("runbook of %s %w", check, ErrNotFound)
+		}
+		return err
+	}
+	return s.store.Delete(Namespace, check)
+}
+
+// validate checks the check name, link and remediation of a runbook
+func validate(rb Runbook) error {
+	if !validCheck.MatchString(rb.Check) {
+		return fmt.Errorf("%w check name %q: use up to 63 letters, digits, _, . and -", ErrInvalid, rb.Check)
+	}
+	if rb.URL == "" && rb.Remediation == "" {
+		return fmt.Errorf("%w runbook of %s: a URL or remediation is required", ErrInvalid, rb.Check)
+	}
+	if rb.URL != "" {
+		u, err := url.Parse(rb.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w runbook URL %q: use an absolute http or https URL", ErrInvalid, rb.URL)
+		}
+	}
+	if len(rb.Remediation) > MaxRemediationLength {
+		return fmt.Errorf("%w runbook of %s: the remediation must not exceed %d bytes", ErrInvalid, rb.Check, MaxRemediationLength)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package runbooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(metadata.NewMemoryStore())
+
+	flapping := Runbook{
+		Check:       "leader-flapping",
+		URL:         "https://wiki.example.com/armada/leader-flapping",
+		Remediation: "1. Check the network between the nodes\n2. Check the disk latency",
+	}
+	_, err := store.Put(flapping)
+	require.NoError(t, err)
+	_, err = store.Put(Runbook{Check: "clock-skew", Remediation: "Restart `chronyd` on the node"})
+	require.NoError(t, err)
+
+	got, err := store.Get("leader-flapping")
+	require.NoError(t, err)
+	assert.Equal(t, flapping, got)
+	list, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "clock-skew", list[0].Check)
+
+	require.NoError(t, store.Delete("clock-skew"))
+	_, err = store.Get("clock-skew")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete("clock-skew"), ErrNotFound)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		runbook Runbook
+	}{
+		{name: "InvalidCheck", runbook: Runbook{Check: "-flapping", URL: "https://wiki.example.com"}},
+		{name: "Empty", runbook: Runbook{Check: "leader-flapping"}},
+		{name: "RelativeURL", runbook: Runbook{Check: "leader-flapping", URL: "/wiki/leader-flapping"}},
+		{name: "OtherScheme", runbook: Runbook{Check: "leader-flapping", URL: "javascript:alert(1)"}},
+		{name: "RemediationTooLong", runbook: Runbook{Check: "leader-flapping", Remediation: strings.Repeat("x", MaxRemediationLength+1)}},
+	}
+	store := NewStore(metadata.NewMemoryStore())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Put(tt.runbook)
+			assert.ErrorIs(t, err, ErrInvalid)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+// secretValueGetter is the part of the Secrets Manager API used for resolving.
+// The *secretsmanager.SecretsManager implements this interface.
+type secretValueGetter interface {
+	GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager. The path of a reference is the name or
+// ARN of the secret, e.g. aws-sm://prod/armada#password reads the password field of the JSON
+// secret prod/armada, aws-sm://prod/armada-token the whole secret.
+type AWSProvider struct {
+	// client returns the Secrets Manager client, it is created on first use
+	client func() (secretValueGetter, error)
+}
+
+// NewAWSProvider creates a provider for Secrets Manager in the region, the region of the
+// environment or the shared configuration if it is empty. Credentials are taken from the
+// default chain, e.g. the environment, the shared configuration or the instance role. The
+// AWS session is only created by the first Fetch, so consoles not using AWS don't need its
+// configuration.
+func NewAWSProvider(region string, client *http.Client) *AWSProvider {
+	return &AWSProvider{client: sync.OnceValues(func() (secretValueGetter, error) {
+		cfg := aws.NewConfig().WithHTTPClient(client)
+		if region != "" {
+			cfg = cfg.WithRegion(region)
+		}
+		sess, err := session.NewSessionWithOptions(session.Options{
+			Config:            *cfg,
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AWS session: %w", err)
+		}
+		return secretsmanager.New(sess), nil
+	})}
+}
+
+// Fetch reads the current version of the secret with the name or ARN path. If key is not
+// empty, the secret must be a JSON object and the value of the field key is returned.
+func (p *AWSProvider) Fetch(ctx context.Context, path, key string) (string, error) {
+	client, err := p.client()
+	if err != nil {
+		return "", err
+	}
+	out, err := client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(path),
+	})
+	if err != nil {
+		return "", err
+	}
+	value := string(out.SecretBinary)
+	if out.SecretString != nil {
+		value = *out.SecretString
+	}
+	if key == "" {
+		return value, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, remove #%s from the reference", key)
+	}
+	field, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", key)
+	}
+	return stringValue(field), nil
+}
@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticSecrets serves secret strings by their ID
+type staticSecrets map[string]string
+
+func (s staticSecrets) GetSecretValueWithContext(_ aws.Context, input *secretsmanager.GetSecretValueInput, _ ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	value, ok := s[*input.SecretId]
+	if !ok {
+		return nil, &secretsmanager.ResourceNotFoundException{}
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func TestAWSProvider(t *testing.T) {
+	secrets := staticSecrets{
+		"prod/armada":       `{"username": "console", "password": "s3cret"}`,
+		"prod/armada-token": "token",
+	}
+	p := &AWSProvider{client: func() (secretValueGetter, error) { return secrets, nil }}
+
+	value, err := p.Fetch(context.Background(), "prod/armada", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	value, err = p.Fetch(context.Background(), "prod/armada-token", "")
+	require.NoError(t, err)
+	assert.Equal(t, "token", value)
+
+	_, err = p.Fetch(context.Background(), "prod/armada-token", "password")
+	assert.Error(t, err, "plain secrets have no fields")
+	_, err = p.Fetch(context.Background(), "prod/armada", "token")
+	assert.Error(t, err)
+	_, err = p.Fetch(context.Background(), "prod/missing", "")
+	assert.Error(t, err)
+}
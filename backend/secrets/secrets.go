@@ -0,0 +1,175 @@
+// Package secrets resolves configuration values from secret stores, so credentials don't have to
+// sit in configuration files or the environment of the console. A value is a reference if it
+// starts with the scheme of a provider, e.g. file:///run/secrets/token, env://ARMADA_TOKEN,
+// vault://secret/data/armada#password or aws-sm://prod/armada#password. Resolved values are
+// cached for a while, so the configuration can be reloaded periodically to pick up rotated
+// secrets without hitting the stores on every reload.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider fetches secrets from a store
+type Provider interface {
+	// Fetch returns the value of the secret at path. If key is not empty, the secret is a
+	// map, e.g. a Vault KV entry or a JSON object, and the value of key is returned.
+	Fetch(ctx context.Context, path, key string) (string, error)
+}
+
+// ProviderFunc adapts a function to a Provider
+type ProviderFunc func(ctx context.Context, path, key string) (string, error)
+
+// Fetch calls f
+func (f ProviderFunc) Fetch(ctx context.Context, path, key string) (string, error) {
+	return f(ctx, path, key)
+}
+
+// Reference is a parsed reference to a secret, scheme://path#key
+type Reference struct {
+	Scheme string
+	Path   string
+	Key    string
+}
+
+// String returns the reference in its scheme://path#key form
+func (r Reference) String() string {
+	if r.Key == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Key
+}
+
+// ParseReference splits a reference into its scheme, path and key.
+// The path is not parsed as a URL, so it may contain colons, e.g. an ARN.
+func ParseReference(raw string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok || scheme == "" || strings.ContainsAny(scheme, " /") {
+		return Reference{}, false
+	}
+	ref := Reference{Scheme: scheme, Path: rest}
+	if i := strings.LastIndex(rest, "#"); i >= 0 {
+		ref.Path, ref.Key = rest[:i], rest[i+1:]
+	}
+	return ref, true
+}
+
+// cached is a resolved secret
+type cached struct {
+	value   string
+	expires time.Time
+}
+
+// Resolver resolves references with the providers registered for their schemes.
+// It is safe for concurrent use.
+type Resolver struct {
+	ttl       time.Duration
+	providers map[string]Provider
+	now       func() time.Time
+
+	// mu protects cache
+	mu    sync.Mutex
+	cache map[string]cached
+}
+
+// Option configures optional behaviour of the Resolver
+type Option func(*Resolver)
+
+// WithProvider resolves the references with the scheme from the provider
+func WithProvider(scheme string, p Provider) Option {
+	return func(r *Resolver) {
+		r.providers[scheme] = p
+	}
+}
+
+// NewResolver creates a resolver caching resolved values for ttl, zero disables the cache.
+// The file and env schemes are always supported, e.g. file:///run/secrets/token reads the
+// token from a file and env://ARMADA_TOKEN from an environment variable.
+func NewResolver(ttl time.Duration, opts ...Option) *Resolver {
+	r := &Resolver{
+		ttl: ttl,
+		providers: map[string]Provider{
+			"file": ProviderFunc(readFile),
+			"env":  ProviderFunc(lookupEnv),
+		},
+		now:   time.Now,
+		cache: make(map[string]cached),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// IsReference reports whether the value is a reference to a secret of a registered provider.
+// Other values are used as they are, e.g. http://armada:5001 is no reference.
+func (r *Resolver) IsReference(value string) bool {
+	ref, ok := ParseReference(value)
+	if !ok {
+		return false
+	}
+	_, ok = r.providers[ref.Scheme]
+	return ok
+}
+
+// Resolve returns the value of the referenced secret, from the cache if it was resolved recently
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := ParseReference(value)
+	if !ok {
+		return "", fmt.Errorf("invalid secret reference %q", value)
+	}
+	provider, ok := r.providers[ref.Scheme]
+	if !ok {
+		return "", fmt.Errorf("unsupported secret store %q", ref.Scheme)
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	c, ok := r.cache[value]
+	r.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.value, nil
+	}
+
+	secret, err := provider.Fetch(ctx, ref.Path, ref.Key)
+	if err != nil {
+		// The reference doesn't reveal the secret, so it can be logged
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if r.ttl > 0 {
+		r.mu.Lock()
+		r.cache[value] = cached{value: secret, expires: now.Add(r.ttl)}
+		r.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// readFile reads a secret from a file, e.g. a mounted Kubernetes secret. Trailing newlines
+// are removed, as most tools write them.
+func readFile(_ context.Context, path, key string) (string, error) {
+	if key != "" {
+		return "", fmt.Errorf("files have no keys, got %q", key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// lookupEnv reads a secret from an environment variable
+func lookupEnv(_ context.Context, name, key string) (string, error) {
+	if key != "" {
+		return "", fmt.Errorf("environment variables have no keys, got %q", key)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,87 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want Reference
+		ok   bool
+	}{
+		{raw: "vault://secret/data/armada#password", want: Reference{Scheme: "vault", Path: "secret/data/armada", Key: "password"}, ok: true},
+		{raw: "file:///run/secrets/token", want: Reference{Scheme: "file", Path: "/run/secrets/token"}, ok: true},
+		{
+			raw:  "aws-sm://arn:aws:secretsmanager:eu-west-1:123456789012:secret:armada#password",
+			want: Reference{Scheme: "aws-sm", Path: "arn:aws:secretsmanager:eu-west-1:123456789012:secret:armada", Key: "password"},
+			ok:   true,
+		},
+		{raw: "plain value"},
+		{raw: "X-Team: ops://x"},
+	}
+	for _, tt := range tests {
+		ref, ok := ParseReference(tt.raw)
+		assert.Equal(t, tt.ok, ok, tt.raw)
+		assert.Equal(t, tt.want, ref, tt.raw)
+	}
+}
+
+func TestResolverBuiltinProviders(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(file, []byte("from-file\n"), 0o600))
+	t.Setenv("SECRETS_TEST_TOKEN", "from-env")
+
+	r := NewResolver(0)
+	assert.True(t, r.IsReference("file://"+file))
+	assert.False(t, r.IsReference("http://armada:5001"), "only schemes of providers are references")
+
+	value, err := r.Resolve(context.Background(), "file://"+file)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+
+	value, err = r.Resolve(context.Background(), "env://SECRETS_TEST_TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	_, err = r.Resolve(context.Background(), "env://SECRETS_TEST_MISSING")
+	assert.Error(t, err)
+	_, err = r.Resolve(context.Background(), "vault://secret/data/armada#password")
+	assert.ErrorContains(t, err, "unsupported secret store")
+}
+
+func TestResolverCache(t *testing.T) {
+	fetches := 0
+	version := "v1"
+	provider := ProviderFunc(func(_ context.Context, path, key string) (string, error) {
+		fetches++
+		return path + "#" + key + "@" + version, nil
+	})
+	now := time.Now()
+	r := NewResolver(time.Minute, WithProvider("test", provider))
+	r.now = func() time.Time { return now }
+
+	value, err := r.Resolve(context.Background(), "test://token#key")
+	require.NoError(t, err)
+	assert.Equal(t, "token#key@v1", value)
+
+	// The secret is rotated, the cached value is used until it expires
+	version = "v2"
+	value, err = r.Resolve(context.Background(), "test://token#key")
+	require.NoError(t, err)
+	assert.Equal(t, "token#key@v1", value)
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(time.Minute)
+	value, err = r.Resolve(context.Background(), "test://token#key")
+	require.NoError(t, err)
+	assert.Equal(t, "token#key@v2", value)
+	assert.Equal(t, 2, fetches)
+}
@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultProvider reads secrets from the KV secrets engine of HashiCorp Vault, both version 1 and 2.
+// The path of a reference is the API path below /v1, e.g. vault://secret/data/armada#password
+// reads the password field of the KV v2 entry armada in the secret mount.
+type VaultProvider struct {
+	// Address is the base URL of Vault, e.g. "https://vault:8200".
+	Address string
+
+	// Token authenticates the requests.
+	Token string
+
+	// TokenFile is read on every request instead of Token, e.g. the sink of a Vault agent
+	// renewing the token.
+	TokenFile string
+
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string
+
+	// HTTPClient is used to call the Vault API.
+	HTTPClient *http.Client
+}
+
+// vaultResponse is the subset of a Vault secret response used for resolving
+type vaultResponse struct {
+	Data map[string]any `json:"data"`
+}
+
+// NewVaultProvider creates a provider for the Vault at address
+func NewVaultProvider(address, token string) *VaultProvider {
+	return &VaultProvider{
+		Address:    address,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Fetch reads the field key of the secret at path. Every secret of the KV engine is a map,
+// so key is required.
+func (p *VaultProvider) Fetch(ctx context.Context, path, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("vault secrets are maps, add the field to the reference, e.g. #password")
+	}
+	token := p.Token
+	if p.TokenFile != "" {
+		data, err := os.ReadFile(p.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read Vault token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	endpoint := strings.TrimSuffix(p.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Vault request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.Namespace)
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var secret vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	fields := secret.Data
+	// KV v2 nests the fields under data next to the metadata of the version
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", key)
+	}
+	return stringValue(value), nil
+}
+
+// stringValue returns strings as they are and encodes other values as JSON
+func stringValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/secret/data/armada":
+			_, _ = w.Write([]byte(`{"data": {"data": {"password": "s3cret", "port": 5001}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/armada":
+			_, _ = w.Write([]byte(`{"data": {"password": "v1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := NewVaultProvider(server.URL, "vault-token")
+	value, err := p.Fetch(context.Background(), "secret/data/armada", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	value, err = p.Fetch(context.Background(), "secret/data/armada", "port")
+	require.NoError(t, err)
+	assert.Equal(t, "5001", value)
+
+	value, err = p.Fetch(context.Background(), "kv/armada", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "v1-secret", value)
+
+	_, err = p.Fetch(context.Background(), "secret/data/armada", "user")
+	assert.Error(t, err)
+	_, err = p.Fetch(context.Background(), "secret/data/missing", "password")
+	assert.ErrorContains(t, err, "404")
+	_, err = p.Fetch(context.Background(), "secret/data/armada", "")
+	assert.Error(t, err, "the field is required")
+
+	// A token renewed by a Vault agent is read from its sink on every request
+	p.Token = ""
+	p.TokenFile = filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(p.TokenFile, []byte("vault-token\n"), 0o600))
+	_, err = p.Fetch(context.Background(), "secret/data/armada", "password")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,261 @@
+// Package shadow mirrors the writes made through the console to a secondary cluster, e.g. to
+// rehearse a migration with real traffic. Writes are mirrored in the background in the order they
+// were made; those that can't be mirrored are kept for the report and can be retried.
+package shadow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"go.uber.org/zap"
+)
+
+// mirrorTimeout bounds mirroring a single write
+const mirrorTimeout = 10 * time.Second
+
+// errQueueFull is recorded for writes that arrived while the queue was full
+var errQueueFull = errors.New("shadow queue is full")
+
+// Target applies the mirrored writes. The armada.Client of the secondary cluster implements it.
+type Target interface {
+	PutKeyValue(ctx context.Context, table, key, value string) error
+	DeleteKey(ctx context.Context, table, key string) error
+	DeletePrefix(ctx context.Context, table, prefix string) (int64, error)
+	CreateTable(ctx context.Context, name string) (string, error)
+	DeleteTable(ctx context.Context, name string) error
+}
+
+// OpType is the kind of a mirrored write
+type OpType string
+
+const (
+	OpPut          OpType = "put"
+	OpDelete       OpType = "delete"
+	OpDeletePrefix OpType = "deletePrefix"
+	OpCreateTable  OpType = "createTable"
+	OpDeleteTable  OpType = "deleteTable"
+)
+
+// Op is a write made through the console
+type Op struct {
+	Type  OpType `json:"type"`
+	Table string `json:"table"`
+	// Key is the key or prefix written, empty for table operations
+	Key string `json:"key,omitempty"`
+	// Value is the value put, it is left out of reports
+	Value string `json:"-"`
+	// At is when the write was made on the primary cluster
+	At time.Time `json:"at"`
+}
+
+// Failure is a write that couldn't be mirrored
+type Failure struct {
+	Op       Op        `json:"op"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failedAt"`
+	// Attempts counts how often mirroring the write was tried
+	Attempts int `json:"attempts"`
+}
+
+// Report describes the state of mirroring
+type Report struct {
+	// Cluster is the secondary cluster the writes are mirrored to
+	Cluster string `json:"cluster"`
+	// Mirrored counts the writes applied to the secondary cluster
+	Mirrored int64 `json:"mirrored"`
+	// Failed counts the attempts that failed, including writes that didn't fit into the queue
+	Failed int64 `json:"failed"`
+	// Pending is the number of writes waiting to be mirrored
+	Pending int `json:"pending"`
+	// Failures are the writes that weren't mirrored yet, oldest first
+	Failures []Failure `json:"failures"`
+	// DroppedFailures counts failures that were discarded to keep at most the configured number
+	DroppedFailures int64 `json:"droppedFailures"`
+}
+
+// entry is a queued write
+type entry struct {
+	op       Op
+	attempts int
+}
+
+// Mirror applies the writes it is given to the secondary cluster in the background
+type Mirror struct {
+	target      Target
+	cluster     string
+	logger      *zap.Logger
+	queue       chan entry
+	maxFailures int
+
+	mu              sync.Mutex
+	failures        []Failure
+	mirrored        int64
+	failed          int64
+	droppedFailures int64
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMirror creates a Mirror to the cluster, queueing at most queueSize writes and keeping
+// at most maxFailures failed ones
+func NewMirror(target Target, cluster string, queueSize, maxFailures int, logger *zap.Logger) *Mirror {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Mirror{
+		target:      target,
+		cluster:     cluster,
+		logger:      logger.Named("shadow"),
+		queue:       make(chan entry, queueSize),
+		maxFailures: maxFailures,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start begins mirroring in the background
+func (m *Mirror) Start(ctx context.Context) {
+	go m.run(ctx)
+}
+
+// Stop stops mirroring. Writes still queued aren't mirrored.
+func (m *Mirror) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+}
+
+// run mirrors the queued writes one at a time, so they are applied in order
+func (m *Mirror) run(ctx context.Context) {
+	for {
+		select {
+		case e := <-m.queue:
+			m.apply(ctx, e)
+		case <-m.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Enqueue queues a write made on the primary cluster for mirroring. It never blocks: writes
+// arriving while the queue is full are recorded as failures.
+func (m *Mirror) Enqueue(op Op) {
+	if op.At.IsZero() {
+		op.At = time.Now()
+	}
+	select {
+	case m.queue <- entry{op: op}:
+	default:
+		m.fail(entry{op: op}, errQueueFull)
+	}
+}
+
+// apply mirrors a single write
+func (m *Mirror) apply(ctx context.Context, e entry) {
+	ctx, cancel := context.WithTimeout(ctx, mirrorTimeout)
+	defer cancel()
+
+	e.attempts++
+	if err := m.write(ctx, e.op); err != nil {
+		m.fail(e, err)
+		return
+	}
+	m.mu.Lock()
+	m.mirrored++
+	m.mu.Unlock()
+}
+
+// write applies op to the target. Creating an existing table or deleting a missing one succeeds,
+// so a secondary cluster prepared beforehand isn't reported.
+func (m *Mirror) write(ctx context.Context, op Op) error {
+	var err error
+	switch op.Type {
+	case OpPut:
+		err = m.target.PutKeyValue(ctx, op.Table, op.Key, op.Value)
+	case OpDelete:
+		err = m.target.DeleteKey(ctx, op.Table, op.Key)
+	case OpDeletePrefix:
+		_, err = m.target.DeletePrefix(ctx, op.Table, op.Key)
+	case OpCreateTable:
+		if _, err = m.target.CreateTable(ctx, op.Table); errors.Is(err, armada.ErrTableExists) {
+			err = nil
+		}
+	case OpDeleteTable:
+		if err = m.target.DeleteTable(ctx, op.Table); errors.Is(err, armada.ErrTableNotFound) {
+			err = nil
+		}
+	default:
+		err = fmt.Errorf("unknown operation %q", op.Type)
+	}
+	return err
+}
+
+// fail records a write that couldn't be mirrored, discarding the oldest failure beyond maxFailures
+func (m *Mirror) fail(e entry, err error) {
+	m.logger.Warn("Failed to mirror write",
+		zap.String("cluster", m.cluster),
+		zap.String("type", string(e.op.Type)),
+		zap.String("table", e.op.Table),
+		zap.String("key", e.op.Key),
+		zap.Error(err))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failed++
+	m.failures = append(m.failures, Failure{Op: e.op, Error: err.Error(), FailedAt: time.Now(), Attempts: e.attempts})
+	if over := len(m.failures) - m.maxFailures; over > 0 {
+		m.failures = m.failures[over:]
+		m.droppedFailures += int64(over)
+	}
+}
+
+// Retry queues the failed writes again, oldest first, and returns how many were queued.
+// Failures that don't fit into the queue are kept. Retried writes are applied after the
+// writes made since, so they may overwrite newer values on the secondary cluster.
+func (m *Mirror) Retry() int {
+	m.mu.Lock()
+	failures := m.failures
+	m.failures = nil
+	m.mu.Unlock()
+
+	for i, f := range failures {
+		select {
+		case m.queue <- entry{op: f.Op, attempts: f.Attempts}:
+		default:
+			m.mu.Lock()
+			m.failures = append(failures[i:], m.failures...)
+			m.mu.Unlock()
+			return i
+		}
+	}
+	return len(failures)
+}
+
+// ClearFailures discards the failed writes and returns how many there were
+func (m *Mirror) ClearFailures() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.failures)
+	m.failures = nil
+	return n
+}
+
+// Report returns the state of mirroring
+func (m *Mirror) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Report{
+		Cluster:         m.cluster,
+		Mirrored:        m.mirrored,
+		Failed:          m.failed,
+		Pending:         len(m.queue),
+		Failures:        append(make([]Failure, 0, len(m.failures)), m.failures...),
+		DroppedFailures: m.droppedFailures,
+	}
+}
@@ -0,0 +1,157 @@
+package shadow
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTarget keeps the mirrored writes in memory and fails those of the "broken" table
+type fakeTarget struct {
+	mu     sync.Mutex
+	tables map[string]map[string]string
+}
+
+func (f *fakeTarget) PutKeyValue(_ context.Context, table, key, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if table == "broken" {
+		return errors.New("unavailable")
+	}
+	f.tables[table][key] = value
+	return nil
+}
+
+func (f *fakeTarget) DeleteKey(_ context.Context, table, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.tables[table], key)
+	return nil
+}
+
+func (f *fakeTarget) DeletePrefix(_ context.Context, table, prefix string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deleted int64
+	for key := range f.tables[table] {
+		if strings.HasPrefix(key, prefix) {
+			delete(f.tables[table], key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (f *fakeTarget) CreateTable(_ context.Context, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.tables[name]; ok {
+		return "", armada.ErrTableExists
+	}
+	f.tables[name] = make(map[string]string)
+	return name, nil
+}
+
+func (f *fakeTarget) DeleteTable(_ context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.tables[name]; !ok {
+		return armada.ErrTableNotFound
+	}
+	delete(f.tables, name)
+	return nil
+}
+
+// pairs returns a copy of the pairs of a table
+func (f *fakeTarget) pairs(table string) map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return maps.Clone(f.tables[table])
+}
+
+func TestMirror(t *testing.T) {
+	target := &fakeTarget{tables: map[string]map[string]string{"existing": {}, "broken": {}}}
+	m := NewMirror(target, "staging", 100, 10, nil)
+	m.Start(context.Background())
+	defer m.Stop()
+
+	for _, op := range []Op{
+		{Type: OpCreateTable, Table: "users"},
+		{Type: OpCreateTable, Table: "existing"},
+		{Type: OpPut, Table: "users", Key: "user/1", Value: "alice"},
+		{Type: OpPut, Table: "users", Key: "user/2", Value: "bob"},
+		{Type: OpPut, Table: "users", Key: "user/2", Value: "carol"},
+		{Type: OpPut, Table: "users", Key: "admin/1", Value: "dave"},
+		{Type: OpDelete, Table: "users", Key: "user/1"},
+		{Type: OpDeletePrefix, Table: "users", Key: "admin/"},
+		{Type: OpDeleteTable, Table: "missing"},
+		{Type: OpPut, Table: "broken", Key: "key", Value: "value"},
+	} {
+		m.Enqueue(op)
+	}
+	require.Eventually(t, func() bool {
+		r := m.Report()
+		return r.Mirrored+r.Failed == 10
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, map[string]string{"user/2": "carol"}, target.pairs("users"), "writes are applied in order")
+	report := m.Report()
+	assert.Equal(t, "staging", report.Cluster)
+	assert.Equal(t, int64(9), report.Mirrored, "existing and missing tables aren't failures")
+	assert.Equal(t, int64(1), report.Failed)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, "broken", report.Failures[0].Op.Table)
+	assert.Equal(t, "unavailable", report.Failures[0].Error)
+	assert.Equal(t, 1, report.Failures[0].Attempts)
+
+	// Retried writes are tried again and fail once more
+	assert.Equal(t, 1, m.Retry())
+	require.Eventually(t, func() bool {
+		return m.Report().Failed == 2
+	}, time.Second, time.Millisecond)
+	report = m.Report()
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, 2, report.Failures[0].Attempts)
+
+	assert.Equal(t, 1, m.ClearFailures())
+	assert.Empty(t, m.Report().Failures)
+}
+
+func TestMirrorQueueFull(t *testing.T) {
+	target := &fakeTarget{tables: map[string]map[string]string{"users": {}}}
+	// The mirror isn't started, so nothing leaves the queue
+	m := NewMirror(target, "staging", 2, 2, nil)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		m.Enqueue(Op{Type: OpPut, Table: "users", Key: key})
+	}
+
+	report := m.Report()
+	assert.Equal(t, 2, report.Pending)
+	assert.Equal(t, int64(3), report.Failed)
+	require.Len(t, report.Failures, 2, "only the newest failures are kept")
+	assert.Equal(t, "d", report.Failures[0].Op.Key)
+	assert.Equal(t, int64(1), report.DroppedFailures)
+	assert.Equal(t, errQueueFull.Error(), report.Failures[0].Error)
+	assert.Equal(t, 0, m.Retry(), "failures stay while the queue is full")
+	assert.Len(t, m.Report().Failures, 2)
+
+	m.Start(context.Background())
+	defer m.Stop()
+	require.Eventually(t, func() bool {
+		return m.Report().Mirrored == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 2, m.Retry())
+	require.Eventually(t, func() bool {
+		return m.Report().Mirrored == 4
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "d", "e"}, slices.Sorted(maps.Keys(target.pairs("users"))))
+}
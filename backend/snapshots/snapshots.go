@@ -0,0 +1,170 @@
+// Package snapshots keeps the query results of a dashboard as they were when the snapshot was
+// taken, together with the queries and time range they came from. Snapshots are never changed,
+// so a shared link shows exactly what its author saw, even after the metrics left the retention.
+package snapshots
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/panels"
+)
+
+// Namespace is the metadata namespace the snapshots are stored in
+const Namespace = "snapshots"
+
+// maxTitleLength limits the titles of snapshots and their panels
+const maxTitleLength = 200
+
+// MaxBytes limits the encoded size of a snapshot, as every snapshot is kept in the metadata store
+const MaxBytes = 16 << 20
+
+var (
+	// ErrNotFound is returned when a snapshot does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for snapshots that can't be stored
+	ErrInvalid = errors.New("invalid")
+)
+
+// Range is the time range the queries of a snapshot were evaluated over, as requested
+type Range struct {
+	// Time is the evaluation time of instant queries
+	Time string `json:"time,omitempty"`
+	// Start, End and Step are the range of range queries
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	Step  string `json:"step,omitempty"`
+}
+
+// Panel is the result of a query of a snapshot
+type Panel struct {
+	Title string `json:"title,omitempty"`
+	// Request is the query as the dashboard sent it, with its evaluation time filled in
+	Request metrics.BatchQuery `json:"request"`
+	// Query is the evaluated PromQL query, e.g. the query of a saved panel at the time
+	Query string `json:"query,omitempty"`
+	// Status is success or error
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Result is the query result as returned by /api/metrics/query and /api/metrics/query_range
+	Result json.RawMessage `json:"result,omitempty"`
+	// Annotations are the unit and thresholds of the saved panel the query was taken from, if any
+	Annotations *panels.Annotations `json:"annotations,omitempty"`
+}
+
+// Snapshot is the stored state of a dashboard
+type Snapshot struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	Range
+	Panels    []Panel   `json:"panels"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Summary describes a snapshot without its results
+type Summary struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	Range
+	// Panels is the number of panels of the snapshot
+	Panels    int       `json:"panels"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store keeps the snapshots in the metadata store
+type Store struct {
+	store metadata.Store
+}
+
+// NewStore creates a snapshot store on top of the metadata store
+func NewStore(store metadata.Store) *Store {
+	return &Store{store: store}
+}
+
+// Create validates and stores a new snapshot, assigning its ID. The ID is random, so only the
+// people the link is shared with find the snapshot.
+func (s *Store) Create(snap Snapshot) (Snapshot, error) {
+	if len(snap.Title) > maxTitleLength {
+		return Snapshot{}, fmt.Errorf("%w snapshot title: use up to %d characters", ErrInvalid, maxTitleLength)
+	}
+	if len(snap.Panels) == 0 {
+		return Snapshot{}, fmt.Errorf("%w snapshot: at least one panel is required", ErrInvalid)
+	}
+	for _, p := range snap.Panels {
+		if len(p.Title) > maxTitleLength {
+			return Snapshot{}, fmt.Errorf("%w panel title: use up to %d characters", ErrInvalid, maxTitleLength)
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Snapshot{}, fmt.Errorf("failed to generate snapshot ID: %w", err)
+	}
+	snap.ID = hex.EncodeToString(id)
+	if snap.CreatedAt.IsZero() {
+		snap.CreatedAt = time.Now().UTC()
+	}
+
+	raw, err := json.Marshal(snap)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if len(raw) > MaxBytes {
+		return Snapshot{}, fmt.Errorf("%w snapshot: its results take %d bytes, at most %d are stored; use fewer panels, a shorter range or a larger step",
+			ErrInvalid, len(raw), MaxBytes)
+	}
+	if err := s.store.Put(Namespace, snap.ID, raw); err != nil {
+		return Snapshot{}, err
+	}
+	return snap, nil
+}
+
+// Get returns a snapshot with its results
+func (s *Store) Get(id string) (Snapshot, error) {
+	snap, err := metadata.Get[Snapshot](s.store, Namespace, id)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return Snapshot{}, fmt.Errorf("snapshot %s %w", id, ErrNotFound)
+	}
+	return snap, err
+}
+
+// List returns the summaries of the snapshots, the newest first
+func (s *Store) List() ([]Summary, error) {
+	stored, err := metadata.List[Snapshot](s.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Summary, 0, len(stored))
+	for _, snap := range stored {
+		list = append(list, Summary{
+			ID:        snap.ID,
+			Title:     snap.Title,
+			Range:     snap.Range,
+			Panels:    len(snap.Panels),
+			CreatedBy: snap.CreatedBy,
+			CreatedAt: snap.CreatedAt,
+		})
+	}
+	slices.SortFunc(list, func(a, b Summary) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	return list, nil
+}
+
+// Delete removes a snapshot
+func (s *Store) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return s.store.Delete(Namespace, id)
+}
@@ -0,0 +1,59 @@
+package snapshots
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(metadata.NewMemoryStore())
+
+	older, err := store.Create(Snapshot{
+		Title:     "Before the upgrade",
+		Range:     Range{Start: "1700000000", End: "1700003600", Step: "1m"},
+		Panels:    []Panel{{Request: metrics.BatchQuery{ID: "puts", Query: "sum(rate(armada_puts_total[5m]))"}, Status: "success", Result: json.RawMessage(`{"resultType":"matrix","result":[]}`)}},
+		CreatedAt: time.Unix(1700003600, 0).UTC(),
+	})
+	require.NoError(t, err)
+	assert.Len(t, older.ID, 32)
+	newer, err := store.Create(Snapshot{Panels: []Panel{{Request: metrics.BatchQuery{ID: "up", Query: "up"}, Status: "error", Error: "Query execution failed"}}})
+	require.NoError(t, err)
+	assert.False(t, newer.CreatedAt.IsZero())
+
+	got, err := store.Get(older.ID)
+	require.NoError(t, err)
+	assert.Equal(t, older, got)
+
+	list, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, newer.ID, list[0].ID, "the newest snapshot is listed first")
+	assert.Equal(t, Summary{ID: older.ID, Title: "Before the upgrade", Range: older.Range, Panels: 1, CreatedAt: older.CreatedAt}, list[1])
+
+	require.NoError(t, store.Delete(older.ID))
+	_, err = store.Get(older.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, store.Delete(older.ID), ErrNotFound)
+}
+
+func TestStoreRejectsInvalidSnapshots(t *testing.T) {
+	store := NewStore(metadata.NewMemoryStore())
+	panel := Panel{Request: metrics.BatchQuery{ID: "up", Query: "up"}, Status: "success"}
+
+	for name, snap := range map[string]Snapshot{
+		"no panels":  {Title: "Empty"},
+		"long title": {Title: strings.Repeat("a", maxTitleLength+1), Panels: []Panel{panel}},
+		"long panel": {Panels: []Panel{{Title: strings.Repeat("a", maxTitleLength+1)}}},
+		"too large":  {Panels: []Panel{{Status: "success", Result: json.RawMessage(`"` + strings.Repeat("a", MaxBytes) + `"`)}}},
+	} {
+		_, err := store.Create(snap)
+		assert.ErrorIs(t, err, ErrInvalid, name)
+	}
+}
@@ -0,0 +1,208 @@
+// Package stats periodically samples table statistics from the Armada cluster
+// and caches them, so endpoints can sort and summarize tables by size without
+// querying every server on each request.
+package stats
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"go.uber.org/zap"
+)
+
+// TableStats are the sampled statistics of a single table.
+type TableStats struct {
+	// DBSize is the size of the table's database in bytes.
+	DBSize int64 `json:"dbSize"`
+	// LogSize is the size of the table's raft log in bytes.
+	LogSize int64 `json:"logSize"`
+	// SampledAt is when the statistics were collected.
+	SampledAt time.Time `json:"sampledAt"`
+}
+
+// Source provides the cluster status the sampler reads table statistics from.
+// The armada.Client implements this interface.
+type Source interface {
+	// GetAllServers retrieves all servers in the Armada cluster.
+	GetAllServers(ctx context.Context) ([]armada.Server, error)
+
+	// GetStatus retrieves the status of the server at the given address.
+	GetStatus(ctx context.Context, serverAddress string) (*armada.Status, error)
+}
+
+// Observation is the cluster state seen by one sampling round.
+type Observation struct {
+	// Time is when the round started.
+	Time time.Time
+	// Servers are the cluster members.
+	Servers []armada.Server
+	// Statuses holds the status reported by every server that answered, by server ID.
+	Statuses map[string]*armada.Status
+}
+
+// Observer is notified after every sampling round in which at least one server answered.
+// It is called synchronously from the sampling goroutine.
+type Observer interface {
+	Observe(o Observation)
+}
+
+// SamplerOption configures optional behaviour of the Sampler
+type SamplerOption func(*Sampler)
+
+// WithObserver passes every sampled cluster state to the observer, so other components
+// can follow the cluster without polling the servers again
+func WithObserver(o Observer) SamplerOption {
+	return func(s *Sampler) {
+		s.observers = append(s.observers, o)
+	}
+}
+
+// Sampler periodically collects table statistics and caches the latest sample.
+type Sampler struct {
+	source   Source
+	interval time.Duration
+	logger   *zap.Logger
+	// observers are notified of every sample
+	observers []Observer
+
+	// mu protects tables and latest
+	mu sync.RWMutex
+	// tables holds the latest statistics by table name
+	tables map[string]TableStats
+	// latest is the cluster state of the latest sample, zero before the first one
+	latest Observation
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSampler creates a new Sampler that collects statistics at the given interval
+func NewSampler(source Source, interval time.Duration, logger *zap.Logger, opts ...SamplerOption) *Sampler {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	s := &Sampler{
+		source:   source,
+		interval: interval,
+		logger:   logger.Named("stats-sampler"),
+		tables:   make(map[string]TableStats),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start begins periodic sampling in the background
+func (s *Sampler) Start(ctx context.Context) {
+	go s.run(ctx)
+}
+
+// Stop stops periodic sampling
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// Table returns the latest statistics of a table, if it has been sampled
+func (s *Sampler) Table(name string) (TableStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ts, ok := s.tables[name]
+	return ts, ok
+}
+
+// Tables returns the latest statistics of all sampled tables
+func (s *Sampler) Tables() map[string]TableStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.tables)
+}
+
+// Latest returns the cluster state seen by the latest sample, if any
+func (s *Sampler) Latest() (Observation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest, !s.latest.Time.IsZero()
+}
+
+// run samples immediately and then at every interval
+func (s *Sampler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.Sample(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Sample(ctx)
+		case <-s.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Sample collects statistics from every server once and replaces the cached sample.
+// For each table the leader's view is preferred; if the leader could not be reached the
+// largest size reported by a follower is used. The previous sample is kept if no server answered.
+func (s *Sampler) Sample(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, s.interval)
+	defer cancel()
+
+	servers, err := s.source.GetAllServers(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to list servers, keeping previous table statistics", zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	tables := make(map[string]TableStats)
+	fromLeader := make(map[string]bool)
+	statuses := make(map[string]*armada.Status)
+	for _, server := range servers {
+		if len(server.ClientURLs) == 0 {
+			continue
+		}
+		status, err := s.source.GetStatus(ctx, server.ClientURLs[0])
+		if err != nil || status.Status == "error" {
+			s.logger.Debug("Failed to sample server", zap.String("serverID", server.ID), zap.Error(err))
+			continue
+		}
+		statuses[server.ID] = status
+
+		for name, ts := range status.Tables {
+			isLeader := ts.Leader == server.ID
+			current, seen := tables[name]
+			if seen && (fromLeader[name] || (!isLeader && current.DBSize >= ts.DBSize)) {
+				continue
+			}
+			tables[name] = TableStats{DBSize: ts.DBSize, LogSize: ts.LogSize, SampledAt: now}
+			fromLeader[name] = isLeader
+		}
+	}
+
+	if len(statuses) == 0 && len(servers) > 0 {
+		s.logger.Warn("No server answered, keeping previous table statistics")
+		return
+	}
+
+	observation := Observation{Time: now, Servers: servers, Statuses: statuses}
+	s.mu.Lock()
+	s.tables = tables
+	s.latest = observation
+	s.mu.Unlock()
+	s.logger.Debug("Sampled table statistics", zap.Int("tables", len(tables)), zap.Int("servers", len(statuses)))
+
+	for _, o := range s.observers {
+		o.Observe(observation)
+	}
+}
@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeSource serves a fixed status per server address
+type fakeSource struct {
+	servers    []armada.Server
+	serversErr error
+	statuses   map[string]*armada.Status
+}
+
+func (f *fakeSource) GetAllServers(_ context.Context) ([]armada.Server, error) {
+	return f.servers, f.serversErr
+}
+
+func (f *fakeSource) GetStatus(_ context.Context, address string) (*armada.Status, error) {
+	status, ok := f.statuses[address]
+	if !ok {
+		return nil, errors.New("unreachable")
+	}
+	return status, nil
+}
+
+func threeServers() []armada.Server {
+	return []armada.Server{
+		{ID: "1", ClientURLs: []string{"http://a:5001"}},
+		{ID: "2", ClientURLs: []string{"http://b:5001"}},
+		{ID: "3", ClientURLs: []string{"http://c:5001"}},
+	}
+}
+
+func TestSamplerPrefersLeader(t *testing.T) {
+	source := &fakeSource{
+		servers: threeServers(),
+		statuses: map[string]*armada.Status{
+			"http://a:5001": {Status: "ok", Tables: map[string]armada.TableStatus{
+				"users": {DBSize: 900, LogSize: 10, Leader: "2"},
+			}},
+			"http://b:5001": {Status: "ok", Tables: map[string]armada.TableStatus{
+				"users": {DBSize: 800, LogSize: 20, Leader: "2"},
+			}},
+		},
+	}
+	s := NewSampler(source, 15*time.Second, zap.NewNop())
+	s.Sample(context.Background())
+
+	ts, ok := s.Table("users")
+	require.True(t, ok)
+	assert.Equal(t, int64(800), ts.DBSize)
+	assert.Equal(t, int64(20), ts.LogSize)
+	assert.False(t, ts.SampledAt.IsZero())
+}
+
+func TestSamplerFallsBackToLargestFollower(t *testing.T) {
+	source := &fakeSource{
+		servers: threeServers(),
+		statuses: map[string]*armada.Status{
+			"http://a:5001": {Status: "ok", Tables: map[string]armada.TableStatus{
+				"users": {DBSize: 500, Leader: "3"},
+			}},
+			"http://b:5001": {Status: "ok", Tables: map[string]armada.TableStatus{
+				"users": {DBSize: 700, Leader: "3"},
+			}},
+		},
+	}
+	s := NewSampler(source, 15*time.Second, zap.NewNop())
+	s.Sample(context.Background())
+
+	ts, ok := s.Table("users")
+	require.True(t, ok)
+	assert.Equal(t, int64(700), ts.DBSize)
+}
+
+func TestSamplerKeepsPreviousSample(t *testing.T) {
+	source := &fakeSource{
+		servers: threeServers(),
+		statuses: map[string]*armada.Status{
+			"http://a:5001": {Status: "ok", Tables: map[string]armada.TableStatus{"users": {DBSize: 1}}},
+		},
+	}
+	s := NewSampler(source, 15*time.Second, zap.NewNop())
+	_, ok := s.Latest()
+	assert.False(t, ok, "nothing should be observed before the first sample")
+	s.Sample(context.Background())
+	require.Len(t, s.Tables(), 1)
+	latest, ok := s.Latest()
+	require.True(t, ok)
+
+	source.statuses = nil
+	s.Sample(context.Background())
+	assert.Len(t, s.Tables(), 1, "sample should be kept when no server answers")
+
+	source.serversErr = errors.New("unreachable")
+	s.Sample(context.Background())
+	assert.Len(t, s.Tables(), 1, "sample should be kept when servers can't be listed")
+
+	// The age of the latest observation tells how stale the statistics are
+	o, _ := s.Latest()
+	assert.Equal(t, latest.Time, o.Time)
+}
+
+// recordingObserver keeps every observation it is notified of
+type recordingObserver struct {
+	observations []Observation
+}
+
+func (r *recordingObserver) Observe(o Observation) {
+	r.observations = append(r.observations, o)
+}
+
+func TestSamplerNotifiesObservers(t *testing.T) {
+	source := &fakeSource{
+		servers: threeServers(),
+		statuses: map[string]*armada.Status{
+			"http://a:5001": {Status: "ok", Tables: map[string]armada.TableStatus{"users": {Leader: "1"}}},
+		},
+	}
+	observer := &recordingObserver{}
+	s := NewSampler(source, 15*time.Second, zap.NewNop(), WithObserver(observer))
+	s.Sample(context.Background())
+
+	require.Len(t, observer.observations, 1)
+	o := observer.observations[0]
+	assert.Len(t, o.Servers, 3)
+	assert.Len(t, o.Statuses, 1)
+	assert.Equal(t, "1", o.Statuses["1"].Tables["users"].Leader)
+
+	// Rounds in which no server answered are not observed
+	source.statuses = nil
+	s.Sample(context.Background())
+	assert.Len(t, observer.observations, 1)
+}
@@ -0,0 +1,334 @@
+// Package topology records the history of the cluster topology: which servers
+// were members and which member led each table. The history is compacted so that
+// only changes are stored, and is kept in the metadata store so it survives restarts
+// and can be consulted during postmortems.
+package topology
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armadakv/console/backend/events"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"go.uber.org/zap"
+)
+
+// Namespace is the metadata namespace the history is stored in
+const Namespace = "topology"
+
+// Member is a server that was part of the cluster.
+type Member struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Leadership is who led a table and in which raft term.
+type Leadership struct {
+	// Leader is the ID of the leading member, empty while the table has no leader.
+	Leader string `json:"leader"`
+	// Term is the raft term the leader was elected in.
+	Term uint64 `json:"term"`
+}
+
+// Snapshot is a topology that was in effect without change from Since until at least LastSeen.
+type Snapshot struct {
+	// Since is when the topology was first observed.
+	Since time.Time `json:"since"`
+	// LastSeen is when the topology was last observed.
+	LastSeen time.Time `json:"lastSeen"`
+	// Members are the servers of the cluster, ordered by ID.
+	Members []Member `json:"members"`
+	// Tables maps table names to their leadership.
+	Tables map[string]Leadership `json:"tables"`
+	// LeaderChanges counts the leader elections of every table since it was first recorded.
+	// The counts are carried over from snapshot to snapshot, so they are not lost when old
+	// snapshots are pruned.
+	LeaderChanges map[string]uint64 `json:"leaderChanges,omitempty"`
+}
+
+// sameTopology reports whether two snapshots describe the same members and leaders
+func (s Snapshot) sameTopology(other Snapshot) bool {
+	return slices.Equal(s.Members, other.Members) && maps.Equal(s.Tables, other.Tables)
+}
+
+// countLeaderChanges carries the leader change counts of the previous snapshot over,
+// counting a change for every table that elected a new leader or re-elected its leader in a new term
+func (s *Snapshot) countLeaderChanges(prev *Snapshot) {
+	s.LeaderChanges = make(map[string]uint64, len(s.Tables))
+	for name, current := range s.Tables {
+		if prev == nil {
+			s.LeaderChanges[name] = 0
+			continue
+		}
+		count := prev.LeaderChanges[name]
+		if before, ok := prev.Tables[name]; ok && current.Leader != "" && current != before {
+			count++
+		}
+		s.LeaderChanges[name] = count
+	}
+}
+
+// key is the metadata key of the snapshot. Keys sort in the order snapshots were taken.
+func (s Snapshot) key() string {
+	return fmt.Sprintf("%020d", s.Since.UnixNano())
+}
+
+// FromObservation derives the topology from a sampled cluster state.
+// Servers may disagree about a table's leader while an election is in progress;
+// the view with the highest term wins and ties are broken by majority.
+func FromObservation(o stats.Observation) Snapshot {
+	members := make([]Member, 0, len(o.Servers))
+	for _, server := range o.Servers {
+		members = append(members, Member{ID: server.ID, Name: server.Name})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+
+	votes := make(map[string]map[Leadership]int)
+	for _, status := range o.Statuses {
+		for name, ts := range status.Tables {
+			if votes[name] == nil {
+				votes[name] = make(map[Leadership]int)
+			}
+			votes[name][Leadership{Leader: ts.Leader, Term: ts.RaftTerm}]++
+		}
+	}
+
+	tables := make(map[string]Leadership, len(votes))
+	for name, candidates := range votes {
+		var best Leadership
+		bestVotes := 0
+		for candidate, n := range candidates {
+			switch {
+			case candidate.Term > best.Term,
+				candidate.Term == best.Term && n > bestVotes,
+				candidate.Term == best.Term && n == bestVotes && candidate.Leader < best.Leader:
+				best, bestVotes = candidate, n
+			}
+		}
+		tables[name] = best
+	}
+
+	return Snapshot{
+		Since:    o.Time.UTC(),
+		LastSeen: o.Time.UTC(),
+		Members:  members,
+		Tables:   tables,
+	}
+}
+
+// MetricSink stores metrics derived by the console. The metrics.MetricsManager implements it.
+type MetricSink interface {
+	AppendSamples(ctx context.Context, samples []metrics.ConsoleSample) error
+}
+
+// HistoryOption configures optional behaviour of the History
+type HistoryOption func(*History)
+
+// WithMetricSink makes the History write the leader change count of every table
+// as the metrics.LeaderChangesMetric counter on every observation
+func WithMetricSink(sink MetricSink) HistoryOption {
+	return func(h *History) {
+		h.sink = sink
+	}
+}
+
+// WithPublisher makes the History publish an events.TypeTopology event whenever the topology changed
+func WithPublisher(p events.Publisher) HistoryOption {
+	return func(h *History) {
+		h.publisher = p
+	}
+}
+
+// History records topology snapshots in the metadata store.
+// A new snapshot is only stored when the topology changed; otherwise the
+// LastSeen time of the latest snapshot is advanced.
+type History struct {
+	store     metadata.Store
+	retention time.Duration
+	logger    *zap.Logger
+	// sink receives the leader change counters, it may be nil
+	sink MetricSink
+	// publisher receives topology changes, it may be nil
+	publisher events.Publisher
+
+	// mu protects latest and loaded
+	mu sync.Mutex
+	// latest is the most recent snapshot, if any
+	latest *Snapshot
+	// loaded is set once the latest snapshot was read from the store
+	loaded bool
+}
+
+// NewHistory creates a History that keeps snapshots for the given retention
+func NewHistory(store metadata.Store, retention time.Duration, logger *zap.Logger, opts ...HistoryOption) *History {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	h := &History{
+		store:     store,
+		retention: retention,
+		logger:    logger.Named("topology"),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Observe records the topology of a sampled cluster state. It implements stats.Observer.
+func (h *History) Observe(o stats.Observation) {
+	if err := h.Record(FromObservation(o)); err != nil {
+		h.logger.Warn("Failed to record topology", zap.Error(err))
+		return
+	}
+	if h.sink == nil {
+		return
+	}
+	if err := h.sink.AppendSamples(context.Background(), h.leaderChangeSamples(o.Time)); err != nil {
+		h.logger.Warn("Failed to store leader change counters", zap.Error(err))
+	}
+}
+
+// LeaderChanges returns the number of leader changes of every current table
+func (h *History) LeaderChanges() map[string]uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.latest == nil {
+		return map[string]uint64{}
+	}
+	return maps.Clone(h.latest.LeaderChanges)
+}
+
+// leaderChangeSamples returns the current leader change counters as samples taken at t
+func (h *History) leaderChangeSamples(t time.Time) []metrics.ConsoleSample {
+	counts := h.LeaderChanges()
+	samples := make([]metrics.ConsoleSample, 0, len(counts))
+	for table, count := range counts {
+		samples = append(samples, metrics.ConsoleSample{
+			Name:   metrics.LeaderChangesMetric,
+			Labels: map[string]string{"table": table},
+			Time:   t,
+			Value:  float64(count),
+		})
+	}
+	return samples
+}
+
+// Record stores a snapshot, merging it into the latest snapshot if the topology is unchanged
+func (h *History) Record(s Snapshot) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.loaded {
+		snapshots, err := h.list()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) > 0 {
+			h.latest = &snapshots[len(snapshots)-1]
+		}
+		h.loaded = true
+	}
+
+	if h.latest != nil && h.latest.sameTopology(s) && !s.LastSeen.Before(h.latest.LastSeen) {
+		merged := *h.latest
+		merged.LastSeen = s.LastSeen
+		if err := metadata.Put(h.store, Namespace, merged.key(), merged); err != nil {
+			return err
+		}
+		h.latest = &merged
+		return nil
+	}
+
+	s.countLeaderChanges(h.latest)
+	if err := metadata.Put(h.store, Namespace, s.key(), s); err != nil {
+		return err
+	}
+	h.latest = &s
+	h.logger.Debug("Topology changed", zap.Time("since", s.Since), zap.Int("members", len(s.Members)))
+	if h.publisher != nil {
+		h.publisher.Publish(events.Event{Type: events.TypeTopology, Time: s.Since, Data: s})
+	}
+	return h.prune(s.LastSeen.Add(-h.retention))
+}
+
+// prune deletes snapshots that were last seen before the cutoff
+func (h *History) prune(cutoff time.Time) error {
+	snapshots, err := h.list()
+	if err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		if !s.LastSeen.Before(cutoff) {
+			break
+		}
+		if err := h.store.Delete(Namespace, s.key()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns all stored snapshots ordered by time
+func (h *History) list() ([]Snapshot, error) {
+	records, err := metadata.List[Snapshot](h.store, Namespace)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := slices.Collect(maps.Values(records))
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Since.Before(snapshots[j].Since) })
+	return snapshots, nil
+}
+
+// At returns the snapshot that was in effect at the given time.
+// It reports false if no snapshot was recorded at or before t.
+// A snapshot whose LastSeen is before t may no longer have been accurate at t,
+// e.g. because the console could not reach the cluster in between.
+func (h *History) At(t time.Time) (Snapshot, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshots, err := h.list()
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+	i := sort.Search(len(snapshots), func(i int) bool { return snapshots[i].Since.After(t) })
+	if i == 0 {
+		return Snapshot{}, false, nil
+	}
+	return snapshots[i-1], true, nil
+}
+
+// Range returns the snapshots that were in effect between from and to, ordered by time
+func (h *History) Range(from, to time.Time) ([]Snapshot, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	snapshots, err := h.list()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Snapshot, 0)
+	for i, s := range snapshots {
+		if s.Since.After(to) {
+			break
+		}
+		// A snapshot is in effect until the next one starts
+		end := s.LastSeen
+		if i+1 < len(snapshots) {
+			end = snapshots[i+1].Since
+		}
+		if end.Before(from) {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package topology
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/events"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var epoch = time.Date(2025, 3, 1, 3, 0, 0, 0, time.UTC)
+
+// observation builds a cluster state in which every server reports the given leader for table "users"
+func observation(at time.Time, leader string, term uint64) stats.Observation {
+	servers := []armada.Server{{ID: "2", Name: "b"}, {ID: "1", Name: "a"}}
+	statuses := make(map[string]*armada.Status)
+	for _, s := range servers {
+		statuses[s.ID] = &armada.Status{Tables: map[string]armada.TableStatus{
+			"users": {Leader: leader, RaftTerm: term},
+		}}
+	}
+	return stats.Observation{Time: at, Servers: servers, Statuses: statuses}
+}
+
+func TestFromObservationPrefersHighestTerm(t *testing.T) {
+	o := observation(epoch, "1", 4)
+	o.Statuses["2"].Tables["users"] = armada.TableStatus{Leader: "2", RaftTerm: 5}
+	o.Statuses["3"] = &armada.Status{Tables: map[string]armada.TableStatus{"users": {Leader: "1", RaftTerm: 4}}}
+
+	s := FromObservation(o)
+	assert.Equal(t, []Member{{ID: "1", Name: "a"}, {ID: "2", Name: "b"}}, s.Members)
+	assert.Equal(t, Leadership{Leader: "2", Term: 5}, s.Tables["users"])
+}
+
+func TestHistoryCompactsUnchangedTopology(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	h := NewHistory(store, 24*time.Hour, zap.NewNop())
+
+	h.Observe(observation(epoch, "1", 1))
+	h.Observe(observation(epoch.Add(time.Minute), "1", 1))
+	h.Observe(observation(epoch.Add(2*time.Minute), "2", 2))
+	h.Observe(observation(epoch.Add(3*time.Minute), "2", 2))
+
+	records, err := store.List(Namespace)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	snapshots, err := h.Range(epoch, epoch.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, epoch, snapshots[0].Since)
+	assert.Equal(t, epoch.Add(time.Minute), snapshots[0].LastSeen)
+	assert.Equal(t, epoch.Add(3*time.Minute), snapshots[1].LastSeen)
+}
+
+func TestHistoryPublishesChanges(t *testing.T) {
+	hub := events.NewHub()
+	sub := hub.Subscribe(4)
+	h := NewHistory(metadata.NewMemoryStore(), 24*time.Hour, zap.NewNop(), WithPublisher(hub))
+
+	h.Observe(observation(epoch, "1", 1))
+	h.Observe(observation(epoch.Add(time.Minute), "1", 1))
+	h.Observe(observation(epoch.Add(2*time.Minute), "2", 2))
+
+	require.Len(t, sub.Events(), 2)
+	<-sub.Events()
+	e := <-sub.Events()
+	assert.Equal(t, events.TypeTopology, e.Type)
+	assert.Equal(t, epoch.Add(2*time.Minute), e.Time)
+	assert.Equal(t, Leadership{Leader: "2", Term: 2}, e.Data.(Snapshot).Tables["users"])
+}
+
+func TestHistoryAt(t *testing.T) {
+	h := NewHistory(metadata.NewMemoryStore(), 24*time.Hour, zap.NewNop())
+	h.Observe(observation(epoch, "1", 1))
+	h.Observe(observation(epoch.Add(10*time.Minute), "2", 2))
+
+	_, ok, err := h.At(epoch.Add(-time.Second))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	s, ok, err := h.At(epoch.Add(5 * time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "1", s.Tables["users"].Leader)
+
+	s, ok, err = h.At(epoch.Add(12 * time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "2", s.Tables["users"].Leader)
+}
+
+func TestHistoryRangeIncludesSnapshotInEffect(t *testing.T) {
+	h := NewHistory(metadata.NewMemoryStore(), 24*time.Hour, zap.NewNop())
+	h.Observe(observation(epoch, "1", 1))
+	h.Observe(observation(epoch.Add(10*time.Minute), "2", 2))
+	h.Observe(observation(epoch.Add(20*time.Minute), "1", 3))
+
+	snapshots, err := h.Range(epoch.Add(12*time.Minute), epoch.Add(15*time.Minute))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "2", snapshots[0].Tables["users"].Leader)
+}
+
+func TestHistoryPrunesExpiredSnapshots(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	h := NewHistory(store, time.Hour, zap.NewNop())
+	h.Observe(observation(epoch, "1", 1))
+	h.Observe(observation(epoch.Add(30*time.Minute), "2", 2))
+	h.Observe(observation(epoch.Add(2*time.Hour), "1", 3))
+
+	records, err := store.List(Namespace)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestHistoryResumesFromStore(t *testing.T) {
+	store := metadata.NewMemoryStore()
+	NewHistory(store, 24*time.Hour, zap.NewNop()).Observe(observation(epoch, "1", 1))
+
+	// A restarted console extends the stored snapshot instead of adding a new one
+	h := NewHistory(store, 24*time.Hour, zap.NewNop())
+	h.Observe(observation(epoch.Add(time.Minute), "1", 1))
+
+	records, err := store.List(Namespace)
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+// recordingSink keeps every appended sample
+type recordingSink struct {
+	samples []metrics.ConsoleSample
+}
+
+func (r *recordingSink) AppendSamples(_ context.Context, samples []metrics.ConsoleSample) error {
+	r.samples = append(r.samples, samples...)
+	return nil
+}
+
+func TestHistoryCountsLeaderChanges(t *testing.T) {
+	sink := &recordingSink{}
+	h := NewHistory(metadata.NewMemoryStore(), time.Hour, zap.NewNop(), WithMetricSink(sink))
+
+	h.Observe(observation(epoch, "1", 1))
+	h.Observe(observation(epoch.Add(time.Minute), "1", 1))
+	// The leader is lost and re-elected in a new term
+	h.Observe(observation(epoch.Add(2*time.Minute), "", 1))
+	h.Observe(observation(epoch.Add(3*time.Minute), "1", 2))
+	h.Observe(observation(epoch.Add(4*time.Minute), "2", 3))
+	assert.Equal(t, map[string]uint64{"users": 2}, h.LeaderChanges())
+
+	// Every observation writes the counter
+	require.Len(t, sink.samples, 5)
+	last := sink.samples[4]
+	assert.Equal(t, metrics.LeaderChangesMetric, last.Name)
+	assert.Equal(t, map[string]string{"table": "users"}, last.Labels)
+	assert.Equal(t, float64(2), last.Value)
+
+	// Counts survive pruning of the snapshots they were derived from
+	h.Observe(observation(epoch.Add(3*time.Hour), "1", 4))
+	assert.Equal(t, map[string]uint64{"users": 3}, h.LeaderChanges())
+}
@@ -0,0 +1,151 @@
+// Package tracing traces HTTP requests of the console and the gRPC calls they make to the
+// Armada servers with OpenTelemetry, so a slow request can be followed down to the individual
+// calls it fanned out to. Spans are exported to an OTLP collector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Exporters
+const (
+	ExporterNone     = "none"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+)
+
+// Options configure the exported traces
+type Options struct {
+	// Exporter selects the protocol spans are exported with
+	Exporter string
+	// Endpoint is the host:port of the collector, the exporter's default if empty
+	Endpoint string
+	// Insecure disables TLS towards the collector
+	Insecure bool
+	// SampleRatio is the fraction of new traces that are recorded
+	SampleRatio float64
+	// ServiceName identifies the console in the tracing backend
+	ServiceName string
+	// Version is the version of the console, if known
+	Version string
+	// Headers are sent with every export, e.g. the API key of a hosted collector
+	Headers map[string]string
+	// UserAgent identifies the console towards the collector, the exporter's default if empty
+	UserAgent string
+	// Proxy selects the proxy of exports over HTTP, the exporter's default if nil
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// Setup installs a global tracer provider exporting spans as configured, and the W3C trace
+// context propagator so requests carrying a trace context continue their caller's trace.
+// The returned function flushes pending spans and must be called before the process exits.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	exporter, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceName(opts.ServiceName)}
+	if opts.Version != "" {
+		attrs = append(attrs, semconv.ServiceVersion(opts.Version))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the span exporter selected by the options
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case ExporterOTLPGRPC:
+		var exporterOpts []otlptracegrpc.Option
+		if opts.Endpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+		}
+		if len(opts.Headers) > 0 {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithHeaders(opts.Headers))
+		}
+		if opts.UserAgent != "" {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithDialOption(grpc.WithUserAgent(opts.UserAgent)))
+		}
+		return otlptracegrpc.New(ctx, exporterOpts...)
+	case ExporterOTLPHTTP:
+		var exporterOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+		}
+		// Headers are set after the exporter's User-Agent, so they override it
+		headers := maps.Clone(opts.Headers)
+		if opts.UserAgent != "" {
+			if headers == nil {
+				headers = make(map[string]string)
+			}
+			headers["User-Agent"] = opts.UserAgent
+		}
+		if len(headers) > 0 {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(headers))
+		}
+		if opts.Proxy != nil {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithProxy(opts.Proxy))
+		}
+		return otlptracehttp.New(ctx, exporterOpts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", opts.Exporter)
+	}
+}
+
+// Middleware starts a span for every request. Spans are named after the matched chi route,
+// e.g. "GET /api/tables/{name}", so they can be grouped regardless of the path parameters.
+// It must be installed on the root router, which resolves the route within the span.
+func Middleware(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		// The route is known once the router has matched the request
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil {
+			return
+		}
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + pattern)
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+	})
+	return otelhttp.NewHandler(named, "http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}))
+}
@@ -0,0 +1,164 @@
+package transform
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minBase64Length avoids mistaking short words like "test" for base64
+const minBase64Length = 8
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Gzip decodes gzip compressed values
+type Gzip struct{}
+
+// Name implements Transformer
+func (Gzip) Name() string { return "gzip" }
+
+// Detect implements Transformer
+func (Gzip) Detect(value []byte) bool { return bytes.HasPrefix(value, gzipMagic) }
+
+// Decode implements Transformer
+func (Gzip) Decode(value []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r)
+}
+
+// Encode implements Transformer
+func (Gzip) Encode(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Zstd decodes zstandard compressed values
+type Zstd struct{}
+
+// Name implements Transformer
+func (Zstd) Name() string { return "zstd" }
+
+// Detect implements Transformer
+func (Zstd) Detect(value []byte) bool { return bytes.HasPrefix(value, zstdMagic) }
+
+// Decode implements Transformer
+func (Zstd) Decode(value []byte) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(value), zstd.WithDecoderMaxMemory(MaxDecodedSize))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r)
+}
+
+// Encode implements Transformer
+func (Zstd) Encode(value []byte) ([]byte, error) {
+	w, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	return w.EncodeAll(value, nil), nil
+}
+
+// Base64 decodes standard base64 encoded values
+type Base64 struct{}
+
+// Name implements Transformer
+func (Base64) Name() string { return "base64" }
+
+// Detect implements Transformer. Only padded standard encodings of a minimum length
+// are detected, so that plain words and identifiers are left alone.
+func (Base64) Detect(value []byte) bool {
+	if len(value) < minBase64Length || len(value)%4 != 0 {
+		return false
+	}
+	for _, c := range value {
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/' || c == '=') {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode implements Transformer
+func (Base64) Decode(value []byte) ([]byte, error) {
+	return base64.StdEncoding.AppendDecode(nil, value)
+}
+
+// Encode implements Transformer
+func (Base64) Encode(value []byte) ([]byte, error) {
+	return base64.StdEncoding.AppendEncode(nil, value), nil
+}
+
+// envelopePrefixes map textual prefixes of encrypted values to their scheme
+var envelopePrefixes = map[string]string{
+	"vault:v":        "vault-transit",
+	"ENC[AES256_GCM": "sops",
+	"-----BEGIN PGP": "pgp",
+	"age-encryption": "age",
+}
+
+// DetectEnvelope reports the encryption scheme of a value, or an empty string if the
+// value doesn't look encrypted. Encrypted values can't be decoded by the console.
+func DetectEnvelope(value []byte) string {
+	for prefix, scheme := range envelopePrefixes {
+		if bytes.HasPrefix(value, []byte(prefix)) {
+			return scheme
+		}
+	}
+
+	// JSON envelopes carry the ciphertext next to an encrypted data key or nonce
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return ""
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &fields); err != nil {
+		return ""
+	}
+	var hasCiphertext, hasKeyMaterial bool
+	for name := range fields {
+		switch strings.ToLower(name) {
+		case "ciphertext", "ciphertextblob", "encrypted_data", "encrypteddata":
+			hasCiphertext = true
+		case "encrypted_key", "encryptedkey", "encrypteddatakey", "wrapped_key", "iv", "nonce":
+			hasKeyMaterial = true
+		}
+	}
+	if hasCiphertext && hasKeyMaterial {
+		return "envelope"
+	}
+	return ""
+}
+
+// readLimited reads a decoded value up to MaxDecodedSize
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxDecodedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxDecodedSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,210 @@
+// Package transform decodes values stored by applications in encoded form, such as
+// compressed or base64 encoded payloads, so that they can be inspected in the console.
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDepth bounds the number of layers peeled off a single value
+const maxDepth = 4
+
+// MaxDecodedSize bounds the size of a decoded value to protect against decompression bombs
+const MaxDecodedSize = 16 << 20
+
+var (
+	// ErrUnknownTransformer is returned when a transformer name is not registered
+	ErrUnknownTransformer = errors.New("unknown transformer")
+	// ErrTooLarge is returned when a decoded value exceeds MaxDecodedSize
+	ErrTooLarge = errors.New("decoded value too large")
+)
+
+// Transformer is a reversible encoding applied to stored values
+type Transformer interface {
+	// Name identifies the transformer, e.g. in the transform query parameter.
+	Name() string
+	// Detect reports whether the value looks like it was encoded by this transformer.
+	Detect(value []byte) bool
+	// Decode reverses the encoding.
+	Decode(value []byte) ([]byte, error)
+	// Encode applies the encoding.
+	Encode(value []byte) ([]byte, error)
+}
+
+// Result is a value decoded by a pipeline
+type Result struct {
+	Value []byte
+	// Applied lists the transformers that were reversed, outermost first
+	Applied []string
+	// Envelope names the encryption scheme if the value is encrypted and can't be decoded further
+	Envelope string
+	// Compression names the outermost compression detected, even if it couldn't be reversed
+	Compression string
+	// Err is set if a detected compression couldn't be reversed, e.g. because it exceeds MaxDecodedSize
+	Err error
+}
+
+// Compressions are the names of the built-in compression transformers
+var Compressions = []string{"gzip", "zstd"}
+
+// IsCompression reports whether the named transformer is a compression
+func IsCompression(name string) bool {
+	return slices.Contains(Compressions, name)
+}
+
+// Pipeline detects and reverses the encodings of values using a list of transformers.
+// Transformers are tried in order, so more specific ones should come first.
+type Pipeline struct {
+	transformers []Transformer
+}
+
+// NewPipeline creates a pipeline of the given transformers
+func NewPipeline(transformers ...Transformer) *Pipeline {
+	return &Pipeline{transformers: transformers}
+}
+
+// Default returns a pipeline with all built-in transformers
+func Default() *Pipeline {
+	return NewPipeline(Gzip{}, Zstd{}, Base64{})
+}
+
+// Names returns the names of the transformers of the pipeline
+func (p *Pipeline) Names() []string {
+	names := make([]string, 0, len(p.transformers))
+	for _, t := range p.transformers {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// Decode peels off detected encodings until none is detected anymore.
+// A layer is only peeled if the result is readable text or another known encoding,
+// so that binary values are never replaced by garbage. Compressed values are flagged
+// even if they can't be decompressed.
+func (p *Pipeline) Decode(value []byte) Result {
+	result := Result{Value: value}
+	for range maxDepth {
+		if envelope := DetectEnvelope(result.Value); envelope != "" {
+			result.Envelope = envelope
+			return result
+		}
+		decoded, name, err := p.decodeLayer(result.Value)
+		if IsCompression(name) && result.Compression == "" {
+			result.Compression = name
+		}
+		if err != nil {
+			result.Err = fmt.Errorf("%s: %w", name, err)
+			return result
+		}
+		if decoded == nil {
+			return result
+		}
+		result.Value = decoded
+		result.Applied = append(result.Applied, name)
+	}
+	return result
+}
+
+// decodeLayer reverses the first detected transformer whose output is usable.
+// Compression is detected reliably by magic bytes, so it is reported by name even if
+// decompression fails or yields binary data; other transformers are skipped in that case.
+func (p *Pipeline) decodeLayer(value []byte) ([]byte, string, error) {
+	for _, t := range p.transformers {
+		if !t.Detect(value) {
+			continue
+		}
+		decoded, err := t.Decode(value)
+		switch {
+		case err == nil && p.readable(decoded):
+			return decoded, t.Name(), nil
+		case IsCompression(t.Name()):
+			return nil, t.Name(), err
+		}
+	}
+	return nil, "", nil
+}
+
+// Only returns a pipeline restricted to the named transformers
+func (p *Pipeline) Only(names ...string) *Pipeline {
+	var transformers []Transformer
+	for _, t := range p.transformers {
+		if slices.Contains(names, t.Name()) {
+			transformers = append(transformers, t)
+		}
+	}
+	return NewPipeline(transformers...)
+}
+
+// readable reports whether a decoded value is worth showing instead of its encoded form
+func (p *Pipeline) readable(value []byte) bool {
+	if printable(value) || DetectEnvelope(value) != "" {
+		return true
+	}
+	for _, t := range p.transformers {
+		if t.Detect(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// printable reports whether the value is text without control characters other than whitespace
+func printable(value []byte) bool {
+	if !utf8.Valid(value) {
+		return false
+	}
+	for _, r := range string(value) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode applies the named transformers so that decoding yields the value again.
+// Names are given outermost first, the same order Decode reports them in.
+func (p *Pipeline) Encode(value []byte, names []string) ([]byte, error) {
+	transformers := make([]Transformer, 0, len(names))
+	for _, name := range names {
+		t, ok := p.lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTransformer, name)
+		}
+		transformers = append(transformers, t)
+	}
+
+	for i := len(transformers) - 1; i >= 0; i-- {
+		var err error
+		value, err = transformers[i].Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", transformers[i].Name(), err)
+		}
+	}
+	return value, nil
+}
+
+// ParseNames splits a comma separated list of transformer names
+func ParseNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookup finds a transformer by name
+func (p *Pipeline) lookup(name string) (Transformer, bool) {
+	for _, t := range p.transformers {
+		if t.Name() == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,151 @@
+package transform
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeLayers(t *testing.T) {
+	p := Default()
+	plain := []byte(`{"user":"alice","roles":["admin"]}`)
+
+	tests := []struct {
+		name   string
+		layers []string
+	}{
+		{name: "Plain"},
+		{name: "Gzip", layers: []string{"gzip"}},
+		{name: "Zstd", layers: []string{"zstd"}},
+		{name: "Base64", layers: []string{"base64"}},
+		{name: "Base64Gzip", layers: []string{"base64", "gzip"}},
+		{name: "Base64Zstd", layers: []string{"base64", "zstd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := p.Encode(plain, tt.layers)
+			require.NoError(t, err)
+
+			result := p.Decode(encoded)
+			assert.Equal(t, string(plain), string(result.Value))
+			assert.Equal(t, tt.layers, result.Applied)
+			assert.Empty(t, result.Envelope)
+		})
+	}
+}
+
+func TestDecodeLeavesPlainValues(t *testing.T) {
+	p := Default()
+	for _, value := range []string{"test", "username", "12345678", "hello world", "ZZZZZZZZ"} {
+		result := p.Decode([]byte(value))
+		assert.Equal(t, value, string(result.Value), value)
+		assert.Empty(t, result.Applied, value)
+	}
+}
+
+func TestDecodeKeepsBinary(t *testing.T) {
+	p := Default()
+	// Base64 of random binary data is left encoded since the binary isn't readable
+	encoded, err := p.Encode([]byte{0x00, 0x01, 0xfe, 0xff, 0x10, 0x80}, []string{"base64"})
+	require.NoError(t, err)
+
+	result := p.Decode(encoded)
+	assert.Equal(t, encoded, result.Value)
+	assert.Empty(t, result.Applied)
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	p := Default()
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "vault:v1:8SDd3WHDOjf7mq69CyCqYjBXAiQQAVZRkFM13ok481zoCmHnSeDX9vyf7w==", want: "vault-transit"},
+		{value: `{"ciphertext":"AAAA","encrypted_key":"BBBB"}`, want: "envelope"},
+		{value: `{"ciphertext":"AAAA"}`, want: ""},
+		{value: "age-encryption.org/v1\n-> X25519", want: "age"},
+	}
+	for _, tt := range tests {
+		result := p.Decode([]byte(tt.value))
+		assert.Equal(t, tt.want, result.Envelope, tt.value)
+		assert.Equal(t, tt.value, string(result.Value))
+	}
+
+	// Envelopes are detected below other layers as well
+	encoded, err := p.Encode([]byte(`{"CiphertextBlob":"AAAA","IV":"BBBB"}`), []string{"base64"})
+	require.NoError(t, err)
+	result := p.Decode(encoded)
+	assert.Equal(t, "envelope", result.Envelope)
+	assert.Equal(t, []string{"base64"}, result.Applied)
+}
+
+func TestEncodeUnknownTransformer(t *testing.T) {
+	_, err := Default().Encode([]byte("value"), []string{"rot13"})
+	assert.ErrorIs(t, err, ErrUnknownTransformer)
+}
+
+func TestDecodeTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(bytes.Repeat([]byte("a"), MaxDecodedSize+1))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = Gzip{}.Decode(buf.Bytes())
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// The pipeline keeps the compressed value and reports why
+	result := Default().Decode(buf.Bytes())
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, "gzip", result.Compression)
+	assert.ErrorIs(t, result.Err, ErrTooLarge)
+}
+
+func TestDecodeFlagsCompression(t *testing.T) {
+	p := Default()
+
+	encoded, err := p.Encode([]byte("hello"), []string{"base64", "zstd"})
+	require.NoError(t, err)
+	result := p.Decode(encoded)
+	assert.Equal(t, "zstd", result.Compression)
+	assert.NoError(t, result.Err)
+
+	// Compressed binary data is flagged but kept compressed
+	compressed, err := p.Encode([]byte{0x00, 0x01, 0xfe, 0xff}, []string{"gzip"})
+	require.NoError(t, err)
+	result = p.Decode(compressed)
+	assert.Equal(t, compressed, result.Value)
+	assert.Empty(t, result.Applied)
+	assert.Equal(t, "gzip", result.Compression)
+
+	// Corrupt data with a compression header is reported
+	result = p.Decode([]byte{0x1f, 0x8b, 0x00})
+	assert.Equal(t, "gzip", result.Compression)
+	assert.Error(t, result.Err)
+}
+
+func TestOnly(t *testing.T) {
+	p := Default().Only(Compressions...)
+	assert.Equal(t, []string{"gzip", "zstd"}, p.Names())
+
+	// Base64 is left alone, so the compression below isn't reached either
+	encoded, err := Default().Encode([]byte("hello"), []string{"base64", "gzip"})
+	require.NoError(t, err)
+	result := p.Decode(encoded)
+	assert.Equal(t, encoded, result.Value)
+	assert.Empty(t, result.Compression)
+
+	compressed, err := Default().Encode([]byte("hello"), []string{"gzip"})
+	require.NoError(t, err)
+	result = p.Decode(compressed)
+	assert.Equal(t, "hello", string(result.Value))
+	assert.Equal(t, "gzip", result.Compression)
+}
+
+func TestParseNames(t *testing.T) {
+	assert.Equal(t, []string{"base64", "gzip"}, ParseNames(" base64, gzip,"))
+	assert.Nil(t, ParseNames(""))
+}
@@ -1,6 +1,11 @@
+//go:build !headless
+
 package frontend
 
 import "embed"
 
+// Embedded reports whether the built frontend is part of the binary
+const Embedded = true
+
 //go:embed dist
 var FS embed.FS
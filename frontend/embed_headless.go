@@ -0,0 +1,12 @@
+//go:build headless
+
+package frontend
+
+import "embed"
+
+// Embedded reports whether the built frontend is part of the binary. Binaries built with the
+// headless tag don't need frontend/dist and always serve the API only.
+const Embedded = false
+
+// FS is empty in headless binaries
+var FS embed.FS
@@ -1,11 +1,13 @@
 import React, { Suspense, useState, lazy } from 'react';
-import { Route, Routes } from 'react-router-dom';
+import { Route, Routes, useLocation } from 'react-router-dom';
 
 import Footer from './components/Footer';
 import Header from './components/Header';
 import { LoadingState } from './components/shared/LoadingState';
 import Sidebar from './components/Sidebar';
 import { NavigationProvider } from './context/NavigationContext';
+import { useBranding, usePageView } from './hooks/useApi';
+import { applyBranding } from './utils/branding';
 
 // Lazy load route components for code splitting
 const DashboardPage = lazy(() => import('./routes/dashboard/DashboardPage'));
@@ -14,6 +16,8 @@ const AddKeyValuePage = lazy(() => import('./routes/data/AddKeyValuePage'));
 const EditKeyValuePage = lazy(() => import('./routes/data/EditKeyValuePage'));
 const ResourcesPage = lazy(() => import('./routes/resources/ResourcesPage'));
 const SettingsPage = lazy(() => import('./routes/settings/SettingsPage'));
+const FleetPage = lazy(() => import('./routes/fleet/FleetPage'));
+const TargetsPage = lazy(() => import('./routes/targets/TargetsPage'));
 
 // Drawer width for the sidebar
 const drawerWidth = 240;
@@ -21,6 +25,17 @@ const drawerWidth = 240;
 const App: React.FC = () => {
   const [mobileOpen, setMobileOpen] = useState(false);
   const [isMobile, setIsMobile] = useState(window.innerWidth < 768);
+  const { data: branding } = useBranding();
+  const location = useLocation();
+
+  // Page views are counted by feature, the first path segment, never by table or key
+  usePageView(location.pathname.split('/')[1] || 'dashboard');
+
+  React.useEffect(() => {
+    if (branding) {
+      applyBranding(branding);
+    }
+  }, [branding]);
 
   React.useEffect(() => {
     const handleResize = () => {
@@ -73,6 +88,8 @@ const App: React.FC = () => {
                 <Route path="/data/:table/add" element={<AddKeyValuePage />} />
                 <Route path="/data/:table/edit/:key" element={<EditKeyValuePage />} />
                 <Route path="/resources" element={<ResourcesPage />} />
+                <Route path="/fleet" element={<FleetPage />} />
+                <Route path="/targets" element={<TargetsPage />} />
                 <Route path="/settings" element={<SettingsPage />} />
               </Routes>
             </Suspense>
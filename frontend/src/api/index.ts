@@ -1,7 +1,61 @@
-import { ClusterInfo, KeyValuePair, MetricsQueryResponse, StatusResponse, Table } from '../types';
+import {
+  AnalyticsStatus,
+  Branding,
+  ClusterInfo,
+  ClustersResponse,
+  FleetResponse,
+  HotKeysReport,
+  KeyValuePage,
+  KeyValuePair,
+  LogoutResponse,
+  MaintenanceWindow,
+  Me,
+  MetricSuggestions,
+  MetricsScope,
+  MetricsQueryResponse,
+  ScanEstimate,
+  ScrapeTarget,
+  ServerResources,
+  StatusResponse,
+  Table,
+  TopologySnapshot,
+  UsageReport,
+} from '../types';
+import { BASE_PATH } from '../utils/basePath';
+import { withCluster } from '../utils/cluster';
 
-// Base API URL
-const API_URL = '/api';
+// Base API URL, the version the frontend is written against, under the base path of the console
+const API_URL = `${BASE_PATH}/api/v1`;
+
+// Changes made with a session cookie must carry the CSRF token of the session, it is fetched once
+// and again after a change was refused, e.g. because the session was replaced by a new login
+let csrfToken: Promise<string> | undefined;
+const getCsrfToken = () => {
+  csrfToken ??= fetch(`${API_URL}/auth/csrf`)
+    .then((response) => (response.ok ? response.json() : { token: '' }))
+    .then((data: { token?: string }) => data.token ?? '')
+    .catch(() => '');
+  return csrfToken;
+};
+
+const safeMethods = ['GET', 'HEAD', 'OPTIONS'];
+
+// Requests are served by the cluster selected in the header, console-wide routes ignore it
+const apiFetch = async (url: string, init?: RequestInit) => {
+  if (safeMethods.includes((init?.method ?? 'GET').toUpperCase())) {
+    return fetch(withCluster(url), init);
+  }
+  const headers = new Headers(init?.headers);
+  const token = await getCsrfToken();
+  if (token) {
+    headers.set('X-CSRF-Token', token);
+  }
+  const response = await fetch(withCluster(url), { ...init, headers });
+  if (response.status === 403) {
+    csrfToken = undefined;
+  }
+  return response;
+};
 
 // Helper function to handle API errors
 const handleApiError = async (response: Response) => {
@@ -17,26 +71,32 @@ const handleApiError = async (response: Response) => {
 
 // API functions
 export const getStatus = async (): Promise<StatusResponse> => {
-  const response = await fetch(`${API_URL}/status`);
+  const response = await apiFetch(`${API_URL}/status`);
   return handleApiError(response);
 };
 
 export const getClusterInfo = async (): Promise<ClusterInfo> => {
-  const response = await fetch(`${API_URL}/cluster`);
+  const response = await apiFetch(`${API_URL}/cluster`);
+  return handleApiError(response);
+};
+
+export const getClusters = async (): Promise<ClustersResponse> => {
+  const response = await apiFetch(`${API_URL}/clusters`);
+  return handleApiError(response);
+};
+
+export const getFleet = async (): Promise<FleetResponse> => {
+  const response = await apiFetch(`${API_URL}/fleet`);
   return handleApiError(response);
 };
 
 export const getTables = async (): Promise<Table[]> => {
-  const response = await fetch(`${API_URL}/tables`);
+  const response = await apiFetch(`${API_URL}/tables`);
   return handleApiError(response);
 };
 
-export const getKeyValuePairs = async (
-  table: string,
-  prefix: string = '',
-  start: string = '',
-  end: string = '',
-): Promise<KeyValuePair[]> => {
+// URL of a key scan filtered by prefix or by a start and end
+const keyValueScanUrl = (table: string, prefix: string, start: string, end: string) => {
   const url = new URL(`${API_URL}/kv/${table}`, window.location.origin);
   if (prefix) {
     url.searchParams.append('prefix', prefix);
@@ -45,20 +105,56 @@ export const getKeyValuePairs = async (
     url.searchParams.append('start', start);
     url.searchParams.append('end', end);
   }
+  return url;
+};
 
-  const response = await fetch(url.toString());
-  return handleApiError(response);
+export const getKeyValuePairs = async (
+  table: string,
+  prefix: string = '',
+  start: string = '',
+  end: string = '',
+  cursor: string = '',
+): Promise<KeyValuePage> => {
+  const url = keyValueScanUrl(table, prefix, start, end);
+  if (cursor) {
+    url.searchParams.append('cursor', cursor);
+  }
+
+  const response = await apiFetch(url.toString());
+  const pairs: KeyValuePair[] = await handleApiError(response);
+  return {
+    pairs,
+    truncated: response.headers.get('X-Truncated') === 'true',
+    cursor: response.headers.get('X-Continuation-Cursor') ?? undefined,
+  };
+};
+
+export const estimateKeyValuePairs = async (
+  table: string,
+  prefix: string = '',
+  start: string = '',
+  end: string = '',
+): Promise<ScanEstimate> => {
+  const url = keyValueScanUrl(table, prefix, start, end);
+  const response = await apiFetch(url.toString(), { method: 'HEAD' });
+  if (!response.ok) {
+    throw { message: 'Failed to estimate the scan', status: response.status };
+  }
+  return {
+    keys: Number(response.headers.get('X-Estimated-Keys')),
+    bytes: Number(response.headers.get('X-Estimated-Bytes')),
+  };
 };
 
 export const getKeyValue = async (table: string, key: string): Promise<KeyValuePair> => {
-  const response = await fetch(`${API_URL}/kv/${table}/${encodeURIComponent(key)}`);
+  const response = await apiFetch(`${API_URL}/kv/${table}/${encodeURIComponent(key)}`);
   return handleApiError(response);
 };
 
 export const putKeyValuePair = async (table: string, key: string, value: string): Promise<void> => {
   const url = new URL(`${API_URL}/kv/${table}`, window.location.origin);
 
-  const response = await fetch(url.toString(), {
+  const response = await apiFetch(url.toString(), {
     method: 'PUT',
     headers: {
       'Content-Type': 'application/json',
@@ -73,7 +169,7 @@ export const deleteKeyValuePair = async (table: string, key: string): Promise<vo
   const url = new URL(`${API_URL}/kv/${table}`, window.location.origin);
   url.searchParams.append('key', key);
 
-  const response = await fetch(url.toString(), {
+  const response = await apiFetch(url.toString(), {
     method: 'DELETE',
   });
 
@@ -81,7 +177,7 @@ export const deleteKeyValuePair = async (table: string, key: string): Promise<vo
 };
 
 export const createTable = async (name: string): Promise<{ id: string }> => {
-  const response = await fetch(`${API_URL}/tables`, {
+  const response = await apiFetch(`${API_URL}/tables`, {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
@@ -93,21 +189,39 @@ export const createTable = async (name: string): Promise<{ id: string }> => {
 };
 
 export const deleteTable = async (name: string): Promise<void> => {
-  const response = await fetch(`${API_URL}/tables/${name}`, {
+  const response = await apiFetch(`${API_URL}/tables/${name}`, {
     method: 'DELETE',
   });
 
   return handleApiError(response);
 };
 
-export const queryMetrics = async (query: string, time?: string): Promise<MetricsQueryResponse> => {
+// appendScope adds the parameters of a metrics scope to a query URL
+const appendScope = (url: URL, scope?: MetricsScope) => {
+  if (scope?.clusters?.length) {
+    url.searchParams.append('clusters', scope.clusters.join(','));
+  }
+  if (scope?.groupBy?.length) {
+    url.searchParams.append('groupBy', scope.groupBy.join(','));
+  }
+  if (scope?.aggregate) {
+    url.searchParams.append('aggregate', scope.aggregate);
+  }
+};
+
+export const queryMetrics = async (
+  query: string,
+  time?: string,
+  scope?: MetricsScope,
+): Promise<MetricsQueryResponse> => {
   const url = new URL(`${API_URL}/metrics/query`, window.location.origin);
   url.searchParams.append('query', query);
   if (time) {
     url.searchParams.append('time', time);
   }
+  appendScope(url, scope);
 
-  const response = await fetch(url.toString());
+  const response = await apiFetch(url.toString());
   return handleApiError(response);
 };
 
@@ -116,6 +230,8 @@ export const queryMetricsRange = async (
   start: string,
   end: string,
   step?: string,
+  compareOffset?: string,
+  scope?: MetricsScope,
 ): Promise<MetricsQueryResponse> => {
   const url = new URL(`${API_URL}/metrics/query_range`, window.location.origin);
   url.searchParams.append('query', query);
@@ -124,7 +240,119 @@ export const queryMetricsRange = async (
   if (step) {
     url.searchParams.append('step', step);
   }
+  // e.g. 24h or 7d for day-over-day or week-over-week comparisons
+  if (compareOffset) {
+    url.searchParams.append('compareOffset', compareOffset);
+  }
+  appendScope(url, scope);
+
+  const response = await apiFetch(url.toString());
+  return handleApiError(response);
+};
+
+export const getMetricSuggestions = async (metric: string): Promise<MetricSuggestions> => {
+  const response = await apiFetch(
+    `${API_URL}/metrics/suggest?metric=${encodeURIComponent(metric)}`,
+  );
+  return handleApiError(response);
+};
+
+export const getScrapeTargets = async (): Promise<ScrapeTarget[]> => {
+  const response = await apiFetch(`${API_URL}/metrics/targets`);
+  return handleApiError(response);
+};
+
+export const getServerResources = async (serverId: string): Promise<ServerResources> => {
+  const response = await apiFetch(`${API_URL}/servers/${encodeURIComponent(serverId)}/resources`);
+  return handleApiError(response);
+};
+
+export const getClusterHistory = async (from?: string, to?: string): Promise<TopologySnapshot[]> => {
+  const params = new URLSearchParams();
+  if (from) params.set('from', from);
+  if (to) params.set('to', to);
+  const response = await apiFetch(`${API_URL}/cluster/history?${params.toString()}`);
+  return handleApiError(response);
+};
+
+export const getHotKeys = async (
+  params: { table?: string; window?: string; separator?: string; depth?: number; limit?: number } = {},
+): Promise<HotKeysReport> => {
+  const query = new URLSearchParams();
+  Object.entries(params).forEach(([name, value]) => {
+    if (value !== undefined && value !== '') query.set(name, String(value));
+  });
+  const response = await apiFetch(`${API_URL}/analysis/hotkeys?${query.toString()}`);
+  return handleApiError(response);
+};
+
+export const getClusterTopologyAt = async (at: string): Promise<TopologySnapshot> => {
+  const response = await apiFetch(`${API_URL}/cluster/history?at=${encodeURIComponent(at)}`);
+  return handleApiError(response);
+};
+
+export const getMaintenanceWindows = async (from?: string, to?: string): Promise<MaintenanceWindow[]> => {
+  const params = new URLSearchParams();
+  if (from) params.set('from', from);
+  if (to) params.set('to', to);
+  const response = await apiFetch(`${API_URL}/maintenance?${params.toString()}`);
+  return handleApiError(response);
+};
+
+export const createMaintenanceWindow = async (
+  window: Omit<MaintenanceWindow, 'id' | 'createdBy' | 'createdAt'>,
+): Promise<MaintenanceWindow> => {
+  const response = await apiFetch(`${API_URL}/maintenance`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify(window),
+  });
+  return handleApiError(response);
+};
+
+export const deleteMaintenanceWindow = async (id: string): Promise<void> => {
+  const response = await apiFetch(`${API_URL}/maintenance/${encodeURIComponent(id)}`, {
+    method: 'DELETE',
+  });
+  return handleApiError(response);
+};
+
+export const getMe = async (): Promise<Me> => {
+  const response = await apiFetch(`${API_URL}/auth/me`);
+  return handleApiError(response);
+};
+
+export const logout = async (): Promise<LogoutResponse> => {
+  const response = await apiFetch(`${API_URL}/auth/logout`, {
+    method: 'POST',
+  });
+  return handleApiError(response);
+};
+
+export const getBranding = async (): Promise<Branding> => {
+  const response = await apiFetch(`${API_URL}/branding`);
+  return handleApiError(response);
+};
+
+export const getAnalyticsStatus = async (): Promise<AnalyticsStatus> => {
+  const response = await apiFetch(`${API_URL}/analytics`);
+  return handleApiError(response);
+};
+
+export const recordPageView = async (feature: string): Promise<void> => {
+  const response = await apiFetch(`${API_URL}/analytics/pageviews`, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+    },
+    body: JSON.stringify({ feature }),
+  });
+  return handleApiError(response);
+};
 
-  const response = await fetch(url.toString());
+export const getUsageReport = async (days: number): Promise<UsageReport> => {
+  const response = await apiFetch(`${API_URL}/admin/analytics?days=${days}`);
   return handleApiError(response);
 };
@@ -0,0 +1,58 @@
+import { useQueryClient } from '@tanstack/react-query';
+import React, { useCallback, useEffect, useState } from 'react';
+import { useNavigate } from 'react-router-dom';
+
+import { useClusters } from '@/hooks/useApi';
+import { getSelectedCluster, setSelectedCluster } from '@/utils/cluster';
+
+/**
+ * Selects the cluster the console shows when it serves several. Switching starts over on the
+ * dashboard, as tables of one cluster don't exist in the others.
+ */
+const ClusterSelector: React.FC = () => {
+  const { data } = useClusters();
+  const queryClient = useQueryClient();
+  const navigate = useNavigate();
+  const [selected, setSelected] = useState(getSelectedCluster());
+
+  const select = useCallback(
+    (name: string) => {
+      // The default cluster is served without the cluster parameter
+      const cluster = name === data?.default ? '' : name;
+      setSelectedCluster(cluster);
+      setSelected(cluster);
+      navigate('/');
+      queryClient.resetQueries();
+    },
+    [data, navigate, queryClient],
+  );
+
+  // A remembered cluster may have been removed from the configuration since
+  useEffect(() => {
+    if (data && selected && !data.clusters.some((c) => c.name === selected)) {
+      select('');
+    }
+  }, [data, selected, select]);
+
+  if (!data || data.clusters.length < 2) {
+    return null;
+  }
+
+  return (
+    <select
+      value={selected || data.default || data.clusters[0].name}
+      onChange={(e) => select(e.target.value)}
+      aria-label="cluster"
+      title="Cluster"
+      className="px-3 py-2 border border-gray-300 rounded-md text-sm bg-white dark:bg-gray-800 dark:border-gray-600 dark:text-gray-300"
+    >
+      {data.clusters.map((cluster) => (
+        <option key={cluster.name} value={cluster.name}>
+          {cluster.name}
+        </option>
+      ))}
+    </select>
+  );
+};
+
+export default ClusterSelector;
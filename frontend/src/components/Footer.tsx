@@ -1,21 +1,35 @@
 import React from 'react';
 
+import { useBranding } from '@/hooks/useApi';
+
+const linkClassName =
+  'text-gray-500 dark:text-gray-400 hover:text-gray-700 dark:hover:text-gray-200 underline';
+
 const Footer: React.FC = () => {
   const year = new Date().getFullYear();
+  const { data: branding } = useBranding();
 
   return (
     <footer className="py-4 mt-auto">
       <div className="border-t border-gray-200 dark:border-gray-700 mb-4" />
       <p className="text-sm text-gray-500 dark:text-gray-400 text-center">
-        &copy; {year} Armada Console |{' '}
+        &copy; {year} {branding?.title ?? 'Armada Console'} |{' '}
         <a
           href="https://github.com/armadakv/armada"
           target="_blank"
           rel="noopener noreferrer"
-          className="text-gray-500 dark:text-gray-400 hover:text-gray-700 dark:hover:text-gray-200 underline"
+          className={linkClassName}
         >
           Armada Project
         </a>
+        {branding?.footerLinks.map((link) => (
+          <React.Fragment key={link.url}>
+            {' | '}
+            <a href={link.url} target="_blank" rel="noopener noreferrer" className={linkClassName}>
+              {link.label}
+            </a>
+          </React.Fragment>
+        ))}
       </p>
     </footer>
   );
@@ -1,9 +1,11 @@
-import { Menu } from 'lucide-react';
+import { LogOut, Menu } from 'lucide-react';
 import React from 'react';
 
+import ClusterSelector from './ClusterSelector';
 import ThemeToggle from './ThemeToggle';
 
 import { useNavigation } from '@/context/NavigationContext';
+import { useBranding, useLogout, useMe } from '@/hooks/useApi';
 
 interface HeaderProps {
   drawerWidth: number;
@@ -13,6 +15,10 @@ interface HeaderProps {
 const Header: React.FC<HeaderProps> = ({ onDrawerToggle }) => {
   const [isMobile, setIsMobile] = React.useState(window.innerWidth < 768);
   const { pageTitle, pageAction } = useNavigation();
+  const { data: me } = useMe();
+  const { data: branding } = useBranding();
+  const title = branding?.title ?? 'Armada Console';
+  const logout = useLogout();
 
   React.useEffect(() => {
     const handleResize = () => {
@@ -42,11 +48,38 @@ const Header: React.FC<HeaderProps> = ({ onDrawerToggle }) => {
 
         <div className="flex items-center justify-between w-full">
           <h1 className="text-lg font-semibold text-gray-900 dark:text-gray-100 truncate">
-            {isMobile ? 'Armada Console' : pageTitle}
+            {isMobile ? title : pageTitle}
           </h1>
 
           <div className="flex items-center gap-4">
             {pageAction}
+            <ClusterSelector />
+            {me?.authenticated && (
+              <span className="hidden md:inline text-sm text-gray-500 dark:text-gray-400">{me.displayName || me.name}</span>
+            )}
+            {(me?.readOnly || me?.role === 'viewer') && (
+              <span
+                className="px-2 py-0.5 rounded text-xs font-medium bg-gray-100 text-gray-600 dark:bg-gray-700 dark:text-gray-300"
+                title={
+                  me.readOnly
+                    ? 'The console is in read-only mode, changes are disabled'
+                    : 'Viewers can browse the cluster but not change it'
+                }
+              >
+                Read-only
+              </span>
+            )}
+            {/* Browsers can't be logged out of basic authentication, so only sessions get a logout button */}
+            {me?.method === 'oidc' && (
+              <button
+                className="p-2 rounded-md text-gray-500 dark:text-gray-400 hover:text-gray-700 dark:hover:text-gray-200 hover:bg-gray-100 dark:hover:bg-gray-700"
+                onClick={() => logout.mutate()}
+                aria-label="log out"
+                title="Log out"
+              >
+                <LogOut className="h-5 w-5" />
+              </button>
+            )}
             <ThemeToggle />
           </div>
         </div>
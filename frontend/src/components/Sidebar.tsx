@@ -2,15 +2,17 @@ import {
   LayoutDashboard,
   ChevronDown,
   ChevronUp,
+  Activity,
   Cpu,
   Settings,
   Database,
+  Globe,
   Table,
 } from 'lucide-react';
 import React, { useState } from 'react';
 import { useLocation, useNavigate } from 'react-router-dom';
 
-import { useTables } from '@/hooks/useApi';
+import { useBranding, useTables } from '@/hooks/useApi';
 
 interface SidebarProps {
   onClose?: () => void;
@@ -23,11 +25,15 @@ const Sidebar: React.FC<SidebarProps> = ({ onClose }) => {
 
   // Fetch tables for submenu
   const { data: tables, isLoading: tablesLoading } = useTables();
+  const { data: branding } = useBranding();
+  const title = branding?.title ?? 'Armada Console';
 
   // Navigation items
   const navItems = [
     { text: 'Dashboard', path: '/', icon: <LayoutDashboard className="h-5 w-5" /> },
+    { text: 'Fleet', path: '/fleet', icon: <Globe className="h-5 w-5" /> },
     { text: 'Resources', path: '/resources', icon: <Cpu className="h-5 w-5" /> },
+    { text: 'Targets', path: '/targets', icon: <Activity className="h-5 w-5" /> },
     { text: 'Settings', path: '/settings', icon: <Settings className="h-5 w-5" /> },
   ];
 
@@ -59,9 +65,13 @@ const Sidebar: React.FC<SidebarProps> = ({ onClose }) => {
     <div className="h-full bg-white dark:bg-gray-800 border-r border-gray-200 dark:border-gray-700">
       {/* Logo */}
       <div className="h-16 flex items-center justify-center border-b border-gray-200 dark:border-gray-700">
-        <h1 className="text-lg font-bold text-primary-600 dark:text-primary-400 tracking-wide">
-          Armada Console
-        </h1>
+        {branding?.logoUrl ? (
+          <img src={branding.logoUrl} alt={title} className="max-h-10 max-w-[12rem]" />
+        ) : (
+          <h1 className="text-lg font-bold text-primary-600 dark:text-primary-400 tracking-wide">
+            {title}
+          </h1>
+        )}
       </div>
 
       {/* Navigation */}
@@ -1,7 +1,9 @@
-import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
+import { useInfiniteQuery, useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
 import { useState, useEffect, useMemo } from 'react';
 
 import * as api from '../api';
+import { MetricsScope } from '../types';
+import { BASE_PATH } from '../utils/basePath';
 
 // Debounce utility function
 const useDebounce = (value: string, delay: number) => {
@@ -32,24 +34,47 @@ export const queryKeys = {
     start,
     end,
   ],
-  keyValuePair: (table: string, key: string) => ['keyValuePair', table, key],
-  metrics: (query: string, time?: string) => ['metrics', query, time],
-  metricsRange: (query: string, start: string, end: string, step?: string) => [
-    'metrics-range',
-    query,
+  scanEstimate: (table: string, prefix: string = '', start: string = '', end: string = '') => [
+    'scanEstimate',
+    table,
+    prefix,
     start,
     end,
-    step,
   ],
+  keyValuePair: (table: string, key: string) => ['keyValuePair', table, key],
+  metrics: (query: string, time?: string, scope?: MetricsScope) => ['metrics', query, time, scope],
+  serverResources: (serverId: string) => ['serverResources', serverId],
+  scrapeTargets: ['scrapeTargets'],
+  fleet: ['fleet'],
+  metricsRange: (
+    query: string,
+    start: string,
+    end: string,
+    step?: string,
+    compareOffset?: string,
+    scope?: MetricsScope,
+  ) => ['metrics-range', query, start, end, step, compareOffset, scope],
+  maintenance: ['maintenance'],
+  me: ['me'],
+  branding: ['branding'],
+  clusters: ['clusters'],
+  analyticsStatus: ['analyticsStatus'],
+  usageReport: (days: number) => ['usageReport', days],
 };
 
+// Polls at the interval suggested by the backend, falling back to the given default
+const suggestedInterval =
+  (fallbackMs: number) =>
+  (data: { suggestedRefreshSeconds?: number } | undefined) =>
+    data?.suggestedRefreshSeconds ? data.suggestedRefreshSeconds * 1000 : fallbackMs;
+
 // Status hook with caching
 export const useStatus = () => {
   return useQuery(queryKeys.status, api.getStatus, {
     staleTime: 30 * 1000, // Consider data fresh for 30 seconds
     cacheTime: 5 * 60 * 1000, // Cache for 5 minutes
     refetchOnWindowFocus: false, // Don't refetch when window regains focus
-    refetchInterval: 60 * 1000, // Refetch every minute
+    refetchInterval: suggestedInterval(60 * 1000), // Refetch as suggested, every minute by default
   });
 };
 
@@ -60,6 +85,81 @@ export const useClusterInfo = () => {
   });
 };
 
+// Logged-in user, it doesn't change while the page is open
+export const useBranding = () => {
+  return useQuery(queryKeys.branding, api.getBranding, {
+    staleTime: Infinity,
+    refetchOnWindowFocus: false,
+  });
+};
+
+// Registered clusters, the header offers them for selection
+export const useClusters = () => {
+  return useQuery(queryKeys.clusters, api.getClusters, {
+    refetchOnWindowFocus: false,
+  });
+};
+
+export const useAnalyticsStatus = () => {
+  return useQuery(queryKeys.analyticsStatus, api.getAnalyticsStatus, {
+    staleTime: Infinity,
+    refetchOnWindowFocus: false,
+  });
+};
+
+// Records a page view of the feature whenever it changes, if usage analytics are enabled
+export const usePageView = (feature: string) => {
+  const { data: status } = useAnalyticsStatus();
+  const enabled = status?.enabled ?? false;
+
+  useEffect(() => {
+    if (enabled) {
+      // Losing a page view is not worth bothering the user with
+      api.recordPageView(feature).catch(() => undefined);
+    }
+  }, [enabled, feature]);
+};
+
+export const useUsageReport = (days: number, enabled: boolean = true) => {
+  return useQuery(queryKeys.usageReport(days), () => api.getUsageReport(days), {
+    enabled,
+  });
+};
+
+export const useMe = () => {
+  return useQuery(queryKeys.me, api.getMe, {
+    staleTime: Infinity,
+    refetchOnWindowFocus: false,
+  });
+};
+
+// Logout mutation, leaves the page for the provider's logout or the login
+export const useLogout = () => {
+  return useMutation(api.logout, {
+    onSuccess: ({ logoutUrl }) => {
+      window.location.href = logoutUrl || `${BASE_PATH}/`;
+    },
+    onError: (error) => {
+      console.error('Failed to log out:', error);
+    },
+  });
+};
+
+// Maintenance windows in effect now or starting within the next day
+export const useMaintenanceWindows = () => {
+  return useQuery(
+    queryKeys.maintenance,
+    () => {
+      const now = new Date();
+      const tomorrow = new Date(now.getTime() + 24 * 60 * 60 * 1000);
+      return api.getMaintenanceWindows(now.toISOString(), tomorrow.toISOString());
+    },
+    {
+      refetchInterval: 60000, // Refetch every minute
+    },
+  );
+};
+
 // Tables hook
 export const useTables = () => {
   return useQuery(queryKeys.tables, api.getTables);
@@ -71,6 +171,7 @@ export const useKeyValuePairs = (
   prefix: string = '',
   start: string = '',
   end: string = '',
+  enabled: boolean = true,
 ) => {
   // Debounce filter values to avoid excessive API calls
   const debouncedPrefix = useDebounce(prefix, 300);
@@ -83,15 +184,47 @@ export const useKeyValuePairs = (
     [table, debouncedPrefix, debouncedStart, debouncedEnd],
   );
 
-  return useQuery(
+  // Scans cut short by their time budget are continued page by page with fetchNextPage
+  const query = useInfiniteQuery(
     queryKey,
-    () => api.getKeyValuePairs(table, debouncedPrefix, debouncedStart, debouncedEnd),
+    ({ pageParam }) =>
+      api.getKeyValuePairs(table, debouncedPrefix, debouncedStart, debouncedEnd, pageParam),
     {
-      enabled: !!table, // Only run the query if table is provided
+      enabled: !!table && enabled, // Only run the query if table is provided
       staleTime: 10 * 1000, // Consider data fresh for 10 seconds
       cacheTime: 5 * 60 * 1000, // Cache for 5 minutes
       refetchOnWindowFocus: false, // Don't refetch when window regains focus
       keepPreviousData: true, // Keep previous data while fetching new data
+      getNextPageParam: (page) => (page.truncated ? page.cursor : undefined),
+    },
+  );
+
+  const data = useMemo(() => query.data?.pages.flatMap((page) => page.pairs), [query.data]);
+  return { ...query, data };
+};
+
+// Scans matching more keys than this are only loaded once the user confirms them
+export const LARGE_SCAN_KEYS = 100000;
+
+// Estimated size of a key scan, debounced like useKeyValuePairs so both follow the same filter
+export const useScanEstimate = (
+  table: string,
+  prefix: string = '',
+  start: string = '',
+  end: string = '',
+) => {
+  const debouncedPrefix = useDebounce(prefix, 300);
+  const debouncedStart = useDebounce(start, 300);
+  const debouncedEnd = useDebounce(end, 300);
+
+  return useQuery(
+    queryKeys.scanEstimate(table, debouncedPrefix, debouncedStart, debouncedEnd),
+    () => api.estimateKeyValuePairs(table, debouncedPrefix, debouncedStart, debouncedEnd),
+    {
+      enabled: !!table,
+      staleTime: 30 * 1000,
+      refetchOnWindowFocus: false,
+      retry: false, // A scan that can't be estimated is loaded without confirmation
     },
   );
 };
@@ -120,18 +253,55 @@ export const useKeyValuePair = (table: string, key: string) => {
 };
 
 // Metrics query hook
-export const useMetricsQuery = (query: string, time?: string) => {
-  return useQuery(queryKeys.metrics(query, time), () => api.queryMetrics(query, time), {
-    enabled: !!query,
-    refetchInterval: 10000, // Refetch every 10 seconds
+export const useMetricsQuery = (query: string, time?: string, scope?: MetricsScope) => {
+  return useQuery(
+    queryKeys.metrics(query, time, scope),
+    () => api.queryMetrics(query, time, scope),
+    {
+      enabled: !!query,
+      refetchInterval: suggestedInterval(10000), // Refetch as suggested, every 10 seconds by default
+    },
+  );
+};
+
+// Node resource utilization hook
+// Scrape state of the clusters, including the back-off of failing ones
+export const useScrapeTargets = () => {
+  return useQuery(queryKeys.scrapeTargets, api.getScrapeTargets, {
+    refetchInterval: 15000, // Refetch every 15 seconds
+  });
+};
+
+// Summary of all registered clusters for the fleet landing page
+export const useFleet = () => {
+  return useQuery(queryKeys.fleet, api.getFleet, {
+    refetchInterval: 30000, // Refetch every 30 seconds
   });
 };
 
+export const useServerResources = (serverId?: string) => {
+  return useQuery(
+    queryKeys.serverResources(serverId ?? ''),
+    () => api.getServerResources(serverId!),
+    {
+      enabled: !!serverId,
+      refetchInterval: 10000, // Refetch every 10 seconds
+    },
+  );
+};
+
 // Metrics range query hook
-export const useMetricsRangeQuery = (query: string, start: string, end: string, step?: string) => {
+export const useMetricsRangeQuery = (
+  query: string,
+  start: string,
+  end: string,
+  step?: string,
+  compareOffset?: string,
+  scope?: MetricsScope,
+) => {
   return useQuery(
-    queryKeys.metricsRange(query, start, end, step),
-    () => api.queryMetricsRange(query, start, end, step),
+    queryKeys.metricsRange(query, start, end, step, compareOffset, scope),
+    () => api.queryMetricsRange(query, start, end, step, compareOffset, scope),
     {
       enabled: !!query && !!start && !!end,
     },
@@ -175,8 +345,14 @@ export const useDeleteKeyValuePair = () => {
 
         // Optimistically remove the item from all relevant queries
         queryClient.setQueriesData(['keyValuePairs', table], (old: any) => {
-          if (!old || !Array.isArray(old)) return old;
-          return old.filter((item: any) => item.key !== key);
+          if (!old || !Array.isArray(old.pages)) return old;
+          return {
+            ...old,
+            pages: old.pages.map((page: any) => ({
+              ...page,
+              pairs: page.pairs.filter((item: any) => item.key !== key),
+            })),
+          };
         });
 
         // Return a context object with the snapshotted value
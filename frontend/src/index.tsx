@@ -5,6 +5,7 @@ import ReactDOM from 'react-dom/client';
 import { BrowserRouter } from 'react-router-dom';
 
 import App from './App';
+import { BASE_PATH } from './utils/basePath';
 import { ThemeProvider } from './theme/ThemeProvider';
 import './index.css';
 
@@ -40,7 +41,7 @@ root.render(
   <React.StrictMode>
     <QueryClientProvider client={queryClient}>
       <ThemeProvider>
-        <BrowserRouter>
+        <BrowserRouter basename={BASE_PATH}>
           <AppInitializer>
             <App />
           </AppInitializer>
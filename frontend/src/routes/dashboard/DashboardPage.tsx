@@ -2,11 +2,12 @@ import React from 'react';
 
 import ClusterSummarySection from './components/ClusterSummarySection';
 import ErrorAccordion from './components/ErrorAccordion';
+import MaintenanceSection from './components/MaintenanceSection';
 import ServerStatusSection from './components/ServerStatusSection';
 import TablesSection from './components/TablesSection';
 
 import { useNavigation } from '@/context/NavigationContext';
-import { useStatus, useTables } from '@/hooks/useApi';
+import { useMaintenanceWindows, useStatus, useTables } from '@/hooks/useApi';
 import { usePageTitle } from '@/hooks/usePageTitle';
 import { ErrorState } from '@/shared/ErrorState';
 import { LoadingState } from '@/shared/LoadingState';
@@ -31,6 +32,7 @@ const DashboardPage: React.FC = () => {
     error: tablesError,
     refetch: refetchTables,
   } = useTables();
+  const { data: maintenanceWindows } = useMaintenanceWindows();
 
   const handleRefresh = React.useCallback(() => {
     refetchStatus();
@@ -66,6 +68,8 @@ const DashboardPage: React.FC = () => {
 
   return (
     <div className="space-y-6">
+      <MaintenanceSection windows={maintenanceWindows || []} />
+
       <ClusterSummarySection
         status={status?.servers?.[0]?.status || 'unknown'}
         message={status?.servers?.[0]?.message || 'No status available'}
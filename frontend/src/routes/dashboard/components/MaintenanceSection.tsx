@@ -0,0 +1,49 @@
+import React from 'react';
+
+import { MaintenanceWindow } from '@/types';
+import { Alert } from '@/ui/Alert';
+import { Typography } from '@/ui/Typography';
+
+interface MaintenanceSectionProps {
+  windows: MaintenanceWindow[];
+}
+
+const formatTime = (time: string) => new Date(time).toLocaleString();
+
+const scopeOf = (window: MaintenanceWindow) => {
+  const tables = window.tables?.length ? `tables ${window.tables.join(', ')}` : 'all tables';
+  return window.cluster ? `${tables} of ${window.cluster}` : tables;
+};
+
+/**
+ * Annotates the dashboard with active and upcoming maintenance windows,
+ * so that unusual behaviour of the affected tables is not mistaken for an incident
+ */
+const MaintenanceSection: React.FC<MaintenanceSectionProps> = ({ windows }) => {
+  if (windows.length === 0) {
+    return null;
+  }
+
+  const now = Date.now();
+  return (
+    <div className="space-y-2">
+      {windows.map((window) => {
+        const active = new Date(window.start).getTime() <= now;
+        return (
+          <Alert key={window.id} variant={active ? 'warning' : 'info'}>
+            <Typography variant="body2" className="font-medium">
+              {active ? 'Maintenance in progress' : 'Upcoming maintenance'}: {window.title}
+            </Typography>
+            <Typography variant="body2">
+              {formatTime(window.start)} – {formatTime(window.end)}, {scopeOf(window)}
+              {window.freezeWrites && ' (read-only)'}
+            </Typography>
+            {window.description && <Typography variant="body2">{window.description}</Typography>}
+          </Alert>
+        );
+      })}
+    </div>
+  );
+};
+
+export default MaintenanceSection;
@@ -3,6 +3,7 @@ import React from 'react';
 import { CardWithHeader } from '@/shared/CardWithHeader';
 import { LoadingState } from '@/shared/LoadingState';
 import { Table as TableType } from '@/types';
+import { Chip } from '@/ui/Chip';
 import { Table, TableRow, TableHeader, TableBody, TableCell } from '@/ui/Table';
 import { Typography } from '@/ui/Typography';
 
@@ -32,7 +33,24 @@ const TablesSection: React.FC<TablesSectionProps> = ({ tables, loading }) => {
           <TableBody>
             {tables.map((table) => (
               <TableRow key={table.id}>
-                <TableCell>{table.name}</TableCell>
+                <TableCell>
+                  <div className="flex items-center gap-2">
+                    {table.name}
+                    {table.readOnly && (
+                      <Chip
+                        variant="warning"
+                        className="text-xs"
+                        title={
+                          table.maintenanceWindow
+                            ? 'Writes are frozen by a maintenance window'
+                            : 'Table is marked read-only'
+                        }
+                      >
+                        Read-only
+                      </Chip>
+                    )}
+                  </div>
+                </TableCell>
                 <TableCell>
                   <Typography variant="body2" className="text-gray-600 dark:text-gray-400">
                     {table.id}
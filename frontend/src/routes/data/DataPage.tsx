@@ -1,3 +1,4 @@
+import { useIsFetching } from '@tanstack/react-query';
 import { Plus } from 'lucide-react';
 import React, { useState } from 'react';
 import { useNavigate, useParams, Link as RouterLink } from 'react-router-dom';
@@ -6,7 +7,7 @@ import KeyValueFilter from './components/KeyValueFilter';
 import KeyValueTable from './components/KeyValueTable';
 import TableSelector from './components/TableSelector';
 
-import { useDeleteKeyValuePair, useKeyValuePairs, useStatus } from '@/hooks/useApi';
+import { useDeleteKeyValuePair, useStatus } from '@/hooks/useApi';
 import { usePageTitle } from '@/hooks/usePageTitle';
 import { Breadcrumb } from '@/shared/Breadcrumb';
 import { CardWithHeader } from '@/shared/CardWithHeader';
@@ -29,13 +30,8 @@ const DataPage: React.FC = () => {
 
   const deleteMutation = useDeleteKeyValuePair();
 
-  // Get loading state for filter
-  const { isLoading: keyValuePairsLoading } = useKeyValuePairs(
-    table || '',
-    appliedFilterMode === 'prefix' ? appliedPrefix : '',
-    appliedFilterMode === 'range' ? appliedStart : '',
-    appliedFilterMode === 'range' ? appliedEnd : '',
-  );
+  // Get loading state for filter, the table decides when the scan is loaded
+  const keyValuePairsLoading = useIsFetching(['keyValuePairs', table || '']) > 0;
 
   // Get server status for table metadata
   const { data: status, isLoading: statusLoading } = useStatus();
@@ -2,11 +2,14 @@ import { Edit, Trash2, List, Grid } from 'lucide-react';
 import React, { useState } from 'react';
 import { Link as RouterLink } from 'react-router-dom';
 
+import { formatBytes } from '../../../utils/contentDetection';
+
 import KeyValueCells from './KeyValueCells';
 
-import { useKeyValuePairs } from '@/hooks/useApi';
+import { LARGE_SCAN_KEYS, useKeyValuePairs, useScanEstimate } from '@/hooks/useApi';
 import { ErrorState } from '@/shared/ErrorState';
 import { LoadingState } from '@/shared/LoadingState';
+import { Alert } from '@/ui/Alert';
 import { Button } from '@/ui/Button';
 import { Table, TableHeader, TableBody, TableRow, TableCell } from '@/ui/Table';
 import { Typography } from '@/ui/Typography';
@@ -27,13 +30,46 @@ const KeyValueTable: React.FC<KeyValueTableProps> = ({
   onDeletePair,
 }) => {
   const [density, setDensity] = useState<'compact' | 'comfortable'>('comfortable');
+  // The scan the user agreed to load although it is large
+  const [confirmedScan, setConfirmedScan] = useState<string | null>(null);
+  const scan = JSON.stringify([table, prefix, start, end]);
+
+  // An estimate that can't be made, e.g. by an older console, doesn't block loading
+  const { data: estimate, isLoading: isEstimating } = useScanEstimate(table, prefix, start, end);
+  const needsConfirmation =
+    !!estimate && estimate.keys > LARGE_SCAN_KEYS && confirmedScan !== scan;
 
   const {
     data: keyValuePairs,
     isLoading,
     isError,
     refetch,
-  } = useKeyValuePairs(table, prefix, start, end);
+    hasNextPage,
+    fetchNextPage,
+    isFetchingNextPage,
+  } = useKeyValuePairs(table, prefix, start, end, !isEstimating && !needsConfirmation);
+
+  if (isEstimating) {
+    return <LoadingState message="Estimating scan size..." height={150} />;
+  }
+
+  if (needsConfirmation && estimate) {
+    return (
+      <Alert
+        variant="warning"
+        className="mt-4"
+        action={
+          <Button variant="outline" size="sm" onClick={() => setConfirmedScan(scan)}>
+            Continue
+          </Button>
+        }
+      >
+        This filter matches about{' '}
+        {new Intl.NumberFormat(undefined, { notation: 'compact' }).format(estimate.keys)} keys (
+        {formatBytes(estimate.bytes)}). Continue loading them?
+      </Alert>
+    );
+  }
 
   if (isLoading) {
     return <LoadingState message="Loading key-value pairs..." height={150} />;
@@ -89,6 +125,26 @@ const KeyValueTable: React.FC<KeyValueTableProps> = ({
         </div>
       </div>
 
+      {/* Slow scans return the keys received within their time budget */}
+      {hasNextPage && (
+        <Alert
+          variant="warning"
+          action={
+            <Button
+              variant="outline"
+              size="sm"
+              onClick={() => fetchNextPage()}
+              disabled={isFetchingNextPage}
+            >
+              {isFetchingNextPage ? 'Loading...' : 'Continue'}
+            </Button>
+          }
+        >
+          The scan timed out, only the keys received so far are shown. Continue to load the keys
+          after them.
+        </Alert>
+      )}
+
       {/* Table Container - Improved for better overflow handling */}
       <div className="overflow-hidden border border-gray-200 dark:border-gray-700 rounded-lg shadow-sm bg-white dark:bg-gray-800">
         <div className="min-w-full overflow-x-auto">
@@ -0,0 +1,125 @@
+import React, { useMemo } from 'react';
+
+import { useFleet } from '@/hooks/useApi';
+import { usePageTitle } from '@/hooks/usePageTitle';
+import { Breadcrumb } from '@/shared/Breadcrumb';
+import { CardWithHeader } from '@/shared/CardWithHeader';
+import { ErrorState } from '@/shared/ErrorState';
+import { LoadingState } from '@/shared/LoadingState';
+import { RefreshButton } from '@/shared/RefreshButton';
+import { StatusChip } from '@/shared/StatusChip';
+import { FleetCluster } from '@/types';
+import { Typography } from '@/ui/Typography';
+
+import { formatBytes } from '../../utils/contentDetection';
+
+const formatTime = (time?: string) => (time ? new Date(time).toLocaleString() : '–');
+
+const healthColors = {
+  healthy: 'success',
+  degraded: 'warning',
+  unreachable: 'error',
+  unknown: 'default',
+} as const;
+
+const formatVersions = (cluster: FleetCluster) => {
+  if (!cluster.minVersion) {
+    return '–';
+  }
+  return cluster.minVersion === cluster.maxVersion
+    ? cluster.minVersion
+    : `${cluster.minVersion} – ${cluster.maxVersion}`;
+};
+
+const ClusterRow: React.FC<{ cluster: FleetCluster }> = ({ cluster }) => (
+  <tr className="border-t border-gray-200 dark:border-gray-700 align-top">
+    <td className="py-2 font-mono">{cluster.name}</td>
+    <td className="py-2">
+      <StatusChip status={cluster.health} colorMapping={healthColors} />
+    </td>
+    <td className="py-2">
+      {cluster.nodesUp}/{cluster.nodes}
+    </td>
+    <td className="py-2 font-mono">{formatVersions(cluster)}</td>
+    <td className="py-2">
+      {formatBytes(cluster.dbSize)} in {cluster.tables} table{cluster.tables === 1 ? '' : 's'}
+    </td>
+    <td className={`py-2 ${cluster.firingAlerts > 0 ? 'text-red-600 dark:text-red-400' : ''}`}>
+      {cluster.firingAlerts}
+    </td>
+    <td className="py-2">
+      {cluster.scrape.up} up
+      {cluster.scrape.down > 0 && (
+        <span className="text-red-600 dark:text-red-400">, {cluster.scrape.down} down</span>
+      )}
+      {cluster.scrape.unknown > 0 && `, ${cluster.scrape.unknown} unknown`}
+    </td>
+    <td className="py-2">{formatTime(cluster.sampledAt)}</td>
+  </tr>
+);
+
+/**
+ * Lists every registered cluster with its health, versions, size, alerts and scrape status.
+ * The rows come from cached samples, so the page stays cheap across dozens of clusters.
+ */
+const FleetPage: React.FC = () => {
+  const { data: fleet, isLoading, isError, error, refetch } = useFleet();
+
+  const refreshButton = useMemo(
+    () => (
+      <RefreshButton
+        onClick={() => refetch()}
+        disabled={isLoading}
+        variant="header"
+        tooltipTitle="Refresh fleet"
+      />
+    ),
+    [isLoading, refetch],
+  );
+  usePageTitle('Fleet', refreshButton);
+
+  if (isLoading) {
+    return <LoadingState message="Loading fleet..." />;
+  }
+
+  if (isError) {
+    return <ErrorState error={error} message="Failed to fetch fleet." onRetry={refetch} />;
+  }
+
+  return (
+    <div className="space-y-6">
+      <Breadcrumb items={[{ label: 'Fleet', current: true }]} />
+      <CardWithHeader title="Clusters">
+        <div className="p-4 overflow-x-auto">
+          {!fleet || fleet.clusters.length === 0 ? (
+            <Typography variant="body2" className="text-gray-600 dark:text-gray-400">
+              No clusters are registered
+            </Typography>
+          ) : (
+            <table className="w-full text-sm">
+              <thead>
+                <tr className="text-left text-xs font-medium text-gray-500 dark:text-gray-400 uppercase">
+                  <th className="py-2">Cluster</th>
+                  <th className="py-2">Health</th>
+                  <th className="py-2">Nodes</th>
+                  <th className="py-2">Version</th>
+                  <th className="py-2">Size</th>
+                  <th className="py-2">Alerts</th>
+                  <th className="py-2">Scrapes</th>
+                  <th className="py-2">Sampled</th>
+                </tr>
+              </thead>
+              <tbody>
+                {fleet.clusters.map((cluster) => (
+                  <ClusterRow key={cluster.name} cluster={cluster} />
+                ))}
+              </tbody>
+            </table>
+          )}
+        </div>
+      </CardWithHeader>
+    </div>
+  );
+};
+
+export default FleetPage;
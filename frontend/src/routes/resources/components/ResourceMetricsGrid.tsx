@@ -1,9 +1,8 @@
 import { Loader2 } from 'lucide-react';
 import React from 'react';
 
-import { useMetricsQuery } from '@/hooks/useApi';
+import { useServerResources } from '@/hooks/useApi';
 import { ErrorState } from '@/shared/ErrorState';
-import { MetricsQueryResponse } from '@/types';
 
 type MetricCardProps = {
   title: string;
@@ -66,106 +65,56 @@ const ResourceMetricsGrid: React.FC<ResourceMetricsGridProps> = ({
   serverId,
   serverAddress: _serverAddress,
 }) => {
-  // Query for CPU usage as percentage
-  const cpuQuery = `rate(process_cpu_seconds_total{node_id="${serverId}"}[1m]) * 100`;
-  const { data: cpuData, isLoading: cpuLoading, isError: cpuError } = useMetricsQuery(cpuQuery);
+  // A single request returns the summary of all resources of the node
+  const { data, isLoading, isError } = useServerResources(serverId);
 
-  // Query for memory usage in MB
-  const memoryQuery = `sum(increase(go_memstats_alloc_bytes_total{node_id="${serverId}"}[1m])) / 1024 / 1024`;
-  const {
-    data: memoryData,
-    isLoading: memoryLoading,
-    isError: memoryError,
-  } = useMetricsQuery(memoryQuery);
+  // Format a value with the given precision, unreported metrics are shown as n/a
+  const format = (value: number | null | undefined, digits: number, divisor: number = 1) =>
+    value === null || value === undefined ? 'n/a' : (value / divisor).toFixed(digits);
 
-  // Query for disk usage in MB
-  const diskQuery = `sum(regatta_table_storage_disk_usage_bytes{node_id="${serverId}"}) / 1024 / 1024`;
-  const { data: diskData, isLoading: diskLoading, isError: diskError } = useMetricsQuery(diskQuery);
-
-  // Query for network throughput in MB/s
-  const networkQuery = `network_throughput_mbps{node_id="${serverId}"}`;
-  const {
-    data: networkData,
-    isLoading: networkLoading,
-    isError: networkError,
-  } = useMetricsQuery(networkQuery);
-
-  const extractVectorMetricValue = (
-    data?: MetricsQueryResponse,
-    defaultValue: number = 0,
-  ): number => {
-    // Check if data exists and has the expected structure
-    if (!data || !data.data) {
-      return defaultValue;
-    }
-
-    const { resultType, result } = data.data;
-
-    switch (resultType) {
-      case 'vector':
-        if (result.length === 0) {
-          return defaultValue;
-        }
-        // The result is a scalar value, which is an array with two elements: [timestamp, value]
-        return Number(result[0].value[1]) || defaultValue;
-      default:
-        console.error(`Wrong result type ${resultType}`);
-        return defaultValue;
-    }
-  };
-
-  // Format the values with appropriate precision
-  const cpuValue = extractVectorMetricValue(cpuData);
-  const formattedCpuValue = cpuValue.toFixed(1);
-
-  const memoryValue = extractVectorMetricValue(memoryData);
-  const formattedMemoryValue = memoryValue.toFixed(0);
-
-  const diskValue = extractVectorMetricValue(diskData);
-  const formattedDiskValue = diskValue.toFixed(1);
-
-  const networkValue = extractVectorMetricValue(networkData);
-  const formattedNetworkValue = networkValue.toFixed(2);
-
-  // Check if all metrics had errors
-  const allError = cpuError && memoryError && diskError && networkError;
-  if (allError) {
+  if (isError) {
     return <ErrorState message="Failed to load metrics data." />;
   }
 
+  const openFds =
+    data?.openFds !== null && data?.openFds !== undefined && data?.maxFds
+      ? `${data.openFds.toFixed(0)} / ${data.maxFds.toFixed(0)}`
+      : format(data?.openFds, 0);
+
   return (
-    <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-4 gap-6">
+    <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-5 gap-6">
       <MetricCard
         title="CPU Usage"
-        value={formattedCpuValue}
+        value={format(data?.cpuPercent, 1)}
         unit="%"
         color="primary.main"
-        loading={cpuLoading}
-        error={cpuError}
+        loading={isLoading}
       />
       <MetricCard
         title="Memory Usage"
-        value={formattedMemoryValue}
+        value={format(data?.memoryBytes, 0, 1024 * 1024)}
         unit="MB"
         color="warning.main"
-        loading={memoryLoading}
-        error={memoryError}
+        loading={isLoading}
       />
       <MetricCard
         title="Disk Usage"
-        value={formattedDiskValue}
+        value={format(data?.diskBytes, 1, 1024 * 1024)}
         unit="MB"
         color="info.main"
-        loading={diskLoading}
-        error={diskError}
+        loading={isLoading}
       />
       <MetricCard
-        title="Network Throughput"
-        value={formattedNetworkValue}
-        unit="MB/s"
+        title="Open File Descriptors"
+        value={openFds}
         color="success.main"
-        loading={networkLoading}
-        error={networkError}
+        loading={isLoading}
+      />
+      <MetricCard
+        title="Goroutines"
+        value={format(data?.goroutines, 0)}
+        color="primary.main"
+        loading={isLoading}
       />
     </div>
   );
@@ -1,9 +1,11 @@
-import { Database, Settings, User } from 'lucide-react';
+import { BarChart3, Database, Settings, User } from 'lucide-react';
 import React from 'react';
 
 import ServerConfig from './components/ServerConfig';
 import TableManagement from './components/TableManagement';
+import UsageReport from './components/UsageReport';
 
+import { useAnalyticsStatus, useMe } from '@/hooks/useApi';
 import { usePageTitle } from '@/hooks/usePageTitle';
 import { Breadcrumb } from '@/shared/Breadcrumb';
 import { Card, Tab, TabList, TabPanel, Tabs } from '@/ui';
@@ -14,6 +16,11 @@ const SettingsPage: React.FC = () => {
   // Use the usePageTitle hook instead of PageHeader component
   usePageTitle('Settings');
 
+  // The usage report is only available to operators, and only if analytics are enabled
+  const { data: analytics } = useAnalyticsStatus();
+  const { data: me } = useMe();
+  const showUsage = analytics?.enabled && me?.role !== 'viewer';
+
   const handleChange = (newValue: number) => {
     setValue(newValue);
   };
@@ -28,6 +35,7 @@ const SettingsPage: React.FC = () => {
             <Tab value={0} label="Tables" icon={<Database />} />
             <Tab value={1} label="System" icon={<Settings />} />
             <Tab value={2} label="User Preferences" icon={<User />} />
+            {showUsage && <Tab value={3} label="Usage" icon={<BarChart3 />} />}
           </TabList>
 
           <TabPanel value={value} index={0}>
@@ -50,6 +58,12 @@ const SettingsPage: React.FC = () => {
               </div>
             </div>
           </TabPanel>
+
+          {showUsage && (
+            <TabPanel value={value} index={3}>
+              <UsageReport />
+            </TabPanel>
+          )}
         </Tabs>
       </Card>
     </div>
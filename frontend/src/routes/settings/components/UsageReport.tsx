@@ -0,0 +1,103 @@
+import React, { useState } from 'react';
+
+import { useUsageReport } from '@/hooks/useApi';
+import { CardWithHeader } from '@/shared/CardWithHeader';
+import { ErrorState } from '@/shared/ErrorState';
+import { LoadingState } from '@/shared/LoadingState';
+import type { UsageCount } from '@/types';
+import { Typography } from '@/ui/Typography';
+
+const rangeOptions = [7, 30, 90];
+
+const CountTable: React.FC<{ label: string; counts: UsageCount[] }> = ({ label, counts }) => {
+  if (counts.length === 0) {
+    return (
+      <Typography variant="body2" className="text-gray-600 dark:text-gray-400">
+        Nothing recorded yet
+      </Typography>
+    );
+  }
+  const top = counts[0].count;
+
+  return (
+    <table className="w-full text-sm">
+      <thead>
+        <tr className="text-left text-xs font-medium text-gray-500 dark:text-gray-400 uppercase">
+          <th className="py-2">{label}</th>
+          <th className="py-2 w-1/2">Count</th>
+        </tr>
+      </thead>
+      <tbody>
+        {counts.map((count) => (
+          <tr key={count.name} className="border-t border-gray-200 dark:border-gray-700">
+            <td className="py-2 font-mono">{count.name}</td>
+            <td className="py-2">
+              <div className="flex items-center gap-2">
+                <div
+                  className="h-2 rounded bg-primary-500"
+                  style={{ width: `${Math.max((count.count / top) * 80, 1)}%` }}
+                />
+                <span>{count.count}</span>
+              </div>
+            </td>
+          </tr>
+        ))}
+      </tbody>
+    </table>
+  );
+};
+
+const UsageReport: React.FC = () => {
+  const [days, setDays] = useState(30);
+  const { data: report, isLoading, error, refetch } = useUsageReport(days);
+
+  if (isLoading) {
+    return (
+      <div className="p-6">
+        <LoadingState message="Loading usage report..." />
+      </div>
+    );
+  }
+
+  if (error || !report) {
+    return (
+      <div className="p-6">
+        <ErrorState error={error} message="Error loading usage report" onRetry={refetch} />
+      </div>
+    );
+  }
+
+  return (
+    <div className="p-6 space-y-6">
+      <div className="flex justify-between items-center">
+        <div>
+          <Typography variant="h6">Usage</Typography>
+          <Typography variant="body2" className="text-gray-600 dark:text-gray-400">
+            Counted by this console from {report.from} to {report.to}. Nothing is sent elsewhere.
+          </Typography>
+        </div>
+        <select
+          value={days}
+          onChange={(e) => setDays(Number(e.target.value))}
+          className="px-3 py-2 border border-gray-300 rounded-md text-sm bg-white dark:bg-gray-800 dark:border-gray-600 dark:text-gray-300"
+        >
+          {rangeOptions.map((option) => (
+            <option key={option} value={option}>
+              Last {option} days
+            </option>
+          ))}
+        </select>
+      </div>
+
+      <CardWithHeader title="Page views by feature">
+        <CountTable label="Feature" counts={report.pageViews} />
+      </CardWithHeader>
+
+      <CardWithHeader title="API requests by route">
+        <CountTable label="Route" counts={report.queries} />
+      </CardWithHeader>
+    </div>
+  );
+};
+
+export default UsageReport;
@@ -0,0 +1,149 @@
+import React, { useMemo } from 'react';
+
+import { useScrapeTargets } from '@/hooks/useApi';
+import { usePageTitle } from '@/hooks/usePageTitle';
+import { Breadcrumb } from '@/shared/Breadcrumb';
+import { CardWithHeader } from '@/shared/CardWithHeader';
+import { ErrorState } from '@/shared/ErrorState';
+import { LoadingState } from '@/shared/LoadingState';
+import { RefreshButton } from '@/shared/RefreshButton';
+import { StatusChip } from '@/shared/StatusChip';
+import { BlockedMetric, ScrapeTarget } from '@/types';
+import { Typography } from '@/ui/Typography';
+
+const formatTime = (time?: string) => (time ? new Date(time).toLocaleString() : '–');
+
+// Back-off durations are reported in nanoseconds
+const formatBackoff = (nanos: number) => {
+  const seconds = Math.round(nanos / 1e9);
+  if (seconds < 60) {
+    return `${seconds}s`;
+  }
+  const minutes = Math.floor(seconds / 60);
+  return seconds % 60 ? `${minutes}m ${seconds % 60}s` : `${minutes}m`;
+};
+
+const healthColors = { up: 'success', down: 'error', unknown: 'default' } as const;
+
+const TargetRow: React.FC<{ target: ScrapeTarget }> = ({ target }) => (
+  <tr className="border-t border-gray-200 dark:border-gray-700 align-top">
+    <td className="py-2 font-mono">{target.cluster}</td>
+    <td className="py-2">
+      <StatusChip status={target.health} colorMapping={healthColors} />
+    </td>
+    <td className="py-2">{formatTime(target.lastScrape)}</td>
+    <td className="py-2">
+      {target.consecutiveFailures > 0
+        ? `${formatBackoff(target.backoff)} after ${target.consecutiveFailures} failure${
+            target.consecutiveFailures === 1 ? '' : 's'
+          }`
+        : '–'}
+    </td>
+    <td className="py-2">{formatTime(target.nextScrape)}</td>
+    <td className="py-2 text-red-600 dark:text-red-400 break-all">{target.lastError}</td>
+  </tr>
+);
+
+const BlockedMetrics: React.FC<{ blocked: BlockedMetric[] }> = ({ blocked }) => (
+  <table className="w-full text-sm">
+    <thead>
+      <tr className="text-left text-xs font-medium text-gray-500 dark:text-gray-400 uppercase">
+        <th className="py-2">Cluster</th>
+        <th className="py-2">Metric</th>
+        <th className="py-2">Reason</th>
+        <th className="py-2">Suspect labels</th>
+        <th className="py-2">Blocked until</th>
+      </tr>
+    </thead>
+    <tbody>
+      {blocked.map((b) => (
+        <tr
+          key={`${b.cluster}/${b.metric}`}
+          className="border-t border-gray-200 dark:border-gray-700 align-top"
+        >
+          <td className="py-2 font-mono">{b.cluster}</td>
+          <td className="py-2 font-mono">{b.metric}</td>
+          <td className="py-2">{b.reason}</td>
+          <td className="py-2 font-mono">{b.labels.length > 0 ? b.labels.join(', ') : '–'}</td>
+          <td className="py-2">{formatTime(b.until)}</td>
+        </tr>
+      ))}
+    </tbody>
+  </table>
+);
+
+/**
+ * Lists the clusters metrics are collected from. Clusters that keep failing are scraped
+ * less and less often, up to the maximum back-off, until a scrape succeeds again. Metrics
+ * exceeding the series or label limits are not stored for a while and listed separately.
+ */
+const TargetsPage: React.FC = () => {
+  const { data: targets, isLoading, isError, error, refetch } = useScrapeTargets();
+
+  const blocked = useMemo(() => targets?.flatMap((target) => target.blocked ?? []) ?? [], [targets]);
+
+  const refreshButton = useMemo(
+    () => (
+      <RefreshButton
+        onClick={() => refetch()}
+        disabled={isLoading}
+        variant="header"
+        tooltipTitle="Refresh scrape targets"
+      />
+    ),
+    [isLoading, refetch],
+  );
+  usePageTitle('Targets', refreshButton);
+
+  if (isLoading) {
+    return <LoadingState message="Loading scrape targets..." />;
+  }
+
+  if (isError) {
+    return (
+      <ErrorState error={error} message="Failed to fetch scrape targets." onRetry={refetch} />
+    );
+  }
+
+  return (
+    <div className="space-y-6">
+      <Breadcrumb items={[{ label: 'Targets', current: true }]} />
+      <CardWithHeader title="Scrape Targets">
+        <div className="p-4 overflow-x-auto">
+          {!targets || targets.length === 0 ? (
+            <Typography variant="body2" className="text-gray-600 dark:text-gray-400">
+              No clusters are scraped yet
+            </Typography>
+          ) : (
+            <table className="w-full text-sm">
+              <thead>
+                <tr className="text-left text-xs font-medium text-gray-500 dark:text-gray-400 uppercase">
+                  <th className="py-2">Cluster</th>
+                  <th className="py-2">Health</th>
+                  <th className="py-2">Last scrape</th>
+                  <th className="py-2">Back-off</th>
+                  <th className="py-2">Next scrape</th>
+                  <th className="py-2">Last error</th>
+                </tr>
+              </thead>
+              <tbody>
+                {targets.map((target) => (
+                  <TargetRow key={target.cluster} target={target} />
+                ))}
+              </tbody>
+            </table>
+          )}
+        </div>
+      </CardWithHeader>
+      {blocked.length > 0 && (
+        <CardWithHeader title="Blocked Metrics">
+          <div className="p-4 overflow-x-auto">
+            <BlockedMetrics blocked={blocked} />
+          </div>
+        </CardWithHeader>
+      )}
+    </div>
+  );
+};
+
+export default TargetsPage;
@@ -16,10 +16,21 @@ export interface ServerStatus {
   config?: Record<string, any>;
   tables?: Record<string, TableStatus>;
   errors?: string[];
+  // Set when the status of the server could not be retrieved
+  error?: string;
 }
 
 export interface StatusResponse {
   servers: ServerStatus[];
+  // True when some servers could not be queried and carry an error instead
+  partial: boolean;
+  // How long to wait before polling again, grows while nothing changes or the backend is busy
+  suggestedRefreshSeconds?: number;
+  // Pass as `since` to only receive servers changed afterwards
+  revision: string;
+  // True when servers only contains the servers changed since the requested revision
+  delta?: boolean;
+  removed?: string[];
 }
 
 // Server info type
@@ -38,15 +49,59 @@ export interface ClusterInfo {
 }
 
 // Table types
+export interface TableStats {
+  dbSize: number;
+  logSize: number;
+  sampledAt: string;
+}
+
+export interface TableMetadata {
+  createdBy?: string;
+  createdAt?: string;
+  purpose?: string;
+  backfilled?: boolean;
+  protected?: boolean;
+  // Writes through the console are refused while set
+  readOnly?: boolean;
+}
+
 export interface Table {
   id: string;
   name: string;
+  // Latest sampled statistics, missing until the table has been sampled
+  stats?: TableStats;
+  // Console-side annotations, missing for tables without any
+  metadata?: TableMetadata;
+  // Set while writes are refused, by the table's flag or a maintenance window
+  readOnly?: boolean;
+  maintenanceWindow?: string;
 }
 
 // Key-value pair types
 export interface KeyValuePair {
   key: string;
   value: string;
+  // Encodings reversed when reading with decode=auto, outermost first
+  transforms?: string[];
+  // Compression of the stored value (gzip or zstd), set even if it couldn't be decompressed
+  compressed?: string;
+  decodeError?: string;
+  // Encryption scheme of values the console can't decode
+  encrypted?: string;
+}
+
+// A page of a key range scan. Scans exceeding their time budget return the pairs received so far
+// and a cursor to continue after them.
+export interface KeyValuePage {
+  pairs: KeyValuePair[];
+  truncated: boolean;
+  cursor?: string;
+}
+
+// Approximate size of a key range scan, estimated before loading it
+export interface ScanEstimate {
+  keys: number;
+  bytes: number;
 }
 
 // Table management types
@@ -61,6 +116,7 @@ export interface CreateTableResponse {
 type QueryResponse<T> = {
   status: 'success' | 'error';
   data: T;
+  suggestedRefreshSeconds?: number;
 };
 
 type VectorResult = {
@@ -83,7 +139,225 @@ type QueryResult =
   | { resultType: 'scalar'; result: ScalarResult }
   | { resultType: 'string'; result: StringResult };
 
-export type MetricsQueryResponse = QueryResponse<QueryResult>;
+// Narrows a metrics query to clusters and aggregates it by labels, e.g. groupBy: ['cluster']
+export interface MetricsScope {
+  clusters?: string[];
+  groupBy?: string[];
+  aggregate?: 'sum' | 'avg' | 'min' | 'max' | 'count';
+}
+
+export type MetricsQueryResponse = QueryResponse<QueryResult> & {
+  // The range query result compareOffset earlier, with timestamps aligned to data
+  compareOffset?: string;
+  comparison?: QueryResult;
+};
+
+// Query templates suggested for a metric by the guided query builder
+export interface QuerySuggestion {
+  title: string;
+  query: string;
+  description?: string;
+}
+
+export interface MetricSuggestions {
+  metric: string;
+  family: string;
+  type: 'counter' | 'gauge' | 'histogram' | 'gaugehistogram' | 'summary' | 'info' | 'stateset' | 'unknown';
+  help?: string;
+  unit?: string;
+  // Set when the type was guessed from the metric name
+  inferred: boolean;
+  labels: string[];
+  suggestions: QuerySuggestion[];
+}
+
+// Resource utilization summary of a node, values are null when not reported
+export interface ServerResources {
+  nodeId: string;
+  time: string;
+  cpuPercent: number | null;
+  memoryBytes: number | null;
+  diskBytes: number | null;
+  openFds: number | null;
+  maxFds: number | null;
+  goroutines: number | null;
+}
+
+// Scrape state of a cluster metrics are collected from, durations are in nanoseconds
+export interface ScrapeTarget {
+  cluster: string;
+  health: 'up' | 'down' | 'unknown';
+  lastScrape?: string;
+  lastError?: string;
+  consecutiveFailures: number;
+  backoff: number;
+  nextScrape?: string;
+  blocked?: BlockedMetric[];
+}
+
+// A metric not stored until `until`, because a scrape exceeded the series or label limits
+export interface BlockedMetric {
+  cluster: string;
+  metric: string;
+  reason: string;
+  labels: string[];
+  since: string;
+  until: string;
+}
+
+// Topology history, one snapshot per change of members or table leaders
+export interface TopologyMember {
+  id: string;
+  name: string;
+}
+
+export interface TableLeadership {
+  leader: string;
+  term: number;
+}
+
+export interface TopologySnapshot {
+  since: string;
+  lastSeen: string;
+  members: TopologyMember[];
+  tables: Record<string, TableLeadership>;
+  leaderChanges?: Record<string, number>;
+}
+
+// Hot key analysis of the key-value requests made through the console
+export interface HotKeyPrefix {
+  table: string;
+  prefix: string;
+  samples: number;
+  estimated: number;
+  ops: Partial<Record<'read' | 'scan' | 'write' | 'delete', number>>;
+  share: number;
+  skewFactor: number;
+  hot: boolean;
+}
+
+export interface HotKeysReport {
+  window: number;
+  sampleRate: number;
+  samples: number;
+  overflow: number;
+  skewed: boolean;
+  prefixes: HotKeyPrefix[];
+}
+
+// Scheduled maintenance of a cluster or of some of its tables
+export interface MaintenanceWindow {
+  id: string;
+  title: string;
+  description?: string;
+  start: string;
+  end: string;
+  cluster?: string;
+  tables?: string[];
+  silence?: string[];
+  freezeWrites: boolean;
+  createdBy?: string;
+  createdAt: string;
+}
+
+// Authentication types
+export interface Me {
+  name: string;
+  // Readable name to show instead of the name, e.g. the preferred username of an OIDC login
+  displayName?: string;
+  method?: 'basic' | 'oidc' | 'bearer';
+  groups?: string[];
+  authenticated: boolean;
+  role?: 'viewer' | 'operator';
+  // Set when the console refuses all changes, whatever the role
+  readOnly?: boolean;
+}
+
+export interface LogoutResponse {
+  logoutUrl?: string;
+}
+
+// Branding types
+export interface FooterLink {
+  label: string;
+  url: string;
+}
+
+export interface Branding {
+  title: string;
+  logoUrl?: string;
+  colors: Partial<Record<'primary' | 'success' | 'error' | 'warning' | 'info', string>>;
+  footerLinks: FooterLink[];
+}
+
+// Usage analytics types
+export interface AnalyticsStatus {
+  enabled: boolean;
+}
+
+export interface UsageCount {
+  name: string;
+  count: number;
+}
+
+export interface UsageDay {
+  date: string;
+  pageViews: number;
+  queries: number;
+}
+
+export interface UsageReport {
+  from: string;
+  to: string;
+  pageViews: UsageCount[];
+  queries: UsageCount[];
+  days: UsageDay[];
+}
+
+// Cluster registry types
+export interface ClusterDefaults {
+  table?: string;
+  keyPrefixes?: string[];
+}
+
+export interface Cluster {
+  name: string;
+  seeds: string[];
+  defaults: ClusterDefaults;
+}
+
+export interface ClustersResponse {
+  clusters: Cluster[];
+  // The cluster serving requests without the cluster parameter
+  default?: string;
+}
+
+// Fleet view, one row per registered cluster assembled from cached samples
+export interface FleetScrape {
+  up: number;
+  down: number;
+  unknown: number;
+  lastScrape?: string;
+}
+
+export interface FleetCluster {
+  name: string;
+  health: 'healthy' | 'degraded' | 'unreachable' | 'unknown';
+  nodes: number;
+  nodesUp: number;
+  minVersion?: string;
+  maxVersion?: string;
+  dbSize: number;
+  tables: number;
+  firingAlerts: number;
+  scrape: FleetScrape;
+  sampledAt?: string;
+}
+
+export interface FleetResponse {
+  generatedAt: string;
+  clusters: FleetCluster[];
+}
 
 // Splash screen types
 declare global {
@@ -91,3 +365,4 @@ declare global {
     hideSplashScreen?: () => void;
   }
 }
+
@@ -0,0 +1,11 @@
+/**
+ * The URL prefix the console is served under, e.g. when a reverse proxy mounts it at
+ * https://ops.example.com/armada/
+ */
+
+// The backend injects a base element with the prefix into index.html, the development server
+// serves the console at the root without one
+const baseHref = document.querySelector('base')?.getAttribute('href') ?? '/';
+
+// BASE_PATH is the prefix without a trailing slash, the empty string at the root
+export const BASE_PATH = baseHref.replace(/\/+$/, '');
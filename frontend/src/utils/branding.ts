@@ -0,0 +1,54 @@
+/**
+ * Applies the branding configured on the backend to the page
+ */
+
+import { Branding } from '../types';
+
+// Shades of a palette color as the fraction of white (positive) or black (negative) mixed into
+// the configured color, which becomes shade 600, the one used for buttons and links
+const shadeMix: Record<number, number> = {
+  50: 0.9,
+  100: 0.75,
+  200: 0.6,
+  300: 0.45,
+  400: 0.3,
+  500: 0.15,
+  600: 0,
+  700: -0.15,
+  800: -0.3,
+  900: -0.45,
+};
+
+const parseHex = (color: string): [number, number, number] => [
+  parseInt(color.slice(1, 3), 16),
+  parseInt(color.slice(3, 5), 16),
+  parseInt(color.slice(5, 7), 16),
+];
+
+/**
+ * Compute the shades of a palette color as the RGB channels used by the Tailwind CSS variables
+ */
+export const paletteShades = (color: string): Record<number, string> => {
+  const rgb = parseHex(color);
+  return Object.fromEntries(
+    Object.entries(shadeMix).map(([shade, mix]) => {
+      const target = mix > 0 ? 255 : 0;
+      const channels = rgb.map((c) => Math.round(c + (target - c) * Math.abs(mix)));
+      return [Number(shade), channels.join(' ')];
+    }),
+  );
+};
+
+/**
+ * Set the document title and override the palette colors of the branding
+ */
+export const applyBranding = (branding: Branding) => {
+  document.title = branding.title;
+  const style = document.documentElement.style;
+  Object.entries(branding.colors).forEach(([name, color]) => {
+    if (!color) return;
+    Object.entries(paletteShades(color)).forEach(([shade, channels]) => {
+      style.setProperty(`--color-${name}-${shade}`, channels);
+    });
+  });
+};
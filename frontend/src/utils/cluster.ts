@@ -0,0 +1,27 @@
+/**
+ * The cluster API requests are served by when the console serves several clusters. The choice is
+ * remembered across visits; the empty string selects the default cluster.
+ */
+
+const storageKey = 'cluster';
+
+export const getSelectedCluster = (): string => localStorage.getItem(storageKey) ?? '';
+
+export const setSelectedCluster = (name: string) => {
+  if (name) {
+    localStorage.setItem(storageKey, name);
+  } else {
+    localStorage.removeItem(storageKey);
+  }
+};
+
+// withCluster adds the cluster parameter selecting the cluster to an API URL
+export const withCluster = (url: string): string => {
+  const cluster = getSelectedCluster();
+  if (!cluster) {
+    return url;
+  }
+  const withParam = new URL(url, window.location.origin);
+  withParam.searchParams.set('cluster', cluster);
+  return withParam.toString();
+};
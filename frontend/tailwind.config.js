@@ -1,71 +1,21 @@
+const brandColors = ['primary', 'success', 'error', 'warning', 'info'];
+
+const shades = (name) =>
+  Object.fromEntries(
+    [50, 100, 200, 300, 400, 500, 600, 700, 800, 900].map((shade) => [
+      shade,
+      `rgb(var(--color-${name}-${shade}) / <alpha-value>)`,
+    ]),
+  );
+
 /** @type {import('tailwindcss').Config} */
 export default {
   content: ['./index.html', './src/**/*.{js,ts,jsx,tsx}'],
   darkMode: 'class',
   theme: {
     extend: {
-      colors: {
-        primary: {
-          50: '#e3f2fd',
-          100: '#bbdefb',
-          200: '#90caf9',
-          300: '#64b5f6',
-          400: '#42a5f5',
-          500: '#2196f3',
-          600: '#1e88e5',
-          700: '#1976d2',
-          800: '#1565c0',
-          900: '#0d47a1',
-        },
-        success: {
-          50: '#e8f5e8',
-          100: '#c8e6c9',
-          200: '#a5d6a7',
-          300: '#81c784',
-          400: '#66bb6a',
-          500: '#4caf50',
-          600: '#43a047',
-          700: '#388e3c',
-          800: '#2e7d32',
-          900: '#1b5e20',
-        },
-        error: {
-          50: '#ffebee',
-          100: '#ffcdd2',
-          200: '#ef9a9a',
-          300: '#e57373',
-          400: '#ef5350',
-          500: '#f44336',
-          600: '#e53935',
-          700: '#d32f2f',
-          800: '#c62828',
-          900: '#b71c1c',
-        },
-        warning: {
-          50: '#fff3e0',
-          100: '#ffe0b2',
-          200: '#ffcc80',
-          300: '#ffb74d',
-          400: '#ffa726',
-          500: '#ff9800',
-          600: '#fb8c00',
-          700: '#f57c00',
-          800: '#ef6c00',
-          900: '#e65100',
-        },
-        info: {
-          50: '#e1f5fe',
-          100: '#b3e5fc',
-          200: '#81d4fa',
-          300: '#4fc3f7',
-          400: '#29b6f6',
-          500: '#03a9f4',
-          600: '#039be5',
-          700: '#0288d1',
-          800: '#0277bd',
-          900: '#01579b',
-        },
-      },
+      // The palette is defined by CSS variables in index.css, so the console can be rebranded at runtime
+      colors: Object.fromEntries(brandColors.map((name) => [name, shades(name)])),
       fontFamily: {
         sans: ['Roboto', 'Helvetica', 'Arial', 'sans-serif'],
       },
@@ -5,6 +5,9 @@ import { defineConfig } from 'vite';
 
 // https://vitejs.dev/config/
 export default defineConfig({
+  // Assets are referenced relative to the base element the backend injects, so the console can be
+  // served under any base path
+  base: './',
   plugins: [react()],
   resolve: {
     alias: {
@@ -1,33 +1,85 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/tls"
 	"errors"
+	"flag"
 	"fmt"
+	"html"
 	"io/fs"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"syscall"
 	"time"
 
+	"github.com/armadakv/console/backend/accounts"
+	"github.com/armadakv/console/backend/analytics"
 	"github.com/armadakv/console/backend/api"
+	"github.com/armadakv/console/backend/apiversion"
 	"github.com/armadakv/console/backend/armada"
+	"github.com/armadakv/console/backend/audit"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/basepath"
+	"github.com/armadakv/console/backend/cluster"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/conventions"
+	"github.com/armadakv/console/backend/discovery"
+	"github.com/armadakv/console/backend/embed"
+	"github.com/armadakv/console/backend/events"
+	"github.com/armadakv/console/backend/growth"
+	"github.com/armadakv/console/backend/hotkeys"
+	"github.com/armadakv/console/backend/httpclient"
+	"github.com/armadakv/console/backend/indexes"
+	"github.com/armadakv/console/backend/locks"
+	"github.com/armadakv/console/backend/maintenance"
+	"github.com/armadakv/console/backend/metadata"
 	"github.com/armadakv/console/backend/metrics"
+	"github.com/armadakv/console/backend/panels"
+	"github.com/armadakv/console/backend/panics"
+	"github.com/armadakv/console/backend/polling"
+	"github.com/armadakv/console/backend/reload"
+	"github.com/armadakv/console/backend/render"
+	"github.com/armadakv/console/backend/replay"
+	"github.com/armadakv/console/backend/retention"
+	"github.com/armadakv/console/backend/rpc"
+	"github.com/armadakv/console/backend/runbooks"
+	"github.com/armadakv/console/backend/secrets"
+	"github.com/armadakv/console/backend/shadow"
+	"github.com/armadakv/console/backend/snapshots"
+	"github.com/armadakv/console/backend/stats"
+	"github.com/armadakv/console/backend/topology"
+	"github.com/armadakv/console/backend/tracing"
 	"github.com/armadakv/console/frontend"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
-	defaultPort      = "8080"
-	staticDir        = "dist"
-	defaultArmadaURL = "http://localhost:5001"
+	staticDir = "dist"
 )
 
+// refreshLoadThreshold is the number of concurrent requests that adds another
+// scrape interval to the polling interval suggested to clients
+const refreshLoadThreshold = 32
+
+// streamingRoutes are served with the long stream timeout instead of the server-wide read and write timeouts
+var streamingRoutes = []string{
+	"/api/metrics/query_range",
+}
+
 type zapAdapter struct {
 	logger *zap.Logger
 }
@@ -36,29 +88,159 @@ func (z zapAdapter) Print(v ...interface{}) {
 	z.logger.Info(fmt.Sprint(v...))
 }
 
+// @title Armada Console API
+// @version v1
+// @description REST API of the Armada console. The routes are served under /api/v1 and the unversioned /api alias of v1.
+// @BasePath /
 func main() {
-	// Initialize zap logger
-	logger, err := zap.NewDevelopment()
+	// The dump command captures the state of a running console into a snapshot bundle
+	if len(os.Args) > 1 && os.Args[1] == "dump" {
+		os.Exit(runDump(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "hash-password" {
+		os.Exit(runHashPassword(os.Args[2:]))
+	}
+	snapshotFile := flag.String("snapshot", "", "serve the console read-only from a snapshot bundle created with the dump command")
+	configFile := flag.String("config", "", "path of the YAML configuration file, overrides $CONFIG_FILE")
+	flags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Initialize zap logger, the level is adjusted once the configuration is loaded
+	logLevel := zap.NewAtomicLevel()
+	zapConfig := zap.NewDevelopmentConfig()
+	zapConfig.Level = logLevel
+	logger, err := zapConfig.Build()
 	if err != nil {
 		fmt.Printf("Failed to create logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync() // flushes buffer, if any
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	path := *configFile
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	cfg, err := config.Load(path, os.LookupEnv)
+	if err != nil {
+		logger.Fatal("Failed to load configuration", zap.Error(err))
+	}
+	if err := flags.Apply(cfg); err != nil {
+		logger.Fatal("Failed to apply command-line flags", zap.Error(err))
+	}
+	for _, warning := range cfg.Warnings() {
+		logger.Warn("Configuration warning", zap.String("warning", warning))
+	}
+	// References to secrets are resolved before validation, so the secrets are validated as well.
+	// The resolver is kept for reloads, so its cache spares the stores.
+	resolver := newSecretResolver(cfg.Secrets)
+	if err := cfg.ResolveSecrets(context.Background(), resolver); err != nil {
+		logger.Fatal("Failed to resolve secrets", zap.Error(err))
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.Fatal("Invalid configuration", zap.Error(err))
+	}
+	if err := logLevel.UnmarshalText([]byte(cfg.Log.Level)); err != nil {
+		logger.Fatal("Invalid log level", zap.Error(err))
+	}
+
+	// Outbound integrations share the headers, proxy and User-Agent egress gateways expect
+	outbound, err := httpclient.NewFactory(httpclient.Options{
+		Headers:   cfg.Outbound.HTTPHeaders(),
+		Proxy:     cfg.Outbound.Proxy,
+		UserAgent: cfg.Outbound.UserAgent,
+		Timeout:   cfg.Outbound.Timeout,
+	})
+	if err != nil {
+		logger.Fatal("Invalid outbound configuration", zap.Error(err))
 	}
 
-	armadaURL := os.Getenv("ARMADA_URL")
-	if armadaURL == "" {
-		armadaURL = defaultArmadaURL
+	render.Configure(render.Options{
+		MaxBuffer:      cfg.Server.MaxResponseBuffer,
+		FlushThreshold: cfg.Server.ResponseFlushThreshold,
+	})
+
+	frontendHandler := newFrontendHandler(logger, cfg.Server)
+
+	// Panics are always logged and optionally shipped to Sentry
+	var panicReporter panics.Reporter = panics.NewLogReporter(logger.Named("panics"))
+	if cfg.Reporting.SentryDSN != "" {
+		sentryReporter, err := panics.NewSentryReporter(panics.SentryOptions{
+			DSN:           cfg.Reporting.SentryDSN,
+			Environment:   cfg.Reporting.Environment,
+			Release:       cfg.Reporting.Release,
+			HTTPTransport: outbound.Transport(),
+		})
+		if err != nil {
+			logger.Fatal("Failed to set up Sentry reporting", zap.Error(err))
+		}
+		defer sentryReporter.Flush(5 * time.Second)
+		panicReporter = panics.MultiReporter{panicReporter, sentryReporter}
 	}
 
-	// Get the frontend filesystem
-	frontendRoot, err := fs.Sub(frontend.FS, staticDir)
+	// Requests and the gRPC calls they make are traced when an exporter is configured
+	if cfg.Tracing.Exporter != tracing.ExporterNone {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+			Exporter:    cfg.Tracing.Exporter,
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			ServiceName: cfg.Tracing.ServiceName,
+			Version:     cfg.Reporting.Release,
+			Headers:     outbound.Headers(),
+			UserAgent:   outbound.UserAgent(),
+			Proxy:       outbound.Proxy(),
+		})
+		if err != nil {
+			logger.Fatal("Failed to set up tracing", zap.Error(err))
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Warn("Failed to flush traces", zap.Error(err))
+			}
+		}()
+	}
+
+	if *snapshotFile != "" {
+		serveSnapshot(logger, cfg, outbound, *snapshotFile, frontendHandler, panicReporter)
+		return
+	}
+
+	discoverer, err := newDiscoverer(cfg.Discovery, outbound)
 	if err != nil {
-		logger.Fatal("Failed to get frontend filesystem", zap.Error(err))
+		logger.Fatal("Invalid discovery configuration", zap.Error(err))
+	}
+
+	armadaURL := cfg.Armada.URL
+	seeds := []string{armadaURL}
+	if discoverer != nil && cfg.Source("armada.url") == config.SourceDefault {
+		// Use the first discovered seed as the primary server address
+		seeds, err = discoverer.Discover(context.Background())
+		if err != nil {
+			logger.Fatal("Failed to discover Armada seed addresses", zap.Error(err))
+		}
+		armadaURL = seeds[0]
+	}
+
+	registry := cluster.NewRegistry()
+	err = registry.Register(cluster.Cluster{
+		Name:  cfg.Armada.ClusterName,
+		Seeds: seeds,
+		Defaults: cluster.Defaults{
+			Table:       cfg.Armada.DefaultTable,
+			KeyPrefixes: cfg.Armada.DefaultKeyPrefixes,
+		},
+		Tags: cfg.Armada.DefaultTags(),
+	})
+	if err != nil {
+		logger.Fatal("Failed to register Armada cluster", zap.Error(err))
+	}
+	named := cfg.Armada.NamedClusters()
+	for _, c := range named {
+		if err := registry.Register(cluster.Cluster{Name: c.Name, Seeds: []string{c.URL}, Tags: c.Tags}); err != nil {
+			logger.Fatal("Failed to register Armada cluster", zap.Error(err), zap.String("cluster", c.Name))
+		}
 	}
 
 	// Create a new Chi router
@@ -73,61 +255,792 @@ func main() {
 	middleware.DefaultLogger = middleware.RequestLogger(&middleware.DefaultLogFormatter{
 		Logger: &zapAdapter{logger: logger}, NoColor: true},
 	)
+	// Tracing comes first, so the span covers the time spent in the other middlewares
+	r.Use(tracing.Middleware)
+	// The API is served under /api/v1 and the unversioned /api alias, the other middlewares see unversioned paths
+	r.Use(apiversion.Middleware)
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
-	// Recoverer middleware recovers from panics, logs the panic, and returns a 500 Internal Server Error response
-	r.Use(middleware.Recoverer)
+	// Requests from trusted proxies are attributed to the client they were forwarded for
+	trustedProxies, err := auth.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+	r.Use(auth.ClientAddresses(trustedProxies))
+	// Recoverer middleware recovers from panics, reports the panic, and returns a 500 Internal Server Error response
+	r.Use(panics.Recoverer(panicReporter))
+	// Streaming endpoints get the long timeout class instead of the server-wide timeouts
+	r.Use(api.LongRunning(cfg.Server.StreamTimeout, logger, streamingRoutes...))
+	// The number of requests in flight feeds the polling interval suggested to clients
+	refreshAdvisor := polling.NewAdvisor(cfg.Metrics.ScrapeInterval, cfg.Metrics.MaxRefreshInterval, refreshLoadThreshold)
+	r.Use(refreshAdvisor.Track)
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", apiversion.Header, api.TruncatedHeader, api.CursorHeader, api.EstimatedKeysHeader, api.EstimatedBytesHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
+	// Authentication covers the API and the frontend; CORS preflight requests are answered before it
+	// Widgets are authorized by their signed URLs, so they can be embedded by sites without a login
+	// Probes of the orchestrator don't carry credentials
+	public := []string{api.LivenessPath, api.ReadinessPath}
+	if cfg.Embed.Secret != "" {
+		public = append(public, embed.PathPrefix)
+	}
+
+	metadataStore, err := openMetadataStore(cfg.Metadata)
+	if err != nil {
+		logger.Fatal("Failed to open metadata store", zap.Error(err))
+	}
 
-	client, err := armada.NewClient(armadaURL, logger.Named("client"))
+	// Clusters imported through the API are connected like the configured ones, which win if the names clash.
+	// Only the import admins may import them, their tokens are limited to the allowed references.
+	importAdmins, err := auth.ParsePrincipals(cfg.Auth.ClusterImportAdmins)
+	if err != nil {
+		logger.Fatal("Invalid cluster import admins", zap.Error(err))
+	}
+	importPolicy := api.ClusterImportPolicy{Admins: importAdmins, Tokens: cfg.Secrets.ImportTokens}
+	importedClusters := cluster.NewStore(metadataStore)
+	imported, err := importedClusters.List()
+	if err != nil {
+		logger.Fatal("Failed to load imported clusters", zap.Error(err))
+	}
+	clusterTokens := make(map[string]credentials.PerRPCCredentials)
+	for _, d := range imported {
+		// Clusters imported before the references were limited, or with a changed limit, are not connected
+		if d.Token != "" && (!importPolicy.AllowsToken(d.Token) || d.TLS.InsecureSkipVerify) {
+			logger.Warn("Skipping imported cluster, its token is not an allowed reference or would be sent unverified",
+				zap.String("cluster", d.Name))
+			continue
+		}
+		if err := registry.Register(d.Cluster()); err != nil {
+			logger.Warn("Skipping imported cluster", zap.Error(err), zap.String("cluster", d.Name))
+			continue
+		}
+		named = append(named, config.NamedCluster{
+			Name:                  d.Name,
+			URL:                   d.Seeds[0],
+			TLSCAFile:             cmp.Or(d.TLS.CAFile, cfg.Armada.TLSCAFile),
+			TLSInsecureSkipVerify: d.TLS.InsecureSkipVerify || cfg.Armada.TLSInsecureSkipVerify,
+		})
+		if d.Token != "" {
+			// The token is resolved through the cache of the resolver, so rotated tokens are picked up
+			clusterTokens[d.Name] = armada.TokenFunc(func() (string, error) {
+				return resolver.Resolve(context.Background(), d.Token)
+			})
+		}
+	}
+
+	// Topology changes, audited operations and blocked metrics are published to the RPC clients
+	hub := events.NewHub()
+
+	// Every entry is chained to its predecessor, so tampering with the log can be detected
+	var auditOptions []audit.Option
+	if cfg.Audit.SigningKey != "" {
+		auditOptions = append(auditOptions, audit.WithSigningKey([]byte(cfg.Audit.SigningKey)))
+	}
+	var auditLog audit.Log = audit.NewMemoryLog(1000, auditOptions...)
+	var fileLog *audit.FileLog
+	if cfg.Metadata.Dir != "" {
+		fileLog, err = audit.OpenFileLog(filepath.Join(cfg.Metadata.Dir, "audit.jsonl"), auditOptions...)
+		if err != nil {
+			logger.Fatal("Failed to open audit log", zap.Error(err))
+		}
+		defer fileLog.Close()
+		auditLog = fileLog
+	}
+	auditLog = audit.Notify(auditLog, func(e audit.Entry) {
+		// State snapshots can be large, clients fetch them from the audit log when needed
+		e.Snapshot = nil
+		hub.Publish(events.Event{Type: events.TypeAudit, Time: e.Time, Data: e})
+	})
+
+	// Armada servers requiring mutual TLS are presented the client certificate, every cluster may have its own CA
+	armadaTLS := map[string]*tls.Config{
+		cfg.Armada.ClusterName: loadArmadaTLS(logger, cfg.Armada, cfg.Armada.ClusterName, cfg.Armada.TLSCAFile, cfg.Armada.TLSInsecureSkipVerify),
+	}
+	for _, c := range named {
+		armadaTLS[c.Name] = loadArmadaTLS(logger, cfg.Armada, c.Name, c.TLSCAFile, c.TLSInsecureSkipVerify)
+	}
+	// Clusters requiring authentication are sent the token with every call
+	var armadaToken credentials.PerRPCCredentials
+	switch {
+	case cfg.Armada.TokenFile != "":
+		armadaToken = armada.FileToken(cfg.Armada.TokenFile)
+	case cfg.Armada.Token != "":
+		armadaToken = armada.StaticToken(cfg.Armada.Token)
+	}
+	armadaOptions := func(cluster string) []armada.ClientOption {
+		grpcLogger := logger.Named("grpc").With(zap.String("cluster", cluster))
+		token := armadaToken
+		if clusterToken, ok := clusterTokens[cluster]; ok {
+			token = clusterToken
+		}
+		return []armada.ClientOption{
+			armada.WithTLSConfig(armadaTLS[cluster]),
+			armada.WithPerRPCCredentials(token),
+			armada.WithHealthCheck(cfg.Armada.HealthCheckInterval),
+			armada.WithRediscovery(cfg.Armada.MemberDiscoveryInterval),
+			armada.WithReconnectPolicy(cfg.Armada.ReconnectMaxRetries, cfg.Armada.ReconnectBaseDelay,
+				cfg.Armada.ReconnectMaxDelay, cfg.Armada.ReconnectJitter),
+			armada.WithKeepalive(cfg.Armada.KeepaliveTime, cfg.Armada.KeepaliveTimeout, cfg.Armada.KeepalivePermitWithoutStream),
+			armada.WithMaxMessageSizes(cfg.Armada.MaxRecvMsgSize, cfg.Armada.MaxSendMsgSize),
+			armada.WithConnectTimeout(cfg.Armada.ConnectTimeout),
+			armada.WithCircuitBreaker(cfg.Armada.BreakerFailures, cfg.Armada.BreakerCooldown),
+			// Calls carry the ID of the request causing them, so the logs of the servers can be matched with ours
+			armada.WithInterceptors(
+				armada.RequestIDInterceptor(middleware.GetReqID),
+				armada.LoggingInterceptor(grpcLogger, cfg.Armada.SlowCallThreshold),
+				// Brief leader elections are retried rather than failing requests, each client has its own budget
+				armada.RetryInterceptor(armada.RetryPolicy{
+					MaxAttempts: cfg.Armada.RetryMaxAttempts,
+					BaseDelay:   cfg.Armada.RetryBaseDelay,
+					MaxDelay:    cfg.Armada.RetryMaxDelay,
+					Budget:      cfg.Armada.RetryBudget,
+				}, grpcLogger)),
+		}
+	}
+	// Members leaving the default cluster are published like its topology changes
+	clientOptions := append(armadaOptions(cfg.Armada.ClusterName), armada.WithPublisher(hub))
+	// Reads of the default cluster are compared with a second replica when diagnosing inconsistency
+	var readVerifier *armada.ReadVerifier
+	if cfg.Armada.VerifyReads {
+		readVerifier = armada.NewReadVerifier(cfg.Armada.VerifyReadsSampleRate, logger)
+		clientOptions = append(clientOptions, armada.WithReadVerifier(readVerifier))
+		logger.Warn("Verifying reads against a second replica, this adds load to the cluster",
+			zap.Float64("sampleRate", cfg.Armada.VerifyReadsSampleRate))
+	}
+	client, err := armada.NewClient(armadaURL, logger.Named("client"), clientOptions...)
 	if err != nil {
 		logger.Fatal("Failed to create Armada client", zap.Error(err))
 	}
 
-	mm, err := metrics.NewMetricsManager(client.GetConnectionPool(), 30*time.Second, "/tmp/tsdb", logger)
+	// Users created in the console log in like the configured user, who can't be shadowed by them,
+	// nor can local users granted privileges by name
+	var directory *accounts.Directory
+	if cfg.Auth.LocalUsers {
+		directory = accounts.NewDirectory(metadataStore, reservedNames(cfg.Auth)...)
+	}
+	// Failed logins lock out the account or the client address for a while, lockouts are audited
+	guard := auth.NewGuard(cfg.Auth.LoginMaxFailures, cfg.Auth.LoginMaxFailuresPerAddress, cfg.Auth.LoginLockout,
+		cfg.Auth.LoginMaxLockout, auth.WithLockoutHook(func(l auth.Lockout) {
+			auditLockout(logger, auditLog, l)
+		}))
+	// Sessions are kept in the metadata store, so restarts don't log users out
+	sessions := newSessionStore(cfg, metadataStore)
+	apiKeys := newAPIKeyManager(logger, cfg.Auth, client, metadataStore)
+	oidc := useAuthentication(logger, r, cfg.Auth, outbound, directory, guard, sessions, apiKeys, public...)
+
+	cert := loadCertificate(logger, cfg)
+
+	// Further clusters are connected with their own clients, each keeping its own connection pool
+	clients := make(map[string]*armada.Client, len(named))
+	// addresses tells the cluster of a scraped server, to label its series with the tags of the cluster
+	addresses := map[string]func() []string{cfg.Armada.ClusterName: client.GetConnectionPool().GetKnownAddresses}
+	var pool metrics.ClusterPool = client.GetConnectionPool()
+	if len(named) > 0 {
+		pools := metrics.Pools{client.GetConnectionPool()}
+		for _, c := range named {
+			clusterClient, err := armada.NewClient(c.URL, logger.Named("client").With(zap.String("cluster", c.Name)),
+				armadaOptions(c.Name)...)
+			if err != nil {
+				logger.Fatal("Failed to create Armada client", zap.Error(err), zap.String("cluster", c.Name))
+			}
+			defer clusterClient.Close()
+			clients[c.Name] = clusterClient
+			addresses[c.Name] = clusterClient.GetConnectionPool().GetKnownAddresses
+			pools = append(pools, clusterClient.GetConnectionPool())
+		}
+		// The metrics of all clusters are stored together, told apart by the server address
+		pool = pools
+	}
+
+	var refresher *discovery.Refresher
+	if discoverer != nil {
+		refresher = discovery.NewRefresher(discoverer, client.GetConnectionPool(), cfg.Discovery.Interval, logger,
+			discovery.WithSeedRegistry(registry, cfg.Armada.ClusterName))
+		refresher.Start(context.Background())
+		defer refresher.Stop()
+	}
+
+	mm, err := metrics.NewMetricsManager(pool, cfg.Metrics.ScrapeInterval, cfg.Metrics.StorageDir, logger,
+		metrics.WithRetention(cfg.Metrics.Retention),
+		metrics.WithBlockDuration(cfg.Metrics.BlockDuration),
+		metrics.WithMaxBackoff(cfg.Metrics.MaxScrapeBackoff),
+		metrics.WithDeduplication(cfg.Metrics.DedupWindow, cfg.Metrics.DedupMaxSeries),
+		metrics.WithCardinalityLimits(cfg.Metrics.MaxSeriesPerMetric, cfg.Metrics.MaxLabels, cfg.Metrics.CardinalityCooldown),
+		metrics.WithTargetLabels(tagLabels(registry, addresses)),
+		metrics.WithPublisher(hub))
 	if err != nil {
 		logger.Fatal("Failed to create metrics manager", zap.Error(err))
 	}
 	mm.Start(context.Background())
 	defer mm.Stop()
 
+	// Every table statistics sample also records the cluster topology
+	topologyHistory := topology.NewHistory(metadataStore, cfg.Metadata.TopologyRetention, logger,
+		topology.WithMetricSink(mm),
+		topology.WithPublisher(hub))
+	sampler := stats.NewSampler(client, cfg.Metrics.TableStatsInterval, logger, stats.WithObserver(topologyHistory))
+	sampler.Start(context.Background())
+	defer sampler.Stop()
+	// Key counts are recorded next to the sampled sizes for the growth of the default cluster's tables
+	growthRecorder := growth.NewRecorder(client, sampler, mm, cfg.Growth.Interval, logger,
+		growth.WithPrefixes(cfg.Growth.PrefixSeparator, cfg.Growth.MaxPrefixes))
+	growthRecorder.Start(context.Background())
+	defer growthRecorder.Stop()
+	samplers := make(map[string]*stats.Sampler, len(named))
+	for _, c := range named {
+		samplers[c.Name] = stats.NewSampler(clients[c.Name], cfg.Metrics.TableStatsInterval, logger.With(zap.String("cluster", c.Name)))
+		samplers[c.Name].Start(context.Background())
+		defer samplers[c.Name].Stop()
+	}
+
+	// Viewers are limited to reading, denied writes are audited
+	roles, err := roleMapping(cfg.Auth, directory, apiKeys)
+	if err != nil {
+		logger.Fatal("Invalid operators", zap.Error(err))
+	}
+	r.Use(api.Authorize(roles, auditLog, logger.Named("rbac"), api.PageViewPath, api.AuditVerifyPath, metrics.BatchQueryPath))
+	// In read-only mode nobody may change anything, operators included
+	if cfg.Server.ReadOnly {
+		logger.Info("Serving read-only, all changes are refused")
+		r.Use(api.ReadOnly(logger.Named("read-only"), api.PageViewPath, api.AuditVerifyPath, metrics.BatchQueryPath))
+	}
+	// Clusters with a tag, e.g. env=prod, may be read-only or require approvals for some changes.
+	// The routes of the other clusters are registered below, guardrails check which cluster serves
+	// a request with them.
+	clusterRoutes := make(map[string]chi.Router, len(named))
+	policies, err := api.ParseGuardrails(cfg.Auth.Guardrails)
+	if err != nil {
+		logger.Fatal("Invalid guardrails", zap.Error(err))
+	}
+	guardrailAdmins, err := auth.ParsePrincipals(cfg.Auth.GuardrailAdmins)
+	if err != nil {
+		logger.Fatal("Invalid guardrail admins", zap.Error(err))
+	}
+	guardrails := api.Guardrails{
+		Policies:       policies,
+		Admins:         guardrailAdmins,
+		AdminGroups:    api.SplitList(cfg.Auth.GuardrailAdminGroups),
+		DefaultCluster: cfg.Armada.ClusterName,
+		ClusterRoutes:  clusterRoutes,
+		Tags: func(name string) cluster.Tags {
+			return clusterTags(registry, name)()
+		},
+		Approvals: api.NewApprovals(cfg.Auth.ApprovalTTL),
+	}
+	if len(policies) > 0 {
+		logger.Info("Enforcing guardrails on tagged clusters", zap.Int("policies", len(policies)))
+		r.Use(api.EnforceGuardrails(guardrails, auditLog, logger.Named("guardrails")))
+	}
+
+	// Usage is only counted if the operator opted in, and never leaves the metadata store
+	var usage *analytics.Recorder
+	if cfg.Analytics.Enabled {
+		usage = analytics.NewRecorder(metadataStore, cfg.Analytics.Retention, logger)
+		usage.Start(context.Background())
+		defer func() {
+			if err := usage.Flush(); err != nil {
+				logger.Warn("Failed to store usage analytics", zap.Error(err))
+			}
+		}()
+		r.Use(usage.Middleware)
+	}
+
+	// Recorded requests are kept in memory only, with credentials and secrets redacted
+	var requests *replay.Recorder
+	if cfg.Debug.RecordRequests {
+		requests = replay.NewRecorder(cfg.Debug.RecordLimit)
+		r.Use(requests.Middleware)
+	}
+
+	hotKeys := hotkeys.NewTracker(cfg.HotKeys.SampleRate, cfg.HotKeys.Window)
+	scheduler := maintenance.NewScheduler(metadataStore)
+
+	// Index tables are built on the default cluster only
+	var indexBuilder *indexes.Builder
+	if cfg.Armada.Indexes {
+		indexBuilder = indexes.NewBuilder(client, metadataStore, logger.Named("indexes"))
+		indexBuilder.Start(context.Background())
+		defer indexBuilder.Stop()
+	}
+
+	// Writes to the default cluster are mirrored to the shadow cluster, e.g. to rehearse a migration
+	var mirror *shadow.Mirror
+	if cfg.Shadow.Cluster != "" {
+		mirror = shadow.NewMirror(clients[cfg.Shadow.Cluster], cfg.Shadow.Cluster, cfg.Shadow.QueueSize, cfg.Shadow.MaxFailures, logger)
+		mirror.Start(context.Background())
+		defer mirror.Stop()
+		logger.Info("Mirroring writes to shadow cluster", zap.String("cluster", cfg.Shadow.Cluster))
+	}
+
+	// Records beyond their retention are removed in the background, the topology history and
+	// the usage analytics prune themselves whenever they are written
+	cleaner := retention.NewCleaner(cfg.Metadata.CleanupInterval, logger, retention.WithMetricSink(mm))
+	cleaner.Add("maintenance", cfg.Metadata.MaintenanceRetention, scheduler.Prune)
+	if fileLog != nil {
+		cleaner.Add("audit", cfg.Audit.Retention, func(cutoff time.Time) (int, int64, error) {
+			return fileLog.Prune(cutoff, cfg.Audit.MaxBytes)
+		})
+	}
+	cleaner.Start(context.Background())
+	defer cleaner.Stop()
+
+	routeTimeouts := api.RouteTimeouts{
+		Status: cfg.Armada.StatusTimeout,
+		Tables: cfg.Armada.TablesTimeout,
+		KV:     cfg.Armada.KVTimeout,
+	}
+
+	// Requests with the cluster parameter are served by the REST API of that cluster; hot keys and
+	// the topology history are only followed for the default cluster
+	for _, c := range named {
+		cr := chi.NewRouter()
+		api.NewHandler(clients[c.Name], logger.Named("api-handler").With(zap.String("cluster", c.Name)),
+			api.WithTableStats(samplers[c.Name]),
+			api.WithMetadataStore(metadataStore),
+			api.WithAuditLog(auditLog),
+			api.WithSnapshotSample(cfg.Audit.SnapshotSampleKeys),
+			api.WithRefreshAdvisor(refreshAdvisor),
+			api.WithMaintenance(scheduler, c.Name),
+			api.WithClusterTags(clusterTags(registry, c.Name)),
+			api.WithRangeTimeout(cfg.Armada.RangeTimeout),
+			api.WithRouteTimeouts(routeTimeouts)).RegisterRoutes(cr)
+		clusterRoutes[c.Name] = cr
+	}
+	r.Use(api.SelectCluster(cfg.Armada.ClusterName, clusterRoutes))
+
 	// Register API routes
-	apiHandler := api.NewHandler(client, logger.Named("api-handler"))
+	auth.NewHandler(oidc, sessions).RegisterRoutes(r)
+
+	apiHandler := api.NewHandler(client, logger.Named("api-handler"),
+		api.WithTableStats(sampler),
+		api.WithMetadataStore(metadataStore),
+		api.WithAuditLog(auditLog),
+		api.WithSnapshotSample(cfg.Audit.SnapshotSampleKeys),
+		api.WithRefreshAdvisor(refreshAdvisor),
+		api.WithHotKeys(hotKeys),
+		api.WithMaintenance(scheduler, cfg.Armada.ClusterName),
+		api.WithClusterTags(clusterTags(registry, cfg.Armada.ClusterName)),
+		api.WithIndexes(indexBuilder),
+		api.WithShadow(mirror),
+		api.WithGrowth(mm, cfg.Growth.SizeCap),
+		api.WithRangeTimeout(cfg.Armada.RangeTimeout),
+		api.WithRouteTimeouts(routeTimeouts))
 	apiHandler.RegisterRoutes(r)
 
-	metricsHandler := metrics.NewMetricsHandler(mm, logger.Named("metrics-handler"))
+	if indexBuilder != nil {
+		api.NewIndexHandler(indexBuilder, logger.Named("index-handler")).RegisterRoutes(r)
+	}
+	if mirror != nil {
+		api.NewShadowHandler(mirror, logger.Named("shadow-handler")).RegisterRoutes(r)
+	}
+	if readVerifier != nil {
+		api.NewReadVerificationHandler(readVerifier, logger.Named("verification-handler")).RegisterRoutes(r)
+	}
+	api.NewPoolHandler(client, auditLog, logger.Named("pool-handler")).RegisterRoutes(r)
+	if len(guardrails.Policies) > 0 {
+		api.NewApprovalHandler(guardrails, auditLog, logger.Named("approval-handler")).RegisterRoutes(r)
+	}
+
+	// Keys are decoded with the conventions of the default cluster's components, the locks and
+	// elections among them can be inspected and force released
+	conventionRegistry := conventions.NewRegistry(metadataStore)
+	conventionsHandler := api.NewConventionsHandler(conventionRegistry, client, logger.Named("conventions-handler"))
+	conventionsHandler.RegisterRoutes(r)
+	locksHandler := api.NewLocksHandler(locks.NewInspector(client, conventionRegistry), auditLog, logger.Named("locks-handler"))
+	locksHandler.RegisterRoutes(r)
+
+	maintenanceHandler := api.NewMaintenanceHandler(scheduler, logger.Named("maintenance-handler"))
+	maintenanceHandler.RegisterRoutes(r)
+
+	hotKeysHandler := api.NewHotKeysHandler(hotKeys, logger.Named("hotkeys-handler"))
+	hotKeysHandler.RegisterRoutes(r)
+
+	auditHandler := api.NewAuditHandler(auditLog, logger.Named("audit-handler"),
+		api.WithAuditSigningKey([]byte(cfg.Audit.SigningKey)))
+	auditHandler.RegisterRoutes(r)
+
+	// Saved panels give queries their unit and thresholds
+	panelStore := panels.NewStore(metadataStore)
+	api.NewPanelsHandler(panelStore, logger.Named("panels-handler")).RegisterRoutes(r)
+	runbookStore := runbooks.NewStore(metadataStore)
+	api.NewRunbooksHandler(runbookStore, logger.Named("runbooks-handler")).RegisterRoutes(r)
+	metricsHandler := metrics.NewMetricsHandler(mm, logger.Named("metrics-handler"),
+		metrics.WithRefreshAdvisor(refreshAdvisor),
+		metrics.WithQueryTimeout(cfg.Metrics.QueryTimeout),
+		metrics.WithPanels(panelStore))
 	metricsHandler.RegisterRoutes(r)
+	// Snapshots keep the results of dashboards for sharing, beyond the retention of the metrics
+	api.NewSnapshotsHandler(snapshots.NewStore(metadataStore), metricsHandler, logger.Named("snapshots-handler")).RegisterRoutes(r)
 
-	// Create a file server from the embedded filesystem
-	fileServer := http.FileServer(http.FS(frontendRoot))
+	alertEngine := metrics.NewQueryEngine(mm.GetStorage(), logger, metrics.WithEvaluationTimeout(cfg.Metrics.QueryTimeout))
+	diagnosticsHandler := api.NewDiagnosticsHandler(mm, cfg.Metrics.MaxClockSkew, logger.Named("diagnostics-handler"),
+		api.WithAlertRules(alertEngine, metrics.DefaultAlertRules),
+		api.WithSilencer(scheduler),
+		api.WithRunbooks(runbookStore))
+	diagnosticsHandler.RegisterRoutes(r)
+
+	// A cluster whose sample is three intervals old didn't answer the last two rounds
+	fleetOptions := []api.FleetOption{
+		api.WithFleetSource(cfg.Armada.ClusterName, api.FleetSource{
+			Stats:     sampler,
+			Targets:   mm,
+			Alerts:    alertEngine,
+			Addresses: client.GetConnectionPool().GetKnownAddresses,
+			Derived:   true,
+		}),
+		api.WithFleetAlertRules(metrics.DefaultAlertRules),
+	}
+	for _, c := range named {
+		fleetOptions = append(fleetOptions, api.WithFleetSource(c.Name, api.FleetSource{
+			Stats:     samplers[c.Name],
+			Targets:   mm,
+			Alerts:    alertEngine,
+			Addresses: clients[c.Name].GetConnectionPool().GetKnownAddresses,
+		}))
+	}
+	fleetHandler := api.NewFleetHandler(registry, 3*cfg.Metrics.TableStatsInterval, logger.Named("fleet-handler"), fleetOptions...)
+	fleetHandler.RegisterRoutes(r)
+
+	// SIGHUP and POST /api/admin/reload apply changed settings in place, so the TSDB head and other
+	// in-memory state survive; other changes are reported as requiring a restart
+	reloader := reload.NewReloader(cfg, func() (*config.Config, error) {
+		next, err := config.Load(path, os.LookupEnv)
+		if err != nil {
+			return nil, err
+		}
+		if err := flags.Apply(next); err != nil {
+			return nil, err
+		}
+		if err := next.ResolveSecrets(context.Background(), resolver); err != nil {
+			return nil, err
+		}
+		return next, next.Validate()
+	}, logger)
+	reloader.Handle("log", func(_ context.Context, next *config.Config) error {
+		return logLevel.UnmarshalText([]byte(next.Log.Level))
+	}, "log.level")
+	reloader.Handle("scrape", func(_ context.Context, next *config.Config) error {
+		mm.SetScrapeInterval(next.Metrics.ScrapeInterval)
+		refreshAdvisor.SetBaseInterval(next.Metrics.ScrapeInterval)
+		return nil
+	}, "metrics.scrapeInterval")
+	reloader.Handle("seeds", func(ctx context.Context, next *config.Config) error {
+		if refresher != nil && next.Source("armada.url") == config.SourceDefault {
+			// The seeds are discovered, look for new ones right away
+			refresher.Refresh(ctx)
+			return nil
+		}
+		if err := client.SetAddress(ctx, next.Armada.URL); err != nil {
+			return err
+		}
+		return registry.SetSeeds(cfg.Armada.ClusterName, []string{next.Armada.URL})
+	}, "armada.url")
+	if cert != nil {
+		// Renewed certificates usually replace the files under the same path
+		reloader.HandleAlways("tls", func(_ context.Context, next *config.Config) error {
+			if next.Server.TLSCertFile == "" {
+				return errors.New("disabling HTTPS requires a restart")
+			}
+			return cert.Load(next.Server.TLSCertFile, next.Server.TLSKeyFile)
+		}, "server.tlsCertFile", "server.tlsKeyFile")
+	}
+	reloader.Notify(context.Background(), syscall.SIGHUP)
+	if cfg.Secrets.RefreshInterval > 0 {
+		// Rotated secrets are picked up by reloading, the cache of the resolver bounds the requests
+		// to the stores
+		go func() {
+			ticker := time.NewTicker(cfg.Secrets.RefreshInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				// Failures are logged, the current configuration stays in effect
+				_, _ = reloader.Reload(context.Background())
+			}
+		}()
+	}
+
+	adminOptions := []api.AdminOption{api.WithReloader(reloader)}
+	if directory != nil {
+		adminOptions = append(adminOptions, api.WithUserDirectory(directory, auditLog), api.WithUserSessions(sessions))
+	}
+	if apiKeys != nil {
+		adminOptions = append(adminOptions, api.WithAPIKeys(apiKeys, auditLog))
+	}
+	adminHandler := api.NewAdminHandler(cfg, logger.Named("admin-handler"), adminOptions...)
+	adminHandler.RegisterRoutes(r)
+
+	clusterHandler := api.NewClusterHandler(registry, logger.Named("cluster-handler"),
+		api.WithDefaultCluster(cfg.Armada.ClusterName),
+		api.WithClusterImport(importedClusters, resolver, importPolicy, auditLog))
+	clusterHandler.RegisterRoutes(r)
+
+	historyHandler := api.NewTopologyHistoryHandler(topologyHistory, logger.Named("history-handler"))
+	historyHandler.RegisterRoutes(r)
+
+	analyticsHandler := api.NewAnalyticsHandler(usage, logger.Named("analytics-handler"))
+	analyticsHandler.RegisterRoutes(r)
+
+	if requests != nil {
+		replayHandler := replay.NewHandler(requests, replayTarget(registry, metadataStore, auditLog, scheduler, guardrails, cfg.Server.ReadOnly, armadaOptions, logger),
+			cfg.Armada.ClusterName, logger.Named("replay-handler"))
+		replayHandler.RegisterRoutes(r)
+	}
+
+	healthHandler := api.NewHealthHandler(logger.Named("health-handler"),
+		api.WithReadinessCheck("armada", func(context.Context) error {
+			if client.GetConnectionPool().HealthyConnections() == 0 {
+				return errors.New("no healthy connection to the Armada cluster")
+			}
+			return nil
+		}),
+		api.WithReadinessCheck("tsdb", func(context.Context) error {
+			return mm.CheckStorage()
+		}))
+	healthHandler.RegisterRoutes(r)
+
+	brandingHandler := api.NewBrandingHandler(cfg.Branding, logger.Named("branding-handler"))
+	brandingHandler.RegisterRoutes(r)
+
+	api.NewDocsHandler(logger.Named("docs-handler")).RegisterRoutes(r)
+
+	// The menu lists the pages of the features enabled above, the management pages for operators only
+	navigation := api.DefaultNavigation()
+	settings, ok := api.FindNavigationItem(navigation, "settings")
+	if !ok {
+		logger.Fatal("The default navigation has no settings item")
+	}
+	if directory != nil {
+		settings.Children = append(settings.Children, api.NavigationItem{ID: "users", Label: "Users", Path: "/settings/users", Icon: "users", Operator: true})
+	}
+	if apiKeys != nil {
+		settings.Children = append(settings.Children, api.NavigationItem{ID: "api-keys", Label: "API keys", Path: "/settings/api-keys", Icon: "key-round", Operator: true})
+	}
+	if usage != nil {
+		settings.Children = append(settings.Children, api.NavigationItem{ID: "analytics", Label: "Usage analytics", Path: "/settings/analytics", Icon: "chart-bar", Operator: true})
+	}
+	if requests != nil {
+		settings.Children = append(settings.Children, api.NavigationItem{ID: "replay", Label: "Request replay", Path: "/settings/replay", Icon: "history", Operator: true})
+	}
+	// Feature pages go before the settings, which stay last
+	features := []api.NavigationItem{
+		{ID: "snapshots", Label: "Snapshots", Path: "/snapshots", Icon: "camera"},
+		{ID: "maintenance", Label: "Maintenance", Path: "/maintenance", Icon: "calendar-clock"},
+		{ID: "audit", Label: "Audit log", Path: "/audit", Icon: "scroll-text"},
+	}
+	if indexBuilder != nil {
+		features = append(features, api.NavigationItem{ID: "indexes", Label: "Indexes", Path: "/indexes", Icon: "list-tree"})
+	}
+	if mirror != nil {
+		features = append(features, api.NavigationItem{ID: "shadow", Label: "Shadow cluster", Path: "/shadow", Icon: "copy"})
+	}
+	navigation = slices.Insert(navigation, len(navigation)-1, features...)
+	api.NewNavigationHandler(navigation, logger.Named("navigation-handler")).RegisterRoutes(r)
+
+	if cfg.Embed.Secret != "" {
+		embedHandler := embed.NewHandler(embed.NewSigner(cfg.Embed.Secret), logger.Named("embed-handler"),
+			embed.WithTTL(cfg.Embed.DefaultTTL, cfg.Embed.MaxTTL),
+			embed.WithFrameAncestors(cfg.Embed.FrameAncestors),
+			embed.WithCharts(metrics.NewQueryEngine(mm.GetStorage(), logger, metrics.WithEvaluationTimeout(cfg.Metrics.QueryTimeout))),
+			embed.WithHealth(client),
+			embed.WithTableStats(sampler))
+		embedHandler.RegisterRoutes(r)
+	}
+
+	// Queries over RPC are served by the router, so they pass through authentication like REST requests
+	rpcHandler := rpc.NewHandler(hub, r, logger.Named("rpc-handler"))
+	rpcHandler.RegisterRoutes(r)
 
 	// Serve frontend files and handle SPA routes
-	r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
+	if frontendHandler != nil {
+		r.Get("/*", frontendHandler.ServeHTTP)
+	}
+
+	serve(logger, cfg, r, armadaURL, cert)
+}
+
+// openMetadataStore opens the store for console-side metadata, kept in memory only if no directory is configured
+func openMetadataStore(cfg config.MetadataConfig) (metadata.Store, error) {
+	if cfg.Dir == "" {
+		return metadata.NewMemoryStore(), nil
+	}
+	return metadata.NewFileStore(cfg.Dir)
+}
+
+// loadArmadaTLS reads the TLS settings of a cluster, shutting down if the certificates can't be read
+func loadArmadaTLS(logger *zap.Logger, cfg config.ArmadaConfig, cluster, caFile string, insecureSkipVerify bool) *tls.Config {
+	if insecureSkipVerify {
+		logger.Warn("Certificates of the Armada servers are not verified", zap.String("cluster", cluster))
+	}
+	tlsConfig, err := armada.LoadTLSConfig(armada.TLSOptions{
+		CertFile:           cfg.TLSCertFile,
+		KeyFile:            cfg.TLSKeyFile,
+		CAPath:             caFile,
+		InsecureSkipVerify: insecureSkipVerify,
+	})
+	if err != nil {
+		logger.Fatal("Failed to load Armada TLS settings", zap.Error(err), zap.String("cluster", cluster))
+	}
+	return tlsConfig
+}
+
+// replayTarget serves replayed requests with the REST API of a registered cluster. Every replay
+// connects with its own client, so its gRPC calls are traced apart from the live traffic. Replayed
+// changes are refused by the guardrails and the read-only mode like live ones.
+func replayTarget(registry *cluster.Registry, store metadata.Store, auditLog audit.Log, scheduler *maintenance.Scheduler,
+	guardrails api.Guardrails, readOnly bool, clientOptions func(cluster string) []armada.ClientOption, logger *zap.Logger) replay.Target {
+	return func(ctx context.Context, name string, provider trace.TracerProvider) (http.Handler, func() error, error) {
+		c, err := registry.Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(c.Seeds) == 0 {
+			return nil, nil, fmt.Errorf("cluster %q has no seed addresses", name)
+		}
+		client, err := armada.NewClient(c.Seeds[0], logger.Named("replay-client"),
+			append(clientOptions(name), armada.WithTracerProvider(provider))...)
+		if err != nil {
+			return nil, nil, err
+		}
+		r := chi.NewRouter()
+		api.NewHandler(client, logger.Named("replay-api-handler"),
+			api.WithMetadataStore(store),
+			api.WithAuditLog(auditLog),
+			api.WithMaintenance(scheduler, name)).RegisterRoutes(r)
+		var target http.Handler = r
+		if len(guardrails.Policies) > 0 {
+			target = api.EnforceGuardrails(guardrails.ForCluster(name), auditLog, logger.Named("guardrails"))(target)
+		}
+		if readOnly {
+			target = api.ReadOnly(logger.Named("read-only"))(target)
+		}
+		return target, client.Close, nil
+	}
+}
+
+// clusterTags returns the current tags of a registered cluster
+func clusterTags(registry *cluster.Registry, name string) func() cluster.Tags {
+	return func() cluster.Tags {
+		c, err := registry.Get(name)
+		if err != nil {
+			return nil
+		}
+		return c.Tags
+	}
+}
+
+// tagLabels returns the tags of the cluster a scraped server belongs to, addresses returns the
+// known server addresses of every cluster by name
+func tagLabels(registry *cluster.Registry, addresses map[string]func() []string) func(addr string) map[string]string {
+	return func(addr string) map[string]string {
+		for name, known := range addresses {
+			if slices.Contains(known(), addr) {
+				return clusterTags(registry, name)()
+			}
+		}
+		return nil
+	}
+}
+
+// newFrontendHandler serves the frontend from the development server or directory configured for
+// frontend development, the embedded bundle otherwise. It returns nil when running headless.
+func newFrontendHandler(logger *zap.Logger, cfg config.ServerConfig) http.Handler {
+	switch {
+	case cfg.FrontendProxy != "":
+		target, err := url.Parse(cfg.FrontendProxy)
+		if err != nil {
+			logger.Fatal("Invalid frontend proxy", zap.Error(err))
+		}
+		logger.Info("Proxying the frontend to a development server", zap.String("url", cfg.FrontendProxy))
+		// Upgrades are forwarded as well, so hot module replacement keeps working
+		return &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(target)
+			},
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				logger.Warn("Frontend development server unavailable", zap.Error(err))
+				http.Error(w, "Frontend development server unavailable", http.StatusBadGateway)
+			},
+		}
+	case cfg.FrontendDir != "":
+		logger.Info("Serving the frontend from disk", zap.String("dir", cfg.FrontendDir))
+		return spaHandler(os.DirFS(cfg.FrontendDir))
+	case cfg.Headless || !frontend.Embedded:
+		logger.Info("Running headless, the frontend is not served", zap.Bool("embedded", frontend.Embedded))
+		return nil
+	}
+	frontendRoot, err := fs.Sub(frontend.FS, staticDir)
+	if err != nil {
+		logger.Fatal("Failed to get frontend filesystem", zap.Error(err))
+	}
+	return spaHandler(frontendRoot)
+}
+
+// spaHandler serves the frontend from frontendRoot. Unknown paths are answered with
+// index.html so that client-side routes can be deep-linked.
+func spaHandler(frontendRoot fs.FS) http.HandlerFunc {
+	fileServer := http.FileServer(http.FS(frontendRoot))
+
+	return func(w http.ResponseWriter, r *http.Request) {
 		// Try to serve the file directly
 		path := r.URL.Path
 		_, err := fs.Stat(frontendRoot, path[1:]) // Remove leading slash
 
 		// If path doesn't exist, serve index.html for SPA client-side routing
-		if os.IsNotExist(err) {
-			// Rewrite to index.html for client-side routing
-			r.URL.Path = "/"
+		if path == "/" || path == "/index.html" || os.IsNotExist(err) {
+			// Read on every request, a frontend served from disk changes with every build
+			index, err := fs.ReadFile(frontendRoot, "index.html")
+			if err != nil {
+				http.Error(w, "Frontend not found", http.StatusNotFound)
+				return
+			}
+			// The frontend resolves its assets and API calls against the base element, so it
+			// works under the base path and from deep links alike
+			base := `<head>
+    <base href="` + html.EscapeString(basepath.Path(r.Context(), "/")) + `">`
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write(bytes.Replace(index, []byte("<head>"), []byte(base), 1))
+			return
 		}
 
 		fileServer.ServeHTTP(w, r)
-	})
+	}
+}
+
+// loadCertificate loads the HTTPS certificate, it returns nil if HTTPS is disabled
+func loadCertificate(logger *zap.Logger, cfg *config.Config) *reload.Certificate {
+	if cfg.Server.TLSCertFile == "" {
+		return nil
+	}
+	cert, err := reload.LoadCertificate(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+	if err != nil {
+		logger.Fatal("Failed to load TLS certificate", zap.Error(err))
+	}
+	return cert
+}
+
+// serve runs the HTTP server until the process is interrupted and then shuts it down gracefully.
+// upstream describes where the served data comes from. HTTPS is served with cert if it isn't nil.
+func serve(logger *zap.Logger, cfg *config.Config, handler http.Handler, upstream string, cert *reload.Certificate) {
+	port := cfg.Server.Port
 
 	// Setup server with graceful shutdown
 	addr := ":" + port
 	server := &http.Server{
-		Addr:    addr,
-		Handler: r,
+		Addr:              addr,
+		Handler:           basepath.Middleware(cfg.Server.BasePath)(handler),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	if cert != nil {
+		server.TLSConfig = &tls.Config{GetCertificate: cert.GetCertificate}
 	}
 
 	// Create a channel to listen for interrupt signals
@@ -137,10 +1050,21 @@ func main() {
 	// Start the server in a goroutine
 	go func() {
 		logger.Info("Starting Armada Dashboard server", zap.String("port", port))
-		logger.Info("Connecting to Armada server", zap.String("url", armadaURL))
-		logger.Info("Server ready", zap.String("url", "http://localhost"+addr))
+		logger.Info("Serving data from", zap.String("upstream", upstream))
+		scheme := "http"
+		if cert != nil {
+			scheme = "https"
+		}
+		logger.Info("Server ready", zap.String("url", scheme+"://localhost"+addr+basepath.Clean(cfg.Server.BasePath)+"/"))
 
-		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		var err error
+		if cert != nil {
+			// The certificate is served by the TLS config, so it can be reloaded
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Fatal("Server error", zap.Error(err))
 		}
 	}()
@@ -161,3 +1085,43 @@ func main() {
 
 	logger.Info("Server exited successfully")
 }
+
+// newSecretResolver creates the resolver of references to secrets in the configuration. The
+// vault:// and aws-sm:// references fail with a hint if their store isn't configured.
+func newSecretResolver(cfg config.SecretsConfig) *secrets.Resolver {
+	vault := secrets.Provider(secrets.ProviderFunc(func(context.Context, string, string) (string, error) {
+		return "", errors.New("set secrets.vaultAddress (VAULT_ADDR) to resolve vault:// references")
+	}))
+	if cfg.VaultAddress != "" {
+		p := secrets.NewVaultProvider(cfg.VaultAddress, cfg.VaultToken)
+		p.TokenFile = cfg.VaultTokenFile
+		p.Namespace = cfg.VaultNamespace
+		vault = p
+	}
+	return secrets.NewResolver(cfg.CacheTTL,
+		secrets.WithProvider("vault", vault),
+		secrets.WithProvider("aws-sm", secrets.NewAWSProvider(cfg.AWSRegion, nil)))
+}
+
+// newDiscoverer creates the seed discoverer selected by the discovery configuration.
+// It returns nil when only the static Armada URL seed is used.
+func newDiscoverer(cfg config.DiscoveryConfig, outbound *httpclient.Factory) (discovery.Discoverer, error) {
+	switch cfg.Mechanism {
+	case "", "static":
+		return nil, nil
+	case "dns-srv":
+		if cfg.SRVRecord == "" {
+			return nil, errors.New("discovery.srvRecord is required for dns-srv discovery")
+		}
+		return discovery.NewSRVDiscoverer(cfg.SRVRecord, cfg.Scheme), nil
+	case "consul":
+		d := discovery.NewConsulDiscoverer(cfg.Consul.Address, cfg.Consul.Service, cfg.Scheme)
+		d.Tag = cfg.Consul.Tag
+		d.Datacenter = cfg.Consul.Datacenter
+		d.Token = cfg.Consul.Token
+		d.HTTPClient = outbound.Client(d.HTTPClient.Timeout)
+		return d, nil
+	default:
+		return nil, fmt.Errorf("unknown discovery mechanism %q", cfg.Mechanism)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// runHashPassword implements the hash-password command which reads a password from
+// standard input and prints the bcrypt hash to configure as AUTH_PASSWORD_HASH.
+// It returns the exit code.
+func runHashPassword(args []string) int {
+	flags := flag.NewFlagSet("hash-password", flag.ContinueOnError)
+	cost := flags.Int("cost", bcrypt.DefaultCost, "bcrypt cost factor")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		fmt.Fprintf(os.Stderr, "Failed to read password: %v\n", err)
+		return 1
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		fmt.Fprintln(os.Stderr, "Password must not be empty")
+		return 1
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), *cost)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to hash password: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(hash))
+	return 0
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/armadakv/console/backend/api"
+	"github.com/armadakv/console/backend/apiversion"
+	"github.com/armadakv/console/backend/auth"
+	"github.com/armadakv/console/backend/bundle"
+	"github.com/armadakv/console/backend/config"
+	"github.com/armadakv/console/backend/httpclient"
+	"github.com/armadakv/console/backend/metadata"
+	"github.com/armadakv/console/backend/panics"
+	"github.com/armadakv/console/backend/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// runDump implements the dump command which captures the state of a running console
+// into a bundle that can be served offline with --snapshot. It returns the exit code.
+func runDump(args []string) int {
+	flags := flag.NewFlagSet("dump", flag.ContinueOnError)
+	consoleURL := flags.String("url", "http://localhost:8080", "URL of the console to capture")
+	output := flags.String("o", "console-snapshot.tar.gz", "file the bundle is written to")
+	metricsRange := flags.Duration("range", time.Hour, "time range of captured metrics, 0 disables range queries")
+	metricsStep := flags.Duration("step", time.Minute, "resolution of captured metrics")
+	timeout := flags.Duration("timeout", 2*time.Minute, "timeout of the whole capture")
+	var queries []string
+	flags.Func("query", "PromQL query to capture, may be repeated; "+bundle.NodePlaceholder+" is replaced with every server ID (default: the queries of the UI)", func(q string) error {
+		queries = append(queries, q)
+		return nil
+	})
+	header := http.Header{}
+	flags.Func("header", `header added to every request, e.g. "Authorization: Bearer <token>"; may be repeated`, func(h string) error {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("expected name: value, got %q", h)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		return nil
+	})
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if len(queries) == 0 {
+		queries = bundle.DefaultMetricsQueries
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create bundle: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	w := bundle.NewWriter(f, *consoleURL)
+	err = bundle.Dump(ctx, http.DefaultClient, *consoleURL, w, bundle.DumpOptions{
+		MetricsQueries: queries,
+		MetricsRange:   *metricsRange,
+		MetricsStep:    *metricsStep,
+		Header:         header,
+	})
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to capture console state: %v\n", err)
+		_ = os.Remove(*output)
+		return 1
+	}
+
+	fmt.Printf("Console state written to %s\n", *output)
+	return 0
+}
+
+// serveSnapshot serves the console read-only from a bundle instead of a live cluster
+func serveSnapshot(logger *zap.Logger, cfg *config.Config, outbound *httpclient.Factory, file string, frontendHandler http.Handler, reporter panics.Reporter) {
+	b, err := bundle.Open(file)
+	if err != nil {
+		logger.Fatal("Failed to open snapshot bundle", zap.Error(err), zap.String("file", file))
+	}
+	manifest := b.Manifest()
+	logger.Info("Serving read-only snapshot",
+		zap.String("source", manifest.Source),
+		zap.Time("createdAt", manifest.CreatedAt))
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(apiversion.Middleware)
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Logger)
+	trustedProxies, err := auth.ParseTrustedProxies(cfg.Server.TrustedProxies)
+	if err != nil {
+		logger.Fatal("Invalid trusted proxies", zap.Error(err))
+	}
+	r.Use(auth.ClientAddresses(trustedProxies))
+	r.Use(panics.Recoverer(reporter))
+	guard := auth.NewGuard(cfg.Auth.LoginMaxFailures, cfg.Auth.LoginMaxFailuresPerAddress, cfg.Auth.LoginLockout, cfg.Auth.LoginMaxLockout)
+	sessions := newSessionStore(cfg, metadata.NewMemoryStore())
+	oidc := useAuthentication(logger, r, cfg.Auth, outbound, nil, guard, sessions, nil, api.LivenessPath, api.ReadinessPath)
+	// The bundle can't be changed anyway, the roles are enforced so the frontend shows them
+	roles, err := roleMapping(cfg.Auth, nil, nil)
+	if err != nil {
+		logger.Fatal("Invalid operators", zap.Error(err))
+	}
+	r.Use(api.Authorize(roles, nil, logger))
+	r.Use(api.ReadOnly(logger.Named("read-only")))
+	auth.NewHandler(oidc, sessions).RegisterRoutes(r)
+	// A snapshot doesn't depend on a cluster, it is ready as soon as the bundle is open
+	api.NewHealthHandler(logger.Named("health-handler")).RegisterRoutes(r)
+	// The snapshot is shown with the branding of the console serving it, not the captured one
+	api.NewBrandingHandler(cfg.Branding, logger.Named("branding-handler")).RegisterRoutes(r)
+	r.Mount("/api", b)
+	if frontendHandler != nil {
+		r.Get("/*", frontendHandler.ServeHTTP)
+	}
+
+	serve(logger, cfg, r, "snapshot "+file, loadCertificate(logger, cfg))
+}